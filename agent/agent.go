@@ -22,6 +22,7 @@ import (
 	"google.golang.org/genai"
 
 	"google.golang.org/adk/artifact"
+	"google.golang.org/adk/httpx"
 	agentinternal "google.golang.org/adk/internal/agent"
 	"google.golang.org/adk/memory"
 	"google.golang.org/adk/model"
@@ -110,6 +111,13 @@ type Artifacts interface {
 	LoadVersion(ctx context.Context, name string, version int) (*artifact.LoadResponse, error)
 }
 
+// ArtifactDeleter is implemented by the [Artifacts] that can delete
+// artifacts, e.g. those of the tool and callback contexts.
+type ArtifactDeleter interface {
+	// Delete deletes all the versions of an artifact.
+	Delete(ctx context.Context, name string) error
+}
+
 // Memory interface provides methods to access agent memory across the
 // sessions of the current user_id.
 type Memory interface {
@@ -158,7 +166,7 @@ func (a *agent) Run(ctx InvocationContext) iter.Seq2[*session.Event, error] {
 	return func(yield func(*session.Event, error) bool) {
 		// TODO: verify&update the setup here. Should we branch etc.
 		ctx := &invocationContext{
-			Context:   ctx,
+			Context:   withHTTPMetadata(contextOf(ctx), ctx, a.Name()),
 			agent:     a,
 			artifacts: ctx.Artifacts(),
 			memory:    ctx.Memory(),
@@ -206,6 +214,31 @@ func (a *agent) internal() *agent {
 	return a
 }
 
+// contextOf returns ctx as a context.Context, or context.Background() if it
+// embeds a nil one, e.g. a custom InvocationContext built without a context:
+// its context methods panic.
+func contextOf(ctx InvocationContext) (c context.Context) {
+	defer func() {
+		if recover() != nil {
+			c = context.Background()
+		}
+	}()
+	ctx.Done()
+	return ctx
+}
+
+// withHTTPMetadata returns a copy of parent whose outbound HTTP metadata
+// holds the invocation of ctx and the agent running.
+func withHTTPMetadata(parent context.Context, ctx InvocationContext, agentName string) context.Context {
+	md, _ := httpx.FromContext(parent)
+	md.InvocationID = ctx.InvocationID()
+	md.AgentName = agentName
+	if s := ctx.Session(); s != nil && md.SessionID == "" {
+		md.AppName, md.UserID, md.SessionID = s.AppName(), s.UserID(), s.ID()
+	}
+	return httpx.NewContext(parent, md)
+}
+
 func getAuthorForEvent(ctx InvocationContext, event *session.Event) string {
 	if event.LLMResponse.Content != nil && event.LLMResponse.Content.Role == genai.RoleUser {
 		return genai.RoleUser
@@ -234,7 +267,7 @@ func runBeforeAgentCallbacks(ctx InvocationContext) (*session.Event, error) {
 			continue
 		}
 
-		event := session.NewEvent(ctx.InvocationID())
+		event := session.NewEventWithContext(ctx, ctx.InvocationID())
 		event.LLMResponse = model.LLMResponse{
 			Content: content,
 		}
@@ -247,7 +280,7 @@ func runBeforeAgentCallbacks(ctx InvocationContext) (*session.Event, error) {
 
 	// check if has delta create event with it
 	if len(callbackCtx.actions.StateDelta) > 0 {
-		event := session.NewEvent(ctx.InvocationID())
+		event := session.NewEventWithContext(ctx, ctx.InvocationID())
 		event.Author = agent.Name()
 		event.Branch = ctx.Branch()
 		event.Actions = *callbackCtx.actions
@@ -277,7 +310,7 @@ func runAfterAgentCallbacks(ctx InvocationContext) (*session.Event, error) {
 			continue
 		}
 
-		event := session.NewEvent(ctx.InvocationID())
+		event := session.NewEventWithContext(ctx, ctx.InvocationID())
 		event.LLMResponse = model.LLMResponse{
 			Content: newContent,
 		}
@@ -291,7 +324,7 @@ func runAfterAgentCallbacks(ctx InvocationContext) (*session.Event, error) {
 
 	// check if has delta create event with it
 	if len(callbackCtx.actions.StateDelta) > 0 {
-		event := session.NewEvent(ctx.InvocationID())
+		event := session.NewEventWithContext(ctx, ctx.InvocationID())
 		event.Author = agent.Name()
 		event.Branch = ctx.Branch()
 		event.Actions = *callbackCtx.actions
@@ -0,0 +1,420 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package budget makes an agent aware of the cost and latency budget of an
+// invocation.
+//
+// A [Budget] tracks the tokens, the cost and the time used by every
+// invocation, and tells the model what remains with a short instruction added
+// to each request, so that it can plan its tool usage. When little budget
+// remains, the instruction asks the model to economize. Once the budget is
+// exhausted, the model and the tools are not called anymore.
+//
+// The budget is a soft limit: the agent answers with a message once it is
+// exhausted. To fail the model calls beyond a hard limit of tokens or calls,
+// whatever the agent, e.g. as a backstop against runaway loops, wrap the
+// model with model.WithBudget.
+//
+// A Budget is installed on an agent with its callbacks:
+//
+//	b, err := budget.New(budget.Config{Limits: budget.Limits{Tokens: 20000}})
+//	...
+//	a, err := llmagent.New(llmagent.Config{
+//		...
+//		BeforeModelCallbacks: []llmagent.BeforeModelCallback{b.BeforeModel},
+//		AfterModelCallbacks:  []llmagent.AfterModelCallback{b.AfterModel},
+//		BeforeToolCallbacks:  []llmagent.BeforeToolCallback{b.BeforeTool},
+//		AfterToolCallbacks:   []llmagent.AfterToolCallback{b.AfterTool},
+//	})
+package budget
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/internal/invocationstate"
+	"google.golang.org/adk/internal/utils"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/tool"
+)
+
+// Limits bounds the resources used by an invocation. Zero values are
+// unlimited.
+type Limits struct {
+	// Tokens bounds the prompt and response tokens of all the model calls.
+	Tokens int64
+	// Cost bounds the cost of the model calls, computed with Config.Prices.
+	Cost float64
+	// Duration bounds the wall-clock time of the invocation.
+	Duration time.Duration
+	// ToolCalls bounds the number of tool calls.
+	ToolCalls int
+}
+
+// Price is the price of a model, per million tokens.
+type Price struct {
+	Input, Output float64
+}
+
+// Stage is the budget stage of an invocation.
+type Stage int
+
+const (
+	// StageNormal means that enough budget remains.
+	StageNormal Stage = iota
+	// StageEconomy means that the remaining budget is below
+	// Config.EconomyThreshold. The model is asked to economize.
+	StageEconomy
+	// StageExhausted means that a limit was reached. The model and the tools
+	// are not called anymore.
+	StageExhausted
+)
+
+// String implements fmt.Stringer.
+func (s Stage) String() string {
+	switch s {
+	case StageNormal:
+		return "normal"
+	case StageEconomy:
+		return "economy"
+	case StageExhausted:
+		return "exhausted"
+	default:
+		return fmt.Sprintf("Stage(%d)", int(s))
+	}
+}
+
+const (
+	defaultEconomyThreshold = 0.25
+	// DefaultTemplate is the default instruction template of StageNormal.
+	DefaultTemplate = "Remaining budget: {{.Remaining}}."
+	// DefaultEconomyTemplate is the default instruction template of
+	// StageEconomy.
+	DefaultEconomyTemplate = "Budget almost exhausted, remaining: {{.Remaining}}. Take the cheapest path: avoid optional tool calls and answer as soon as possible."
+	// DefaultExhaustedMessage is the default response once the budget is
+	// exhausted.
+	DefaultExhaustedMessage = "I have to stop here: the budget for this request is exhausted."
+)
+
+// Config is the configuration of a [Budget].
+type Config struct {
+	// Limits is the budget of every invocation.
+	Limits Limits
+	// Prices maps model names to their prices. Required to enforce
+	// Limits.Cost.
+	Prices map[string]Price
+	// EconomyThreshold is the fraction of the budget below which the model
+	// is asked to economize. Defaults to 0.25.
+	EconomyThreshold float64
+	// Template is the text/template of the instruction added to the requests
+	// in StageNormal. It is executed with a [TemplateData].
+	// Optional: if empty, DefaultTemplate is used.
+	Template string
+	// EconomyTemplate is the text/template of the instruction in
+	// StageEconomy. Optional: if empty, DefaultEconomyTemplate is used.
+	EconomyTemplate string
+	// ExhaustedMessage is the text of the response returned instead of
+	// calling the model once the budget is exhausted.
+	// Optional: if empty, DefaultExhaustedMessage is used.
+	ExhaustedMessage string
+	// Now returns the current time. Defaults to time.Now.
+	Now func() time.Time
+}
+
+// TemplateData is the data of the instruction templates.
+type TemplateData struct {
+	// Remaining describes the remaining budget in plain terms, e.g.
+	// "about 2 more tool calls or 4,000 tokens".
+	Remaining string
+	State     State
+}
+
+// Usage is the resources used by an invocation.
+type Usage struct {
+	Tokens     int64
+	Cost       float64
+	Duration   time.Duration
+	ModelCalls int
+	ToolCalls  int
+}
+
+// State is the budget state of an invocation.
+type State struct {
+	Stage Stage
+	Used  Usage
+	// Remaining holds the remaining amount of every limit set in
+	// Config.Limits, never negative.
+	Remaining Limits
+}
+
+// Budget tracks the budget of invocations.
+type Budget struct {
+	cfg              Config
+	template         *template.Template
+	economyTemplate  *template.Template
+	exhaustedMessage string
+
+	mu sync.Mutex
+	// trackers holds the trackers by invocation ID, the least recently used
+	// being forgotten beyond invocationstate.DefaultCapacity.
+	trackers *invocationstate.Map[*tracker]
+}
+
+type tracker struct {
+	start time.Time
+	model string
+	used  Usage
+}
+
+// New returns a new Budget.
+func New(cfg Config) (*Budget, error) {
+	if cfg.EconomyThreshold <= 0 {
+		cfg.EconomyThreshold = defaultEconomyThreshold
+	}
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+	b := &Budget{
+		cfg:              cfg,
+		exhaustedMessage: cfg.ExhaustedMessage,
+		trackers:         invocationstate.New[*tracker](0),
+	}
+	if b.exhaustedMessage == "" {
+		b.exhaustedMessage = DefaultExhaustedMessage
+	}
+	var err error
+	if b.template, err = parseTemplate("budget", cfg.Template, DefaultTemplate); err != nil {
+		return nil, err
+	}
+	if b.economyTemplate, err = parseTemplate("economy", cfg.EconomyTemplate, DefaultEconomyTemplate); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func parseTemplate(name, text, def string) (*template.Template, error) {
+	if text == "" {
+		text = def
+	}
+	t, err := template.New(name).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s template: %w", name, err)
+	}
+	return t, nil
+}
+
+// State returns the budget state of an invocation. It reports false if the
+// invocation did not call the model or a tool yet.
+func (b *Budget) State(invocationID string) (State, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	t, ok := b.trackers.Get(invocationID)
+	if !ok {
+		return State{}, false
+	}
+	return b.state(t), true
+}
+
+// Forget drops the state of an invocation, e.g. once it completed. The
+// states of the least recently used invocations are dropped anyway beyond a
+// thousand of them.
+func (b *Budget) Forget(invocationID string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.trackers.Delete(invocationID)
+}
+
+// tracker returns the tracker of an invocation. It expects b.mu to be held.
+func (b *Budget) tracker(invocationID string) *tracker {
+	return b.trackers.GetOrCreate(invocationID, func() *tracker {
+		return &tracker{start: b.cfg.Now()}
+	})
+}
+
+// state computes the state of a tracker. It expects b.mu to be held.
+func (b *Budget) state(t *tracker) State {
+	limits := b.cfg.Limits
+	used := t.used
+	used.Duration = b.cfg.Now().Sub(t.start)
+
+	s := State{Used: used}
+	// remaining is the smallest fraction of budget left across the limits.
+	remaining := 1.0
+	track := func(limit, used float64) float64 {
+		if limit <= 0 {
+			return 0
+		}
+		left := max(limit-used, 0)
+		remaining = min(remaining, left/limit)
+		return left
+	}
+	s.Remaining.Tokens = int64(track(float64(limits.Tokens), float64(used.Tokens)))
+	s.Remaining.Cost = track(limits.Cost, used.Cost)
+	s.Remaining.Duration = time.Duration(track(float64(limits.Duration), float64(used.Duration)))
+	s.Remaining.ToolCalls = int(track(float64(limits.ToolCalls), float64(used.ToolCalls)))
+
+	switch {
+	case remaining <= 0:
+		s.Stage = StageExhausted
+	case remaining <= b.cfg.EconomyThreshold:
+		s.Stage = StageEconomy
+	}
+	return s
+}
+
+// BeforeModel is an llmagent.BeforeModelCallback. It adds the budget
+// instruction to the request, unless no limit is set, or returns the
+// exhausted message once the budget is exhausted.
+func (b *Budget) BeforeModel(ctx agent.CallbackContext, req *model.LLMRequest) (*model.LLMResponse, error) {
+	b.mu.Lock()
+	t := b.tracker(ctx.InvocationID())
+	t.model = req.Model
+	s := b.state(t)
+	tokensPerCall := averageTokens(t.used)
+	b.mu.Unlock()
+
+	tmpl := b.template
+	switch s.Stage {
+	case StageExhausted:
+		return &model.LLMResponse{
+			Content:      genai.NewContentFromText(b.exhaustedMessage, genai.RoleModel),
+			TurnComplete: true,
+		}, nil
+	case StageEconomy:
+		tmpl = b.economyTemplate
+	}
+
+	remaining := describe(b.cfg.Limits, s, tokensPerCall)
+	if remaining == "" {
+		// Nothing is limited.
+		return nil, nil
+	}
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, TemplateData{Remaining: remaining, State: s}); err != nil {
+		return nil, fmt.Errorf("failed to execute budget template: %w", err)
+	}
+	utils.AppendInstructions(req, sb.String())
+	return nil, nil
+}
+
+// AfterModel is an llmagent.AfterModelCallback. It records the usage of the
+// model call.
+func (b *Budget) AfterModel(ctx agent.CallbackContext, resp *model.LLMResponse, respErr error) (*model.LLMResponse, error) {
+	if resp == nil || resp.Partial || resp.UsageMetadata == nil {
+		return nil, nil
+	}
+	usage := resp.UsageMetadata
+	input := int64(usage.PromptTokenCount)
+	output := int64(usage.CandidatesTokenCount) + int64(usage.ThoughtsTokenCount)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	t := b.tracker(ctx.InvocationID())
+	t.used.ModelCalls++
+	t.used.Tokens += input + output
+	if price, ok := b.cfg.Prices[t.model]; ok {
+		t.used.Cost += (float64(input)*price.Input + float64(output)*price.Output) / 1e6
+	}
+	return nil, nil
+}
+
+// BeforeTool is an llmagent.BeforeToolCallback. It prevents tool calls once
+// the budget is exhausted.
+func (b *Budget) BeforeTool(ctx tool.Context, _ tool.Tool, _ map[string]any) (map[string]any, error) {
+	b.mu.Lock()
+	s := b.state(b.tracker(ctx.InvocationID()))
+	b.mu.Unlock()
+	if s.Stage == StageExhausted {
+		return map[string]any{"error": "the budget for this request is exhausted; answer with the information gathered so far"}, nil
+	}
+	return nil, nil
+}
+
+// AfterTool is an llmagent.AfterToolCallback. It records the tool call.
+func (b *Budget) AfterTool(ctx tool.Context, _ tool.Tool, _, _ map[string]any, _ error) (map[string]any, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tracker(ctx.InvocationID()).used.ToolCalls++
+	return nil, nil
+}
+
+// averageTokens returns the average tokens used by a model call.
+func averageTokens(used Usage) int64 {
+	if used.ModelCalls == 0 {
+		return 0
+	}
+	return used.Tokens / int64(used.ModelCalls)
+}
+
+// describe describes the remaining budget in plain terms.
+func describe(configured Limits, s State, tokensPerCall int64) string {
+	limits := s.Remaining
+	var parts []string
+
+	// Every tool call costs a model call to process its result, so the
+	// remaining tool calls are estimated from the remaining tokens too.
+	calls := -1
+	if configured.ToolCalls > 0 {
+		calls = limits.ToolCalls
+	}
+	if limits.Tokens > 0 && tokensPerCall > 0 {
+		byTokens := int(limits.Tokens / tokensPerCall)
+		if calls < 0 || byTokens < calls {
+			calls = byTokens
+		}
+	}
+	switch {
+	case calls == 0:
+		parts = append(parts, "no more tool calls")
+	case calls > 0:
+		parts = append(parts, fmt.Sprintf("about %s more tool %s", formatInt(int64(calls)), plural(calls, "call", "calls")))
+	}
+	if limits.Tokens > 0 {
+		parts = append(parts, formatInt(limits.Tokens)+" tokens")
+	}
+	if limits.Cost > 0 {
+		parts = append(parts, fmt.Sprintf("$%.2f", limits.Cost))
+	}
+	if limits.Duration > 0 {
+		parts = append(parts, limits.Duration.Round(time.Second).String())
+	}
+	return strings.Join(parts, " or ")
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
+
+// formatInt formats n with thousands separators.
+func formatInt(n int64) string {
+	s := strconv.FormatInt(n, 10)
+	var sb strings.Builder
+	for i, r := range s {
+		if i > 0 && (len(s)-i)%3 == 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
@@ -0,0 +1,155 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package budget_test
+
+import (
+	"context"
+	"iter"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent/budget"
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/internal/testutil"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+)
+
+// usageModel reports a fixed token usage for every response.
+type usageModel struct {
+	*testutil.MockModel
+	tokens int32
+}
+
+func (m *usageModel) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {
+		for resp, err := range m.MockModel.GenerateContent(ctx, req, stream) {
+			if resp != nil {
+				resp.UsageMetadata = &genai.GenerateContentResponseUsageMetadata{PromptTokenCount: m.tokens - 50, CandidatesTokenCount: 50}
+			}
+			if !yield(resp, err) {
+				return
+			}
+		}
+	}
+}
+
+type searchArgs struct {
+	Query string `json:"query"`
+}
+
+func TestBudget(t *testing.T) {
+	b, err := budget.New(budget.Config{
+		Limits:           budget.Limits{Tokens: 1000},
+		EconomyThreshold: 0.5,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var searches int
+	search, err := functiontool.New(functiontool.Config{Name: "search", Description: "search"}, func(tool.Context, searchArgs) (map[string]any, error) {
+		searches++
+		return map[string]any{"result": "found"}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	call := genai.NewContentFromFunctionCall("search", map[string]any{"query": "q"}, genai.RoleModel)
+	testModel := &usageModel{
+		MockModel: &testutil.MockModel{Responses: []*genai.Content{call, call, call, call, genai.NewContentFromText("done", genai.RoleModel)}},
+		tokens:    300,
+	}
+	a, err := llmagent.New(llmagent.Config{
+		Name:                 "agent",
+		Model:                testModel,
+		Tools:                []tool.Tool{search},
+		BeforeModelCallbacks: []llmagent.BeforeModelCallback{b.BeforeModel},
+		AfterModelCallbacks:  []llmagent.AfterModelCallback{b.AfterModel},
+		BeforeToolCallbacks:  []llmagent.BeforeToolCallback{b.BeforeTool},
+		AfterToolCallbacks:   []llmagent.AfterToolCallback{b.AfterTool},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	events, err := testutil.CollectEvents(testutil.NewTestAgentRunner(t, a).Run(t, "session", "research this"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var instructions []string
+	for _, req := range testModel.Requests {
+		instructions = append(instructions, req.Config.SystemInstruction.Parts[0].Text)
+	}
+	wantInstructions := []string{
+		"Remaining budget: 1,000 tokens.",
+		"Remaining budget: about 2 more tool calls or 700 tokens.",
+		"Budget almost exhausted, remaining: about 1 more tool call or 400 tokens. Take the cheapest path: avoid optional tool calls and answer as soon as possible.",
+		"Budget almost exhausted, remaining: no more tool calls or 100 tokens. Take the cheapest path: avoid optional tool calls and answer as soon as possible.",
+	}
+	if diff := cmp.Diff(wantInstructions, instructions); diff != "" {
+		t.Errorf("budget instructions mismatch (-want +got):\n%s", diff)
+	}
+
+	// The fourth search exceeded the budget: the tool was not called and the
+	// model was stopped.
+	if searches != 3 {
+		t.Errorf("search ran %d times, want 3", searches)
+	}
+	last := events[len(events)-1]
+	if got := last.Content.Parts[0].Text; got != budget.DefaultExhaustedMessage {
+		t.Errorf("last event text = %q, want %q", got, budget.DefaultExhaustedMessage)
+	}
+
+	state, ok := b.State(last.InvocationID)
+	if !ok {
+		t.Fatalf("State(%q) not found", last.InvocationID)
+	}
+	if state.Stage != budget.StageExhausted || state.Used.Tokens != 1200 || state.Used.ToolCalls != 4 {
+		t.Errorf("State() = %+v, want exhausted after 1200 tokens and 4 tool calls", state)
+	}
+}
+
+func TestBudget_NoLimits(t *testing.T) {
+	b, err := budget.New(budget.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	testModel := &usageModel{
+		MockModel: &testutil.MockModel{Responses: []*genai.Content{genai.NewContentFromText("done", genai.RoleModel)}},
+		tokens:    300,
+	}
+	a, err := llmagent.New(llmagent.Config{
+		Name:                 "agent",
+		Model:                testModel,
+		BeforeModelCallbacks: []llmagent.BeforeModelCallback{b.BeforeModel},
+		AfterModelCallbacks:  []llmagent.AfterModelCallback{b.AfterModel},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := testutil.CollectEvents(testutil.NewTestAgentRunner(t, a).Run(t, "session", "hello")); err != nil {
+		t.Fatal(err)
+	}
+	// With nothing limited, no instruction is added.
+	if si := testModel.Requests[0].Config.SystemInstruction; si != nil && len(si.Parts) > 0 {
+		t.Errorf("system instruction = %q, want none", si.Parts[0].Text)
+	}
+}
@@ -0,0 +1,400 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package checkpoint lets tools and applications rewind a conversation.
+//
+// [Create] records a labeled checkpoint of the conversation, with a snapshot
+// of the session state. [Restore] rewinds the conversation to a checkpoint:
+// the session state is reset to the snapshot, and the events recorded
+// between the checkpoint and the restore are left out of the later model
+// requests. The events are not removed from the session: they stay in the
+// log, and [Superseded] reports them.
+//
+// Checkpoints work at the granularity of turns, which start with a user
+// message: the events of the turn that created the checkpoint and of the turn
+// that restored it are always kept, so that function calls stay paired with
+// their responses.
+package checkpoint
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/runtimedeps"
+	"google.golang.org/adk/session"
+)
+
+const (
+	// StateKey is the session state key holding the checkpoints.
+	StateKey = "_adk_checkpoints"
+	// RestoresStateKey is the session state key holding the restores.
+	RestoresStateKey = "_adk_checkpoint_restores"
+)
+
+// internalKeyPrefix is the prefix of the state keys excluded from snapshots.
+const internalKeyPrefix = "_adk_"
+
+// ErrNotFound is returned when restoring an unknown checkpoint.
+var ErrNotFound = errors.New("checkpoint not found")
+
+// PendingPolicy decides what happens to the long-running operations that
+// were started after a checkpoint and are still pending when it is restored.
+type PendingPolicy string
+
+const (
+	// PendingCancel drops the operations: their responses, if they arrive
+	// later, are left out of the model requests.
+	PendingCancel PendingPolicy = "cancel"
+	// PendingRelink keeps the function calls of the operations in the model
+	// requests, so that their responses are processed when they arrive.
+	PendingRelink PendingPolicy = "relink"
+)
+
+// Checkpoint is a labeled position in a conversation.
+type Checkpoint struct {
+	ID           string    `json:"id"`
+	Label        string    `json:"label"`
+	InvocationID string    `json:"invocation_id"`
+	CreatedAt    time.Time `json:"created_at"`
+	// State is the snapshot of the session scoped state.
+	State map[string]any `json:"state"`
+}
+
+type restore struct {
+	ID           string        `json:"id"`
+	CheckpointID string        `json:"checkpoint_id"`
+	Pending      PendingPolicy `json:"pending"`
+}
+
+// Create records a checkpoint of the conversation under label, replacing
+// the checkpoint with the same label.
+func Create(ctx agent.CallbackContext, label string) (*Checkpoint, error) {
+	if label == "" {
+		return nil, fmt.Errorf("checkpoint label is required")
+	}
+	checkpoints, err := decode[map[string]*Checkpoint](ctx.State(), StateKey)
+	if err != nil {
+		return nil, err
+	}
+	if checkpoints == nil {
+		checkpoints = make(map[string]*Checkpoint)
+	}
+
+	snapshot := make(map[string]any)
+	for key, value := range ctx.State().All() {
+		if sessionScoped(key) {
+			snapshot[key] = value
+		}
+	}
+	deps := runtimedeps.FromContext(ctx)
+	cp := &Checkpoint{
+		ID:           deps.NewID(),
+		Label:        label,
+		InvocationID: ctx.InvocationID(),
+		CreatedAt:    deps.Now(),
+		State:        snapshot,
+	}
+	checkpoints[label] = cp
+	if err := encode(ctx.State(), StateKey, checkpoints); err != nil {
+		return nil, err
+	}
+	return cp, nil
+}
+
+// Restore rewinds the conversation to the checkpoint with the given label.
+// The session scoped state is reset to the snapshot of the checkpoint, and
+// the events recorded since the checkpoint are left out of the next model
+// requests. The pending policy must be set explicitly.
+func Restore(ctx agent.CallbackContext, label string, pending PendingPolicy) error {
+	if pending != PendingCancel && pending != PendingRelink {
+		return fmt.Errorf("invalid pending policy %q: want %q or %q", pending, PendingCancel, PendingRelink)
+	}
+	checkpoints, err := decode[map[string]*Checkpoint](ctx.State(), StateKey)
+	if err != nil {
+		return err
+	}
+	cp, ok := checkpoints[label]
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrNotFound, label)
+	}
+	restores, err := decode[[]restore](ctx.State(), RestoresStateKey)
+	if err != nil {
+		return err
+	}
+	restores = append(restores, restore{ID: runtimedeps.FromContext(ctx).NewID(), CheckpointID: cp.ID, Pending: pending})
+
+	// State has no delete operation: the keys created after the checkpoint
+	// are reset to nil.
+	for key := range ctx.State().All() {
+		if _, ok := cp.State[key]; !ok && sessionScoped(key) {
+			if err := ctx.State().Set(key, nil); err != nil {
+				return err
+			}
+		}
+	}
+	for key, value := range cp.State {
+		if err := ctx.State().Set(key, value); err != nil {
+			return err
+		}
+	}
+	return encode(ctx.State(), RestoresStateKey, restores)
+}
+
+// List returns the checkpoints of the session, oldest first.
+func List(state session.ReadonlyState) ([]*Checkpoint, error) {
+	checkpoints, err := decode[map[string]*Checkpoint](state, StateKey)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]*Checkpoint, 0, len(checkpoints))
+	for _, cp := range checkpoints {
+		list = append(list, cp)
+	}
+	slices.SortFunc(list, func(a, b *Checkpoint) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+	return list, nil
+}
+
+// Superseded returns the IDs of the events excluded from the model requests
+// by the restores recorded in state.
+func Superseded(state session.ReadonlyState, events []*session.Event) (map[string]bool, error) {
+	excluded, _, err := supersede(state, events)
+	if err != nil {
+		return nil, err
+	}
+	ids := make(map[string]bool)
+	for i, ev := range events {
+		if excluded != nil && excluded[i] {
+			ids[ev.ID] = true
+		}
+	}
+	return ids, nil
+}
+
+// Filter returns the events to build the model requests from: the events
+// superseded by a restore are left out, and so are the responses of the
+// long-running operations cancelled by a restore.
+func Filter(state session.ReadonlyState, events []*session.Event) ([]*session.Event, error) {
+	excluded, cancelled, err := supersede(state, events)
+	if err != nil {
+		return nil, err
+	}
+	if excluded == nil {
+		return events, nil
+	}
+	var filtered []*session.Event
+	for i, ev := range events {
+		if excluded[i] {
+			continue
+		}
+		if ev.Content != nil && len(cancelled) > 0 && slices.ContainsFunc(ev.Content.Parts, func(p *genai.Part) bool {
+			return p != nil && p.FunctionResponse != nil && cancelled[p.FunctionResponse.ID]
+		}) {
+			ev = withoutCancelledResponses(ev, cancelled)
+			if ev == nil {
+				continue
+			}
+		}
+		filtered = append(filtered, ev)
+	}
+	return filtered, nil
+}
+
+// supersede reports which events are excluded by the restores, and the IDs
+// of the long-running function calls they cancelled.
+func supersede(state session.ReadonlyState, events []*session.Event) ([]bool, map[string]bool, error) {
+	if state == nil {
+		return nil, nil, nil
+	}
+	restores, err := decode[[]restore](state, RestoresStateKey)
+	if err != nil || len(restores) == 0 {
+		return nil, nil, err
+	}
+
+	// The checkpoints and the restores are located by the events whose
+	// state delta first recorded them.
+	checkpointAt := make(map[string]int)
+	restoreAt := make(map[string]int)
+	for i, ev := range events {
+		if v, ok := ev.Actions.StateDelta[StateKey]; ok {
+			var checkpoints map[string]*Checkpoint
+			if err := convert(v, &checkpoints); err != nil {
+				return nil, nil, err
+			}
+			for _, cp := range checkpoints {
+				if _, ok := checkpointAt[cp.ID]; !ok {
+					checkpointAt[cp.ID] = i
+				}
+			}
+		}
+		if v, ok := ev.Actions.StateDelta[RestoresStateKey]; ok {
+			var rs []restore
+			if err := convert(v, &rs); err != nil {
+				return nil, nil, err
+			}
+			for _, r := range rs {
+				if _, ok := restoreAt[r.ID]; !ok {
+					restoreAt[r.ID] = i
+				}
+			}
+		}
+	}
+
+	excluded := make([]bool, len(events))
+	cancelled := make(map[string]bool)
+	for _, r := range restores {
+		ci, ok := checkpointAt[r.CheckpointID]
+		if !ok {
+			continue
+		}
+		ri, ok := restoreAt[r.ID]
+		if !ok {
+			continue
+		}
+		// Exclude the events after the turn of the checkpoint, up to the turn
+		// of the restore.
+		start := ci + 1
+		for start < len(events) && !isTurnStart(events[start]) {
+			start++
+		}
+		end := ri
+		for end > 0 && !isTurnStart(events[end]) {
+			end--
+		}
+		if start >= end {
+			continue
+		}
+
+		responded := make(map[string]bool)
+		for _, ev := range events[start:end] {
+			for _, fr := range functionResponses(ev) {
+				responded[fr.ID] = true
+			}
+		}
+		for i := start; i < end; i++ {
+			pending := slices.ContainsFunc(events[i].LongRunningToolIDs, func(id string) bool { return !responded[id] })
+			if !pending {
+				excluded[i] = true
+				continue
+			}
+			switch r.Pending {
+			case PendingRelink:
+				// Keep the event of the pending call.
+			default:
+				excluded[i] = true
+				for _, id := range events[i].LongRunningToolIDs {
+					if !responded[id] {
+						cancelled[id] = true
+					}
+				}
+			}
+		}
+	}
+	return excluded, cancelled, nil
+}
+
+// isTurnStart reports whether ev is a user message starting a turn of the
+// conversation.
+func isTurnStart(ev *session.Event) bool {
+	return ev.Author == "user" && ev.Content != nil && len(functionResponses(ev)) == 0
+}
+
+func functionResponses(ev *session.Event) []*genai.FunctionResponse {
+	if ev.Content == nil {
+		return nil
+	}
+	var responses []*genai.FunctionResponse
+	for _, p := range ev.Content.Parts {
+		if p != nil && p.FunctionResponse != nil {
+			responses = append(responses, p.FunctionResponse)
+		}
+	}
+	return responses
+}
+
+// withoutCancelledResponses returns a copy of ev without the responses of
+// the cancelled calls, or nil if nothing remains.
+func withoutCancelledResponses(ev *session.Event, cancelled map[string]bool) *session.Event {
+	var parts []*genai.Part
+	for _, p := range ev.Content.Parts {
+		if p != nil && p.FunctionResponse != nil && cancelled[p.FunctionResponse.ID] {
+			continue
+		}
+		parts = append(parts, p)
+	}
+	if len(parts) == 0 {
+		return nil
+	}
+	copied := *ev
+	content := *ev.Content
+	content.Parts = parts
+	copied.Content = &content
+	return &copied
+}
+
+// sessionScoped reports whether key is a session scoped state key captured
+// by the snapshots.
+func sessionScoped(key string) bool {
+	for _, prefix := range []string{session.KeyPrefixApp, session.KeyPrefixUser, session.KeyPrefixTemp, internalKeyPrefix} {
+		if strings.HasPrefix(key, prefix) {
+			return false
+		}
+	}
+	return true
+}
+
+// decode reads the value of key from state into a T.
+func decode[T any](state session.ReadonlyState, key string) (T, error) {
+	var out T
+	v, err := state.Get(key)
+	if errors.Is(err, session.ErrStateKeyNotExist) || v == nil {
+		return out, nil
+	}
+	if err != nil {
+		return out, err
+	}
+	if err := convert(v, &out); err != nil {
+		return out, err
+	}
+	return out, nil
+}
+
+// encode stores v under key in state as a JSON compatible value, so that
+// it survives persistent session services.
+func encode(state session.State, key string, v any) error {
+	var value any
+	if err := convert(v, &value); err != nil {
+		return err
+	}
+	return state.Set(key, value)
+}
+
+func convert(in, out any) error {
+	b, err := json.Marshal(in)
+	if err != nil {
+		return fmt.Errorf("failed to encode checkpoints: %w", err)
+	}
+	if err := json.Unmarshal(b, out); err != nil {
+		return fmt.Errorf("failed to decode checkpoints: %w", err)
+	}
+	return nil
+}
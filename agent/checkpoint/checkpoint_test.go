@@ -0,0 +1,128 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package checkpoint_test
+
+import (
+	"iter"
+	"maps"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent/checkpoint"
+	"google.golang.org/adk/session"
+)
+
+type mapState map[string]any
+
+func (s mapState) Get(key string) (any, error) {
+	v, ok := s[key]
+	if !ok {
+		return nil, session.ErrStateKeyNotExist
+	}
+	return v, nil
+}
+
+func (s mapState) All() iter.Seq2[string, any] {
+	return maps.All(s)
+}
+
+func newEvent(id, invocationID string, content *genai.Content, delta map[string]any) *session.Event {
+	ev := session.NewEvent(invocationID)
+	ev.ID = id
+	ev.Content = content
+	ev.Actions.StateDelta = delta
+	if content != nil && content.Role == genai.RoleUser {
+		ev.Author = "user"
+	}
+	return ev
+}
+
+func TestFilter_PendingOperations(t *testing.T) {
+	checkpoints := map[string]any{"start": map[string]any{"id": "c1", "label": "start"}}
+	restores := func(pending checkpoint.PendingPolicy) []any {
+		return []any{map[string]any{"id": "r1", "checkpoint_id": "c1", "pending": string(pending)}}
+	}
+	text := func(s string, role genai.Role) *genai.Content { return genai.NewContentFromText(s, role) }
+
+	events := func(pending checkpoint.PendingPolicy) []*session.Event {
+		longRunning := newEvent("e4", "inv2", genai.NewContentFromFunctionCall("book_hotel", nil, genai.RoleModel), nil)
+		longRunning.Content.Parts[0].FunctionCall.ID = "call1"
+		longRunning.LongRunningToolIDs = []string{"call1"}
+		lateResponse := genai.NewContentFromFunctionResponse("book_hotel", map[string]any{"status": "booked"}, genai.RoleUser)
+		lateResponse.Parts[0].FunctionResponse.ID = "call1"
+		return []*session.Event{
+			newEvent("e1", "inv1", text("plan a trip", genai.RoleUser), nil),
+			newEvent("e2", "inv1", text("checkpoint created", genai.RoleModel), map[string]any{checkpoint.StateKey: checkpoints}),
+			newEvent("e3", "inv2", text("book a hotel in Paris", genai.RoleUser), nil),
+			longRunning,
+			newEvent("e5", "inv3", text("go back", genai.RoleUser), nil),
+			newEvent("e6", "inv3", text("restored", genai.RoleModel), map[string]any{checkpoint.RestoresStateKey: restores(pending)}),
+			newEvent("e7", "inv4", lateResponse, nil),
+		}
+	}
+
+	for _, tc := range []struct {
+		pending        checkpoint.PendingPolicy
+		wantEvents     []string
+		wantSuperseded map[string]bool
+	}{
+		{
+			pending:        checkpoint.PendingCancel,
+			wantEvents:     []string{"e1", "e2", "e5", "e6"},
+			wantSuperseded: map[string]bool{"e3": true, "e4": true},
+		},
+		{
+			pending:        checkpoint.PendingRelink,
+			wantEvents:     []string{"e1", "e2", "e4", "e5", "e6", "e7"},
+			wantSuperseded: map[string]bool{"e3": true},
+		},
+	} {
+		t.Run(string(tc.pending), func(t *testing.T) {
+			state := mapState{checkpoint.StateKey: checkpoints, checkpoint.RestoresStateKey: restores(tc.pending)}
+			filtered, err := checkpoint.Filter(state, events(tc.pending))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var ids []string
+			for _, ev := range filtered {
+				ids = append(ids, ev.ID)
+			}
+			if diff := cmp.Diff(tc.wantEvents, ids); diff != "" {
+				t.Errorf("Filter() mismatch (-want +got):\n%s", diff)
+			}
+
+			superseded, err := checkpoint.Superseded(state, events(tc.pending))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.wantSuperseded, superseded); diff != "" {
+				t.Errorf("Superseded() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFilter_NoRestore(t *testing.T) {
+	events := []*session.Event{newEvent("e1", "inv1", genai.NewContentFromText("hi", genai.RoleUser), nil)}
+	got, err := checkpoint.Filter(mapState{}, events)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 {
+		t.Errorf("Filter() returned %d events, want 1", len(got))
+	}
+}
@@ -15,9 +15,11 @@
 package llmagent
 
 import (
+	"context"
 	"fmt"
 	"iter"
 	"strings"
+	"time"
 
 	"google.golang.org/genai"
 
@@ -27,11 +29,15 @@ import (
 	"google.golang.org/adk/internal/llminternal"
 	"google.golang.org/adk/model"
 	"google.golang.org/adk/session"
+	"google.golang.org/adk/strictness"
 	"google.golang.org/adk/tool"
 )
 
 // New is a constructor for LLMAgent.
 func New(cfg Config) (agent.Agent, error) {
+	if cfg.ContextSelection != nil && cfg.ContextSelection.MaxTokens <= 0 {
+		return nil, fmt.Errorf("context selection of agent %q: MaxTokens must be positive", cfg.Name)
+	}
 	beforeModelCallbacks := make([]llminternal.BeforeModelCallback, 0, len(cfg.BeforeModelCallbacks))
 	for _, c := range cfg.BeforeModelCallbacks {
 		beforeModelCallbacks = append(beforeModelCallbacks, llminternal.BeforeModelCallback(c))
@@ -61,6 +67,8 @@ func New(cfg Config) (agent.Agent, error) {
 		instruction:          cfg.Instruction,
 		inputSchema:          cfg.InputSchema,
 		outputSchema:         cfg.OutputSchema,
+		strictness:           cfg.Strictness,
+		prefetch:             cfg.Prefetch.internal(),
 
 		State: llminternal.State{
 			Model:                    cfg.Model,
@@ -73,11 +81,15 @@ func New(cfg Config) (agent.Agent, error) {
 			OutputSchema:             cfg.OutputSchema,
 			// TODO: internal type for includeContents
 			IncludeContents:           string(cfg.IncludeContents),
+			CrossBranch:               cfg.CrossBranchVisibility.internal(),
+			ContextSelection:          cfg.ContextSelection.internal(),
 			Instruction:               cfg.Instruction,
 			InstructionProvider:       llminternal.InstructionProvider(cfg.InstructionProvider),
 			GlobalInstruction:         cfg.GlobalInstruction,
 			GlobalInstructionProvider: llminternal.InstructionProvider(cfg.GlobalInstructionProvider),
 			OutputKey:                 cfg.OutputKey,
+			ToolChangeNotices:         cfg.ToolChangeNotices,
+			ToolNameCollisions:        string(cfg.ToolNameCollisions),
 		},
 	}
 
@@ -213,6 +225,17 @@ type Config struct {
 
 	// Whether to include contents (conversation history) in the model request.
 	IncludeContents IncludeContents
+	// CrossBranchVisibility makes the agent see the events of the agents
+	// running in sibling branches of the session, e.g. the other sub-agents
+	// of a ParallelAgent sharing the session. Nil, the default, keeps the
+	// agent isolated in its branch. It has no effect with
+	// IncludeContentsNone.
+	CrossBranchVisibility *CrossBranchVisibility
+	// ContextSelection selects the turns of the conversation history
+	// included in the model requests by relevance when the history exceeds
+	// a token budget. Nil, the default, includes the whole history. It has
+	// no effect with IncludeContentsNone.
+	ContextSelection *ContextSelection
 
 	// TODO(ngeorgy): consider to switch to jsonschema for input and output schema.
 	// The input schema when agent is used as a tool.
@@ -243,6 +266,30 @@ type Config struct {
 	// - Extracts agent reply for later use, such as in tools, callbacks, etc.
 	// - Connects agents to coordinate with each other.
 	OutputKey string
+
+	// ToolChangeNotices makes the agent remember a compact summary of the
+	// tools it declared to the model in the session state. When the tools
+	// differ on a later turn, e.g. in a session resumed after a deployment,
+	// the model is told which tools were added, removed, renamed or changed,
+	// and calls to removed tools are answered with an error response instead
+	// of failing the invocation.
+	ToolChangeNotices bool
+
+	// ToolNameCollisions controls how a function tool is handled when its
+	// name is already used by another tool of the request, either another
+	// function tool or a Gemini native tool like geminitool.GoogleSearch.
+	// Defaults to ToolNameCollisionError.
+	ToolNameCollisions ToolNameCollisionPolicy
+
+	// Strictness is the policy applied when the data of the runs of the
+	// agent is dropped or degraded, e.g. by the model adapter. It overrides
+	// the policy of the invocation context, if any. Optional.
+	Strictness *strictness.Policy
+
+	// Prefetch starts speculative calls of read-only tools guessed from the
+	// user content, concurrently with the first model call of the agent
+	// run. Optional.
+	Prefetch *Prefetch
 }
 
 // BeforeModelCallback that is called before sending a request to the model.
@@ -286,6 +333,147 @@ const (
 	IncludeContentsDefault IncludeContents = "default"
 )
 
+// CrossBranchVisibility controls which events of the sibling branches are
+// included in the requests of an agent. They are rendered as user content
+// attributed to their author, e.g. "[writer agent]: ...", and their
+// function calls and responses are collapsed to short notations like
+// "(called search(query))", so that the agent does not answer the calls of
+// another agent.
+type CrossBranchVisibility struct {
+	// Agents is the allowlist of the agents whose events are visible.
+	// Empty means all the agents.
+	Agents []string
+	// MaxTokens bounds the estimated tokens of the included events: the most
+	// recent events are preferred. Zero means unlimited.
+	MaxTokens int
+}
+
+func (v *CrossBranchVisibility) internal() *llminternal.CrossBranchConfig {
+	if v == nil {
+		return nil
+	}
+	return &llminternal.CrossBranchConfig{Agents: v.Agents, MaxTokens: v.MaxTokens}
+}
+
+// ContextSelection bounds the conversation history included in the model
+// requests of an agent. When the estimated tokens of the system instruction
+// and the history exceed MaxTokens, the most recent turns, and the turns
+// whose function calls are not answered yet, are kept; the remaining budget
+// is filled with the older turns scoring best against the latest user
+// message, in their original order. A turn starts with a user message.
+// Each run of omitted turns is replaced by a marker telling the model that
+// material was omitted.
+//
+// The scores are cached during the invocation. The selection is
+// deterministic for a deterministic scorer: ties are broken in favor of
+// the most recent turns.
+type ContextSelection struct {
+	// MaxTokens is the estimated token budget, at four characters per
+	// token. It must be positive.
+	MaxTokens int
+	// KeepRecentTurns is the number of most recent turns always included.
+	// Defaults to 2.
+	KeepRecentTurns int
+	// Scorer scores the older turns. Defaults to LexicalScorer.
+	Scorer ContextScorer
+}
+
+// ContextScorer scores the relevance of the text of a turn of the history
+// to a query, the latest user message. Higher scores are more relevant.
+type ContextScorer interface {
+	Score(ctx context.Context, query, text string) (float64, error)
+}
+
+// Embedder returns the embedding vector of a text.
+type Embedder interface {
+	Embed(ctx context.Context, text string) ([]float32, error)
+}
+
+// LexicalScorer returns a scorer scoring a text by the fraction of the
+// words of the query it contains, ignoring the case and the words shorter
+// than three letters.
+func LexicalScorer() ContextScorer {
+	return llminternal.LexicalScorer{}
+}
+
+// EmbeddingScorer returns a scorer scoring a text by the cosine similarity
+// of its embedding with the embedding of the query.
+func EmbeddingScorer(e Embedder) ContextScorer {
+	return llminternal.EmbeddingScorer{Embedder: e}
+}
+
+func (s *ContextSelection) internal() *llminternal.ContextSelectionConfig {
+	if s == nil {
+		return nil
+	}
+	cfg := &llminternal.ContextSelectionConfig{MaxTokens: s.MaxTokens, KeepRecentTurns: s.KeepRecentTurns, Scorer: s.Scorer}
+	if cfg.KeepRecentTurns <= 0 {
+		cfg.KeepRecentTurns = 2
+	}
+	if cfg.Scorer == nil {
+		cfg.Scorer = LexicalScorer()
+	}
+	return cfg
+}
+
+// Prefetch configures the speculative tool calls of an agent. When the user
+// content matches a rule, the tool of the rule is called while the model is
+// generating its response. If the model then calls the tool with the same
+// arguments, the prefetched result is returned instead of calling the tool
+// again; otherwise it is discarded.
+//
+// Only the tools annotated as read-only and idempotent are prefetched, see
+// [tool.IsReadOnly]. A prefetched call producing actions, e.g. state
+// changes, or failing is discarded too: the model never sees the
+// speculative calls. Nothing is prefetched for the agents with
+// [Config.BeforeToolCallbacks], which must run before the tools.
+type Prefetch struct {
+	Rules []PrefetchRule
+	// Budget bounds the duration of the speculative calls: they are
+	// cancelled when it is exhausted. Defaults to one second.
+	Budget time.Duration
+}
+
+// PrefetchRule starts a speculative call of a tool when the user content
+// matches it.
+type PrefetchRule struct {
+	// Tool is the name of the called tool.
+	Tool string
+	// Match reports whether the rule applies to the user content.
+	Match func(content *genai.Content) bool
+	// Args returns the arguments of the call for the user content.
+	Args func(content *genai.Content) map[string]any
+}
+
+func (p *Prefetch) internal() *llminternal.PrefetchConfig {
+	if p == nil {
+		return nil
+	}
+	rules := make([]llminternal.PrefetchRule, 0, len(p.Rules))
+	for _, r := range p.Rules {
+		rules = append(rules, llminternal.PrefetchRule{Tool: r.Tool, Match: r.Match, Args: r.Args})
+	}
+	return &llminternal.PrefetchConfig{Rules: rules, Budget: p.Budget}
+}
+
+// ToolNameCollisionPolicy controls how llmagent handles a function tool
+// whose name collides with another tool of the request.
+type ToolNameCollisionPolicy string
+
+const (
+	// ToolNameCollisionError fails the request with an error naming both
+	// tools. It is the default.
+	ToolNameCollisionError ToolNameCollisionPolicy = "error"
+	// ToolNameCollisionRename declares the function tool under a new name
+	// with a numeric suffix, e.g. "google_search_2". Calls to the new name
+	// are dispatched to the function tool.
+	ToolNameCollisionRename ToolNameCollisionPolicy = "rename"
+	// ToolNameCollisionPreferNative drops the function tools whose name
+	// collides with a Gemini native tool. Collisions between function tools
+	// are still errors.
+	ToolNameCollisionPreferNative ToolNameCollisionPolicy = "prefer_native"
+)
+
 type llmAgent struct {
 	agent.Agent
 	llminternal.State
@@ -301,13 +489,23 @@ type llmAgent struct {
 
 	inputSchema  *genai.Schema
 	outputSchema *genai.Schema
+
+	strictness *strictness.Policy
+	prefetch   *llminternal.PrefetchConfig
 }
 
 type agentState = agentinternal.State
 
 func (a *llmAgent) run(ctx agent.InvocationContext) iter.Seq2[*session.Event, error] {
 	// TODO: branch context?
-	ctx = icontext.NewInvocationContext(ctx, icontext.InvocationContextParams{
+	var base context.Context = ctx
+	if a.strictness != nil {
+		base = strictness.NewContext(ctx, a.strictness)
+	}
+	if a.ContextSelection != nil {
+		base = llminternal.WithScoreCache(base)
+	}
+	ctx = icontext.NewInvocationContext(base, icontext.InvocationContextParams{
 		Artifacts:   ctx.Artifacts(),
 		Memory:      ctx.Memory(),
 		Session:     ctx.Session(),
@@ -325,6 +523,7 @@ func (a *llmAgent) run(ctx agent.InvocationContext) iter.Seq2[*session.Event, er
 		AfterModelCallbacks:  a.afterModelCallbacks,
 		BeforeToolCallbacks:  a.beforeToolCallbacks,
 		AfterToolCallbacks:   a.afterToolCallbacks,
+		Prefetch:             a.prefetch,
 	}
 
 	return func(yield func(*session.Event, error) bool) {
@@ -0,0 +1,200 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llmagent_test
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/internal/testutil"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+)
+
+type forecastArgs struct {
+	City string `json:"city"`
+}
+
+type forecastResult struct {
+	City string `json:"city"`
+	// Speculative is set by the prefetched calls.
+	Speculative bool `json:"speculative"`
+}
+
+// osloRule prefetches the forecast of Oslo when the user mentions it.
+var osloRule = llmagent.PrefetchRule{
+	Tool: "get_forecast",
+	Match: func(c *genai.Content) bool {
+		return len(c.Parts) > 0 && strings.Contains(c.Parts[0].Text, "Oslo")
+	},
+	Args: func(*genai.Content) map[string]any {
+		return map[string]any{"city": "Oslo"}
+	},
+}
+
+func TestPrefetch(t *testing.T) {
+	testCases := []struct {
+		name     string
+		readOnly bool
+		budget   time.Duration
+		// slow makes the speculative calls wait for their cancellation.
+		slow bool
+		// beforeTool sets a before tool callback letting the calls run.
+		beforeTool bool
+		call       map[string]any
+		want       map[string]any
+		// wantRuns is the number of the calls which are not speculative.
+		wantRuns int32
+		wantHit  bool
+	}{
+		{
+			name:     "hit",
+			readOnly: true,
+			call:     map[string]any{"city": "Oslo"},
+			want:     map[string]any{"city": "Oslo", "speculative": true},
+			wantRuns: 0,
+			wantHit:  true,
+		},
+		{
+			name:     "near miss is discarded",
+			readOnly: true,
+			call:     map[string]any{"city": "Bergen"},
+			want:     map[string]any{"city": "Bergen", "speculative": false},
+			wantRuns: 1,
+		},
+		{
+			name:     "tool not read-only",
+			call:     map[string]any{"city": "Oslo"},
+			want:     map[string]any{"city": "Oslo", "speculative": false},
+			wantRuns: 1,
+		},
+		{
+			name:       "before tool callback",
+			readOnly:   true,
+			beforeTool: true,
+			call:       map[string]any{"city": "Oslo"},
+			want:       map[string]any{"city": "Oslo", "speculative": false},
+			wantRuns:   1,
+		},
+		{
+			name:     "budget cancels a slow call",
+			readOnly: true,
+			budget:   20 * time.Millisecond,
+			slow:     true,
+			call:     map[string]any{"city": "Oslo"},
+			want:     map[string]any{"city": "Oslo", "speculative": false},
+			wantRuns: 1,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := tracetest.NewSpanRecorder()
+			previous := otel.GetTracerProvider()
+			otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+			t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+			var runs atomic.Int32
+			cancelled := make(chan error, 1)
+			forecast, err := functiontool.New(functiontool.Config{Name: "get_forecast", Description: "returns the forecast", ReadOnly: tc.readOnly},
+				func(ctx tool.Context, args forecastArgs) (forecastResult, error) {
+					// Only the speculative calls have a deadline.
+					_, speculative := ctx.Deadline()
+					if !speculative {
+						runs.Add(1)
+					}
+					if speculative && tc.slow {
+						<-ctx.Done()
+						cancelled <- ctx.Err()
+						return forecastResult{}, ctx.Err()
+					}
+					return forecastResult{City: args.City, Speculative: speculative}, nil
+				})
+			if err != nil {
+				t.Fatal(err)
+			}
+			testModel := &testutil.MockModel{Responses: []*genai.Content{
+				genai.NewContentFromFunctionCall("get_forecast", tc.call, genai.RoleModel),
+				genai.NewContentFromText("done", genai.RoleModel),
+			}}
+			var beforeTool []llmagent.BeforeToolCallback
+			if tc.beforeTool {
+				beforeTool = append(beforeTool, func(tool.Context, tool.Tool, map[string]any) (map[string]any, error) {
+					return nil, nil
+				})
+			}
+			a, err := llmagent.New(llmagent.Config{
+				Name:                "agent",
+				Model:               testModel,
+				Tools:               []tool.Tool{forecast},
+				BeforeToolCallbacks: beforeTool,
+				Prefetch:            &llmagent.Prefetch{Rules: []llmagent.PrefetchRule{osloRule}, Budget: tc.budget},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			events, err := testutil.CollectEvents(testutil.NewTestAgentRunner(t, a).Run(t, "session", "What's the weather in Oslo?"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var responses []map[string]any
+			for _, ev := range events {
+				for _, p := range ev.Content.Parts {
+					if p.FunctionResponse != nil {
+						responses = append(responses, p.FunctionResponse.Response)
+					}
+				}
+			}
+			if diff := cmp.Diff([]map[string]any{tc.want}, responses); diff != "" {
+				t.Errorf("function responses mismatch (-want +got):\n%s", diff)
+			}
+			// The model only sees the answered call.
+			if got := len(testModel.Requests[1].Contents); got != 3 {
+				t.Errorf("second model request has %d contents, want 3", got)
+			}
+			if got := runs.Load(); got != tc.wantRuns {
+				t.Errorf("tool runs = %d, want %d", got, tc.wantRuns)
+			}
+			if tc.slow {
+				if err := <-cancelled; err != context.DeadlineExceeded {
+					t.Errorf("speculative call error = %v, want %v", err, context.DeadlineExceeded)
+				}
+			}
+
+			var hit bool
+			for _, s := range recorder.Ended() {
+				for _, attr := range s.Attributes() {
+					if attr == attribute.Bool("gcp.vertex.agent.prefetch_hit", true) {
+						hit = s.Name() == "execute_tool get_forecast"
+					}
+				}
+			}
+			if hit != tc.wantHit {
+				t.Errorf("prefetch hit recorded = %v, want %v", hit, tc.wantHit)
+			}
+		})
+	}
+}
@@ -0,0 +1,166 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llmagent_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/internal/testutil"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/runner"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+)
+
+type reportQueryArgs struct {
+	Query string `json:"query"`
+}
+
+type reportExportArgs struct {
+	Query  string `json:"query"`
+	Format string `json:"format"`
+}
+
+func newReportTool[TArgs any](t *testing.T, name string) tool.Tool {
+	t.Helper()
+	tl, err := functiontool.New(functiontool.Config{Name: name, Description: name}, func(tool.Context, TArgs) (map[string]any, error) {
+		return map[string]any{"rows": 1}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tl
+}
+
+func TestToolChangeNotices(t *testing.T) {
+	query := func(name string) tool.Tool { return newReportTool[reportQueryArgs](t, name) }
+	export := func(name string) tool.Tool { return newReportTool[reportExportArgs](t, name) }
+	text := func(s string) *genai.Content { return genai.NewContentFromText(s, genai.RoleModel) }
+
+	testCases := []struct {
+		name               string
+		firstTools         []tool.Tool
+		secondTools        []tool.Tool
+		secondTurn         []*genai.Content
+		wantNotice         string
+		wantFunctionResult map[string]any
+	}{
+		{
+			name:        "rename",
+			firstTools:  []tool.Tool{query("fetch_report")},
+			secondTools: []tool.Tool{query("get_report")},
+			secondTurn:  []*genai.Content{text("ok")},
+			wantNotice: "NOTE: The available tools changed since the previous turn of this conversation. Only call the tools declared in this request.\n" +
+				"- the fetch_report tool was renamed to get_report",
+		},
+		{
+			name:        "parameter addition",
+			firstTools:  []tool.Tool{query("export_data")},
+			secondTools: []tool.Tool{export("export_data")},
+			secondTurn:  []*genai.Content{text("ok")},
+			wantNotice: "NOTE: The available tools changed since the previous turn of this conversation. Only call the tools declared in this request.\n" +
+				"- the export_data tool now requires a 'format' argument",
+		},
+		{
+			name:        "removal",
+			firstTools:  []tool.Tool{query("export_data"), query("delete_data")},
+			secondTools: []tool.Tool{query("export_data")},
+			secondTurn: []*genai.Content{
+				genai.NewContentFromFunctionCall("delete_data", map[string]any{"query": "all"}, genai.RoleModel),
+				text("ok"),
+			},
+			wantNotice: "NOTE: The available tools changed since the previous turn of this conversation. Only call the tools declared in this request.\n" +
+				"- the delete_data tool was removed",
+			wantFunctionResult: map[string]any{
+				"error": `tool "delete_data" was removed and cannot be called anymore; available tools: export_data`,
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sessionService := session.InMemoryService()
+			if _, err := sessionService.Create(t.Context(), &session.CreateRequest{AppName: "app", UserID: "user", SessionID: "session"}); err != nil {
+				t.Fatal(err)
+			}
+			run := func(tools []tool.Tool, responses []*genai.Content) *testutil.MockModel {
+				t.Helper()
+				testModel := &testutil.MockModel{Responses: responses}
+				a, err := llmagent.New(llmagent.Config{
+					Name:              "agent",
+					Model:             testModel,
+					Tools:             tools,
+					ToolChangeNotices: true,
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+				r, err := runner.New(runner.Config{AppName: "app", Agent: a, SessionService: sessionService})
+				if err != nil {
+					t.Fatal(err)
+				}
+				for _, err := range r.Run(t.Context(), "user", "session", genai.NewContentFromText("hi", genai.RoleUser), agent.RunConfig{}) {
+					if err != nil {
+						t.Fatal(err)
+					}
+				}
+				return testModel
+			}
+
+			first := run(tc.firstTools, []*genai.Content{text("ok")})
+			if got := systemInstruction(first.Requests[0]); got != "" {
+				t.Errorf("first turn system instruction = %q, want none", got)
+			}
+
+			second := run(tc.secondTools, tc.secondTurn)
+			if diff := cmp.Diff(tc.wantNotice, systemInstruction(second.Requests[0])); diff != "" {
+				t.Errorf("notice mismatch (-want +got):\n%s", diff)
+			}
+			if tc.wantFunctionResult != nil {
+				var got map[string]any
+				for _, c := range second.Requests[1].Contents {
+					for _, p := range c.Parts {
+						if p.FunctionResponse != nil {
+							got = p.FunctionResponse.Response
+						}
+					}
+				}
+				if diff := cmp.Diff(tc.wantFunctionResult, got); diff != "" {
+					t.Errorf("function response mismatch (-want +got):\n%s", diff)
+				}
+			}
+			// The notice is only injected once.
+			if got := systemInstruction(second.Requests[len(second.Requests)-1]); len(second.Requests) > 1 && got != "" {
+				t.Errorf("follow-up request system instruction = %q, want none", got)
+			}
+		})
+	}
+}
+
+func systemInstruction(req *model.LLMRequest) string {
+	if req.Config == nil || req.Config.SystemInstruction == nil {
+		return ""
+	}
+	var text string
+	for _, p := range req.Config.SystemInstruction.Parts {
+		text += p.Text
+	}
+	return text
+}
@@ -0,0 +1,180 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llmagent_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/internal/testutil"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+	"google.golang.org/adk/tool/geminitool"
+)
+
+type searchArgs struct {
+	Query string `json:"query"`
+}
+
+func TestToolNameCollisions(t *testing.T) {
+	newSearchTool := func(name, result string) tool.Tool {
+		tl, err := functiontool.New(functiontool.Config{Name: name, Description: "searches the intranet"}, func(tool.Context, searchArgs) (map[string]any, error) {
+			return map[string]any{"result": result}, nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tl
+	}
+
+	testCases := []struct {
+		name      string
+		policy    llmagent.ToolNameCollisionPolicy
+		tools     []tool.Tool
+		responses []*genai.Content
+		// wantErr is a substring of the expected error.
+		wantErr       string
+		wantDecls     []string
+		wantNative    bool
+		wantFnResults map[string]any
+	}{
+		{
+			name:    "native collision is an error by default",
+			tools:   []tool.Tool{geminitool.GoogleSearch{}, newSearchTool("google_search", "intranet")},
+			wantErr: `tool name "google_search" is declared by both a *functiontool.functionTool and the Gemini native google_search tool`,
+		},
+		{
+			name:   "native collision renames the function tool",
+			policy: llmagent.ToolNameCollisionRename,
+			tools:  []tool.Tool{newSearchTool("google_search", "intranet"), geminitool.GoogleSearch{}},
+			responses: []*genai.Content{
+				genai.NewContentFromFunctionCall("google_search_2", map[string]any{"query": "q"}, genai.RoleModel),
+				genai.NewContentFromText("done", genai.RoleModel),
+			},
+			wantDecls:     []string{"google_search_2"},
+			wantNative:    true,
+			wantFnResults: map[string]any{"google_search_2": map[string]any{"result": "intranet"}},
+		},
+		{
+			name:       "native collision prefers the native tool",
+			policy:     llmagent.ToolNameCollisionPreferNative,
+			tools:      []tool.Tool{newSearchTool("google_search", "intranet"), geminitool.GoogleSearch{}, newSearchTool("lookup", "lookup")},
+			responses:  []*genai.Content{genai.NewContentFromText("done", genai.RoleModel)},
+			wantDecls:  []string{"lookup"},
+			wantNative: true,
+		},
+		{
+			name:    "duplicate function tools are an error by default",
+			tools:   []tool.Tool{newSearchTool("lookup", "first"), newSearchTool("lookup", "second")},
+			wantErr: `tool name "lookup" is declared by both a *functiontool.functionTool and a *functiontool.functionTool`,
+		},
+		{
+			name:   "duplicate function tools are renamed",
+			policy: llmagent.ToolNameCollisionRename,
+			tools:  []tool.Tool{newSearchTool("lookup", "first"), newSearchTool("lookup", "second")},
+			responses: []*genai.Content{
+				{Role: genai.RoleModel, Parts: []*genai.Part{
+					genai.NewPartFromFunctionCall("lookup", map[string]any{"query": "q"}),
+					genai.NewPartFromFunctionCall("lookup_2", map[string]any{"query": "q"}),
+				}},
+				genai.NewContentFromText("done", genai.RoleModel),
+			},
+			wantDecls: []string{"lookup", "lookup_2"},
+			wantFnResults: map[string]any{
+				"lookup":   map[string]any{"result": "first"},
+				"lookup_2": map[string]any{"result": "second"},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testModel := &testutil.MockModel{Responses: tc.responses}
+			a, err := llmagent.New(llmagent.Config{
+				Name:               "agent",
+				Model:              testModel,
+				Tools:              tc.tools,
+				ToolNameCollisions: tc.policy,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			events, err := testutil.CollectEvents(testutil.NewTestAgentRunner(t, a).Run(t, "session", "search"))
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("Run() error = %v, want an error containing %q", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			req := testModel.Requests[0]
+			var decls []string
+			var native bool
+			for _, tl := range req.Config.Tools {
+				for _, decl := range tl.FunctionDeclarations {
+					decls = append(decls, decl.Name)
+				}
+				native = native || tl.GoogleSearch != nil
+			}
+			if diff := cmp.Diff(tc.wantDecls, decls); diff != "" {
+				t.Errorf("function declarations mismatch (-want +got):\n%s", diff)
+			}
+			if native != tc.wantNative {
+				t.Errorf("native google_search declared = %v, want %v", native, tc.wantNative)
+			}
+
+			fnResults := map[string]any{}
+			for _, ev := range events {
+				for _, p := range ev.Content.Parts {
+					if p.FunctionResponse != nil {
+						fnResults[p.FunctionResponse.Name] = p.FunctionResponse.Response
+					}
+				}
+			}
+			if tc.wantFnResults == nil {
+				tc.wantFnResults = map[string]any{}
+			}
+			if diff := cmp.Diff(tc.wantFnResults, fnResults); diff != "" {
+				t.Errorf("function results mismatch (-want +got):\n%s", diff)
+			}
+			if got := testModel.Requests[len(testModel.Requests)-1]; !declares(got, tc.wantDecls) {
+				t.Errorf("follow-up request does not declare %v", tc.wantDecls)
+			}
+		})
+	}
+}
+
+// declares reports whether req declares all the given functions.
+func declares(req *model.LLMRequest, names []string) bool {
+	declared := map[string]bool{}
+	for _, tl := range req.Config.Tools {
+		for _, decl := range tl.FunctionDeclarations {
+			declared[decl.Name] = true
+		}
+	}
+	for _, name := range names {
+		if !declared[name] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,272 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package outbox protects the side effects of tools against the crashes
+// happening between the completion of a tool and the persistence of its
+// function response.
+//
+// Before a side-effectful tool runs, an [Outbox] durably writes a pending
+// record of the call in a [Store]. The record is deleted once the event with
+// the function response is appended to the session. A record left behind,
+// e.g. because the process crashed in between, is unreconciled: the model is
+// told that the call may have already executed, and an identical call is
+// blocked until the application reconciles the record with [Outbox.Reconcile]
+// after checking the external system.
+//
+// An Outbox is installed with its callbacks and its session service wrapper:
+//
+//	o, err := outbox.New(outbox.Config{
+//		Store:       store,
+//		SideEffects: outbox.ToolNames("send_email"),
+//	})
+//	...
+//	a, err := llmagent.New(llmagent.Config{
+//		...
+//		BeforeModelCallbacks: []llmagent.BeforeModelCallback{o.BeforeModel},
+//		BeforeToolCallbacks:  []llmagent.BeforeToolCallback{o.BeforeTool},
+//	})
+//	...
+//	r, err := runner.New(runner.Config{
+//		...
+//		SessionService: o.SessionService(sessionService),
+//	})
+package outbox
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/internal/utils"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/runtimedeps"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+)
+
+// Key identifies the record of a function call.
+type Key struct {
+	AppName   string `json:"appName"`
+	UserID    string `json:"userId"`
+	SessionID string `json:"sessionId"`
+	// CallID is the ID of the function call.
+	CallID string `json:"callId"`
+}
+
+// Record is the pending record of a side-effectful function call.
+type Record struct {
+	Key
+	InvocationID string `json:"invocationId"`
+	Tool         string `json:"tool"`
+	// ArgsHash is the SHA-256 of the canonical JSON encoding of the
+	// arguments of the call.
+	ArgsHash  string    `json:"argsHash"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Store stores the records durably. Put must not return before the record
+// is durable.
+type Store interface {
+	Put(ctx context.Context, r Record) error
+	// Delete deletes the record with the given key. Deleting a missing
+	// record is not an error.
+	Delete(ctx context.Context, key Key) error
+	// List returns all the records.
+	List(ctx context.Context) ([]Record, error)
+}
+
+// Config is the configuration of an [Outbox].
+type Config struct {
+	// Store stores the records.
+	Store Store
+	// SideEffects reports whether the calls to a tool have side effects
+	// that must not be repeated, e.g. sending an email.
+	SideEffects func(tool.Tool) bool
+}
+
+// ToolNames returns a Config.SideEffects function marking the tools with
+// the given names.
+func ToolNames(names ...string) func(tool.Tool) bool {
+	return func(t tool.Tool) bool {
+		return slices.Contains(names, t.Name())
+	}
+}
+
+// Outbox records the side-effectful function calls until their responses
+// are persisted.
+type Outbox struct {
+	cfg Config
+}
+
+// New returns an Outbox with the given configuration.
+func New(cfg Config) (*Outbox, error) {
+	if cfg.Store == nil {
+		return nil, errors.New("outbox store is required")
+	}
+	if cfg.SideEffects == nil {
+		return nil, errors.New("outbox side effects function is required")
+	}
+	return &Outbox{cfg: cfg}, nil
+}
+
+// BeforeTool is an llmagent.BeforeToolCallback. Before a side-effectful
+// call, it writes its pending record, unless an identical call is
+// unreconciled: the call is then answered with an error instead of being
+// run.
+func (o *Outbox) BeforeTool(ctx tool.Context, t tool.Tool, args map[string]any) (map[string]any, error) {
+	if !o.cfg.SideEffects(t) {
+		return nil, nil
+	}
+	hash, err := argsHash(args)
+	if err != nil {
+		return nil, err
+	}
+	unreconciled, err := o.unreconciled(ctx, ctx.AppName(), ctx.UserID(), ctx.SessionID(), ctx.InvocationID())
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range unreconciled {
+		if r.Tool == t.Name() && r.ArgsHash == hash {
+			return map[string]any{"error": fmt.Sprintf(
+				"an identical %s call from a previous turn may have already executed and awaits confirmation; verify its effect instead of retrying it", t.Name())}, nil
+		}
+	}
+	err = o.cfg.Store.Put(ctx, Record{
+		Key: Key{
+			AppName:   ctx.AppName(),
+			UserID:    ctx.UserID(),
+			SessionID: ctx.SessionID(),
+			CallID:    ctx.FunctionCallID(),
+		},
+		InvocationID: ctx.InvocationID(),
+		Tool:         t.Name(),
+		ArgsHash:     hash,
+		CreatedAt:    runtimedeps.FromContext(ctx).Now(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to record the call of tool %q: %w", t.Name(), err)
+	}
+	return nil, nil
+}
+
+// BeforeModel is an llmagent.BeforeModelCallback. It tells the model about
+// the unreconciled calls of the session.
+func (o *Outbox) BeforeModel(ctx agent.CallbackContext, req *model.LLMRequest) (*model.LLMResponse, error) {
+	unreconciled, err := o.unreconciled(ctx, ctx.AppName(), ctx.UserID(), ctx.SessionID(), ctx.InvocationID())
+	if err != nil {
+		return nil, err
+	}
+	if len(unreconciled) == 0 {
+		return nil, nil
+	}
+	var b strings.Builder
+	b.WriteString("NOTE: the outcome of these earlier tool calls is unknown.")
+	for _, r := range unreconciled {
+		fmt.Fprintf(&b, "\n- A previous %s call (started %s) may have already executed; verify before retrying.", r.Tool, r.CreatedAt.UTC().Format(time.RFC3339))
+	}
+	utils.AppendInstructions(req, b.String())
+	return nil, nil
+}
+
+// Unreconciled returns the records of the session left behind by the
+// previous invocations, oldest first. Applications can call it at startup
+// or at the start of a turn, and reconcile the records.
+func (o *Outbox) Unreconciled(ctx context.Context, appName, userID, sessionID string) ([]Record, error) {
+	return o.unreconciled(ctx, appName, userID, sessionID, "")
+}
+
+// unreconciled returns the records of the session, except the ones of the
+// current invocation, whose calls may be in progress.
+func (o *Outbox) unreconciled(ctx context.Context, appName, userID, sessionID, invocationID string) ([]Record, error) {
+	records, err := o.cfg.Store.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the outbox records: %w", err)
+	}
+	records = slices.DeleteFunc(records, func(r Record) bool {
+		return r.AppName != appName || r.UserID != userID || r.SessionID != sessionID || (invocationID != "" && r.InvocationID == invocationID)
+	})
+	slices.SortFunc(records, func(a, b Record) int { return a.CreatedAt.Compare(b.CreatedAt) })
+	return records, nil
+}
+
+// Reconcile deletes the record with the given key, once the application
+// has checked the outcome of the call in the external system. The model is
+// not told about the call anymore, and identical calls are allowed again.
+func (o *Outbox) Reconcile(ctx context.Context, key Key) error {
+	return o.cfg.Store.Delete(ctx, key)
+}
+
+// SessionService returns a session service delegating to s, which deletes
+// the records of the function responses of the events once they are
+// appended.
+func (o *Outbox) SessionService(s session.Service) session.Service {
+	return &sessionService{Service: s, store: o.cfg.Store}
+}
+
+type sessionService struct {
+	session.Service
+	store Store
+}
+
+func (s *sessionService) AppendEvent(ctx context.Context, sess session.Session, ev *session.Event) error {
+	if err := s.Service.AppendEvent(ctx, sess, ev); err != nil {
+		return err
+	}
+	if ev.Partial || ev.Content == nil {
+		return nil
+	}
+	responded := make(map[string]bool)
+	for _, p := range ev.Content.Parts {
+		if p != nil && p.FunctionResponse != nil {
+			responded[p.FunctionResponse.ID] = true
+		}
+	}
+	if len(responded) == 0 {
+		return nil
+	}
+	records, err := s.store.List(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list the outbox records: %w", err)
+	}
+	for _, r := range records {
+		// The invocation is checked too, so that the response of a blocked
+		// call reusing the ID of an unreconciled one does not complete it.
+		if r.AppName != sess.AppName() || r.UserID != sess.UserID() || r.SessionID != sess.ID() ||
+			r.InvocationID != ev.InvocationID || !responded[r.CallID] {
+			continue
+		}
+		if err := s.store.Delete(ctx, r.Key); err != nil {
+			return fmt.Errorf("failed to complete the outbox record of call %q: %w", r.CallID, err)
+		}
+	}
+	return nil
+}
+
+// argsHash returns the SHA-256 of the JSON encoding of args, whose map keys
+// are sorted.
+func argsHash(args map[string]any) (string, error) {
+	data, err := json.Marshal(args)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode the arguments: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,254 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package outbox_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/agent/outbox"
+	"google.golang.org/adk/internal/testutil"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/runner"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+)
+
+type emailArgs struct {
+	To      string `json:"to"`
+	Subject string `json:"subject"`
+}
+
+var errCrash = errors.New("process crashed")
+
+// crashingService fails to append the function responses, like a process
+// crashing after the tools completed.
+type crashingService struct {
+	session.Service
+}
+
+func (s *crashingService) AppendEvent(ctx context.Context, sess session.Session, ev *session.Event) error {
+	if ev.Content != nil {
+		for _, p := range ev.Content.Parts {
+			if p.FunctionResponse != nil {
+				return errCrash
+			}
+		}
+	}
+	return s.Service.AppendEvent(ctx, sess, ev)
+}
+
+type app struct {
+	outbox *outbox.Outbox
+	model  *testutil.MockModel
+	runner *runner.Runner
+}
+
+// newApp returns the application as started by a process, over the given
+// storage.
+func newApp(t *testing.T, sessions session.Service, store outbox.Store, sent *[]emailArgs, responses ...*genai.Content) *app {
+	t.Helper()
+	o, err := outbox.New(outbox.Config{Store: store, SideEffects: outbox.ToolNames("send_email")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sendEmail, err := functiontool.New(functiontool.Config{Name: "send_email", Description: "Sends an email."}, func(_ tool.Context, args emailArgs) (map[string]any, error) {
+		*sent = append(*sent, args)
+		return map[string]any{"status": "sent"}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	llm := &testutil.MockModel{Responses: responses}
+	a, err := llmagent.New(llmagent.Config{
+		Name:                 "assistant",
+		Model:                llm,
+		Tools:                []tool.Tool{sendEmail},
+		BeforeModelCallbacks: []llmagent.BeforeModelCallback{o.BeforeModel},
+		BeforeToolCallbacks:  []llmagent.BeforeToolCallback{o.BeforeTool},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := runner.New(runner.Config{AppName: "app", Agent: a, SessionService: o.SessionService(sessions)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &app{outbox: o, model: llm, runner: r}
+}
+
+func (a *app) run(t *testing.T, msg string) ([]*session.Event, error) {
+	t.Helper()
+	var events []*session.Event
+	for ev, err := range a.runner.Run(t.Context(), "user", "session", genai.NewContentFromText(msg, genai.RoleUser), agent.RunConfig{}) {
+		if err != nil {
+			return events, err
+		}
+		events = append(events, ev)
+	}
+	return events, nil
+}
+
+func functionResponses(events []*session.Event) []map[string]any {
+	var responses []map[string]any
+	for _, ev := range events {
+		for _, p := range ev.Content.Parts {
+			if p.FunctionResponse != nil {
+				responses = append(responses, p.FunctionResponse.Response)
+			}
+		}
+	}
+	return responses
+}
+
+func systemInstruction(req *model.LLMRequest) string {
+	if req.Config == nil || req.Config.SystemInstruction == nil {
+		return ""
+	}
+	var texts []string
+	for _, p := range req.Config.SystemInstruction.Parts {
+		texts = append(texts, p.Text)
+	}
+	return strings.Join(texts, "\n")
+}
+
+func TestOutbox_CrashWindow(t *testing.T) {
+	sessions := session.InMemoryService()
+	if _, err := sessions.Create(t.Context(), &session.CreateRequest{AppName: "app", UserID: "user", SessionID: "session"}); err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	// The content is shared by the runs, so that the calls keep the ID given
+	// by the first one: the blocked call must not complete the record.
+	call := genai.NewContentFromFunctionCall("send_email", map[string]any{"to": "bob@example.com", "subject": "Invoice"}, genai.RoleModel)
+	var sent []emailArgs
+
+	// The first process sends the email, and crashes before persisting the
+	// function response.
+	store, err := outbox.FileStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := newApp(t, &crashingService{sessions}, store, &sent, call)
+	if _, err := first.run(t, "Send the invoice to Bob"); !errors.Is(err, errCrash) {
+		t.Fatalf("run() error = %v, want the crash", err)
+	}
+	if len(sent) != 1 {
+		t.Fatalf("sent %d emails, want 1", len(sent))
+	}
+
+	// After the restart, the model is told about the call, and the identical
+	// call is blocked.
+	store, err = outbox.FileStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second := newApp(t, sessions, store, &sent, call, genai.NewContentFromText("I need to check first.", genai.RoleModel))
+	unreconciled, err := second.outbox.Unreconciled(t.Context(), "app", "user", "session")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(unreconciled) != 1 || unreconciled[0].Tool != "send_email" {
+		t.Fatalf("Unreconciled() = %+v, want the send_email call", unreconciled)
+	}
+	events, err := second.run(t, "Did you send it?")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sent) != 1 {
+		t.Errorf("sent %d emails, want the identical call to be blocked", len(sent))
+	}
+	if instruction := systemInstruction(second.model.Requests[0]); !strings.Contains(instruction, "A previous send_email call") || !strings.Contains(instruction, "verify before retrying") {
+		t.Errorf("system instruction = %q, want the notice of the send_email call", instruction)
+	}
+	responses := functionResponses(events)
+	if len(responses) != 1 || !strings.Contains(responses[0]["error"].(string), "awaits confirmation") {
+		t.Errorf("function responses = %v, want the blocked call error", responses)
+	}
+
+	// A different call is not blocked, and its record is completed once its
+	// response is persisted.
+	third := newApp(t, sessions, store, &sent,
+		genai.NewContentFromFunctionCall("send_email", map[string]any{"to": "alice@example.com", "subject": "Invoice"}, genai.RoleModel),
+		genai.NewContentFromText("Sent to Alice.", genai.RoleModel))
+	if _, err := third.run(t, "Send it to Alice too"); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]emailArgs{{"bob@example.com", "Invoice"}, {"alice@example.com", "Invoice"}}, sent); diff != "" {
+		t.Errorf("sent emails mismatch (-want +got):\n%s", diff)
+	}
+	if records, _ := store.List(t.Context()); len(records) != 1 {
+		t.Errorf("store has %d records, want only the unreconciled one", len(records))
+	}
+
+	// The application checked that the email was sent: once reconciled, the
+	// notice is gone and identical calls are allowed again.
+	if err := third.outbox.Reconcile(t.Context(), unreconciled[0].Key); err != nil {
+		t.Fatal(err)
+	}
+	fourth := newApp(t, sessions, store, &sent, call, genai.NewContentFromText("Sent again.", genai.RoleModel))
+	if _, err := fourth.run(t, "Send it to Bob again"); err != nil {
+		t.Fatal(err)
+	}
+	if instruction := systemInstruction(fourth.model.Requests[0]); strings.Contains(instruction, "send_email") {
+		t.Errorf("system instruction = %q, want no notice after the reconciliation", instruction)
+	}
+	if len(sent) != 3 {
+		t.Errorf("sent %d emails, want the call to be allowed after the reconciliation", len(sent))
+	}
+	if records, _ := store.List(t.Context()); len(records) != 0 {
+		t.Errorf("store has %d records, want none", len(records))
+	}
+}
+
+func TestStores(t *testing.T) {
+	fileStore, err := outbox.FileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, store := range map[string]outbox.Store{"InMemory": outbox.InMemoryStore(), "File": fileStore} {
+		t.Run(name, func(t *testing.T) {
+			ctx := t.Context()
+			r := outbox.Record{Key: outbox.Key{AppName: "app", UserID: "user", SessionID: "session", CallID: "call-1"}, Tool: "send_email", ArgsHash: "abc"}
+			if err := store.Put(ctx, r); err != nil {
+				t.Fatal(err)
+			}
+			got, err := store.List(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff([]outbox.Record{r}, got); diff != "" {
+				t.Errorf("List() mismatch (-want +got):\n%s", diff)
+			}
+			if err := store.Delete(ctx, r.Key); err != nil {
+				t.Fatal(err)
+			}
+			if err := store.Delete(ctx, r.Key); err != nil {
+				t.Errorf("Delete() of a missing record failed: %v", err)
+			}
+			if got, _ := store.List(ctx); len(got) != 0 {
+				t.Errorf("List() after Delete() = %v, want none", got)
+			}
+		})
+	}
+}
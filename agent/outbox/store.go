@@ -0,0 +1,158 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package outbox
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// InMemoryStore returns a Store keeping the records in memory. It does not
+// survive the crashes of the process: it is meant for tests and for the
+// deployments where another process tracks the side effects.
+func InMemoryStore() Store {
+	return &inMemoryStore{records: make(map[Key]Record)}
+}
+
+type inMemoryStore struct {
+	mu      sync.Mutex
+	records map[Key]Record
+}
+
+func (s *inMemoryStore) Put(_ context.Context, r Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records[r.Key] = r
+	return nil
+}
+
+func (s *inMemoryStore) Delete(_ context.Context, key Key) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, key)
+	return nil
+}
+
+func (s *inMemoryStore) List(context.Context) ([]Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	records := make([]Record, 0, len(s.records))
+	for _, r := range s.records {
+		records = append(records, r)
+	}
+	return records, nil
+}
+
+const recordExt = ".json"
+
+// FileStore returns a Store keeping every record in a JSON file of dir,
+// which is created if needed. Records are written to a temporary file which
+// is synced, then renamed, so that a crash never leaves a partial record.
+func FileStore(dir string) (Store, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create the outbox directory: %w", err)
+	}
+	return &fileStore{dir: dir}, nil
+}
+
+type fileStore struct {
+	dir string
+}
+
+func (s *fileStore) path(key Key) string {
+	data, _ := json.Marshal(key)
+	sum := sha256.Sum256(data)
+	return filepath.Join(s.dir, hex.EncodeToString(sum[:16])+recordExt)
+}
+
+func (s *fileStore) Put(_ context.Context, r Record) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(s.dir, "record-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(f.Name(), s.path(r.Key)); err != nil {
+		return err
+	}
+	return syncDir(s.dir)
+}
+
+func (s *fileStore) Delete(_ context.Context, key Key) error {
+	if err := os.Remove(s.path(key)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (s *fileStore) List(context.Context) ([]Record, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	var records []Record
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), recordExt) {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(s.dir, e.Name()))
+		if errors.Is(err, fs.ErrNotExist) {
+			// Deleted concurrently.
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		var r Record
+		if err := json.Unmarshal(data, &r); err != nil {
+			return nil, fmt.Errorf("invalid outbox record %s: %w", e.Name(), err)
+		}
+		records = append(records, r)
+	}
+	return records, nil
+}
+
+// syncDir makes the renames in dir durable.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
@@ -0,0 +1,458 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package plan lets applications review the tool calls of the model before
+// they run.
+//
+// When the ProposePlans field of agent.RunConfig is set, the function calls emitted by
+// the model are not executed: they are captured into a [Plan], whose steps
+// are the calls in order, and the invocation ends with a plan-proposed
+// event carrying the plan, see [FromEvent]. The plan is stored in the
+// session state until the application decides: runner.ApprovePlan runs the
+// approved steps, possibly with edited arguments, and runner.RejectPlan
+// tells the model why the plan was rejected. In both cases the model then
+// gets the function responses and continues.
+//
+// An argument of a step may reference the result of an earlier step of the
+// same plan as ${stepN}, or ${stepN.field} for a field of the result, where
+// N is the position of the step starting at 1. The step depends on the
+// referenced steps: the references are replaced by the results once the
+// referenced steps ran.
+package plan
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/google/jsonschema-go/jsonschema"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/internal/utils"
+	"google.golang.org/adk/session"
+)
+
+const (
+	// StateKeyPrefix prefixes the session state keys holding the plans,
+	// followed by the ID of the plan.
+	StateKeyPrefix = "_adk_plan_"
+	// MetadataKey is the key of the plan in the custom metadata of the
+	// plan-proposed events.
+	MetadataKey = "adk_plan"
+)
+
+// Instruction is added to the requests of the invocations proposing plans.
+const Instruction = "Your tool calls are reviewed before they run. To use the result of an earlier call of the same response" +
+	" as an argument, write ${stepN}, or ${stepN.field} for a field of the result, where N is the position of the call starting at 1."
+
+// ErrNotFound is returned when deciding on an unknown plan.
+var ErrNotFound = errors.New("plan not found")
+
+// Status is the status of a plan.
+type Status string
+
+const (
+	// StatusProposed means that the plan waits for a decision.
+	StatusProposed Status = "proposed"
+	// StatusApproved means that some or all of the steps were approved, and
+	// that the approved steps will run with the next invocation.
+	StatusApproved Status = "approved"
+	// StatusRejected means that the plan was rejected, and that the model
+	// will be told with the next invocation.
+	StatusRejected Status = "rejected"
+	// StatusExecuted means that the decision was applied.
+	StatusExecuted Status = "executed"
+)
+
+// Step is a function call of a plan.
+type Step struct {
+	// ID identifies the step in the plan, e.g. "step1".
+	ID string `json:"id"`
+	// CallID is the ID of the function call.
+	CallID string         `json:"call_id"`
+	Tool   string         `json:"tool"`
+	Args   map[string]any `json:"args"`
+	// DependsOn are the IDs of the steps referenced by the arguments.
+	DependsOn []string `json:"depends_on,omitempty"`
+	// Approved reports whether the step was approved.
+	Approved bool `json:"approved,omitempty"`
+	// Parameters and ParametersJSONSchema are the schema of the arguments
+	// declared by the tool, used to validate the edited arguments.
+	Parameters           *genai.Schema `json:"parameters,omitempty"`
+	ParametersJSONSchema any           `json:"parameters_json_schema,omitempty"`
+}
+
+// Plan is the function calls of a model response, waiting for a decision.
+type Plan struct {
+	ID string `json:"id"`
+	// Agent is the name of the agent that proposed the plan, which runs it.
+	Agent        string  `json:"agent"`
+	InvocationID string  `json:"invocation_id"`
+	Steps        []*Step `json:"steps"`
+	Status       Status  `json:"status"`
+	// Reason is told to the model for a rejected plan or the steps that
+	// were not approved.
+	Reason string `json:"reason,omitempty"`
+}
+
+// Approval is the decision to run some or all of the steps of a plan.
+type Approval struct {
+	// Steps are the IDs of the approved steps. If nil, all the steps are
+	// approved.
+	Steps []string
+	// Args replaces the arguments of the approved steps, by step ID.
+	Args map[string]map[string]any
+	// Reason is told to the model for the steps that were not approved.
+	Reason string
+}
+
+// New returns the plan of the function calls, proposed by agent during the
+// invocation. decls are the declarations of the tools by name.
+func New(id, agent, invocationID string, calls []*genai.FunctionCall, decls map[string]*genai.FunctionDeclaration) *Plan {
+	p := &Plan{ID: id, Agent: agent, InvocationID: invocationID, Status: StatusProposed}
+	for i, call := range calls {
+		step := &Step{ID: "step" + strconv.Itoa(i+1), CallID: call.ID, Tool: call.Name, Args: call.Args}
+		step.DependsOn = references(step.Args, i)
+		if decl := decls[call.Name]; decl != nil {
+			step.Parameters, step.ParametersJSONSchema = decl.Parameters, decl.ParametersJsonSchema
+		}
+		p.Steps = append(p.Steps, step)
+	}
+	return p
+}
+
+// Step returns the step of p with the given ID, or nil.
+func (p *Plan) Step(id string) *Step {
+	for _, step := range p.Steps {
+		if step.ID == id {
+			return step
+		}
+	}
+	return nil
+}
+
+// Approve approves the steps of p selected by a, with their edited
+// arguments. The edited arguments are validated against the schema of
+// their tool, unless they reference other steps: those are validated when
+// the step runs. The steps the approved steps depend on must be approved.
+func (p *Plan) Approve(a Approval) error {
+	if p.Status != StatusProposed {
+		return fmt.Errorf("plan %q is %s, want %s", p.ID, p.Status, StatusProposed)
+	}
+	approved := make(map[string]bool)
+	if a.Steps == nil {
+		for _, step := range p.Steps {
+			approved[step.ID] = true
+		}
+	}
+	for _, id := range a.Steps {
+		if p.Step(id) == nil {
+			return fmt.Errorf("plan %q has no step %q", p.ID, id)
+		}
+		approved[id] = true
+	}
+	edited := make(map[string]map[string]any, len(a.Args))
+	for _, id := range slices.Sorted(maps.Keys(a.Args)) {
+		step := p.Step(id)
+		if step == nil || !approved[id] {
+			return fmt.Errorf("cannot edit the arguments of step %q: it is not an approved step of plan %q", id, p.ID)
+		}
+		args := a.Args[id]
+		if err := step.validate(args); err != nil {
+			return fmt.Errorf("invalid arguments for step %q: %w", id, err)
+		}
+		edited[id] = args
+	}
+	for i, step := range p.Steps {
+		if !approved[step.ID] {
+			continue
+		}
+		deps := step.DependsOn
+		if args, ok := edited[step.ID]; ok {
+			deps = references(args, i)
+		}
+		for _, dep := range deps {
+			if !approved[dep] {
+				return fmt.Errorf("step %q depends on step %q, which is not approved", step.ID, dep)
+			}
+		}
+	}
+	for i, step := range p.Steps {
+		step.Approved = approved[step.ID]
+		if args, ok := edited[step.ID]; ok {
+			step.Args = args
+			step.DependsOn = references(args, i)
+		}
+	}
+	p.Status = StatusApproved
+	p.Reason = a.Reason
+	return nil
+}
+
+// Reject rejects p. The model is told the reason.
+func (p *Plan) Reject(reason string) error {
+	if p.Status != StatusProposed {
+		return fmt.Errorf("plan %q is %s, want %s", p.ID, p.Status, StatusProposed)
+	}
+	p.Status = StatusRejected
+	p.Reason = reason
+	return nil
+}
+
+// SkippedResponse returns the function response telling the model why step
+// was not run.
+func (p *Plan) SkippedResponse(step *Step) map[string]any {
+	msg := "This call was not approved and did not run."
+	if p.Status == StatusRejected {
+		msg = "The plan was rejected and this call did not run."
+	}
+	if p.Reason != "" {
+		msg += " Reason: " + p.Reason
+	}
+	return map[string]any{"error": msg}
+}
+
+// StateDelta returns the state delta storing p.
+func (p *Plan) StateDelta() (map[string]any, error) {
+	var value any
+	if err := convert(p, &value); err != nil {
+		return nil, err
+	}
+	return map[string]any{StateKeyPrefix + p.ID: value}, nil
+}
+
+// Get returns the plan with the given ID stored in state.
+func Get(state session.ReadonlyState, id string) (*Plan, error) {
+	v, err := state.Get(StateKeyPrefix + id)
+	if errors.Is(err, session.ErrStateKeyNotExist) || (err == nil && v == nil) {
+		return nil, fmt.Errorf("%w: %q", ErrNotFound, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var p Plan
+	if err := convert(v, &p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Decided returns the plans of agent stored in state that were approved or
+// rejected, and are not executed yet.
+func Decided(state session.ReadonlyState, agent string) ([]*Plan, error) {
+	if state == nil {
+		return nil, nil
+	}
+	var plans []*Plan
+	for key, v := range state.All() {
+		if !strings.HasPrefix(key, StateKeyPrefix) || v == nil {
+			continue
+		}
+		var p Plan
+		if err := convert(v, &p); err != nil {
+			return nil, err
+		}
+		if p.Agent == agent && (p.Status == StatusApproved || p.Status == StatusRejected) {
+			plans = append(plans, &p)
+		}
+	}
+	slices.SortFunc(plans, func(a, b *Plan) int { return strings.Compare(a.ID, b.ID) })
+	return plans, nil
+}
+
+// FromEvent returns the plan proposed by ev, or nil if ev is not a
+// plan-proposed event.
+func FromEvent(ev *session.Event) *Plan {
+	if ev == nil {
+		return nil
+	}
+	p, _ := ev.CustomMetadata[MetadataKey].(*Plan)
+	return p
+}
+
+// ResolveArgs returns a copy of args where the references to other steps
+// are replaced by their results.
+func ResolveArgs(args map[string]any, results map[string]map[string]any) (map[string]any, error) {
+	resolved, err := resolve(args, results)
+	if err != nil {
+		return nil, err
+	}
+	m, _ := resolved.(map[string]any)
+	return m, nil
+}
+
+// reference matches a reference to the result of a step, and captures the
+// step ID and the field path.
+var reference = regexp.MustCompile(`\$\{(step[0-9]+)((?:\.[A-Za-z0-9_-]+)*)\}`)
+
+func resolve(v any, results map[string]map[string]any) (any, error) {
+	switch v := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, e := range v {
+			r, err := resolve(e, results)
+			if err != nil {
+				return nil, err
+			}
+			out[k] = r
+		}
+		return out, nil
+	case []any:
+		out := make([]any, len(v))
+		for i, e := range v {
+			r, err := resolve(e, results)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = r
+		}
+		return out, nil
+	case string:
+		matches := reference.FindAllStringSubmatchIndex(v, -1)
+		if len(matches) == 1 && matches[0][0] == 0 && matches[0][1] == len(v) {
+			// The whole string is a reference: it is replaced by the value.
+			return lookup(v[matches[0][2]:matches[0][3]], v[matches[0][4]:matches[0][5]], results)
+		}
+		var err error
+		s := reference.ReplaceAllStringFunc(v, func(ref string) string {
+			m := reference.FindStringSubmatch(ref)
+			value, lookupErr := lookup(m[1], m[2], results)
+			if lookupErr != nil {
+				err = lookupErr
+				return ref
+			}
+			if s, ok := value.(string); ok {
+				return s
+			}
+			b, _ := json.Marshal(value)
+			return string(b)
+		})
+		return s, err
+	default:
+		return v, nil
+	}
+}
+
+func lookup(step, path string, results map[string]map[string]any) (any, error) {
+	result, ok := results[step]
+	if !ok {
+		return nil, fmt.Errorf("the result of %s is not available", step)
+	}
+	var value any = result
+	for _, field := range strings.Split(strings.TrimPrefix(path, "."), ".") {
+		if field == "" {
+			continue
+		}
+		m, ok := value.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("the result of %s has no field %q", step, path[1:])
+		}
+		if value, ok = m[field]; !ok {
+			return nil, fmt.Errorf("the result of %s has no field %q", step, path[1:])
+		}
+	}
+	return value, nil
+}
+
+// references returns the IDs of the steps before the i-th step referenced
+// by v, in order.
+func references(v any, i int) []string {
+	var ids []string
+	var walk func(any)
+	walk = func(v any) {
+		switch v := v.(type) {
+		case map[string]any:
+			for _, k := range slices.Sorted(maps.Keys(v)) {
+				walk(v[k])
+			}
+		case []any:
+			for _, e := range v {
+				walk(e)
+			}
+		case string:
+			for _, m := range reference.FindAllStringSubmatch(v, -1) {
+				n, _ := strconv.Atoi(strings.TrimPrefix(m[1], "step"))
+				if n >= 1 && n <= i && !slices.Contains(ids, m[1]) {
+					ids = append(ids, m[1])
+				}
+			}
+		}
+	}
+	walk(v)
+	slices.SortFunc(ids, func(a, b string) int {
+		na, _ := strconv.Atoi(strings.TrimPrefix(a, "step"))
+		nb, _ := strconv.Atoi(strings.TrimPrefix(b, "step"))
+		return na - nb
+	})
+	return ids
+}
+
+// validate validates args against the schema declared by the tool of s.
+// Arguments referencing other steps are not validated.
+func (s *Step) validate(args map[string]any) error {
+	if hasReferences(args) {
+		return nil
+	}
+	if s.ParametersJSONSchema != nil {
+		var schema jsonschema.Schema
+		if err := convert(s.ParametersJSONSchema, &schema); err != nil {
+			return err
+		}
+		resolved, err := schema.Resolve(nil)
+		if err != nil {
+			return err
+		}
+		return resolved.Validate(args)
+	}
+	if s.Parameters != nil {
+		return utils.ValidateMapOnSchema(args, s.Parameters, true)
+	}
+	return nil
+}
+
+func hasReferences(v any) bool {
+	switch v := v.(type) {
+	case map[string]any:
+		for _, e := range v {
+			if hasReferences(e) {
+				return true
+			}
+		}
+	case []any:
+		for _, e := range v {
+			if hasReferences(e) {
+				return true
+			}
+		}
+	case string:
+		return reference.MatchString(v)
+	}
+	return false
+}
+
+func convert(in, out any) error {
+	b, err := json.Marshal(in)
+	if err != nil {
+		return fmt.Errorf("failed to encode plan: %w", err)
+	}
+	if err := json.Unmarshal(b, out); err != nil {
+		return fmt.Errorf("failed to decode plan: %w", err)
+	}
+	return nil
+}
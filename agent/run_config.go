@@ -32,4 +32,8 @@ type RunConfig struct {
 	// If true, ADK runner will save each part of the user input that is a blob
 	// (e.g., images, files) as an artifact.
 	SaveInputBlobsAsArtifacts bool
+	// If true, the function calls of the models are not executed: they are
+	// proposed as a plan that the application approves or rejects, see
+	// package plan.
+	ProposePlans bool
 }
@@ -0,0 +1,526 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifact
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"slices"
+	"sync"
+
+	"google.golang.org/genai"
+)
+
+// WritePolicy controls when [Layered] writes saved artifacts to its primary
+// store.
+type WritePolicy int
+
+const (
+	// WriteThrough writes artifacts to the primary store before Save returns.
+	WriteThrough WritePolicy = iota
+	// WriteBack buffers saved artifacts in memory and writes them to the
+	// primary store on Flush, or when LayeredConfig.MaxPendingSaves is
+	// reached. Buffered artifacts are visible to Load, List and Versions.
+	WriteBack
+)
+
+const defaultMaxPendingSaves = 64
+
+// maxMigratedFiles bounds the number of migrated artifacts remembered by
+// [Layered]. The artifacts it forgets are checked again on their next save
+// or load.
+const maxMigratedFiles = 4096
+
+// LayeredConfig configures [Layered].
+type LayeredConfig struct {
+	// Cache caches the artifact versions loaded and saved through the
+	// service. Optional: if nil, nothing is cached.
+	Cache Cache
+	// WritePolicy controls when saves reach the primary store.
+	WritePolicy WritePolicy
+	// MaxPendingSaves bounds the number of saves buffered by WriteBack.
+	// Defaults to 64.
+	MaxPendingSaves int
+	// Migrate copies the artifacts read from the secondary store to the
+	// primary store.
+	Migrate bool
+}
+
+// LayeredService is the artifact service returned by [Layered].
+type LayeredService interface {
+	Service
+	// Flush writes the saves buffered by the WriteBack policy to the primary
+	// store.
+	Flush(ctx context.Context) error
+}
+
+// Layered returns an artifact service backed by a primary store, an
+// optional secondary store and an optional cache.
+//
+// Loads are served from the cache when possible and populate it on a miss.
+// Artifacts missing from the primary store are read from the secondary
+// store, typically the store being migrated away from. With Migrate set, they
+// are then copied to the primary store with their version numbers. A save to
+// an artifact that only exists in the secondary store copies it first, so
+// that its versions continue where the secondary store left off. Deletes
+// apply to both stores and List merges the artifacts of both stores.
+//
+// The latest version of an artifact is resolved from the stores by every
+// load of the latest version and every save buffered by WriteBack, so that
+// the stores can be shared with other writers. A buffered save numbered
+// before another writer saved a version fails to flush.
+func Layered(primary, secondary Service, cfg LayeredConfig) LayeredService {
+	if cfg.MaxPendingSaves <= 0 {
+		cfg.MaxPendingSaves = defaultMaxPendingSaves
+	}
+	return &layeredService{
+		primary:   primary,
+		secondary: secondary,
+		cfg:       cfg,
+		migrated:  make(map[CacheKey]*list.Element),
+		order:     list.New(),
+	}
+}
+
+type layeredService struct {
+	primary, secondary Service
+	cfg                LayeredConfig
+
+	// writeMu serializes the writes to the stores.
+	writeMu sync.Mutex
+
+	mu sync.Mutex
+	// migrated records the last maxMigratedFiles artifacts found with all
+	// their versions in the primary store, in order.
+	migrated map[CacheKey]*list.Element
+	order    *list.List // of CacheKey, most recent first
+	// pending holds the saves buffered by WriteBack, oldest first.
+	pending []pendingSave
+}
+
+type pendingSave struct {
+	key  CacheKey
+	part *genai.Part
+}
+
+// fileKey returns the key of an artifact, with user scoped artifacts shared
+// by all the sessions.
+func fileKey(appName, userID, sessionID, fileName string) CacheKey {
+	if fileHasUserNamespace(fileName) {
+		sessionID = userScopedArtifactKey
+	}
+	return CacheKey{AppName: appName, UserID: userID, SessionID: sessionID, FileName: fileName}
+}
+
+// Save implements [artifact.Service]
+func (s *layeredService) Save(ctx context.Context, req *SaveRequest) (*SaveResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("request validation failed: %w", err)
+	}
+	key := fileKey(req.AppName, req.UserID, req.SessionID, req.FileName)
+
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	if err := s.copyForward(ctx, key); err != nil {
+		return nil, err
+	}
+
+	if s.cfg.WritePolicy == WriteBack {
+		s.mu.Lock()
+		full := len(s.pending) >= s.cfg.MaxPendingSaves
+		s.mu.Unlock()
+		if full {
+			if err := s.flushLocked(ctx); err != nil {
+				return nil, err
+			}
+		}
+		latest, err := s.latestVersion(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		key.Version = latest + 1
+		s.mu.Lock()
+		s.pending = append(s.pending, pendingSave{key: key, part: req.Part})
+		s.mu.Unlock()
+		return &SaveResponse{Version: key.Version}, nil
+	}
+
+	resp, err := s.primary.Save(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	key.Version = resp.Version
+	if s.cfg.Cache != nil {
+		s.cfg.Cache.Add(key, req.Part)
+	}
+	return resp, nil
+}
+
+// Load implements [artifact.Service]
+func (s *layeredService) Load(ctx context.Context, req *LoadRequest) (*LoadResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("request validation failed: %w", err)
+	}
+	key := fileKey(req.AppName, req.UserID, req.SessionID, req.FileName)
+	key.Version = req.Version
+
+	if key.Version == 0 {
+		if s.cfg.Cache == nil && s.secondary == nil && s.cfg.WritePolicy == WriteThrough {
+			return s.primary.Load(ctx, req)
+		}
+		// Resolve the latest version, so that a cached older version never
+		// shadows a newer one.
+		latest, err := s.latestVersion(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		if latest == 0 {
+			return nil, fmt.Errorf("artifact not found: %w", fs.ErrNotExist)
+		}
+		key.Version = latest
+	}
+
+	if part, ok := s.pendingPart(key); ok {
+		return &LoadResponse{Part: part}, nil
+	}
+	if s.cfg.Cache != nil {
+		if part, ok := s.cfg.Cache.Get(key); ok {
+			return &LoadResponse{Part: part}, nil
+		}
+	}
+	resp, err := s.loadFromStores(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if s.cfg.Cache != nil {
+		s.cfg.Cache.Add(key, resp.Part)
+	}
+	return resp, nil
+}
+
+// loadFromStores loads an artifact version from the primary store, falling
+// back to the secondary store.
+func (s *layeredService) loadFromStores(ctx context.Context, key CacheKey) (*LoadResponse, error) {
+	req := &LoadRequest{AppName: key.AppName, UserID: key.UserID, SessionID: key.SessionID, FileName: key.FileName, Version: key.Version}
+	resp, err := s.primary.Load(ctx, req)
+	if err == nil || s.secondary == nil || !errors.Is(err, fs.ErrNotExist) {
+		return resp, err
+	}
+	resp, err = s.secondary.Load(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if s.cfg.Migrate {
+		s.writeMu.Lock()
+		// A failed copy does not fail the load: the artifact is still in the
+		// secondary store and the copy is retried by the next load.
+		_ = s.copyForward(ctx, key)
+		s.writeMu.Unlock()
+	}
+	return resp, nil
+}
+
+// latestVersion returns the latest version of an artifact in the stores or
+// buffered by WriteBack, or 0 if it does not exist.
+func (s *layeredService) latestVersion(ctx context.Context, key CacheKey) (int64, error) {
+	file := key.file()
+	// The buffered saves are read first: a save flushed meanwhile is then in
+	// the primary store.
+	var latest int64
+	s.mu.Lock()
+	for _, p := range s.pending {
+		if p.key.file() == file {
+			latest = max(latest, p.key.Version)
+		}
+	}
+	s.mu.Unlock()
+
+	versions, err := s.storeVersions(ctx, file)
+	if err != nil {
+		return 0, err
+	}
+	for _, v := range versions {
+		latest = max(latest, v)
+	}
+	return latest, nil
+}
+
+// storeVersions returns the versions of an artifact in both stores.
+func (s *layeredService) storeVersions(ctx context.Context, key CacheKey) ([]int64, error) {
+	versions, err := versionsOf(ctx, s.primary, key)
+	if err != nil {
+		return nil, err
+	}
+	if s.secondary != nil {
+		secondary, err := versionsOf(ctx, s.secondary, key)
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, secondary...)
+	}
+	return versions, nil
+}
+
+// versionsOf returns the versions of an artifact in srv, or none if it does
+// not exist.
+func versionsOf(ctx context.Context, srv Service, key CacheKey) ([]int64, error) {
+	resp, err := srv.Versions(ctx, &VersionsRequest{AppName: key.AppName, UserID: key.UserID, SessionID: key.SessionID, FileName: key.FileName})
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return resp.Versions, nil
+}
+
+// copyForward copies the versions of an artifact from the secondary store to
+// the primary store, unless the primary store already has them. It expects
+// writeMu to be held.
+func (s *layeredService) copyForward(ctx context.Context, key CacheKey) error {
+	if s.secondary == nil {
+		return nil
+	}
+	file := key.file()
+	if s.isMigrated(file) {
+		return nil
+	}
+
+	secondary, err := versionsOf(ctx, s.secondary, file)
+	if err != nil {
+		return err
+	}
+	primary, err := versionsOf(ctx, s.primary, file)
+	if err != nil {
+		return err
+	}
+	slices.Sort(secondary)
+	slices.Sort(primary)
+
+	if len(secondary) > 0 && (len(primary) == 0 || primary[len(primary)-1] < secondary[len(secondary)-1]) {
+		// The stores number versions sequentially, so the version numbers are
+		// kept only if the secondary store has no gaps. The primary store may
+		// hold the first versions already, left by an interrupted copy.
+		for i, v := range secondary {
+			if v != int64(i+1) {
+				return fmt.Errorf("failed to copy artifact %q to the primary store: its versions %v are not sequential", file.FileName, secondary)
+			}
+		}
+		if len(primary) > len(secondary) || !slices.Equal(primary, secondary[:len(primary)]) {
+			return fmt.Errorf("failed to copy artifact %q to the primary store: its versions %v conflict with the versions %v of the secondary store", file.FileName, primary, secondary)
+		}
+		for _, v := range secondary[len(primary):] {
+			resp, err := s.secondary.Load(ctx, &LoadRequest{AppName: file.AppName, UserID: file.UserID, SessionID: file.SessionID, FileName: file.FileName, Version: v})
+			if err != nil {
+				return fmt.Errorf("failed to copy artifact %q to the primary store: %w", file.FileName, err)
+			}
+			saved, err := s.primary.Save(ctx, &SaveRequest{AppName: file.AppName, UserID: file.UserID, SessionID: file.SessionID, FileName: file.FileName, Part: resp.Part})
+			if err != nil {
+				return fmt.Errorf("failed to copy artifact %q to the primary store: %w", file.FileName, err)
+			}
+			if saved.Version != v {
+				return fmt.Errorf("failed to copy artifact %q to the primary store: version %d was saved as version %d", file.FileName, v, saved.Version)
+			}
+		}
+	}
+
+	s.markMigrated(file)
+	return nil
+}
+
+// isMigrated reports whether all the versions of the artifact file were
+// found in the primary store.
+func (s *layeredService) isMigrated(file CacheKey) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	elem, ok := s.migrated[file]
+	if ok {
+		s.order.MoveToFront(elem)
+	}
+	return ok
+}
+
+// markMigrated records that all the versions of the artifact file are in
+// the primary store, forgetting the least recently used artifact beyond
+// maxMigratedFiles.
+func (s *layeredService) markMigrated(file CacheKey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if elem, ok := s.migrated[file]; ok {
+		s.order.MoveToFront(elem)
+		return
+	}
+	s.migrated[file] = s.order.PushFront(file)
+	if s.order.Len() > maxMigratedFiles {
+		delete(s.migrated, s.order.Remove(s.order.Back()).(CacheKey))
+	}
+}
+
+// pendingPart returns the artifact version buffered by WriteBack.
+func (s *layeredService) pendingPart(key CacheKey) (*genai.Part, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, p := range s.pending {
+		if p.key == key {
+			return p.part, true
+		}
+	}
+	return nil, false
+}
+
+// Flush implements [LayeredService].
+func (s *layeredService) Flush(ctx context.Context) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	return s.flushLocked(ctx)
+}
+
+// flushLocked is like Flush, but expects writeMu to be held.
+func (s *layeredService) flushLocked(ctx context.Context) error {
+	for {
+		s.mu.Lock()
+		if len(s.pending) == 0 {
+			s.mu.Unlock()
+			return nil
+		}
+		p := s.pending[0]
+		s.mu.Unlock()
+
+		resp, err := s.primary.Save(ctx, &SaveRequest{AppName: p.key.AppName, UserID: p.key.UserID, SessionID: p.key.SessionID, FileName: p.key.FileName, Part: p.part})
+		if err != nil {
+			return fmt.Errorf("failed to flush artifact %q: %w", p.key.FileName, err)
+		}
+
+		s.mu.Lock()
+		s.pending = s.pending[1:]
+		s.mu.Unlock()
+
+		if resp.Version != p.key.Version {
+			// Another writer changed the primary store: the cached versions
+			// of the artifact may be stale.
+			if s.cfg.Cache != nil {
+				s.cfg.Cache.Remove(p.key.file())
+			}
+			return fmt.Errorf("failed to flush artifact %q: version %d was saved as version %d", p.key.FileName, p.key.Version, resp.Version)
+		}
+		if s.cfg.Cache != nil {
+			s.cfg.Cache.Add(p.key, p.part)
+		}
+	}
+}
+
+// Delete implements [artifact.Service]
+func (s *layeredService) Delete(ctx context.Context, req *DeleteRequest) error {
+	if err := req.Validate(); err != nil {
+		return fmt.Errorf("request validation failed: %w", err)
+	}
+	key := fileKey(req.AppName, req.UserID, req.SessionID, req.FileName)
+	key.Version = req.Version
+
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	if err := s.flushLocked(ctx); err != nil {
+		return err
+	}
+	if err := s.primary.Delete(ctx, req); err != nil {
+		return err
+	}
+	if s.secondary != nil {
+		if err := s.secondary.Delete(ctx, req); err != nil {
+			return err
+		}
+	}
+	if s.cfg.Cache != nil {
+		s.cfg.Cache.Remove(key)
+	}
+
+	if key.Version == 0 {
+		s.mu.Lock()
+		if elem, ok := s.migrated[key.file()]; ok {
+			s.order.Remove(elem)
+			delete(s.migrated, key.file())
+		}
+		s.mu.Unlock()
+	}
+	return nil
+}
+
+// List implements [artifact.Service]
+func (s *layeredService) List(ctx context.Context, req *ListRequest) (*ListResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("request validation failed: %w", err)
+	}
+	files := map[string]bool{}
+	for _, srv := range []Service{s.primary, s.secondary} {
+		if srv == nil {
+			continue
+		}
+		resp, err := srv.List(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range resp.FileNames {
+			files[name] = true
+		}
+	}
+
+	s.mu.Lock()
+	for _, p := range s.pending {
+		if p.key.AppName == req.AppName && p.key.UserID == req.UserID &&
+			(p.key.SessionID == req.SessionID || fileHasUserNamespace(p.key.FileName)) {
+			files[p.key.FileName] = true
+		}
+	}
+	s.mu.Unlock()
+
+	return &ListResponse{FileNames: slices.Sorted(maps.Keys(files))}, nil
+}
+
+// Versions implements [artifact.Service] and returns an error if no versions are found.
+func (s *layeredService) Versions(ctx context.Context, req *VersionsRequest) (*VersionsResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("request validation failed: %w", err)
+	}
+	key := fileKey(req.AppName, req.UserID, req.SessionID, req.FileName)
+
+	versions, err := s.storeVersions(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	for _, p := range s.pending {
+		if p.key.file() == key {
+			versions = append(versions, p.key.Version)
+		}
+	}
+	s.mu.Unlock()
+
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("artifact not found: %w", fs.ErrNotExist)
+	}
+	// Like the stores, list the newest version first.
+	slices.Sort(versions)
+	versions = slices.Compact(versions)
+	slices.Reverse(versions)
+	return &VersionsResponse{Versions: versions}, nil
+}
+
+var _ LayeredService = (*layeredService)(nil)
@@ -0,0 +1,267 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifact_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/artifact"
+	"google.golang.org/adk/internal/artifact/tests"
+)
+
+func TestLayeredArtifactService(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		cfg  artifact.LayeredConfig
+	}{
+		{"WriteThrough", artifact.LayeredConfig{Cache: artifact.NewLRUCache(1 << 20)}},
+		{"WriteBack", artifact.LayeredConfig{Cache: artifact.NewLRUCache(1 << 20), WritePolicy: artifact.WriteBack}},
+		{"SmallCache", artifact.LayeredConfig{Cache: artifact.NewLRUCache(8)}},
+		{"Migrate", artifact.LayeredConfig{Cache: artifact.NewLRUCache(1 << 20), Migrate: true}},
+	} {
+		factory := func(t *testing.T) (artifact.Service, error) {
+			return artifact.Layered(artifact.InMemoryService(), artifact.InMemoryService(), tc.cfg), nil
+		}
+		tests.TestArtifactService(t, "Layered"+tc.name, factory)
+	}
+}
+
+// countingService counts the loads served by the wrapped service.
+type countingService struct {
+	artifact.Service
+	loads atomic.Int64
+}
+
+func (s *countingService) Load(ctx context.Context, req *artifact.LoadRequest) (*artifact.LoadResponse, error) {
+	s.loads.Add(1)
+	return s.Service.Load(ctx, req)
+}
+
+const (
+	appName   = "app"
+	userID    = "user"
+	sessionID = "session"
+)
+
+func save(t *testing.T, srv artifact.Service, fileName, text string) int64 {
+	t.Helper()
+	resp, err := srv.Save(t.Context(), &artifact.SaveRequest{AppName: appName, UserID: userID, SessionID: sessionID, FileName: fileName, Part: genai.NewPartFromText(text)})
+	if err != nil {
+		t.Fatalf("Save(%q) failed: %v", fileName, err)
+	}
+	return resp.Version
+}
+
+func load(t *testing.T, srv artifact.Service, fileName string, version int64) string {
+	t.Helper()
+	resp, err := srv.Load(t.Context(), &artifact.LoadRequest{AppName: appName, UserID: userID, SessionID: sessionID, FileName: fileName, Version: version})
+	if err != nil {
+		t.Fatalf("Load(%q, %d) failed: %v", fileName, version, err)
+	}
+	return resp.Part.Text
+}
+
+func TestLayered_ReadThrough(t *testing.T) {
+	primary := &countingService{Service: artifact.InMemoryService()}
+	save(t, primary, "report", "v1")
+	save(t, primary, "report", "v2")
+
+	srv := artifact.Layered(primary, nil, artifact.LayeredConfig{Cache: artifact.NewLRUCache(1 << 20)})
+	for range 3 {
+		if got := load(t, srv, "report", 0); got != "v2" {
+			t.Errorf("Load(latest) = %q, want %q", got, "v2")
+		}
+		if got := load(t, srv, "report", 1); got != "v1" {
+			t.Errorf("Load(1) = %q, want %q", got, "v1")
+		}
+	}
+	if got := primary.loads.Load(); got != 2 {
+		t.Errorf("primary served %d loads, want 2", got)
+	}
+
+	// A new save must not be shadowed by the cached older version.
+	if got := save(t, srv, "report", "v3"); got != 3 {
+		t.Errorf("Save() version = %d, want 3", got)
+	}
+	if got := load(t, srv, "report", 0); got != "v3" {
+		t.Errorf("Load(latest) after save = %q, want %q", got, "v3")
+	}
+	if got := primary.loads.Load(); got != 2 {
+		t.Errorf("primary served %d loads after save, want 2", got)
+	}
+
+	// Deleting the latest version makes the previous one the latest.
+	if err := srv.Delete(t.Context(), &artifact.DeleteRequest{AppName: appName, UserID: userID, SessionID: sessionID, FileName: "report", Version: 3}); err != nil {
+		t.Fatal(err)
+	}
+	if got := load(t, srv, "report", 0); got != "v2" {
+		t.Errorf("Load(latest) after delete = %q, want %q", got, "v2")
+	}
+}
+
+func TestLayered_WriteBack(t *testing.T) {
+	primary := artifact.InMemoryService()
+	save(t, primary, "report", "v1")
+
+	srv := artifact.Layered(primary, nil, artifact.LayeredConfig{Cache: artifact.NewLRUCache(1 << 20), WritePolicy: artifact.WriteBack})
+	if got := save(t, srv, "report", "v2"); got != 2 {
+		t.Errorf("Save() version = %d, want 2", got)
+	}
+	if got := load(t, srv, "report", 0); got != "v2" {
+		t.Errorf("Load(latest) = %q, want %q", got, "v2")
+	}
+	if got := load(t, primary, "report", 0); got != "v1" {
+		t.Errorf("primary Load(latest) before flush = %q, want %q", got, "v1")
+	}
+	if err := srv.Flush(t.Context()); err != nil {
+		t.Fatalf("Flush() failed: %v", err)
+	}
+	if got := load(t, primary, "report", 0); got != "v2" {
+		t.Errorf("primary Load(latest) after flush = %q, want %q", got, "v2")
+	}
+}
+
+func TestLayered_OtherWriter(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		cfg  artifact.LayeredConfig
+	}{
+		{"WriteThrough", artifact.LayeredConfig{Cache: artifact.NewLRUCache(1 << 20)}},
+		{"WriteBack", artifact.LayeredConfig{Cache: artifact.NewLRUCache(1 << 20), WritePolicy: artifact.WriteBack}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			primary := artifact.InMemoryService()
+			srv := artifact.Layered(primary, nil, tc.cfg)
+			save(t, srv, "report", "v1")
+			if err := srv.Flush(t.Context()); err != nil {
+				t.Fatal(err)
+			}
+			if got := load(t, srv, "report", 0); got != "v1" {
+				t.Errorf("Load(latest) = %q, want %q", got, "v1")
+			}
+
+			// Another process saves a version to the primary store.
+			save(t, primary, "report", "v2")
+			if got := load(t, srv, "report", 0); got != "v2" {
+				t.Errorf("Load(latest) after another save = %q, want %q", got, "v2")
+			}
+			if got := save(t, srv, "report", "v3"); got != 3 {
+				t.Errorf("Save() version = %d, want 3", got)
+			}
+			if err := srv.Flush(t.Context()); err != nil {
+				t.Fatal(err)
+			}
+			if got := load(t, primary, "report", 2); got != "v2" {
+				t.Errorf("primary Load(2) = %q, want %q", got, "v2")
+			}
+		})
+	}
+}
+
+func TestLayered_Migrate(t *testing.T) {
+	primary := artifact.InMemoryService()
+	secondary := &countingService{Service: artifact.InMemoryService()}
+	save(t, secondary, "old", "old v1")
+	save(t, secondary, "old", "old v2")
+	save(t, secondary, "other", "other v1")
+
+	srv := artifact.Layered(primary, secondary, artifact.LayeredConfig{Cache: artifact.NewLRUCache(1 << 20), Migrate: true})
+	save(t, srv, "new", "new v1")
+
+	if got := load(t, srv, "old", 0); got != "old v2" {
+		t.Errorf("Load(latest) = %q, want %q", got, "old v2")
+	}
+	// The read copied all the versions forward.
+	for version, want := range map[int64]string{1: "old v1", 2: "old v2"} {
+		if got := load(t, primary, "old", version); got != want {
+			t.Errorf("primary Load(%d) = %q, want %q", version, got, want)
+		}
+	}
+
+	// Saves continue the version numbers of the secondary store.
+	if got := save(t, srv, "other", "other v2"); got != 2 {
+		t.Errorf("Save() version = %d, want 2", got)
+	}
+	if got := load(t, primary, "other", 1); got != "other v1" {
+		t.Errorf("primary Load(1) = %q, want %q", got, "other v1")
+	}
+
+	loads := secondary.loads.Load()
+	if got := load(t, srv, "old", 1); got != "old v1" {
+		t.Errorf("Load(1) = %q, want %q", got, "old v1")
+	}
+	if got := secondary.loads.Load(); got != loads {
+		t.Errorf("secondary served %d loads after the copy, want none", got-loads)
+	}
+}
+
+func TestLayered_List(t *testing.T) {
+	primary := artifact.InMemoryService()
+	secondary := artifact.InMemoryService()
+	save(t, primary, "a", "primary")
+	save(t, primary, "shared", "primary")
+	save(t, secondary, "shared", "secondary")
+	save(t, secondary, "user:profile", "secondary")
+
+	srv := artifact.Layered(primary, secondary, artifact.LayeredConfig{WritePolicy: artifact.WriteBack})
+	save(t, srv, "pending", "buffered")
+
+	resp, err := srv.List(t.Context(), &artifact.ListRequest{AppName: appName, UserID: userID, SessionID: sessionID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"a", "pending", "shared", "user:profile"}
+	if diff := cmp.Diff(want, resp.FileNames); diff != "" {
+		t.Errorf("List() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestLRUCache(t *testing.T) {
+	key := func(fileName string, version int64) artifact.CacheKey {
+		return artifact.CacheKey{AppName: appName, UserID: userID, SessionID: sessionID, FileName: fileName, Version: version}
+	}
+	cache := artifact.NewLRUCache(10)
+	cache.Add(key("a", 1), genai.NewPartFromText("aaaa"))
+	cache.Add(key("a", 2), genai.NewPartFromText("aaaa"))
+	cache.Get(key("a", 1))
+	// Exceeds the bound: evicts the least recently used version.
+	cache.Add(key("b", 1), genai.NewPartFromText("bbbb"))
+	// Larger than the bound: not cached.
+	cache.Add(key("c", 1), genai.NewPartFromText("ccccccccccc"))
+
+	for _, tc := range []struct {
+		key  artifact.CacheKey
+		want bool
+	}{
+		{key("a", 1), true},
+		{key("a", 2), false},
+		{key("b", 1), true},
+		{key("c", 1), false},
+	} {
+		if _, got := cache.Get(tc.key); got != tc.want {
+			t.Errorf("Get(%v) found = %v, want %v", tc.key, got, tc.want)
+		}
+	}
+
+	cache.Remove(key("a", 0))
+	if _, ok := cache.Get(key("a", 1)); ok {
+		t.Errorf("Get(%v) found a removed version", key("a", 1))
+	}
+}
@@ -0,0 +1,160 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifact
+
+import (
+	"container/list"
+	"sync"
+
+	"google.golang.org/genai"
+)
+
+// CacheKey identifies a version of an artifact in a [Cache].
+type CacheKey struct {
+	AppName, UserID, SessionID, FileName string
+	Version                              int64
+}
+
+// file returns the key without its version.
+func (k CacheKey) file() CacheKey {
+	k.Version = 0
+	return k
+}
+
+// Cache caches artifact versions. It is used as the caching layer of
+// [Layered]. Implementations must be safe for concurrent use.
+type Cache interface {
+	// Get returns the cached artifact version.
+	Get(key CacheKey) (*genai.Part, bool)
+	// Add caches an artifact version.
+	Add(key CacheKey, part *genai.Part)
+	// Remove removes an artifact version from the cache. If key.Version is
+	// 0, all the versions of the artifact are removed.
+	Remove(key CacheKey)
+}
+
+type lruEntry struct {
+	key  CacheKey
+	part *genai.Part
+	size int64
+}
+
+// lruCache is an in-memory Cache that evicts the least recently used
+// versions once the total size of the cached artifacts exceeds a bound.
+type lruCache struct {
+	mu       sync.Mutex
+	maxBytes int64
+	size     int64
+	order    *list.List // of *lruEntry, most recently used first
+	entries  map[CacheKey]*list.Element
+	// files indexes the cached versions of every artifact.
+	files map[CacheKey]map[int64]*list.Element
+}
+
+// NewLRUCache returns an in-memory [Cache] holding up to maxBytes of
+// artifact data. Artifacts larger than maxBytes are not cached.
+func NewLRUCache(maxBytes int64) Cache {
+	return &lruCache{
+		maxBytes: maxBytes,
+		order:    list.New(),
+		entries:  make(map[CacheKey]*list.Element),
+		files:    make(map[CacheKey]map[int64]*list.Element),
+	}
+}
+
+// Get implements [Cache].
+func (c *lruCache) Get(key CacheKey) (*genai.Part, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return elem.Value.(*lruEntry).part, true
+}
+
+// Add implements [Cache].
+func (c *lruCache) Add(key CacheKey, part *genai.Part) {
+	size := partSize(part)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		c.removeElement(elem)
+	}
+	if size > c.maxBytes {
+		return
+	}
+	elem := c.order.PushFront(&lruEntry{key: key, part: part, size: size})
+	c.entries[key] = elem
+	versions, ok := c.files[key.file()]
+	if !ok {
+		versions = make(map[int64]*list.Element)
+		c.files[key.file()] = versions
+	}
+	versions[key.Version] = elem
+	c.size += size
+
+	for c.size > c.maxBytes {
+		c.removeElement(c.order.Back())
+	}
+}
+
+// Remove implements [Cache].
+func (c *lruCache) Remove(key CacheKey) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if key.Version != 0 {
+		if elem, ok := c.entries[key]; ok {
+			c.removeElement(elem)
+		}
+		return
+	}
+	for _, elem := range c.files[key] {
+		c.removeElement(elem)
+	}
+}
+
+func (c *lruCache) removeElement(elem *list.Element) {
+	entry := c.order.Remove(elem).(*lruEntry)
+	delete(c.entries, entry.key)
+	versions := c.files[entry.key.file()]
+	delete(versions, entry.key.Version)
+	if len(versions) == 0 {
+		delete(c.files, entry.key.file())
+	}
+	c.size -= entry.size
+}
+
+// partSize estimates the memory held by an artifact.
+func partSize(part *genai.Part) int64 {
+	if part == nil {
+		return 0
+	}
+	size := int64(len(part.Text))
+	if part.InlineData != nil {
+		size += int64(len(part.InlineData.Data) + len(part.InlineData.MIMEType) + len(part.InlineData.DisplayName))
+	}
+	if part.FileData != nil {
+		size += int64(len(part.FileData.FileURI) + len(part.FileData.MIMEType) + len(part.FileData.DisplayName))
+	}
+	return size
+}
+
+var _ Cache = (*lruCache)(nil)
@@ -0,0 +1,153 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runnercli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"google.golang.org/adk/session"
+)
+
+const redacted = "[REDACTED]"
+
+// DefaultRedactFields are the fields redacted when [Options.RedactFields] is
+// not set.
+var DefaultRedactFields = []string{"password", "secret", "token", "access_token", "refresh_token", "api_key", "apikey", "authorization"}
+
+// renderer renders the events of the runs as text. It keeps no other state
+// than the progress of the streamed response, so that it can be driven by
+// events alone.
+type renderer struct {
+	w      io.Writer
+	redact map[string]bool
+	// streaming reports whether the text of a partial response was written
+	// and not yet terminated.
+	streaming bool
+}
+
+func newRenderer(w io.Writer, redactFields []string) *renderer {
+	redact := make(map[string]bool, len(redactFields))
+	for _, f := range redactFields {
+		redact[strings.ToLower(f)] = true
+	}
+	return &renderer{w: w, redact: redact}
+}
+
+// event renders ev. The text of the partial events is written as it comes;
+// the final event repeating it only terminates the line.
+func (r *renderer) event(ev *session.Event) {
+	if ev.ErrorCode != "" || ev.ErrorMessage != "" {
+		r.endStream()
+		fmt.Fprintf(r.w, "[error] %s: %s\n", ev.ErrorCode, ev.ErrorMessage)
+	}
+	if ev.Content == nil {
+		return
+	}
+	var text strings.Builder
+	for _, p := range ev.Content.Parts {
+		if p != nil && p.Text != "" && !p.Thought {
+			text.WriteString(p.Text)
+		}
+	}
+	if ev.Partial {
+		if text.Len() > 0 {
+			if !r.streaming {
+				fmt.Fprintf(r.w, "%s: ", ev.Author)
+				r.streaming = true
+			}
+			io.WriteString(r.w, text.String())
+		}
+		return
+	}
+	if r.streaming {
+		r.endStream()
+	} else if text.Len() > 0 {
+		fmt.Fprintf(r.w, "%s: %s\n", ev.Author, text.String())
+	}
+	for _, p := range ev.Content.Parts {
+		switch {
+		case p == nil:
+		case p.FunctionCall != nil:
+			fmt.Fprintf(r.w, "[call] %s %s\n", p.FunctionCall.Name, r.json(p.FunctionCall.Args))
+		case p.FunctionResponse != nil:
+			fmt.Fprintf(r.w, "[result] %s %s\n", p.FunctionResponse.Name, r.json(p.FunctionResponse.Response))
+		}
+	}
+}
+
+// endStream terminates the line of a streamed response.
+func (r *renderer) endStream() {
+	if r.streaming {
+		io.WriteString(r.w, "\n")
+		r.streaming = false
+	}
+}
+
+// json returns the JSON encoding of m with the redacted fields.
+func (r *renderer) json(m map[string]any) string {
+	b, err := json.Marshal(r.redactMap(m))
+	if err != nil {
+		return fmt.Sprintf("%v", m)
+	}
+	return string(b)
+}
+
+func (r *renderer) redactMap(m map[string]any) map[string]any {
+	if m == nil || len(r.redact) == 0 {
+		return m
+	}
+	out := make(map[string]any, len(m))
+	for k, v := range m {
+		if r.redact[strings.ToLower(k)] {
+			out[k] = redacted
+		} else {
+			out[k] = r.redactValue(v)
+		}
+	}
+	return out
+}
+
+func (r *renderer) redactValue(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		return r.redactMap(v)
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = r.redactValue(item)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// finalText returns the text of ev if it is a final response, and false
+// otherwise.
+func finalText(ev *session.Event) (string, bool) {
+	if ev.Partial || !ev.IsFinalResponse() || ev.Content == nil {
+		return "", false
+	}
+	var text strings.Builder
+	for _, p := range ev.Content.Parts {
+		if p != nil && !p.Thought {
+			text.WriteString(p.Text)
+		}
+	}
+	return text.String(), true
+}
@@ -0,0 +1,375 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package runnercli provides helpers to run an agent from the command line:
+// an interactive REPL and a one-shot invocation for scripts.
+//
+// A local main.go poking at an agent boils down to:
+//
+//	r, _ := runner.New(runner.Config{AppName: "app", Agent: a, SessionService: svc})
+//	runnercli.Main(ctx, r, runnercli.Options{AppName: "app", SessionService: svc})
+//
+// Main starts the REPL, or runs a single prompt given with -prompt, as
+// arguments or on the standard input.
+package runnercli
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/artifact"
+	"google.golang.org/adk/runner"
+	"google.golang.org/adk/session"
+)
+
+// Options configures [Interactive], [Once] and [Main].
+type Options struct {
+	// AppName is the name of the application of the runner. Defaults to
+	// "cli".
+	AppName string
+	// UserID is the ID of the user sending the prompts. Defaults to
+	// "cli_user".
+	UserID string
+	// SessionID is the ID of the session the prompts are sent in. It is
+	// created if it does not exist. Optional: a new session is created by
+	// default.
+	SessionID string
+	// SessionService is the session service of the runner.
+	SessionService session.Service
+	// ArtifactService is the artifact service of the runner, if any. It is
+	// used by the /artifacts command.
+	ArtifactService artifact.Service
+
+	// In is the input. Defaults to os.Stdin.
+	In io.Reader
+	// Out is the output. Defaults to os.Stdout.
+	Out io.Writer
+	// Err is the output of the errors of Main. Defaults to os.Stderr.
+	Err io.Writer
+
+	// Prompt is written before every input of the REPL. Defaults to "> ".
+	Prompt string
+	// StreamingMode is the streaming mode of the REPL. Defaults to
+	// agent.StreamingModeSSE, so that the model output is written token by
+	// token. Once does not stream.
+	StreamingMode agent.StreamingMode
+	// RedactFields are the names of the fields, at any depth and in any
+	// case, whose values are replaced by "[REDACTED]" in the rendered tool
+	// calls, tool results and state. Defaults to DefaultRedactFields.
+	RedactFields []string
+	// JSON makes Once write the events as JSON lines instead of the final
+	// text.
+	JSON bool
+}
+
+func (o Options) withDefaults() Options {
+	if o.AppName == "" {
+		o.AppName = "cli"
+	}
+	if o.UserID == "" {
+		o.UserID = "cli_user"
+	}
+	if o.In == nil {
+		o.In = os.Stdin
+	}
+	if o.Out == nil {
+		o.Out = os.Stdout
+	}
+	if o.Err == nil {
+		o.Err = os.Stderr
+	}
+	if o.Prompt == "" {
+		o.Prompt = "> "
+	}
+	if o.StreamingMode == "" {
+		o.StreamingMode = agent.StreamingModeSSE
+	}
+	if o.RedactFields == nil {
+		o.RedactFields = DefaultRedactFields
+	}
+	return o
+}
+
+// session returns the ID of the session of opts, creating it if needed.
+func (o Options) session(ctx context.Context) (string, error) {
+	if o.SessionService == nil {
+		return "", errors.New("session service is required")
+	}
+	if o.SessionID != "" {
+		_, err := o.SessionService.Get(ctx, &session.GetRequest{AppName: o.AppName, UserID: o.UserID, SessionID: o.SessionID})
+		if err == nil {
+			return o.SessionID, nil
+		}
+	}
+	resp, err := o.SessionService.Create(ctx, &session.CreateRequest{AppName: o.AppName, UserID: o.UserID, SessionID: o.SessionID})
+	if err != nil {
+		return "", fmt.Errorf("failed to create session: %w", err)
+	}
+	return resp.Session.ID(), nil
+}
+
+// Once sends prompt to the agent of r and writes the text of its final
+// response to opts.Out, or the events as JSON lines if opts.JSON is set.
+// It returns an error if the run fails or an event reports an error.
+func Once(ctx context.Context, r *runner.Runner, prompt string, opts Options) error {
+	opts = opts.withDefaults()
+	sessionID, err := opts.session(ctx)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(opts.Out)
+	var final string
+	var failure error
+	msg := genai.NewContentFromText(prompt, genai.RoleUser)
+	for ev, err := range r.Run(ctx, opts.UserID, sessionID, msg, agent.RunConfig{StreamingMode: agent.StreamingModeNone}) {
+		if err != nil {
+			return err
+		}
+		if opts.JSON {
+			if err := enc.Encode(ev); err != nil {
+				return err
+			}
+		}
+		if (ev.ErrorCode != "" || ev.ErrorMessage != "") && failure == nil {
+			failure = fmt.Errorf("agent %q failed: %s: %s", ev.Author, ev.ErrorCode, ev.ErrorMessage)
+		}
+		if text, ok := finalText(ev); ok && text != "" {
+			final = text
+		}
+	}
+	if !opts.JSON && (final != "" || failure == nil) {
+		if _, err := fmt.Fprintln(opts.Out, final); err != nil {
+			return err
+		}
+	}
+	return failure
+}
+
+// Interactive runs a REPL sending every line read from opts.In to the agent
+// of r, in the same session, and rendering the events to opts.Out: the model
+// output as it is streamed, the tool calls and their results. It returns
+// when the input ends or on /quit.
+//
+// The lines starting with a slash are commands:
+//
+//	/state         writes the state of the session
+//	/artifacts     lists the artifacts of the session
+//	/save <path>   saves the transcript of the REPL to a file
+//	/help          lists the commands
+//	/quit          ends the REPL
+func Interactive(ctx context.Context, r *runner.Runner, opts Options) error {
+	opts = opts.withDefaults()
+	sessionID, err := opts.session(ctx)
+	if err != nil {
+		return err
+	}
+	repl := &repl{runner: r, opts: opts, sessionID: sessionID}
+	repl.out = io.MultiWriter(opts.Out, &repl.transcript)
+	repl.render = newRenderer(repl.out, opts.RedactFields)
+
+	scanner := bufio.NewScanner(opts.In)
+	scanner.Buffer(nil, 1<<20)
+	for ctx.Err() == nil {
+		io.WriteString(opts.Out, opts.Prompt)
+		if !scanner.Scan() {
+			io.WriteString(opts.Out, "\n")
+			return scanner.Err()
+		}
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		fmt.Fprintf(&repl.transcript, "%s%s\n", opts.Prompt, line)
+		if strings.HasPrefix(line, "/") {
+			if repl.command(ctx, line) {
+				return nil
+			}
+			continue
+		}
+		repl.send(ctx, line)
+	}
+	return ctx.Err()
+}
+
+// repl is the state of an Interactive session.
+type repl struct {
+	runner    *runner.Runner
+	opts      Options
+	sessionID string
+
+	// out writes to the output and to the transcript.
+	out        io.Writer
+	transcript strings.Builder
+	render     *renderer
+}
+
+// send sends text to the agent and renders the events. The errors are
+// rendered: they do not end the REPL.
+func (r *repl) send(ctx context.Context, text string) {
+	msg := genai.NewContentFromText(text, genai.RoleUser)
+	for ev, err := range r.runner.Run(ctx, r.opts.UserID, r.sessionID, msg, agent.RunConfig{StreamingMode: r.opts.StreamingMode}) {
+		if err != nil {
+			r.render.endStream()
+			fmt.Fprintf(r.out, "[error] %v\n", err)
+			break
+		}
+		r.render.event(ev)
+	}
+	r.render.endStream()
+}
+
+// command runs a slash command and reports whether the REPL must end.
+func (r *repl) command(ctx context.Context, line string) bool {
+	name, arg, _ := strings.Cut(line, " ")
+	arg = strings.TrimSpace(arg)
+	var err error
+	switch name {
+	case "/quit", "/exit":
+		return true
+	case "/help":
+		io.WriteString(r.out, "commands: /state, /artifacts, /save <path>, /help, /quit\n")
+	case "/state":
+		err = r.state(ctx)
+	case "/artifacts":
+		err = r.artifacts(ctx)
+	case "/save":
+		err = r.save(arg)
+	default:
+		err = fmt.Errorf("unknown command %s, see /help", name)
+	}
+	if err != nil {
+		fmt.Fprintf(r.out, "[error] %v\n", err)
+	}
+	return false
+}
+
+func (r *repl) state(ctx context.Context) error {
+	resp, err := r.opts.SessionService.Get(ctx, &session.GetRequest{AppName: r.opts.AppName, UserID: r.opts.UserID, SessionID: r.sessionID})
+	if err != nil {
+		return err
+	}
+	state := make(map[string]any)
+	for k, v := range resp.Session.State().All() {
+		state[k] = v
+	}
+	b, err := json.MarshalIndent(r.render.redactMap(state), "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(r.out, "%s\n", b)
+	return nil
+}
+
+func (r *repl) artifacts(ctx context.Context) error {
+	if r.opts.ArtifactService == nil {
+		return errors.New("no artifact service")
+	}
+	resp, err := r.opts.ArtifactService.List(ctx, &artifact.ListRequest{AppName: r.opts.AppName, UserID: r.opts.UserID, SessionID: r.sessionID})
+	if err != nil {
+		return err
+	}
+	if len(resp.FileNames) == 0 {
+		io.WriteString(r.out, "no artifacts\n")
+		return nil
+	}
+	names := slices.Sorted(slices.Values(resp.FileNames))
+	for _, name := range names {
+		fmt.Fprintf(r.out, "%s\n", name)
+	}
+	return nil
+}
+
+func (r *repl) save(path string) error {
+	if path == "" {
+		return errors.New("usage: /save <path>")
+	}
+	if err := os.WriteFile(path, []byte(r.transcript.String()), 0o644); err != nil {
+		return err
+	}
+	fmt.Fprintf(r.opts.Out, "transcript saved to %s\n", path)
+	return nil
+}
+
+// Main runs the command line of an agent and exits: see [Run].
+func Main(ctx context.Context, r *runner.Runner, opts Options) {
+	os.Exit(Run(ctx, r, os.Args[1:], opts))
+}
+
+// Run parses the command-line arguments args and runs the agent of r
+// accordingly, returning the exit code: 0 on success, 1 if the run failed
+// and 2 for invalid arguments. The flags are:
+//
+//	-prompt <text>  runs the prompt with Once rather than the REPL
+//	-json           writes the events of Once as JSON lines
+//	-session <id>   the ID of the session to use
+//	-user <id>      the ID of the user
+//
+// The arguments left after the flags are also a prompt. Without a prompt,
+// a piped input is read as the prompt; a terminal starts the REPL.
+func Run(ctx context.Context, r *runner.Runner, args []string, opts Options) int {
+	opts = opts.withDefaults()
+	fs := flag.NewFlagSet("runnercli", flag.ContinueOnError)
+	fs.SetOutput(opts.Err)
+	prompt := fs.String("prompt", "", "runs a single prompt rather than the REPL")
+	fs.BoolVar(&opts.JSON, "json", opts.JSON, "writes the events of a single prompt as JSON lines")
+	fs.StringVar(&opts.SessionID, "session", opts.SessionID, "the ID of the session to use")
+	fs.StringVar(&opts.UserID, "user", opts.UserID, "the ID of the user")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *prompt == "" {
+		*prompt = strings.Join(fs.Args(), " ")
+	}
+	if *prompt == "" && !isTerminal(opts.In) {
+		b, err := io.ReadAll(opts.In)
+		if err != nil {
+			fmt.Fprintf(opts.Err, "failed to read the input: %v\n", err)
+			return 1
+		}
+		*prompt = strings.TrimSpace(string(b))
+	}
+	var err error
+	if *prompt != "" {
+		err = Once(ctx, r, *prompt, opts)
+	} else {
+		err = Interactive(ctx, r, opts)
+	}
+	if err != nil {
+		fmt.Fprintf(opts.Err, "error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// isTerminal reports whether in is a terminal, rather than a pipe or a
+// file.
+func isTerminal(in io.Reader) bool {
+	f, ok := in.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,281 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runnercli_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"iter"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/artifact"
+	"google.golang.org/adk/cmd/runnercli"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/runner"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+)
+
+// scriptedModel yields the responses of a turn for every call.
+type scriptedModel struct {
+	turns [][]*model.LLMResponse
+}
+
+func (m *scriptedModel) Name() string { return "scripted" }
+
+func (m *scriptedModel) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {
+		if len(m.turns) == 0 {
+			yield(nil, errors.New("no more turns"))
+			return
+		}
+		turn := m.turns[0]
+		m.turns = m.turns[1:]
+		for _, resp := range turn {
+			if !yield(resp, nil) {
+				return
+			}
+		}
+	}
+}
+
+func text(s string, partial bool) *model.LLMResponse {
+	return &model.LLMResponse{Content: genai.NewContentFromText(s, genai.RoleModel), Partial: partial}
+}
+
+type loginArgs struct {
+	User     string `json:"user"`
+	Password string `json:"password"`
+}
+
+type fixture struct {
+	runner    *runner.Runner
+	sessions  session.Service
+	artifacts artifact.Service
+}
+
+func newFixture(t *testing.T, turns ...[]*model.LLMResponse) *fixture {
+	t.Helper()
+	login, err := functiontool.New(functiontool.Config{Name: "login", Description: "logs in"}, func(ctx tool.Context, args loginArgs) (map[string]any, error) {
+		if err := ctx.State().Set("user", args.User); err != nil {
+			return nil, err
+		}
+		if err := ctx.State().Set("api_key", "k-123"); err != nil {
+			return nil, err
+		}
+		return map[string]any{"ok": true, "token": "t-456"}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := llmagent.New(llmagent.Config{Name: "assistant", Model: &scriptedModel{turns: turns}, Tools: []tool.Tool{login}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fixture{sessions: session.InMemoryService(), artifacts: artifact.InMemoryService()}
+	f.runner, err = runner.New(runner.Config{AppName: "app", Agent: a, SessionService: f.sessions, ArtifactService: f.artifacts})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+func (f *fixture) options(in string, out *strings.Builder) runnercli.Options {
+	return runnercli.Options{
+		AppName:         "app",
+		UserID:          "user",
+		SessionID:       "s1",
+		SessionService:  f.sessions,
+		ArtifactService: f.artifacts,
+		In:              strings.NewReader(in),
+		Out:             out,
+		Err:             out,
+	}
+}
+
+func TestInteractive(t *testing.T) {
+	loginCall := &model.LLMResponse{Content: genai.NewContentFromFunctionCall("login", map[string]any{"user": "alice", "password": "hunter2"}, genai.RoleModel)}
+	f := newFixture(t,
+		[]*model.LLMResponse{text("Checking.", false), loginCall},
+		[]*model.LLMResponse{text("Logged ", true), text("in.", true), text("Logged in.", false)},
+	)
+	_, err := f.artifacts.Save(t.Context(), &artifact.SaveRequest{AppName: "app", UserID: "user", SessionID: "s1", FileName: "notes.txt", Part: genai.NewPartFromText("notes")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "transcript.txt")
+	script := strings.Join([]string{"log me in", "", "/state", "/artifacts", "/nope", "/save " + path, "/quit", "ignored"}, "\n")
+
+	var out strings.Builder
+	if err := runnercli.Interactive(t.Context(), f.runner, f.options(script, &out)); err != nil {
+		t.Fatal(err)
+	}
+
+	transcript := `> log me in
+assistant: Checking.
+[call] login {"password":"[REDACTED]","user":"alice"}
+[result] login {"ok":true,"token":"[REDACTED]"}
+assistant: Logged in.
+> /state
+{
+  "api_key": "[REDACTED]",
+  "user": "alice"
+}
+> /artifacts
+notes.txt
+> /nope
+[error] unknown command /nope, see /help
+> /save ` + path + `
+`
+	saved, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(transcript, string(saved)); diff != "" {
+		t.Errorf("saved transcript mismatch (-want +got):\n%s", diff)
+	}
+	// The output has the prompts without the input, written by the
+	// terminal.
+	want := `> assistant: Checking.
+[call] login {"password":"[REDACTED]","user":"alice"}
+[result] login {"ok":true,"token":"[REDACTED]"}
+assistant: Logged in.
+> > {
+  "api_key": "[REDACTED]",
+  "user": "alice"
+}
+> notes.txt
+> [error] unknown command /nope, see /help
+> transcript saved to ` + path + `
+> `
+	if diff := cmp.Diff(want, out.String()); diff != "" {
+		t.Errorf("output mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestInteractive_ModelError(t *testing.T) {
+	f := newFixture(t, []*model.LLMResponse{{ErrorCode: string(model.ErrorCodeRateLimited), ErrorMessage: "slow down"}})
+	var out strings.Builder
+	if err := runnercli.Interactive(t.Context(), f.runner, f.options("hi\nhi again\n", &out)); err != nil {
+		t.Fatal(err)
+	}
+	want := "> [error] RATE_LIMITED: slow down\n> [error] no more turns\n> \n"
+	if diff := cmp.Diff(want, out.String()); diff != "" {
+		t.Errorf("output mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestOnce(t *testing.T) {
+	f := newFixture(t, []*model.LLMResponse{text("Hello!", false)})
+	var out strings.Builder
+	if err := runnercli.Once(t.Context(), f.runner, "hi", f.options("", &out)); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff("Hello!\n", out.String()); diff != "" {
+		t.Errorf("output mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestRun(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		args     []string
+		in       string
+		turns    [][]*model.LLMResponse
+		wantCode int
+		wantOut  string
+	}{
+		{
+			name:    "PromptFlag",
+			args:    []string{"-prompt", "hi"},
+			turns:   [][]*model.LLMResponse{{text("Hello!", false)}},
+			wantOut: "Hello!\n",
+		},
+		{
+			name:    "Arguments",
+			args:    []string{"say", "hi"},
+			turns:   [][]*model.LLMResponse{{text("Hi!", false)}},
+			wantOut: "Hi!\n",
+		},
+		{
+			name:    "Piped",
+			in:      "hi from a pipe\n",
+			turns:   [][]*model.LLMResponse{{text("Hello pipe!", false)}},
+			wantOut: "Hello pipe!\n",
+		},
+		{
+			name:     "ModelError",
+			args:     []string{"-prompt", "hi"},
+			turns:    [][]*model.LLMResponse{{{ErrorCode: string(model.ErrorCodeSafetyBlocked), ErrorMessage: "blocked"}}},
+			wantCode: 1,
+			wantOut:  "error: agent \"assistant\" failed: SAFETY_BLOCKED: blocked\n",
+		},
+		{
+			name:     "RunError",
+			args:     []string{"-prompt", "hi"},
+			wantCode: 1,
+			wantOut:  "error: no more turns\n",
+		},
+		{
+			name:     "InvalidFlag",
+			args:     []string{"-nope"},
+			wantCode: 2,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			f := newFixture(t, tc.turns...)
+			var out strings.Builder
+			code := runnercli.Run(t.Context(), f.runner, tc.args, f.options(tc.in, &out))
+			if code != tc.wantCode {
+				t.Errorf("Run() = %d, want %d; output:\n%s", code, tc.wantCode, out.String())
+			}
+			if tc.wantOut != "" && out.String() != tc.wantOut {
+				t.Errorf("output = %q, want %q", out.String(), tc.wantOut)
+			}
+		})
+	}
+}
+
+func TestRun_JSON(t *testing.T) {
+	f := newFixture(t, []*model.LLMResponse{text("Hello!", false)})
+	var out strings.Builder
+	if code := runnercli.Run(t.Context(), f.runner, []string{"-json", "-prompt", "hi"}, f.options("", &out)); code != 0 {
+		t.Fatalf("Run() = %d, output:\n%s", code, out.String())
+	}
+	var texts []string
+	for line := range strings.Lines(out.String()) {
+		var ev session.Event
+		if err := json.Unmarshal([]byte(line), &ev); err != nil {
+			t.Fatalf("invalid event %q: %v", line, err)
+		}
+		if ev.Content != nil {
+			for _, p := range ev.Content.Parts {
+				texts = append(texts, p.Text)
+			}
+		}
+	}
+	if diff := cmp.Diff([]string{"Hello!"}, texts); diff != "" {
+		t.Errorf("event texts mismatch (-want +got):\n%s", diff)
+	}
+}
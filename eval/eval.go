@@ -0,0 +1,59 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package eval defines the cases of the evaluation harness.
+//
+// The JSON encoding of the types follows the eval set files of the other ADK
+// languages, so that the cases can be evaluated with their tooling.
+package eval
+
+import (
+	"google.golang.org/genai"
+)
+
+// Case is an evaluation case: a conversation with the expected responses of
+// the agent.
+type Case struct {
+	// ID identifies the case within its eval set.
+	ID string `json:"eval_id"`
+	// Conversation is the expected conversation, one invocation per turn.
+	Conversation []Invocation `json:"conversation"`
+	// SessionInput is the initial session of the conversation.
+	SessionInput *SessionInput `json:"session_input,omitempty"`
+	// Labels annotate the case, e.g. with the user feedback it comes from.
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// Invocation is a turn of a conversation: a user message and the expected
+// response of the agent.
+type Invocation struct {
+	InvocationID  string         `json:"invocation_id,omitempty"`
+	UserContent   *genai.Content `json:"user_content"`
+	FinalResponse *genai.Content `json:"final_response,omitempty"`
+	// IntermediateData is the expected trajectory of the agent.
+	IntermediateData *IntermediateData `json:"intermediate_data,omitempty"`
+}
+
+// IntermediateData is the trajectory of an agent during an invocation.
+type IntermediateData struct {
+	ToolUses      []*genai.FunctionCall     `json:"tool_uses,omitempty"`
+	ToolResponses []*genai.FunctionResponse `json:"tool_responses,omitempty"`
+}
+
+// SessionInput is the initial session of an evaluated conversation.
+type SessionInput struct {
+	AppName string         `json:"app_name"`
+	UserID  string         `json:"user_id"`
+	State   map[string]any `json:"state,omitempty"`
+}
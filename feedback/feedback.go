@@ -0,0 +1,388 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package feedback records the feedback of end users on the answers of an
+// agent, and turns it into evaluation cases.
+//
+// A feedback [Record] references the events of the rated answer and of the
+// user message that triggered it. Records are stored in the session state,
+// one per answer, and written to a [Sink]. [ExportCases] rebuilds the
+// conversations that led to the rated answers as [eval.Case] values.
+package feedback
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/eval"
+	"google.golang.org/adk/session"
+)
+
+// StateKey is the session state key holding the feedback records.
+const StateKey = "_adk_feedback"
+
+// Rating is the rating of an answer.
+type Rating string
+
+const (
+	// RatingUp is a positive rating.
+	RatingUp Rating = "thumbs_up"
+	// RatingDown is a negative rating.
+	RatingDown Rating = "thumbs_down"
+)
+
+// Valid reports whether r is a known rating.
+func (r Rating) Valid() bool {
+	return r == RatingUp || r == RatingDown
+}
+
+// Record is the feedback of a user on an answer.
+type Record struct {
+	ID        string `json:"id"`
+	AppName   string `json:"app_name"`
+	UserID    string `json:"user_id"`
+	SessionID string `json:"session_id"`
+
+	Rating  Rating `json:"rating"`
+	Comment string `json:"comment,omitempty"`
+
+	// AnswerEventID is the ID of the event of the rated answer.
+	AnswerEventID string `json:"answer_event_id"`
+	// UserEventID is the ID of the event of the user message the answer
+	// responded to.
+	UserEventID string `json:"user_event_id,omitempty"`
+	// InvocationID is the ID of the invocation that produced the answer.
+	InvocationID string `json:"invocation_id,omitempty"`
+	// ConfigFingerprint identifies the configuration of the agent that
+	// produced the answer.
+	ConfigFingerprint string `json:"config_fingerprint,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Sink receives the feedback records. A record updating the feedback on an
+// answer keeps the ID of the record it replaces.
+type Sink interface {
+	Write(ctx context.Context, record *Record) error
+}
+
+// SinkFunc is a Sink calling a function.
+type SinkFunc func(ctx context.Context, record *Record) error
+
+// Write implements Sink.
+func (f SinkFunc) Write(ctx context.Context, record *Record) error {
+	return f(ctx, record)
+}
+
+// JSONLSink is a Sink appending the records to a file, one JSON object per
+// line. Updates are appended too: [ReadJSONL] keeps the latest record of
+// every answer.
+type JSONLSink struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewJSONLSink returns a JSONLSink writing to the file at path, which is
+// created if needed.
+func NewJSONLSink(path string) *JSONLSink {
+	return &JSONLSink{path: path}
+}
+
+// Write implements Sink.
+func (s *JSONLSink) Write(ctx context.Context, record *Record) error {
+	b, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode feedback record: %w", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open feedback file: %w", err)
+	}
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write feedback record: %w", err)
+	}
+	return f.Close()
+}
+
+// ReadJSONL reads the records written by a JSONLSink. Only the latest record
+// of every answer is returned, in the order the answers were first rated.
+func ReadJSONL(path string) ([]*Record, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open feedback file: %w", err)
+	}
+	defer f.Close()
+
+	var records []*Record
+	index := make(map[string]int)
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var r Record
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			return nil, fmt.Errorf("failed to decode feedback record: %w", err)
+		}
+		if i, ok := index[r.AnswerEventID]; ok {
+			records[i] = &r
+			continue
+		}
+		index[r.AnswerEventID] = len(records)
+		records = append(records, &r)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read feedback file: %w", err)
+	}
+	return records, nil
+}
+
+// Load returns the records stored in state, oldest first.
+func Load(state session.ReadonlyState) ([]*Record, error) {
+	stored, err := load(state)
+	if err != nil {
+		return nil, err
+	}
+	records := make([]*Record, 0, len(stored))
+	for _, r := range stored {
+		records = append(records, r)
+	}
+	slices.SortFunc(records, func(a, b *Record) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+	return records, nil
+}
+
+// Store stores record in state. The feedback on the same answer is updated
+// rather than duplicated: the stored record keeps the ID and the creation
+// time of the previous one. Store returns the stored record and whether it
+// updated a previous one.
+func Store(state session.State, record *Record) (*Record, bool, error) {
+	if record.AnswerEventID == "" {
+		return nil, false, errors.New("feedback record has no answer event ID")
+	}
+	stored, err := load(state)
+	if err != nil {
+		return nil, false, err
+	}
+	if stored == nil {
+		stored = make(map[string]*Record)
+	}
+	updated := *record
+	previous, ok := stored[record.AnswerEventID]
+	if ok {
+		updated.ID = previous.ID
+		updated.CreatedAt = previous.CreatedAt
+	}
+	stored[record.AnswerEventID] = &updated
+
+	var value any
+	if err := convert(stored, &value); err != nil {
+		return nil, false, err
+	}
+	if err := state.Set(StateKey, value); err != nil {
+		return nil, false, err
+	}
+	return &updated, ok, nil
+}
+
+func load(state session.ReadonlyState) (map[string]*Record, error) {
+	v, err := state.Get(StateKey)
+	if errors.Is(err, session.ErrStateKeyNotExist) || v == nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var stored map[string]*Record
+	if err := convert(v, &stored); err != nil {
+		return nil, err
+	}
+	return stored, nil
+}
+
+// convert stores records as JSON compatible values, so that they survive
+// persistent session services.
+func convert(in, out any) error {
+	b, err := json.Marshal(in)
+	if err != nil {
+		return fmt.Errorf("failed to encode feedback records: %w", err)
+	}
+	if err := json.Unmarshal(b, out); err != nil {
+		return fmt.Errorf("failed to decode feedback records: %w", err)
+	}
+	return nil
+}
+
+// PreviousAnswer returns the last answer recorded in events before the
+// invocation with the given ID, and the user message it responded to. The
+// answers of the invocations that recorded feedback, typically
+// acknowledgements, are skipped. It returns nil if there is no such answer;
+// user is nil if the answer has no user message.
+func PreviousAnswer(events []*session.Event, invocationID string) (answer, user *session.Event) {
+	skipped := map[string]bool{invocationID: true}
+	for _, ev := range events {
+		if _, ok := ev.Actions.StateDelta[StateKey]; ok {
+			skipped[ev.InvocationID] = true
+		}
+	}
+	for i := len(events) - 1; i >= 0; i-- {
+		if !skipped[events[i].InvocationID] && isAnswer(events[i]) {
+			return events[i], userMessage(events[:i])
+		}
+	}
+	return nil, nil
+}
+
+// Answer returns the answer event with the given ID, and the user message it
+// responded to. It returns nil if there is no such answer.
+func Answer(events []*session.Event, eventID string) (answer, user *session.Event) {
+	for i, ev := range events {
+		if ev.ID == eventID && isAnswer(ev) {
+			return ev, userMessage(events[:i])
+		}
+	}
+	return nil, nil
+}
+
+// ExportCases converts the feedback records on the answers in events, the
+// history of a session, into evaluation cases. Every case replays the
+// conversation up to the rated answer, and is labeled with the feedback.
+func ExportCases(events []*session.Event, records []*Record) ([]eval.Case, error) {
+	index := make(map[string]int, len(events))
+	for i, ev := range events {
+		index[ev.ID] = i
+	}
+	cases := make([]eval.Case, 0, len(records))
+	for _, r := range records {
+		i, ok := index[r.AnswerEventID]
+		if !ok || !isAnswer(events[i]) {
+			return nil, fmt.Errorf("answer event %q of feedback %q not found", r.AnswerEventID, r.ID)
+		}
+		labels := map[string]string{
+			"rating":          string(r.Rating),
+			"answer_event_id": r.AnswerEventID,
+			"session_id":      r.SessionID,
+		}
+		for key, value := range map[string]string{
+			"comment":            r.Comment,
+			"user_event_id":      r.UserEventID,
+			"config_fingerprint": r.ConfigFingerprint,
+		} {
+			if value != "" {
+				labels[key] = value
+			}
+		}
+		cases = append(cases, eval.Case{
+			ID:           r.ID,
+			Conversation: conversation(events[:i+1]),
+			SessionInput: &eval.SessionInput{AppName: r.AppName, UserID: r.UserID},
+			Labels:       labels,
+		})
+	}
+	return cases, nil
+}
+
+// conversation splits events into turns starting with a user message. The
+// events recorded before the first user message are ignored.
+func conversation(events []*session.Event) []eval.Invocation {
+	var turns []eval.Invocation
+	for _, ev := range events {
+		if isUserMessage(ev) {
+			turns = append(turns, eval.Invocation{InvocationID: ev.InvocationID, UserContent: ev.Content})
+			continue
+		}
+		if len(turns) == 0 || ev.Content == nil {
+			continue
+		}
+		turn := &turns[len(turns)-1]
+		if ev.Author != "user" {
+			// The user and the agent events have different invocation IDs:
+			// report the invocation of the agent.
+			turn.InvocationID = ev.InvocationID
+		}
+		if isAnswer(ev) {
+			turn.FinalResponse = ev.Content
+		}
+		for _, p := range ev.Content.Parts {
+			if p == nil {
+				continue
+			}
+			if p.FunctionCall != nil || p.FunctionResponse != nil {
+				if turn.IntermediateData == nil {
+					turn.IntermediateData = &eval.IntermediateData{}
+				}
+			}
+			if p.FunctionCall != nil {
+				turn.IntermediateData.ToolUses = append(turn.IntermediateData.ToolUses, p.FunctionCall)
+			}
+			if p.FunctionResponse != nil {
+				turn.IntermediateData.ToolResponses = append(turn.IntermediateData.ToolResponses, p.FunctionResponse)
+			}
+		}
+	}
+	return turns
+}
+
+// userMessage returns the last user message of events.
+func userMessage(events []*session.Event) *session.Event {
+	for i := len(events) - 1; i >= 0; i-- {
+		if isUserMessage(events[i]) {
+			return events[i]
+		}
+	}
+	return nil
+}
+
+// isUserMessage reports whether ev is a message of the user, as opposed to
+// the function responses authored by the user.
+func isUserMessage(ev *session.Event) bool {
+	return ev.Author == "user" && ev.Content != nil && !slices.ContainsFunc(ev.Content.Parts, func(p *genai.Part) bool {
+		return p != nil && p.FunctionResponse != nil
+	})
+}
+
+// isAnswer reports whether ev is a complete text response of an agent.
+func isAnswer(ev *session.Event) bool {
+	if ev.Author == "user" || ev.Content == nil || ev.Partial {
+		return false
+	}
+	hasText := false
+	for _, p := range ev.Content.Parts {
+		if p == nil {
+			continue
+		}
+		if p.FunctionCall != nil || p.FunctionResponse != nil {
+			return false
+		}
+		if p.Text != "" && !p.Thought {
+			hasText = true
+		}
+	}
+	return hasText
+}
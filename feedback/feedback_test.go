@@ -0,0 +1,205 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package feedback_test
+
+import (
+	"iter"
+	"maps"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/eval"
+	"google.golang.org/adk/feedback"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/session"
+)
+
+func event(id, invocationID, author string, content *genai.Content) *session.Event {
+	return &session.Event{
+		ID:           id,
+		InvocationID: invocationID,
+		Author:       author,
+		LLMResponse:  model.LLMResponse{Content: content},
+	}
+}
+
+func history() []*session.Event {
+	return []*session.Event{
+		event("u1", "i1-user", "user", genai.NewContentFromText("weather in Paris?", genai.RoleUser)),
+		event("c1", "i1", "agent", genai.NewContentFromFunctionCall("get_weather", map[string]any{"city": "Paris"}, genai.RoleModel)),
+		event("r1", "i1", "user", genai.NewContentFromFunctionResponse("get_weather", map[string]any{"sky": "sunny"}, genai.RoleUser)),
+		event("a1", "i1", "agent", genai.NewContentFromText("It is sunny.", genai.RoleModel)),
+		event("u2", "i2-user", "user", genai.NewContentFromText("and in Rome?", genai.RoleUser)),
+		event("a2", "i2", "agent", genai.NewContentFromText("It is raining.", genai.RoleModel)),
+		event("u3", "i3-user", "user", genai.NewContentFromText("thanks, that was helpful", genai.RoleUser)),
+	}
+}
+
+func TestPreviousAnswer(t *testing.T) {
+	events := history()
+	answer, user := feedback.PreviousAnswer(events, "i3")
+	if answer == nil || answer.ID != "a2" || user == nil || user.ID != "u2" {
+		t.Errorf("PreviousAnswer() = (%v, %v), want (a2, u2)", answer, user)
+	}
+
+	// The answer acknowledging feedback is skipped.
+	ack := event("a3", "i3", "agent", genai.NewContentFromText("Thanks!", genai.RoleModel))
+	ack.Actions.StateDelta = map[string]any{feedback.StateKey: map[string]any{}}
+	events = append(events, ack, event("u4", "i4-user", "user", genai.NewContentFromText("no wait", genai.RoleUser)))
+	if answer, _ := feedback.PreviousAnswer(events, "i4"); answer == nil || answer.ID != "a2" {
+		t.Errorf("PreviousAnswer() after feedback = %v, want a2", answer)
+	}
+
+	// The function call and response are not answers.
+	if answer, user := feedback.Answer(history(), "a1"); answer == nil || user == nil || user.ID != "u1" {
+		t.Errorf("Answer(a1) = (%v, %v), want (a1, u1)", answer, user)
+	}
+	if answer, _ := feedback.Answer(history(), "c1"); answer != nil {
+		t.Errorf("Answer(c1) = %v, want nil", answer)
+	}
+}
+
+// mapState is a session.State backed by a map.
+type mapState map[string]any
+
+func (s mapState) Get(key string) (any, error) {
+	v, ok := s[key]
+	if !ok {
+		return nil, session.ErrStateKeyNotExist
+	}
+	return v, nil
+}
+
+func (s mapState) Set(key string, value any) error {
+	s[key] = value
+	return nil
+}
+
+func (s mapState) All() iter.Seq2[string, any] {
+	return maps.All(s)
+}
+
+func TestStore(t *testing.T) {
+	state := mapState{}
+	created := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	first, updated, err := feedback.Store(state, &feedback.Record{ID: "f1", AnswerEventID: "a1", Rating: feedback.RatingUp, CreatedAt: created})
+	if err != nil || updated {
+		t.Fatalf("Store() = (%v, %v, %v), want a new record", first, updated, err)
+	}
+	if _, _, err := feedback.Store(state, &feedback.Record{ID: "f2", AnswerEventID: "a2", Rating: feedback.RatingUp, CreatedAt: created.Add(time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+	second, updated, err := feedback.Store(state, &feedback.Record{ID: "f3", AnswerEventID: "a1", Rating: feedback.RatingDown, Comment: "wrong", CreatedAt: created.Add(2 * time.Hour)})
+	if err != nil || !updated {
+		t.Fatalf("Store() = (%v, %v, %v), want an update", second, updated, err)
+	}
+
+	records, err := feedback.Load(state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*feedback.Record{
+		{ID: "f1", AnswerEventID: "a1", Rating: feedback.RatingDown, Comment: "wrong", CreatedAt: created},
+		{ID: "f2", AnswerEventID: "a2", Rating: feedback.RatingUp, CreatedAt: created.Add(time.Hour)},
+	}
+	if diff := cmp.Diff(want, records); diff != "" {
+		t.Errorf("Load() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestJSONLSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "feedback.jsonl")
+	sink := feedback.NewJSONLSink(path)
+	for _, r := range []*feedback.Record{
+		{ID: "f1", AnswerEventID: "a1", Rating: feedback.RatingUp, ConfigFingerprint: "config-v1"},
+		{ID: "f2", AnswerEventID: "a2", Rating: feedback.RatingUp},
+		{ID: "f1", AnswerEventID: "a1", Rating: feedback.RatingDown, Comment: "wrong", ConfigFingerprint: "config-v1"},
+	} {
+		if err := sink.Write(t.Context(), r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := feedback.ReadJSONL(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*feedback.Record{
+		{ID: "f1", AnswerEventID: "a1", Rating: feedback.RatingDown, Comment: "wrong", ConfigFingerprint: "config-v1"},
+		{ID: "f2", AnswerEventID: "a2", Rating: feedback.RatingUp},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("ReadJSONL() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestExportCases(t *testing.T) {
+	events := history()
+	records := []*feedback.Record{{
+		ID:                "f1",
+		AppName:           "app",
+		UserID:            "user",
+		SessionID:         "session",
+		Rating:            feedback.RatingUp,
+		Comment:           "helpful",
+		AnswerEventID:     "a2",
+		UserEventID:       "u2",
+		ConfigFingerprint: "config-v1",
+	}}
+
+	got, err := feedback.ExportCases(events, records)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []eval.Case{{
+		ID: "f1",
+		Conversation: []eval.Invocation{
+			{
+				InvocationID:  "i1",
+				UserContent:   events[0].Content,
+				FinalResponse: events[3].Content,
+				IntermediateData: &eval.IntermediateData{
+					ToolUses:      []*genai.FunctionCall{events[1].Content.Parts[0].FunctionCall},
+					ToolResponses: []*genai.FunctionResponse{events[2].Content.Parts[0].FunctionResponse},
+				},
+			},
+			{
+				InvocationID:  "i2",
+				UserContent:   events[4].Content,
+				FinalResponse: events[5].Content,
+			},
+		},
+		SessionInput: &eval.SessionInput{AppName: "app", UserID: "user"},
+		Labels: map[string]string{
+			"rating":             "thumbs_up",
+			"comment":            "helpful",
+			"answer_event_id":    "a2",
+			"user_event_id":      "u2",
+			"session_id":         "session",
+			"config_fingerprint": "config-v1",
+		},
+	}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("ExportCases() mismatch (-want +got):\n%s", diff)
+	}
+
+	if _, err := feedback.ExportCases(events, []*feedback.Record{{ID: "f2", AnswerEventID: "unknown"}}); err == nil {
+		t.Error("ExportCases() with an unknown answer succeeded, want an error")
+	}
+}
@@ -0,0 +1,243 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package health checks that the dependencies of the agents, such as the
+// model backends, the MCP servers and the artifact service, are reachable,
+// and reports the result for the readiness probes of a deployment.
+//
+// Example:
+//
+//	checker := health.NewChecker(health.Config{
+//		Dependencies: append(health.ForAgent(rootAgent), health.ArtifactService("artifacts", artifactService)),
+//	})
+//	http.Handle("/readyz", checker.Handler())
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/artifact"
+	"google.golang.org/adk/internal/llminternal"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/tool"
+)
+
+// Status is the status of a dependency.
+type Status string
+
+const (
+	// StatusOK means that the dependency is reachable.
+	StatusOK Status = "ok"
+	// StatusUnavailable means that the dependency failed its check.
+	StatusUnavailable Status = "unavailable"
+	// StatusUnchecked means that the dependency cannot be checked, e.g. a
+	// model whose adapter does not implement model.HealthChecker. It does
+	// not make the report unready.
+	StatusUnchecked Status = "unchecked"
+)
+
+// Dependency is a dependency checked by a [Checker].
+type Dependency struct {
+	// Name identifies the dependency in the report.
+	Name string
+	// Kind is the kind of the dependency, e.g. "model".
+	Kind string
+	// Check returns an error if the dependency is not reachable. If nil,
+	// the dependency is reported as unchecked.
+	Check func(ctx context.Context) error
+}
+
+// Model returns the dependency on llm, checked if it implements
+// [model.HealthChecker].
+func Model(llm model.LLM) Dependency {
+	return checkerDependency(llm.Name(), "model", llm)
+}
+
+// Toolset returns the dependency on ts, checked if it implements
+// [model.HealthChecker], as the MCP toolsets do.
+func Toolset(ts tool.Toolset) Dependency {
+	return checkerDependency(ts.Name(), "toolset", ts)
+}
+
+// ArtifactService returns the dependency on service, checked by listing the
+// artifacts of a session that does not exist.
+func ArtifactService(name string, service artifact.Service) Dependency {
+	return Dependency{
+		Name: name,
+		Kind: "artifact_service",
+		Check: func(ctx context.Context) error {
+			_, err := service.List(ctx, &artifact.ListRequest{AppName: "_health", UserID: "_health", SessionID: "_health"})
+			return err
+		},
+	}
+}
+
+func checkerDependency(name, kind string, v any) Dependency {
+	d := Dependency{Name: name, Kind: kind}
+	if c, ok := v.(model.HealthChecker); ok {
+		d.Check = c.HealthCheck
+	}
+	return d
+}
+
+// ForAgent returns the dependencies on the models and the toolsets of root
+// and its sub-agents. The models and toolsets shared by several agents are
+// listed once.
+func ForAgent(root agent.Agent) []Dependency {
+	var deps []Dependency
+	seen := make(map[any]bool)
+	add := func(v any, d func() Dependency) {
+		if !seen[v] {
+			seen[v] = true
+			deps = append(deps, d())
+		}
+	}
+	var walk func(a agent.Agent)
+	walk = func(a agent.Agent) {
+		if seen[a] {
+			return
+		}
+		seen[a] = true
+		if llmAgent, ok := a.(llminternal.Agent); ok {
+			state := llminternal.Reveal(llmAgent)
+			if state.Model != nil {
+				add(state.Model, func() Dependency { return Model(state.Model) })
+			}
+			for _, ts := range state.Toolsets {
+				add(ts, func() Dependency { return Toolset(ts) })
+			}
+		}
+		for _, sub := range a.SubAgents() {
+			walk(sub)
+		}
+	}
+	walk(root)
+	return deps
+}
+
+// Config configures a [Checker].
+type Config struct {
+	Dependencies []Dependency
+	// Timeout limits the duration of the check of each dependency.
+	// Defaults to 10 seconds.
+	Timeout time.Duration
+}
+
+// Checker checks the dependencies concurrently.
+type Checker struct {
+	cfg Config
+}
+
+// NewChecker returns a Checker of the dependencies of cfg.
+func NewChecker(cfg Config) *Checker {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	return &Checker{cfg: cfg}
+}
+
+// Report is the result of a check.
+type Report struct {
+	// Ready is false if a dependency is unavailable.
+	Ready        bool               `json:"ready"`
+	CheckedAt    time.Time          `json:"checked_at"`
+	Dependencies []DependencyStatus `json:"dependencies"`
+}
+
+// DependencyStatus is the result of the check of a dependency.
+type DependencyStatus struct {
+	Name    string        `json:"name"`
+	Kind    string        `json:"kind,omitempty"`
+	Status  Status        `json:"status"`
+	Error   string        `json:"error,omitempty"`
+	Latency time.Duration `json:"-"`
+}
+
+// MarshalJSON reports the latency in milliseconds.
+func (s DependencyStatus) MarshalJSON() ([]byte, error) {
+	type status DependencyStatus
+	return json.Marshal(struct {
+		status
+		LatencyMS float64 `json:"latency_ms"`
+	}{status(s), float64(s.Latency.Microseconds()) / 1000})
+}
+
+// Check checks all the dependencies, concurrently, and returns the report
+// listing them in the configured order.
+func (c *Checker) Check(ctx context.Context) Report {
+	report := Report{
+		Ready:        true,
+		CheckedAt:    time.Now(),
+		Dependencies: make([]DependencyStatus, len(c.cfg.Dependencies)),
+	}
+	var wg sync.WaitGroup
+	for i, d := range c.cfg.Dependencies {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			report.Dependencies[i] = c.check(ctx, d)
+		}()
+	}
+	wg.Wait()
+	for _, s := range report.Dependencies {
+		if s.Status == StatusUnavailable {
+			report.Ready = false
+		}
+	}
+	return report
+}
+
+func (c *Checker) check(ctx context.Context, d Dependency) (status DependencyStatus) {
+	status = DependencyStatus{Name: d.Name, Kind: d.Kind, Status: StatusUnchecked}
+	if d.Check == nil {
+		return status
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.cfg.Timeout)
+	defer cancel()
+	start := time.Now()
+	defer func() {
+		if r := recover(); r != nil {
+			status.Status, status.Error = StatusUnavailable, fmt.Sprintf("check panicked: %v", r)
+		}
+		status.Latency = time.Since(start)
+	}()
+	if err := d.Check(ctx); err != nil {
+		status.Status, status.Error = StatusUnavailable, err.Error()
+	} else {
+		status.Status = StatusOK
+	}
+	return status
+}
+
+// Handler returns an HTTP handler serving the report as JSON, with the
+// status 200 if the dependencies are ready and 503 otherwise.
+func (c *Checker) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := c.Check(r.Context())
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		if report.Ready {
+			w.WriteHeader(http.StatusOK)
+		} else {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		_ = json.NewEncoder(w).Encode(report)
+	})
+}
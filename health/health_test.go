@@ -0,0 +1,149 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package health_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/artifact"
+	"google.golang.org/adk/health"
+	"google.golang.org/adk/internal/testutil"
+	"google.golang.org/adk/tool"
+)
+
+// checkedModel is a model whose health check returns err.
+type checkedModel struct {
+	testutil.MockModel
+	name string
+	err  error
+}
+
+func (m *checkedModel) Name() string { return m.name }
+
+func (m *checkedModel) HealthCheck(context.Context) error { return m.err }
+
+// checkedToolset is a toolset whose health check returns err.
+type checkedToolset struct {
+	err error
+}
+
+func (*checkedToolset) Name() string { return "mcp_tool_set" }
+
+func (*checkedToolset) Tools(agent.ReadonlyContext) ([]tool.Tool, error) { return nil, nil }
+
+func (ts *checkedToolset) HealthCheck(context.Context) error { return ts.err }
+
+var ignoreLatency = cmpopts.IgnoreFields(health.DependencyStatus{}, "Latency")
+
+func TestChecker(t *testing.T) {
+	checker := health.NewChecker(health.Config{Dependencies: []health.Dependency{
+		health.Model(&checkedModel{name: "healthy"}),
+		health.Model(&checkedModel{name: "unhealthy", err: errors.New("invalid API key")}),
+		health.Model(&testutil.MockModel{}),
+		health.Toolset(&checkedToolset{}),
+		health.ArtifactService("artifacts", artifact.InMemoryService()),
+		{Name: "panicking", Check: func(context.Context) error { panic("boom") }},
+	}})
+
+	report := checker.Check(t.Context())
+	want := []health.DependencyStatus{
+		{Name: "healthy", Kind: "model", Status: health.StatusOK},
+		{Name: "unhealthy", Kind: "model", Status: health.StatusUnavailable, Error: "invalid API key"},
+		{Name: "mock", Kind: "model", Status: health.StatusUnchecked},
+		{Name: "mcp_tool_set", Kind: "toolset", Status: health.StatusOK},
+		{Name: "artifacts", Kind: "artifact_service", Status: health.StatusOK},
+		{Name: "panicking", Status: health.StatusUnavailable, Error: "check panicked: boom"},
+	}
+	if diff := cmp.Diff(want, report.Dependencies, ignoreLatency); diff != "" {
+		t.Errorf("Check() dependencies mismatch (-want +got):\n%s", diff)
+	}
+	if report.Ready {
+		t.Error("Check() reported ready with unavailable dependencies")
+	}
+
+	healthy := health.NewChecker(health.Config{Dependencies: []health.Dependency{
+		health.Model(&checkedModel{name: "healthy"}),
+		health.Model(&testutil.MockModel{}),
+	}})
+	if report := healthy.Check(t.Context()); !report.Ready {
+		t.Errorf("Check() = %+v, want ready", report)
+	}
+}
+
+func TestForAgent(t *testing.T) {
+	shared := &checkedModel{name: "shared"}
+	toolset := &checkedToolset{err: errors.New("connection refused")}
+	sub, err := llmagent.New(llmagent.Config{Name: "sub", Model: shared, Toolsets: []tool.Toolset{toolset}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := llmagent.New(llmagent.Config{Name: "other", Model: &checkedModel{name: "other"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := llmagent.New(llmagent.Config{Name: "root", Model: shared, SubAgents: []agent.Agent{sub, other}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report := health.NewChecker(health.Config{Dependencies: health.ForAgent(root)}).Check(t.Context())
+	want := []health.DependencyStatus{
+		{Name: "shared", Kind: "model", Status: health.StatusOK},
+		{Name: "mcp_tool_set", Kind: "toolset", Status: health.StatusUnavailable, Error: "connection refused"},
+		{Name: "other", Kind: "model", Status: health.StatusOK},
+	}
+	if diff := cmp.Diff(want, report.Dependencies, ignoreLatency); diff != "" {
+		t.Errorf("Check() dependencies mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		err        error
+		wantStatus int
+	}{
+		{"Ready", nil, http.StatusOK},
+		{"NotReady", errors.New("unreachable"), http.StatusServiceUnavailable},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			checker := health.NewChecker(health.Config{Dependencies: []health.Dependency{health.Model(&checkedModel{name: "gpt", err: tc.err})}})
+			rec := httptest.NewRecorder()
+			checker.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+			if rec.Code != tc.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tc.wantStatus)
+			}
+			var report map[string]any
+			if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+				t.Fatal(err)
+			}
+			deps := report["dependencies"].([]any)
+			dep := deps[0].(map[string]any)
+			if _, ok := dep["latency_ms"].(float64); !ok || dep["name"] != "gpt" || report["ready"] != (tc.err == nil) {
+				t.Errorf("report = %v", report)
+			}
+		})
+	}
+}
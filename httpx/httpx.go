@@ -0,0 +1,192 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package httpx propagates the metadata of the invocations to the outbound
+// HTTP calls of the components, so that the systems they call can correlate
+// the requests of an invocation, e.g. through a gateway.
+//
+// The runner and the agents place the [Metadata] of the invocation in the
+// context of the run. A [Transport] sets the configured headers from the
+// metadata of the context of each request it sends. The headers are
+// configured once, for all the components:
+//
+//	httpx.Configure(httpx.Config{
+//		HashKey: hashKey,
+//		Headers: append(httpx.DefaultHeaders(), httpx.Header{
+//			Name:  "X-User-Hash",
+//			Value: func(md httpx.Metadata) string { return md.UserID },
+//			Hash:  true,
+//		}),
+//	})
+//
+// The components sending HTTP requests default to [DefaultClient], e.g. the
+// OpenAI models, the webhooks of the event hooks and of the long running
+// operations, the HTTP transport of the tool servers and the HTTP transports
+// of the MCP tool sets. A component given its own client bypasses the
+// propagation, unless the client uses a [Transport].
+package httpx
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"sync/atomic"
+)
+
+// Metadata is the metadata of an invocation propagated to the outbound
+// calls.
+type Metadata struct {
+	AppName      string
+	UserID       string
+	SessionID    string
+	InvocationID string
+	// AgentName is the name of the agent running.
+	AgentName string
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying md.
+func NewContext(ctx context.Context, md Metadata) context.Context {
+	return context.WithValue(ctx, contextKey{}, md)
+}
+
+// FromContext returns the metadata carried by ctx, if any.
+func FromContext(ctx context.Context) (Metadata, bool) {
+	md, ok := ctx.Value(contextKey{}).(Metadata)
+	return md, ok
+}
+
+// Header is a header set on the outbound requests.
+type Header struct {
+	Name string
+	// Value derives the value of the header from the metadata. The header
+	// is not set if the value is empty.
+	Value func(Metadata) string
+	// Hash replaces the value by its hash, so that an identifier can be
+	// correlated without being disclosed, see [Hash].
+	Hash bool
+}
+
+// DefaultHeaders returns the headers set by default: X-Request-ID holds the
+// invocation ID, X-Session-Hash the hash of the session ID and X-Agent-Name
+// the name of the agent running.
+func DefaultHeaders() []Header {
+	return []Header{
+		{Name: "X-Request-ID", Value: func(md Metadata) string { return md.InvocationID }},
+		{Name: "X-Session-Hash", Value: func(md Metadata) string { return md.SessionID }, Hash: true},
+		{Name: "X-Agent-Name", Value: func(md Metadata) string { return md.AgentName }},
+	}
+}
+
+// Config configures the headers set by the transports.
+type Config struct {
+	// Headers are the headers set on the requests. Defaults to
+	// DefaultHeaders().
+	Headers []Header
+	// HashKey keys the hashes of the values, see [Hash]. Optional, but
+	// recommended: without a key, an identifier which can be guessed can
+	// be recovered from its hash.
+	HashKey []byte
+}
+
+var config atomic.Pointer[Config]
+
+// Configure sets the configuration of the transports which have none. It is
+// meant to be called once, before the components send requests.
+func Configure(cfg Config) {
+	if cfg.Headers == nil {
+		cfg.Headers = DefaultHeaders()
+	}
+	config.Store(&cfg)
+}
+
+func init() {
+	Configure(Config{})
+}
+
+// Hash returns the hex-encoded HMAC-SHA256 of value with key, truncated to
+// 16 bytes, or its SHA-256 if key is empty.
+func Hash(key []byte, value string) string {
+	var sum []byte
+	if len(key) > 0 {
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(value))
+		sum = mac.Sum(nil)
+	} else {
+		s := sha256.Sum256([]byte(value))
+		sum = s[:]
+	}
+	return hex.EncodeToString(sum[:16])
+}
+
+// Transport is an http.RoundTripper setting the headers derived from the
+// [Metadata] of the context of the requests. The headers already set on a
+// request are kept, and the requests whose context carries no metadata are
+// sent unchanged.
+type Transport struct {
+	// Base sends the requests. Defaults to http.DefaultTransport.
+	Base http.RoundTripper
+	// Config, if set, is used instead of the configuration set by
+	// [Configure].
+	Config *Config
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	md, ok := FromContext(req.Context())
+	if !ok {
+		return base.RoundTrip(req)
+	}
+	cfg := t.Config
+	if cfg == nil {
+		cfg = config.Load()
+	}
+	headers := cfg.Headers
+	if headers == nil {
+		headers = DefaultHeaders()
+	}
+	// A RoundTripper must not modify the request: the headers are set on a
+	// copy.
+	cloned := false
+	for _, h := range headers {
+		if h.Value == nil || req.Header.Get(h.Name) != "" {
+			continue
+		}
+		value := h.Value(md)
+		if value == "" {
+			continue
+		}
+		if h.Hash {
+			value = Hash(cfg.HashKey, value)
+		}
+		if !cloned {
+			req = req.Clone(req.Context())
+			cloned = true
+		}
+		req.Header.Set(h.Name, value)
+	}
+	return base.RoundTrip(req)
+}
+
+// DefaultClient is the client used by default by the components, sending
+// the requests through a [Transport] with the configuration set by
+// [Configure].
+var DefaultClient = &http.Client{Transport: &Transport{}}
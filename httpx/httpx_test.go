@@ -0,0 +1,179 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpx_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"google.golang.org/adk/httpx"
+)
+
+var testMetadata = httpx.Metadata{AppName: "app", UserID: "u1", SessionID: "s1", InvocationID: "e-1", AgentName: "root"}
+
+// newHeaderServer returns a server recording the headers of the last
+// request it received.
+func newHeaderServer(t *testing.T) (*httptest.Server, *http.Header) {
+	t.Helper()
+	var got http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &got
+}
+
+// headers returns the headers of names in h.
+func headers(h http.Header, names ...string) map[string]string {
+	got := map[string]string{}
+	for _, name := range names {
+		if v := h.Get(name); v != "" {
+			got[name] = v
+		}
+	}
+	return got
+}
+
+func TestTransport(t *testing.T) {
+	names := []string{"X-Request-ID", "X-Session-Hash", "X-Agent-Name", "X-User-Hash"}
+	key := []byte("secret")
+	tests := []struct {
+		name     string
+		client   *http.Client
+		metadata *httpx.Metadata
+		preset   map[string]string
+		want     map[string]string
+	}{
+		{
+			name:     "Default",
+			client:   httpx.DefaultClient,
+			metadata: &testMetadata,
+			want: map[string]string{
+				"X-Request-ID":   "e-1",
+				"X-Session-Hash": httpx.Hash(nil, "s1"),
+				"X-Agent-Name":   "root",
+			},
+		},
+		{
+			name:   "NoMetadata",
+			client: httpx.DefaultClient,
+			want:   map[string]string{},
+		},
+		{
+			name:     "Preset",
+			client:   httpx.DefaultClient,
+			metadata: &testMetadata,
+			preset:   map[string]string{"X-Request-ID": "custom"},
+			want: map[string]string{
+				"X-Request-ID":   "custom",
+				"X-Session-Hash": httpx.Hash(nil, "s1"),
+				"X-Agent-Name":   "root",
+			},
+		},
+		{
+			name:     "EmptyValues",
+			client:   httpx.DefaultClient,
+			metadata: &httpx.Metadata{InvocationID: "e-2"},
+			want:     map[string]string{"X-Request-ID": "e-2"},
+		},
+		{
+			name: "Config",
+			client: &http.Client{Transport: &httpx.Transport{Config: &httpx.Config{
+				HashKey: key,
+				Headers: []httpx.Header{{Name: "X-User-Hash", Value: func(md httpx.Metadata) string { return md.UserID }, Hash: true}},
+			}}},
+			metadata: &testMetadata,
+			want:     map[string]string{"X-User-Hash": httpx.Hash(key, "u1")},
+		},
+		{
+			name:     "Bypass",
+			client:   &http.Client{},
+			metadata: &testMetadata,
+			want:     map[string]string{},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			srv, got := newHeaderServer(t)
+			ctx := t.Context()
+			if tc.metadata != nil {
+				ctx = httpx.NewContext(ctx, *tc.metadata)
+			}
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for k, v := range tc.preset {
+				req.Header.Set(k, v)
+			}
+			resp, err := tc.client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if diff := cmp.Diff(tc.want, headers(*got, names...)); diff != "" {
+				t.Errorf("headers mismatch (-want +got):\n%s", diff)
+			}
+			// The request of the caller is not modified.
+			if len(req.Header) != len(tc.preset) {
+				t.Errorf("request headers = %v, want %v", req.Header, tc.preset)
+			}
+		})
+	}
+}
+
+func TestConfigure(t *testing.T) {
+	t.Cleanup(func() { httpx.Configure(httpx.Config{}) })
+	key := []byte("secret")
+	httpx.Configure(httpx.Config{
+		HashKey: key,
+		Headers: append(httpx.DefaultHeaders(), httpx.Header{Name: "X-App", Value: func(md httpx.Metadata) string { return md.AppName }}),
+	})
+
+	srv, got := newHeaderServer(t)
+	req, err := http.NewRequestWithContext(httpx.NewContext(t.Context(), testMetadata), http.MethodGet, srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := httpx.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	want := map[string]string{
+		"X-Request-ID":   "e-1",
+		"X-Session-Hash": httpx.Hash(key, "s1"),
+		"X-Agent-Name":   "root",
+		"X-App":          "app",
+	}
+	if diff := cmp.Diff(want, headers(*got, "X-Request-ID", "X-Session-Hash", "X-Agent-Name", "X-App")); diff != "" {
+		t.Errorf("headers mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestHash(t *testing.T) {
+	if got := httpx.Hash(nil, "s1"); len(got) != 32 || got == "s1" {
+		t.Errorf("Hash() = %q, want 32 hex digits", got)
+	}
+	if httpx.Hash([]byte("a"), "s1") == httpx.Hash([]byte("b"), "s1") {
+		t.Error("Hash() with different keys returned the same hash")
+	}
+	if httpx.Hash(nil, "s1") == httpx.Hash(nil, "s2") {
+		t.Error("Hash() of different values returned the same hash")
+	}
+}
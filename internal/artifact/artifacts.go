@@ -15,7 +15,13 @@
 package artifact
 
 import (
+	"bytes"
 	"context"
+	"fmt"
+	"io/fs"
+	"maps"
+	"slices"
+	"sync"
 
 	"google.golang.org/genai"
 
@@ -23,25 +29,66 @@ import (
 	"google.golang.org/adk/artifact"
 )
 
-// Artifacts implements Artifacts
+// Artifacts implements agent.Artifacts for an invocation.
+//
+// The artifacts saved or deleted through it are kept in an overlay, which
+// serves the later loads and lists of the invocation before the Service:
+// the invocation reads its own writes even if the Service is eventually
+// consistent. The overlay only lives as long as the invocation, since the
+// writes are durable in the Service.
 type Artifacts struct {
 	Service   artifact.Service
 	AppName   string
 	UserID    string
 	SessionID string
+
+	mu      sync.Mutex
+	overlay map[string]*overlayEntry
+}
+
+// overlayEntry holds the versions of an artifact written during the
+// invocation.
+type overlayEntry struct {
+	versions map[int64]*genai.Part
+	latest   int64
+	// deleted reports whether the artifact was deleted during the
+	// invocation: the versions of the Service are hidden.
+	deleted bool
 }
 
 func (a *Artifacts) Save(ctx context.Context, name string, data *genai.Part) (*artifact.SaveResponse, error) {
-	return a.Service.Save(ctx, &artifact.SaveRequest{
+	resp, err := a.Service.Save(ctx, &artifact.SaveRequest{
 		AppName:   a.AppName,
 		UserID:    a.UserID,
 		SessionID: a.SessionID,
 		FileName:  name,
 		Part:      data,
 	})
+	if err != nil {
+		return resp, err
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.overlay == nil {
+		a.overlay = make(map[string]*overlayEntry)
+	}
+	e, ok := a.overlay[name]
+	if !ok {
+		e = &overlayEntry{versions: make(map[int64]*genai.Part)}
+		a.overlay[name] = e
+	}
+	e.versions[resp.Version] = clonePart(data)
+	e.latest = max(e.latest, resp.Version)
+	return resp, nil
 }
 
 func (a *Artifacts) Load(ctx context.Context, name string) (*artifact.LoadResponse, error) {
+	if part, found, ok := a.lookup(name, 0); ok {
+		if !found {
+			return nil, fmt.Errorf("artifact not found: %w", fs.ErrNotExist)
+		}
+		return &artifact.LoadResponse{Part: part}, nil
+	}
 	return a.Service.Load(ctx, &artifact.LoadRequest{
 		AppName:   a.AppName,
 		UserID:    a.UserID,
@@ -51,6 +98,12 @@ func (a *Artifacts) Load(ctx context.Context, name string) (*artifact.LoadRespon
 }
 
 func (a *Artifacts) LoadVersion(ctx context.Context, name string, version int) (*artifact.LoadResponse, error) {
+	if part, found, ok := a.lookup(name, int64(version)); ok {
+		if !found {
+			return nil, fmt.Errorf("artifact not found: %w", fs.ErrNotExist)
+		}
+		return &artifact.LoadResponse{Part: part}, nil
+	}
 	return a.Service.Load(ctx, &artifact.LoadRequest{
 		AppName:   a.AppName,
 		UserID:    a.UserID,
@@ -60,12 +113,96 @@ func (a *Artifacts) LoadVersion(ctx context.Context, name string, version int) (
 	})
 }
 
+// lookup returns the given version of an artifact from the overlay, the
+// latest one if version is 0. It reports false if the Service must be asked,
+// and found false if the artifact is known to be absent.
+func (a *Artifacts) lookup(name string, version int64) (part *genai.Part, found, ok bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	e, ok := a.overlay[name]
+	if !ok {
+		return nil, false, false
+	}
+	if version == 0 {
+		version = e.latest
+	}
+	if part, ok := e.versions[version]; ok {
+		return clonePart(part), true, true
+	}
+	// The older versions come from the Service, unless the artifact was
+	// deleted.
+	return nil, false, e.deleted
+}
+
 func (a *Artifacts) List(ctx context.Context) (*artifact.ListResponse, error) {
-	return a.Service.List(ctx, &artifact.ListRequest{
+	resp, err := a.Service.List(ctx, &artifact.ListRequest{
+		AppName:   a.AppName,
+		UserID:    a.UserID,
+		SessionID: a.SessionID,
+	})
+	if err != nil {
+		return resp, err
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if len(a.overlay) == 0 {
+		return resp, nil
+	}
+	names := make(map[string]bool)
+	for _, name := range resp.FileNames {
+		names[name] = true
+	}
+	for name, e := range a.overlay {
+		names[name] = len(e.versions) > 0 || (!e.deleted && names[name])
+	}
+	merged := &artifact.ListResponse{}
+	for _, name := range slices.Sorted(maps.Keys(names)) {
+		if names[name] {
+			merged.FileNames = append(merged.FileNames, name)
+		}
+	}
+	return merged, nil
+}
+
+func (a *Artifacts) Delete(ctx context.Context, name string) error {
+	err := a.Service.Delete(ctx, &artifact.DeleteRequest{
 		AppName:   a.AppName,
 		UserID:    a.UserID,
 		SessionID: a.SessionID,
+		FileName:  name,
 	})
+	if err != nil {
+		return err
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.overlay == nil {
+		a.overlay = make(map[string]*overlayEntry)
+	}
+	a.overlay[name] = &overlayEntry{versions: make(map[int64]*genai.Part), deleted: true}
+	return nil
+}
+
+// clonePart returns a copy of part, so that the caller modifying a part it
+// saved or loaded does not modify the artifact.
+func clonePart(part *genai.Part) *genai.Part {
+	if part == nil {
+		return nil
+	}
+	c := *part
+	if part.InlineData != nil {
+		blob := *part.InlineData
+		blob.Data = bytes.Clone(part.InlineData.Data)
+		c.InlineData = &blob
+	}
+	if part.FileData != nil {
+		file := *part.FileData
+		c.FileData = &file
+	}
+	return &c
 }
 
-var _ agent.Artifacts = (*Artifacts)(nil)
+var (
+	_ agent.Artifacts       = (*Artifacts)(nil)
+	_ agent.ArtifactDeleter = (*Artifacts)(nil)
+)
@@ -15,6 +15,10 @@
 package artifact_test
 
 import (
+	"context"
+	"errors"
+	"io/fs"
+	"sync"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -122,3 +126,173 @@ func TestArtifacts_Errors(t *testing.T) {
 		t.Errorf("LoadVersion(\"existsArtifact\", 99) succeeded, want error")
 	}
 }
+
+// laggyService is an eventually consistent artifact service: the writes are
+// durable, but only visible to the reads once flushed.
+type laggyService struct {
+	artifact.Service // the replica serving the reads
+	primary          artifact.Service
+
+	mu      sync.Mutex
+	pending []func()
+}
+
+func newLaggyService() *laggyService {
+	return &laggyService{Service: artifact.InMemoryService(), primary: artifact.InMemoryService()}
+}
+
+func (s *laggyService) Save(ctx context.Context, req *artifact.SaveRequest) (*artifact.SaveResponse, error) {
+	resp, err := s.primary.Save(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pending = append(s.pending, func() { _, _ = s.Service.Save(context.Background(), req) })
+	return resp, nil
+}
+
+func (s *laggyService) Delete(ctx context.Context, req *artifact.DeleteRequest) error {
+	if err := s.primary.Delete(ctx, req); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pending = append(s.pending, func() { _ = s.Service.Delete(context.Background(), req) })
+	return nil
+}
+
+// flush makes the writes visible.
+func (s *laggyService) flush() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, apply := range s.pending {
+		apply()
+	}
+	s.pending = nil
+}
+
+func TestArtifacts_ReadYourWrites(t *testing.T) {
+	svc := newLaggyService()
+	newArtifacts := func() *artifactinternal.Artifacts {
+		return &artifactinternal.Artifacts{Service: svc, AppName: "testApp", UserID: "testUser", SessionID: "testSession"}
+	}
+	v1, v2 := genai.NewPartFromText("report v1"), genai.NewPartFromText("report v2")
+
+	// A previous invocation saved the first versions.
+	previous := newArtifacts()
+	for _, name := range []string{"report", "obsolete"} {
+		if _, err := previous.Save(t.Context(), name, v1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	svc.flush()
+
+	// Without the overlay, a save is not visible right away.
+	if _, err := svc.Save(t.Context(), &artifact.SaveRequest{AppName: "testApp", UserID: "testUser", SessionID: "testSession", FileName: "other", Part: v1}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.Load(t.Context(), &artifact.LoadRequest{AppName: "testApp", UserID: "testUser", SessionID: "testSession", FileName: "other"}); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("Load() from the laggy service error = %v, want fs.ErrNotExist", err)
+	}
+
+	a := newArtifacts()
+	resp, err := a.Save(t.Context(), "report", v2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Version != 2 {
+		t.Fatalf("Save() version = %d, want 2", resp.Version)
+	}
+	for _, name := range []string{"draft", "scratch"} {
+		if _, err := a.Save(t.Context(), name, v1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, name := range []string{"scratch", "obsolete"} {
+		if err := a.Delete(t.Context(), name); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	load := func(name string, version int) *genai.Part {
+		t.Helper()
+		resp, err := a.LoadVersion(t.Context(), name, version)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			t.Fatalf("LoadVersion(%q, %d) failed: %v", name, version, err)
+		}
+		return resp.Part
+	}
+	for _, tc := range []struct {
+		name    string
+		version int
+		want    *genai.Part
+	}{
+		{"report", 0, v2},
+		{"report", 2, v2},
+		// The older versions come from the service.
+		{"report", 1, v1},
+		{"draft", 0, v1},
+		// Saved then deleted.
+		{"scratch", 0, nil},
+		// Deleted, although the service still serves it.
+		{"obsolete", 0, nil},
+		{"obsolete", 1, nil},
+	} {
+		if diff := cmp.Diff(tc.want, load(tc.name, tc.version)); diff != "" {
+			t.Errorf("LoadVersion(%q, %d) mismatch (-want +got):\n%s", tc.name, tc.version, diff)
+		}
+	}
+	if got, err := a.Load(t.Context(), "report"); err != nil {
+		t.Errorf("Load(report) failed: %v", err)
+	} else if diff := cmp.Diff(v2, got.Part); diff != "" {
+		t.Errorf("Load(report) mismatch (-want +got):\n%s", diff)
+	}
+
+	list, err := a.List(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"draft", "report"}, list.FileNames); diff != "" {
+		t.Errorf("List() mismatch (-want +got):\n%s", diff)
+	}
+
+	// The next invocation starts without overlay, once the writes are
+	// visible.
+	svc.flush()
+	list, err = newArtifacts().List(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"draft", "other", "report"}, list.FileNames); diff != "" {
+		t.Errorf("List() of the next invocation mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestArtifacts_SavedPartCopied(t *testing.T) {
+	a := artifactinternal.Artifacts{
+		Service:   artifact.InMemoryService(),
+		AppName:   "testApp",
+		UserID:    "testUser",
+		SessionID: "testSession",
+	}
+
+	part := genai.NewPartFromBytes([]byte("data"), "text/plain")
+	if _, err := a.Save(t.Context(), "testArtifact", part); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	// The caller reuses the part it saved.
+	part.InlineData.Data[0] = 'D'
+	part.InlineData.MIMEType = "application/octet-stream"
+
+	resp, err := a.Load(t.Context(), "testArtifact")
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if diff := cmp.Diff(genai.NewPartFromBytes([]byte("data"), "text/plain"), resp.Part); diff != "" {
+		t.Errorf("Loaded part mismatch (-want +got):\n%s", diff)
+	}
+}
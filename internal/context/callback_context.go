@@ -16,6 +16,8 @@ package context
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"iter"
 
 	"google.golang.org/genai"
@@ -45,6 +47,15 @@ func (ia *internalArtifacts) Save(ctx context.Context, name string, data *genai.
 	return resp, nil
 }
 
+// Delete implements [agent.ArtifactDeleter], if the wrapped artifacts do.
+func (ia *internalArtifacts) Delete(ctx context.Context, name string) error {
+	deleter, ok := ia.Artifacts.(agent.ArtifactDeleter)
+	if !ok {
+		return fmt.Errorf("failed to delete artifact %q: %w", name, errors.ErrUnsupported)
+	}
+	return deleter.Delete(ctx, name)
+}
+
 func NewCallbackContext(ctx agent.InvocationContext) agent.CallbackContext {
 	return newCallbackContext(ctx, make(map[string]any))
 }
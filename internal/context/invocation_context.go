@@ -17,10 +17,10 @@ package context
 import (
 	"context"
 
-	"github.com/google/uuid"
 	"google.golang.org/genai"
 
 	"google.golang.org/adk/agent"
+	"google.golang.org/adk/runtimedeps"
 	"google.golang.org/adk/session"
 )
 
@@ -41,7 +41,7 @@ func NewInvocationContext(ctx context.Context, params InvocationContextParams) a
 	return &InvocationContext{
 		Context:      ctx,
 		params:       params,
-		invocationID: "e-" + uuid.NewString(),
+		invocationID: "e-" + runtimedeps.FromContext(ctx).NewID(),
 	}
 }
 
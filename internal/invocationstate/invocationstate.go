@@ -0,0 +1,87 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package invocationstate holds the state kept by the components for every
+// invocation, e.g. the usage of its budget, bounded in number so that a
+// long-running server does not accumulate the state of the past invocations.
+package invocationstate
+
+import "container/list"
+
+// DefaultCapacity is the number of invocations tracked by default.
+const DefaultCapacity = 1024
+
+// Map holds values by invocation ID. Beyond its capacity, the value of the
+// least recently used invocation is forgotten. It is not safe for concurrent
+// use: its users guard it with their own lock.
+type Map[V any] struct {
+	capacity int
+	// order holds the entries, the most recently used first.
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+type entry[V any] struct {
+	id    string
+	value V
+}
+
+// New returns a map holding the values of up to capacity invocations, or
+// DefaultCapacity if capacity is not positive.
+func New[V any](capacity int) *Map[V] {
+	if capacity <= 0 {
+		capacity = DefaultCapacity
+	}
+	return &Map[V]{capacity: capacity, order: list.New(), entries: make(map[string]*list.Element)}
+}
+
+// Get returns the value of the invocation id, if any, marking it used.
+func (m *Map[V]) Get(id string) (V, bool) {
+	e, ok := m.entries[id]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	m.order.MoveToFront(e)
+	return e.Value.(*entry[V]).value, true
+}
+
+// GetOrCreate returns the value of the invocation id, marking it used. If
+// there is none, it sets it to the result of create.
+func (m *Map[V]) GetOrCreate(id string, create func() V) V {
+	if v, ok := m.Get(id); ok {
+		return v
+	}
+	if m.order.Len() >= m.capacity {
+		oldest := m.order.Back()
+		m.order.Remove(oldest)
+		delete(m.entries, oldest.Value.(*entry[V]).id)
+	}
+	v := create()
+	m.entries[id] = m.order.PushFront(&entry[V]{id: id, value: v})
+	return v
+}
+
+// Delete forgets the value of the invocation id.
+func (m *Map[V]) Delete(id string) {
+	if e, ok := m.entries[id]; ok {
+		m.order.Remove(e)
+		delete(m.entries, id)
+	}
+}
+
+// Len returns the number of invocations tracked.
+func (m *Map[V]) Len() int {
+	return m.order.Len()
+}
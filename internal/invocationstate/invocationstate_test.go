@@ -0,0 +1,49 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package invocationstate_test
+
+import (
+	"testing"
+
+	"google.golang.org/adk/internal/invocationstate"
+)
+
+func TestMap(t *testing.T) {
+	m := invocationstate.New[*int](2)
+	created := 0
+	create := func() *int {
+		created++
+		n := created
+		return &n
+	}
+
+	a := m.GetOrCreate("e-1", create)
+	m.GetOrCreate("e-2", create)
+	if got := m.GetOrCreate("e-1", create); got != a {
+		t.Errorf("GetOrCreate(e-1) = %d, want the existing value %d", *got, *a)
+	}
+	// e-2 is the least recently used: it is forgotten for e-3.
+	m.GetOrCreate("e-3", create)
+	if _, ok := m.Get("e-2"); ok {
+		t.Error("Get(e-2) found the least recently used invocation beyond the capacity")
+	}
+	if _, ok := m.Get("e-1"); !ok {
+		t.Error("Get(e-1) did not find a recently used invocation")
+	}
+	m.Delete("e-1")
+	if m.Len() != 1 {
+		t.Errorf("Len() = %d after Delete, want 1", m.Len())
+	}
+}
@@ -0,0 +1,188 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package llmconv holds the conversions of the Gemini requests shared by the
+// adapters of the other providers, e.g. of the schemas of the function
+// declarations to JSON schemas.
+package llmconv
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/model"
+)
+
+const (
+	// InitialUserText is the text of the user content appended to the
+	// requests with an empty history.
+	InitialUserText = "Handle the requests as specified in the System Instruction."
+	// ContinuationUserText is the text of the user content appended to the
+	// requests whose history does not end with a user turn.
+	ContinuationUserText = "Continue processing previous requests as instructed. Exit or provide a summary if no more outputs are needed."
+)
+
+// MaybeAppendUserContent appends a user content to the requests with an
+// empty history or whose history does not end with a user turn, so that
+// the model can continue to output.
+func MaybeAppendUserContent(req *model.LLMRequest) {
+	if len(req.Contents) == 0 {
+		req.Contents = append(req.Contents, genai.NewContentFromText(InitialUserText, genai.RoleUser))
+	}
+	if last := req.Contents[len(req.Contents)-1]; last != nil && last.Role != genai.RoleUser {
+		req.Contents = append(req.Contents, genai.NewContentFromText(ContinuationUserText, genai.RoleUser))
+	}
+}
+
+// SchemaMap converts a Gemini schema to a JSON schema.
+func SchemaMap(s *genai.Schema) map[string]any {
+	m := make(map[string]any)
+	if s.Type != "" && s.Type != genai.TypeUnspecified {
+		typ := strings.ToLower(string(s.Type))
+		if s.Nullable != nil && *s.Nullable {
+			m["type"] = []string{typ, "null"}
+		} else {
+			m["type"] = typ
+		}
+	}
+	if s.Title != "" {
+		m["title"] = s.Title
+	}
+	if s.Description != "" {
+		m["description"] = s.Description
+	}
+	if s.Format != "" {
+		m["format"] = s.Format
+	}
+	if s.Pattern != "" {
+		m["pattern"] = s.Pattern
+	}
+	if len(s.Enum) > 0 {
+		m["enum"] = s.Enum
+	}
+	if s.Default != nil {
+		m["default"] = s.Default
+	}
+	for key, v := range map[string]*int64{
+		"minItems": s.MinItems, "maxItems": s.MaxItems,
+		"minLength": s.MinLength, "maxLength": s.MaxLength,
+		"minProperties": s.MinProperties, "maxProperties": s.MaxProperties,
+	} {
+		if v != nil {
+			m[key] = *v
+		}
+	}
+	if s.Minimum != nil {
+		m["minimum"] = *s.Minimum
+	}
+	if s.Maximum != nil {
+		m["maximum"] = *s.Maximum
+	}
+	if s.Items != nil {
+		m["items"] = SchemaMap(s.Items)
+	}
+	if len(s.Properties) > 0 {
+		properties := make(map[string]any, len(s.Properties))
+		for name, p := range s.Properties {
+			if p != nil {
+				properties[name] = SchemaMap(p)
+			}
+		}
+		m["properties"] = properties
+	}
+	if len(s.Required) > 0 {
+		m["required"] = s.Required
+	}
+	if len(s.AnyOf) > 0 {
+		anyOf := make([]any, 0, len(s.AnyOf))
+		for _, sub := range s.AnyOf {
+			if sub != nil {
+				anyOf = append(anyOf, SchemaMap(sub))
+			}
+		}
+		m["anyOf"] = anyOf
+	}
+	return m
+}
+
+// ParametersSchema returns the JSON schema of the parameters of decl, nil if
+// it has none. ParametersJsonSchema takes precedence over Parameters.
+func ParametersSchema(decl *genai.FunctionDeclaration) (map[string]any, error) {
+	if decl.ParametersJsonSchema != nil {
+		b, err := json.Marshal(decl.ParametersJsonSchema)
+		if err != nil {
+			return nil, err
+		}
+		var schema map[string]any
+		if err := json.Unmarshal(b, &schema); err != nil {
+			return nil, err
+		}
+		return schema, nil
+	}
+	if decl.Parameters != nil {
+		return SchemaMap(decl.Parameters), nil
+	}
+	return nil, nil
+}
+
+// CallIDs pairs the function calls and responses without an ID, e.g. in a
+// history imported from a Gemini session, which the other providers reject.
+// The calls get the ID "<Prefix><n>", n being their index among the calls
+// of the request, so that the IDs are stable as the history grows; the
+// responses get the ID of the first unanswered call of the same function.
+type CallIDs struct {
+	// Prefix is the prefix of the IDs, e.g. "call_".
+	Prefix string
+
+	calls int
+	// pending holds the synthesized IDs of the unanswered calls by function
+	// name.
+	pending map[string][]string
+}
+
+// Call returns call with an ID. The call is copied rather than modified.
+func (c *CallIDs) Call(call *genai.FunctionCall) *genai.FunctionCall {
+	c.calls++
+	if call.ID != "" {
+		return call
+	}
+	copied := *call
+	copied.ID = fmt.Sprintf("%s%d", c.Prefix, c.calls-1)
+	if c.pending == nil {
+		c.pending = make(map[string][]string)
+	}
+	c.pending[call.Name] = append(c.pending[call.Name], copied.ID)
+	return &copied
+}
+
+// Response returns resp with the ID of its call. The response is copied
+// rather than modified.
+func (c *CallIDs) Response(resp *genai.FunctionResponse) *genai.FunctionResponse {
+	if resp.ID != "" {
+		return resp
+	}
+	copied := *resp
+	if pending := c.pending[resp.Name]; len(pending) > 0 {
+		copied.ID, c.pending[resp.Name] = pending[0], pending[1:]
+	} else {
+		// There is no call to pair the response with: the ID is unique but
+		// the model may still reject the message.
+		copied.ID = fmt.Sprintf("%s%d_response", c.Prefix, c.calls)
+		c.calls++
+	}
+	return &copied
+}
@@ -0,0 +1,115 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llmconv_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/internal/llmconv"
+)
+
+func TestSchemaMap(t *testing.T) {
+	schema := &genai.Schema{
+		Type:     genai.TypeObject,
+		Title:    "Query",
+		Required: []string{"city"},
+		Properties: map[string]*genai.Schema{
+			"city": {Type: genai.TypeString, Description: "The city.", Pattern: "^[A-Z]", MinLength: genai.Ptr[int64](1), Default: "Paris"},
+			"days": {Type: genai.TypeInteger, Nullable: genai.Ptr(true), Minimum: genai.Ptr(1.0), Maximum: genai.Ptr(7.0)},
+			"when": {Type: genai.TypeString, Format: "date-time"},
+			"tags": {Type: genai.TypeArray, Items: &genai.Schema{Type: genai.TypeString, Enum: []string{"a", "b"}}, MaxItems: genai.Ptr[int64](3)},
+			"unit": {AnyOf: []*genai.Schema{{Type: genai.TypeString}, {Type: genai.TypeNumber}}},
+		},
+	}
+	want := map[string]any{
+		"type":     "object",
+		"title":    "Query",
+		"required": []string{"city"},
+		"properties": map[string]any{
+			"city": map[string]any{"type": "string", "description": "The city.", "pattern": "^[A-Z]", "minLength": int64(1), "default": "Paris"},
+			"days": map[string]any{"type": []string{"integer", "null"}, "minimum": 1.0, "maximum": 7.0},
+			"when": map[string]any{"type": "string", "format": "date-time"},
+			"tags": map[string]any{"type": "array", "items": map[string]any{"type": "string", "enum": []string{"a", "b"}}, "maxItems": int64(3)},
+			"unit": map[string]any{"anyOf": []any{map[string]any{"type": "string"}, map[string]any{"type": "number"}}},
+		},
+	}
+	if diff := cmp.Diff(want, llmconv.SchemaMap(schema)); diff != "" {
+		t.Errorf("SchemaMap() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestParametersSchema(t *testing.T) {
+	testCases := []struct {
+		name string
+		decl *genai.FunctionDeclaration
+		want map[string]any
+	}{
+		{
+			name: "JSONSchemaFirst",
+			decl: &genai.FunctionDeclaration{
+				ParametersJsonSchema: map[string]any{"type": "object", "properties": map[string]any{"x": map[string]any{"type": "integer"}}},
+				Parameters:           &genai.Schema{Type: genai.TypeString},
+			},
+			want: map[string]any{"type": "object", "properties": map[string]any{"x": map[string]any{"type": "integer"}}},
+		},
+		{
+			name: "Parameters",
+			decl: &genai.FunctionDeclaration{Parameters: &genai.Schema{Type: genai.TypeObject}},
+			want: map[string]any{"type": "object"},
+		},
+		{
+			name: "NoParameters",
+			decl: &genai.FunctionDeclaration{},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := llmconv.ParametersSchema(tc.decl)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("ParametersSchema() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCallIDs(t *testing.T) {
+	ids := llmconv.CallIDs{Prefix: "call_"}
+	var got []string
+	for _, call := range []*genai.FunctionCall{
+		{Name: "a"},
+		{ID: "given", Name: "b"},
+		{Name: "a"},
+	} {
+		got = append(got, ids.Call(call).ID)
+	}
+	for _, resp := range []*genai.FunctionResponse{
+		{Name: "a"},
+		{ID: "given", Name: "b"},
+		{Name: "a"},
+		{Name: "c"},
+	} {
+		got = append(got, ids.Response(resp).ID)
+	}
+	want := []string{"call_0", "given", "call_2", "call_0", "given", "call_2", "call_3_response"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("IDs mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -33,7 +33,9 @@ type State struct {
 	Tools    []tool.Tool
 	Toolsets []tool.Toolset
 
-	IncludeContents string
+	IncludeContents  string
+	CrossBranch      *CrossBranchConfig
+	ContextSelection *ContextSelectionConfig
 
 	GenerateContentConfig *genai.GenerateContentConfig
 
@@ -49,6 +51,10 @@ type State struct {
 	OutputSchema *genai.Schema
 
 	OutputKey string
+
+	ToolChangeNotices bool
+
+	ToolNameCollisions string
 }
 
 type InstructionProvider func(ctx agent.ReadonlyContext) (string, error)
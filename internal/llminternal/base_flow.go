@@ -19,11 +19,16 @@ import (
 	"fmt"
 	"iter"
 	"maps"
+	"reflect"
+	"runtime"
 	"slices"
+	"strconv"
+	"strings"
 
 	"google.golang.org/genai"
 
 	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/plan"
 	"google.golang.org/adk/internal/agent/parentmap"
 	"google.golang.org/adk/internal/agent/runconfig"
 	icontext "google.golang.org/adk/internal/context"
@@ -31,8 +36,10 @@ import (
 	"google.golang.org/adk/internal/toolinternal"
 	"google.golang.org/adk/internal/utils"
 	"google.golang.org/adk/model"
+	"google.golang.org/adk/model/assembly"
 	"google.golang.org/adk/session"
 	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/toolresult"
 )
 
 var ErrModelNotConfigured = errors.New("model not configured; ensure Model is set in llmagent.Config")
@@ -54,6 +61,13 @@ type Flow struct {
 	AfterModelCallbacks  []AfterModelCallback
 	BeforeToolCallbacks  []BeforeToolCallback
 	AfterToolCallbacks   []AfterToolCallback
+
+	// Prefetch configures the speculative tool calls started with the first
+	// model call of the run. It is ignored if BeforeToolCallbacks are set.
+	// Optional.
+	Prefetch *PrefetchConfig
+
+	prefetch *prefetcher
 }
 
 var (
@@ -80,6 +94,7 @@ var (
 
 func (f *Flow) Run(ctx agent.InvocationContext) iter.Seq2[*session.Event, error] {
 	return func(yield func(*session.Event, error) bool) {
+		defer f.prefetch.stop()
 		for {
 			var lastEvent *session.Event
 			for ev, err := range f.runOneStep(ctx) {
@@ -116,18 +131,46 @@ func (f *Flow) runOneStep(ctx agent.InvocationContext) iter.Seq2[*session.Event,
 		req := &model.LLMRequest{
 			Model: f.Model.Name(),
 		}
+		trace, endTrace := assembly.Start(ctx, req)
+		defer endTrace()
+
+		// Create event to pass to callback state delta. The state changes of
+		// the tools preprocessing the request are recorded in it too.
+		stateDelta := make(map[string]any)
 
 		// Preprocess before calling the LLM.
-		if err := f.preprocess(ctx, req); err != nil {
+		if err := f.preprocess(ctx, req, stateDelta); err != nil {
 			yield(nil, err)
 			return
 		}
 		if ctx.Ended() {
 			return
 		}
+		// The decided plans run before the model is called again with their
+		// results.
+		if ev, err := f.runDecidedPlan(ctx, req); err != nil || ev != nil {
+			yield(ev, err)
+			return
+		}
+		if proposePlans(ctx) {
+			done := assembly.Step(req, "flow", "append instruction", "plan")
+			utils.AppendInstructions(req, plan.Instruction)
+			done()
+		}
+		var removedTools map[string]bool
+		if llmAgent := asLLMAgent(ctx.Agent()); llmAgent != nil && llmAgent.internal().ToolChangeNotices {
+			var err error
+			if removedTools, err = detectToolChanges(ctx, req, stateDelta); err != nil {
+				yield(nil, err)
+				return
+			}
+		}
+		// The before tool callbacks may block a call or change its
+		// arguments: the tools are not called before them.
+		if f.Prefetch != nil && f.prefetch == nil && len(f.BeforeToolCallbacks) == 0 {
+			f.prefetch = startPrefetch(ctx, f.Prefetch, req.Tools)
+		}
 		spans := telemetry.StartTrace(ctx, "call_llm")
-		// Create event to pass to callback state delta
-		stateDelta := make(map[string]any)
 		// Calls the LLM.
 		for resp, err := range f.callLLM(ctx, req, stateDelta) {
 			if err != nil {
@@ -138,6 +181,13 @@ func (f *Flow) runOneStep(ctx agent.InvocationContext) iter.Seq2[*session.Event,
 				yield(nil, err)
 				return
 			}
+			if trace != nil && !resp.Partial {
+				resp.CustomMetadata = maps.Clone(resp.CustomMetadata)
+				if resp.CustomMetadata == nil {
+					resp.CustomMetadata = make(map[string]any)
+				}
+				resp.CustomMetadata[assembly.MetadataKey] = trace
+			}
 			// Skip the model response event if there is no content and no error code.
 			// This is needed for the code executor to trigger another loop according to
 			// adk-python src/google/adk/flows/llm_flows/base_llm_flow.py BaseLlmFlow._postprocess_async.
@@ -165,9 +215,22 @@ func (f *Flow) runOneStep(ctx agent.InvocationContext) iter.Seq2[*session.Event,
 			}
 			// TODO: generate and yield an auth event if needed.
 
+			if proposePlans(ctx) {
+				ev, err := proposePlan(ctx, tools, resp)
+				if err != nil {
+					yield(nil, err)
+					return
+				}
+				if ev != nil {
+					// The invocation pauses until the plan is decided.
+					yield(ev, nil)
+					return
+				}
+			}
+
 			// Handle function calls.
 
-			ev, err := f.handleFunctionCalls(ctx, tools, resp)
+			ev, err := f.handleFunctionCalls(ctx, tools, resp, removedTools)
 			if err != nil {
 				yield(nil, err)
 				return
@@ -202,15 +265,23 @@ func (f *Flow) runOneStep(ctx agent.InvocationContext) iter.Seq2[*session.Event,
 	}
 }
 
-func (f *Flow) preprocess(ctx agent.InvocationContext, req *model.LLMRequest) error {
+func (f *Flow) preprocess(ctx agent.InvocationContext, req *model.LLMRequest, stateDelta map[string]any) error {
 	llmAgent, ok := ctx.Agent().(Agent)
 	if !ok {
 		return fmt.Errorf("agent %v is not an LLMAgent", ctx.Agent().Name())
 	}
 
 	// apply request processor functions to the request in the configured order.
+	trace := assembly.ForRequest(req)
 	for _, processor := range f.RequestProcessors {
-		if err := processor(ctx, req); err != nil {
+		var name string
+		if trace != nil {
+			name = funcName(processor)
+		}
+		done := trace.Step(req, "flow", "request processor", name)
+		err := processor(ctx, req)
+		done()
+		if err != nil {
 			return err
 		}
 	}
@@ -226,32 +297,48 @@ func (f *Flow) preprocess(ctx agent.InvocationContext, req *model.LLMRequest) er
 		tools = append(tools, tsTools...)
 	}
 
-	return toolPreprocess(ctx, req, tools)
+	return toolPreprocess(ctx, req, tools, stateDelta)
 }
 
 // toolPreprocess runs tool preprocess on the given request
 // If a tool set is encountered, it's expanded recursively in DFS fashion.
+// The state changes made by the tools are recorded in stateDelta, which is
+// saved with the model response event.
 // TODO: check need/feasibility of running this concurrently.
-func toolPreprocess(ctx agent.InvocationContext, req *model.LLMRequest, tools []tool.Tool) error {
+func toolPreprocess(ctx agent.InvocationContext, req *model.LLMRequest, tools []tool.Tool, stateDelta map[string]any) error {
+	policy := toolNameCollisionPolicy(ctx)
 	for _, t := range tools {
+		if ft, ok := t.(toolinternal.FunctionTool); ok {
+			if _, dup := req.Tools[t.Name()]; dup {
+				var err error
+				if t, err = resolveDuplicateTool(req, ft, policy); err != nil {
+					return err
+				}
+			}
+		}
 		requestProcessor, ok := t.(toolinternal.RequestProcessor)
 		if !ok {
 			return fmt.Errorf("tool %q does not implement RequestProcessor() method", t.Name())
 		}
 		// TODO: how to prevent mutation on this?
-		toolCtx := toolinternal.NewToolContext(ctx, "", &session.EventActions{})
-		if err := requestProcessor.ProcessRequest(toolCtx, req); err != nil {
+		toolCtx := toolinternal.NewToolContext(ctx, "", &session.EventActions{StateDelta: stateDelta})
+		done := assembly.Step(req, "tool", "process request", t.Name())
+		err := requestProcessor.ProcessRequest(toolCtx, req)
+		done()
+		if err != nil {
 			return err
 		}
 	}
-	return nil
+	return resolveNativeToolCollisions(req, policy)
 }
 
 func (f *Flow) callLLM(ctx agent.InvocationContext, req *model.LLMRequest, stateDelta map[string]any) iter.Seq2[*model.LLMResponse, error] {
 	return func(yield func(*model.LLMResponse, error) bool) {
-		for _, callback := range f.BeforeModelCallbacks {
+		for i, callback := range f.BeforeModelCallbacks {
 			cctx := icontext.NewCallbackContextWithDelta(ctx, stateDelta)
+			done := assembly.Step(req, "flow", "before model callback", strconv.Itoa(i))
 			callbackResponse, callbackErr := callback(cctx, req)
+			done()
 
 			if callbackResponse != nil || callbackErr != nil {
 				yield(callbackResponse, callbackErr)
@@ -334,9 +421,9 @@ func (f *Flow) finalizeModelResponseEvent(ctx agent.InvocationContext, resp *mod
 	// FunctionCall & FunctionResponse matching algorithm assumes non-empty function call IDs
 	// but function call ID is optional in genai API and some models do not use the field.
 	// Generate function call ids. (see functions.populate_client_function_call_id in python SDK)
-	utils.PopulateClientFunctionCallID(resp.Content)
+	utils.PopulateClientFunctionCallID(ctx, resp.Content)
 
-	ev := session.NewEvent(ctx.InvocationID())
+	ev := session.NewEventWithContext(ctx, ctx.InvocationID())
 	ev.Author = ctx.Agent().Name()
 	ev.Branch = ctx.Branch()
 	ev.LLMResponse = *resp
@@ -364,15 +451,21 @@ func findLongRunningFunctionCallIDs(c *genai.Content, tools map[string]tool.Tool
 }
 
 // handleFunctionCalls calls the functions and returns the function response event.
+// Calls to the tools in removedTools are answered with an error response
+// instead of failing the invocation.
 //
 // TODO: accept filters to include/exclude function calls.
 // TODO: check feasibility of running tool.Run concurrently.
-func (f *Flow) handleFunctionCalls(ctx agent.InvocationContext, toolsDict map[string]tool.Tool, resp *model.LLMResponse) (*session.Event, error) {
+func (f *Flow) handleFunctionCalls(ctx agent.InvocationContext, toolsDict map[string]tool.Tool, resp *model.LLMResponse, removedTools map[string]bool) (*session.Event, error) {
 	var fnResponseEvents []*session.Event
 
 	fnCalls := utils.FunctionCalls(resp.Content)
 	for _, fnCall := range fnCalls {
 		curTool, ok := toolsDict[fnCall.Name]
+		if !ok && removedTools[fnCall.Name] {
+			fnResponseEvents = append(fnResponseEvents, removedToolResponseEvent(ctx, fnCall, toolsDict))
+			continue
+		}
 		if !ok {
 			return nil, fmt.Errorf("unknown tool: %q", fnCall.Name)
 		}
@@ -384,11 +477,21 @@ func (f *Flow) handleFunctionCalls(ctx agent.InvocationContext, toolsDict map[st
 		// toolCtx := tool.
 		spans := telemetry.StartTrace(ctx, "execute_tool "+fnCall.Name)
 
-		result := f.callTool(funcTool, fnCall.Args, toolCtx)
+		result, prefetched := f.callTool(funcTool, fnCall.Args, toolCtx)
+		if prefetched {
+			telemetry.TracePrefetchHit(spans)
+		}
+		// The model only sees the data of an enveloped result: its metadata
+		// is saved in the event.
+		var customMetadata map[string]any
+		if env, ok := toolresult.Unwrap(result); ok {
+			result = env.Data
+			customMetadata = map[string]any{toolresult.MetadataKey: map[string]any{fnCall.ID: env.Metadata()}}
+		}
 
 		// TODO: agent.canonical_after_tool_callbacks
 		// TODO: handle long-running tool.
-		ev := session.NewEvent(ctx.InvocationID())
+		ev := session.NewEventWithContext(ctx, ctx.InvocationID())
 		ev.LLMResponse = model.LLMResponse{
 			Content: &genai.Content{
 				Role: "user",
@@ -402,6 +505,7 @@ func (f *Flow) handleFunctionCalls(ctx agent.InvocationContext, toolsDict map[st
 					},
 				},
 			},
+			CustomMetadata: customMetadata,
 		}
 		ev.Author = ctx.Agent().Name()
 		ev.Branch = ctx.Branch()
@@ -419,16 +523,47 @@ func (f *Flow) handleFunctionCalls(ctx agent.InvocationContext, toolsDict map[st
 	return mergedEvent, nil
 }
 
-func (f *Flow) callTool(tool toolinternal.FunctionTool, fArgs map[string]any, toolCtx tool.Context) map[string]any {
+// removedToolResponseEvent returns the function response event telling the
+// model that the called tool does not exist anymore.
+func removedToolResponseEvent(ctx agent.InvocationContext, fnCall *genai.FunctionCall, toolsDict map[string]tool.Tool) *session.Event {
+	available := slices.Sorted(maps.Keys(toolsDict))
+	ev := session.NewEventWithContext(ctx, ctx.InvocationID())
+	ev.LLMResponse = model.LLMResponse{
+		Content: &genai.Content{
+			Role: "user",
+			Parts: []*genai.Part{
+				{
+					FunctionResponse: &genai.FunctionResponse{
+						ID:   fnCall.ID,
+						Name: fnCall.Name,
+						Response: map[string]any{
+							"error": fmt.Sprintf("tool %q was removed and cannot be called anymore; available tools: %s", fnCall.Name, strings.Join(available, ", ")),
+						},
+					},
+				},
+			},
+		},
+	}
+	ev.Author = ctx.Agent().Name()
+	ev.Branch = ctx.Branch()
+	return ev
+}
+
+// callTool calls the tool, unless its result was prefetched with the same
+// arguments. It reports whether the prefetched result is used.
+func (f *Flow) callTool(tool toolinternal.FunctionTool, fArgs map[string]any, toolCtx tool.Context) (map[string]any, bool) {
+	var prefetched bool
 	result, err := f.invokeBeforeToolCallbacks(tool, fArgs, toolCtx)
 	if result == nil && err == nil {
-		result, err = tool.Run(toolCtx, fArgs)
+		if result, prefetched = f.prefetch.result(tool.Name(), fArgs); !prefetched {
+			result, err = tool.Run(toolCtx, fArgs)
+		}
 	}
 	result, err = f.invokeAfterToolCallbacks(tool, fArgs, toolCtx, result, err)
 	if err != nil {
-		return map[string]any{"error": err.Error()}
+		return map[string]any{"error": err.Error()}, prefetched
 	}
-	return result
+	return result, prefetched
 }
 
 func (f *Flow) invokeBeforeToolCallbacks(tool toolinternal.FunctionTool, fArgs map[string]any, toolCtx tool.Context) (map[string]any, error) {
@@ -471,12 +606,16 @@ func mergeParallelFunctionResponseEvents(events []*session.Event) (*session.Even
 	}
 	var parts []*genai.Part
 	var actions *session.EventActions
+	results := make(map[string]any)
 	for _, ev := range events {
 		if ev == nil || ev.LLMResponse.Content == nil {
 			continue
 		}
 		parts = append(parts, ev.LLMResponse.Content.Parts...)
 		actions = mergeEventActions(actions, &ev.Actions)
+		if m, ok := ev.CustomMetadata[toolresult.MetadataKey].(map[string]any); ok {
+			maps.Copy(results, m)
+		}
 	}
 	// reuse events[0]
 	ev := events[0]
@@ -486,6 +625,9 @@ func mergeParallelFunctionResponseEvents(events []*session.Event) (*session.Even
 			Parts: parts,
 		},
 	}
+	if len(results) > 0 {
+		ev.CustomMetadata = map[string]any{toolresult.MetadataKey: results}
+	}
 	ev.Actions = *actions
 	return ev, nil
 }
@@ -514,5 +656,19 @@ func mergeEventActions(base, other *session.EventActions) *session.EventActions
 	if other.StateDelta != nil {
 		base.StateDelta = other.StateDelta
 	}
+	// The state operations are applied in order: unlike the delta, they all
+	// apply.
+	base.StateOps = append(base.StateOps, other.StateOps...)
 	return base
 }
+
+// funcName returns the name of the function f, without its package path,
+// e.g. "llminternal.basicRequestProcessor".
+func funcName(f any) string {
+	fn := runtime.FuncForPC(reflect.ValueOf(f).Pointer())
+	if fn == nil {
+		return ""
+	}
+	name := fn.Name()
+	return name[strings.LastIndex(name, "/")+1:]
+}
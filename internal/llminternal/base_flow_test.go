@@ -21,8 +21,11 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"google.golang.org/genai"
 
+	"google.golang.org/adk/agent"
+	icontext "google.golang.org/adk/internal/context"
 	"google.golang.org/adk/internal/toolinternal"
 	"google.golang.org/adk/model"
+	"google.golang.org/adk/session"
 	"google.golang.org/adk/tool"
 )
 
@@ -266,10 +269,52 @@ func TestCallTool(t *testing.T) {
 				AfterToolCallbacks:  tc.afterToolCallbacks,
 			}
 
-			got := f.callTool(tc.tool, tc.args, nil)
+			got, _ := f.callTool(tc.tool, tc.args, nil)
 			if diff := cmp.Diff(tc.want, got); diff != "" {
 				t.Errorf("callTool() mismatch (-want +got):\n%s", diff)
 			}
 		})
 	}
 }
+
+func TestHandleFunctionCalls_MergesStateOps(t *testing.T) {
+	a, err := agent.New(agent.Config{Name: "agent"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := icontext.NewInvocationContext(t.Context(), icontext.InvocationContextParams{Agent: a})
+	tools := map[string]tool.Tool{
+		"count": &mockFunctionTool{
+			name: "count",
+			runFunc: func(ctx tool.Context, args map[string]any) (map[string]any, error) {
+				ctx.Actions().IncrementState("calls", 1)
+				return map[string]any{}, nil
+			},
+		},
+		"log": &mockFunctionTool{
+			name: "log",
+			runFunc: func(ctx tool.Context, args map[string]any) (map[string]any, error) {
+				ctx.Actions().AppendState("log", "logged")
+				ctx.Actions().IncrementState("calls", 1)
+				return map[string]any{}, nil
+			},
+		},
+	}
+	resp := &model.LLMResponse{Content: &genai.Content{Role: genai.RoleModel, Parts: []*genai.Part{
+		{FunctionCall: &genai.FunctionCall{ID: "1", Name: "count"}},
+		{FunctionCall: &genai.FunctionCall{ID: "2", Name: "log"}},
+	}}}
+
+	ev, err := (&Flow{}).handleFunctionCalls(ctx, tools, resp, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []session.StateOp{
+		{Op: session.StateOpIncrement, Key: "calls", Payload: int64(1)},
+		{Op: session.StateOpAppend, Key: "log", Payload: []any{"logged"}},
+		{Op: session.StateOpIncrement, Key: "calls", Payload: int64(1)},
+	}
+	if diff := cmp.Diff(want, ev.Actions.StateOps); diff != "" {
+		t.Errorf("StateOps mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -25,6 +25,7 @@ import (
 	"google.golang.org/genai"
 
 	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/checkpoint"
 	"google.golang.org/adk/internal/utils"
 	"google.golang.org/adk/model"
 	"google.golang.org/adk/session"
@@ -40,7 +41,8 @@ func ContentsRequestProcessor(ctx agent.InvocationContext, req *model.LLMRequest
 		return nil // In python, no error is yielded.
 	}
 	fn := buildContentsDefault // "" or "default".
-	if llmAgent.internal().IncludeContents == "none" {
+	includeNone := llmAgent.internal().IncludeContents == "none"
+	if includeNone {
 		// Include current turn context only (no conversation history)
 		fn = buildContentsCurrentTurnContextOnly
 	}
@@ -49,15 +51,54 @@ func ContentsRequestProcessor(ctx agent.InvocationContext, req *model.LLMRequest
 		for e := range ctx.Session().Events().All() {
 			events = append(events, e)
 		}
+		// Leave out the events rewound by a checkpoint restore.
+		var err error
+		if events, err = checkpoint.Filter(ctx.Session().State(), events); err != nil {
+			return err
+		}
+		// The sibling branches are part of the conversation history.
+		if !includeNone {
+			events = includeCrossBranchEvents(llmAgent.internal().CrossBranch, ctx.Agent().Name(), ctx.Branch(), events)
+		}
 	}
 	contents, err := fn(ctx.Agent().Name(), ctx.Branch(), events)
 	if err != nil {
 		return err
 	}
+	if cfg := llmAgent.internal().ContextSelection; cfg != nil && !includeNone {
+		systemTokens := 0
+		if req.Config != nil && req.Config.SystemInstruction != nil {
+			systemTokens = estimateTokens(req.Config.SystemInstruction)
+		}
+		if contents, err = selectContents(ctx, cfg, systemTokens, latestUserText(ctx, contents), contents); err != nil {
+			return err
+		}
+	}
 	req.Contents = append(req.Contents, contents...)
 	return nil
 }
 
+// latestUserText returns the text of the user content of the invocation,
+// or else of the last user message of contents.
+func latestUserText(ctx agent.InvocationContext, contents []*genai.Content) string {
+	content := ctx.UserContent()
+	for i := len(contents) - 1; content == nil && i >= 0; i-- {
+		if isUserMessage(contents[i]) {
+			content = contents[i]
+		}
+	}
+	if content == nil {
+		return ""
+	}
+	var texts []string
+	for _, p := range content.Parts {
+		if p.Text != "" {
+			texts = append(texts, p.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
 // buildContentsDefault returns the contents for the LLM request by applying
 // filtering, rearrangement, and content processing to the given events.
 func buildContentsDefault(agentName, invocationBranch string, events []*session.Event) ([]*genai.Content, error) {
@@ -82,6 +123,11 @@ func buildContentsDefault(agentName, invocationBranch string, events []*session.
 		if isAuthEvent(ev) {
 			continue
 		}
+		// The partial events stored in the session are covered by the event
+		// ending their response.
+		if ev.Partial {
+			continue
+		}
 		if isOtherAgentReply(agentName, ev) {
 			filtered = append(filtered, ConvertForeignEvent(ev))
 		} else {
@@ -0,0 +1,330 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llminternal
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"unicode"
+
+	"google.golang.org/genai"
+)
+
+// ContextSelectionConfig selects the turns of the history included in the
+// requests by relevance. See llmagent.ContextSelection.
+type ContextSelectionConfig struct {
+	// MaxTokens is the estimated token budget of the system instruction and
+	// the contents.
+	MaxTokens int
+	// KeepRecentTurns is the number of most recent turns always included.
+	KeepRecentTurns int
+	// Scorer scores the older turns against the latest user message.
+	Scorer ContextScorer
+}
+
+// ContextScorer scores the relevance of the text of a turn to a query.
+type ContextScorer interface {
+	Score(ctx context.Context, query, text string) (float64, error)
+}
+
+// Embedder returns the embedding vector of a text.
+type Embedder interface {
+	Embed(ctx context.Context, text string) ([]float32, error)
+}
+
+// LexicalScorer scores a text by the fraction of the words of the query it
+// contains. Words shorter than three letters are ignored.
+type LexicalScorer struct{}
+
+// Score implements ContextScorer.
+func (LexicalScorer) Score(_ context.Context, query, text string) (float64, error) {
+	terms := words(query)
+	if len(terms) == 0 {
+		return 0, nil
+	}
+	found := words(text)
+	matched := 0
+	for term := range terms {
+		if found[term] {
+			matched++
+		}
+	}
+	return float64(matched) / float64(len(terms)), nil
+}
+
+func words(s string) map[string]bool {
+	set := make(map[string]bool)
+	for _, w := range strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if len([]rune(w)) >= 3 {
+			set[w] = true
+		}
+	}
+	return set
+}
+
+// EmbeddingScorer scores a text by the cosine similarity of its embedding
+// with the embedding of the query.
+type EmbeddingScorer struct {
+	Embedder Embedder
+}
+
+// Score implements ContextScorer.
+func (s EmbeddingScorer) Score(ctx context.Context, query, text string) (float64, error) {
+	q, err := s.Embedder.Embed(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+	t, err := s.Embedder.Embed(ctx, text)
+	if err != nil {
+		return 0, err
+	}
+	if len(q) != len(t) {
+		return 0, fmt.Errorf("embeddings of different dimensions: %d and %d", len(q), len(t))
+	}
+	var dot, nq, nt float64
+	for i := range q {
+		dot += float64(q[i]) * float64(t[i])
+		nq += float64(q[i]) * float64(q[i])
+		nt += float64(t[i]) * float64(t[i])
+	}
+	if nq == 0 || nt == 0 {
+		return 0, nil
+	}
+	return dot / math.Sqrt(nq*nt), nil
+}
+
+// scoreCache caches the scores of the turns during an invocation, by turn
+// and query hash.
+type scoreCache struct {
+	mu     sync.Mutex
+	scores map[[2][sha256.Size]byte]float64
+}
+
+type scoreCacheKey struct{}
+
+// WithScoreCache returns ctx with an empty cache of the scores computed by
+// the context selection.
+func WithScoreCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, scoreCacheKey{}, &scoreCache{scores: make(map[[2][sha256.Size]byte]float64)})
+}
+
+func (c *scoreCache) score(ctx context.Context, scorer ContextScorer, query, text string) (float64, error) {
+	if c == nil {
+		return scorer.Score(ctx, query, text)
+	}
+	key := [2][sha256.Size]byte{sha256.Sum256([]byte(text)), sha256.Sum256([]byte(query))}
+	c.mu.Lock()
+	score, ok := c.scores[key]
+	c.mu.Unlock()
+	if ok {
+		return score, nil
+	}
+	score, err := scorer.Score(ctx, query, text)
+	if err != nil {
+		return 0, err
+	}
+	c.mu.Lock()
+	c.scores[key] = score
+	c.mu.Unlock()
+	return score, nil
+}
+
+// turn is a user message and the contents following it.
+type turn struct {
+	contents []*genai.Content
+	tokens   int
+}
+
+// selectContents returns the contents fitting the token budget of cfg,
+// minus systemTokens for the system instruction. If they all fit, they are
+// returned unchanged. Otherwise the protected turns, i.e. the most recent
+// ones and the ones with unanswered function calls, are kept, and the
+// remaining budget is filled with the older turns scoring best against
+// query, in their original order. A marker content replaces each run of
+// omitted turns; the markers are not counted in the budget. Ties are
+// broken in favor of the most recent turns, so that the selection is
+// deterministic for a deterministic scorer.
+func selectContents(ctx context.Context, cfg *ContextSelectionConfig, systemTokens int, query string, contents []*genai.Content) ([]*genai.Content, error) {
+	turns := splitTurns(contents)
+	budget := cfg.MaxTokens - systemTokens
+	total := 0
+	for _, t := range turns {
+		total += t.tokens
+	}
+	if total <= budget {
+		return contents, nil
+	}
+
+	keep := make([]bool, len(turns))
+	var candidates []int
+	for i, t := range turns {
+		if i >= len(turns)-cfg.KeepRecentTurns || hasUnansweredCalls(t) {
+			keep[i] = true
+			budget -= t.tokens
+		} else {
+			candidates = append(candidates, i)
+		}
+	}
+
+	cache, _ := ctx.Value(scoreCacheKey{}).(*scoreCache)
+	scores := make(map[int]float64, len(candidates))
+	for _, i := range candidates {
+		score, err := cache.score(ctx, cfg.Scorer, query, turnText(turns[i]))
+		if err != nil {
+			return nil, fmt.Errorf("failed to score the history: %w", err)
+		}
+		scores[i] = score
+	}
+	slices.SortStableFunc(candidates, func(a, b int) int {
+		if scores[a] != scores[b] {
+			if scores[a] > scores[b] {
+				return -1
+			}
+			return 1
+		}
+		return b - a
+	})
+	for _, i := range candidates {
+		if turns[i].tokens <= budget {
+			keep[i] = true
+			budget -= turns[i].tokens
+		}
+	}
+
+	var selected []*genai.Content
+	omitted := 0
+	for i, t := range turns {
+		if !keep[i] {
+			omitted++
+			continue
+		}
+		if omitted > 0 {
+			selected = append(selected, omissionMarker(omitted))
+			omitted = 0
+		}
+		selected = append(selected, t.contents...)
+	}
+	if omitted > 0 {
+		selected = append(selected, omissionMarker(omitted))
+	}
+	return selected, nil
+}
+
+// splitTurns splits contents into turns, each starting with a user message
+// other than function responses.
+func splitTurns(contents []*genai.Content) []turn {
+	var turns []turn
+	for _, c := range contents {
+		if len(turns) == 0 || isUserMessage(c) {
+			turns = append(turns, turn{})
+		}
+		t := &turns[len(turns)-1]
+		t.contents = append(t.contents, c)
+		t.tokens += estimateContentTokens(c)
+	}
+	return turns
+}
+
+func isUserMessage(c *genai.Content) bool {
+	if c.Role != genai.RoleUser {
+		return false
+	}
+	for _, p := range c.Parts {
+		if p.FunctionResponse != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// hasUnansweredCalls reports whether t has more function calls than function
+// responses for a function.
+func hasUnansweredCalls(t turn) bool {
+	pending := make(map[string]int)
+	for _, c := range t.contents {
+		for _, p := range c.Parts {
+			if p.FunctionCall != nil {
+				pending[p.FunctionCall.Name]++
+			}
+			if p.FunctionResponse != nil {
+				pending[p.FunctionResponse.Name]--
+			}
+		}
+	}
+	for _, n := range pending {
+		if n > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// turnText returns the text scored for t: the texts of its contents, and
+// the names and arguments of its function calls and responses.
+func turnText(t turn) string {
+	var b strings.Builder
+	for _, c := range t.contents {
+		for _, p := range c.Parts {
+			switch {
+			case p.Text != "":
+				b.WriteString(p.Text)
+			case p.FunctionCall != nil:
+				args, _ := json.Marshal(p.FunctionCall.Args)
+				fmt.Fprintf(&b, "%s %s", p.FunctionCall.Name, args)
+			case p.FunctionResponse != nil:
+				response, _ := json.Marshal(p.FunctionResponse.Response)
+				fmt.Fprintf(&b, "%s %s", p.FunctionResponse.Name, response)
+			}
+			b.WriteByte('\n')
+		}
+	}
+	return b.String()
+}
+
+// estimateContentTokens estimates the tokens of content like estimateTokens,
+// counting the JSON encoding of its function calls and responses too.
+func estimateContentTokens(content *genai.Content) int {
+	n := 0
+	for _, p := range content.Parts {
+		n += len(p.Text)
+		if p.FunctionCall != nil {
+			b, _ := json.Marshal(p.FunctionCall)
+			n += len(b)
+		}
+		if p.FunctionResponse != nil {
+			b, _ := json.Marshal(p.FunctionResponse)
+			n += len(b)
+		}
+	}
+	return (n + 3) / 4
+}
+
+// omissionMarker returns the content telling the model that n turns of the
+// conversation were omitted.
+func omissionMarker(n int) *genai.Content {
+	text := "[1 earlier turn of the conversation omitted]"
+	if n > 1 {
+		text = fmt.Sprintf("[%d earlier turns of the conversation omitted]", n)
+	}
+	return genai.NewContentFromText(text, genai.RoleUser)
+}
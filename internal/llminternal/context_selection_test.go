@@ -0,0 +1,148 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llminternal_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent/llmagent"
+	icontext "google.golang.org/adk/internal/context"
+	"google.golang.org/adk/internal/llminternal"
+	"google.golang.org/adk/internal/utils"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/session"
+)
+
+// countingScorer counts the calls of the wrapped scorer.
+type countingScorer struct {
+	llmagent.ContextScorer
+	calls int
+}
+
+func (s *countingScorer) Score(ctx context.Context, query, text string) (float64, error) {
+	s.calls++
+	return s.ContextScorer.Score(ctx, query, text)
+}
+
+func TestContentsRequestProcessor_ContextSelection(t *testing.T) {
+	// pad pads s to 40 characters, i.e. 10 estimated tokens.
+	pad := func(s string) string { return s + strings.Repeat(".", 40-len(s)) }
+	user := func(s string) *genai.Content { return genai.NewContentFromText(pad(s), genai.RoleUser) }
+	reply := func(s string) *genai.Content { return genai.NewContentFromText(pad(s), genai.RoleModel) }
+	marker := func(s string) *genai.Content { return genai.NewContentFromText(s, genai.RoleUser) }
+	weatherCall := genai.NewContentFromFunctionCall("get_weather", map[string]any{"city": "Paris"}, genai.RoleModel)
+
+	// Turns of 20 tokens, and the current user message of 10 tokens.
+	history := [][]*genai.Content{
+		{user("I am allergic to peanuts"), reply("Noted")},
+		{user("What is the weather in Paris?"), reply("Sunny")},
+		{user("Tell me a joke about cats"), reply("Meow")},
+		{user("Recommend a film for tonight"), reply("Casablanca")},
+		{user("Suggest a snack without any peanuts")},
+	}
+	pending := [][]*genai.Content{history[0], {history[1][0], weatherCall}, history[2], history[3], history[4]}
+
+	for _, tc := range []struct {
+		name      string
+		history   [][]*genai.Content
+		maxTokens int
+		system    string
+		want      []*genai.Content
+	}{
+		{
+			name:      "FitsBudget",
+			history:   history,
+			maxTokens: 90,
+			want:      append(append(append(append(history[0], history[1]...), history[2]...), history[3]...), history[4]...),
+		},
+		{
+			name:      "SelectByScore",
+			history:   history,
+			maxTokens: 30,
+			want:      append(append(history[0], marker("[3 earlier turns of the conversation omitted]")), history[4]...),
+		},
+		{
+			name:      "ChronologicalOrder",
+			history:   history,
+			maxTokens: 50,
+			want:      append(append(append(history[0], marker("[2 earlier turns of the conversation omitted]")), history[3]...), history[4]...),
+		},
+		{
+			name:      "BudgetBoundary",
+			history:   history,
+			maxTokens: 49,
+			want:      append(append(history[0], marker("[3 earlier turns of the conversation omitted]")), history[4]...),
+		},
+		{
+			name:      "SystemInstruction",
+			history:   history,
+			maxTokens: 60,
+			system:    pad("You are a helpful assistant"),
+			want:      append(append(append(history[0], marker("[2 earlier turns of the conversation omitted]")), history[3]...), history[4]...),
+		},
+		{
+			name:      "UnansweredCall",
+			history:   pending,
+			maxTokens: 30,
+			want: append(append(append([]*genai.Content{marker("[1 earlier turn of the conversation omitted]")}, pending[1]...),
+				marker("[2 earlier turns of the conversation omitted]")), history[4]...),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var events []*session.Event
+			for _, turn := range tc.history {
+				for _, c := range turn {
+					author := "assistant"
+					if c.Role == genai.RoleUser {
+						author = "user"
+					}
+					events = append(events, &session.Event{Author: author, LLMResponse: model.LLMResponse{Content: c}})
+				}
+			}
+			scorer := &countingScorer{ContextScorer: llmagent.LexicalScorer()}
+			a := utils.Must(llmagent.New(llmagent.Config{
+				Name:             "assistant",
+				Model:            &testModel{},
+				ContextSelection: &llmagent.ContextSelection{MaxTokens: tc.maxTokens, KeepRecentTurns: 1, Scorer: scorer},
+			}))
+			ctx := icontext.NewInvocationContext(llminternal.WithScoreCache(t.Context()), icontext.InvocationContextParams{
+				Agent:   a,
+				Session: &fakeSession{events: events},
+			})
+
+			for range 2 {
+				req := &model.LLMRequest{}
+				if tc.system != "" {
+					req.Config = &genai.GenerateContentConfig{SystemInstruction: genai.NewContentFromText(tc.system, genai.RoleUser)}
+				}
+				if err := llminternal.ContentsRequestProcessor(ctx, req); err != nil {
+					t.Fatal(err)
+				}
+				if diff := cmp.Diff(tc.want, req.Contents); diff != "" {
+					t.Errorf("contents mismatch (-want +got):\n%s", diff)
+				}
+			}
+			// The scores of the second request are cached.
+			if max := len(tc.history) - 1; scorer.calls > max {
+				t.Errorf("scorer called %d times, want at most %d", scorer.calls, max)
+			}
+		})
+	}
+}
@@ -21,6 +21,14 @@ import (
 )
 
 func Genai2LLMResponse(res *genai.GenerateContentResponse) *model.LLMResponse {
+	resp := genai2LLMResponse(res)
+	if len(res.Candidates) > 1 {
+		resp.Candidates = res.Candidates
+	}
+	return resp
+}
+
+func genai2LLMResponse(res *genai.GenerateContentResponse) *model.LLMResponse {
 	usageMetadata := res.UsageMetadata
 	if len(res.Candidates) > 0 && res.Candidates[0] != nil {
 		candidate := res.Candidates[0]
@@ -55,7 +63,7 @@ func Genai2LLMResponse(res *genai.GenerateContentResponse) *model.LLMResponse {
 		}
 	}
 	return &model.LLMResponse{
-		ErrorCode:     "UNKNOWN_ERROR",
+		ErrorCode:     string(model.ErrorCodeUnknown),
 		ErrorMessage:  "Unknown error.",
 		UsageMetadata: usageMetadata,
 	}
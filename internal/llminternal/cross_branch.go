@@ -0,0 +1,137 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llminternal
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/internal/utils"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/session"
+)
+
+// CrossBranchConfig makes the events of the sibling branches visible to an
+// agent. See llmagent.CrossBranchVisibility.
+type CrossBranchConfig struct {
+	// Agents is the allowlist of the agents whose events are visible.
+	// Empty means all the agents.
+	Agents []string
+	// MaxTokens bounds the estimated tokens of the visible events.
+	// Zero means unlimited.
+	MaxTokens int
+}
+
+// includeCrossBranchEvents replaces the events of the branches that are not
+// visible from invocationBranch by user events attributing their text to
+// their author. Function calls and responses are collapsed to short
+// notations, so that the model does not answer the calls of another agent.
+// When the rendered events exceed the token budget, the most recent ones
+// are kept. The events of the branch are returned unchanged.
+func includeCrossBranchEvents(cfg *CrossBranchConfig, agentName, invocationBranch string, events []*session.Event) []*session.Event {
+	if cfg == nil || invocationBranch == "" {
+		return events
+	}
+	rendered := make(map[int]*session.Event)
+	tokens := 0
+	for i := len(events) - 1; i >= 0; i-- {
+		ev := events[i]
+		if eventBelongsToBranch(invocationBranch, ev) || !crossBranchVisible(cfg, agentName, ev) {
+			continue
+		}
+		r := renderCrossBranchEvent(ev)
+		if r == nil {
+			continue
+		}
+		if cfg.MaxTokens > 0 {
+			tokens += estimateTokens(r.Content)
+			if tokens > cfg.MaxTokens {
+				break
+			}
+		}
+		rendered[i] = r
+	}
+
+	result := make([]*session.Event, 0, len(events))
+	for i, ev := range events {
+		if r, ok := rendered[i]; ok {
+			result = append(result, r)
+		} else {
+			result = append(result, ev)
+		}
+	}
+	return result
+}
+
+func crossBranchVisible(cfg *CrossBranchConfig, agentName string, ev *session.Event) bool {
+	if ev.Partial || ev.Author == "user" || ev.Author == agentName || isAuthEvent(ev) {
+		return false
+	}
+	return len(cfg.Agents) == 0 || slices.Contains(cfg.Agents, ev.Author)
+}
+
+// renderCrossBranchEvent returns a user event, visible from all the
+// branches, rendering ev. It returns nil if there is nothing to render.
+func renderCrossBranchEvent(ev *session.Event) *session.Event {
+	content := utils.Content(ev)
+	if content == nil {
+		return nil
+	}
+	prefix := fmt.Sprintf("[%s agent]: ", ev.Author)
+	var parts []*genai.Part
+	for _, p := range content.Parts {
+		switch {
+		case p == nil || p.Thought:
+		case p.Text != "":
+			parts = append(parts, &genai.Part{Text: prefix + p.Text})
+		case p.FunctionCall != nil:
+			parts = append(parts, &genai.Part{Text: prefix + "(called " + callNotation(p.FunctionCall) + ")"})
+		case p.FunctionResponse != nil:
+			parts = append(parts, &genai.Part{Text: prefix + fmt.Sprintf("(%s returned)", p.FunctionResponse.Name)})
+		}
+	}
+	if len(parts) == 0 {
+		return nil
+	}
+	return &session.Event{ // made-up event, like in ConvertForeignEvent.
+		Timestamp:   ev.Timestamp,
+		Author:      "user",
+		LLMResponse: model.LLMResponse{Content: &genai.Content{Role: "user", Parts: parts}},
+	}
+}
+
+// callNotation returns the short notation of a function call: its name and
+// the names of its arguments, e.g. "search(limit, query)".
+func callNotation(call *genai.FunctionCall) string {
+	names := make([]string, 0, len(call.Args))
+	for name := range call.Args {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return call.Name + "(" + strings.Join(names, ", ") + ")"
+}
+
+// estimateTokens estimates the tokens of the text of content, at about four
+// characters per token.
+func estimateTokens(content *genai.Content) int {
+	n := 0
+	for _, p := range content.Parts {
+		n += len(p.Text)
+	}
+	return (n + 3) / 4
+}
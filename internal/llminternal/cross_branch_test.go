@@ -0,0 +1,122 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llminternal_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent/llmagent"
+	icontext "google.golang.org/adk/internal/context"
+	"google.golang.org/adk/internal/llminternal"
+	"google.golang.org/adk/internal/utils"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/session"
+)
+
+func TestContentsRequestProcessor_CrossBranch(t *testing.T) {
+	event := func(author, branch string, content *genai.Content) *session.Event {
+		return &session.Event{Author: author, Branch: branch, LLMResponse: model.LLMResponse{Content: content}}
+	}
+	// The researcher and the writer run in parallel branches of the session.
+	events := []*session.Event{
+		event("user", "", genai.NewContentFromText("Write about otters", "user")),
+		event("researcher", "team.researcher", genai.NewContentFromText("Otters hold hands while sleeping", "model")),
+		event("writer", "team.writer", genai.NewContentFromFunctionCall("draft", map[string]any{"title": "Otters", "length": 300}, "model")),
+		event("writer", "team.writer", genai.NewContentFromFunctionResponse("draft", map[string]any{"text": "..."}, "user")),
+		event("writer", "team.writer", genai.NewContentFromText("Drafted the intro", "model")),
+		event("editor", "team.editor", genai.NewContentFromText("Fixed typos", "model")),
+	}
+	own := []*genai.Content{
+		genai.NewContentFromText("Write about otters", "user"),
+		genai.NewContentFromText("Otters hold hands while sleeping", "model"),
+	}
+	foreign := func(texts ...string) *genai.Content {
+		c := &genai.Content{Role: "user"}
+		for _, text := range texts {
+			c.Parts = append(c.Parts, &genai.Part{Text: text})
+		}
+		return c
+	}
+
+	for _, tc := range []struct {
+		name       string
+		visibility *llmagent.CrossBranchVisibility
+		want       []*genai.Content
+	}{
+		{
+			name: "Isolated",
+			want: own,
+		},
+		{
+			name:       "AllAgents",
+			visibility: &llmagent.CrossBranchVisibility{},
+			want: slices.Concat(own, []*genai.Content{
+				foreign("[writer agent]: (called draft(length, title))"),
+				foreign("[writer agent]: (draft returned)"),
+				foreign("[writer agent]: Drafted the intro"),
+				foreign("[editor agent]: Fixed typos"),
+			}),
+		},
+		{
+			name:       "Allowlist",
+			visibility: &llmagent.CrossBranchVisibility{Agents: []string{"writer"}},
+			want: slices.Concat(own, []*genai.Content{
+				foreign("[writer agent]: (called draft(length, title))"),
+				foreign("[writer agent]: (draft returned)"),
+				foreign("[writer agent]: Drafted the intro"),
+			}),
+		},
+		{
+			name:       "TokenBudget",
+			visibility: &llmagent.CrossBranchVisibility{MaxTokens: 16},
+			want: slices.Concat(own, []*genai.Content{
+				foreign("[writer agent]: Drafted the intro"),
+				foreign("[editor agent]: Fixed typos"),
+			}),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			researcher := utils.Must(llmagent.New(llmagent.Config{
+				Name:                  "researcher",
+				Model:                 &testModel{},
+				CrossBranchVisibility: tc.visibility,
+			}))
+			ctx := icontext.NewInvocationContext(t.Context(), icontext.InvocationContextParams{
+				Agent:   researcher,
+				Branch:  "team.researcher",
+				Session: &fakeSession{events: events},
+			})
+
+			req := &model.LLMRequest{}
+			if err := llminternal.ContentsRequestProcessor(ctx, req); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.want, req.Contents); diff != "" {
+				t.Errorf("contents mismatch (-want +got):\n%s", diff)
+			}
+			for _, c := range req.Contents {
+				for _, p := range c.Parts {
+					if p.FunctionCall != nil || p.FunctionResponse != nil {
+						t.Errorf("contents include the function call or response %+v of another agent", p)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llminternal
+
+import (
+	"fmt"
+	"maps"
+
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/plan"
+	"google.golang.org/adk/internal/toolinternal"
+	"google.golang.org/adk/internal/utils"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/runtimedeps"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+)
+
+// proposePlans reports whether the function calls of the invocation are
+// proposed as plans instead of being executed.
+func proposePlans(ctx agent.InvocationContext) bool {
+	cfg := ctx.RunConfig()
+	return cfg != nil && cfg.ProposePlans
+}
+
+// proposePlan returns the plan-proposed event of the function calls of
+// resp, or nil if there is none. The plan is stored in the state delta of
+// the event.
+func proposePlan(ctx agent.InvocationContext, tools map[string]tool.Tool, resp *model.LLMResponse) (*session.Event, error) {
+	calls := utils.FunctionCalls(resp.Content)
+	if len(calls) == 0 {
+		return nil, nil
+	}
+	decls := make(map[string]*genai.FunctionDeclaration)
+	for name, t := range tools {
+		if ft, ok := t.(toolinternal.FunctionTool); ok {
+			decls[name] = ft.Declaration()
+		}
+	}
+	p := plan.New(runtimedeps.FromContext(ctx).NewID(), ctx.Agent().Name(), ctx.InvocationID(), calls, decls)
+	delta, err := p.StateDelta()
+	if err != nil {
+		return nil, err
+	}
+	ev := session.NewEventWithContext(ctx, ctx.InvocationID())
+	ev.Author = ctx.Agent().Name()
+	ev.Branch = ctx.Branch()
+	ev.Actions.StateDelta = delta
+	ev.CustomMetadata = map[string]any{plan.MetadataKey: p}
+	return ev, nil
+}
+
+// runDecidedPlan runs the oldest plan of the agent that was approved or
+// rejected, and returns the event with the function responses of all its
+// steps, or nil if there is no such plan. The approved steps run in order
+// through the tools of req, with the references to the earlier steps
+// resolved; the other steps are answered with the reason they did not run.
+func (f *Flow) runDecidedPlan(ctx agent.InvocationContext, req *model.LLMRequest) (*session.Event, error) {
+	if ctx.Session() == nil {
+		return nil, nil
+	}
+	plans, err := plan.Decided(ctx.Session().State(), ctx.Agent().Name())
+	if err != nil || len(plans) == 0 {
+		return nil, err
+	}
+	p := plans[0]
+	tools := make(map[string]tool.Tool, len(req.Tools))
+	for name, v := range req.Tools {
+		t, ok := v.(tool.Tool)
+		if !ok {
+			return nil, fmt.Errorf("unexpected tool type %T for tool %v", v, name)
+		}
+		tools[name] = t
+	}
+
+	results := make(map[string]map[string]any)
+	stateDelta := make(map[string]any)
+	var events []*session.Event
+	for _, step := range p.Steps {
+		call := &genai.FunctionCall{ID: step.CallID, Name: step.Tool}
+		if !step.Approved || p.Status == plan.StatusRejected {
+			events = append(events, planResponseEvent(ctx, call, p.SkippedResponse(step)))
+			continue
+		}
+		if call.Args, err = plan.ResolveArgs(step.Args, results); err != nil {
+			events = append(events, planResponseEvent(ctx, call, map[string]any{"error": err.Error()}))
+			continue
+		}
+		ev, err := f.handleFunctionCalls(ctx, tools, &model.LLMResponse{Content: &genai.Content{
+			Role:  genai.RoleModel,
+			Parts: []*genai.Part{{FunctionCall: call}},
+		}}, nil)
+		if err != nil {
+			return nil, err
+		}
+		if resp := ev.Content.Parts[0].FunctionResponse; resp != nil {
+			results[step.ID] = resp.Response
+		}
+		maps.Copy(stateDelta, ev.Actions.StateDelta)
+		events = append(events, ev)
+	}
+	merged, err := mergeParallelFunctionResponseEvents(events)
+	if err != nil {
+		return nil, err
+	}
+	p.Status = plan.StatusExecuted
+	delta, err := p.StateDelta()
+	if err != nil {
+		return nil, err
+	}
+	maps.Copy(stateDelta, delta)
+	merged.Actions.StateDelta = stateDelta
+	return merged, nil
+}
+
+// planResponseEvent returns the event answering call with response.
+func planResponseEvent(ctx agent.InvocationContext, call *genai.FunctionCall, response map[string]any) *session.Event {
+	ev := session.NewEventWithContext(ctx, ctx.InvocationID())
+	ev.LLMResponse = model.LLMResponse{
+		Content: &genai.Content{
+			Role:  "user",
+			Parts: []*genai.Part{{FunctionResponse: &genai.FunctionResponse{ID: call.ID, Name: call.Name, Response: response}}},
+		},
+	}
+	ev.Author = ctx.Agent().Name()
+	ev.Branch = ctx.Branch()
+	return ev
+}
@@ -0,0 +1,175 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llminternal
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/internal/toolinternal"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+)
+
+// PrefetchRule starts a speculative call of a tool when the user content
+// matches it.
+type PrefetchRule struct {
+	// Tool is the name of the called tool.
+	Tool string
+	// Match reports whether the rule applies to the user content.
+	Match func(content *genai.Content) bool
+	// Args returns the arguments of the call for the user content.
+	Args func(content *genai.Content) map[string]any
+}
+
+// PrefetchConfig configures the speculative calls of an agent.
+type PrefetchConfig struct {
+	Rules []PrefetchRule
+	// Budget bounds the duration of the speculative calls. Zero means
+	// defaultPrefetchBudget.
+	Budget time.Duration
+}
+
+const defaultPrefetchBudget = time.Second
+
+// prefetcher holds the speculative calls of an invocation, keyed by the
+// name of the tool and the canonical JSON encoding of the arguments.
+//
+// The entries are added before the calls start, and only read and removed
+// by the flow afterwards: each call only writes to its own entry.
+type prefetcher struct {
+	ctx     context.Context
+	cancel  context.CancelFunc
+	entries map[string]*prefetchEntry
+}
+
+type prefetchEntry struct {
+	done   chan struct{}
+	result map[string]any
+	ok     bool
+}
+
+// startPrefetch starts the calls of the rules matching the user content of
+// the invocation, concurrently. Only the function tools of the request
+// annotated as read-only are called, see [tool.IsReadOnly].
+func startPrefetch(ctx agent.InvocationContext, cfg *PrefetchConfig, tools map[string]any) *prefetcher {
+	content := ctx.UserContent()
+	if content == nil {
+		return nil
+	}
+	budget := cfg.Budget
+	if budget <= 0 {
+		budget = defaultPrefetchBudget
+	}
+	pctx, cancel := context.WithTimeout(ctx, budget)
+	p := &prefetcher{ctx: pctx, cancel: cancel, entries: make(map[string]*prefetchEntry)}
+	for _, rule := range cfg.Rules {
+		t, ok := tools[rule.Tool].(toolinternal.FunctionTool)
+		if !ok || !tool.IsReadOnly(t) || !rule.Match(content) {
+			continue
+		}
+		args := rule.Args(content)
+		key, err := prefetchKey(rule.Tool, args)
+		if err != nil {
+			continue
+		}
+		if _, dup := p.entries[key]; dup {
+			continue
+		}
+		e := &prefetchEntry{done: make(chan struct{})}
+		p.entries[key] = e
+		go p.call(ctx, t, args, e)
+	}
+	return p
+}
+
+// call runs the speculative call of t. Its result is kept only if the call
+// succeeded within the budget without any action, e.g. a state change.
+func (p *prefetcher) call(ctx agent.InvocationContext, t toolinternal.FunctionTool, args map[string]any, e *prefetchEntry) {
+	defer close(e.done)
+	actions := &session.EventActions{StateDelta: make(map[string]any)}
+	toolCtx := toolinternal.NewToolContext(&prefetchContext{InvocationContext: ctx, ctx: p.ctx}, "", actions)
+	result, err := t.Run(toolCtx, args)
+	if err != nil || p.ctx.Err() != nil || hasActions(actions) {
+		return
+	}
+	e.result, e.ok = result, true
+}
+
+// result returns the result of the speculative call of the named tool with
+// the same arguments, waiting for the call to complete within the budget.
+// A result is returned once: the entry is removed.
+func (p *prefetcher) result(name string, args map[string]any) (map[string]any, bool) {
+	if p == nil {
+		return nil, false
+	}
+	key, err := prefetchKey(name, args)
+	if err != nil {
+		return nil, false
+	}
+	e, ok := p.entries[key]
+	if !ok {
+		return nil, false
+	}
+	delete(p.entries, key)
+	select {
+	case <-e.done:
+		return e.result, e.ok
+	case <-p.ctx.Done():
+		return nil, false
+	}
+}
+
+// stop cancels the pending calls. Their results are discarded.
+func (p *prefetcher) stop() {
+	if p != nil {
+		p.cancel()
+	}
+}
+
+// prefetchKey returns the cache key of a call. The keys of the JSON objects
+// are sorted by the encoding, so equal arguments have the same key.
+func prefetchKey(name string, args map[string]any) (string, error) {
+	if args == nil {
+		args = map[string]any{}
+	}
+	b, err := json.Marshal(args)
+	if err != nil {
+		return "", err
+	}
+	return name + "\x00" + string(b), nil
+}
+
+func hasActions(actions *session.EventActions) bool {
+	return len(actions.StateDelta) > 0 || len(actions.StateOps) > 0 || len(actions.ArtifactDelta) > 0 ||
+		actions.SkipSummarization || actions.TransferToAgent != "" || actions.Escalate
+}
+
+// prefetchContext is the invocation context of the speculative calls. It is
+// cancelled when the budget is exhausted, and cannot end the invocation.
+type prefetchContext struct {
+	agent.InvocationContext
+	ctx context.Context
+}
+
+func (c *prefetchContext) Deadline() (time.Time, bool) { return c.ctx.Deadline() }
+func (c *prefetchContext) Done() <-chan struct{}       { return c.ctx.Done() }
+func (c *prefetchContext) Err() error                  { return c.ctx.Err() }
+func (c *prefetchContext) Value(key any) any           { return c.ctx.Value(key) }
+func (c *prefetchContext) EndInvocation()              {}
@@ -19,6 +19,8 @@ import (
 	"fmt"
 	"iter"
 	"reflect"
+	"slices"
+	"strings"
 
 	"google.golang.org/genai"
 
@@ -46,6 +48,13 @@ func NewStreamingResponseAggregator() *streamingResponseAggregator {
 func (s *streamingResponseAggregator) ProcessResponse(ctx context.Context, genResp *genai.GenerateContentResponse) iter.Seq2[*model.LLMResponse, error] {
 	return func(yield func(*model.LLMResponse, error) bool) {
 		if len(genResp.Candidates) == 0 {
+			if genResp.PromptFeedback != nil {
+				// The prompt was blocked: the response carries the reason.
+				resp := converters.Genai2LLMResponse(genResp)
+				resp.TurnComplete = true
+				yield(resp, nil)
+				return
+			}
 			// shouldn't happen?
 			yield(nil, fmt.Errorf("empty response"))
 			return
@@ -143,3 +152,76 @@ func (s *streamingResponseAggregator) clear() {
 	s.thoughtText = ""
 	s.role = ""
 }
+
+// AggregatePartials returns the response ending a stream, final, completed
+// with the contents of the partial responses preceding it. Some adapters
+// only stream deltas, and end the stream with the last one: the returned
+// response holds the whole text, the function calls and the usage of the
+// turn. final is returned as is if it already holds the aggregated contents,
+// as the responses aggregated by streamingResponseAggregator do.
+func AggregatePartials(partials []*model.LLMResponse, final *model.LLMResponse) *model.LLMResponse {
+	if coversPartials(partials, final) {
+		return final
+	}
+
+	all := append(slices.Clone(partials), final)
+	s := NewStreamingResponseAggregator()
+	var others []*genai.Part
+	s.text, s.thoughtText, others = splitParts(all...)
+	var usage *genai.GenerateContentResponseUsageMetadata
+	for _, resp := range all {
+		if resp.UsageMetadata != nil {
+			usage = resp.UsageMetadata
+		}
+		if resp.Content != nil && resp.Content.Role != "" {
+			s.role = resp.Content.Role
+		}
+	}
+
+	s.response = final
+	aggregated := s.createAggregateResponse()
+	if aggregated == nil {
+		aggregated = &model.LLMResponse{
+			Content:           &genai.Content{Role: s.role},
+			ErrorCode:         final.ErrorCode,
+			ErrorMessage:      final.ErrorMessage,
+			GroundingMetadata: final.GroundingMetadata,
+			FinishReason:      final.FinishReason,
+		}
+	}
+	aggregated.Content.Parts = append(aggregated.Content.Parts, others...)
+	aggregated.UsageMetadata = usage
+	aggregated.CustomMetadata = final.CustomMetadata
+	aggregated.TurnComplete = final.TurnComplete
+	aggregated.Interrupted = final.Interrupted
+	return aggregated
+}
+
+// coversPartials reports whether final already holds the contents of the
+// partial responses: their text is a prefix of its text, and it has all
+// their other parts.
+func coversPartials(partials []*model.LLMResponse, final *model.LLMResponse) bool {
+	partialText, partialThoughts, partialOthers := splitParts(partials...)
+	text, thoughts, others := splitParts(final)
+	return strings.HasPrefix(text, partialText) && strings.HasPrefix(thoughts, partialThoughts) && len(others) >= len(partialOthers)
+}
+
+func splitParts(resps ...*model.LLMResponse) (text, thoughts string, others []*genai.Part) {
+	for _, resp := range resps {
+		if resp.Content == nil {
+			continue
+		}
+		for _, part := range resp.Content.Parts {
+			switch {
+			case part == nil || reflect.ValueOf(*part).IsZero():
+			case part.Text != "" && part.Thought:
+				thoughts += part.Text
+			case part.Text != "":
+				text += part.Text
+			default:
+				others = append(others, part)
+			}
+		}
+	}
+	return text, thoughts, others
+}
@@ -0,0 +1,294 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llminternal
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/internal/utils"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/session"
+)
+
+const (
+	// toolDeclarationsStateKeyPrefix prefixes the session state key, one per
+	// agent, holding the summary of the tools declared to the model.
+	toolDeclarationsStateKeyPrefix = "_adk_tool_declarations:"
+	// maxToolChangeNoticeLines caps the number of changes listed in the notice.
+	maxToolChangeNoticeLines = 8
+	// maxRemovedTools caps the number of removed tools remembered in the state.
+	maxRemovedTools = 32
+	// maxSummaryFields caps the number of parameter names kept per tool.
+	maxSummaryFields = 16
+)
+
+// toolDeclarations is the compact summary of the tools declared to the model,
+// stored in the session state.
+type toolDeclarations struct {
+	Fingerprint string                 `json:"fingerprint"`
+	Tools       map[string]toolSummary `json:"tools"`
+	// Removed lists the tools removed in previous turns, so calls to them can
+	// be answered with a helpful error.
+	Removed []string `json:"removed,omitempty"`
+}
+
+type toolSummary struct {
+	// Hash of the parameters and response schemas.
+	Hash     string   `json:"hash"`
+	Params   []string `json:"params,omitempty"`
+	Required []string `json:"required,omitempty"`
+}
+
+// detectToolChanges compares the tools declared in req with the ones stored
+// in the session state during the previous turn. If they differ, it appends a
+// notice describing the changes to the system instruction and records the new
+// summary in stateDelta.
+//
+// It returns the names of the tools known to be removed.
+func detectToolChanges(ctx agent.InvocationContext, req *model.LLMRequest, stateDelta map[string]any) (map[string]bool, error) {
+	key := toolDeclarationsStateKeyPrefix + ctx.Agent().Name()
+	current, err := summarizeToolDeclarations(utils.FunctionDecls(req.Config))
+	if err != nil {
+		return nil, err
+	}
+
+	var previous *toolDeclarations
+	if ctx.Session() != nil {
+		v, err := ctx.Session().State().Get(key)
+		switch {
+		case err == nil:
+			previous, err = decodeToolDeclarations(v)
+			if err != nil {
+				return nil, err
+			}
+		case !errors.Is(err, session.ErrStateKeyNotExist):
+			return nil, err
+		}
+	}
+
+	removed := make(map[string]bool)
+	if previous == nil {
+		stateDelta[key] = encodeToolDeclarations(current)
+		return removed, nil
+	}
+	for _, name := range previous.Removed {
+		if _, ok := current.Tools[name]; !ok {
+			removed[name] = true
+		}
+	}
+	if previous.Fingerprint == current.Fingerprint {
+		return removed, nil
+	}
+
+	for name := range previous.Tools {
+		if _, ok := current.Tools[name]; !ok {
+			removed[name] = true
+		}
+	}
+	current.Removed = slices.Sorted(maps.Keys(removed))
+	if len(current.Removed) > maxRemovedTools {
+		current.Removed = current.Removed[:maxRemovedTools]
+	}
+	stateDelta[key] = encodeToolDeclarations(current)
+
+	if notice := toolChangeNotice(previous, current); notice != "" {
+		utils.AppendInstructions(req, notice)
+	}
+	return removed, nil
+}
+
+// toolChangeNotice describes the differences between the previous and the
+// current tools.
+func toolChangeNotice(previous, current *toolDeclarations) string {
+	var added, removed []string
+	for _, name := range slices.Sorted(maps.Keys(current.Tools)) {
+		if _, ok := previous.Tools[name]; !ok {
+			added = append(added, name)
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(previous.Tools)) {
+		if _, ok := current.Tools[name]; !ok {
+			removed = append(removed, name)
+		}
+	}
+
+	var lines []string
+	renamed := make(map[string]bool)
+	for _, oldName := range removed {
+		newName := ""
+		for _, name := range added {
+			if !renamed[name] && current.Tools[name].Hash == previous.Tools[oldName].Hash {
+				newName = name
+				break
+			}
+		}
+		if newName != "" {
+			renamed[newName] = true
+			lines = append(lines, fmt.Sprintf("the %s tool was renamed to %s", oldName, newName))
+		} else {
+			lines = append(lines, fmt.Sprintf("the %s tool was removed", oldName))
+		}
+	}
+	for _, name := range added {
+		if !renamed[name] {
+			lines = append(lines, fmt.Sprintf("the %s tool was added", name))
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(current.Tools)) {
+		prev, ok := previous.Tools[name]
+		if !ok || prev.Hash == current.Tools[name].Hash {
+			continue
+		}
+		lines = append(lines, schemaChanges(name, prev, current.Tools[name])...)
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	if len(lines) > maxToolChangeNoticeLines {
+		more := len(lines) - maxToolChangeNoticeLines + 1
+		lines = append(lines[:maxToolChangeNoticeLines-1], fmt.Sprintf("%d more changes", more))
+	}
+
+	var sb strings.Builder
+	sb.WriteString("NOTE: The available tools changed since the previous turn of this conversation. Only call the tools declared in this request.")
+	for _, line := range lines {
+		sb.WriteString("\n- ")
+		sb.WriteString(line)
+	}
+	return sb.String()
+}
+
+func schemaChanges(name string, prev, cur toolSummary) []string {
+	var lines []string
+	for _, p := range cur.Required {
+		if !slices.Contains(prev.Required, p) {
+			lines = append(lines, fmt.Sprintf("the %s tool now requires a '%s' argument", name, p))
+		}
+	}
+	for _, p := range cur.Params {
+		if !slices.Contains(prev.Params, p) && !slices.Contains(cur.Required, p) {
+			lines = append(lines, fmt.Sprintf("the %s tool now accepts an optional '%s' argument", name, p))
+		}
+	}
+	for _, p := range prev.Params {
+		if !slices.Contains(cur.Params, p) {
+			lines = append(lines, fmt.Sprintf("the %s tool no longer accepts a '%s' argument", name, p))
+		}
+	}
+	if len(lines) == 0 {
+		lines = append(lines, fmt.Sprintf("the schema of the %s tool changed", name))
+	}
+	return lines
+}
+
+func summarizeToolDeclarations(decls []*genai.FunctionDeclaration) (*toolDeclarations, error) {
+	summary := &toolDeclarations{Tools: make(map[string]toolSummary)}
+	for _, decl := range decls {
+		if decl == nil {
+			continue
+		}
+		s, err := summarizeToolDeclaration(decl)
+		if err != nil {
+			return nil, fmt.Errorf("failed to summarize the declaration of tool %q: %w", decl.Name, err)
+		}
+		summary.Tools[decl.Name] = s
+	}
+	// json.Marshal sorts map keys, so the fingerprint is stable.
+	b, err := json.Marshal(summary.Tools)
+	if err != nil {
+		return nil, err
+	}
+	summary.Fingerprint = shortHash(b)
+	return summary, nil
+}
+
+func summarizeToolDeclaration(decl *genai.FunctionDeclaration) (toolSummary, error) {
+	schemas := []any{decl.Parameters, decl.ParametersJsonSchema, decl.Response, decl.ResponseJsonSchema}
+	b, err := json.Marshal(schemas)
+	if err != nil {
+		return toolSummary{}, err
+	}
+	summary := toolSummary{Hash: shortHash(b)}
+
+	params := decl.ParametersJsonSchema
+	if decl.Parameters != nil {
+		params = decl.Parameters
+	}
+	if params == nil {
+		return summary, nil
+	}
+	b, err = json.Marshal(params)
+	if err != nil {
+		return toolSummary{}, err
+	}
+	var fields struct {
+		Properties map[string]json.RawMessage `json:"properties"`
+		Required   []string                   `json:"required"`
+	}
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return toolSummary{}, err
+	}
+	summary.Params = capFields(slices.Sorted(maps.Keys(fields.Properties)))
+	summary.Required = capFields(slices.Sorted(slices.Values(fields.Required)))
+	return summary, nil
+}
+
+func capFields(fields []string) []string {
+	if len(fields) > maxSummaryFields {
+		return fields[:maxSummaryFields]
+	}
+	return fields
+}
+
+func shortHash(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:8])
+}
+
+// encodeToolDeclarations converts the summary to a JSON-like value that
+// survives persistence by any session service.
+func encodeToolDeclarations(d *toolDeclarations) map[string]any {
+	b, err := json.Marshal(d)
+	if err != nil {
+		return nil
+	}
+	var m map[string]any
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil
+	}
+	return m
+}
+
+func decodeToolDeclarations(v any) (*toolDeclarations, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tool declarations summary in state: %w", err)
+	}
+	var d toolDeclarations
+	if err := json.Unmarshal(b, &d); err != nil {
+		return nil, fmt.Errorf("invalid tool declarations summary in state: %w", err)
+	}
+	return &d, nil
+}
@@ -0,0 +1,168 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llminternal
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/internal/toolinternal"
+	"google.golang.org/adk/internal/toolinternal/toolutils"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/tool"
+)
+
+// Tool name collision policies, see llmagent.ToolNameCollisionPolicy.
+const (
+	toolNameCollisionError        = "error"
+	toolNameCollisionRename       = "rename"
+	toolNameCollisionPreferNative = "prefer_native"
+)
+
+// toolNameCollisionPolicy returns the tool name collision policy of the
+// agent being run.
+func toolNameCollisionPolicy(ctx agent.InvocationContext) string {
+	if llmAgent := asLLMAgent(ctx.Agent()); llmAgent != nil && llmAgent.internal().ToolNameCollisions != "" {
+		return llmAgent.internal().ToolNameCollisions
+	}
+	return toolNameCollisionError
+}
+
+// aliasTool declares a function tool under another name. The renaming is
+// deterministic, so every request of the invocation declares the same alias
+// and the calls to it keep being dispatched to the function tool.
+type aliasTool struct {
+	toolinternal.FunctionTool
+	name string
+}
+
+// Name implements tool.Tool.
+func (t *aliasTool) Name() string {
+	return t.name
+}
+
+// Declaration returns the declaration of the function tool under its alias.
+func (t *aliasTool) Declaration() *genai.FunctionDeclaration {
+	decl := t.FunctionTool.Declaration()
+	if decl == nil {
+		return nil
+	}
+	alias := *decl
+	alias.Name = t.name
+	return &alias
+}
+
+// ProcessRequest packs the aliased declaration into the request.
+func (t *aliasTool) ProcessRequest(ctx tool.Context, req *model.LLMRequest) error {
+	return toolutils.PackTool(ctx, req, t)
+}
+
+// resolveDuplicateTool handles a function tool whose name is already declared
+// by another tool of the request. It returns the tool to pack instead.
+func resolveDuplicateTool(req *model.LLMRequest, t toolinternal.FunctionTool, policy string) (tool.Tool, error) {
+	if policy != toolNameCollisionRename {
+		return nil, fmt.Errorf("tool name %q is declared by both %s and %s", t.Name(), toolSource(req.Tools[t.Name()]), toolSource(t))
+	}
+	return &aliasTool{FunctionTool: t, name: uniqueToolName(req, t.Name())}, nil
+}
+
+// resolveNativeToolCollisions handles the function declarations of req whose
+// names collide with the Gemini native tools of req.
+func resolveNativeToolCollisions(req *model.LLMRequest, policy string) error {
+	if req.Config == nil {
+		return nil
+	}
+	natives := nativeToolNames(req)
+	if len(natives) == 0 {
+		return nil
+	}
+	emptied := make(map[*genai.Tool]bool)
+	for _, t := range req.Config.Tools {
+		if t == nil || len(t.FunctionDeclarations) == 0 {
+			continue
+		}
+		var decls []*genai.FunctionDeclaration
+		for _, decl := range t.FunctionDeclarations {
+			if decl == nil || !natives[decl.Name] {
+				decls = append(decls, decl)
+				continue
+			}
+			switch policy {
+			case toolNameCollisionPreferNative:
+				delete(req.Tools, decl.Name)
+			case toolNameCollisionRename:
+				alias := uniqueToolName(req, decl.Name)
+				renamed := *decl
+				renamed.Name = alias
+				decls = append(decls, &renamed)
+				if ft, ok := req.Tools[decl.Name].(toolinternal.FunctionTool); ok {
+					req.Tools[alias] = &aliasTool{FunctionTool: ft, name: alias}
+				} else {
+					req.Tools[alias] = req.Tools[decl.Name]
+				}
+				delete(req.Tools, decl.Name)
+			default:
+				return fmt.Errorf("tool name %q is declared by both %s and the Gemini native %s tool", decl.Name, toolSource(req.Tools[decl.Name]), decl.Name)
+			}
+		}
+		t.FunctionDeclarations = decls
+		emptied[t] = len(decls) == 0 && len(toolutils.NativeToolNames(t)) == 0
+	}
+	// Drop the tools left without any declaration.
+	req.Config.Tools = slices.DeleteFunc(req.Config.Tools, func(t *genai.Tool) bool {
+		return emptied[t]
+	})
+	return nil
+}
+
+// nativeToolNames returns the names of the Gemini native tools of req.
+func nativeToolNames(req *model.LLMRequest) map[string]bool {
+	names := make(map[string]bool)
+	for _, t := range req.Config.Tools {
+		for _, name := range toolutils.NativeToolNames(t) {
+			names[name] = true
+		}
+	}
+	return names
+}
+
+// uniqueToolName returns name with the smallest numeric suffix not used by
+// the tools of req.
+func uniqueToolName(req *model.LLMRequest, name string) string {
+	var natives map[string]bool
+	if req.Config != nil {
+		natives = nativeToolNames(req)
+	}
+	for i := 2; ; i++ {
+		alias := fmt.Sprintf("%s_%d", name, i)
+		if _, ok := req.Tools[alias]; !ok && !natives[alias] {
+			return alias
+		}
+	}
+}
+
+// toolSource describes the tool declaring a function in error messages.
+func toolSource(t any) string {
+	typeName := fmt.Sprintf("%T", t)
+	// Drop the type arguments of generic tools.
+	if i := strings.IndexByte(typeName, '['); i >= 0 {
+		typeName = typeName[:i]
+	}
+	return "a " + typeName
+}
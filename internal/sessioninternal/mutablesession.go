@@ -15,6 +15,7 @@
 package sessioninternal
 
 import (
+	"context"
 	"fmt"
 	"iter"
 	"time"
@@ -82,3 +83,31 @@ func (s *MutableSession) Set(key string, value any) error {
 	}
 	return nil
 }
+
+// StateVersion returns the version of the state of the stored session, or 0
+// if it is not a [session.VersionedSession].
+func (s *MutableSession) StateVersion() int64 {
+	if vs, ok := s.storedSession.(session.VersionedSession); ok {
+		return vs.StateVersion()
+	}
+	return 0
+}
+
+// StateStale reports whether the state of the stored session was changed by
+// a concurrent invocation.
+func (s *MutableSession) StateStale() bool {
+	if vs, ok := s.storedSession.(session.VersionedSession); ok {
+		return vs.StateStale()
+	}
+	return false
+}
+
+// RefreshState reloads the state of the stored session.
+func (s *MutableSession) RefreshState(ctx context.Context) error {
+	if vs, ok := s.storedSession.(session.VersionedSession); ok {
+		return vs.RefreshState(ctx)
+	}
+	return nil
+}
+
+var _ session.VersionedSession = (*MutableSession)(nil)
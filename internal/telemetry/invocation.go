@@ -0,0 +1,268 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetry
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/runtimedeps"
+	"google.golang.org/adk/session"
+)
+
+const (
+	invocationSpanName = "invocation"
+
+	gcpVertexAgentAgentName         = "gcp.vertex.agent.agent_name"
+	gcpVertexAgentSessionIDHash     = "gcp.vertex.agent.session_id_hash"
+	gcpVertexAgentConfigFingerprint = "gcp.vertex.agent.config_fingerprint"
+	gcpVertexAgentErrorCode         = "gcp.vertex.agent.error_code"
+	gcpVertexAgentTotalTokenCount   = "gcp.vertex.agent.total_token_count"
+	gcpVertexAgentCost              = "gcp.vertex.agent.cost"
+	gcpVertexAgentLinkType          = "gcp.vertex.agent.link_type"
+	gcpVertexAgentStateKeys         = "gcp.vertex.agent.state_keys"
+	gcpVertexAgentArtifactName      = "gcp.vertex.agent.artifact_name"
+	gcpVertexAgentArtifactVersion   = "gcp.vertex.agent.artifact_version"
+
+	stateChangeEventName       = "state_change"
+	artifactOperationEventName = "artifact_operation"
+	previousInvocationLinkType = "previous_invocation"
+)
+
+// InvocationConfig configures the root span of an invocation.
+type InvocationConfig struct {
+	// Sampler decides whether the invocation is traced. Nil samples all the
+	// invocations.
+	Sampler sdktrace.Sampler
+	// Previous are the references to the root spans of the previous
+	// invocation of the session, as returned by [Invocation.Refs].
+	Previous string
+	// AgentName, SessionIDHash and ConfigFingerprint are set on the root
+	// span.
+	AgentName         string
+	SessionIDHash     string
+	ConfigFingerprint string
+}
+
+// Invocation is the root span of an invocation, started on every tracer. The
+// spans started by [StartTrace] with a context carrying it are its children.
+type Invocation struct {
+	spans []trace.Span
+
+	mu          sync.Mutex
+	totalTokens int64
+	errorCode   model.ErrorCode
+}
+
+type invocationKey struct{}
+
+// StartInvocation starts the root span of an invocation and returns a context
+// carrying it. Within an invocation, the root span of the nested invocation
+// is a child of the outer one; otherwise it starts a new trace, linked to the
+// previous invocation of the session. If the sampler drops the invocation,
+// its spans are parented on an unsampled span context, so that they are
+// dropped by the parent-based samplers.
+func StartInvocation(ctx context.Context, cfg InvocationConfig) (context.Context, *Invocation) {
+	tracers := getTracers()
+	outer, _ := ctx.Value(invocationKey{}).(*Invocation)
+	inv := &Invocation{spans: make([]trace.Span, len(tracers))}
+
+	if outer == nil && !sampled(ctx, cfg.Sampler) {
+		deps := runtimedeps.FromContext(ctx)
+		traceID, spanID := uuid.MustParse(deps.NewID()), uuid.MustParse(deps.NewID())
+		sc := trace.NewSpanContext(trace.SpanContextConfig{TraceID: trace.TraceID(traceID), SpanID: trace.SpanID(spanID[:8])})
+		for i := range inv.spans {
+			inv.spans[i] = trace.SpanFromContext(trace.ContextWithSpanContext(ctx, sc))
+		}
+		return context.WithValue(ctx, invocationKey{}, inv), inv
+	}
+
+	previous := parseRefs(cfg.Previous)
+	attrs := []attribute.KeyValue{attribute.String(gcpVertexAgentAgentName, cfg.AgentName)}
+	if cfg.SessionIDHash != "" {
+		attrs = append(attrs, attribute.String(gcpVertexAgentSessionIDHash, cfg.SessionIDHash))
+	}
+	if cfg.ConfigFingerprint != "" {
+		attrs = append(attrs, attribute.String(gcpVertexAgentConfigFingerprint, cfg.ConfigFingerprint))
+	}
+	for i, tracer := range tracers {
+		parent := ctx
+		opts := []trace.SpanStartOption{trace.WithAttributes(attrs...)}
+		if outer != nil {
+			parent = trace.ContextWithSpan(ctx, outer.spans[i])
+		} else {
+			opts = append(opts, trace.WithNewRoot())
+			if i < len(previous) && previous[i].IsValid() {
+				opts = append(opts, trace.WithLinks(trace.Link{
+					SpanContext: previous[i],
+					Attributes:  []attribute.KeyValue{attribute.String(gcpVertexAgentLinkType, previousInvocationLinkType)},
+				}))
+			}
+		}
+		_, inv.spans[i] = tracer.Start(parent, invocationSpanName, opts...)
+	}
+	return context.WithValue(ctx, invocationKey{}, inv), inv
+}
+
+// sampled reports whether sampler samples a new invocation.
+func sampled(ctx context.Context, sampler sdktrace.Sampler) bool {
+	if sampler == nil {
+		return true
+	}
+	traceID := uuid.MustParse(runtimedeps.FromContext(ctx).NewID())
+	result := sampler.ShouldSample(sdktrace.SamplingParameters{
+		ParentContext: trace.ContextWithSpanContext(ctx, trace.SpanContext{}),
+		TraceID:       trace.TraceID(traceID),
+		Name:          invocationSpanName,
+		Kind:          trace.SpanKindInternal,
+	})
+	return result.Decision != sdktrace.Drop
+}
+
+// SetInvocationID sets the ID of the invocation on its root span.
+func (inv *Invocation) SetInvocationID(id string) {
+	for _, span := range inv.spans {
+		span.SetAttributes(attribute.String(gcpVertexAgentInvocationID, id))
+	}
+}
+
+// Refs returns references to the root spans of the invocation, to be passed
+// as InvocationConfig.Previous to the next invocation of the session. It is
+// empty if the invocation is not traced.
+func (inv *Invocation) Refs() string {
+	refs := make([]string, len(inv.spans))
+	traced := false
+	for i, span := range inv.spans {
+		if sc := span.SpanContext(); sc.IsValid() && sc.IsSampled() {
+			refs[i] = sc.TraceID().String() + "-" + sc.SpanID().String()
+			traced = true
+		}
+	}
+	if !traced {
+		return ""
+	}
+	return strings.Join(refs, ",")
+}
+
+// parseRefs parses the references returned by [Invocation.Refs]. The invalid
+// references are returned as invalid span contexts.
+func parseRefs(refs string) []trace.SpanContext {
+	if refs == "" {
+		return nil
+	}
+	var scs []trace.SpanContext
+	for _, ref := range strings.Split(refs, ",") {
+		var sc trace.SpanContext
+		if traceID, spanID, ok := strings.Cut(ref, "-"); ok {
+			tid, err1 := trace.TraceIDFromHex(traceID)
+			sid, err2 := trace.SpanIDFromHex(spanID)
+			if err1 == nil && err2 == nil {
+				sc = trace.NewSpanContext(trace.SpanContextConfig{TraceID: tid, SpanID: sid, TraceFlags: trace.FlagsSampled, Remote: true})
+			}
+		}
+		scs = append(scs, sc)
+	}
+	return scs
+}
+
+// RecordEvent accounts the tokens and the error code of ev, and adds span
+// events for its state changes and artifact operations. Only the keys of the
+// state changes are recorded. It does nothing if inv is nil.
+func (inv *Invocation) RecordEvent(ev *session.Event) {
+	if inv == nil {
+		return
+	}
+	inv.mu.Lock()
+	if ev.UsageMetadata != nil {
+		inv.totalTokens += int64(ev.UsageMetadata.TotalTokenCount)
+	}
+	if ev.ErrorCode != "" {
+		inv.errorCode = model.ErrorCode(ev.ErrorCode)
+	}
+	inv.mu.Unlock()
+
+	var keys []string
+	for k := range ev.Actions.StateDelta {
+		keys = append(keys, k)
+	}
+	for _, op := range ev.Actions.StateOps {
+		keys = append(keys, op.Key)
+	}
+	slices.Sort(keys)
+	keys = slices.Compact(keys)
+	artifacts := make([]string, 0, len(ev.Actions.ArtifactDelta))
+	for name := range ev.Actions.ArtifactDelta {
+		artifacts = append(artifacts, name)
+	}
+	slices.Sort(artifacts)
+
+	for _, span := range inv.spans {
+		if len(keys) > 0 {
+			span.AddEvent(stateChangeEventName, trace.WithAttributes(
+				attribute.StringSlice(gcpVertexAgentStateKeys, keys),
+				attribute.String(gcpVertexAgentEventID, ev.ID),
+			))
+		}
+		for _, name := range artifacts {
+			span.AddEvent(artifactOperationEventName, trace.WithAttributes(
+				attribute.String(gcpVertexAgentArtifactName, name),
+				attribute.Int64(gcpVertexAgentArtifactVersion, ev.Actions.ArtifactDelta[name]),
+				attribute.String(gcpVertexAgentEventID, ev.ID),
+			))
+		}
+	}
+}
+
+// RecordError records the error code of err, a failure of the invocation.
+// It does nothing if inv is nil.
+func (inv *Invocation) RecordError(err error) {
+	if inv == nil {
+		return
+	}
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	inv.errorCode = model.CodeOf(err)
+}
+
+// End sets the final attributes of the root span and ends it. cost is set if
+// hasCost is true.
+func (inv *Invocation) End(cost float64, hasCost bool) {
+	inv.mu.Lock()
+	attrs := []attribute.KeyValue{attribute.Int64(gcpVertexAgentTotalTokenCount, inv.totalTokens)}
+	if hasCost {
+		attrs = append(attrs, attribute.Float64(gcpVertexAgentCost, cost))
+	}
+	errorCode := inv.errorCode
+	inv.mu.Unlock()
+	if errorCode != "" {
+		attrs = append(attrs, attribute.String(gcpVertexAgentErrorCode, string(errorCode)))
+	}
+	for _, span := range inv.spans {
+		span.SetAttributes(attrs...)
+		if errorCode != "" {
+			span.SetStatus(codes.Error, string(errorCode))
+		}
+		span.End()
+	}
+}
@@ -65,6 +65,7 @@ const (
 	genAiRequestTopP      = "gen_ai.request.top_p"
 	genAiRequestMaxTokens = "gen_ai.request.max_tokens"
 
+	genAiResponseID                      = "gen_ai.response.id"
 	genAiResponseFinishReason            = "gen_ai.response.finish_reason"
 	genAiResponsePromptTokenCount        = "gen_ai.response.prompt_token_count"
 	genAiResponseCandidatesTokenCount    = "gen_ai.response.candidates_token_count"
@@ -78,6 +79,7 @@ const (
 	gcpVertexAgentLLMResponseName  = "gcp.vertex.agent.llm_response"
 	gcpVertexAgentInvocationID     = "gcp.vertex.agent.invocation_id"
 	gcpVertexAgentSessionID        = "gcp.vertex.agent.session_id"
+	gcpVertexAgentPrefetchHit      = "gcp.vertex.agent.prefetch_hit"
 
 	executeToolName = "execute_tool"
 	mergeToolName   = "(merged tools)"
@@ -119,11 +121,17 @@ func getTracers() []trace.Tracer {
 }
 
 // StartTrace returns two spans to start emitting events, one from global tracer and second from the local.
+// If ctx carries an [Invocation], the spans are children of its root spans.
 func StartTrace(ctx context.Context, traceName string) []trace.Span {
 	tracers := getTracers()
+	inv, _ := ctx.Value(invocationKey{}).(*Invocation)
 	spans := make([]trace.Span, len(tracers))
 	for i, tracer := range tracers {
-		_, span := tracer.Start(ctx, traceName)
+		parent := ctx
+		if inv != nil {
+			parent = trace.ContextWithSpan(ctx, inv.spans[i])
+		}
+		_, span := tracer.Start(parent, traceName)
 		spans[i] = span
 	}
 	return spans
@@ -199,6 +207,14 @@ func TraceToolCall(spans []trace.Span, tool tool.Tool, fnArgs map[string]any, fn
 	}
 }
 
+// TracePrefetchHit marks the execute_tool spans of a call answered with a
+// prefetched result.
+func TracePrefetchHit(spans []trace.Span) {
+	for _, span := range spans {
+		span.SetAttributes(attribute.Bool(gcpVertexAgentPrefetchHit, true))
+	}
+}
+
 // TraceLLMCall fills the call_llm event details.
 func TraceLLMCall(spans []trace.Span, agentCtx agent.InvocationContext, llmRequest *model.LLMRequest, event *session.Event) {
 	for _, span := range spans {
@@ -219,6 +235,9 @@ func TraceLLMCall(spans []trace.Span, agentCtx agent.InvocationContext, llmReque
 		if llmRequest.Config.MaxOutputTokens != 0 {
 			attributes = append(attributes, attribute.Int(genAiRequestMaxTokens, int(llmRequest.Config.MaxOutputTokens)))
 		}
+		if id, _ := event.CustomMetadata[model.ResponseIDMetadataKey].(string); id != "" {
+			attributes = append(attributes, attribute.String(genAiResponseID, id))
+		}
 		if event.FinishReason != "" {
 			attributes = append(attributes, attribute.String(genAiResponseFinishReason, string(event.FinishReason)))
 		}
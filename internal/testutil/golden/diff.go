@@ -0,0 +1,106 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+type section struct {
+	title, body string
+}
+
+// parseSections splits a rendering into its sections.
+func parseSections(s string) []section {
+	var sections []section
+	for line := range strings.Lines(s) {
+		trimmed := strings.TrimSuffix(line, "\n")
+		if strings.HasPrefix(trimmed, sectionStart) && strings.HasSuffix(trimmed, sectionEnd) {
+			title := strings.TrimSuffix(strings.TrimPrefix(trimmed, sectionStart), sectionEnd)
+			sections = append(sections, section{title: title})
+			continue
+		}
+		if len(sections) == 0 {
+			sections = append(sections, section{})
+		}
+		sections[len(sections)-1].body += line
+	}
+	return sections
+}
+
+// diff describes the differences between two renderings, reporting the
+// changes of the schemas and of the instructions separately from the
+// others.
+func diff(want, got string) string {
+	wantSections := parseSections(want)
+	gotBodies := make(map[string]string)
+	var order []string
+	for _, s := range parseSections(got) {
+		gotBodies[s.title] = s.body
+		order = append(order, s.title)
+	}
+	wantBodies := make(map[string]string)
+	for _, s := range wantSections {
+		wantBodies[s.title] = s.body
+	}
+
+	var schema, instructions, other strings.Builder
+	report := func(title, change string) {
+		b := &other
+		switch {
+		case title == "instructions":
+			b = &instructions
+		case strings.HasPrefix(title, "declaration "), strings.HasPrefix(title, "parameters "), strings.HasPrefix(title, "response "):
+			b = &schema
+		}
+		fmt.Fprintf(b, "%s%s%s %s\n", sectionStart, title, sectionEnd, change)
+	}
+	for _, s := range wantSections {
+		gotBody, ok := gotBodies[s.title]
+		switch {
+		case !ok:
+			report(s.title, "removed")
+		case gotBody != s.body:
+			report(s.title, "changed (-want +got):\n"+cmp.Diff(strings.Split(s.body, "\n"), strings.Split(gotBody, "\n")))
+		}
+	}
+	for _, title := range order {
+		if _, ok := wantBodies[title]; !ok {
+			report(title, "added:\n"+gotBodies[title])
+		}
+	}
+
+	var b strings.Builder
+	for _, group := range []struct {
+		name    string
+		changes *strings.Builder
+	}{
+		{"Schema changes", &schema},
+		{"Instruction changes", &instructions},
+		{"Other changes", &other},
+	} {
+		if group.changes.Len() > 0 {
+			fmt.Fprintf(&b, "%s:\n%s", group.name, group.changes.String())
+		}
+	}
+	if b.Len() == 0 {
+		// Only the order of the sections changed.
+		return "Order changes (-want +got):\n" + cmp.Diff(strings.Split(want, "\n"), strings.Split(got, "\n"))
+	}
+	return b.String()
+}
@@ -0,0 +1,254 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package golden compares canonical renderings of function declarations and
+// packed LLM requests with golden files, so that the changes of the prompt
+// bytes sent to the models are visible in review.
+//
+// The golden files are stored in the testdata directory of the package
+// under test, and named after the test. Run the tests with -update to
+// create or update them.
+package golden
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/tool"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+// maxString is the length above which strings are elided with their digest.
+const maxString = 2048
+
+// Declaration compares the rendering of the declaration of the function
+// tool tl with the golden file of t.
+func Declaration(t testing.TB, tl tool.Tool) {
+	t.Helper()
+	d, ok := tl.(interface {
+		Declaration() *genai.FunctionDeclaration
+	})
+	if !ok {
+		t.Fatalf("tool %q is not a function tool: use PackedRequest", tl.Name())
+	}
+	compare(t, renderDeclaration(t, d.Declaration()))
+}
+
+// Text compares got, a rendering by the code under test, e.g. a report,
+// with the golden file of t.
+func Text(t testing.TB, got string) {
+	t.Helper()
+	compare(t, got)
+}
+
+// PackedRequest compares the rendering of req with the golden file of t.
+// The rendering has the instructions, the function declarations, the other
+// tools, the contents and the rest of the configuration, in that order.
+func PackedRequest(t testing.TB, req *model.LLMRequest) {
+	t.Helper()
+	var b strings.Builder
+	if req.Config != nil && req.Config.SystemInstruction != nil {
+		writeSection(&b, "instructions", renderInstructions(req.Config.SystemInstruction))
+	}
+
+	var decls []*genai.FunctionDeclaration
+	var native []*genai.Tool
+	if req.Config != nil {
+		for _, tl := range req.Config.Tools {
+			if tl == nil {
+				continue
+			}
+			decls = append(decls, tl.FunctionDeclarations...)
+			if other := *tl; len(other.FunctionDeclarations) > 0 {
+				other.FunctionDeclarations = nil
+				if canonical(t, &other) == "{}\n" {
+					continue
+				}
+				native = append(native, &other)
+			} else {
+				native = append(native, tl)
+			}
+		}
+	}
+	slices.SortStableFunc(decls, func(a, b *genai.FunctionDeclaration) int { return strings.Compare(a.Name, b.Name) })
+	for _, d := range decls {
+		b.WriteString(renderDeclaration(t, d))
+	}
+	if len(native) > 0 {
+		writeSection(&b, "native tools", canonical(t, native))
+	}
+	if len(req.Contents) > 0 {
+		writeSection(&b, "contents", canonical(t, req.Contents))
+	}
+
+	config := map[string]any{"model": req.Model}
+	if req.Config != nil {
+		rest := *req.Config
+		rest.SystemInstruction, rest.Tools = nil, nil
+		config["generateContentConfig"] = rest
+	}
+	if len(req.Tools) > 0 {
+		names := make([]string, 0, len(req.Tools))
+		for name := range req.Tools {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		config["dispatchedTools"] = names
+	}
+	writeSection(&b, "config", canonical(t, config))
+	compare(t, b.String())
+}
+
+func renderDeclaration(t testing.TB, d *genai.FunctionDeclaration) string {
+	var b strings.Builder
+	header := "description: " + d.Description + "\n"
+	if d.Behavior != "" {
+		header += "behavior: " + string(d.Behavior) + "\n"
+	}
+	writeSection(&b, "declaration "+d.Name, header)
+	switch {
+	case d.ParametersJsonSchema != nil:
+		writeSection(&b, "parameters "+d.Name, canonical(t, d.ParametersJsonSchema))
+	case d.Parameters != nil:
+		writeSection(&b, "parameters "+d.Name, canonical(t, d.Parameters))
+	}
+	switch {
+	case d.ResponseJsonSchema != nil:
+		writeSection(&b, "response "+d.Name, canonical(t, d.ResponseJsonSchema))
+	case d.Response != nil:
+		writeSection(&b, "response "+d.Name, canonical(t, d.Response))
+	}
+	return b.String()
+}
+
+func renderInstructions(c *genai.Content) string {
+	var texts []string
+	for _, p := range c.Parts {
+		if p != nil && p.Text != "" {
+			texts = append(texts, p.Text)
+		}
+	}
+	return strings.Join(texts, "\n\n") + "\n"
+}
+
+// canonical returns the indented JSON encoding of v, with sorted object
+// keys, the large blobs elided with their digest and the volatile fields
+// masked.
+func canonical(t testing.TB, v any) string {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("failed to encode %T: %v", v, err)
+	}
+	var generic any
+	if err := json.Unmarshal(data, &generic); err != nil {
+		t.Fatalf("failed to decode %T: %v", v, err)
+	}
+	var b strings.Builder
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(scrub("", generic)); err != nil {
+		t.Fatalf("failed to encode %T: %v", v, err)
+	}
+	return b.String()
+}
+
+// scrub elides the large values and masks the volatile fields of v, the
+// value of the given key.
+func scrub(key string, v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, item := range v {
+			v[k] = scrub(k, item)
+		}
+		// The IDs of the function calls and responses are generated.
+		if _, ok := v["name"]; ok && (key == "functionCall" || key == "functionResponse") && v["id"] != nil {
+			v["id"] = "<masked>"
+		}
+		return v
+	case []any:
+		for i, item := range v {
+			v[i] = scrub("", item)
+		}
+		return v
+	case string:
+		// Blobs are encoded in base64, and always elided.
+		if key == "data" {
+			if blob, err := base64.StdEncoding.DecodeString(v); err == nil {
+				return elided(blob)
+			}
+		}
+		if len(v) > maxString {
+			return elided([]byte(v))
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+func elided(b []byte) string {
+	sum := sha256.Sum256(b)
+	return fmt.Sprintf("<elided %d bytes, sha256:%s>", len(b), hex.EncodeToString(sum[:8]))
+}
+
+const (
+	sectionStart = "=== "
+	sectionEnd   = " ==="
+)
+
+func writeSection(b *strings.Builder, title, body string) {
+	b.WriteString(sectionStart + title + sectionEnd + "\n")
+	b.WriteString(body)
+}
+
+func goldenPath(t testing.TB) string {
+	name := strings.NewReplacer("/", "__", " ", "_").Replace(t.Name())
+	return filepath.Join("testdata", name+".golden")
+}
+
+func compare(t testing.TB, got string) {
+	t.Helper()
+	path := goldenPath(t)
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read the golden file, run the test with -update to create it: %v", err)
+	}
+	if string(want) != got {
+		t.Errorf("rendering does not match %s, run the test with -update to accept the changes:\n%s", path, diff(string(want), got))
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	want := `=== instructions ===
+Be concise.
+=== declaration search ===
+description: Searches the web.
+=== parameters search ===
+{"query": "string"}
+=== config ===
+{}
+`
+	got := `=== instructions ===
+Be concise and polite.
+=== declaration search ===
+description: Searches the web.
+=== parameters search ===
+{"query": "string", "limit": "integer"}
+=== config ===
+{}
+=== declaration fetch ===
+description: Fetches a page.
+`
+	report := diff(want, got)
+	schema, rest, ok := strings.Cut(report, "Instruction changes:\n")
+	if !ok || !strings.HasPrefix(schema, "Schema changes:\n") {
+		t.Fatalf("diff() = %q, want the schema changes then the instruction changes", report)
+	}
+	for _, s := range []string{"=== parameters search === changed", `"limit": "integer"`, "=== declaration fetch === added"} {
+		if !strings.Contains(schema, s) {
+			t.Errorf("schema changes %q do not contain %q", schema, s)
+		}
+	}
+	if strings.Contains(schema, "declaration search") {
+		t.Errorf("schema changes %q report the unchanged declaration", schema)
+	}
+	if !strings.Contains(rest, "=== instructions === changed") || !strings.Contains(rest, "Be concise and polite.") {
+		t.Errorf("instruction changes %q do not report the new instructions", rest)
+	}
+	if strings.Contains(report, "Other changes") {
+		t.Errorf("diff() = %q, want no other changes", report)
+	}
+}
+
+func TestScrub(t *testing.T) {
+	got := canonical(t, map[string]any{
+		"inlineData":   map[string]any{"data": []byte("blob")},
+		"functionCall": map[string]any{"id": "adk-123", "name": "search"},
+		"text":         strings.Repeat("a", maxString+1),
+	})
+	want := `{
+  "functionCall": {
+    "id": "<masked>",
+    "name": "search"
+  },
+  "inlineData": {
+    "data": "<elided 4 bytes, sha256:fa2c8cc4f28176bb>"
+  },
+  "text": "<elided 2049 bytes, sha256:ba7bea600e8f3dfd>"
+}
+`
+	if got != want {
+		t.Errorf("canonical() = %s, want %s", got, want)
+	}
+}
@@ -16,14 +16,16 @@ package toolinternal
 
 import (
 	"context"
+	"errors"
+	"fmt"
 
-	"github.com/google/uuid"
 	"google.golang.org/genai"
 
 	"google.golang.org/adk/agent"
 	"google.golang.org/adk/artifact"
 	contextinternal "google.golang.org/adk/internal/context"
 	"google.golang.org/adk/memory"
+	"google.golang.org/adk/runtimedeps"
 	"google.golang.org/adk/session"
 	"google.golang.org/adk/tool"
 )
@@ -48,9 +50,18 @@ func (ia *internalArtifacts) Save(ctx context.Context, name string, data *genai.
 	return resp, nil
 }
 
+// Delete implements [agent.ArtifactDeleter], if the wrapped artifacts do.
+func (ia *internalArtifacts) Delete(ctx context.Context, name string) error {
+	deleter, ok := ia.Artifacts.(agent.ArtifactDeleter)
+	if !ok {
+		return fmt.Errorf("failed to delete artifact %q: %w", name, errors.ErrUnsupported)
+	}
+	return deleter.Delete(ctx, name)
+}
+
 func NewToolContext(ctx agent.InvocationContext, functionCallID string, actions *session.EventActions) tool.Context {
 	if functionCallID == "" {
-		functionCallID = uuid.NewString()
+		functionCallID = runtimedeps.FromContext(ctx).NewID()
 	}
 	if actions == nil {
 		actions = &session.EventActions{StateDelta: make(map[string]any)}
@@ -99,3 +110,28 @@ func (c *toolContext) AgentName() string {
 func (c *toolContext) SearchMemory(ctx context.Context, query string) (*memory.SearchResponse, error) {
 	return c.invocationContext.Memory().Search(ctx, query)
 }
+
+// StateStale reports whether the session state read by the tool was changed
+// by a concurrent invocation.
+func (c *toolContext) StateStale() bool {
+	vs, ok := c.invocationContext.Session().(session.VersionedSession)
+	return ok && vs.StateStale()
+}
+
+// RefreshState reloads the session state, keeping the changes made by the
+// tool so far.
+func (c *toolContext) RefreshState(ctx context.Context) error {
+	vs, ok := c.invocationContext.Session().(session.VersionedSession)
+	if !ok {
+		return nil
+	}
+	if err := vs.RefreshState(ctx); err != nil {
+		return err
+	}
+	for key, value := range c.eventActions.StateDelta {
+		if err := c.State().Set(key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
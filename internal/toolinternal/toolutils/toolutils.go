@@ -16,11 +16,14 @@
 package toolutils
 
 import (
+	"context"
 	"fmt"
 
 	"google.golang.org/genai"
 
 	"google.golang.org/adk/model"
+	"google.golang.org/adk/model/assembly"
+	"google.golang.org/adk/strictness"
 )
 
 type Tool interface {
@@ -32,7 +35,11 @@ type Tool interface {
 // all of them are consolidated into one genai tool that has all the function declarations
 // provided by the tools. So, if there is already a tool with a function declaration,
 // it appends another to it; otherwise, it creates a new genai tool.
-func PackTool(req *model.LLMRequest, tool Tool) error {
+// A tool without a declaration is not declared, which is reported with the
+// strictness policy of ctx.
+func PackTool(ctx context.Context, req *model.LLMRequest, tool Tool) error {
+	defer assembly.Step(req, "toolutils.PackTool", "declare tool", tool.Name())()
+
 	if req.Tools == nil {
 		req.Tools = make(map[string]any)
 	}
@@ -48,7 +55,7 @@ func PackTool(req *model.LLMRequest, tool Tool) error {
 		req.Config = &genai.GenerateContentConfig{}
 	}
 	if decl := tool.Declaration(); decl == nil {
-		return nil
+		return strictness.Report(ctx, strictness.Tools, "toolutils.PackTool", "tool %q has no declaration: it is not declared to the model", name)
 	}
 	// Find an existing genai.Tool with FunctionDeclarations
 	var funcTool *genai.Tool
@@ -67,3 +74,31 @@ func PackTool(req *model.LLMRequest, tool Tool) error {
 	}
 	return nil
 }
+
+// NativeToolNames returns the names identifying the Gemini native tools
+// configured in t, e.g. "google_search". Function declarations are ignored.
+func NativeToolNames(t *genai.Tool) []string {
+	if t == nil {
+		return nil
+	}
+	var names []string
+	for _, native := range []struct {
+		name string
+		set  bool
+	}{
+		{"retrieval", t.Retrieval != nil},
+		{"google_search_retrieval", t.GoogleSearchRetrieval != nil},
+		{"computer_use", t.ComputerUse != nil},
+		{"file_search", t.FileSearch != nil},
+		{"code_execution", t.CodeExecution != nil},
+		{"enterprise_web_search", t.EnterpriseWebSearch != nil},
+		{"google_maps", t.GoogleMaps != nil},
+		{"google_search", t.GoogleSearch != nil},
+		{"url_context", t.URLContext != nil},
+	} {
+		if native.set {
+			names = append(names, native.name)
+		}
+	}
+	return names
+}
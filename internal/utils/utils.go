@@ -15,13 +15,15 @@
 package utils
 
 import (
+	"context"
 	"strings"
 
-	"github.com/google/uuid"
 	"google.golang.org/genai"
 
 	"google.golang.org/adk/agent"
 	"google.golang.org/adk/model"
+	"google.golang.org/adk/model/assembly"
+	"google.golang.org/adk/runtimedeps"
 	"google.golang.org/adk/session"
 )
 
@@ -32,11 +34,12 @@ const afFunctionCallIDPrefix = "adk-"
 // PopulateClientFunctionCallID sets the function call ID field if it is empty.
 // Since the ID field is optional, some models don't fill the field, but
 // the LLMAgent depends on the IDs to map FunctionCall and FunctionResponse events
-// in the event stream.
-func PopulateClientFunctionCallID(c *genai.Content) {
+// in the event stream. The IDs are given by the dependencies of ctx, see
+// [runtimedeps].
+func PopulateClientFunctionCallID(ctx context.Context, c *genai.Content) {
 	for _, fn := range FunctionCalls(c) {
 		if fn.ID == "" {
-			fn.ID = afFunctionCallIDPrefix + uuid.NewString()
+			fn.ID = afFunctionCallIDPrefix + runtimedeps.FromContext(ctx).NewID()
 		}
 	}
 }
@@ -131,6 +134,8 @@ func AppendInstructions(r *model.LLMRequest, instructions ...string) {
 		return
 	}
 
+	defer assembly.Step(r, "utils.AppendInstructions", "append instructions", "")()
+
 	inst := strings.Join(instructions, "\n\n")
 
 	if r.Config == nil {
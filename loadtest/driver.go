@@ -0,0 +1,219 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loadtest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/artifact"
+	"google.golang.org/adk/runner"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+)
+
+const (
+	appName = "loadtest"
+	userID  = "loadtest_user"
+)
+
+// Config is the configuration of a load test.
+type Config struct {
+	Scenario Scenario
+	// Conversations is the number of concurrent conversations. Defaults to 1.
+	Conversations int
+	// RampUp is the time taken to start the concurrent conversations: they
+	// are started at regular intervals. They all start at once if it is
+	// zero.
+	RampUp time.Duration
+	// SteadyState is how long the load is sustained once all the
+	// conversations are started: every finished conversation is replaced by
+	// a new one until it has elapsed. If it is zero, each of the concurrent
+	// conversations is run once.
+	SteadyState time.Duration
+
+	// SessionService stores the sessions of the conversations. Defaults to
+	// an in-memory service.
+	SessionService session.Service
+	// ArtifactService stores the artifacts of the tool calls. Defaults to an
+	// in-memory service.
+	ArtifactService artifact.Service
+}
+
+// Run runs the load test described by cfg, and reports the latencies of
+// the stages.
+//
+// If ctx is cancelled, the conversations are stopped and Run returns the
+// report of the turns run so far, marked as aborted, with the error of ctx.
+func Run(ctx context.Context, cfg Config) (*Report, error) {
+	if cfg.Conversations <= 0 {
+		cfg.Conversations = 1
+	}
+	if cfg.Scenario.Turns <= 0 {
+		cfg.Scenario.Turns = 1
+	}
+	if cfg.SessionService == nil {
+		cfg.SessionService = session.InMemoryService()
+	}
+	if cfg.ArtifactService == nil {
+		cfg.ArtifactService = artifact.InMemoryService()
+	}
+
+	rec := NewRecorder()
+	work, err := NewTool(cfg.Scenario, rec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the synthetic tool: %w", err)
+	}
+	a, err := llmagent.New(llmagent.Config{
+		Name:        "loadtest_agent",
+		Description: "Agent answering the synthetic conversations.",
+		Model:       NewModel(cfg.Scenario, rec),
+		Tools:       []tool.Tool{work},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the agent: %w", err)
+	}
+	sessionService := &instrumentedService{Service: cfg.SessionService, rec: rec}
+	r, err := runner.New(runner.Config{
+		AppName:         appName,
+		Agent:           a,
+		SessionService:  sessionService,
+		ArtifactService: cfg.ArtifactService,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the runner: %w", err)
+	}
+
+	d := &driver{cfg: cfg, runner: r, sessions: sessionService, rec: rec}
+	start := time.Now()
+	d.steadyEnd = start.Add(cfg.RampUp + cfg.SteadyState)
+	var wg sync.WaitGroup
+	for i := range cfg.Conversations {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			delay := cfg.RampUp * time.Duration(i) / time.Duration(cfg.Conversations)
+			if sleep(ctx, delay) != nil {
+				return
+			}
+			d.runWorker(ctx, i)
+		}()
+	}
+	wg.Wait()
+
+	report := d.report(time.Since(start))
+	report.Aborted = ctx.Err() != nil
+	return report, ctx.Err()
+}
+
+// driver runs the conversations of a load test.
+type driver struct {
+	cfg       Config
+	runner    *runner.Runner
+	sessions  session.Service
+	rec       *Recorder
+	steadyEnd time.Time
+
+	mu            sync.Mutex
+	conversations int
+	turns         int
+	failedTurns   int
+}
+
+// runWorker runs the conversations of the worker, one after the other.
+func (d *driver) runWorker(ctx context.Context, worker int) {
+	for n := 0; ; n++ {
+		if ctx.Err() != nil {
+			return
+		}
+		if d.conversation(ctx, fmt.Sprintf("conversation_%d_%d", worker, n)) {
+			d.mu.Lock()
+			d.conversations++
+			d.mu.Unlock()
+		}
+		if d.cfg.SteadyState <= 0 || !time.Now().Before(d.steadyEnd) {
+			return
+		}
+	}
+}
+
+// conversation runs a conversation in a new session. It reports whether
+// all its turns were run.
+func (d *driver) conversation(ctx context.Context, sessionID string) bool {
+	if _, err := d.sessions.Create(ctx, &session.CreateRequest{AppName: appName, UserID: userID, SessionID: sessionID}); err != nil {
+		// The first turn cannot run.
+		d.recordTurn(ctx, err)
+		return false
+	}
+	for turn := range d.cfg.Scenario.Turns {
+		if ctx.Err() != nil {
+			return false
+		}
+		msg := genai.NewContentFromText(fmt.Sprintf("Synthetic message %d.", turn+1), genai.RoleUser)
+		start := time.Now()
+		var turnErr error
+		for _, err := range d.runner.Run(ctx, userID, sessionID, msg, agent.RunConfig{}) {
+			if err != nil {
+				turnErr = err
+				break
+			}
+		}
+		if ctx.Err() != nil {
+			// The turn was interrupted by the abort of the test.
+			return false
+		}
+		d.rec.Observe(StageTurn, time.Since(start), turnErr)
+		d.recordTurn(ctx, turnErr)
+	}
+	return true
+}
+
+func (d *driver) recordTurn(ctx context.Context, err error) {
+	if ctx.Err() != nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.turns++
+	if err != nil {
+		d.failedTurns++
+	}
+}
+
+func (d *driver) report(elapsed time.Duration) *Report {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	report := &Report{
+		Scenario:      d.cfg.Scenario.Name,
+		Conversations: d.conversations,
+		Turns:         d.turns,
+		FailedTurns:   d.failedTurns,
+		Duration:      elapsed,
+		Stages:        d.rec.Summaries(),
+	}
+	if d.turns > 0 {
+		report.ErrorRate = float64(d.failedTurns) / float64(d.turns)
+	}
+	if elapsed > 0 {
+		report.TurnsPerSecond = float64(d.turns) / elapsed.Seconds()
+	}
+	return report
+}
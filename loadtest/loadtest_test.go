@@ -0,0 +1,164 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loadtest_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"testing"
+	"time"
+
+	"google.golang.org/adk/loadtest"
+)
+
+func TestRun(t *testing.T) {
+	report, err := loadtest.Run(t.Context(), loadtest.Config{
+		Scenario: loadtest.Scenario{
+			Name:                "tiny",
+			Turns:               2,
+			ToolCallProbability: 1,
+			ModelLatency:        loadtest.Latency{Mean: time.Millisecond, Jitter: time.Millisecond},
+			ToolLatency:         loadtest.Latency{Mean: time.Millisecond},
+			ArtifactSize:        128,
+		},
+		Conversations: 3,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.Scenario != "tiny" || report.Conversations != 3 || report.Turns != 6 || report.FailedTurns != 0 || report.Aborted {
+		t.Errorf("report = %+v, want 3 conversations of 2 turns", report)
+	}
+	if report.Duration <= 0 || report.TurnsPerSecond <= 0 {
+		t.Errorf("report duration = %v, throughput = %v, want positive values", report.Duration, report.TurnsPerSecond)
+	}
+	wantCounts := map[loadtest.Stage]int{
+		loadtest.StageTurn: 6,
+		// Every turn calls the tool, then answers.
+		loadtest.StageModel:    12,
+		loadtest.StageTool:     6,
+		loadtest.StageArtifact: 6,
+	}
+	if len(report.Stages) != len(loadtest.Stages) {
+		t.Fatalf("report has %d stages, want %d", len(report.Stages), len(loadtest.Stages))
+	}
+	for i, s := range report.Stages {
+		if s.Stage != loadtest.Stages[i] {
+			t.Errorf("Stages[%d] = %q, want %q", i, s.Stage, loadtest.Stages[i])
+		}
+		if want, ok := wantCounts[s.Stage]; ok && s.Count != want {
+			t.Errorf("stage %q count = %d, want %d", s.Stage, s.Count, want)
+		}
+		if s.Count == 0 {
+			t.Errorf("stage %q was not measured", s.Stage)
+			continue
+		}
+		if s.Errors != 0 {
+			t.Errorf("stage %q has %d errors, want none", s.Stage, s.Errors)
+		}
+		if !(s.Min <= s.P50 && s.P50 <= s.P90 && s.P90 <= s.P95 && s.P95 <= s.P99 && s.P99 <= s.Max) {
+			t.Errorf("stage %q percentiles are not ordered: %+v", s.Stage, s)
+		}
+	}
+	if model := report.Stage(loadtest.StageModel); model.Min < 0 || model.Max > time.Second {
+		t.Errorf("model latency between %v and %v, want about 1ms", model.Min, model.Max)
+	}
+
+	var buf bytes.Buffer
+	if err := report.WriteCSV(&buf); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1+len(loadtest.Stages) || rows[0][1] != "stage" || rows[1][1] != string(loadtest.StageTurn) {
+		t.Errorf("CSV rows = %v, want a header and a row per stage", rows)
+	}
+}
+
+func TestRun_Errors(t *testing.T) {
+	report, err := loadtest.Run(t.Context(), loadtest.Config{
+		Scenario: loadtest.Scenario{
+			Turns:               4,
+			ToolCallProbability: 1,
+			ToolErrorRate:       1,
+			ModelErrorRate:      0.5,
+			Seed:                7,
+		},
+		Conversations: 2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.FailedTurns == 0 || report.ErrorRate <= 0 {
+		t.Errorf("report = %+v, want failed turns", report)
+	}
+	if got := report.Stage(loadtest.StageModel); got.Errors == 0 || got.Errors != report.FailedTurns {
+		t.Errorf("model errors = %d, want the %d failed turns", got.Errors, report.FailedTurns)
+	}
+	if got := report.Stage(loadtest.StageTool); got.Count > 0 && got.ErrorRate != 1 {
+		t.Errorf("tool error rate = %v, want 1", got.ErrorRate)
+	}
+}
+
+func TestRun_RampUpAndSteadyState(t *testing.T) {
+	report, err := loadtest.Run(t.Context(), loadtest.Config{
+		Scenario: loadtest.Scenario{
+			ModelLatency: loadtest.Latency{Mean: 2 * time.Millisecond},
+		},
+		Conversations: 2,
+		RampUp:        10 * time.Millisecond,
+		SteadyState:   20 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The conversations are replaced until the steady state ends.
+	if report.Conversations <= 2 {
+		t.Errorf("report has %d conversations, want more than the 2 concurrent ones", report.Conversations)
+	}
+	if report.Duration < 30*time.Millisecond {
+		t.Errorf("report duration = %v, want at least the ramp-up and steady state", report.Duration)
+	}
+}
+
+func TestRun_Abort(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	start := time.Now()
+	report, err := loadtest.Run(ctx, loadtest.Config{
+		Scenario: loadtest.Scenario{
+			Turns:        100,
+			ModelLatency: loadtest.Latency{Mean: 5 * time.Millisecond},
+		},
+		Conversations: 4,
+		RampUp:        time.Hour,
+		SteadyState:   time.Hour,
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Run() error = %v, want context.Canceled", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Run() returned after %v, want a prompt abort", elapsed)
+	}
+	if report == nil || !report.Aborted || report.FailedTurns != 0 {
+		t.Errorf("report = %+v, want an aborted report without failures", report)
+	}
+}
@@ -0,0 +1,196 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loadtest
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"google.golang.org/adk/session"
+)
+
+// Recorder collects the latencies of the stages. It is safe for concurrent
+// use.
+type Recorder struct {
+	mu      sync.Mutex
+	samples map[Stage][]time.Duration
+	errors  map[Stage]int
+}
+
+// NewRecorder returns an empty Recorder.
+func NewRecorder() *Recorder {
+	return &Recorder{
+		samples: make(map[Stage][]time.Duration),
+		errors:  make(map[Stage]int),
+	}
+}
+
+// Observe records an execution of stage which took d and failed with err,
+// if not nil.
+func (r *Recorder) Observe(stage Stage, d time.Duration, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.samples[stage] = append(r.samples[stage], d)
+	if err != nil {
+		r.errors[stage]++
+	}
+}
+
+// Summaries returns the summaries of the latencies of the stages, in the
+// order of [Stages].
+func (r *Recorder) Summaries() []StageSummary {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	summaries := make([]StageSummary, 0, len(Stages))
+	for _, stage := range Stages {
+		summaries = append(summaries, summarize(stage, r.samples[stage], r.errors[stage]))
+	}
+	return summaries
+}
+
+// StageSummary summarizes the latencies of a stage.
+type StageSummary struct {
+	Stage Stage
+	// Count is the number of executions of the stage, and Errors the number
+	// of failed ones.
+	Count  int
+	Errors int
+	// ErrorRate is Errors/Count.
+	ErrorRate float64
+	Min       time.Duration
+	Mean      time.Duration
+	P50       time.Duration
+	P90       time.Duration
+	P95       time.Duration
+	P99       time.Duration
+	Max       time.Duration
+}
+
+func summarize(stage Stage, samples []time.Duration, errors int) StageSummary {
+	s := StageSummary{Stage: stage, Count: len(samples), Errors: errors}
+	if len(samples) == 0 {
+		return s
+	}
+	sorted := slices.Sorted(slices.Values(samples))
+	var total time.Duration
+	for _, d := range sorted {
+		total += d
+	}
+	s.ErrorRate = float64(errors) / float64(len(sorted))
+	s.Min, s.Max = sorted[0], sorted[len(sorted)-1]
+	s.Mean = total / time.Duration(len(sorted))
+	s.P50 = percentile(sorted, 50)
+	s.P90 = percentile(sorted, 90)
+	s.P95 = percentile(sorted, 95)
+	s.P99 = percentile(sorted, 99)
+	return s
+}
+
+// percentile returns the pth percentile of sorted, with the nearest-rank
+// method.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	return sorted[max(rank-1, 0)]
+}
+
+// Report is the result of a load test.
+type Report struct {
+	Scenario string
+	// Conversations is the number of conversations completed.
+	Conversations int
+	// Turns is the number of turns run, and FailedTurns the number of the
+	// ones which failed.
+	Turns       int
+	FailedTurns int
+	// ErrorRate is FailedTurns/Turns.
+	ErrorRate float64
+	// Duration is the duration of the test, and TurnsPerSecond the
+	// throughput.
+	Duration       time.Duration
+	TurnsPerSecond float64
+	// Aborted reports whether the test was stopped by the cancellation of its
+	// context.
+	Aborted bool
+	// Stages summarizes the latencies of the stages, in the order of
+	// [Stages].
+	Stages []StageSummary
+}
+
+// Stage returns the summary of stage.
+func (r *Report) Stage(stage Stage) StageSummary {
+	for _, s := range r.Stages {
+		if s.Stage == stage {
+			return s
+		}
+	}
+	return StageSummary{Stage: stage}
+}
+
+// WriteCSV writes the summaries of the stages to w as CSV, with a header
+// row. The durations are in milliseconds.
+func (r *Report) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"scenario", "stage", "count", "errors", "error_rate", "min_ms", "mean_ms", "p50_ms", "p90_ms", "p95_ms", "p99_ms", "max_ms"}); err != nil {
+		return err
+	}
+	ms := func(d time.Duration) string {
+		return strconv.FormatFloat(float64(d)/float64(time.Millisecond), 'f', 3, 64)
+	}
+	for _, s := range r.Stages {
+		row := []string{
+			r.Scenario, string(s.Stage), strconv.Itoa(s.Count), strconv.Itoa(s.Errors),
+			strconv.FormatFloat(s.ErrorRate, 'f', 4, 64),
+			ms(s.Min), ms(s.Mean), ms(s.P50), ms(s.P90), ms(s.P95), ms(s.P99), ms(s.Max),
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// String returns a human readable summary of the report.
+func (r *Report) String() string {
+	out := fmt.Sprintf("scenario %q: %d conversations, %d turns (%.1f/s), %d failed (%.2f%%) in %v",
+		r.Scenario, r.Conversations, r.Turns, r.TurnsPerSecond, r.FailedTurns, 100*r.ErrorRate, r.Duration)
+	if r.Aborted {
+		out += " (aborted)"
+	}
+	for _, s := range r.Stages {
+		out += fmt.Sprintf("\n  %-11s n=%-6d err=%-4d p50=%v p90=%v p99=%v max=%v", s.Stage, s.Count, s.Errors, s.P50, s.P90, s.P99, s.Max)
+	}
+	return out
+}
+
+// instrumentedService measures the storage of the events as
+// StagePersistence.
+type instrumentedService struct {
+	session.Service
+	rec *Recorder
+}
+
+func (s *instrumentedService) AppendEvent(ctx context.Context, sess session.Session, event *session.Event) error {
+	start := time.Now()
+	err := s.Service.AppendEvent(ctx, sess, event)
+	s.rec.Observe(StagePersistence, time.Since(start), err)
+	return err
+}
@@ -0,0 +1,112 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package loadtest runs load tests of agent deployments, to find how many
+// concurrent conversations they handle and where they saturate.
+//
+// A [Scenario] describes the conversations: their number of turns, how
+// often the model calls a tool and the latency of the model and the tools.
+// [Run] plays the conversations concurrently through the real runner, agent
+// and tool plumbing, with a synthetic model and tool which generate the load
+// without external calls, and reports the latency of every stage.
+package loadtest
+
+import (
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// Scenario describes the conversations of a load test.
+type Scenario struct {
+	// Name identifies the scenario in the reports.
+	Name string
+	// Turns is the number of user messages per conversation. Defaults to 1.
+	Turns int
+	// ToolCallProbability is the probability that the model calls the tool
+	// before answering a user message, between 0 and 1.
+	ToolCallProbability float64
+	// ModelLatency is the time taken by each model call.
+	ModelLatency Latency
+	// ToolLatency is the time taken by each tool call.
+	ToolLatency Latency
+	// ArtifactSize is the size in bytes of the artifact saved by each tool
+	// call. No artifact is saved if it is zero.
+	ArtifactSize int
+	// ModelErrorRate and ToolErrorRate are the probabilities, between 0 and
+	// 1, that a model or a tool call fails.
+	ModelErrorRate float64
+	ToolErrorRate  float64
+	// Seed seeds the random choices of the synthetic model and tool.
+	Seed uint64
+}
+
+// Latency is a distribution of durations, uniform between Mean-Jitter and
+// Mean+Jitter. The negative durations are rounded to zero.
+type Latency struct {
+	Mean   time.Duration
+	Jitter time.Duration
+}
+
+// Stage is a stage of the processing of the turns, measured by the load
+// tests.
+type Stage string
+
+const (
+	// StageTurn is the whole processing of a user message by the runner.
+	StageTurn Stage = "turn"
+	// StageModel is the wait for a model response.
+	StageModel Stage = "model"
+	// StageTool is the execution of a tool call, including the artifact
+	// saved by the tool.
+	StageTool Stage = "tool"
+	// StageArtifact is the saving of an artifact.
+	StageArtifact Stage = "artifact"
+	// StagePersistence is the storage of an event in the session service.
+	StagePersistence Stage = "persistence"
+)
+
+// Stages lists the stages in the order of the reports.
+var Stages = []Stage{StageTurn, StageModel, StageTool, StageArtifact, StagePersistence}
+
+// random is a source of random numbers safe for concurrent use.
+type random struct {
+	mu   sync.Mutex
+	rand *rand.Rand
+}
+
+func newRandom(seed uint64) *random {
+	return &random{rand: rand.New(rand.NewPCG(seed, seed))}
+}
+
+// chance returns true with probability p.
+func (r *random) chance(p float64) bool {
+	if p <= 0 {
+		return false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.rand.Float64() < p
+}
+
+// duration returns a duration following l.
+func (r *random) duration(l Latency) time.Duration {
+	d := l.Mean
+	if l.Jitter > 0 {
+		r.mu.Lock()
+		d += time.Duration(r.rand.Int64N(int64(2*l.Jitter)+1)) - l.Jitter
+		r.mu.Unlock()
+	}
+	return max(d, 0)
+}
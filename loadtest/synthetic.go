@@ -0,0 +1,159 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loadtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"strings"
+	"time"
+
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+)
+
+// ToolName is the name of the synthetic tool.
+const ToolName = "synthetic_work"
+
+var (
+	// ErrSyntheticModel is returned by the model calls failed by the
+	// synthetic model.
+	ErrSyntheticModel = errors.New("synthetic model failure")
+	// ErrSyntheticTool is returned by the tool calls failed by the synthetic
+	// tool.
+	ErrSyntheticTool = errors.New("synthetic tool failure")
+)
+
+// NewModel returns a synthetic model generating the load of scenario. It
+// answers the user messages, after calling the synthetic tool with the
+// probability of the scenario. Its calls are measured as StageModel in rec.
+func NewModel(scenario Scenario, rec *Recorder) model.LLM {
+	return &syntheticModel{scenario: scenario, rec: rec, random: newRandom(scenario.Seed)}
+}
+
+type syntheticModel struct {
+	scenario Scenario
+	rec      *Recorder
+	random   *random
+}
+
+func (m *syntheticModel) Name() string {
+	return "synthetic"
+}
+
+func (m *syntheticModel) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {
+		start := time.Now()
+		resp, err := m.generate(ctx, req)
+		m.rec.Observe(StageModel, time.Since(start), err)
+		yield(resp, err)
+	}
+}
+
+func (m *syntheticModel) generate(ctx context.Context, req *model.LLMRequest) (*model.LLMResponse, error) {
+	if err := sleep(ctx, m.random.duration(m.scenario.ModelLatency)); err != nil {
+		return nil, err
+	}
+	if m.random.chance(m.scenario.ModelErrorRate) {
+		return nil, ErrSyntheticModel
+	}
+	if !answersTool(req) && m.random.chance(m.scenario.ToolCallProbability) {
+		call := genai.NewContentFromFunctionCall(ToolName, map[string]any{"task": "work"}, genai.RoleModel)
+		return &model.LLMResponse{Content: call, TurnComplete: true}, nil
+	}
+	return &model.LLMResponse{
+		Content:      genai.NewContentFromText("Synthetic answer.", genai.RoleModel),
+		FinishReason: genai.FinishReasonStop,
+		TurnComplete: true,
+	}, nil
+}
+
+// answersTool reports whether the last content of req is a tool response.
+func answersTool(req *model.LLMRequest) bool {
+	if len(req.Contents) == 0 {
+		return false
+	}
+	last := req.Contents[len(req.Contents)-1]
+	for _, p := range last.Parts {
+		if p.FunctionResponse != nil {
+			return true
+		}
+	}
+	return false
+}
+
+type toolArgs struct {
+	Task string `json:"task"`
+}
+
+type toolResult struct {
+	Status string `json:"status"`
+}
+
+// NewTool returns the synthetic tool called by the synthetic model. It
+// waits for the latency of scenario and saves an artifact of its size. Its
+// calls are measured as StageTool in rec, and the artifacts as
+// StageArtifact.
+func NewTool(scenario Scenario, rec *Recorder) (tool.Tool, error) {
+	random := newRandom(scenario.Seed + 1)
+	var payload string
+	if scenario.ArtifactSize > 0 {
+		payload = strings.Repeat("x", scenario.ArtifactSize)
+	}
+	return functiontool.New(functiontool.Config{
+		Name:        ToolName,
+		Description: "Performs synthetic work for load tests.",
+	}, func(ctx tool.Context, args toolArgs) (result toolResult, err error) {
+		start := time.Now()
+		defer func() {
+			rec.Observe(StageTool, time.Since(start), err)
+		}()
+		if err := sleep(ctx, random.duration(scenario.ToolLatency)); err != nil {
+			return toolResult{}, err
+		}
+		if random.chance(scenario.ToolErrorRate) {
+			return toolResult{}, ErrSyntheticTool
+		}
+		if payload != "" && ctx.Artifacts() != nil {
+			saveStart := time.Now()
+			_, err := ctx.Artifacts().Save(ctx, fmt.Sprintf("synthetic_%s.txt", ctx.FunctionCallID()), genai.NewPartFromText(payload))
+			rec.Observe(StageArtifact, time.Since(saveStart), err)
+			if err != nil {
+				return toolResult{}, err
+			}
+		}
+		return toolResult{Status: "done"}, nil
+	})
+}
+
+// sleep waits for d, or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,390 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package anthropic implements the [model.LLM] interface for the Claude
+// models, with the Anthropic Messages API.
+package anthropic
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"iter"
+
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/internal/llmconv"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/strictness"
+)
+
+// defaultMaxTokens is the maximum number of output tokens of the requests
+// whose config sets none, the API requiring one.
+const defaultMaxTokens = 4096
+
+// CacheCreationTokensMetadataKey is the key of the custom metadata holding
+// the number of input tokens written to the prompt cache, which are counted
+// in the prompt tokens of the usage.
+const CacheCreationTokensMetadataKey = "anthropic_cache_creation_input_tokens"
+
+type anthropicModel struct {
+	name   string
+	client *Client
+}
+
+// NewModel returns the model modelName of a client created with opts.
+//
+// The client sends the requests with httpx.DefaultClient, which propagates
+// the metadata of the invocations in their headers, unless opts set another
+// client with [WithHTTPClient].
+func NewModel(ctx context.Context, modelName string, opts ...Option) (model.LLM, error) {
+	return NewModelWithClient(modelName, NewClient(opts...)), nil
+}
+
+// ModelPattern is the pattern of the names of the Claude models, registered
+// by [RegisterDefaults].
+const ModelPattern = `^claude-`
+
+// RegisterDefaults registers the Claude models for [model.Resolve], created
+// with opts.
+func RegisterDefaults(opts ...Option) {
+	model.Register(ModelPattern, func(ctx context.Context, name string) (model.LLM, error) {
+		return NewModel(ctx, name, opts...)
+	})
+}
+
+// NewModelWithClient returns the model modelName called with client, so
+// that a client can be shared by several models.
+func NewModelWithClient(modelName string, client *Client) model.LLM {
+	return &anthropicModel{name: modelName, client: client}
+}
+
+func (m *anthropicModel) Name() string {
+	return m.name
+}
+
+func (m *anthropicModel) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	llmconv.MaybeAppendUserContent(req)
+	body, err := newRequest(ctx, req, m.name)
+	if err != nil {
+		return func(yield func(*model.LLMResponse, error) bool) {
+			yield(nil, err)
+		}
+	}
+	if stream {
+		body.Stream = true
+		return m.generateStream(ctx, body)
+	}
+	return func(yield func(*model.LLMResponse, error) bool) {
+		resp, err := m.generate(ctx, body)
+		yield(resp, err)
+	}
+}
+
+func (m *anthropicModel) generate(ctx context.Context, body *messageRequest) (*model.LLMResponse, error) {
+	msg, err := m.client.createMessage(ctx, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate content: %w", classify(err))
+	}
+	return convertMessage(msg), nil
+}
+
+// generateStream yields the text of the stream as partial responses,
+// followed by the complete response of the turn: its whole text and its
+// function calls, with the finish reason and the usage.
+func (m *anthropicModel) generateStream(ctx context.Context, body *messageRequest) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {
+		var acc messageAccumulator
+		for event, err := range m.client.streamMessage(ctx, body) {
+			if err != nil {
+				yield(nil, fmt.Errorf("failed to generate stream content: %w", classify(err)))
+				return
+			}
+			if resp := acc.add(event); resp != nil && !yield(resp, nil) {
+				return
+			}
+			if event.Type == "message_stop" {
+				break
+			}
+		}
+		msg := acc.message()
+		if msg == nil {
+			yield(nil, fmt.Errorf("failed to generate stream content: the stream ended without a message"))
+			return
+		}
+		yield(convertMessage(msg), nil)
+	}
+}
+
+// newRequest converts req to a request of the Messages API. The parts and
+// the parameters that cannot be sent to Anthropic are dropped, which is
+// reported with the strictness policy of ctx.
+func newRequest(ctx context.Context, req *model.LLMRequest, modelName string) (*messageRequest, error) {
+	body := &messageRequest{
+		Model:     modelName,
+		MaxTokens: defaultMaxTokens,
+	}
+	messages, err := convertContents(ctx, req.Contents)
+	if err != nil {
+		return nil, err
+	}
+	body.Messages = messages
+
+	cfg := req.Config
+	if cfg == nil {
+		return body, nil
+	}
+	if cfg.SystemInstruction != nil {
+		for _, part := range cfg.SystemInstruction.Parts {
+			if part != nil && part.Text != "" && !part.Thought {
+				body.System = append(body.System, contentBlock{Type: "text", Text: part.Text})
+			}
+		}
+	}
+	if cfg.MaxOutputTokens > 0 {
+		body.MaxTokens = int64(cfg.MaxOutputTokens)
+	}
+	body.Temperature = cfg.Temperature
+	body.TopP = cfg.TopP
+	if cfg.TopK != nil {
+		topK := int64(*cfg.TopK)
+		body.TopK = &topK
+	}
+	body.StopSequences = cfg.StopSequences
+	if cfg.CandidateCount > 1 {
+		if err := ignore(ctx, "candidate_count"); err != nil {
+			return nil, err
+		}
+	}
+	if body.Tools, err = convertTools(ctx, cfg.Tools); err != nil {
+		return nil, err
+	}
+	if len(body.Tools) > 0 && cfg.ToolConfig != nil {
+		body.ToolChoice = convertToolConfig(cfg.ToolConfig)
+	}
+	return body, nil
+}
+
+// convertContents converts the contents to messages: the contents of the
+// model are sent as assistant messages, the others as user messages. The
+// function calls and responses without an ID are paired with synthesized
+// IDs, which Anthropic requires.
+func convertContents(ctx context.Context, contents []*genai.Content) ([]message, error) {
+	var messages []message
+	ids := llmconv.CallIDs{Prefix: "toolu_"}
+	for _, content := range contents {
+		if content == nil {
+			continue
+		}
+		role := "user"
+		if content.Role == genai.RoleModel {
+			role = "assistant"
+		}
+		var blocks []contentBlock
+		for _, part := range content.Parts {
+			if part == nil || part.Thought {
+				continue
+			}
+			switch {
+			case part.Text != "":
+				blocks = append(blocks, contentBlock{Type: "text", Text: part.Text})
+			case part.FunctionCall != nil:
+				call := ids.Call(part.FunctionCall)
+				input, err := json.Marshal(call.Args)
+				if err != nil {
+					return nil, fmt.Errorf("failed to encode the arguments of the call to %q: %w", call.Name, err)
+				}
+				if call.Args == nil {
+					input = []byte("{}")
+				}
+				blocks = append(blocks, contentBlock{Type: "tool_use", ID: call.ID, Name: call.Name, Input: input})
+			case part.FunctionResponse != nil:
+				resp := ids.Response(part.FunctionResponse)
+				result, err := json.Marshal(resp.Response)
+				if err != nil {
+					return nil, fmt.Errorf("failed to encode the response of %q: %w", resp.Name, err)
+				}
+				_, failed := resp.Response["error"]
+				blocks = append(blocks, contentBlock{Type: "tool_result", ToolUseID: resp.ID, Content: string(result), IsError: failed})
+			case part.InlineData != nil && isImage(part.InlineData):
+				blocks = append(blocks, contentBlock{Type: "image", Source: &imageSource{
+					Type:      "base64",
+					MediaType: part.InlineData.MIMEType,
+					Data:      base64.StdEncoding.EncodeToString(part.InlineData.Data),
+				}})
+			case part.InlineData != nil:
+				if err := drop(ctx, "inline data", part.InlineData.MIMEType); err != nil {
+					return nil, err
+				}
+			case part.FileData != nil:
+				if err := drop(ctx, "file data", part.FileData.FileURI); err != nil {
+					return nil, err
+				}
+			}
+		}
+		// The API rejects the messages without content.
+		if len(blocks) > 0 {
+			messages = append(messages, message{Role: role, Content: blocks})
+		}
+	}
+	return messages, nil
+}
+
+// isImage reports whether blob is an image of a type accepted by the API.
+func isImage(blob *genai.Blob) bool {
+	switch blob.MIMEType {
+	case "image/jpeg", "image/png", "image/gif", "image/webp":
+		return true
+	default:
+		return false
+	}
+}
+
+// convertTools converts the function declarations of tools. The other
+// tools, e.g. Google Search, are dropped.
+func convertTools(ctx context.Context, tools []*genai.Tool) ([]toolParam, error) {
+	var params []toolParam
+	for _, t := range tools {
+		if t == nil {
+			continue
+		}
+		for _, decl := range t.FunctionDeclarations {
+			schema, err := inputSchema(decl)
+			if err != nil {
+				return nil, fmt.Errorf("failed to convert the parameters of %q: %w", decl.Name, err)
+			}
+			params = append(params, toolParam{Name: decl.Name, Description: decl.Description, InputSchema: schema})
+		}
+		if len(t.FunctionDeclarations) == 0 {
+			if err := drop(ctx, "tool", "other than functions"); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return params, nil
+}
+
+// inputSchema returns the JSON schema of the parameters of decl. The
+// functions without parameters take an empty object.
+func inputSchema(decl *genai.FunctionDeclaration) (any, error) {
+	schema, err := llmconv.ParametersSchema(decl)
+	if err != nil || schema != nil {
+		return schema, err
+	}
+	return map[string]any{"type": "object", "properties": map[string]any{}}, nil
+}
+
+// convertToolConfig converts the function calling mode of cfg to a tool
+// choice: ANY forces a tool call, of the only allowed function if there is
+// one.
+func convertToolConfig(cfg *genai.ToolConfig) *toolChoice {
+	if cfg.FunctionCallingConfig == nil {
+		return nil
+	}
+	switch cfg.FunctionCallingConfig.Mode {
+	case genai.FunctionCallingConfigModeAuto:
+		return &toolChoice{Type: "auto"}
+	case genai.FunctionCallingConfigModeAny:
+		if names := cfg.FunctionCallingConfig.AllowedFunctionNames; len(names) == 1 {
+			return &toolChoice{Type: "tool", Name: names[0]}
+		}
+		return &toolChoice{Type: "any"}
+	case genai.FunctionCallingConfigModeNone:
+		return &toolChoice{Type: "none"}
+	default:
+		return nil
+	}
+}
+
+// drop reports that the adapter dropped a part or a tool which cannot be
+// sent to Anthropic, with the strictness policy of ctx.
+func drop(ctx context.Context, kind, detail string) error {
+	return strictness.Report(ctx, strictness.Conversion, "anthropic", "dropped %s %s: not supported by Anthropic", kind, detail)
+}
+
+// ignore reports that the adapter ignored a parameter of the config which
+// Anthropic does not support, with the strictness policy of ctx.
+func ignore(ctx context.Context, what string) error {
+	return strictness.Report(ctx, strictness.Conversion, "anthropic", "ignored %s: not supported by Anthropic", what)
+}
+
+// convertMessage converts a complete message to a response.
+func convertMessage(msg *messageResponse) *model.LLMResponse {
+	content := &genai.Content{Role: genai.RoleModel}
+	resp := &model.LLMResponse{
+		Content:       content,
+		UsageMetadata: convertUsage(msg.Usage),
+		FinishReason:  finishReason(msg.StopReason),
+		TurnComplete:  true,
+	}
+	for _, block := range msg.Content {
+		switch block.Type {
+		case "text":
+			content.Parts = append(content.Parts, &genai.Part{Text: block.Text})
+		case "tool_use":
+			var args map[string]any
+			if len(block.Input) > 0 {
+				if err := json.Unmarshal(block.Input, &args); err != nil {
+					resp.ErrorCode = string(model.ErrorCodeUnknown)
+					resp.ErrorMessage = fmt.Sprintf("invalid arguments of the call to %q: %v", block.Name, err)
+					continue
+				}
+			}
+			content.Parts = append(content.Parts, &genai.Part{FunctionCall: &genai.FunctionCall{ID: block.ID, Name: block.Name, Args: args}})
+		}
+	}
+	if msg.StopReason == "refusal" {
+		resp.ErrorCode = string(model.ErrorCodeRefusal)
+		resp.ErrorMessage = "The model declined to answer."
+	}
+	if msg.ID != "" {
+		resp.CustomMetadata = map[string]any{model.ResponseIDMetadataKey: msg.ID}
+	}
+	if n := msg.Usage.CacheCreationInputTokens; n > 0 {
+		if resp.CustomMetadata == nil {
+			resp.CustomMetadata = map[string]any{}
+		}
+		resp.CustomMetadata[CacheCreationTokensMetadataKey] = n
+	}
+	return resp
+}
+
+// convertUsage converts the usage of a message. Anthropic does not count
+// the tokens read from or written to the cache in the input tokens: they
+// are added to the prompt tokens.
+func convertUsage(u usage) *genai.GenerateContentResponseUsageMetadata {
+	prompt := u.InputTokens + u.CacheCreationInputTokens + u.CacheReadInputTokens
+	return &genai.GenerateContentResponseUsageMetadata{
+		PromptTokenCount:        int32(prompt),
+		CachedContentTokenCount: int32(u.CacheReadInputTokens),
+		CandidatesTokenCount:    int32(u.OutputTokens),
+		TotalTokenCount:         int32(prompt + u.OutputTokens),
+	}
+}
+
+func finishReason(reason string) genai.FinishReason {
+	switch reason {
+	case "end_turn", "stop_sequence", "tool_use", "pause_turn":
+		return genai.FinishReasonStop
+	case "max_tokens", "model_context_window_exceeded":
+		return genai.FinishReasonMaxTokens
+	case "refusal":
+		return genai.FinishReasonSafety
+	case "":
+		return genai.FinishReasonUnspecified
+	default:
+		return genai.FinishReasonOther
+	}
+}
@@ -0,0 +1,270 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package anthropic_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/model/anthropic"
+	"google.golang.org/adk/model/modeltest"
+)
+
+func newModel(t *testing.T, srv *httptest.Server) model.LLM {
+	t.Helper()
+	llm, err := anthropic.NewModel(t.Context(), "claude-sonnet-4-5", anthropic.WithBaseURL(srv.URL), anthropic.WithAPIKey("key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return llm
+}
+
+func TestModel_Request(t *testing.T) {
+	var got map[string]any
+	srv := modeltest.NewServer(t, "/v1/messages", &got, func(w http.ResponseWriter, r *http.Request) {
+		if key := r.Header.Get("x-api-key"); key != "key" {
+			t.Errorf("x-api-key = %q, want key", key)
+		}
+		if r.Header.Get("anthropic-version") == "" {
+			t.Error("anthropic-version header missing")
+		}
+		fmt.Fprint(w, `{"id":"msg_1","content":[],"stop_reason":"end_turn","usage":{"input_tokens":1,"output_tokens":1}}`)
+	})
+	temperature := float32(0.5)
+	req := &model.LLMRequest{
+		Contents: []*genai.Content{
+			{Role: genai.RoleUser, Parts: []*genai.Part{
+				genai.NewPartFromText("what is this?"),
+				genai.NewPartFromBytes([]byte("png"), "image/png"),
+			}},
+			genai.NewContentFromFunctionCall("lookup", map[string]any{"q": "cat"}, genai.RoleModel),
+			genai.NewContentFromFunctionResponse("lookup", map[string]any{"result": "a cat"}, genai.RoleUser),
+		},
+		Config: &genai.GenerateContentConfig{
+			SystemInstruction: genai.NewContentFromText("Be brief.", genai.RoleUser),
+			MaxOutputTokens:   100,
+			Temperature:       &temperature,
+			StopSequences:     []string{"END"},
+			Tools: []*genai.Tool{{FunctionDeclarations: []*genai.FunctionDeclaration{{
+				Name:        "lookup",
+				Description: "looks things up",
+				Parameters: &genai.Schema{
+					Type:       genai.TypeObject,
+					Properties: map[string]*genai.Schema{"q": {Type: genai.TypeString}},
+					Required:   []string{"q"},
+				},
+			}}}},
+			ToolConfig: &genai.ToolConfig{FunctionCallingConfig: &genai.FunctionCallingConfig{Mode: genai.FunctionCallingConfigModeAny}},
+		},
+	}
+	if _, err := model.Collect(newModel(t, srv).GenerateContent(t.Context(), req, false)); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]any{
+		"model":          "claude-sonnet-4-5",
+		"max_tokens":     float64(100),
+		"temperature":    0.5,
+		"stop_sequences": []any{"END"},
+		"system":         []any{map[string]any{"type": "text", "text": "Be brief."}},
+		"messages": []any{
+			map[string]any{"role": "user", "content": []any{
+				map[string]any{"type": "text", "text": "what is this?"},
+				map[string]any{"type": "image", "source": map[string]any{"type": "base64", "media_type": "image/png", "data": "cG5n"}},
+			}},
+			map[string]any{"role": "assistant", "content": []any{
+				map[string]any{"type": "tool_use", "id": "toolu_0", "name": "lookup", "input": map[string]any{"q": "cat"}},
+			}},
+			map[string]any{"role": "user", "content": []any{
+				map[string]any{"type": "tool_result", "tool_use_id": "toolu_0", "content": `{"result":"a cat"}`},
+			}},
+		},
+		"tools": []any{map[string]any{
+			"name":        "lookup",
+			"description": "looks things up",
+			"input_schema": map[string]any{
+				"type":       "object",
+				"properties": map[string]any{"q": map[string]any{"type": "string"}},
+				"required":   []any{"q"},
+			},
+		}},
+		"tool_choice": map[string]any{"type": "any"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("request mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestModel_Response(t *testing.T) {
+	var got map[string]any
+	srv := modeltest.NewServer(t, "/v1/messages", &got, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{
+			"id": "msg_1",
+			"type": "message",
+			"role": "assistant",
+			"content": [
+				{"type": "text", "text": "Let me look."},
+				{"type": "tool_use", "id": "toolu_1", "name": "lookup", "input": {"q": "cat"}}
+			],
+			"stop_reason": "tool_use",
+			"usage": {"input_tokens": 10, "output_tokens": 5, "cache_creation_input_tokens": 20, "cache_read_input_tokens": 30}
+		}`)
+	})
+	resp, err := model.Collect(newModel(t, srv).GenerateContent(t.Context(), &model.LLMRequest{Contents: []*genai.Content{genai.NewContentFromText("hi", genai.RoleUser)}}, false))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &model.LLMResponse{
+		Content: &genai.Content{Role: genai.RoleModel, Parts: []*genai.Part{
+			{Text: "Let me look."},
+			{FunctionCall: &genai.FunctionCall{ID: "toolu_1", Name: "lookup", Args: map[string]any{"q": "cat"}}},
+		}},
+		UsageMetadata: &genai.GenerateContentResponseUsageMetadata{
+			PromptTokenCount:        60,
+			CachedContentTokenCount: 30,
+			CandidatesTokenCount:    5,
+			TotalTokenCount:         65,
+		},
+		CustomMetadata: map[string]any{
+			model.ResponseIDMetadataKey:              "msg_1",
+			anthropic.CacheCreationTokensMetadataKey: int64(20),
+		},
+		TurnComplete: true,
+		FinishReason: genai.FinishReasonStop,
+	}
+	if diff := cmp.Diff(want, resp); diff != "" {
+		t.Errorf("response mismatch (-want +got):\n%s", diff)
+	}
+}
+
+const streamBody = `event: message_start
+data: {"type":"message_start","message":{"id":"msg_1","type":"message","role":"assistant","content":[],"stop_reason":null,"usage":{"input_tokens":10,"output_tokens":1}}}
+
+event: content_block_start
+data: {"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}
+
+event: ping
+data: {"type":"ping"}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Let me "}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"look."}}
+
+event: content_block_stop
+data: {"type":"content_block_stop","index":0}
+
+event: content_block_start
+data: {"type":"content_block_start","index":1,"content_block":{"type":"tool_use","id":"toolu_1","name":"lookup","input":{}}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"{\"q\": "}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"\"cat\"}"}}
+
+event: content_block_stop
+data: {"type":"content_block_stop","index":1}
+
+event: message_delta
+data: {"type":"message_delta","delta":{"stop_reason":"tool_use"},"usage":{"output_tokens":15}}
+
+event: message_stop
+data: {"type":"message_stop"}
+
+`
+
+func TestModel_Stream(t *testing.T) {
+	var got map[string]any
+	srv := modeltest.NewServer(t, "/v1/messages", &got, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, streamBody)
+	})
+	var partials []*model.LLMResponse
+	resp, err := model.TeeCollect(newModel(t, srv).GenerateContent(t.Context(), &model.LLMRequest{Contents: []*genai.Content{genai.NewContentFromText("hi", genai.RoleUser)}}, true), func(partial *model.LLMResponse) error {
+		partials = append(partials, partial)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got["stream"] != true {
+		t.Errorf("stream = %v, want true", got["stream"])
+	}
+	wantPartials := []*model.LLMResponse{
+		{Content: genai.NewContentFromText("Let me ", genai.RoleModel), Partial: true},
+		{Content: genai.NewContentFromText("look.", genai.RoleModel), Partial: true},
+	}
+	if diff := cmp.Diff(wantPartials, partials); diff != "" {
+		t.Errorf("partial responses mismatch (-want +got):\n%s", diff)
+	}
+	want := &model.LLMResponse{
+		Content: &genai.Content{Role: genai.RoleModel, Parts: []*genai.Part{
+			{Text: "Let me look."},
+			{FunctionCall: &genai.FunctionCall{ID: "toolu_1", Name: "lookup", Args: map[string]any{"q": "cat"}}},
+		}},
+		UsageMetadata: &genai.GenerateContentResponseUsageMetadata{
+			PromptTokenCount:     10,
+			CandidatesTokenCount: 15,
+			TotalTokenCount:      25,
+		},
+		CustomMetadata: map[string]any{model.ResponseIDMetadataKey: "msg_1"},
+		TurnComplete:   true,
+		FinishReason:   genai.FinishReasonStop,
+	}
+	if diff := cmp.Diff(want, resp); diff != "" {
+		t.Errorf("response mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestModel_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		stream bool
+		status int
+		body   string
+		want   model.ErrorCode
+	}{
+		{"RateLimited", false, http.StatusTooManyRequests, `{"type":"error","error":{"type":"rate_limit_error","message":"slow down"}}`, model.ErrorCodeRateLimited},
+		{"Overloaded", false, 529, `{"type":"error","error":{"type":"overloaded_error","message":"overloaded"}}`, model.ErrorCodeUnavailable},
+		{"PromptTooLong", false, http.StatusBadRequest, `{"type":"error","error":{"type":"invalid_request_error","message":"prompt is too long: 300000 tokens > 200000 maximum"}}`, model.ErrorCodeContextTooLong},
+		{"AuthFailed", false, http.StatusUnauthorized, `{"type":"error","error":{"type":"authentication_error","message":"invalid x-api-key"}}`, model.ErrorCodeAuthFailed},
+		{"StreamError", true, http.StatusOK, "event: error\ndata: {\"type\":\"error\",\"error\":{\"type\":\"overloaded_error\",\"message\":\"overloaded\"}}\n\n", model.ErrorCodeUnavailable},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var got map[string]any
+			srv := modeltest.NewServer(t, "/v1/messages", &got, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tc.status)
+				fmt.Fprint(w, tc.body)
+			})
+			var err error
+			for _, err = range newModel(t, srv).GenerateContent(t.Context(), &model.LLMRequest{}, tc.stream) {
+			}
+			if code := model.CodeOf(err); code != tc.want {
+				t.Errorf("CodeOf(%v) = %s, want %s", err, code, tc.want)
+			}
+		})
+	}
+}
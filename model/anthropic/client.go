@@ -0,0 +1,247 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package anthropic
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+	"os"
+	"strings"
+
+	"google.golang.org/adk/httpx"
+)
+
+const (
+	defaultBaseURL = "https://api.anthropic.com"
+	// apiVersion is the version of the Messages API the requests are sent
+	// for, in their anthropic-version header.
+	apiVersion = "2023-06-01"
+)
+
+// Client sends the requests of the models to the Anthropic Messages API.
+type Client struct {
+	apiKey     string
+	baseURL    string
+	httpClient *http.Client
+	headers    http.Header
+}
+
+// Option configures a [Client].
+type Option func(*Client)
+
+// WithAPIKey sets the API key of the client. Defaults to the
+// ANTHROPIC_API_KEY environment variable.
+func WithAPIKey(key string) Option {
+	return func(c *Client) { c.apiKey = key }
+}
+
+// WithBaseURL sets the URL the client sends the requests to, e.g. for a
+// proxy or a test server. Defaults to the ANTHROPIC_BASE_URL environment
+// variable, or else to the Anthropic API.
+func WithBaseURL(url string) Option {
+	return func(c *Client) { c.baseURL = strings.TrimSuffix(url, "/") }
+}
+
+// WithHTTPClient sets the HTTP client sending the requests. Defaults to
+// httpx.DefaultClient, which propagates the metadata of the invocations in
+// their headers.
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *Client) { c.httpClient = client }
+}
+
+// WithHeader adds a header to the requests of the client, e.g. an
+// anthropic-beta header enabling a beta feature.
+func WithHeader(key, value string) Option {
+	return func(c *Client) { c.headers.Add(key, value) }
+}
+
+// NewClient returns a client configured by opts.
+func NewClient(opts ...Option) *Client {
+	c := &Client{
+		apiKey:     os.Getenv("ANTHROPIC_API_KEY"),
+		baseURL:    defaultBaseURL,
+		httpClient: httpx.DefaultClient,
+		headers:    make(http.Header),
+	}
+	if url := os.Getenv("ANTHROPIC_BASE_URL"); url != "" {
+		c.baseURL = strings.TrimSuffix(url, "/")
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Error is an error returned by the Anthropic API.
+type Error struct {
+	// StatusCode is the HTTP status of the response.
+	StatusCode int
+	// Type is the type of the error, e.g. "rate_limit_error".
+	Type string
+	// Message describes the error.
+	Message string
+}
+
+// Error implements error.
+func (e *Error) Error() string {
+	if e.StatusCode == 0 {
+		return fmt.Sprintf("anthropic: %s: %s", e.Type, e.Message)
+	}
+	return fmt.Sprintf("anthropic: %d %s: %s", e.StatusCode, e.Type, e.Message)
+}
+
+// errorBody is the body of the error responses and events.
+type errorBody struct {
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// send posts body to the messages endpoint, and returns the response if it
+// succeeded.
+func (c *Client) send(ctx context.Context, body *messageRequest) (*http.Response, error) {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode the request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/v1/messages", bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range c.headers {
+		req.Header[key] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("anthropic-version", apiVersion)
+	if c.apiKey != "" {
+		req.Header.Set("x-api-key", c.apiKey)
+	}
+	if body.Stream {
+		req.Header.Set("Accept", "text/event-stream")
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		apiErr := &Error{StatusCode: resp.StatusCode}
+		b, _ := io.ReadAll(resp.Body)
+		var e errorBody
+		if json.Unmarshal(b, &e) == nil && e.Error.Type != "" {
+			apiErr.Type, apiErr.Message = e.Error.Type, e.Error.Message
+		} else {
+			apiErr.Message = strings.TrimSpace(string(b))
+		}
+		return nil, apiErr
+	}
+	return resp, nil
+}
+
+// createMessage sends a non-streamed request.
+func (c *Client) createMessage(ctx context.Context, body *messageRequest) (*messageResponse, error) {
+	resp, err := c.send(ctx, body)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var msg messageResponse
+	if err := json.NewDecoder(resp.Body).Decode(&msg); err != nil {
+		return nil, fmt.Errorf("failed to decode the response: %w", err)
+	}
+	return &msg, nil
+}
+
+// streamMessage sends a streamed request, and returns its events. The
+// error events of the stream are returned as an [Error].
+func (c *Client) streamMessage(ctx context.Context, body *messageRequest) iter.Seq2[*streamEvent, error] {
+	return func(yield func(*streamEvent, error) bool) {
+		resp, err := c.send(ctx, body)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		defer resp.Body.Close()
+		scanner := bufio.NewScanner(resp.Body)
+		scanner.Buffer(make([]byte, 0, 64<<10), 16<<20)
+		var data strings.Builder
+		for scanner.Scan() {
+			line := scanner.Text()
+			if value, ok := strings.CutPrefix(line, "data:"); ok {
+				data.WriteString(strings.TrimPrefix(value, " "))
+				continue
+			}
+			// The event lines are redundant with the type of the data;
+			// the events are dispatched by the blank lines.
+			if line != "" || data.Len() == 0 {
+				continue
+			}
+			event, err := decodeEvent(data.String())
+			data.Reset()
+			if err != nil || event != nil && !yield(event, nil) {
+				if err != nil {
+					yield(nil, err)
+				}
+				return
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			yield(nil, err)
+			return
+		}
+		if data.Len() > 0 {
+			if event, err := decodeEvent(data.String()); err != nil {
+				yield(nil, err)
+			} else if event != nil {
+				yield(event, nil)
+			}
+		}
+	}
+}
+
+// decodeEvent decodes the data of a streamed event. It returns nil for the
+// pings.
+func decodeEvent(data string) (*streamEvent, error) {
+	var event streamEvent
+	if err := json.Unmarshal([]byte(data), &event); err != nil {
+		return nil, fmt.Errorf("failed to decode the stream event %q: %w", data, err)
+	}
+	switch event.Type {
+	case "ping":
+		return nil, nil
+	case "error":
+		var e errorBody
+		if err := json.Unmarshal([]byte(data), &e); err != nil {
+			return nil, err
+		}
+		return nil, &Error{Type: e.Error.Type, Message: e.Error.Message}
+	}
+	return &event, nil
+}
+
+// isAPIError reports whether err is an [Error], and returns it.
+func isAPIError(err error) (*Error, bool) {
+	var apiErr *Error
+	ok := errors.As(err, &apiErr)
+	return apiErr, ok
+}
@@ -0,0 +1,85 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package anthropic
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+
+	"google.golang.org/adk/model"
+)
+
+// ErrorCode classifies an error returned by the Anthropic client. API errors
+// are classified by their type and status code; network errors are
+// reported as unavailable.
+func ErrorCode(err error) model.ErrorCode {
+	if apiErr, ok := isAPIError(err); ok {
+		return apiErrorCode(apiErr)
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return model.ErrorCodeUnknown
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return model.ErrorCodeUnavailable
+	}
+	return model.ErrorCodeUnknown
+}
+
+// apiErrorCode classifies an Anthropic API error. The type of the error
+// takes precedence over the status code, which the errors of the streams
+// lack.
+func apiErrorCode(err *Error) model.ErrorCode {
+	switch err.Type {
+	case "rate_limit_error":
+		return model.ErrorCodeRateLimited
+	case "overloaded_error", "api_error":
+		return model.ErrorCodeUnavailable
+	case "authentication_error", "permission_error":
+		return model.ErrorCodeAuthFailed
+	case "request_too_large":
+		return model.ErrorCodeContextTooLong
+	case "billing_error":
+		return model.ErrorCodeQuotaExceeded
+	case "invalid_request_error":
+		// Context overflows are only told apart by their message.
+		if strings.Contains(strings.ToLower(err.Message), "prompt is too long") {
+			return model.ErrorCodeContextTooLong
+		}
+		return model.ErrorCodeInvalidRequest
+	}
+	switch status := err.StatusCode; {
+	case status == http.StatusTooManyRequests:
+		return model.ErrorCodeRateLimited
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return model.ErrorCodeAuthFailed
+	case status == http.StatusRequestEntityTooLarge:
+		return model.ErrorCodeContextTooLong
+	case status >= 500:
+		return model.ErrorCodeUnavailable
+	case status >= 400:
+		return model.ErrorCodeInvalidRequest
+	default:
+		return model.ErrorCodeUnknown
+	}
+}
+
+// classify wraps err in a [model.Error] carrying its code.
+func classify(err error) error {
+	return &model.Error{Code: ErrorCode(err), Err: err}
+}
@@ -0,0 +1,130 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package anthropic
+
+import (
+	"encoding/json"
+	"strings"
+
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/model"
+)
+
+// messageAccumulator rebuilds the message of a stream from its events.
+type messageAccumulator struct {
+	msg *messageResponse
+	// deltas collects the text or the arguments streamed for each content
+	// block, by index.
+	deltas map[int]*strings.Builder
+}
+
+// add applies event to the message. It returns the partial response
+// carrying the text streamed by event, if any.
+func (a *messageAccumulator) add(event *streamEvent) *model.LLMResponse {
+	switch event.Type {
+	case "message_start":
+		if event.Message != nil {
+			a.msg = event.Message
+		}
+	case "content_block_start":
+		if a.msg == nil || event.ContentBlock == nil || event.Index < 0 {
+			return nil
+		}
+		for len(a.msg.Content) <= event.Index {
+			a.msg.Content = append(a.msg.Content, contentBlock{})
+		}
+		a.msg.Content[event.Index] = *event.ContentBlock
+		if text := event.ContentBlock.Text; text != "" {
+			a.delta(event.Index).WriteString(text)
+			return partial(text)
+		}
+	case "content_block_delta":
+		if a.msg == nil || event.Delta == nil || event.Index < 0 || event.Index >= len(a.msg.Content) {
+			return nil
+		}
+		switch event.Delta.Type {
+		case "text_delta":
+			a.delta(event.Index).WriteString(event.Delta.Text)
+			if event.Delta.Text != "" {
+				return partial(event.Delta.Text)
+			}
+		case "input_json_delta":
+			a.delta(event.Index).WriteString(event.Delta.PartialJSON)
+		}
+	case "message_delta":
+		if a.msg == nil {
+			return nil
+		}
+		if event.Delta != nil && event.Delta.StopReason != "" {
+			a.msg.StopReason = event.Delta.StopReason
+		}
+		// The usage of the deltas is cumulative: the output tokens, and the
+		// input tokens if they are reported again.
+		if u := event.Usage; u != nil {
+			a.msg.Usage.OutputTokens = u.OutputTokens
+			if u.InputTokens > 0 {
+				a.msg.Usage.InputTokens = u.InputTokens
+			}
+			if u.CacheCreationInputTokens > 0 {
+				a.msg.Usage.CacheCreationInputTokens = u.CacheCreationInputTokens
+			}
+			if u.CacheReadInputTokens > 0 {
+				a.msg.Usage.CacheReadInputTokens = u.CacheReadInputTokens
+			}
+		}
+	}
+	return nil
+}
+
+func (a *messageAccumulator) delta(index int) *strings.Builder {
+	if a.deltas == nil {
+		a.deltas = make(map[int]*strings.Builder)
+	}
+	b, ok := a.deltas[index]
+	if !ok {
+		b = &strings.Builder{}
+		a.deltas[index] = b
+	}
+	return b
+}
+
+// message returns the message rebuilt from the events, nil if the stream
+// did not start one.
+func (a *messageAccumulator) message() *messageResponse {
+	if a.msg == nil {
+		return nil
+	}
+	for index, b := range a.deltas {
+		block := &a.msg.Content[index]
+		switch block.Type {
+		case "text":
+			block.Text = b.String()
+		case "tool_use":
+			if b.Len() > 0 {
+				block.Input = json.RawMessage(b.String())
+			}
+		}
+	}
+	a.deltas = nil
+	return a.msg
+}
+
+func partial(text string) *model.LLMResponse {
+	return &model.LLMResponse{
+		Content: genai.NewContentFromText(text, genai.RoleModel),
+		Partial: true,
+	}
+}
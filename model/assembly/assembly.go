@@ -0,0 +1,347 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package assembly traces how the LLM requests are assembled, to explain
+// which component added an instruction, declared a tool or dropped a
+// content of the request finally sent to the model.
+//
+// Tracing is enabled for all the requests with [Enable], or for the
+// requests of the invocations whose context carries a [Recorder]:
+//
+//	rec := &assembly.Recorder{}
+//	for ev, err := range r.Run(assembly.NewContext(ctx, rec), userID, sessionID, msg, cfg) {
+//		...
+//	}
+//	for _, tr := range rec.Traces() {
+//		fmt.Print(tr.Report())
+//	}
+//
+// The flow then attaches a [Trace] to every request it builds, and the
+// components mutating the request record their entries in it: the request
+// processors and the before model callbacks of the flow, the tools and the
+// declarations they pack, the instructions they append and the conversions
+// of the model adapters. The trace is also set in the custom metadata of
+// the model responses, see [FromResponse].
+//
+// Other components record their entries with [Step] or [Record]. Both
+// cost a single atomic load when tracing is disabled.
+package assembly
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"google.golang.org/adk/model"
+)
+
+// MetadataKey is the key of the trace in the custom metadata of the model
+// responses.
+const MetadataKey = "adk_assembly_trace"
+
+// Entry is a mutation of the request by a component.
+type Entry struct {
+	// Component names the component, e.g. "toolutils.PackTool".
+	Component string `json:"component"`
+	// Operation is what the component did, e.g. "declare tool".
+	Operation string `json:"operation"`
+	// Detail identifies the subject of the operation, e.g. a tool name.
+	Detail string `json:"detail,omitempty"`
+	// Depth is the number of steps the entry is nested in, e.g. 1 for the
+	// tool declared by the ProcessRequest of a tool.
+	Depth int `json:"depth,omitempty"`
+	// BytesDelta is the change of the size of the JSON encoding of the
+	// contents and the configuration of the request.
+	BytesDelta int `json:"bytesDelta"`
+	// TokensDelta is an estimate of the change of the number of tokens of
+	// the request, at 4 bytes per token.
+	TokensDelta int `json:"tokensDelta"`
+	// Diff summarizes the change, e.g. the appended instruction or the
+	// declared tools.
+	Diff string `json:"diff,omitempty"`
+}
+
+// Trace records the assembly of a request. The methods are safe to call on
+// a nil Trace, and do nothing.
+type Trace struct {
+	mu      sync.Mutex
+	entries []Entry
+	depth   int
+}
+
+// Entries returns the entries of t, in the order the steps started.
+func (t *Trace) Entries() []Entry {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return slices.Clone(t.entries)
+}
+
+// MarshalJSON encodes t as an object with its entries.
+func (t *Trace) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Entries []Entry `json:"entries"`
+	}{t.Entries()})
+}
+
+// Record records e, nested in the steps in progress.
+func (t *Trace) Record(e Entry) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	e.Depth += t.depth
+	t.entries = append(t.entries, e)
+}
+
+// Step records a mutation of req by a component: it measures req and
+// returns the function to call once the mutation is done, which records the
+// entry with the changes. The entries recorded in between are nested in it.
+func (t *Trace) Step(req *model.LLMRequest, component, operation, detail string) func() {
+	if t == nil {
+		return noop
+	}
+	before := snap(req)
+	t.mu.Lock()
+	i := len(t.entries)
+	t.entries = append(t.entries, Entry{Component: component, Operation: operation, Detail: detail, Depth: t.depth})
+	t.depth++
+	t.mu.Unlock()
+	return func() {
+		after := snap(req)
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		t.depth--
+		e := &t.entries[i]
+		e.BytesDelta = after.bytes - before.bytes
+		e.TokensDelta = tokens(after.bytes) - tokens(before.bytes)
+		e.Diff = diff(before, after)
+	}
+}
+
+func noop() {}
+
+// Report renders t as a readable report: an entry per line, indented by
+// depth, followed by the total change.
+func (t *Trace) Report() string {
+	var b strings.Builder
+	bytes, toks := 0, 0
+	for _, e := range t.Entries() {
+		b.WriteString(strings.Repeat("  ", e.Depth))
+		b.WriteString(e.Component + ": " + e.Operation)
+		if e.Detail != "" {
+			b.WriteString(" " + e.Detail)
+		}
+		fmt.Fprintf(&b, " (%+d bytes, ~%+d tokens)", e.BytesDelta, e.TokensDelta)
+		if e.Diff != "" {
+			b.WriteString(" " + e.Diff)
+		}
+		b.WriteString("\n")
+		if e.Depth == 0 {
+			bytes, toks = bytes+e.BytesDelta, toks+e.TokensDelta
+		}
+	}
+	fmt.Fprintf(&b, "total: %+d bytes, ~%+d tokens\n", bytes, toks)
+	return b.String()
+}
+
+// Recorder collects the traces of the requests of the invocations run with
+// a context carrying it, see [NewContext].
+type Recorder struct {
+	mu     sync.Mutex
+	traces []*Trace
+}
+
+// Traces returns the traces of the requests, in order.
+func (r *Recorder) Traces() []*Trace {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.traces)
+}
+
+type recorderKey struct{}
+
+// NewContext returns a context enabling tracing, with the traces collected
+// by r.
+func NewContext(ctx context.Context, r *Recorder) context.Context {
+	return context.WithValue(ctx, recorderKey{}, r)
+}
+
+// FromContext returns the recorder of ctx, or nil.
+func FromContext(ctx context.Context) *Recorder {
+	r, _ := ctx.Value(recorderKey{}).(*Recorder)
+	return r
+}
+
+var enabled atomic.Bool
+
+// Enable enables or disables tracing for all the requests.
+func Enable(on bool) {
+	enabled.Store(on)
+}
+
+var (
+	// attached is the number of traced requests, checked without lock to
+	// keep the lookups cheap when tracing is disabled.
+	attached atomic.Int64
+	tracesMu sync.Mutex
+	traces   = make(map[*model.LLMRequest]*Trace)
+)
+
+// Start starts tracing req if tracing is enabled for ctx, and returns its
+// trace and the function to call once req is sent, which detaches the
+// trace from req. It returns a nil Trace if tracing is disabled.
+func Start(ctx context.Context, req *model.LLMRequest) (*Trace, func()) {
+	rec := FromContext(ctx)
+	if rec == nil && !enabled.Load() {
+		return nil, noop
+	}
+	t := &Trace{}
+	if rec != nil {
+		rec.mu.Lock()
+		rec.traces = append(rec.traces, t)
+		rec.mu.Unlock()
+	}
+	tracesMu.Lock()
+	traces[req] = t
+	tracesMu.Unlock()
+	attached.Add(1)
+	return t, func() {
+		tracesMu.Lock()
+		delete(traces, req)
+		tracesMu.Unlock()
+		attached.Add(-1)
+	}
+}
+
+// ForRequest returns the trace of req, or nil if req is not traced.
+func ForRequest(req *model.LLMRequest) *Trace {
+	if attached.Load() == 0 {
+		return nil
+	}
+	tracesMu.Lock()
+	defer tracesMu.Unlock()
+	return traces[req]
+}
+
+// Step records a mutation of req in its trace, if any: see [Trace.Step].
+func Step(req *model.LLMRequest, component, operation, detail string) func() {
+	return ForRequest(req).Step(req, component, operation, detail)
+}
+
+// Record records e in the trace of req, if any.
+func Record(req *model.LLMRequest, e Entry) {
+	ForRequest(req).Record(e)
+}
+
+// FromResponse returns the trace set in the custom metadata of resp, or
+// nil.
+func FromResponse(resp *model.LLMResponse) *Trace {
+	if resp == nil {
+		return nil
+	}
+	t, _ := resp.CustomMetadata[MetadataKey].(*Trace)
+	return t
+}
+
+// snapshot is the measure of a request.
+type snapshot struct {
+	bytes       int
+	instruction string
+	tools       []string
+	contents    int
+}
+
+func snap(req *model.LLMRequest) snapshot {
+	var s snapshot
+	if b, err := json.Marshal(req.Contents); err == nil && req.Contents != nil {
+		s.bytes += len(b)
+	}
+	s.contents = len(req.Contents)
+	if req.Config == nil {
+		return s
+	}
+	if b, err := json.Marshal(req.Config); err == nil {
+		s.bytes += len(b)
+	}
+	if inst := req.Config.SystemInstruction; inst != nil {
+		var texts []string
+		for _, p := range inst.Parts {
+			if p != nil && p.Text != "" {
+				texts = append(texts, p.Text)
+			}
+		}
+		s.instruction = strings.Join(texts, "\n")
+	}
+	for _, t := range req.Config.Tools {
+		if t == nil {
+			continue
+		}
+		for _, d := range t.FunctionDeclarations {
+			if d != nil {
+				s.tools = append(s.tools, d.Name)
+			}
+		}
+	}
+	return s
+}
+
+func tokens(bytes int) int {
+	return (bytes + 3) / 4
+}
+
+// maxSnippet is the length above which the snippets of the diffs are
+// elided.
+const maxSnippet = 60
+
+// diff summarizes the changes between two snapshots.
+func diff(before, after snapshot) string {
+	var changes []string
+	if after.instruction != before.instruction {
+		if added, ok := strings.CutPrefix(after.instruction, before.instruction); ok {
+			changes = append(changes, "+instruction "+snippet(strings.TrimSpace(added)))
+		} else {
+			changes = append(changes, "~instruction")
+		}
+	}
+	for _, name := range after.tools {
+		if !slices.Contains(before.tools, name) {
+			changes = append(changes, "+tool "+name)
+		}
+	}
+	for _, name := range before.tools {
+		if !slices.Contains(after.tools, name) {
+			changes = append(changes, "-tool "+name)
+		}
+	}
+	if after.contents != before.contents {
+		changes = append(changes, fmt.Sprintf("contents %d -> %d", before.contents, after.contents))
+	}
+	return strings.Join(changes, "; ")
+}
+
+func snippet(s string) string {
+	if r := []rune(s); len(r) > maxSnippet {
+		s = string(r[:maxSnippet]) + "..."
+	}
+	return strconv.Quote(s)
+}
@@ -0,0 +1,171 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package assembly_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/artifact"
+	"google.golang.org/adk/internal/testutil"
+	"google.golang.org/adk/internal/testutil/golden"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/model/assembly"
+	"google.golang.org/adk/runner"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+	"google.golang.org/adk/tool/loadartifactstool"
+)
+
+func TestTrace_Report(t *testing.T) {
+	type lookupArgs struct {
+		Query string `json:"query"`
+	}
+	lookup, err := functiontool.New(functiontool.Config{Name: "lookup", Description: "looks things up"}, func(tool.Context, lookupArgs) (map[string]any, error) {
+		return map[string]any{}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	llm := &testutil.MockModel{Responses: []*genai.Content{genai.NewContentFromText("done", genai.RoleModel)}}
+	a, err := llmagent.New(llmagent.Config{
+		Name:        "agent",
+		Model:       llm,
+		Instruction: "You answer questions about the artifacts.",
+		Tools:       []tool.Tool{lookup, loadartifactstool.New()},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessions, artifacts := session.InMemoryService(), artifact.InMemoryService()
+	if _, err := sessions.Create(t.Context(), &session.CreateRequest{AppName: "app", UserID: "user", SessionID: "session"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := artifacts.Save(t.Context(), &artifact.SaveRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "notes.txt", Part: genai.NewPartFromText("notes")}); err != nil {
+		t.Fatal(err)
+	}
+	r, err := runner.New(runner.Config{AppName: "app", Agent: a, SessionService: sessions, ArtifactService: artifacts})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := &assembly.Recorder{}
+	ctx := assembly.NewContext(t.Context(), rec)
+	var events []*session.Event
+	for ev, err := range r.Run(ctx, "user", "session", genai.NewContentFromText("what is in my notes?", genai.RoleUser), agent.RunConfig{}) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		events = append(events, ev)
+	}
+
+	traces := rec.Traces()
+	if len(traces) != 1 {
+		t.Fatalf("got %d traces, want 1", len(traces))
+	}
+	golden.Text(t, traces[0].Report())
+	if got := assembly.FromResponse(&events[len(events)-1].LLMResponse); got != traces[0] {
+		t.Errorf("FromResponse() = %p, want the trace of the request %p", got, traces[0])
+	}
+
+	b, err := json.Marshal(traces[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded struct {
+		Entries []assembly.Entry `json:"entries"`
+	}
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(traces[0].Entries(), decoded.Entries); diff != "" {
+		t.Errorf("JSON entries mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestTrace_Disabled(t *testing.T) {
+	llm := &testutil.MockModel{Responses: []*genai.Content{genai.NewContentFromText("done", genai.RoleModel)}}
+	a, err := llmagent.New(llmagent.Config{Name: "agent", Model: llm, Instruction: "Be brief."})
+	if err != nil {
+		t.Fatal(err)
+	}
+	events, err := testutil.CollectEvents(testutil.NewTestAgentRunner(t, a).Run(t, "session", "hi"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, ev := range events {
+		if tr := assembly.FromResponse(&ev.LLMResponse); tr != nil {
+			t.Errorf("event has a trace without tracing: %s", tr.Report())
+		}
+	}
+}
+
+func TestTrace_Step(t *testing.T) {
+	req := &model.LLMRequest{}
+	tr, end := assembly.Start(assembly.NewContext(t.Context(), &assembly.Recorder{}), req)
+	if got := assembly.ForRequest(req); got != tr {
+		t.Fatalf("ForRequest() = %p, want %p", got, tr)
+	}
+
+	outer := assembly.Step(req, "custom", "rewrite", "")
+	req.Contents = append(req.Contents, genai.NewContentFromText("hello", genai.RoleUser))
+	inner := assembly.Step(req, "custom", "declare", "f")
+	req.Config = &genai.GenerateContentConfig{Tools: []*genai.Tool{{FunctionDeclarations: []*genai.FunctionDeclaration{{Name: "f"}}}}}
+	inner()
+	assembly.Record(req, assembly.Entry{Component: "custom", Operation: "note"})
+	outer()
+	end()
+
+	if got := assembly.ForRequest(req); got != nil {
+		t.Errorf("ForRequest() after end = %p, want nil", got)
+	}
+	// Nothing is recorded once the request is sent.
+	assembly.Record(req, assembly.Entry{Component: "custom", Operation: "late"})
+
+	contentsBytes := len(`[{"parts":[{"text":"hello"}],"role":"user"}]`)
+	configBytes := len(`{"tools":[{"functionDeclarations":[{"name":"f"}]}]}`)
+	want := []assembly.Entry{
+		{Component: "custom", Operation: "rewrite", BytesDelta: contentsBytes + configBytes, TokensDelta: 24, Diff: "+tool f; contents 0 -> 1"},
+		{Component: "custom", Operation: "declare", Detail: "f", Depth: 1, BytesDelta: configBytes, TokensDelta: 13, Diff: "+tool f"},
+		{Component: "custom", Operation: "note", Depth: 1},
+	}
+	if diff := cmp.Diff(want, tr.Entries()); diff != "" {
+		t.Errorf("entries mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestStep_DisabledAllocs(t *testing.T) {
+	req := &model.LLMRequest{}
+	allocs := testing.AllocsPerRun(100, func() {
+		assembly.Step(req, "component", "operation", "detail")()
+		assembly.Record(req, assembly.Entry{Component: "component"})
+	})
+	if allocs != 0 {
+		t.Errorf("disabled tracing allocates %v times per step, want 0", allocs)
+	}
+}
+
+func BenchmarkStep_Disabled(b *testing.B) {
+	req := &model.LLMRequest{}
+	for b.Loop() {
+		assembly.Step(req, "component", "operation", "detail")()
+	}
+}
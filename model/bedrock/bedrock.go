@@ -0,0 +1,416 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package bedrock implements the [model.LLM] interface for the models
+// hosted by Amazon Bedrock, e.g. Claude or Llama, with the Converse API of
+// the Bedrock Runtime.
+//
+// The requests are signed with AWS Signature Version 4 by the AWS SDK, with
+// the credentials of its default configuration or provided with
+// [WithCredentials].
+package bedrock
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"strings"
+
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/internal/llmconv"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/strictness"
+)
+
+// CacheWriteTokensMetadataKey is the key of the custom metadata holding
+// the number of input tokens written to the prompt cache, which are counted
+// in the prompt tokens of the usage.
+const CacheWriteTokensMetadataKey = "bedrock_cache_write_input_tokens"
+
+type bedrockModel struct {
+	id     string
+	client *Client
+}
+
+// NewModel returns the model modelID of the region, e.g.
+// "anthropic.claude-3-5-sonnet-20240620-v1:0", or the ID or ARN of an
+// inference profile. The client of the model is created with opts.
+//
+// The client sends the requests with httpx.DefaultClient, which propagates
+// the metadata of the invocations in their headers, unless opts set another
+// client with [WithHTTPClient].
+func NewModel(ctx context.Context, region, modelID string, opts ...Option) (model.LLM, error) {
+	client, err := NewClient(ctx, region, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return NewModelWithClient(modelID, client), nil
+}
+
+// NewModelWithClient returns the model modelID called with client, so that
+// a client can be shared by several models.
+func NewModelWithClient(modelID string, client *Client) model.LLM {
+	return &bedrockModel{id: modelID, client: client}
+}
+
+func (m *bedrockModel) Name() string {
+	return m.id
+}
+
+func (m *bedrockModel) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	llmconv.MaybeAppendUserContent(req)
+	body, err := newRequest(ctx, req, m.id)
+	if err != nil {
+		return func(yield func(*model.LLMResponse, error) bool) {
+			yield(nil, err)
+		}
+	}
+	if stream {
+		return m.generateStream(ctx, body)
+	}
+	return func(yield func(*model.LLMResponse, error) bool) {
+		resp, err := m.client.converse(ctx, m.id, body)
+		if err != nil {
+			yield(nil, fmt.Errorf("failed to generate content: %w", classify(err)))
+			return
+		}
+		yield(convertResponse(resp), nil)
+	}
+}
+
+// generateStream yields the text of the stream as partial responses,
+// followed by the complete response of the turn: its whole text and its
+// function calls, with the finish reason and the usage, which Bedrock
+// sends after the end of the message.
+func (m *bedrockModel) generateStream(ctx context.Context, body *converseRequest) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {
+		var acc streamAccumulator
+		for event, err := range m.client.converseStream(ctx, m.id, body) {
+			if err != nil {
+				yield(nil, fmt.Errorf("failed to generate stream content: %w", classify(err)))
+				return
+			}
+			if resp := acc.add(event); resp != nil && !yield(resp, nil) {
+				return
+			}
+		}
+		resp := acc.response()
+		if resp == nil {
+			yield(nil, fmt.Errorf("failed to generate stream content: the stream ended before the end of the message"))
+			return
+		}
+		yield(convertResponse(resp), nil)
+	}
+}
+
+// newRequest converts req to a request of the Converse API. The parts and
+// the parameters that cannot be sent to Bedrock are dropped, which is
+// reported with the strictness policy of ctx.
+func newRequest(ctx context.Context, req *model.LLMRequest, modelID string) (*converseRequest, error) {
+	body := &converseRequest{}
+	messages, err := convertContents(ctx, req.Contents)
+	if err != nil {
+		return nil, err
+	}
+	body.Messages = messages
+
+	cfg := req.Config
+	if cfg == nil {
+		return body, nil
+	}
+	if cfg.SystemInstruction != nil {
+		for _, part := range cfg.SystemInstruction.Parts {
+			if part != nil && part.Text != "" && !part.Thought {
+				body.System = append(body.System, systemBlock{Text: part.Text})
+			}
+		}
+	}
+	inference := inferenceConfig{
+		MaxTokens:     cfg.MaxOutputTokens,
+		Temperature:   cfg.Temperature,
+		TopP:          cfg.TopP,
+		StopSequences: cfg.StopSequences,
+	}
+	if inference.MaxTokens > 0 || inference.Temperature != nil || inference.TopP != nil || len(inference.StopSequences) > 0 {
+		body.InferenceConfig = &inference
+	}
+	if cfg.TopK != nil {
+		// The Converse API has no top k: it is a field of the requests of
+		// the Claude models.
+		if isClaude(modelID) {
+			body.AdditionalModelRequestFields = map[string]any{"top_k": int(*cfg.TopK)}
+		} else if err := ignore(ctx, "top_k"); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.CandidateCount > 1 {
+		if err := ignore(ctx, "candidate_count"); err != nil {
+			return nil, err
+		}
+	}
+	tools, err := convertTools(ctx, cfg.Tools)
+	if err != nil {
+		return nil, err
+	}
+	if len(tools) > 0 {
+		body.ToolConfig = &toolConfig{Tools: tools}
+		if cfg.ToolConfig != nil {
+			if body.ToolConfig.ToolChoice, err = convertToolConfig(ctx, cfg.ToolConfig); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return body, nil
+}
+
+// isClaude reports whether modelID is a Claude model, or an inference
+// profile of one, e.g. "us.anthropic.claude-sonnet-4-20250514-v1:0".
+func isClaude(modelID string) bool {
+	return strings.Contains(modelID, "anthropic.claude")
+}
+
+// convertContents converts the contents to messages: the contents of the
+// model are sent as assistant messages, the others as user messages. The
+// consecutive contents of the same role are merged into one message, the
+// roles of the messages having to alternate. The function calls and
+// responses without an ID are paired with synthesized IDs, which Bedrock
+// requires.
+func convertContents(ctx context.Context, contents []*genai.Content) ([]message, error) {
+	var messages []message
+	ids := llmconv.CallIDs{Prefix: "tooluse_"}
+	for _, content := range contents {
+		if content == nil {
+			continue
+		}
+		role := "user"
+		if content.Role == genai.RoleModel {
+			role = "assistant"
+		}
+		var blocks []contentBlock
+		for _, part := range content.Parts {
+			if part == nil || part.Thought {
+				continue
+			}
+			switch {
+			case part.Text != "":
+				blocks = append(blocks, contentBlock{Text: part.Text})
+			case part.FunctionCall != nil:
+				call := ids.Call(part.FunctionCall)
+				input, err := json.Marshal(call.Args)
+				if err != nil {
+					return nil, fmt.Errorf("failed to encode the arguments of the call to %q: %w", call.Name, err)
+				}
+				if call.Args == nil {
+					input = []byte("{}")
+				}
+				blocks = append(blocks, contentBlock{ToolUse: &toolUseBlock{ToolUseID: call.ID, Name: call.Name, Input: input}})
+			case part.FunctionResponse != nil:
+				resp := ids.Response(part.FunctionResponse)
+				result := &toolResultBlock{ToolUseID: resp.ID, Content: []toolResultContent{{JSON: resp.Response}}}
+				if resp.Response == nil {
+					result.Content[0].JSON = map[string]any{}
+				}
+				if _, failed := resp.Response["error"]; failed {
+					result.Status = "error"
+				}
+				blocks = append(blocks, contentBlock{ToolResult: result})
+			case part.InlineData != nil && imageFormat(part.InlineData.MIMEType) != "":
+				blocks = append(blocks, contentBlock{Image: &imageBlock{
+					Format: imageFormat(part.InlineData.MIMEType),
+					Source: imageSource{Bytes: part.InlineData.Data},
+				}})
+			case part.InlineData != nil:
+				if err := drop(ctx, "inline data", part.InlineData.MIMEType); err != nil {
+					return nil, err
+				}
+			case part.FileData != nil:
+				if err := drop(ctx, "file data", part.FileData.FileURI); err != nil {
+					return nil, err
+				}
+			}
+		}
+		// The API rejects the messages without content.
+		if len(blocks) == 0 {
+			continue
+		}
+		if n := len(messages); n > 0 && messages[n-1].Role == role {
+			messages[n-1].Content = append(messages[n-1].Content, blocks...)
+			continue
+		}
+		messages = append(messages, message{Role: role, Content: blocks})
+	}
+	return messages, nil
+}
+
+// imageFormat returns the format of the images of type mimeType accepted
+// by the API, empty if they are not.
+func imageFormat(mimeType string) string {
+	switch mimeType {
+	case "image/png":
+		return "png"
+	case "image/jpeg":
+		return "jpeg"
+	case "image/gif":
+		return "gif"
+	case "image/webp":
+		return "webp"
+	default:
+		return ""
+	}
+}
+
+// convertTools converts the function declarations of tools. The other
+// tools, e.g. Google Search, are dropped.
+func convertTools(ctx context.Context, tools []*genai.Tool) ([]tool, error) {
+	var specs []tool
+	for _, t := range tools {
+		if t == nil {
+			continue
+		}
+		for _, decl := range t.FunctionDeclarations {
+			schema, err := parametersSchema(decl)
+			if err != nil {
+				return nil, fmt.Errorf("failed to convert the parameters of %q: %w", decl.Name, err)
+			}
+			specs = append(specs, tool{ToolSpec: toolSpec{
+				Name:        decl.Name,
+				Description: decl.Description,
+				InputSchema: inputSchema{JSON: schema},
+			}})
+		}
+		if len(t.FunctionDeclarations) == 0 {
+			if err := drop(ctx, "tool", "other than functions"); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return specs, nil
+}
+
+// parametersSchema returns the JSON schema of the parameters of decl. The
+// functions without parameters take an empty object.
+func parametersSchema(decl *genai.FunctionDeclaration) (any, error) {
+	schema, err := llmconv.ParametersSchema(decl)
+	if err != nil || schema != nil {
+		return schema, err
+	}
+	return map[string]any{"type": "object", "properties": map[string]any{}}, nil
+}
+
+// convertToolConfig converts the function calling mode of cfg to a tool
+// choice: ANY forces a tool call, of the only allowed function if there is
+// one. The Converse API cannot forbid the tool calls: NONE is ignored.
+func convertToolConfig(ctx context.Context, cfg *genai.ToolConfig) (*toolChoice, error) {
+	if cfg.FunctionCallingConfig == nil {
+		return nil, nil
+	}
+	switch cfg.FunctionCallingConfig.Mode {
+	case genai.FunctionCallingConfigModeAuto:
+		return &toolChoice{Auto: &struct{}{}}, nil
+	case genai.FunctionCallingConfigModeAny:
+		if names := cfg.FunctionCallingConfig.AllowedFunctionNames; len(names) == 1 {
+			return &toolChoice{Tool: &toolChoiceTool{Name: names[0]}}, nil
+		}
+		return &toolChoice{Any: &struct{}{}}, nil
+	case genai.FunctionCallingConfigModeNone:
+		return nil, ignore(ctx, "function calling mode NONE")
+	default:
+		return nil, nil
+	}
+}
+
+// drop reports that the adapter dropped a part or a tool which cannot be
+// sent to Bedrock, with the strictness policy of ctx.
+func drop(ctx context.Context, kind, detail string) error {
+	return strictness.Report(ctx, strictness.Conversion, "bedrock", "dropped %s %s: not supported by Bedrock", kind, detail)
+}
+
+// ignore reports that the adapter ignored a parameter of the config which
+// Bedrock does not support, with the strictness policy of ctx.
+func ignore(ctx context.Context, what string) error {
+	return strictness.Report(ctx, strictness.Conversion, "bedrock", "ignored %s: not supported by Bedrock", what)
+}
+
+// convertResponse converts a complete response.
+func convertResponse(out *converseResponse) *model.LLMResponse {
+	content := &genai.Content{Role: genai.RoleModel}
+	resp := &model.LLMResponse{
+		Content:       content,
+		UsageMetadata: convertUsage(out.Usage),
+		FinishReason:  finishReason(out.StopReason),
+		TurnComplete:  true,
+	}
+	if msg := out.Output.Message; msg != nil {
+		for _, block := range msg.Content {
+			switch {
+			case block.Text != "":
+				content.Parts = append(content.Parts, &genai.Part{Text: block.Text})
+			case block.ToolUse != nil:
+				var args map[string]any
+				if len(block.ToolUse.Input) > 0 {
+					if err := json.Unmarshal(block.ToolUse.Input, &args); err != nil {
+						resp.ErrorCode = string(model.ErrorCodeUnknown)
+						resp.ErrorMessage = fmt.Sprintf("invalid arguments of the call to %q: %v", block.ToolUse.Name, err)
+						continue
+					}
+				}
+				content.Parts = append(content.Parts, &genai.Part{FunctionCall: &genai.FunctionCall{ID: block.ToolUse.ToolUseID, Name: block.ToolUse.Name, Args: args}})
+			}
+		}
+	}
+	if resp.FinishReason == genai.FinishReasonSafety {
+		resp.ErrorCode = string(model.ErrorCodeSafetyBlocked)
+		resp.ErrorMessage = "The response was blocked by a guardrail or a content filter."
+	}
+	if out.RequestID != "" {
+		resp.CustomMetadata = map[string]any{model.ResponseIDMetadataKey: out.RequestID}
+	}
+	if n := out.Usage.CacheWriteInputTokens; n > 0 {
+		if resp.CustomMetadata == nil {
+			resp.CustomMetadata = map[string]any{}
+		}
+		resp.CustomMetadata[CacheWriteTokensMetadataKey] = n
+	}
+	return resp
+}
+
+// convertUsage converts the usage of a response. Bedrock does not count
+// the tokens read from or written to the cache in the input tokens: they
+// are added to the prompt tokens.
+func convertUsage(u usage) *genai.GenerateContentResponseUsageMetadata {
+	prompt := u.InputTokens + u.CacheReadInputTokens + u.CacheWriteInputTokens
+	return &genai.GenerateContentResponseUsageMetadata{
+		PromptTokenCount:        int32(prompt),
+		CachedContentTokenCount: int32(u.CacheReadInputTokens),
+		CandidatesTokenCount:    int32(u.OutputTokens),
+		TotalTokenCount:         int32(prompt + u.OutputTokens),
+	}
+}
+
+func finishReason(reason string) genai.FinishReason {
+	switch reason {
+	case "end_turn", "stop_sequence", "tool_use":
+		return genai.FinishReasonStop
+	case "max_tokens", "model_context_window_exceeded":
+		return genai.FinishReasonMaxTokens
+	case "guardrail_intervened", "content_filtered":
+		return genai.FinishReasonSafety
+	case "":
+		return genai.FinishReasonUnspecified
+	default:
+		return genai.FinishReasonOther
+	}
+}
@@ -0,0 +1,399 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bedrock_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/protocol/eventstream"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/internal/httprr"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/model/bedrock"
+	"google.golang.org/adk/model/modeltest"
+)
+
+//go:generate go test -run TestModel_ToolCallExchange -httprecord=testdata/.*\.httprr
+
+const modelID = "anthropic.claude-3-5-sonnet-20240620-v1:0"
+
+// newServer returns a server answering the signed requests of action with
+// handle, after decoding their body into got.
+func newServer(t *testing.T, action string, got *map[string]any, handle func(w http.ResponseWriter)) *httptest.Server {
+	t.Helper()
+	return modeltest.NewServer(t, "/model/anthropic.claude-3-5-sonnet-20240620-v1%3A0/"+action, got, func(w http.ResponseWriter, r *http.Request) {
+		if auth := r.Header.Get("Authorization"); !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKID/") || !strings.Contains(auth, "/us-east-1/bedrock/aws4_request") {
+			t.Errorf("Authorization = %q, want a signature of AKID for bedrock in us-east-1", auth)
+		}
+		if token := r.Header.Get("X-Amz-Security-Token"); token != "token" {
+			t.Errorf("X-Amz-Security-Token = %q, want token", token)
+		}
+		w.Header().Set("X-Amzn-Requestid", "req-1")
+		handle(w)
+	})
+}
+
+func newModel(t *testing.T, srv *httptest.Server) model.LLM {
+	t.Helper()
+	llm, err := bedrock.NewModel(t.Context(), "us-east-1", modelID,
+		bedrock.WithEndpoint(srv.URL),
+		bedrock.WithCredentials(credentials.NewStaticCredentialsProvider("AKID", "secret", "token")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return llm
+}
+
+func TestModel_Request(t *testing.T) {
+	var got map[string]any
+	srv := newServer(t, "converse", &got, func(w http.ResponseWriter) {
+		fmt.Fprint(w, `{"output":{"message":{"role":"assistant","content":[]}},"stopReason":"end_turn","usage":{"inputTokens":1,"outputTokens":1,"totalTokens":2}}`)
+	})
+	temperature := float32(0.5)
+	topK := float32(40)
+	req := &model.LLMRequest{
+		Contents: []*genai.Content{
+			{Role: genai.RoleUser, Parts: []*genai.Part{
+				genai.NewPartFromText("what is this?"),
+				genai.NewPartFromBytes([]byte("png"), "image/png"),
+			}},
+			genai.NewContentFromFunctionCall("lookup", map[string]any{"q": "cat"}, genai.RoleModel),
+			genai.NewContentFromFunctionResponse("lookup", map[string]any{"result": "a cat"}, genai.RoleUser),
+			genai.NewContentFromText("and now?", genai.RoleUser),
+		},
+		Config: &genai.GenerateContentConfig{
+			SystemInstruction: genai.NewContentFromText("Be brief.", genai.RoleUser),
+			MaxOutputTokens:   100,
+			Temperature:       &temperature,
+			TopK:              &topK,
+			StopSequences:     []string{"END"},
+			Tools: []*genai.Tool{{FunctionDeclarations: []*genai.FunctionDeclaration{{
+				Name:        "lookup",
+				Description: "looks things up",
+				Parameters: &genai.Schema{
+					Type:       genai.TypeObject,
+					Properties: map[string]*genai.Schema{"q": {Type: genai.TypeString}},
+					Required:   []string{"q"},
+				},
+			}}}},
+			ToolConfig: &genai.ToolConfig{FunctionCallingConfig: &genai.FunctionCallingConfig{Mode: genai.FunctionCallingConfigModeAny}},
+		},
+	}
+	if _, err := model.Collect(newModel(t, srv).GenerateContent(t.Context(), req, false)); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]any{
+		"system": []any{map[string]any{"text": "Be brief."}},
+		"inferenceConfig": map[string]any{
+			"maxTokens":     float64(100),
+			"temperature":   0.5,
+			"stopSequences": []any{"END"},
+		},
+		"additionalModelRequestFields": map[string]any{"top_k": float64(40)},
+		"messages": []any{
+			map[string]any{"role": "user", "content": []any{
+				map[string]any{"text": "what is this?"},
+				map[string]any{"image": map[string]any{"format": "png", "source": map[string]any{"bytes": "cG5n"}}},
+			}},
+			map[string]any{"role": "assistant", "content": []any{
+				map[string]any{"toolUse": map[string]any{"toolUseId": "tooluse_0", "name": "lookup", "input": map[string]any{"q": "cat"}}},
+			}},
+			// The function response and the text are merged in a message.
+			map[string]any{"role": "user", "content": []any{
+				map[string]any{"toolResult": map[string]any{"toolUseId": "tooluse_0", "content": []any{map[string]any{"json": map[string]any{"result": "a cat"}}}}},
+				map[string]any{"text": "and now?"},
+			}},
+		},
+		"toolConfig": map[string]any{
+			"tools": []any{map[string]any{"toolSpec": map[string]any{
+				"name":        "lookup",
+				"description": "looks things up",
+				"inputSchema": map[string]any{"json": map[string]any{
+					"type":       "object",
+					"properties": map[string]any{"q": map[string]any{"type": "string"}},
+					"required":   []any{"q"},
+				}},
+			}}},
+			"toolChoice": map[string]any{"any": map[string]any{}},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("request mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestModel_Response(t *testing.T) {
+	var got map[string]any
+	srv := newServer(t, "converse", &got, func(w http.ResponseWriter) {
+		fmt.Fprint(w, `{
+			"output": {"message": {"role": "assistant", "content": [
+				{"text": "Let me look."},
+				{"toolUse": {"toolUseId": "tooluse_1", "name": "lookup", "input": {"q": "cat"}}}
+			]}},
+			"stopReason": "tool_use",
+			"usage": {"inputTokens": 10, "outputTokens": 5, "totalTokens": 65, "cacheReadInputTokens": 30, "cacheWriteInputTokens": 20},
+			"metrics": {"latencyMs": 500}
+		}`)
+	})
+	resp, err := model.Collect(newModel(t, srv).GenerateContent(t.Context(), &model.LLMRequest{Contents: []*genai.Content{genai.NewContentFromText("hi", genai.RoleUser)}}, false))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &model.LLMResponse{
+		Content: &genai.Content{Role: genai.RoleModel, Parts: []*genai.Part{
+			{Text: "Let me look."},
+			{FunctionCall: &genai.FunctionCall{ID: "tooluse_1", Name: "lookup", Args: map[string]any{"q": "cat"}}},
+		}},
+		UsageMetadata: &genai.GenerateContentResponseUsageMetadata{
+			PromptTokenCount:        60,
+			CachedContentTokenCount: 30,
+			CandidatesTokenCount:    5,
+			TotalTokenCount:         65,
+		},
+		CustomMetadata: map[string]any{
+			model.ResponseIDMetadataKey:         "req-1",
+			bedrock.CacheWriteTokensMetadataKey: int64(20),
+		},
+		TurnComplete: true,
+		FinishReason: genai.FinishReasonStop,
+	}
+	if diff := cmp.Diff(want, resp); diff != "" {
+		t.Errorf("response mismatch (-want +got):\n%s", diff)
+	}
+}
+
+// encodeEvent encodes a message of an AWS event stream with the given
+// string headers, as name-value pairs, and payload.
+func encodeEvent(payload string, headers ...string) []byte {
+	msg := eventstream.Message{Payload: []byte(payload)}
+	for i := 0; i+1 < len(headers); i += 2 {
+		msg.Headers.Set(headers[i], eventstream.StringValue(headers[i+1]))
+	}
+	var b bytes.Buffer
+	if err := eventstream.NewEncoder().Encode(&b, msg); err != nil {
+		panic(err)
+	}
+	return b.Bytes()
+}
+
+func event(typ, payload string) []byte {
+	return encodeEvent(payload, ":message-type", "event", ":event-type", typ, ":content-type", "application/json")
+}
+
+func TestModel_Stream(t *testing.T) {
+	var got map[string]any
+	srv := newServer(t, "converse-stream", &got, func(w http.ResponseWriter) {
+		w.Header().Set("Content-Type", "application/vnd.amazon.eventstream")
+		for _, e := range [][]byte{
+			event("messageStart", `{"role":"assistant"}`),
+			event("contentBlockDelta", `{"contentBlockIndex":0,"delta":{"text":"Let me "}}`),
+			event("contentBlockDelta", `{"contentBlockIndex":0,"delta":{"text":"look."}}`),
+			event("contentBlockStop", `{"contentBlockIndex":0}`),
+			event("contentBlockStart", `{"contentBlockIndex":1,"start":{"toolUse":{"toolUseId":"tooluse_1","name":"lookup"}}}`),
+			event("contentBlockDelta", `{"contentBlockIndex":1,"delta":{"toolUse":{"input":"{\"q\": "}}}`),
+			event("contentBlockDelta", `{"contentBlockIndex":1,"delta":{"toolUse":{"input":"\"cat\"}"}}}`),
+			event("contentBlockStop", `{"contentBlockIndex":1}`),
+			event("messageStop", `{"stopReason":"tool_use"}`),
+			event("metadata", `{"usage":{"inputTokens":10,"outputTokens":15,"totalTokens":25},"metrics":{"latencyMs":500}}`),
+		} {
+			w.Write(e)
+		}
+	})
+	var partials []*model.LLMResponse
+	resp, err := model.TeeCollect(newModel(t, srv).GenerateContent(t.Context(), &model.LLMRequest{Contents: []*genai.Content{genai.NewContentFromText("hi", genai.RoleUser)}}, true), func(partial *model.LLMResponse) error {
+		partials = append(partials, partial)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantPartials := []*model.LLMResponse{
+		{Content: genai.NewContentFromText("Let me ", genai.RoleModel), Partial: true},
+		{Content: genai.NewContentFromText("look.", genai.RoleModel), Partial: true},
+	}
+	if diff := cmp.Diff(wantPartials, partials); diff != "" {
+		t.Errorf("partial responses mismatch (-want +got):\n%s", diff)
+	}
+	want := &model.LLMResponse{
+		Content: &genai.Content{Role: genai.RoleModel, Parts: []*genai.Part{
+			{Text: "Let me look."},
+			{FunctionCall: &genai.FunctionCall{ID: "tooluse_1", Name: "lookup", Args: map[string]any{"q": "cat"}}},
+		}},
+		UsageMetadata: &genai.GenerateContentResponseUsageMetadata{
+			PromptTokenCount:     10,
+			CandidatesTokenCount: 15,
+			TotalTokenCount:      25,
+		},
+		CustomMetadata: map[string]any{model.ResponseIDMetadataKey: "req-1"},
+		TurnComplete:   true,
+		FinishReason:   genai.FinishReasonStop,
+	}
+	if diff := cmp.Diff(want, resp); diff != "" {
+		t.Errorf("response mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestModel_Errors(t *testing.T) {
+	exception := encodeEvent(`{"message":"Too many requests"}`, ":message-type", "exception", ":exception-type", "throttlingException")
+	tests := []struct {
+		name      string
+		stream    bool
+		status    int
+		errorType string
+		body      string
+		want      model.ErrorCode
+	}{
+		{"Throttled", false, http.StatusTooManyRequests, "ThrottlingException", `{"message":"Too many requests, please wait before trying again."}`, model.ErrorCodeRateLimited},
+		{"InputTooLong", false, http.StatusBadRequest, "ValidationException", `{"message":"Input is too long for requested model."}`, model.ErrorCodeContextTooLong},
+		{"AccessDenied", false, http.StatusForbidden, "AccessDeniedException", `{"message":"You don't have access to the model with the specified model ID."}`, model.ErrorCodeAuthFailed},
+		{"Unavailable", false, http.StatusServiceUnavailable, "ServiceUnavailableException", `{"message":"Service unavailable"}`, model.ErrorCodeUnavailable},
+		{"StreamException", true, http.StatusOK, "", string(exception), model.ErrorCodeRateLimited},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			action := "converse"
+			if tc.stream {
+				action = "converse-stream"
+			}
+			var got map[string]any
+			srv := newServer(t, action, &got, func(w http.ResponseWriter) {
+				if tc.errorType != "" {
+					w.Header().Set("X-Amzn-Errortype", tc.errorType+":http://internal.amazon.com/coral/com.amazon.bedrock/")
+				}
+				w.WriteHeader(tc.status)
+				fmt.Fprint(w, tc.body)
+			})
+			var err error
+			for _, err = range newModel(t, srv).GenerateContent(t.Context(), &model.LLMRequest{}, tc.stream) {
+			}
+			if code := model.CodeOf(err); code != tc.want {
+				t.Errorf("CodeOf(%v) = %s, want %s", err, code, tc.want)
+			}
+		})
+	}
+
+	t.Run("NoCredentials", func(t *testing.T) {
+		llm, err := bedrock.NewModel(t.Context(), "us-east-1", modelID,
+			bedrock.WithEndpoint("http://localhost:0"),
+			bedrock.WithCredentials(aws.CredentialsProviderFunc(func(context.Context) (aws.Credentials, error) {
+				return aws.Credentials{}, errors.New("no credentials")
+			})))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, err = range llm.GenerateContent(t.Context(), &model.LLMRequest{}, false) {
+		}
+		if code := model.CodeOf(err); code != model.ErrorCodeAuthFailed {
+			t.Errorf("CodeOf(%v) = %s, want %s", err, code, model.ErrorCodeAuthFailed)
+		}
+	})
+}
+
+// TestModel_ToolCallExchange replays a recorded exchange in which the
+// model calls a function, and answers with its response. The exchange is
+// recorded with the AWS credentials of the environment by go generate.
+func TestModel_ToolCallExchange(t *testing.T) {
+	file := filepath.Join("testdata", t.Name()+".httprr")
+	recording, err := httprr.Recording(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(file); !recording && errors.Is(err, os.ErrNotExist) {
+		t.Skipf("no recording in %s, run go generate with AWS credentials to record it", file)
+	}
+	rr, err := httprr.Open(file, http.DefaultTransport)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { rr.Close() })
+	// The signatures change with the time of the requests.
+	rr.ScrubReq(func(req *http.Request) error {
+		for _, h := range []string{"Authorization", "X-Amz-Date", "X-Amz-Security-Token"} {
+			req.Header.Del(h)
+		}
+		return nil
+	})
+	creds := credentials.NewStaticCredentialsProvider("AKID", "secret", "")
+	if rr.Recording() {
+		creds = credentials.NewStaticCredentialsProvider(os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY"), os.Getenv("AWS_SESSION_TOKEN"))
+	}
+	llm, err := bedrock.NewModel(t.Context(), "us-east-1", modelID, bedrock.WithHTTPClient(rr.Client()), bedrock.WithCredentials(creds))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &genai.GenerateContentConfig{
+		MaxOutputTokens: 256,
+		Tools: []*genai.Tool{{FunctionDeclarations: []*genai.FunctionDeclaration{{
+			Name:        "get_weather",
+			Description: "Returns the current weather of a city.",
+			Parameters: &genai.Schema{
+				Type:       genai.TypeObject,
+				Properties: map[string]*genai.Schema{"city": {Type: genai.TypeString}},
+				Required:   []string{"city"},
+			},
+		}}}},
+	}
+	contents := []*genai.Content{genai.NewContentFromText("What is the weather in Paris?", genai.RoleUser)}
+
+	resp, err := model.Collect(llm.GenerateContent(t.Context(), &model.LLMRequest{Contents: contents, Config: cfg}, false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Content == nil {
+		t.Fatalf("response = %+v, want a function call", resp)
+	}
+	last := resp.Content.Parts[len(resp.Content.Parts)-1]
+	call := last.FunctionCall
+	if call == nil || call.ID == "" {
+		t.Fatalf("last part = %+v, want a function call with an ID", last)
+	}
+	if diff := cmp.Diff(&genai.FunctionCall{ID: call.ID, Name: "get_weather", Args: map[string]any{"city": "Paris"}}, call); diff != "" {
+		t.Fatalf("function call mismatch (-want +got):\n%s", diff)
+	}
+
+	contents = append(contents, resp.Content, &genai.Content{Role: genai.RoleUser, Parts: []*genai.Part{{
+		FunctionResponse: &genai.FunctionResponse{ID: call.ID, Name: call.Name, Response: map[string]any{"temperature": "18°C", "conditions": "sunny"}},
+	}}})
+	resp, err = model.Collect(llm.GenerateContent(t.Context(), &model.LLMRequest{Contents: contents, Config: cfg}, false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Content == nil || !strings.Contains(resp.Content.Parts[0].Text, "18") {
+		t.Errorf("Content = %+v, want the text of the answer with the temperature", resp.Content)
+	}
+	if resp.FinishReason != genai.FinishReasonStop || !resp.TurnComplete {
+		t.Errorf("FinishReason, TurnComplete = %v, %v, want %v, true", resp.FinishReason, resp.TurnComplete, genai.FinishReasonStop)
+	}
+	if resp.CustomMetadata[model.ResponseIDMetadataKey] == "" {
+		t.Error("the response has no request ID")
+	}
+}
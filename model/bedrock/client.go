@@ -0,0 +1,331 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bedrock
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/protocol/eventstream"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-sdk-go-v2/config"
+
+	"google.golang.org/adk/httpx"
+	"google.golang.org/adk/runtimedeps"
+)
+
+// signingService is the name of the service in the signatures of the
+// requests.
+const signingService = "bedrock"
+
+// Client sends the requests of the models to the Bedrock Runtime API of a
+// region.
+type Client struct {
+	region      string
+	endpoint    string
+	credentials aws.CredentialsProvider
+	signer      *v4.Signer
+	httpClient  *http.Client
+}
+
+// Option configures a [Client].
+type Option func(*Client)
+
+// WithCredentials sets the provider of the credentials signing the
+// requests, e.g. credentials.NewStaticCredentialsProvider of the AWS SDK.
+// The provider is called for each request: the providers fetching their
+// credentials should be wrapped in an aws.CredentialsCache. Defaults to the
+// credentials of the default configuration of the AWS SDK.
+func WithCredentials(provider aws.CredentialsProvider) Option {
+	return func(c *Client) { c.credentials = provider }
+}
+
+// WithEndpoint sets the URL the client sends the requests to, e.g. for a
+// VPC endpoint or a test server. Defaults to the
+// AWS_ENDPOINT_URL_BEDROCK_RUNTIME environment variable, or else to the
+// endpoint of the region.
+func WithEndpoint(url string) Option {
+	return func(c *Client) { c.endpoint = strings.TrimSuffix(url, "/") }
+}
+
+// WithHTTPClient sets the HTTP client sending the requests. Defaults to
+// httpx.DefaultClient, which propagates the metadata of the invocations in
+// their headers.
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *Client) { c.httpClient = client }
+}
+
+// NewClient returns a client of the Bedrock Runtime API of region,
+// configured by opts. If region is empty or opts set no credentials, they
+// are loaded as the AWS SDK does with config.LoadDefaultConfig: from the
+// environment, the shared configuration and credentials files, including
+// the SSO, web identity (e.g. IRSA) and assume role profiles, the container
+// or the instance metadata.
+func NewClient(ctx context.Context, region string, opts ...Option) (*Client, error) {
+	c := &Client{
+		region:     region,
+		signer:     v4.NewSigner(),
+		httpClient: httpx.DefaultClient,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.region == "" || c.credentials == nil {
+		cfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(region))
+		if err != nil {
+			return nil, fmt.Errorf("bedrock: failed to load the AWS configuration: %w", err)
+		}
+		if c.region == "" {
+			c.region = cfg.Region
+		}
+		if c.credentials == nil {
+			c.credentials = cfg.Credentials
+		}
+	}
+	if c.region == "" {
+		return nil, errors.New("bedrock: no region given, and none is configured for the AWS SDK")
+	}
+	if c.endpoint == "" {
+		c.endpoint = "https://bedrock-runtime." + c.region + ".amazonaws.com"
+		if url := os.Getenv("AWS_ENDPOINT_URL_BEDROCK_RUNTIME"); url != "" {
+			c.endpoint = strings.TrimSuffix(url, "/")
+		}
+	}
+	return c, nil
+}
+
+// Error is an error returned by the Bedrock Runtime API.
+type Error struct {
+	// StatusCode is the HTTP status of the response, zero for the
+	// exceptions of the streams.
+	StatusCode int
+	// Type is the type of the error, e.g. "ThrottlingException".
+	Type string
+	// Message describes the error.
+	Message string
+}
+
+// Error implements error.
+func (e *Error) Error() string {
+	if e.StatusCode == 0 {
+		return fmt.Sprintf("bedrock: %s: %s", e.Type, e.Message)
+	}
+	return fmt.Sprintf("bedrock: %d %s: %s", e.StatusCode, e.Type, e.Message)
+}
+
+// uriEncode escapes all the bytes of s but the unreserved characters of
+// RFC 3986, e.g. the colons of the model IDs.
+func uriEncode(s string) string {
+	const hexDigits = "0123456789ABCDEF"
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+			continue
+		}
+		b.WriteByte('%')
+		b.WriteByte(hexDigits[c>>4])
+		b.WriteByte(hexDigits[c&15])
+	}
+	return b.String()
+}
+
+// credentialsError is returned when the credentials cannot be retrieved.
+type credentialsError struct {
+	err error
+}
+
+func (e *credentialsError) Error() string {
+	return "bedrock: failed to retrieve the AWS credentials: " + e.err.Error()
+}
+
+func (e *credentialsError) Unwrap() error {
+	return e.err
+}
+
+// send posts body to the action, converse or converse-stream, of the
+// model, and returns the response if it succeeded.
+func (c *Client) send(ctx context.Context, modelID, action string, body *converseRequest) (*http.Response, error) {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode the request: %w", err)
+	}
+	creds, err := c.credentials.Retrieve(ctx)
+	if err != nil {
+		return nil, &credentialsError{err: err}
+	}
+	// The model IDs may contain a colon, e.g.
+	// "anthropic.claude-3-5-sonnet-20240620-v1:0", or be ARNs: they are
+	// escaped like the AWS SDK does.
+	path := "/model/" + uriEncode(modelID) + "/" + action
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint+path, bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if action == "converse-stream" {
+		req.Header.Set("Accept", "application/vnd.amazon.eventstream")
+	} else {
+		req.Header.Set("Accept", "application/json")
+	}
+	hash := sha256.Sum256(b)
+	if err := c.signer.SignHTTP(ctx, creds, req, hex.EncodeToString(hash[:]), signingService, c.region, runtimedeps.FromContext(ctx).Now()); err != nil {
+		return nil, fmt.Errorf("failed to sign the request: %w", err)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+		return nil, decodeError(resp.StatusCode, resp.Header.Get("X-Amzn-Errortype"), b)
+	}
+	return resp, nil
+}
+
+// decodeError returns the error of a response with the given status,
+// type header and body.
+func decodeError(status int, typ string, body []byte) *Error {
+	apiErr := &Error{StatusCode: status, Type: errorType(typ)}
+	var e struct {
+		Type         string `json:"__type"`
+		Message      string `json:"message"`
+		MessageUpper string `json:"Message"`
+	}
+	if json.Unmarshal(body, &e) == nil {
+		if apiErr.Type == "" {
+			apiErr.Type = errorType(e.Type)
+		}
+		apiErr.Message = e.Message
+		if apiErr.Message == "" {
+			apiErr.Message = e.MessageUpper
+		}
+	}
+	if apiErr.Message == "" {
+		apiErr.Message = strings.TrimSpace(string(body))
+	}
+	return apiErr
+}
+
+// errorType returns the name of the exception of a type given as
+// "ThrottlingException:http://internal.amazon.com/coral/...",
+// "com.amazon.coral.service#ThrottlingException" or, in the streams,
+// "throttlingException".
+func errorType(typ string) string {
+	typ, _, _ = strings.Cut(typ, ":")
+	if i := strings.LastIndex(typ, "#"); i >= 0 {
+		typ = typ[i+1:]
+	}
+	if typ != "" {
+		typ = strings.ToUpper(typ[:1]) + typ[1:]
+	}
+	return typ
+}
+
+// converse sends a non-streamed request.
+func (c *Client) converse(ctx context.Context, modelID string, body *converseRequest) (*converseResponse, error) {
+	resp, err := c.send(ctx, modelID, "converse", body)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var out converseResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("failed to decode the response: %w", err)
+	}
+	out.RequestID = resp.Header.Get("X-Amzn-Requestid")
+	return &out, nil
+}
+
+// converseStream sends a streamed request, and returns its events. The
+// exceptions of the stream are returned as an [Error].
+func (c *Client) converseStream(ctx context.Context, modelID string, body *converseRequest) iter.Seq2[*streamEvent, error] {
+	return func(yield func(*streamEvent, error) bool) {
+		resp, err := c.send(ctx, modelID, "converse-stream", body)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		defer resp.Body.Close()
+		requestID := resp.Header.Get("X-Amzn-Requestid")
+		decoder := eventstream.NewDecoder()
+		for {
+			msg, err := decoder.Decode(resp.Body, nil)
+			if errors.Is(err, io.EOF) {
+				return
+			}
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			event, err := decodeStreamEvent(msg)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			event.RequestID = requestID
+			if !yield(event, nil) {
+				return
+			}
+		}
+	}
+}
+
+// decodeStreamEvent decodes a message of a stream: an event, or an
+// exception returned as an [Error].
+func decodeStreamEvent(msg eventstream.Message) (*streamEvent, error) {
+	switch header(msg, ":message-type") {
+	case "exception":
+		return nil, decodeError(0, header(msg, ":exception-type"), msg.Payload)
+	case "error":
+		return nil, &Error{Type: header(msg, ":error-code"), Message: header(msg, ":error-message")}
+	}
+	event := &streamEvent{Type: header(msg, ":event-type")}
+	if len(msg.Payload) > 0 {
+		if err := json.Unmarshal(msg.Payload, event); err != nil {
+			return nil, fmt.Errorf("failed to decode the stream event %s %q: %w", event.Type, msg.Payload, err)
+		}
+	}
+	return event, nil
+}
+
+// header returns the value of the header name of msg, empty if it has
+// none.
+func header(msg eventstream.Message, name string) string {
+	if v := msg.Headers.Get(name); v != nil {
+		return v.String()
+	}
+	return ""
+}
+
+// isAPIError reports whether err is an [Error], and returns it.
+func isAPIError(err error) (*Error, bool) {
+	var apiErr *Error
+	ok := errors.As(err, &apiErr)
+	return apiErr, ok
+}
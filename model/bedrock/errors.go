@@ -0,0 +1,91 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bedrock
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+
+	"google.golang.org/adk/model"
+)
+
+// ErrorCode classifies an error returned by the Bedrock client. API errors
+// are classified by their type and status code, the failures to retrieve
+// the credentials as authentication failures, and network errors as
+// unavailable.
+func ErrorCode(err error) model.ErrorCode {
+	if apiErr, ok := isAPIError(err); ok {
+		return apiErrorCode(apiErr)
+	}
+	var credsErr *credentialsError
+	if errors.As(err, &credsErr) {
+		return model.ErrorCodeAuthFailed
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return model.ErrorCodeUnknown
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return model.ErrorCodeUnavailable
+	}
+	return model.ErrorCodeUnknown
+}
+
+// apiErrorCode classifies a Bedrock API error. The type of the error takes
+// precedence over the status code, which the exceptions of the streams
+// lack.
+func apiErrorCode(err *Error) model.ErrorCode {
+	switch err.Type {
+	case "ThrottlingException":
+		return model.ErrorCodeRateLimited
+	case "ServiceQuotaExceededException":
+		return model.ErrorCodeQuotaExceeded
+	case "ServiceUnavailableException", "InternalServerException", "ModelNotReadyException", "ModelTimeoutException", "ModelStreamErrorException":
+		return model.ErrorCodeUnavailable
+	case "AccessDeniedException", "UnrecognizedClientException", "ExpiredTokenException", "InvalidSignatureException", "MissingAuthenticationTokenException":
+		return model.ErrorCodeAuthFailed
+	case "ValidationException":
+		// Context overflows are only told apart by their message.
+		msg := strings.ToLower(err.Message)
+		if strings.Contains(msg, "too long") || strings.Contains(msg, "too many input tokens") || strings.Contains(msg, "context length") {
+			return model.ErrorCodeContextTooLong
+		}
+		return model.ErrorCodeInvalidRequest
+	case "ResourceNotFoundException":
+		return model.ErrorCodeInvalidRequest
+	}
+	switch status := err.StatusCode; {
+	case status == http.StatusTooManyRequests:
+		return model.ErrorCodeRateLimited
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return model.ErrorCodeAuthFailed
+	case status == http.StatusRequestEntityTooLarge:
+		return model.ErrorCodeContextTooLong
+	case status >= 500:
+		return model.ErrorCodeUnavailable
+	case status >= 400:
+		return model.ErrorCodeInvalidRequest
+	default:
+		return model.ErrorCodeUnknown
+	}
+}
+
+// classify wraps err in a [model.Error] carrying its code.
+func classify(err error) error {
+	return &model.Error{Code: ErrorCode(err), Err: err}
+}
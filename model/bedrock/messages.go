@@ -0,0 +1,156 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bedrock
+
+import "encoding/json"
+
+// The types below are the subset of the Converse API used by the adapter.
+
+type converseRequest struct {
+	Messages                     []message        `json:"messages"`
+	System                       []systemBlock    `json:"system,omitempty"`
+	InferenceConfig              *inferenceConfig `json:"inferenceConfig,omitempty"`
+	ToolConfig                   *toolConfig      `json:"toolConfig,omitempty"`
+	AdditionalModelRequestFields map[string]any   `json:"additionalModelRequestFields,omitempty"`
+}
+
+type message struct {
+	Role    string         `json:"role"`
+	Content []contentBlock `json:"content"`
+}
+
+// contentBlock is a block of the content of a message. Exactly one of its
+// fields is set.
+type contentBlock struct {
+	Text       string           `json:"text,omitempty"`
+	Image      *imageBlock      `json:"image,omitempty"`
+	ToolUse    *toolUseBlock    `json:"toolUse,omitempty"`
+	ToolResult *toolResultBlock `json:"toolResult,omitempty"`
+}
+
+type systemBlock struct {
+	Text string `json:"text"`
+}
+
+type imageBlock struct {
+	// Format is the type of the image: png, jpeg, gif or webp.
+	Format string      `json:"format"`
+	Source imageSource `json:"source"`
+}
+
+type imageSource struct {
+	Bytes []byte `json:"bytes"`
+}
+
+type toolUseBlock struct {
+	ToolUseID string          `json:"toolUseId"`
+	Name      string          `json:"name"`
+	Input     json.RawMessage `json:"input"`
+}
+
+type toolResultBlock struct {
+	ToolUseID string              `json:"toolUseId"`
+	Content   []toolResultContent `json:"content"`
+	// Status is "error" for the results of the calls that failed.
+	Status string `json:"status,omitempty"`
+}
+
+type toolResultContent struct {
+	JSON any `json:"json"`
+}
+
+type inferenceConfig struct {
+	MaxTokens     int32    `json:"maxTokens,omitempty"`
+	Temperature   *float32 `json:"temperature,omitempty"`
+	TopP          *float32 `json:"topP,omitempty"`
+	StopSequences []string `json:"stopSequences,omitempty"`
+}
+
+type toolConfig struct {
+	Tools      []tool      `json:"tools"`
+	ToolChoice *toolChoice `json:"toolChoice,omitempty"`
+}
+
+type tool struct {
+	ToolSpec toolSpec `json:"toolSpec"`
+}
+
+type toolSpec struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description,omitempty"`
+	InputSchema inputSchema `json:"inputSchema"`
+}
+
+type inputSchema struct {
+	JSON any `json:"json"`
+}
+
+// toolChoice is auto, any or a specific tool, depending on which of its
+// fields is set.
+type toolChoice struct {
+	Auto *struct{}       `json:"auto,omitempty"`
+	Any  *struct{}       `json:"any,omitempty"`
+	Tool *toolChoiceTool `json:"tool,omitempty"`
+}
+
+type toolChoiceTool struct {
+	Name string `json:"name"`
+}
+
+type converseResponse struct {
+	Output struct {
+		Message *message `json:"message"`
+	} `json:"output"`
+	StopReason string `json:"stopReason"`
+	Usage      usage  `json:"usage"`
+	// RequestID is the ID of the request, from the x-amzn-RequestId header
+	// of the response.
+	RequestID string `json:"-"`
+}
+
+type usage struct {
+	InputTokens           int64 `json:"inputTokens"`
+	OutputTokens          int64 `json:"outputTokens"`
+	TotalTokens           int64 `json:"totalTokens"`
+	CacheReadInputTokens  int64 `json:"cacheReadInputTokens"`
+	CacheWriteInputTokens int64 `json:"cacheWriteInputTokens"`
+}
+
+// streamEvent is an event of a streamed response. Its fields are set
+// depending on its type: messageStart, contentBlockStart,
+// contentBlockDelta, contentBlockStop, messageStop or metadata.
+type streamEvent struct {
+	Type string `json:"-"`
+	// RequestID is the ID of the request of the stream.
+	RequestID         string      `json:"-"`
+	Role              string      `json:"role"`
+	ContentBlockIndex int         `json:"contentBlockIndex"`
+	Start             *blockStart `json:"start"`
+	Delta             *blockDelta `json:"delta"`
+	StopReason        string      `json:"stopReason"`
+	Usage             *usage      `json:"usage"`
+}
+
+type blockStart struct {
+	ToolUse *toolUseBlock `json:"toolUse"`
+}
+
+type blockDelta struct {
+	Text    string `json:"text"`
+	ToolUse *struct {
+		// Input is a fragment of the JSON arguments of the call.
+		Input string `json:"input"`
+	} `json:"toolUse"`
+}
@@ -0,0 +1,118 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bedrock
+
+import (
+	"encoding/json"
+	"strings"
+
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/model"
+)
+
+// streamAccumulator rebuilds the response of a stream from its events.
+type streamAccumulator struct {
+	requestID string
+	started   bool
+	stopped   bool
+	// blocks are the content blocks of the message, by index.
+	blocks     []*streamBlock
+	stopReason string
+	usage      usage
+}
+
+// streamBlock is a text block, or a toolUse block whose arguments are
+// streamed in input.
+type streamBlock struct {
+	text    strings.Builder
+	toolUse *toolUseBlock
+	input   strings.Builder
+}
+
+// add applies event to the response. It returns the partial response
+// carrying the text streamed by event, if any.
+func (a *streamAccumulator) add(event *streamEvent) *model.LLMResponse {
+	if a.requestID == "" {
+		a.requestID = event.RequestID
+	}
+	switch event.Type {
+	case "messageStart":
+		a.started = true
+	case "contentBlockStart":
+		if event.Start != nil && event.Start.ToolUse != nil {
+			if b := a.block(event.ContentBlockIndex); b != nil {
+				b.toolUse = event.Start.ToolUse
+			}
+		}
+	case "contentBlockDelta":
+		b := a.block(event.ContentBlockIndex)
+		if b == nil || event.Delta == nil {
+			return nil
+		}
+		if event.Delta.ToolUse != nil {
+			b.input.WriteString(event.Delta.ToolUse.Input)
+		}
+		if event.Delta.Text != "" {
+			b.text.WriteString(event.Delta.Text)
+			return &model.LLMResponse{
+				Content: genai.NewContentFromText(event.Delta.Text, genai.RoleModel),
+				Partial: true,
+			}
+		}
+	case "messageStop":
+		a.stopped = true
+		a.stopReason = event.StopReason
+	case "metadata":
+		if event.Usage != nil {
+			a.usage = *event.Usage
+		}
+	}
+	return nil
+}
+
+func (a *streamAccumulator) block(index int) *streamBlock {
+	if index < 0 {
+		return nil
+	}
+	for len(a.blocks) <= index {
+		a.blocks = append(a.blocks, &streamBlock{})
+	}
+	return a.blocks[index]
+}
+
+// response returns the response rebuilt from the events, nil if the
+// stream did not stop its message.
+func (a *streamAccumulator) response() *converseResponse {
+	if !a.started || !a.stopped {
+		return nil
+	}
+	msg := &message{Role: "assistant"}
+	for _, b := range a.blocks {
+		switch {
+		case b.toolUse != nil:
+			toolUse := *b.toolUse
+			if b.input.Len() > 0 {
+				toolUse.Input = json.RawMessage(b.input.String())
+			}
+			msg.Content = append(msg.Content, contentBlock{ToolUse: &toolUse})
+		case b.text.Len() > 0:
+			msg.Content = append(msg.Content, contentBlock{Text: b.text.String()})
+		}
+	}
+	resp := &converseResponse{StopReason: a.stopReason, Usage: a.usage, RequestID: a.requestID}
+	resp.Output.Message = msg
+	return resp
+}
@@ -0,0 +1,221 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"sync"
+
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/httpx"
+	"google.golang.org/adk/internal/invocationstate"
+)
+
+// Budget bounds the calls of an invocation, see [WithBudget]. The zero
+// values of the limits disable them.
+//
+// A Budget is a hard limit enforced by the model, failing the calls beyond
+// it, e.g. to stop a runaway agent loop whatever the agent. To make an agent
+// aware of its budget, so that it plans its tool calls and answers before
+// running out of it, or to bound its cost, duration or tool calls, use the
+// callbacks of package agent/budget instead, possibly with a Budget as a
+// backstop.
+type Budget struct {
+	// MaxPromptTokens bounds the prompt tokens of all the calls of an
+	// invocation.
+	MaxPromptTokens int
+	// MaxTotalTokensPerInvocation bounds the prompt and output tokens of all
+	// the calls of an invocation.
+	MaxTotalTokensPerInvocation int
+	// MaxCalls bounds the number of calls of an invocation.
+	MaxCalls int
+}
+
+// TokenCounter is implemented by the models able to count the prompt tokens
+// of a request without generating content, so that [WithBudget] can reject
+// the calls exceeding the budget before sending them.
+type TokenCounter interface {
+	CountTokens(ctx context.Context, req *LLMRequest) (int, error)
+}
+
+// BudgetUsage is the use of the budget of an invocation.
+type BudgetUsage struct {
+	Calls        int
+	PromptTokens int
+	TotalTokens  int
+}
+
+// BudgetedModel is a model whose calls are bounded by a budget per
+// invocation.
+type BudgetedModel struct {
+	llm    LLM
+	budget Budget
+
+	mu sync.Mutex
+	// invocations holds the usage of the invocations by their ID, the least
+	// recently used being forgotten beyond
+	// invocationstate.DefaultCapacity.
+	invocations *invocationstate.Map[*invocationBudget]
+}
+
+type invocationBudget struct {
+	used BudgetUsage
+	// reserved is the prompt tokens counted for the calls in progress, not
+	// accounted yet.
+	reserved int
+}
+
+var _ LLM = (*BudgetedModel)(nil)
+
+// WithBudget returns llm with the calls of each invocation bounded by
+// budget. The usage of an invocation is the sum of the usage metadata
+// reported by its calls, the invocation being the one of the context of
+// the calls: the invocation context of the agent, or the invocation of the
+// metadata propagated by the runner, see [httpx.FromContext]. The calls of
+// no invocation are not bounded.
+//
+// A call fails with an [Error] of code ErrorCodeBudgetExceeded, without
+// being sent, if the calls of its invocation reached a limit. If llm is a
+// [TokenCounter], the prompt of the call is counted first, and the call is
+// also rejected if its prompt would exceed a limit; otherwise a call
+// exceeding the limits is only detected once it returned its usage, failing
+// the next calls. The budget of an invocation is shared by its concurrent
+// calls, e.g. those of parallel sub-agents.
+func WithBudget(llm LLM, budget Budget) *BudgetedModel {
+	return &BudgetedModel{llm: llm, budget: budget, invocations: invocationstate.New[*invocationBudget](0)}
+}
+
+// Name implements LLM.
+func (m *BudgetedModel) Name() string {
+	return m.llm.Name()
+}
+
+// GenerateContent implements LLM.
+func (m *BudgetedModel) GenerateContent(ctx context.Context, req *LLMRequest, stream bool) iter.Seq2[*LLMResponse, error] {
+	return func(yield func(*LLMResponse, error) bool) {
+		id := invocationID(ctx)
+		if id == "" {
+			for resp, err := range m.llm.GenerateContent(ctx, req, stream) {
+				if !yield(resp, err) {
+					return
+				}
+			}
+			return
+		}
+
+		// The prompt is unknown, -1, if the model cannot count it.
+		prompt := -1
+		if counter, ok := m.llm.(TokenCounter); ok {
+			if n, err := counter.CountTokens(ctx, req); err == nil {
+				prompt = n
+			}
+		}
+		if err := m.reserve(id, prompt); err != nil {
+			yield(nil, err)
+			return
+		}
+
+		var usage *genai.GenerateContentResponseUsageMetadata
+		defer func() { m.account(id, prompt, usage) }()
+		for resp, err := range m.llm.GenerateContent(ctx, req, stream) {
+			if resp != nil && resp.UsageMetadata != nil {
+				usage = resp.UsageMetadata
+			}
+			if !yield(resp, err) {
+				return
+			}
+		}
+	}
+}
+
+// reserve checks that a call of invocation id with a prompt of prompt
+// tokens, or -1 if unknown, is within the budget, and reserves it.
+func (m *BudgetedModel) reserve(id string, prompt int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	inv := m.invocation(id)
+	known := max(prompt, 0)
+	exceeds := func(limit, used int) bool {
+		if limit <= 0 {
+			return false
+		}
+		if prompt < 0 {
+			return used >= limit
+		}
+		return used+inv.reserved+known > limit
+	}
+	switch {
+	case m.budget.MaxCalls > 0 && inv.used.Calls >= m.budget.MaxCalls:
+		return budgetError(id, "%d calls made, the budget is %d calls", inv.used.Calls, m.budget.MaxCalls)
+	case exceeds(m.budget.MaxPromptTokens, inv.used.PromptTokens):
+		return budgetError(id, "%d prompt tokens used and %d more requested, the budget is %d prompt tokens", inv.used.PromptTokens, inv.reserved+known, m.budget.MaxPromptTokens)
+	case exceeds(m.budget.MaxTotalTokensPerInvocation, inv.used.TotalTokens):
+		return budgetError(id, "%d tokens used and %d more requested, the budget is %d tokens", inv.used.TotalTokens, inv.reserved+known, m.budget.MaxTotalTokensPerInvocation)
+	}
+	inv.used.Calls++
+	inv.reserved += known
+	return nil
+}
+
+// account replaces the reservation of a call of invocation id by its usage,
+// the counts it did not report being those of its prompt.
+func (m *BudgetedModel) account(id string, prompt int, usage *genai.GenerateContentResponseUsageMetadata) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	inv := m.invocation(id)
+	known := max(prompt, 0)
+	inv.reserved -= known
+	promptTokens, totalTokens := known, known
+	if usage != nil && usage.PromptTokenCount > 0 {
+		promptTokens = int(usage.PromptTokenCount)
+	}
+	if usage != nil && usage.TotalTokenCount > 0 {
+		totalTokens = int(usage.TotalTokenCount)
+	}
+	inv.used.PromptTokens += promptTokens
+	inv.used.TotalTokens += totalTokens
+}
+
+// invocation returns the budget of invocation id, created if needed. m.mu
+// must be held.
+func (m *BudgetedModel) invocation(id string) *invocationBudget {
+	return m.invocations.GetOrCreate(id, func() *invocationBudget { return &invocationBudget{} })
+}
+
+// Usage returns the use of the budget of the invocation of ID id.
+func (m *BudgetedModel) Usage(id string) BudgetUsage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if inv, ok := m.invocations.Get(id); ok {
+		return inv.used
+	}
+	return BudgetUsage{}
+}
+
+func budgetError(id, format string, args ...any) error {
+	return &Error{Code: ErrorCodeBudgetExceeded, Err: fmt.Errorf("budget of invocation %s exceeded: "+format, append([]any{id}, args...)...)}
+}
+
+// invocationID returns the ID of the invocation of ctx, or "" if none.
+func invocationID(ctx context.Context) string {
+	if inv, ok := ctx.(interface{ InvocationID() string }); ok {
+		return inv.InvocationID()
+	}
+	md, _ := httpx.FromContext(ctx)
+	return md.InvocationID
+}
@@ -0,0 +1,126 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model_test
+
+import (
+	"context"
+	"iter"
+	"sync"
+	"testing"
+
+	"google.golang.org/adk/httpx"
+	"google.golang.org/adk/model"
+)
+
+// countingLLM is a usageLLM counting the prompt of its requests as prompt
+// tokens.
+type countingLLM struct {
+	usageLLM
+	prompt int
+}
+
+func (m *countingLLM) CountTokens(ctx context.Context, req *model.LLMRequest) (int, error) {
+	return m.prompt, nil
+}
+
+func invocationContext(t *testing.T, id string) context.Context {
+	return httpx.NewContext(t.Context(), httpx.Metadata{InvocationID: id})
+}
+
+func TestWithBudget_PreCall(t *testing.T) {
+	inner := &countingLLM{usageLLM: usageLLM{tokens: 150}, prompt: 100}
+	llm := model.WithBudget(inner, model.Budget{MaxPromptTokens: 250})
+	ctx := invocationContext(t, "e-1")
+
+	for range 2 {
+		if err := call(ctx, llm); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The third prompt would exceed the budget: the call is not sent.
+	if err := call(ctx, llm); model.CodeOf(err) != model.ErrorCodeBudgetExceeded {
+		t.Errorf("call() error = %v, want %s", err, model.ErrorCodeBudgetExceeded)
+	}
+	if inner.calls != 2 {
+		t.Errorf("model called %d times, want 2", inner.calls)
+	}
+	// The budgets of the invocations are independent.
+	if err := call(invocationContext(t, "e-2"), llm); err != nil {
+		t.Errorf("call() of another invocation error = %v", err)
+	}
+	// The calls of no invocation are not bounded.
+	if err := call(t.Context(), llm); err != nil {
+		t.Errorf("call() of no invocation error = %v", err)
+	}
+}
+
+func TestWithBudget_PostCall(t *testing.T) {
+	inner := &usageLLM{tokens: 150}
+	llm := model.WithBudget(inner, model.Budget{MaxTotalTokensPerInvocation: 200, MaxCalls: 5})
+	ctx := invocationContext(t, "e-1")
+
+	// The model cannot count the tokens: the second call is only known to
+	// exceed the budget once it returned its usage.
+	for range 2 {
+		if err := call(ctx, llm); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got, want := llm.Usage("e-1"), (model.BudgetUsage{Calls: 2, TotalTokens: 300}); got != want {
+		t.Errorf("Usage() = %+v, want %+v", got, want)
+	}
+	if err := call(ctx, llm); model.CodeOf(err) != model.ErrorCodeBudgetExceeded {
+		t.Errorf("call() error = %v, want %s", err, model.ErrorCodeBudgetExceeded)
+	}
+	if inner.calls != 2 {
+		t.Errorf("model called %d times, want 2", inner.calls)
+	}
+}
+
+func TestWithBudget_ConcurrentCalls(t *testing.T) {
+	llm := model.WithBudget(&lockedUsageLLM{}, model.Budget{MaxCalls: 10})
+	ctx := invocationContext(t, "e-1")
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	rejected := 0
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := call(ctx, llm); model.CodeOf(err) == model.ErrorCodeBudgetExceeded {
+				mu.Lock()
+				rejected++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if rejected != 10 || llm.Usage("e-1").Calls != 10 {
+		t.Errorf("rejected %d calls, made %d, want 10 of each", rejected, llm.Usage("e-1").Calls)
+	}
+}
+
+// lockedUsageLLM is a usageLLM safe for concurrent use.
+type lockedUsageLLM struct {
+	mu sync.Mutex
+	usageLLM
+}
+
+func (m *lockedUsageLLM) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.usageLLM.GenerateContent(ctx, req, stream)
+}
@@ -0,0 +1,263 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"maps"
+	"sync"
+	"time"
+
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/runtimedeps"
+)
+
+// CacheHitMetadataKey is the key of the custom metadata of the responses of
+// a model created by [WithCache] set to true for the responses served from
+// the cache.
+const CacheHitMetadataKey = "adk_cache_hit"
+
+// Cache stores the responses of the models created by [WithCache], by the
+// key of their request. Its methods are called concurrently.
+type Cache interface {
+	// Get returns the response stored for key, if any and not expired.
+	Get(ctx context.Context, key string) (*LLMResponse, bool)
+	// Set stores resp for key for ttl, or without expiry if ttl is zero.
+	Set(ctx context.Context, key string, resp *LLMResponse, ttl time.Duration)
+}
+
+// CacheConfig configures [WithCacheConfig].
+type CacheConfig struct {
+	// TTL is how long the responses are stored. Zero stores them without
+	// expiry, until the cache evicts them.
+	TTL time.Duration
+	// Force caches the responses of the requests whose temperature is not
+	// zero, which are not deterministic.
+	Force bool
+}
+
+type bypassCacheKey struct{}
+
+// BypassCache returns a copy of ctx whose calls of the models created by
+// [WithCache] are sent to the model, and their responses not stored.
+func BypassCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, bypassCacheKey{}, true)
+}
+
+// WithCache returns llm with its responses cached in cache, using the
+// default CacheConfig. See [WithCacheConfig].
+func WithCache(llm LLM, cache Cache) LLM {
+	return WithCacheConfig(llm, cache, CacheConfig{})
+}
+
+// WithCacheConfig returns llm with the responses of its deterministic
+// requests, whose temperature is set to zero, cached in cache, e.g. to save
+// the calls repeated during the development or the evaluations of an
+// agent. The key of a request is a hash of its model, contents and config,
+// including the system instruction, the tools and the generation config,
+// see [CacheKey].
+//
+// The response stored is the last complete response of a call which did not
+// fail: a call served from the cache yields it once, even if streamed,
+// marked with [CacheHitMetadataKey]. The calls with a context returned by
+// [BypassCache] are not cached.
+func WithCacheConfig(llm LLM, cache Cache, cfg CacheConfig) LLM {
+	return &cachedModel{llm: llm, cache: cache, cfg: cfg}
+}
+
+type cachedModel struct {
+	llm   LLM
+	cache Cache
+	cfg   CacheConfig
+}
+
+// Name implements LLM.
+func (m *cachedModel) Name() string {
+	return m.llm.Name()
+}
+
+// GenerateContent implements LLM.
+func (m *cachedModel) GenerateContent(ctx context.Context, req *LLMRequest, stream bool) iter.Seq2[*LLMResponse, error] {
+	return func(yield func(*LLMResponse, error) bool) {
+		key, cacheable := m.key(ctx, req)
+		if cacheable {
+			if resp, ok := m.cache.Get(ctx, key); ok {
+				resp.Partial = false
+				resp.CustomMetadata = maps.Clone(resp.CustomMetadata)
+				if resp.CustomMetadata == nil {
+					resp.CustomMetadata = make(map[string]any)
+				}
+				resp.CustomMetadata[CacheHitMetadataKey] = true
+				yield(resp, nil)
+				return
+			}
+		}
+
+		var final *LLMResponse
+		failed := false
+		for resp, err := range m.llm.GenerateContent(ctx, req, stream) {
+			switch {
+			case err != nil || resp == nil || resp.ErrorCode != "":
+				failed = true
+			case cacheable && !resp.Partial:
+				// The response is copied before the caller can modify it.
+				if final, err = cloneResponse(resp); err != nil {
+					cacheable = false
+				}
+			}
+			if !yield(resp, err) {
+				return
+			}
+		}
+		if cacheable && !failed && final != nil {
+			m.cache.Set(ctx, key, final, m.cfg.TTL)
+		}
+	}
+}
+
+// key returns the key of req, and whether its responses are cached.
+func (m *cachedModel) key(ctx context.Context, req *LLMRequest) (string, bool) {
+	if bypass, _ := ctx.Value(bypassCacheKey{}).(bool); bypass {
+		return "", false
+	}
+	if !m.cfg.Force && (req.Config == nil || req.Config.Temperature == nil || *req.Config.Temperature != 0) {
+		return "", false
+	}
+	key, err := CacheKey(req, m.llm.Name())
+	return key, err == nil
+}
+
+// CacheKey returns the key of req in the caches of [WithCache]: a hash of
+// its model, or else of modelName, of its contents and of its config. It
+// fails if the request cannot be encoded in JSON.
+func CacheKey(req *LLMRequest, modelName string) (string, error) {
+	if req.Model != "" {
+		modelName = req.Model
+	}
+	b, err := json.Marshal(struct {
+		Model    string                       `json:"model"`
+		Contents []*genai.Content             `json:"contents"`
+		Config   *genai.GenerateContentConfig `json:"config"`
+	}{modelName, req.Contents, req.Config})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode the request: %w", err)
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// cloneResponse returns a deep copy of resp.
+func cloneResponse(resp *LLMResponse) (*LLMResponse, error) {
+	b, err := json.Marshal(resp)
+	if err != nil {
+		return nil, err
+	}
+	var clone LLMResponse
+	if err := json.Unmarshal(b, &clone); err != nil {
+		return nil, err
+	}
+	return &clone, nil
+}
+
+// LRUCache is an in-memory [Cache] holding a bounded number of responses,
+// evicting the least recently used ones. The responses are stored encoded,
+// so that the callers cannot modify them. The expiry is told by the clock
+// of [runtimedeps.FromContext].
+type LRUCache struct {
+	size int
+
+	mu sync.Mutex
+	// lru holds the entries, the most recently used first.
+	lru     *list.List
+	entries map[string]*list.Element
+}
+
+type lruEntry struct {
+	key  string
+	resp []byte
+	// expires is zero for the entries without expiry.
+	expires time.Time
+}
+
+var _ Cache = (*LRUCache)(nil)
+
+// NewLRUCache returns a cache holding up to size responses.
+func NewLRUCache(size int) *LRUCache {
+	return &LRUCache{size: max(size, 1), lru: list.New(), entries: make(map[string]*list.Element)}
+}
+
+// Get implements Cache.
+func (c *LRUCache) Get(ctx context.Context, key string) (*LLMResponse, bool) {
+	now := runtimedeps.FromContext(ctx).Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*lruEntry)
+	if !entry.expires.IsZero() && !now.Before(entry.expires) {
+		c.lru.Remove(elem)
+		delete(c.entries, key)
+		return nil, false
+	}
+	var resp LLMResponse
+	if err := json.Unmarshal(entry.resp, &resp); err != nil {
+		return nil, false
+	}
+	c.lru.MoveToFront(elem)
+	return &resp, true
+}
+
+// Set implements Cache. The responses which cannot be encoded in JSON are
+// not stored.
+func (c *LRUCache) Set(ctx context.Context, key string, resp *LLMResponse, ttl time.Duration) {
+	b, err := json.Marshal(resp)
+	if err != nil {
+		return
+	}
+	entry := &lruEntry{key: key, resp: b}
+	if ttl > 0 {
+		entry.expires = runtimedeps.FromContext(ctx).Now().Add(ttl)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[key]; ok {
+		elem.Value = entry
+		c.lru.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.lru.PushFront(entry)
+	for c.lru.Len() > c.size {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*lruEntry).key)
+	}
+}
+
+// Len returns the number of responses in the cache, including the expired
+// ones not evicted yet.
+func (c *LRUCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
@@ -0,0 +1,212 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model_test
+
+import (
+	"context"
+	"iter"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/model"
+)
+
+// fixedLLM is a model whose calls yield responses, or their partial texts
+// followed by the last response if streamed.
+type fixedLLM struct {
+	responses []*model.LLMResponse
+	calls     int
+}
+
+func (m *fixedLLM) Name() string { return "fixed" }
+
+func (m *fixedLLM) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	m.calls++
+	return func(yield func(*model.LLMResponse, error) bool) {
+		for _, resp := range m.responses {
+			if !yield(resp, nil) {
+				return
+			}
+		}
+	}
+}
+
+func deterministicRequest(text string) *model.LLMRequest {
+	return &model.LLMRequest{
+		Contents: []*genai.Content{genai.NewContentFromText(text, genai.RoleUser)},
+		Config:   &genai.GenerateContentConfig{Temperature: genai.Ptr[float32](0)},
+	}
+}
+
+// responses returns the responses of a call of llm.
+func responses(t *testing.T, ctx context.Context, llm model.LLM, req *model.LLMRequest, stream bool) []*model.LLMResponse {
+	t.Helper()
+	var got []*model.LLMResponse
+	for resp, err := range llm.GenerateContent(ctx, req, stream) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, resp)
+	}
+	return got
+}
+
+func TestWithCache_HitAndMiss(t *testing.T) {
+	inner := &fixedLLM{responses: []*model.LLMResponse{{Content: genai.NewContentFromText("4", genai.RoleModel), TurnComplete: true}}}
+	llm := model.WithCache(inner, model.NewLRUCache(10))
+
+	responses(t, t.Context(), llm, deterministicRequest("2+2?"), false)
+	got := responses(t, t.Context(), llm, deterministicRequest("2+2?"), false)
+	if inner.calls != 1 {
+		t.Errorf("model called %d times, want 1: the second call is a hit", inner.calls)
+	}
+	if len(got) != 1 || got[0].Content.Parts[0].Text != "4" || got[0].CustomMetadata[model.CacheHitMetadataKey] != true {
+		t.Errorf("responses = %+v, want the cached response marked as a hit", got)
+	}
+
+	responses(t, t.Context(), llm, deterministicRequest("3+3?"), false)
+	if inner.calls != 2 {
+		t.Errorf("model called %d times, want 2: another request is a miss", inner.calls)
+	}
+}
+
+func TestWithCache_StreamReplay(t *testing.T) {
+	inner := &fixedLLM{responses: []*model.LLMResponse{
+		{Content: genai.NewContentFromText("hel", genai.RoleModel), Partial: true},
+		{Content: genai.NewContentFromText("lo", genai.RoleModel), Partial: true},
+		{Content: genai.NewContentFromText("hello", genai.RoleModel), TurnComplete: true},
+	}}
+	llm := model.WithCache(inner, model.NewLRUCache(10))
+
+	if got := responses(t, t.Context(), llm, deterministicRequest("hi"), true); len(got) != 3 {
+		t.Fatalf("first call yielded %d responses, want 3", len(got))
+	}
+	got := responses(t, t.Context(), llm, deterministicRequest("hi"), true)
+	if len(got) != 1 || got[0].Partial || got[0].Content.Parts[0].Text != "hello" {
+		t.Errorf("responses = %+v, want the final response yielded once", got)
+	}
+}
+
+func TestWithCache_TTL(t *testing.T) {
+	ctx, clock := frozenContext(t)
+	inner := &fixedLLM{responses: []*model.LLMResponse{{Content: genai.NewContentFromText("4", genai.RoleModel)}}}
+	llm := model.WithCacheConfig(inner, model.NewLRUCache(10), model.CacheConfig{TTL: time.Minute})
+
+	responses(t, ctx, llm, deterministicRequest("2+2?"), false)
+	clock.Advance(59 * time.Second)
+	responses(t, ctx, llm, deterministicRequest("2+2?"), false)
+	if inner.calls != 1 {
+		t.Errorf("model called %d times within the TTL, want 1", inner.calls)
+	}
+	clock.Advance(time.Second)
+	responses(t, ctx, llm, deterministicRequest("2+2?"), false)
+	if inner.calls != 2 {
+		t.Errorf("model called %d times after the TTL, want 2", inner.calls)
+	}
+}
+
+func TestWithCache_ToolCall(t *testing.T) {
+	call := &genai.FunctionCall{Name: "get_weather", Args: map[string]any{"city": "Paris", "days": float64(3)}}
+	inner := &fixedLLM{responses: []*model.LLMResponse{{
+		Content:       &genai.Content{Role: genai.RoleModel, Parts: []*genai.Part{{FunctionCall: call}}},
+		FinishReason:  genai.FinishReasonStop,
+		UsageMetadata: &genai.GenerateContentResponseUsageMetadata{TotalTokenCount: 12},
+	}}}
+	llm := model.WithCache(inner, model.NewLRUCache(10))
+	req := deterministicRequest("weather in Paris?")
+	req.Config.Tools = []*genai.Tool{{FunctionDeclarations: []*genai.FunctionDeclaration{{Name: "get_weather"}}}}
+
+	first := responses(t, t.Context(), llm, req, false)
+	// The caller modifying the response, e.g. to set the ID of the call,
+	// does not modify the cached one.
+	first[0].Content.Parts[0].FunctionCall.ID = "adk-1"
+
+	got := responses(t, t.Context(), llm, req, false)
+	if inner.calls != 1 {
+		t.Fatalf("model called %d times, want 1", inner.calls)
+	}
+	want := &model.LLMResponse{
+		Content:        &genai.Content{Role: genai.RoleModel, Parts: []*genai.Part{{FunctionCall: &genai.FunctionCall{Name: "get_weather", Args: map[string]any{"city": "Paris", "days": float64(3)}}}}},
+		FinishReason:   genai.FinishReasonStop,
+		UsageMetadata:  &genai.GenerateContentResponseUsageMetadata{TotalTokenCount: 12},
+		CustomMetadata: map[string]any{model.CacheHitMetadataKey: true},
+	}
+	if diff := cmp.Diff(want, got[0]); diff != "" {
+		t.Errorf("cached response mismatch (-want +got):\n%s", diff)
+	}
+
+	// The tools are part of the key.
+	req.Config.Tools = nil
+	responses(t, t.Context(), llm, req, false)
+	if inner.calls != 2 {
+		t.Errorf("model called %d times for other tools, want 2", inner.calls)
+	}
+}
+
+func TestWithCache_OptOut(t *testing.T) {
+	nonDeterministic := func() *model.LLMRequest {
+		req := deterministicRequest("tell me a joke")
+		req.Config.Temperature = genai.Ptr[float32](0.7)
+		return req
+	}
+	for _, tc := range []struct {
+		name      string
+		ctx       func(context.Context) context.Context
+		req       func() *model.LLMRequest
+		cfg       model.CacheConfig
+		wantCalls int
+	}{
+		{name: "Temperature", req: nonDeterministic, wantCalls: 2},
+		{name: "NoTemperature", req: func() *model.LLMRequest { return &model.LLMRequest{} }, wantCalls: 2},
+		{name: "Forced", req: nonDeterministic, cfg: model.CacheConfig{Force: true}, wantCalls: 1},
+		{name: "Bypass", ctx: model.BypassCache, req: func() *model.LLMRequest { return deterministicRequest("2+2?") }, wantCalls: 2},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := t.Context()
+			if tc.ctx != nil {
+				ctx = tc.ctx(ctx)
+			}
+			inner := &fixedLLM{responses: []*model.LLMResponse{{Content: genai.NewContentFromText("ok", genai.RoleModel)}}}
+			llm := model.WithCacheConfig(inner, model.NewLRUCache(10), tc.cfg)
+			for range 2 {
+				responses(t, ctx, llm, tc.req(), false)
+			}
+			if inner.calls != tc.wantCalls {
+				t.Errorf("model called %d times, want %d", inner.calls, tc.wantCalls)
+			}
+		})
+	}
+}
+
+func TestLRUCache_Eviction(t *testing.T) {
+	ctx := t.Context()
+	cache := model.NewLRUCache(2)
+	resp := &model.LLMResponse{Content: genai.NewContentFromText("ok", genai.RoleModel)}
+	cache.Set(ctx, "a", resp, 0)
+	cache.Set(ctx, "b", resp, 0)
+	cache.Get(ctx, "a")
+	cache.Set(ctx, "c", resp, 0)
+	if _, ok := cache.Get(ctx, "b"); ok {
+		t.Error("Get(b) found the least recently used entry, want it evicted")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, ok := cache.Get(ctx, key); !ok {
+			t.Errorf("Get(%s) found nothing", key)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"iter"
+	"maps"
+
+	"google.golang.org/genai"
+)
+
+// Collect drains seq, the responses of a call, streamed or not, and returns
+// them merged in a single response:
+//   - its content holds the parts of the responses, the consecutive texts
+//     being concatenated and the function calls of the same ID merged. The
+//     complete content yielded after partial responses, as the adapters do
+//     at the end of a stream, replaces their content;
+//   - its usage, finish reason, error and metadata are the last reported,
+//     the providers reporting the usage of the whole call;
+//   - its custom metadata merges those of the responses.
+//
+// If seq yields an error, Collect stops and returns it with the responses
+// merged so far, nil if there was none. A sequence yielding no response
+// returns a response of code ErrorCodeUnknown, like the adapters do for a
+// provider returning no candidate.
+func Collect(seq iter.Seq2[*LLMResponse, error]) (*LLMResponse, error) {
+	return TeeCollect(seq, nil)
+}
+
+// TeeCollect is like [Collect], forwarding the partial responses of seq to
+// forward as they arrive, e.g. to stream them to a client while keeping the
+// complete response. If forward fails, TeeCollect stops and returns its
+// error with the responses merged so far. forward may be nil.
+func TeeCollect(seq iter.Seq2[*LLMResponse, error], forward func(*LLMResponse) error) (*LLMResponse, error) {
+	var c collector
+	for resp, err := range seq {
+		if err != nil {
+			return c.resp, err
+		}
+		if resp == nil {
+			continue
+		}
+		c.add(resp)
+		if forward != nil && resp.Partial {
+			if err := forward(resp); err != nil {
+				return c.resp, err
+			}
+		}
+	}
+	if c.resp == nil {
+		return &LLMResponse{ErrorCode: string(ErrorCodeUnknown), ErrorMessage: "The model returned no response."}, nil
+	}
+	return c.resp, nil
+}
+
+// collector merges the responses of a call.
+type collector struct {
+	resp *LLMResponse
+	// fromPartials tells that the content merged so far only comes from
+	// partial responses.
+	fromPartials bool
+}
+
+func (c *collector) add(resp *LLMResponse) {
+	if c.resp == nil {
+		c.resp = &LLMResponse{}
+	}
+	merged := c.resp
+	if resp.Content != nil && len(resp.Content.Parts) > 0 {
+		if !resp.Partial && c.fromPartials {
+			// The complete content of the stream.
+			merged.Content = nil
+		}
+		if merged.Content == nil {
+			merged.Content = &genai.Content{Role: resp.Content.Role}
+			c.fromPartials = resp.Partial
+		}
+		if merged.Content.Role == "" {
+			merged.Content.Role = resp.Content.Role
+		}
+		for _, part := range resp.Content.Parts {
+			merged.Content.Parts = appendPart(merged.Content.Parts, part)
+		}
+		if !resp.Partial {
+			c.fromPartials = false
+		}
+	}
+	if resp.CitationMetadata != nil {
+		merged.CitationMetadata = resp.CitationMetadata
+	}
+	if resp.GroundingMetadata != nil {
+		merged.GroundingMetadata = resp.GroundingMetadata
+	}
+	if resp.UsageMetadata != nil {
+		merged.UsageMetadata = resp.UsageMetadata
+	}
+	if resp.LogprobsResult != nil {
+		merged.LogprobsResult = resp.LogprobsResult
+	}
+	if resp.AvgLogprobs != 0 {
+		merged.AvgLogprobs = resp.AvgLogprobs
+	}
+	if resp.Candidates != nil {
+		merged.Candidates = resp.Candidates
+	}
+	if len(resp.CustomMetadata) > 0 {
+		if merged.CustomMetadata == nil {
+			merged.CustomMetadata = map[string]any{}
+		}
+		maps.Copy(merged.CustomMetadata, resp.CustomMetadata)
+	}
+	if resp.ErrorCode != "" || resp.ErrorMessage != "" {
+		merged.ErrorCode, merged.ErrorMessage = resp.ErrorCode, resp.ErrorMessage
+	}
+	if resp.FinishReason != "" {
+		merged.FinishReason = resp.FinishReason
+	}
+	merged.TurnComplete = merged.TurnComplete || resp.TurnComplete
+	merged.Interrupted = merged.Interrupted || resp.Interrupted
+}
+
+// appendPart appends part to parts, concatenating its text to the text part
+// ending parts, and replacing the function call of the same ID, if any. The
+// parts are copied before being modified.
+func appendPart(parts []*genai.Part, part *genai.Part) []*genai.Part {
+	if part == nil {
+		return parts
+	}
+	if n := len(parts); n > 0 && isTextPart(parts[n-1]) && isTextPart(part) && parts[n-1].Thought == part.Thought {
+		text := *parts[n-1]
+		text.Text += part.Text
+		parts[n-1] = &text
+		return parts
+	}
+	if part.FunctionCall != nil && part.FunctionCall.ID != "" {
+		for i, p := range parts {
+			if p.FunctionCall != nil && p.FunctionCall.ID == part.FunctionCall.ID {
+				parts[i] = part
+				return parts
+			}
+		}
+	}
+	return append(parts, part)
+}
+
+func isTextPart(part *genai.Part) bool {
+	return part.Text != "" && part.FunctionCall == nil && part.FunctionResponse == nil && part.InlineData == nil && part.FileData == nil
+}
@@ -0,0 +1,173 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model_test
+
+import (
+	"errors"
+	"iter"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/model"
+)
+
+// sequence returns a sequence yielding responses, then err if not nil.
+func sequence(err error, responses ...*model.LLMResponse) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {
+		for _, resp := range responses {
+			if !yield(resp, nil) {
+				return
+			}
+		}
+		if err != nil {
+			yield(nil, err)
+		}
+	}
+}
+
+func partial(text string) *model.LLMResponse {
+	return &model.LLMResponse{Content: genai.NewContentFromText(text, genai.RoleModel), Partial: true}
+}
+
+func functionCall(id, name string, args map[string]any) *genai.Part {
+	return &genai.Part{FunctionCall: &genai.FunctionCall{ID: id, Name: name, Args: args}}
+}
+
+func TestCollect(t *testing.T) {
+	usage := &genai.GenerateContentResponseUsageMetadata{PromptTokenCount: 10, TotalTokenCount: 15}
+	testCases := []struct {
+		name string
+		seq  iter.Seq2[*model.LLMResponse, error]
+		want *model.LLMResponse
+	}{
+		{
+			name: "Unary",
+			seq:  sequence(nil, &model.LLMResponse{Content: genai.NewContentFromText("Hello", genai.RoleModel), UsageMetadata: usage, FinishReason: genai.FinishReasonStop}),
+			want: &model.LLMResponse{Content: genai.NewContentFromText("Hello", genai.RoleModel), UsageMetadata: usage, FinishReason: genai.FinishReasonStop},
+		},
+		{
+			name: "PartialsOnly",
+			seq: sequence(nil,
+				partial("Hel"),
+				partial("lo"),
+				&model.LLMResponse{UsageMetadata: usage, FinishReason: genai.FinishReasonStop, TurnComplete: true},
+			),
+			want: &model.LLMResponse{Content: genai.NewContentFromText("Hello", genai.RoleModel), UsageMetadata: usage, FinishReason: genai.FinishReasonStop, TurnComplete: true},
+		},
+		{
+			name: "PartialsThenComplete",
+			seq: sequence(nil,
+				partial("Hel"),
+				partial("lo"),
+				&model.LLMResponse{Content: genai.NewContentFromText("Hello", genai.RoleModel), UsageMetadata: usage, TurnComplete: true},
+			),
+			want: &model.LLMResponse{Content: genai.NewContentFromText("Hello", genai.RoleModel), UsageMetadata: usage, TurnComplete: true},
+		},
+		{
+			name: "FunctionCalls",
+			seq: sequence(nil,
+				&model.LLMResponse{Content: &genai.Content{Role: genai.RoleModel, Parts: []*genai.Part{
+					{Text: "Checking"},
+					functionCall("call_1", "get_weather", nil),
+				}}, Partial: true},
+				&model.LLMResponse{Content: &genai.Content{Role: genai.RoleModel, Parts: []*genai.Part{
+					functionCall("call_1", "get_weather", map[string]any{"city": "Paris"}),
+					functionCall("call_2", "get_time", nil),
+				}}, Partial: true, CustomMetadata: map[string]any{"k": "v"}},
+				&model.LLMResponse{FinishReason: genai.FinishReasonStop},
+			),
+			want: &model.LLMResponse{
+				Content: &genai.Content{Role: genai.RoleModel, Parts: []*genai.Part{
+					{Text: "Checking"},
+					functionCall("call_1", "get_weather", map[string]any{"city": "Paris"}),
+					functionCall("call_2", "get_time", nil),
+				}},
+				CustomMetadata: map[string]any{"k": "v"},
+				FinishReason:   genai.FinishReasonStop,
+			},
+		},
+		{
+			name: "Thoughts",
+			seq: sequence(nil,
+				&model.LLMResponse{Content: &genai.Content{Role: genai.RoleModel, Parts: []*genai.Part{{Text: "Let me ", Thought: true}}}, Partial: true},
+				&model.LLMResponse{Content: &genai.Content{Role: genai.RoleModel, Parts: []*genai.Part{{Text: "think.", Thought: true}}}, Partial: true},
+				partial("Done."),
+			),
+			want: &model.LLMResponse{Content: &genai.Content{Role: genai.RoleModel, Parts: []*genai.Part{
+				{Text: "Let me think.", Thought: true},
+				{Text: "Done."},
+			}}},
+		},
+		{
+			name: "Empty",
+			seq:  sequence(nil),
+			want: &model.LLMResponse{ErrorCode: string(model.ErrorCodeUnknown), ErrorMessage: "The model returned no response."},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := model.Collect(tc.seq)
+			if err != nil {
+				t.Fatalf("Collect() error = %v", err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Collect() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCollect_ErrorMidStream(t *testing.T) {
+	errStream := errors.New("connection reset")
+	got, err := model.Collect(sequence(errStream, partial("Hel"), partial("lo")))
+	if err != errStream {
+		t.Errorf("Collect() error = %v, want %v", err, errStream)
+	}
+	if diff := cmp.Diff(genai.NewContentFromText("Hello", genai.RoleModel), got.Content); diff != "" {
+		t.Errorf("Collect() content mismatch (-want +got):\n%s", diff)
+	}
+
+	if got, err := model.Collect(sequence(errStream)); err != errStream || got != nil {
+		t.Errorf("Collect() = %v, %v, want no response and %v", got, err, errStream)
+	}
+}
+
+func TestTeeCollect(t *testing.T) {
+	complete := &model.LLMResponse{Content: genai.NewContentFromText("Hello", genai.RoleModel), TurnComplete: true}
+	var forwarded []string
+	got, err := model.TeeCollect(sequence(nil, partial("Hel"), partial("lo"), complete), func(resp *model.LLMResponse) error {
+		forwarded = append(forwarded, resp.Content.Parts[0].Text)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"Hel", "lo"}, forwarded); diff != "" {
+		t.Errorf("forwarded mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(complete, got); diff != "" {
+		t.Errorf("TeeCollect() mismatch (-want +got):\n%s", diff)
+	}
+
+	errClosed := errors.New("client gone")
+	got, err = model.TeeCollect(sequence(nil, partial("Hel"), partial("lo")), func(resp *model.LLMResponse) error {
+		return errClosed
+	})
+	if err != errClosed || got.Content.Parts[0].Text != "Hel" {
+		t.Errorf("TeeCollect() = %v, %v, want the first partial response and %v", got, err, errClosed)
+	}
+}
@@ -0,0 +1,301 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package degrade retries the requests a model rejects as too complex with
+// progressively simpler versions of them.
+//
+// [New] wraps a [model.LLM]. When a call fails with one of the configured
+// error codes, by default [model.ErrorCodeRequestTooComplex] and
+// [model.ErrorCodeContextTooLong], the request is simplified by the next
+// step of the ladder and sent again:
+//
+//  1. [StepCompressDeclarations] compresses all the function declarations
+//     the way [describetool.Compress] does.
+//  2. [StepDropOptional] drops the demonstrations from the contents and the
+//     optional sections of the system instruction, as identified by
+//     [Config.Demonstration] and [Config.Optional].
+//  3. [StepRestrictTools] only declares the functions called in the
+//     contents of the request, and the meta tools listed in
+//     [Config.KeepTools].
+//
+// The steps that would not change the request are skipped. The step after
+// which the call succeeded is recorded in the custom metadata of the
+// responses under [MetadataKey], and each step is recorded in the assembly
+// trace of the request. When all the steps or [Config.MaxRetries] retries
+// are exhausted, the call fails with an [ExhaustedError].
+package degrade
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"maps"
+	"reflect"
+	"slices"
+
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/model/assembly"
+	"google.golang.org/adk/tool/describetool"
+)
+
+// Step is a step of the degradation ladder.
+type Step string
+
+const (
+	// StepCompressDeclarations compresses the function declarations.
+	StepCompressDeclarations Step = "compress_declarations"
+	// StepDropOptional drops the demonstrations and the optional sections of
+	// the system instruction.
+	StepDropOptional Step = "drop_optional"
+	// StepRestrictTools restricts the declared functions to the ones called
+	// in the contents and the meta tools.
+	StepRestrictTools Step = "restrict_tools"
+)
+
+// steps is the degradation ladder, in order.
+var steps = []Step{StepCompressDeclarations, StepDropOptional, StepRestrictTools}
+
+// MetadataKey is the key of the last step applied to the request in the
+// custom metadata of the responses of a degraded request.
+const MetadataKey = "adk_degradation_step"
+
+// Config configures a Degrader.
+type Config struct {
+	// Codes are the error codes triggering the degradation. Defaults to
+	// ErrorCodeRequestTooComplex and ErrorCodeContextTooLong.
+	Codes []model.ErrorCode
+	// MaxRetries caps the number of retries of a request. Defaults to 3,
+	// one per step.
+	MaxRetries int
+	// MaxDescriptionLength is the maximum length of the descriptions of the
+	// compressed declarations. Defaults to 100.
+	MaxDescriptionLength int
+	// Optional reports whether a text part of the system instruction is an
+	// optional section, dropped by StepDropOptional. If nil, the system
+	// instruction is kept.
+	Optional func(section string) bool
+	// Demonstration reports whether a content of the request is a
+	// demonstration, e.g. a few-shot example, dropped by StepDropOptional.
+	// If nil, the contents are kept.
+	Demonstration func(*genai.Content) bool
+	// KeepTools are the functions StepRestrictTools keeps declared even if
+	// they were not called. Defaults to describe_tool and transfer_to_agent.
+	KeepTools []string
+}
+
+// ExhaustedError is returned for a request still failing once all the
+// steps of the ladder or all the retries are exhausted.
+type ExhaustedError struct {
+	// Steps are the steps applied to the request, in order.
+	Steps []Step
+	// Err is the error of the last call.
+	Err error
+}
+
+// Error implements error.
+func (e *ExhaustedError) Error() string {
+	return fmt.Sprintf("request still failing after degradation steps %v: %v", e.Steps, e.Err)
+}
+
+// Unwrap returns the error of the last call.
+func (e *ExhaustedError) Unwrap() error {
+	return e.Err
+}
+
+// Degrader is a model retrying the requests rejected as too complex with
+// simpler versions of them.
+type Degrader struct {
+	llm model.LLM
+	cfg Config
+}
+
+var _ model.LLM = (*Degrader)(nil)
+
+// New returns a Degrader in front of llm.
+func New(llm model.LLM, cfg Config) *Degrader {
+	if cfg.Codes == nil {
+		cfg.Codes = []model.ErrorCode{model.ErrorCodeRequestTooComplex, model.ErrorCodeContextTooLong}
+	}
+	if cfg.MaxRetries <= 0 {
+		cfg.MaxRetries = len(steps)
+	}
+	if cfg.KeepTools == nil {
+		cfg.KeepTools = []string{describetool.Name, "transfer_to_agent"}
+	}
+	return &Degrader{llm: llm, cfg: cfg}
+}
+
+// Name implements model.LLM.
+func (d *Degrader) Name() string {
+	return d.llm.Name()
+}
+
+// GenerateContent implements model.LLM. A call failing with one of the
+// configured codes before any response is retried with the request
+// simplified by the next step of the ladder. req is modified in place.
+func (d *Degrader) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {
+		var applied []Step
+		next := 0
+		for {
+			var failure error
+			responded := false
+			for resp, err := range d.llm.GenerateContent(ctx, req, stream) {
+				if err != nil && !responded && d.triggers(err) {
+					failure = err
+					break
+				}
+				responded = true
+				if err == nil && resp != nil && len(applied) > 0 {
+					resp.CustomMetadata = maps.Clone(resp.CustomMetadata)
+					if resp.CustomMetadata == nil {
+						resp.CustomMetadata = make(map[string]any)
+					}
+					resp.CustomMetadata[MetadataKey] = applied[len(applied)-1]
+				}
+				if !yield(resp, err) {
+					return
+				}
+			}
+			if failure == nil {
+				return
+			}
+			if len(applied) >= d.cfg.MaxRetries || ctx.Err() != nil {
+				yield(nil, &ExhaustedError{Steps: applied, Err: failure})
+				return
+			}
+			step, ok := d.degrade(req, &next)
+			if !ok {
+				yield(nil, &ExhaustedError{Steps: applied, Err: failure})
+				return
+			}
+			applied = append(applied, step)
+		}
+	}
+}
+
+func (d *Degrader) triggers(err error) bool {
+	var modelErr *model.Error
+	return errors.As(err, &modelErr) && slices.Contains(d.cfg.Codes, modelErr.Code)
+}
+
+// degrade applies to req the first step from *next changing it, and
+// returns it. It returns false if no step changes req.
+func (d *Degrader) degrade(req *model.LLMRequest, next *int) (Step, bool) {
+	for *next < len(steps) {
+		step := steps[*next]
+		*next++
+		done := assembly.Step(req, "degrade", "degrade request", string(step))
+		changed := d.apply(step, req)
+		done()
+		if changed {
+			return step, true
+		}
+	}
+	return "", false
+}
+
+// apply applies step to req, and reports whether it changed req. The
+// slices of req are replaced rather than modified.
+func (d *Degrader) apply(step Step, req *model.LLMRequest) bool {
+	switch step {
+	case StepCompressDeclarations:
+		return mapDeclarations(req, func(decls []*genai.FunctionDeclaration) []*genai.FunctionDeclaration {
+			compressed := make([]*genai.FunctionDeclaration, len(decls))
+			for i, decl := range decls {
+				compressed[i] = describetool.CompressDeclaration(decl, d.cfg.MaxDescriptionLength)
+			}
+			return compressed
+		})
+	case StepDropOptional:
+		changed := false
+		if d.cfg.Demonstration != nil {
+			contents := slices.DeleteFunc(slices.Clone(req.Contents), d.cfg.Demonstration)
+			changed = len(contents) != len(req.Contents)
+			req.Contents = contents
+		}
+		if d.cfg.Optional != nil && req.Config != nil && req.Config.SystemInstruction != nil {
+			instruction := *req.Config.SystemInstruction
+			instruction.Parts = slices.DeleteFunc(slices.Clone(instruction.Parts), func(p *genai.Part) bool {
+				return p != nil && p.Text != "" && d.cfg.Optional(p.Text)
+			})
+			if len(instruction.Parts) != len(req.Config.SystemInstruction.Parts) {
+				req.Config.SystemInstruction = &instruction
+				changed = true
+			}
+		}
+		return changed
+	case StepRestrictTools:
+		used := make(map[string]bool)
+		for _, name := range d.cfg.KeepTools {
+			used[name] = true
+		}
+		for _, content := range req.Contents {
+			if content == nil {
+				continue
+			}
+			for _, p := range content.Parts {
+				if p != nil && p.FunctionCall != nil {
+					used[p.FunctionCall.Name] = true
+				}
+			}
+		}
+		return mapDeclarations(req, func(decls []*genai.FunctionDeclaration) []*genai.FunctionDeclaration {
+			return slices.DeleteFunc(slices.Clone(decls), func(decl *genai.FunctionDeclaration) bool {
+				return decl != nil && !used[decl.Name]
+			})
+		})
+	default:
+		return false
+	}
+}
+
+// mapDeclarations replaces the function declarations of the tools of req by
+// their mapping with f, and reports whether they changed. The tools left
+// without declaration nor other capability are removed.
+func mapDeclarations(req *model.LLMRequest, f func([]*genai.FunctionDeclaration) []*genai.FunctionDeclaration) bool {
+	if req.Config == nil {
+		return false
+	}
+	changed := false
+	tools := make([]*genai.Tool, 0, len(req.Config.Tools))
+	for _, t := range req.Config.Tools {
+		if t == nil || len(t.FunctionDeclarations) == 0 {
+			tools = append(tools, t)
+			continue
+		}
+		mapped := f(t.FunctionDeclarations)
+		if reflect.DeepEqual(mapped, t.FunctionDeclarations) {
+			tools = append(tools, t)
+			continue
+		}
+		changed = true
+		c := *t
+		c.FunctionDeclarations = mapped
+		if len(mapped) == 0 {
+			c.FunctionDeclarations = nil
+			if reflect.DeepEqual(c, genai.Tool{}) {
+				continue
+			}
+		}
+		tools = append(tools, &c)
+	}
+	if changed {
+		req.Config.Tools = tools
+	}
+	return changed
+}
@@ -0,0 +1,228 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package degrade_test
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/model/assembly"
+	"google.golang.org/adk/model/degrade"
+)
+
+// attempt summarizes a request received by the scripted model.
+type attempt struct {
+	Tools        []string
+	Description  string
+	Contents     int
+	Instructions int
+}
+
+// scriptedModel rejects the requests as too complex until accept returns
+// true, and records them.
+type scriptedModel struct {
+	accept   func(*model.LLMRequest) bool
+	attempts []attempt
+}
+
+func (m *scriptedModel) Name() string {
+	return "scripted"
+}
+
+func (m *scriptedModel) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {
+		a := attempt{Contents: len(req.Contents), Instructions: len(req.Config.SystemInstruction.Parts)}
+		for _, t := range req.Config.Tools {
+			for _, decl := range t.FunctionDeclarations {
+				a.Tools = append(a.Tools, decl.Name)
+				if a.Description == "" {
+					a.Description = decl.Description
+				}
+			}
+		}
+		m.attempts = append(m.attempts, a)
+		if !m.accept(req) {
+			yield(nil, &model.Error{Code: model.ErrorCodeRequestTooComplex, Err: errors.New("too many tools")})
+			return
+		}
+		yield(&model.LLMResponse{Content: genai.NewContentFromText("ok", genai.RoleModel)}, nil)
+	}
+}
+
+func newRequest() *model.LLMRequest {
+	long := strings.Repeat("x", 300)
+	return &model.LLMRequest{
+		Contents: []*genai.Content{
+			genai.NewContentFromText("example: what is 2+2?", genai.RoleUser),
+			genai.NewContentFromText("search the docs", genai.RoleUser),
+			genai.NewContentFromFunctionCall("search", map[string]any{"q": "docs"}, genai.RoleModel),
+		},
+		Config: &genai.GenerateContentConfig{
+			SystemInstruction: genai.NewContentFromParts([]*genai.Part{
+				genai.NewPartFromText("You are helpful."),
+				genai.NewPartFromText("Optional: be concise."),
+			}, genai.RoleUser),
+			Tools: []*genai.Tool{{FunctionDeclarations: []*genai.FunctionDeclaration{
+				{Name: "search", Description: long},
+				{Name: "translate", Description: long},
+				{Name: "describe_tool", Description: "describes tools"},
+			}}},
+		},
+	}
+}
+
+func newDegrader(llm model.LLM, maxRetries int) *degrade.Degrader {
+	return degrade.New(llm, degrade.Config{
+		MaxRetries: maxRetries,
+		Optional:   func(section string) bool { return strings.HasPrefix(section, "Optional:") },
+		Demonstration: func(c *genai.Content) bool {
+			return len(c.Parts) > 0 && strings.HasPrefix(c.Parts[0].Text, "example:")
+		},
+	})
+}
+
+func TestDegrader_Ladder(t *testing.T) {
+	// Only accepts the requests declaring fewer than 3 tools.
+	backend := &scriptedModel{accept: func(req *model.LLMRequest) bool {
+		return len(req.Config.Tools[0].FunctionDeclarations) < 3
+	}}
+	rec := &assembly.Recorder{}
+	ctx := assembly.NewContext(context.Background(), rec)
+	req := newRequest()
+	_, endTrace := assembly.Start(ctx, req)
+	defer endTrace()
+
+	var got []*model.LLMResponse
+	for resp, err := range newDegrader(backend, 0).GenerateContent(ctx, req, false) {
+		if err != nil {
+			t.Fatalf("GenerateContent() failed: %v", err)
+		}
+		got = append(got, resp)
+	}
+
+	compressed := strings.Repeat("x", 99) + "…"
+	want := []attempt{
+		{Tools: []string{"search", "translate", "describe_tool"}, Description: strings.Repeat("x", 300), Contents: 3, Instructions: 2},
+		{Tools: []string{"search", "translate", "describe_tool"}, Description: compressed, Contents: 3, Instructions: 2},
+		{Tools: []string{"search", "translate", "describe_tool"}, Description: compressed, Contents: 2, Instructions: 1},
+		{Tools: []string{"search", "describe_tool"}, Description: compressed, Contents: 2, Instructions: 1},
+	}
+	if diff := cmp.Diff(want, backend.attempts); diff != "" {
+		t.Errorf("attempts mismatch (-want +got):\n%s", diff)
+	}
+	if len(got) != 1 || got[0].CustomMetadata[degrade.MetadataKey] != degrade.StepRestrictTools {
+		t.Errorf("responses = %v, want one response degraded by %q", got, degrade.StepRestrictTools)
+	}
+
+	var steps []string
+	for _, e := range rec.Traces()[0].Entries() {
+		if e.Component == "degrade" {
+			steps = append(steps, e.Detail)
+		}
+	}
+	if diff := cmp.Diff([]string{"compress_declarations", "drop_optional", "restrict_tools"}, steps); diff != "" {
+		t.Errorf("traced steps mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestDegrader_SkipsNoopSteps(t *testing.T) {
+	calls := 0
+	backend := &scriptedModel{accept: func(*model.LLMRequest) bool {
+		calls++
+		return calls > 1
+	}}
+	req := newRequest()
+	// Nothing is optional: the second step is skipped if the first fails.
+	llm := degrade.New(backend, degrade.Config{})
+	for resp, err := range llm.GenerateContent(context.Background(), req, false) {
+		if err != nil {
+			t.Fatalf("GenerateContent() failed: %v", err)
+		}
+		if got := resp.CustomMetadata[degrade.MetadataKey]; got != degrade.StepCompressDeclarations {
+			t.Errorf("degradation step = %v, want %q", got, degrade.StepCompressDeclarations)
+		}
+	}
+
+	// The request is already compressed: restricting the tools is next.
+	calls = 0
+	backend.attempts = nil
+	for resp, err := range llm.GenerateContent(context.Background(), req, false) {
+		if err != nil {
+			t.Fatalf("GenerateContent() failed: %v", err)
+		}
+		if got := resp.CustomMetadata[degrade.MetadataKey]; got != degrade.StepRestrictTools {
+			t.Errorf("degradation step = %v, want %q", got, degrade.StepRestrictTools)
+		}
+	}
+	if len(backend.attempts) != 2 {
+		t.Errorf("got %d attempts, want 2", len(backend.attempts))
+	}
+}
+
+func TestDegrader_Exhausted(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		maxRetries int
+		wantSteps  []degrade.Step
+	}{
+		{"AllSteps", 0, []degrade.Step{degrade.StepCompressDeclarations, degrade.StepDropOptional, degrade.StepRestrictTools}},
+		{"MaxRetries", 1, []degrade.Step{degrade.StepCompressDeclarations}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			backend := &scriptedModel{accept: func(*model.LLMRequest) bool { return false }}
+			var gotErr error
+			for _, err := range newDegrader(backend, tc.maxRetries).GenerateContent(context.Background(), newRequest(), false) {
+				gotErr = err
+			}
+			var exhausted *degrade.ExhaustedError
+			if !errors.As(gotErr, &exhausted) {
+				t.Fatalf("GenerateContent() error = %v, want an ExhaustedError", gotErr)
+			}
+			if diff := cmp.Diff(tc.wantSteps, exhausted.Steps); diff != "" {
+				t.Errorf("steps mismatch (-want +got):\n%s", diff)
+			}
+			if got := model.CodeOf(gotErr); got != model.ErrorCodeRequestTooComplex {
+				t.Errorf("CodeOf() = %q, want %q", got, model.ErrorCodeRequestTooComplex)
+			}
+			if got, want := len(backend.attempts), len(tc.wantSteps)+1; got != want {
+				t.Errorf("got %d attempts, want %d", got, want)
+			}
+		})
+	}
+}
+
+func TestDegrader_OtherErrors(t *testing.T) {
+	backend := &scriptedModel{accept: func(*model.LLMRequest) bool { return false }}
+	llm := degrade.New(backend, degrade.Config{Codes: []model.ErrorCode{model.ErrorCodeContextTooLong}})
+	for _, err := range llm.GenerateContent(context.Background(), newRequest(), false) {
+		if model.CodeOf(err) != model.ErrorCodeRequestTooComplex {
+			t.Errorf("GenerateContent() error = %v, want the error of the model", err)
+		}
+		var exhausted *degrade.ExhaustedError
+		if errors.As(err, &exhausted) {
+			t.Errorf("GenerateContent() error = %v, want no degradation", err)
+		}
+	}
+	if len(backend.attempts) != 1 {
+		t.Errorf("got %d attempts, want 1", len(backend.attempts))
+	}
+}
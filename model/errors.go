@@ -0,0 +1,177 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrorCode classifies the failures of the models, the same way for all the
+// providers. The adapters report it, converted to a string, in
+// [LLMResponse.ErrorCode] for the responses carrying an error, and in an
+// [Error] for the calls that fail.
+type ErrorCode string
+
+const (
+	// ErrorCodeRateLimited means that too many requests were sent in a short
+	// time. Retrying after a delay is expected to succeed.
+	ErrorCodeRateLimited ErrorCode = "RATE_LIMITED"
+	// ErrorCodeQuotaExceeded means that a quota or a billing limit is
+	// exhausted. Retrying does not help until the quota is reset or raised.
+	ErrorCodeQuotaExceeded ErrorCode = "QUOTA_EXCEEDED"
+	// ErrorCodeBudgetExceeded means that the call was not sent because it
+	// would exceed the token or call budget of the invocation, see
+	// [WithBudget]. Retrying does not help within the same invocation.
+	ErrorCodeBudgetExceeded ErrorCode = "BUDGET_EXCEEDED"
+	// ErrorCodeContextTooLong means that the request does not fit in the
+	// context window of the model. The request must be shortened.
+	ErrorCodeContextTooLong ErrorCode = "CONTEXT_TOO_LONG"
+	// ErrorCodeRequestTooComplex means that the request declares too many
+	// functions or schemas too large for the provider. Declaring fewer or
+	// smaller tools is expected to succeed.
+	ErrorCodeRequestTooComplex ErrorCode = "REQUEST_TOO_COMPLEX"
+	// ErrorCodeSafetyBlocked means that the request or the response was
+	// blocked by the safety or content filters of the provider.
+	ErrorCodeSafetyBlocked ErrorCode = "SAFETY_BLOCKED"
+	// ErrorCodeRefusal means that the model declined to answer, e.g. for
+	// safety reasons. The explanation of the model is the error message.
+	ErrorCodeRefusal ErrorCode = "REFUSAL"
+	// ErrorCodeInvalidRequest means that the provider rejected the request,
+	// e.g. because of an unsupported parameter or an unknown model.
+	ErrorCodeInvalidRequest ErrorCode = "INVALID_REQUEST"
+	// ErrorCodeAuthFailed means that the credentials are missing, invalid or
+	// not allowed to use the model.
+	ErrorCodeAuthFailed ErrorCode = "AUTH_FAILED"
+	// ErrorCodeUnavailable means that the provider failed or could not be
+	// reached. Retrying after a delay may succeed.
+	ErrorCodeUnavailable ErrorCode = "UNAVAILABLE"
+	// ErrorCodeUnknown is used for the failures that cannot be classified.
+	ErrorCodeUnknown ErrorCode = "UNKNOWN"
+)
+
+// IsRetryable reports whether the failures with the given code are
+// transient, so that the same request may succeed if retried later.
+func IsRetryable(code ErrorCode) bool {
+	switch code {
+	case ErrorCodeRateLimited, ErrorCodeUnavailable:
+		return true
+	default:
+		return false
+	}
+}
+
+// userMessages holds the messages of UserMessageFor by language.
+var userMessages = map[string]map[ErrorCode]string{
+	"en": {
+		ErrorCodeRateLimited:       "The service is busy right now. Please try again in a moment.",
+		ErrorCodeQuotaExceeded:     "The service has reached its usage limit. Please try again later.",
+		ErrorCodeBudgetExceeded:    "This request used up its processing budget. Please try a simpler request.",
+		ErrorCodeContextTooLong:    "The conversation is too long. Please start a new conversation or shorten your message.",
+		ErrorCodeRequestTooComplex: "This request uses too many tools to be processed. Please try a simpler request.",
+		ErrorCodeSafetyBlocked:     "This request could not be completed because it was blocked by the content policy.",
+		ErrorCodeRefusal:           "The assistant declined to answer this request.",
+		ErrorCodeInvalidRequest:    "This request could not be processed.",
+		ErrorCodeAuthFailed:        "The service is not configured correctly. Please contact the administrator.",
+		ErrorCodeUnavailable:       "The service is temporarily unavailable. Please try again in a moment.",
+		ErrorCodeUnknown:           "Something went wrong. Please try again.",
+	},
+	"fr": {
+		ErrorCodeRateLimited:       "Le service est très sollicité. Veuillez réessayer dans un instant.",
+		ErrorCodeQuotaExceeded:     "Le service a atteint sa limite d'utilisation. Veuillez réessayer plus tard.",
+		ErrorCodeBudgetExceeded:    "Cette demande a épuisé son budget de traitement. Veuillez essayer une demande plus simple.",
+		ErrorCodeContextTooLong:    "La conversation est trop longue. Veuillez commencer une nouvelle conversation ou raccourcir votre message.",
+		ErrorCodeRequestTooComplex: "Cette demande utilise trop d'outils pour être traitée. Veuillez essayer une demande plus simple.",
+		ErrorCodeSafetyBlocked:     "Cette demande n'a pas pu aboutir car elle a été bloquée par la politique de contenu.",
+		ErrorCodeRefusal:           "L'assistant a refusé de répondre à cette demande.",
+		ErrorCodeInvalidRequest:    "Cette demande n'a pas pu être traitée.",
+		ErrorCodeAuthFailed:        "Le service n'est pas configuré correctement. Veuillez contacter l'administrateur.",
+		ErrorCodeUnavailable:       "Le service est temporairement indisponible. Veuillez réessayer dans un instant.",
+		ErrorCodeUnknown:           "Une erreur s'est produite. Veuillez réessayer.",
+	},
+	"es": {
+		ErrorCodeRateLimited:       "El servicio está ocupado en este momento. Inténtalo de nuevo en un momento.",
+		ErrorCodeQuotaExceeded:     "El servicio ha alcanzado su límite de uso. Inténtalo de nuevo más tarde.",
+		ErrorCodeBudgetExceeded:    "Esta solicitud ha agotado su presupuesto de procesamiento. Prueba con una solicitud más sencilla.",
+		ErrorCodeContextTooLong:    "La conversación es demasiado larga. Inicia una nueva conversación o acorta tu mensaje.",
+		ErrorCodeRequestTooComplex: "Esta solicitud usa demasiadas herramientas para poder procesarse. Prueba con una solicitud más sencilla.",
+		ErrorCodeSafetyBlocked:     "No se pudo completar esta solicitud porque la bloqueó la política de contenido.",
+		ErrorCodeRefusal:           "El asistente se ha negado a responder a esta solicitud.",
+		ErrorCodeInvalidRequest:    "No se pudo procesar esta solicitud.",
+		ErrorCodeAuthFailed:        "El servicio no está configurado correctamente. Ponte en contacto con el administrador.",
+		ErrorCodeUnavailable:       "El servicio no está disponible temporalmente. Inténtalo de nuevo en un momento.",
+		ErrorCodeUnknown:           "Se ha producido un error. Inténtalo de nuevo.",
+	},
+	"de": {
+		ErrorCodeRateLimited:       "Der Dienst ist gerade ausgelastet. Bitte versuchen Sie es gleich noch einmal.",
+		ErrorCodeQuotaExceeded:     "Der Dienst hat sein Nutzungslimit erreicht. Bitte versuchen Sie es später noch einmal.",
+		ErrorCodeBudgetExceeded:    "Diese Anfrage hat ihr Verarbeitungsbudget aufgebraucht. Bitte versuchen Sie eine einfachere Anfrage.",
+		ErrorCodeContextTooLong:    "Die Unterhaltung ist zu lang. Bitte beginnen Sie eine neue Unterhaltung oder kürzen Sie Ihre Nachricht.",
+		ErrorCodeRequestTooComplex: "Diese Anfrage verwendet zu viele Werkzeuge, um verarbeitet zu werden. Bitte versuchen Sie eine einfachere Anfrage.",
+		ErrorCodeSafetyBlocked:     "Diese Anfrage konnte nicht ausgeführt werden, da sie durch die Inhaltsrichtlinie blockiert wurde.",
+		ErrorCodeRefusal:           "Der Assistent hat die Beantwortung dieser Anfrage abgelehnt.",
+		ErrorCodeInvalidRequest:    "Diese Anfrage konnte nicht verarbeitet werden.",
+		ErrorCodeAuthFailed:        "Der Dienst ist nicht richtig konfiguriert. Bitte wenden Sie sich an den Administrator.",
+		ErrorCodeUnavailable:       "Der Dienst ist vorübergehend nicht verfügbar. Bitte versuchen Sie es gleich noch einmal.",
+		ErrorCodeUnknown:           "Etwas ist schiefgelaufen. Bitte versuchen Sie es noch einmal.",
+	},
+}
+
+// UserMessageFor returns a message explaining a failure with the given code
+// to an end user, in the language of locale, e.g. "fr" or "fr-CA". Messages
+// are available in English, French, Spanish and German; other locales get
+// the English message. Unknown codes get the message of ErrorCodeUnknown.
+func UserMessageFor(code ErrorCode, locale string) string {
+	lang, _, _ := strings.Cut(strings.ReplaceAll(locale, "_", "-"), "-")
+	messages, ok := userMessages[strings.ToLower(lang)]
+	if !ok {
+		messages = userMessages["en"]
+	}
+	if msg, ok := messages[code]; ok {
+		return msg
+	}
+	return messages[ErrorCodeUnknown]
+}
+
+// Error is the error returned by the adapters for a failed model call,
+// classified by its code.
+type Error struct {
+	Code ErrorCode
+	// Err is the error returned by the provider.
+	Err error
+}
+
+// Error implements error.
+func (e *Error) Error() string {
+	return string(e.Code) + ": " + e.Err.Error()
+}
+
+// Unwrap returns the error returned by the provider.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// CodeOf returns the code of the first [Error] in the chain of err. It
+// returns ErrorCodeUnknown if there is none, and an empty code if err is nil.
+func CodeOf(err error) ErrorCode {
+	if err == nil {
+		return ""
+	}
+	var modelErr *Error
+	if errors.As(err, &modelErr) {
+		return modelErr.Code
+	}
+	return ErrorCodeUnknown
+}
@@ -0,0 +1,95 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"google.golang.org/adk/model"
+)
+
+func TestIsRetryable(t *testing.T) {
+	for code, want := range map[model.ErrorCode]bool{
+		model.ErrorCodeRateLimited:       true,
+		model.ErrorCodeUnavailable:       true,
+		model.ErrorCodeQuotaExceeded:     false,
+		model.ErrorCodeBudgetExceeded:    false,
+		model.ErrorCodeContextTooLong:    false,
+		model.ErrorCodeRequestTooComplex: false,
+		model.ErrorCodeSafetyBlocked:     false,
+		model.ErrorCodeRefusal:           false,
+		model.ErrorCodeInvalidRequest:    false,
+		model.ErrorCodeAuthFailed:        false,
+		model.ErrorCodeUnknown:           false,
+	} {
+		if got := model.IsRetryable(code); got != want {
+			t.Errorf("IsRetryable(%q) = %v, want %v", code, got, want)
+		}
+	}
+}
+
+func TestUserMessageFor(t *testing.T) {
+	testCases := []struct {
+		code   model.ErrorCode
+		locale string
+		want   string
+	}{
+		{model.ErrorCodeRateLimited, "en", "The service is busy right now. Please try again in a moment."},
+		{model.ErrorCodeRateLimited, "fr-CA", "Le service est très sollicité. Veuillez réessayer dans un instant."},
+		{model.ErrorCodeContextTooLong, "de_DE", "Die Unterhaltung ist zu lang. Bitte beginnen Sie eine neue Unterhaltung oder kürzen Sie Ihre Nachricht."},
+		{model.ErrorCodeAuthFailed, "ES", "El servicio no está configurado correctamente. Ponte en contacto con el administrador."},
+		{model.ErrorCodeSafetyBlocked, "ja-JP", "This request could not be completed because it was blocked by the content policy."},
+		{model.ErrorCodeRefusal, "fr", "L'assistant a refusé de répondre à cette demande."},
+		{model.ErrorCodeBudgetExceeded, "de", "Diese Anfrage hat ihr Verarbeitungsbudget aufgebraucht. Bitte versuchen Sie eine einfachere Anfrage."},
+		{model.ErrorCodeUnavailable, "", "The service is temporarily unavailable. Please try again in a moment."},
+		{"SOMETHING_ELSE", "fr", "Une erreur s'est produite. Veuillez réessayer."},
+	}
+	for _, tc := range testCases {
+		if got := model.UserMessageFor(tc.code, tc.locale); got != tc.want {
+			t.Errorf("UserMessageFor(%q, %q) = %q, want %q", tc.code, tc.locale, got, tc.want)
+		}
+	}
+}
+
+func TestCodeOf(t *testing.T) {
+	cause := errors.New("too many requests")
+	modelErr := &model.Error{Code: model.ErrorCodeRateLimited, Err: cause}
+
+	testCases := []struct {
+		name string
+		err  error
+		want model.ErrorCode
+	}{
+		{"Nil", nil, ""},
+		{"ModelError", modelErr, model.ErrorCodeRateLimited},
+		{"Wrapped", fmt.Errorf("calling model: %w", modelErr), model.ErrorCodeRateLimited},
+		{"Other", cause, model.ErrorCodeUnknown},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := model.CodeOf(tc.err); got != tc.want {
+				t.Errorf("CodeOf() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+	if !errors.Is(modelErr, cause) {
+		t.Error("errors.Is(modelErr, cause) = false, want true")
+	}
+	if got, want := modelErr.Error(), "RATE_LIMITED: too many requests"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,201 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"maps"
+	"sync"
+	"time"
+
+	"google.golang.org/adk/runtimedeps"
+)
+
+// FallbackModelMetadataKey is the key of the custom metadata of the
+// responses of a model created by [WithFallback] holding the name of the
+// model which served them.
+const FallbackModelMetadataKey = "adk_fallback_model"
+
+// ErrAllModelsSkipped is the error of the calls of a model created by
+// [WithFallback] whose models all have their circuit open.
+var ErrAllModelsSkipped = errors.New("all the models are skipped after failing repeatedly")
+
+// FallbackConfig configures [WithFallbackConfig].
+type FallbackConfig struct {
+	// ShouldFallback reports whether a call failing with err is sent to the
+	// next model. Defaults to IsRetryableError: the rate limits and the
+	// unavailable models.
+	ShouldFallback func(err error) bool
+	// FirstResponseTimeout limits the wait for the first response of a
+	// model, after which the call is sent to the next model. Optional.
+	FirstResponseTimeout time.Duration
+	// FailureThreshold is the number of consecutive calls failing over from
+	// a model after which its circuit opens: it is skipped for Cooldown.
+	// Defaults to 5; negative disables the circuit breaker.
+	FailureThreshold int
+	// Cooldown is how long a model whose circuit opened is skipped.
+	// Defaults to 30 seconds.
+	Cooldown time.Duration
+}
+
+func (c FallbackConfig) withDefaults() FallbackConfig {
+	if c.ShouldFallback == nil {
+		c.ShouldFallback = IsRetryableError
+	}
+	if c.FailureThreshold == 0 {
+		c.FailureThreshold = 5
+	}
+	if c.Cooldown <= 0 {
+		c.Cooldown = 30 * time.Second
+	}
+	return c
+}
+
+// WithFallback returns a model calling primary, and the secondaries in
+// order when the previous model fails, using the default FallbackConfig.
+// See [WithFallbackConfig].
+func WithFallback(primary LLM, secondaries ...LLM) LLM {
+	return WithFallbackConfig(FallbackConfig{}, primary, secondaries...)
+}
+
+// WithFallbackConfig returns a model sending the calls to primary, and to
+// the secondaries in order when the previous model fails with an error
+// selected by cfg.ShouldFallback, e.g. so that an outage of a provider
+// degrades to another one. The Model of the request is set to the name of
+// each model called. A call is only sent to the next model if it failed
+// before yielding any response: a stream failing midway fails. The
+// responses carry the name of the model which served them in their custom
+// metadata under [FallbackModelMetadataKey]. A call failing with all the
+// models fails with the error of the last one.
+//
+// The models failing cfg.FailureThreshold calls in a row are skipped for
+// cfg.Cooldown, told by the clock of [runtimedeps.FromContext].
+func WithFallbackConfig(cfg FallbackConfig, primary LLM, secondaries ...LLM) LLM {
+	m := &fallbackModel{cfg: cfg.withDefaults()}
+	for _, llm := range append([]LLM{primary}, secondaries...) {
+		m.backends = append(m.backends, &fallbackBackend{llm: llm})
+	}
+	return m
+}
+
+type fallbackModel struct {
+	cfg      FallbackConfig
+	backends []*fallbackBackend
+}
+
+// fallbackBackend is a model of the chain, with its circuit breaker.
+type fallbackBackend struct {
+	llm LLM
+
+	mu sync.Mutex
+	// failures is the number of consecutive calls which failed over.
+	failures int
+	// openUntil is when the circuit closes, if it is open.
+	openUntil time.Time
+}
+
+// available reports whether the circuit of b is closed at now.
+func (b *fallbackBackend) available(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return !now.Before(b.openUntil)
+}
+
+// record records the outcome of a call of b at now, failed if it failed
+// over.
+func (b *fallbackBackend) record(failed bool, now time.Time, cfg FallbackConfig) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !failed {
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if cfg.FailureThreshold > 0 && b.failures >= cfg.FailureThreshold {
+		b.openUntil = now.Add(cfg.Cooldown)
+		b.failures = 0
+	}
+}
+
+// Name implements LLM. It is the name of the primary model.
+func (m *fallbackModel) Name() string {
+	return m.backends[0].llm.Name()
+}
+
+// GenerateContent implements LLM.
+func (m *fallbackModel) GenerateContent(ctx context.Context, req *LLMRequest, stream bool) iter.Seq2[*LLMResponse, error] {
+	return func(yield func(*LLMResponse, error) bool) {
+		deps := runtimedeps.FromContext(ctx)
+		var last error
+		for _, b := range m.backends {
+			if !b.available(deps.Now()) {
+				continue
+			}
+			err := m.call(ctx, b, req, stream, yield)
+			b.record(err != nil, deps.Now(), m.cfg)
+			if err == nil {
+				return
+			}
+			last = fmt.Errorf("model %q failed: %w", b.llm.Name(), err)
+			if ctx.Err() != nil {
+				break
+			}
+		}
+		if last == nil {
+			last = &Error{Code: ErrorCodeUnavailable, Err: ErrAllModelsSkipped}
+		}
+		yield(nil, last)
+	}
+}
+
+// call sends req to the model of b, and yields its responses. It returns
+// the error of the call if it failed before yielding any response with an
+// error to fail over; the error is then not yielded.
+func (m *fallbackModel) call(ctx context.Context, b *fallbackBackend, req *LLMRequest, stream bool, yield func(*LLMResponse, error) bool) error {
+	callCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var timer *time.Timer
+	if m.cfg.FirstResponseTimeout > 0 {
+		timer = time.AfterFunc(m.cfg.FirstResponseTimeout, cancel)
+		defer timer.Stop()
+	}
+
+	r := *req
+	r.Model = b.llm.Name()
+	yielded := false
+	for resp, err := range b.llm.GenerateContent(callCtx, &r, stream) {
+		if !yielded && timer != nil {
+			timer.Stop()
+		}
+		if err != nil && !yielded && ctx.Err() == nil && (callCtx.Err() != nil || m.cfg.ShouldFallback(err)) {
+			return err
+		}
+		yielded = true
+		if resp != nil {
+			resp.CustomMetadata = maps.Clone(resp.CustomMetadata)
+			if resp.CustomMetadata == nil {
+				resp.CustomMetadata = make(map[string]any)
+			}
+			resp.CustomMetadata[FallbackModelMetadataKey] = b.llm.Name()
+		}
+		if !yield(resp, err) {
+			return nil
+		}
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"google.golang.org/adk/model"
+)
+
+// served returns the texts of the responses of llm, the models which served
+// them, and its error.
+func served(ctx context.Context, llm model.LLM) (texts []string, models []any, err error) {
+	for resp, err := range llm.GenerateContent(ctx, &model.LLMRequest{Model: "gpt-4o"}, true) {
+		if err != nil {
+			return texts, models, err
+		}
+		texts = append(texts, resp.Content.Parts[0].Text)
+		models = append(models, resp.CustomMetadata[model.FallbackModelMetadataKey])
+	}
+	return texts, models, nil
+}
+
+func TestWithFallback_PrimaryFailsFast(t *testing.T) {
+	ctx, _ := frozenContext(t)
+	primary := &scriptedLLM{name: "gpt-4o", calls: []scriptedCall{{err: errUnavailable}}}
+	secondary := &scriptedLLM{name: "gemini-2.5-flash", calls: []scriptedCall{{texts: []string{"hi"}}}}
+
+	texts, models, err := served(ctx, model.WithFallback(primary, secondary))
+	if err != nil {
+		t.Fatalf("GenerateContent() failed: %v", err)
+	}
+	if len(texts) != 1 || texts[0] != "hi" || models[0] != "gemini-2.5-flash" {
+		t.Errorf("responses = %q served by %v, want hi served by gemini-2.5-flash", texts, models)
+	}
+	if len(secondary.models) != 1 || secondary.models[0] != "gemini-2.5-flash" {
+		t.Errorf("secondary called for models %q, want the request rewritten for gemini-2.5-flash", secondary.models)
+	}
+}
+
+func TestWithFallback_PrimaryFailsSlow(t *testing.T) {
+	primary := &scriptedLLM{name: "gpt-4o", calls: []scriptedCall{{block: true}}}
+	secondary := &scriptedLLM{name: "gemini-2.5-flash", calls: []scriptedCall{{texts: []string{"hi"}}}}
+	llm := model.WithFallbackConfig(model.FallbackConfig{FirstResponseTimeout: 10 * time.Millisecond}, primary, secondary)
+
+	texts, models, err := served(t.Context(), llm)
+	if err != nil {
+		t.Fatalf("GenerateContent() failed: %v", err)
+	}
+	if len(texts) != 1 || models[0] != "gemini-2.5-flash" {
+		t.Errorf("responses = %q served by %v, want a response of gemini-2.5-flash", texts, models)
+	}
+}
+
+func TestWithFallback_NoFallback(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		primary scriptedCall
+		wantErr error
+	}{
+		{name: "PartialStream", primary: scriptedCall{texts: []string{"hel"}, err: errUnavailable}, wantErr: errUnavailable},
+		{name: "InvalidRequest", primary: scriptedCall{err: errInvalid}, wantErr: errInvalid},
+		{name: "Success", primary: scriptedCall{texts: []string{"hello"}}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, _ := frozenContext(t)
+			primary := &scriptedLLM{name: "gpt-4o", calls: []scriptedCall{tc.primary}}
+			secondary := &scriptedLLM{name: "gemini-2.5-flash", calls: []scriptedCall{{texts: []string{"unexpected"}}}}
+			texts, models, err := served(ctx, model.WithFallback(primary, secondary))
+			if err != tc.wantErr {
+				t.Errorf("GenerateContent() error = %v, want %v", err, tc.wantErr)
+			}
+			if secondary.n != 0 {
+				t.Errorf("secondary called %d times, want 0", secondary.n)
+			}
+			for _, m := range models {
+				if m != "gpt-4o" {
+					t.Errorf("response %q served by %v, want gpt-4o", texts, m)
+				}
+			}
+		})
+	}
+}
+
+func TestWithFallback_AllFail(t *testing.T) {
+	ctx, _ := frozenContext(t)
+	primary := &scriptedLLM{name: "gpt-4o", calls: []scriptedCall{{err: errUnavailable}}}
+	secondary := &scriptedLLM{name: "gemini-2.5-flash", calls: []scriptedCall{{err: &model.Error{Code: model.ErrorCodeRateLimited, Err: errors.New("slow down")}}}}
+	_, _, err := served(ctx, model.WithFallback(primary, secondary))
+	if got := model.CodeOf(err); got != model.ErrorCodeRateLimited {
+		t.Errorf("GenerateContent() error = %v, want the error of the last model", err)
+	}
+}
+
+func TestWithFallback_CircuitBreaker(t *testing.T) {
+	ctx, clock := frozenContext(t)
+	primary := &scriptedLLM{name: "gpt-4o", calls: []scriptedCall{{err: errUnavailable}, {err: errUnavailable}, {texts: []string{"back"}}}}
+	secondary := &scriptedLLM{name: "gemini-2.5-flash", calls: []scriptedCall{{texts: []string{"hi"}}}}
+	llm := model.WithFallbackConfig(model.FallbackConfig{FailureThreshold: 2, Cooldown: time.Minute}, primary, secondary)
+
+	for range 2 {
+		if _, _, err := served(ctx, llm); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The circuit of the primary is open.
+	_, models, err := served(ctx, llm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if primary.n != 2 || models[0] != "gemini-2.5-flash" {
+		t.Errorf("primary called %d times, response served by %v, want the primary skipped after 2 failures", primary.n, models[0])
+	}
+
+	clock.Advance(time.Minute)
+	_, models, err = served(ctx, llm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if models[0] != "gpt-4o" {
+		t.Errorf("response served by %v after the cooldown, want gpt-4o", models[0])
+	}
+}
+
+func TestWithFallback_AllSkipped(t *testing.T) {
+	ctx, _ := frozenContext(t)
+	primary := &scriptedLLM{name: "gpt-4o", calls: []scriptedCall{{err: errUnavailable}}}
+	llm := model.WithFallbackConfig(model.FallbackConfig{FailureThreshold: 1}, primary)
+	if _, _, err := served(ctx, llm); !errors.Is(err, errUnavailable) {
+		t.Fatalf("first call error = %v, want %v", err, errUnavailable)
+	}
+	if _, _, err := served(ctx, llm); !errors.Is(err, model.ErrAllModelsSkipped) {
+		t.Errorf("second call error = %v, want %v", err, model.ErrAllModelsSkipped)
+	}
+}
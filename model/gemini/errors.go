@@ -0,0 +1,124 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gemini
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/model"
+)
+
+// ErrorCode classifies an error returned by the Gemini API client.
+func ErrorCode(err error) model.ErrorCode {
+	var apiErr genai.APIError
+	if errors.As(err, &apiErr) {
+		return apiErrorCode(apiErr)
+	}
+	var apiErrPtr *genai.APIError
+	if errors.As(err, &apiErrPtr) {
+		return apiErrorCode(*apiErrPtr)
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return model.ErrorCodeUnavailable
+	}
+	return model.ErrorCodeUnknown
+}
+
+func apiErrorCode(err genai.APIError) model.ErrorCode {
+	switch {
+	case err.Code == http.StatusTooManyRequests || err.Status == "RESOURCE_EXHAUSTED":
+		if isQuotaFailure(err) {
+			return model.ErrorCodeQuotaExceeded
+		}
+		return model.ErrorCodeRateLimited
+	case err.Code == http.StatusUnauthorized || err.Code == http.StatusForbidden || err.Status == "UNAUTHENTICATED" || err.Status == "PERMISSION_DENIED":
+		return model.ErrorCodeAuthFailed
+	case err.Code >= 500:
+		return model.ErrorCodeUnavailable
+	case err.Code >= 400:
+		// The API reports context overflows as invalid arguments: only the
+		// message tells them apart.
+		msg := strings.ToLower(err.Message)
+		if strings.Contains(msg, "token count") || strings.Contains(msg, "exceeds the maximum number of tokens") {
+			return model.ErrorCodeContextTooLong
+		}
+		if strings.Contains(msg, "too many states") || strings.Contains(msg, "too many function declarations") || strings.Contains(msg, "too many tools") {
+			return model.ErrorCodeRequestTooComplex
+		}
+		return model.ErrorCodeInvalidRequest
+	default:
+		return model.ErrorCodeUnknown
+	}
+}
+
+// isQuotaFailure reports whether err is caused by the exhaustion of a daily
+// or billing quota, rather than of a per-minute rate limit.
+func isQuotaFailure(err genai.APIError) bool {
+	for _, detail := range err.Details {
+		if detail["@type"] != "type.googleapis.com/google.rpc.QuotaFailure" {
+			continue
+		}
+		violations, _ := detail["violations"].([]any)
+		for _, v := range violations {
+			violation, _ := v.(map[string]any)
+			if id := fmt.Sprint(violation["quotaId"]); strings.Contains(id, "PerDay") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ResponseErrorCode classifies the error code of a response converted from
+// the Gemini API, i.e. its finish reason. The responses to a blocked prompt
+// are all classified as ErrorCodeSafetyBlocked, whatever the block reason.
+func ResponseErrorCode(code string) model.ErrorCode {
+	switch code {
+	case "":
+		return ""
+	case string(genai.FinishReasonSafety), string(genai.FinishReasonRecitation), string(genai.FinishReasonBlocklist),
+		string(genai.FinishReasonProhibitedContent), string(genai.FinishReasonSPII), string(genai.FinishReasonImageSafety),
+		string(genai.FinishReasonImageProhibitedContent), string(genai.FinishReasonImageRecitation),
+		string(genai.BlockedReasonJailbreak), string(genai.BlockedReasonModelArmor):
+		return model.ErrorCodeSafetyBlocked
+	default:
+		return model.ErrorCodeUnknown
+	}
+}
+
+// classifyResponse replaces the Gemini error code of resp by its
+// classification. The finish reason keeps the details: the finish reason of
+// the candidate, or the reason the prompt was blocked.
+func classifyResponse(resp *model.LLMResponse) *model.LLMResponse {
+	if resp == nil || resp.ErrorCode == "" {
+		return resp
+	}
+	if resp.FinishReason == "" && resp.ErrorCode != string(model.ErrorCodeUnknown) {
+		// The responses converted from a candidate have its finish reason:
+		// the prompt was blocked, and the code is the block reason.
+		resp.FinishReason = genai.FinishReason(resp.ErrorCode)
+		resp.ErrorCode = string(model.ErrorCodeSafetyBlocked)
+		return resp
+	}
+	resp.ErrorCode = string(ResponseErrorCode(resp.ErrorCode))
+	return resp
+}
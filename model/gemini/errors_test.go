@@ -0,0 +1,114 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gemini_test
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/model/gemini"
+)
+
+func TestErrorCode(t *testing.T) {
+	quotaFailure := func(quotaID string) []map[string]any {
+		return []map[string]any{{
+			"@type":      "type.googleapis.com/google.rpc.QuotaFailure",
+			"violations": []any{map[string]any{"quotaId": quotaID}},
+		}}
+	}
+
+	testCases := []struct {
+		name string
+		err  error
+		want model.ErrorCode
+	}{
+		{
+			name: "RateLimited",
+			err:  genai.APIError{Code: 429, Status: "RESOURCE_EXHAUSTED", Details: quotaFailure("GenerateRequestsPerMinutePerProjectPerModel")},
+			want: model.ErrorCodeRateLimited,
+		},
+		{
+			name: "QuotaExceeded",
+			err:  genai.APIError{Code: 429, Status: "RESOURCE_EXHAUSTED", Details: quotaFailure("GenerateRequestsPerDayPerProjectPerModel")},
+			want: model.ErrorCodeQuotaExceeded,
+		},
+		{
+			name: "ContextTooLong",
+			err:  genai.APIError{Code: 400, Status: "INVALID_ARGUMENT", Message: "The input token count (1200000) exceeds the maximum number of tokens allowed (1048576)."},
+			want: model.ErrorCodeContextTooLong,
+		},
+		{
+			name: "RequestTooComplex",
+			err:  genai.APIError{Code: 400, Status: "INVALID_ARGUMENT", Message: "The specified schema produces a constraint that has too many states for serving."},
+			want: model.ErrorCodeRequestTooComplex,
+		},
+		{
+			name: "InvalidRequest",
+			err:  genai.APIError{Code: 400, Status: "INVALID_ARGUMENT", Message: "Invalid JSON payload received."},
+			want: model.ErrorCodeInvalidRequest,
+		},
+		{
+			name: "AuthFailed",
+			err:  genai.APIError{Code: 403, Status: "PERMISSION_DENIED", Message: "API key not valid."},
+			want: model.ErrorCodeAuthFailed,
+		},
+		{
+			name: "Unavailable",
+			err:  genai.APIError{Code: 503, Status: "UNAVAILABLE", Message: "The model is overloaded."},
+			want: model.ErrorCodeUnavailable,
+		},
+		{
+			name: "WrappedPointer",
+			err:  fmt.Errorf("generate: %w", &genai.APIError{Code: 500, Status: "INTERNAL"}),
+			want: model.ErrorCodeUnavailable,
+		},
+		{
+			name: "Network",
+			err:  &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")},
+			want: model.ErrorCodeUnavailable,
+		},
+		{
+			name: "Other",
+			err:  errors.New("boom"),
+			want: model.ErrorCodeUnknown,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := gemini.ErrorCode(tc.err); got != tc.want {
+				t.Errorf("ErrorCode() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestResponseErrorCode(t *testing.T) {
+	for code, want := range map[string]model.ErrorCode{
+		"":                                   "",
+		string(genai.FinishReasonSafety):     model.ErrorCodeSafetyBlocked,
+		string(genai.FinishReasonRecitation): model.ErrorCodeSafetyBlocked,
+		string(genai.BlockedReasonJailbreak): model.ErrorCodeSafetyBlocked,
+		string(genai.FinishReasonMalformedFunctionCall): model.ErrorCodeUnknown,
+	} {
+		if got := gemini.ResponseErrorCode(code); got != want {
+			t.Errorf("ResponseErrorCode(%q) = %q, want %q", code, got, want)
+		}
+	}
+}
@@ -17,6 +17,7 @@ package gemini
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"iter"
 	"net/http"
@@ -62,6 +63,23 @@ func NewModel(ctx context.Context, modelName string, cfg *genai.ClientConfig) (m
 	}, nil
 }
 
+// ModelPattern is the pattern of the names of the Gemini models, registered
+// by [RegisterDefaults].
+const ModelPattern = `^gemini-`
+
+// RegisterDefaults registers the Gemini models for [model.Resolve], created
+// with cfg. A nil cfg reads the API key or the Vertex AI project and
+// location from the environment.
+func RegisterDefaults(cfg *genai.ClientConfig) {
+	model.Register(ModelPattern, func(ctx context.Context, name string) (model.LLM, error) {
+		cfg := cfg
+		if cfg == nil {
+			cfg = &genai.ClientConfig{}
+		}
+		return NewModel(ctx, name, cfg)
+	})
+}
+
 func (m *geminiModel) Name() string {
 	return m.name
 }
@@ -100,13 +118,13 @@ func (m *geminiModel) addHeaders(headers http.Header) {
 func (m *geminiModel) generate(ctx context.Context, req *model.LLMRequest) (*model.LLMResponse, error) {
 	resp, err := m.client.Models.GenerateContent(ctx, m.name, req.Contents, req.Config)
 	if err != nil {
-		return nil, fmt.Errorf("failed to call model: %w", err)
+		return nil, fmt.Errorf("failed to call model: %w", &model.Error{Code: ErrorCode(err), Err: err})
 	}
-	if len(resp.Candidates) == 0 {
+	if len(resp.Candidates) == 0 && resp.PromptFeedback == nil {
 		// shouldn't happen?
-		return nil, fmt.Errorf("empty response")
+		return nil, &model.Error{Code: model.ErrorCodeUnknown, Err: errors.New("empty response")}
 	}
-	return converters.Genai2LLMResponse(resp), nil
+	return classifyResponse(converters.Genai2LLMResponse(resp)), nil
 }
 
 // generateStream returns a stream of responses from the model.
@@ -116,17 +134,17 @@ func (m *geminiModel) generateStream(ctx context.Context, req *model.LLMRequest)
 	return func(yield func(*model.LLMResponse, error) bool) {
 		for resp, err := range m.client.Models.GenerateContentStream(ctx, m.name, req.Contents, req.Config) {
 			if err != nil {
-				yield(nil, err)
+				yield(nil, &model.Error{Code: ErrorCode(err), Err: err})
 				return
 			}
 			for llmResponse, err := range aggregator.ProcessResponse(ctx, resp) {
-				if !yield(llmResponse, err) {
+				if !yield(classifyResponse(llmResponse), err) {
 					return // Consumer stopped
 				}
 			}
 		}
 		if closeResult := aggregator.Close(); closeResult != nil {
-			yield(closeResult, nil)
+			yield(classifyResponse(closeResult), nil)
 		}
 	}
 }
@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"iter"
 	"net/http"
+	"net/http/httptest"
 	"path/filepath"
 	"strings"
 	"testing"
@@ -188,6 +189,56 @@ func TestModel_TrackingHeaders(t *testing.T) {
 	})
 }
 
+func TestModel_BlockedPrompt(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		blockReason genai.BlockedReason
+	}{
+		{"Safety", genai.BlockedReasonSafety},
+		{"Other", genai.BlockedReasonOther},
+	} {
+		for _, stream := range []bool{false, true} {
+			t.Run(fmt.Sprintf("%s/stream=%v", tc.name, stream), func(t *testing.T) {
+				srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					body := fmt.Sprintf(`{"promptFeedback": {"blockReason": %q, "blockReasonMessage": "blocked"}}`, tc.blockReason)
+					if stream {
+						w.Header().Set("Content-Type", "text/event-stream")
+						fmt.Fprintf(w, "data: %s\n\n", body)
+						return
+					}
+					w.Header().Set("Content-Type", "application/json")
+					fmt.Fprint(w, body)
+				}))
+				defer srv.Close()
+				llm, err := NewModel(t.Context(), "gemini-2.0-flash", &genai.ClientConfig{
+					APIKey:      "fakekey",
+					Backend:     genai.BackendGeminiAPI,
+					HTTPOptions: genai.HTTPOptions{BaseURL: srv.URL},
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				var got *model.LLMResponse
+				for resp, err := range llm.GenerateContent(t.Context(), &model.LLMRequest{Contents: genai.Text("hello")}, stream) {
+					if err != nil {
+						t.Fatal(err)
+					}
+					got = resp
+				}
+				want := &model.LLMResponse{
+					ErrorCode:    string(model.ErrorCodeSafetyBlocked),
+					ErrorMessage: "blocked",
+					FinishReason: genai.FinishReason(tc.blockReason),
+				}
+				if diff := cmp.Diff(want, got, cmpopts.IgnoreFields(model.LLMResponse{}, "TurnComplete")); diff != "" {
+					t.Errorf("GenerateContent() mismatch (-want +got):\n%s", diff)
+				}
+			})
+		}
+	}
+}
+
 // newGeminiTestClientConfig returns the genai.ClientConfig configured for record and replay.
 func newGeminiTestClientConfig(t *testing.T, rrfile string) *genai.ClientConfig {
 	t.Helper()
@@ -28,6 +28,14 @@ type LLM interface {
 	GenerateContent(ctx context.Context, req *LLMRequest, stream bool) iter.Seq2[*LLMResponse, error]
 }
 
+// HealthChecker is implemented by the models, and other dependencies of the
+// agents, that can check whether their backend is reachable, e.g. for the
+// readiness probes of a deployment.
+type HealthChecker interface {
+	// HealthCheck returns an error if the backend cannot serve requests.
+	HealthCheck(ctx context.Context) error
+}
+
 // LLMRequest is the raw LLM request.
 type LLMRequest struct {
 	Model    string
@@ -37,6 +45,12 @@ type LLMRequest struct {
 	Tools map[string]any `json:"-"`
 }
 
+// ResponseIDMetadataKey is the key of the custom metadata of the responses
+// holding the ID given to them by the provider, e.g. to be quoted in a
+// support request. It is set by the adapters of the providers identifying
+// their responses.
+const ResponseIDMetadataKey = "adk_response_id"
+
 // LLMResponse is the raw LLM response.
 // It provides the first candidate response from the model if available.
 type LLMResponse struct {
@@ -54,9 +68,18 @@ type LLMResponse struct {
 	TurnComplete bool
 	// Flag indicating that LLM was interrupted when generating the content.
 	// Usually it is due to user interruption during a bidi streaming.
-	Interrupted  bool
+	Interrupted bool
+	// ErrorCode is the code of the error of a response carrying one, one of
+	// the [ErrorCode] constants. The FinishReason keeps the details, e.g. the
+	// finish or block reason of a Gemini response.
 	ErrorCode    string
 	ErrorMessage string
 	FinishReason genai.FinishReason
 	AvgLogprobs  float64
+	// Candidates are all the candidates returned by the model when it
+	// returned several of them, e.g. because of a CandidateCount above 1 in
+	// the request, ordered by their index. The other fields of the response
+	// are those of the first candidate. Nil if the model returned a single
+	// candidate.
+	Candidates []*genai.Candidate
 }
@@ -0,0 +1,341 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package modeltest provides a scripted fake model for the tests of agents,
+// tools and callbacks, and a server standing for a provider API for the
+// tests of the model adapters, see [NewServer].
+//
+// The replies of a [Fake] are scripted in order, and its requests checked
+// once the agent ran:
+//
+//	llm := modeltest.NewFake(t).
+//		ReplyFunctionCall("get_weather", map[string]any{"city": "Paris"}).
+//		ReplyText("It is sunny in Paris.")
+//	// Run an agent using llm...
+//	llm.Request(0).HasTool("get_weather").ContainsText("weather in Paris")
+//	llm.Request(1).HasFunctionResponse("get_weather")
+//
+// The test fails if the agent calls the model more often than scripted, or
+// if scripted replies are left when it ends.
+package modeltest
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/model"
+)
+
+// Fake is a model replying to its calls with scripted replies, in order,
+// and recording their requests. It is safe for concurrent use.
+type Fake struct {
+	t    testing.TB
+	name string
+
+	mu       sync.Mutex
+	replies  []reply
+	requests []*model.LLMRequest
+}
+
+// reply is the reply to a call: the partial responses streamed, if any, and
+// the final response or the error.
+type reply struct {
+	partials []*model.LLMResponse
+	final    *model.LLMResponse
+	err      error
+}
+
+var _ model.LLM = (*Fake)(nil)
+
+// NewFake returns a fake model with no reply scripted. The test fails when
+// it ends if scripted replies were not consumed.
+func NewFake(t testing.TB) *Fake {
+	t.Helper()
+	f := &Fake{t: t, name: "fake"}
+	t.Cleanup(func() {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		if len(f.replies) > 0 {
+			t.Errorf("modeltest: %d scripted replies of model %q not consumed", len(f.replies), f.name)
+		}
+	})
+	return f
+}
+
+// WithName sets the name of the model, "fake" by default.
+func (f *Fake) WithName(name string) *Fake {
+	f.name = name
+	return f
+}
+
+func (f *Fake) add(r reply) *Fake {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.replies = append(f.replies, r)
+	return f
+}
+
+// Reply scripts a reply with resp.
+func (f *Fake) Reply(resp *model.LLMResponse) *Fake {
+	return f.add(reply{final: resp})
+}
+
+// ReplyText scripts a reply with text.
+func (f *Fake) ReplyText(text string) *Fake {
+	return f.Reply(&model.LLMResponse{Content: genai.NewContentFromText(text, genai.RoleModel), TurnComplete: true, FinishReason: genai.FinishReasonStop})
+}
+
+// ReplyFunctionCall scripts a reply calling the function name with args.
+func (f *Fake) ReplyFunctionCall(name string, args map[string]any) *Fake {
+	return f.Reply(&model.LLMResponse{Content: genai.NewContentFromFunctionCall(name, args, genai.RoleModel), TurnComplete: true, FinishReason: genai.FinishReasonStop})
+}
+
+// ReplyError scripts a call failing with err.
+func (f *Fake) ReplyError(err error) *Fake {
+	return f.add(reply{err: err})
+}
+
+// ReplyStream scripts a reply with the text of chunks. A streamed call
+// yields a partial response per chunk, then the complete text; a call not
+// streamed only returns the complete text.
+func (f *Fake) ReplyStream(chunks ...string) *Fake {
+	r := reply{final: &model.LLMResponse{
+		Content:      genai.NewContentFromText(strings.Join(chunks, ""), genai.RoleModel),
+		TurnComplete: true,
+		FinishReason: genai.FinishReasonStop,
+	}}
+	for _, chunk := range chunks {
+		r.partials = append(r.partials, &model.LLMResponse{Content: genai.NewContentFromText(chunk, genai.RoleModel), Partial: true})
+	}
+	return f.add(r)
+}
+
+// Name implements model.LLM.
+func (f *Fake) Name() string {
+	return f.name
+}
+
+// GenerateContent implements model.LLM. A call with no reply left fails,
+// and fails the test.
+func (f *Fake) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	f.mu.Lock()
+	f.requests = append(f.requests, req)
+	var r reply
+	exhausted := len(f.replies) == 0
+	if !exhausted {
+		r = f.replies[0]
+		f.replies = f.replies[1:]
+	}
+	n := len(f.requests)
+	f.mu.Unlock()
+
+	return func(yield func(*model.LLMResponse, error) bool) {
+		if exhausted {
+			f.t.Errorf("modeltest: model %q called %d times, more than scripted", f.name, n)
+			yield(nil, &model.Error{Code: model.ErrorCodeUnavailable, Err: errExhausted})
+			return
+		}
+		if stream {
+			for _, resp := range r.partials {
+				if !yield(clone(resp), nil) {
+					return
+				}
+			}
+		}
+		if r.err != nil {
+			yield(nil, r.err)
+			return
+		}
+		yield(clone(r.final), nil)
+	}
+}
+
+// clone returns a copy of resp, so that the caller modifying it, e.g. to
+// set the IDs of the function calls, does not modify the script.
+func clone(resp *model.LLMResponse) *model.LLMResponse {
+	c := *resp
+	if resp.Content != nil {
+		content := *resp.Content
+		content.Parts = make([]*genai.Part, len(resp.Content.Parts))
+		for i, part := range resp.Content.Parts {
+			if part == nil {
+				continue
+			}
+			p := *part
+			if part.FunctionCall != nil {
+				call := *part.FunctionCall
+				p.FunctionCall = &call
+			}
+			content.Parts[i] = &p
+		}
+		c.Content = &content
+	}
+	return &c
+}
+
+var errExhausted = errors.New("modeltest: no scripted reply left")
+
+// Requests returns the requests received, in order.
+func (f *Fake) Requests() []*model.LLMRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return slices.Clone(f.requests)
+}
+
+// Request returns the checks of the i-th request received, from 0. The test
+// fails if there is no such request.
+func (f *Fake) Request(i int) *RequestCheck {
+	f.t.Helper()
+	requests := f.Requests()
+	if i < 0 || i >= len(requests) {
+		f.t.Fatalf("modeltest: model %q received %d requests, no request %d", f.name, len(requests), i)
+	}
+	return &RequestCheck{t: f.t, index: i, req: requests[i]}
+}
+
+// RequestCheck checks a request received by a [Fake]. Its methods fail the
+// test if the request does not pass the check, and return the check so
+// that they can be chained.
+type RequestCheck struct {
+	t     testing.TB
+	index int
+	req   *model.LLMRequest
+}
+
+// LLMRequest returns the request checked, for the other checks.
+func (c *RequestCheck) LLMRequest() *model.LLMRequest {
+	return c.req
+}
+
+// ContainsText checks that a text part of the contents contains text.
+func (c *RequestCheck) ContainsText(text string) *RequestCheck {
+	c.t.Helper()
+	for _, content := range c.req.Contents {
+		for _, part := range partsOf(content) {
+			if strings.Contains(part.Text, text) {
+				return c
+			}
+		}
+	}
+	c.t.Errorf("modeltest: request %d: no text of the contents contains %q", c.index, text)
+	return c
+}
+
+// SystemInstructionContains checks that the system instruction contains
+// text.
+func (c *RequestCheck) SystemInstructionContains(text string) *RequestCheck {
+	c.t.Helper()
+	if c.req.Config != nil {
+		for _, part := range partsOf(c.req.Config.SystemInstruction) {
+			if strings.Contains(part.Text, text) {
+				return c
+			}
+		}
+	}
+	c.t.Errorf("modeltest: request %d: the system instruction does not contain %q", c.index, text)
+	return c
+}
+
+// HasTool checks that the function name is declared.
+func (c *RequestCheck) HasTool(name string) *RequestCheck {
+	c.t.Helper()
+	if !slices.Contains(c.functions(), name) {
+		c.t.Errorf("modeltest: request %d: function %q not declared, declared: %q", c.index, name, c.functions())
+	}
+	return c
+}
+
+// HasNoTools checks that no function is declared.
+func (c *RequestCheck) HasNoTools() *RequestCheck {
+	c.t.Helper()
+	if functions := c.functions(); len(functions) > 0 {
+		c.t.Errorf("modeltest: request %d: functions %q declared, want none", c.index, functions)
+	}
+	return c
+}
+
+// HasFunctionResponse checks that the contents carry a response of the
+// function name.
+func (c *RequestCheck) HasFunctionResponse(name string) *RequestCheck {
+	c.t.Helper()
+	for _, content := range c.req.Contents {
+		for _, part := range partsOf(content) {
+			if part.FunctionResponse != nil && part.FunctionResponse.Name == name {
+				return c
+			}
+		}
+	}
+	c.t.Errorf("modeltest: request %d: no response of function %q in the contents", c.index, name)
+	return c
+}
+
+// Config checks that check reports true for the config of the request,
+// which is never nil, described by what in the failure.
+func (c *RequestCheck) Config(what string, check func(*genai.GenerateContentConfig) bool) *RequestCheck {
+	c.t.Helper()
+	cfg := c.req.Config
+	if cfg == nil {
+		cfg = &genai.GenerateContentConfig{}
+	}
+	if !check(cfg) {
+		c.t.Errorf("modeltest: request %d: config check %q failed", c.index, what)
+	}
+	return c
+}
+
+// Temperature checks that the temperature of the request is want.
+func (c *RequestCheck) Temperature(want float32) *RequestCheck {
+	c.t.Helper()
+	if c.req.Config == nil || c.req.Config.Temperature == nil || *c.req.Config.Temperature != want {
+		var got any = "unset"
+		if c.req.Config != nil && c.req.Config.Temperature != nil {
+			got = *c.req.Config.Temperature
+		}
+		c.t.Errorf("modeltest: request %d: temperature = %v, want %v", c.index, got, want)
+	}
+	return c
+}
+
+// functions returns the names of the functions declared.
+func (c *RequestCheck) functions() []string {
+	var names []string
+	if c.req.Config == nil {
+		return names
+	}
+	for _, tool := range c.req.Config.Tools {
+		if tool == nil {
+			continue
+		}
+		for _, decl := range tool.FunctionDeclarations {
+			if decl != nil {
+				names = append(names, decl.Name)
+			}
+		}
+	}
+	return names
+}
+
+func partsOf(content *genai.Content) []*genai.Part {
+	if content == nil {
+		return nil
+	}
+	return slices.DeleteFunc(slices.Clone(content.Parts), func(p *genai.Part) bool { return p == nil })
+}
@@ -0,0 +1,173 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package modeltest_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/model/modeltest"
+	"google.golang.org/adk/runner"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+)
+
+func TestFake_Agent(t *testing.T) {
+	llm := modeltest.NewFake(t).
+		ReplyFunctionCall("get_weather", map[string]any{"city": "Paris"}).
+		ReplyText("It is sunny in Paris.")
+	getWeather, err := functiontool.New(functiontool.Config{Name: "get_weather", Description: "returns the weather of a city"}, func(ctx tool.Context, args struct{ City string }) (map[string]any, error) {
+		return map[string]any{"weather": "sunny"}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := llmagent.New(llmagent.Config{Name: "agent", Model: llm, Instruction: "Answer about the weather.", Tools: []tool.Tool{getWeather}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	service := session.InMemoryService()
+	created, err := service.Create(t.Context(), &session.CreateRequest{AppName: "app", UserID: "user"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := runner.New(runner.Config{AppName: "app", Agent: a, SessionService: service})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var last string
+	for ev, err := range r.Run(t.Context(), "user", created.Session.ID(), genai.NewContentFromText("What is the weather in Paris?", genai.RoleUser), agent.RunConfig{}) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ev.Content != nil && len(ev.Content.Parts) > 0 {
+			last = ev.Content.Parts[0].Text
+		}
+	}
+
+	if last != "It is sunny in Paris." {
+		t.Errorf("last text = %q, want the scripted answer", last)
+	}
+	llm.Request(0).HasTool("get_weather").ContainsText("weather in Paris").SystemInstructionContains("Answer about the weather.")
+	llm.Request(1).HasFunctionResponse("get_weather")
+}
+
+func TestFake_Stream(t *testing.T) {
+	llm := modeltest.NewFake(t).ReplyStream("Hel", "lo").ReplyStream("Hel", "lo")
+
+	var streamed []string
+	for resp, err := range llm.GenerateContent(t.Context(), &model.LLMRequest{}, true) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		streamed = append(streamed, fmt.Sprintf("%s partial=%t", resp.Content.Parts[0].Text, resp.Partial))
+	}
+	if got, want := strings.Join(streamed, ", "), "Hel partial=true, lo partial=true, Hello partial=false"; got != want {
+		t.Errorf("streamed = %s, want %s", got, want)
+	}
+
+	var unary []string
+	for resp, err := range llm.GenerateContent(t.Context(), &model.LLMRequest{}, false) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		unary = append(unary, resp.Content.Parts[0].Text)
+	}
+	if len(unary) != 1 || unary[0] != "Hello" {
+		t.Errorf("unary = %q, want the complete text only", unary)
+	}
+}
+
+func TestFake_Error(t *testing.T) {
+	errScripted := errors.New("boom")
+	llm := modeltest.NewFake(t).ReplyError(errScripted)
+	for _, err := range llm.GenerateContent(t.Context(), &model.LLMRequest{}, false) {
+		if err != errScripted {
+			t.Errorf("GenerateContent() error = %v, want %v", err, errScripted)
+		}
+	}
+}
+
+// recordingTB records the failures of a test instead of failing it.
+type recordingTB struct {
+	testing.TB
+	errors   []string
+	cleanups []func()
+}
+
+func (r *recordingTB) Helper() {}
+
+func (r *recordingTB) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func (r *recordingTB) Cleanup(f func()) {
+	r.cleanups = append(r.cleanups, f)
+}
+
+func TestFake_Failures(t *testing.T) {
+	t.Run("Unconsumed", func(t *testing.T) {
+		tb := &recordingTB{TB: t}
+		modeltest.NewFake(tb).ReplyText("never sent")
+		for _, f := range tb.cleanups {
+			f()
+		}
+		if len(tb.errors) != 1 || !strings.Contains(tb.errors[0], "1 scripted replies") {
+			t.Errorf("errors = %q, want the unconsumed reply reported", tb.errors)
+		}
+	})
+	t.Run("Exhausted", func(t *testing.T) {
+		tb := &recordingTB{TB: t}
+		llm := modeltest.NewFake(tb)
+		for _, err := range llm.GenerateContent(t.Context(), &model.LLMRequest{}, false) {
+			if err == nil {
+				t.Error("GenerateContent() succeeded, want an error with no reply scripted")
+			}
+		}
+		if len(tb.errors) != 1 || !strings.Contains(tb.errors[0], "more than scripted") {
+			t.Errorf("errors = %q, want the exhausted script reported", tb.errors)
+		}
+	})
+	t.Run("Checks", func(t *testing.T) {
+		tb := &recordingTB{TB: t}
+		llm := modeltest.NewFake(tb).ReplyText("ok")
+		req := &model.LLMRequest{
+			Contents: []*genai.Content{genai.NewContentFromText("hi", genai.RoleUser)},
+			Config:   &genai.GenerateContentConfig{Temperature: genai.Ptr[float32](0.5)},
+		}
+		for range llm.GenerateContent(t.Context(), req, false) {
+		}
+		llm.Request(0).
+			ContainsText("hi").
+			Temperature(0.5).
+			HasNoTools().
+			Config("candidate count unset", func(c *genai.GenerateContentConfig) bool { return c.CandidateCount == 0 })
+		if len(tb.errors) != 0 {
+			t.Fatalf("errors = %q, want none for the passing checks", tb.errors)
+		}
+		llm.Request(0).ContainsText("bye").HasTool("get_weather").Temperature(0)
+		if len(tb.errors) != 3 {
+			t.Errorf("errors = %q, want 3 for the failing checks", tb.errors)
+		}
+	})
+}
@@ -0,0 +1,41 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package modeltest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// NewServer returns a server standing for the API of a provider, for the
+// tests of the model adapters. It checks that the requests are sent to
+// path, escaped, decodes their JSON body into got, then answers them with
+// handle. The server is closed when the test ends.
+func NewServer(t testing.TB, path string, got *map[string]any, handle http.HandlerFunc) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.EscapedPath() != path {
+			t.Errorf("modeltest: request path = %q, want %q", r.URL.EscapedPath(), path)
+		}
+		if err := json.NewDecoder(r.Body).Decode(got); err != nil {
+			t.Errorf("modeltest: failed to decode the request body: %v", err)
+		}
+		handle(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
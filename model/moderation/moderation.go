@@ -0,0 +1,187 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package moderation runs user input through a moderation classifier before
+// it reaches a model.
+//
+// [Wrap] returns a [model.LLM] that checks the request with a [Moderator]
+// and answers blocked requests with a refusal without calling the wrapped
+// model.
+package moderation
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"maps"
+
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/model"
+)
+
+// Moderator classifies contents.
+type Moderator interface {
+	Check(ctx context.Context, contents []*genai.Content) (Result, error)
+}
+
+// Decision is the outcome of a moderation check.
+type Decision string
+
+const (
+	// DecisionAllow lets the request through.
+	DecisionAllow Decision = "allow"
+	// DecisionFlag lets the request through, but marks it as suspicious.
+	DecisionFlag Decision = "flag"
+	// DecisionBlock stops the request.
+	DecisionBlock Decision = "block"
+)
+
+// Result is the result of a moderation check.
+type Result struct {
+	Decision Decision
+	// Scores maps every category to its score, between 0 and 1.
+	Scores map[string]float64
+	// Categories lists the categories that caused the decision.
+	Categories []string
+}
+
+// MetadataKey is the LLMResponse.CustomMetadata key holding the moderation
+// result of the request.
+const MetadataKey = "moderation"
+
+// Config configures [Wrap].
+type Config struct {
+	// CheckHistory makes the moderator check all the contents of the request.
+	// Otherwise only the newest user content is checked, and requests that do
+	// not end with user input, e.g. the ones sending function responses, are
+	// not checked at all.
+	CheckHistory bool
+	// Refusal is the response returned when a request is blocked.
+	// Optional: if nil, DefaultRefusal() is used.
+	Refusal *model.LLMResponse
+	// FailOpen lets requests through when the moderator fails. Otherwise the
+	// error of the moderator is returned and the model is not called.
+	FailOpen bool
+}
+
+// DefaultRefusal returns the response used for blocked requests when
+// Config.Refusal is not set.
+func DefaultRefusal() *model.LLMResponse {
+	return &model.LLMResponse{
+		Content:      genai.NewContentFromText("I can't help with that request.", genai.RoleModel),
+		FinishReason: genai.FinishReasonSafety,
+		TurnComplete: true,
+	}
+}
+
+// Wrap returns a [model.LLM] that runs the request contents through m before
+// calling llm.
+//
+// Blocked requests are answered with the configured refusal. When a request
+// is allowed or flagged, the responses of llm are annotated with the result
+// under the [MetadataKey] key of LLMResponse.CustomMetadata.
+func Wrap(llm model.LLM, m Moderator, cfg Config) model.LLM {
+	return &moderatedModel{llm: llm, moderator: m, cfg: cfg}
+}
+
+type moderatedModel struct {
+	llm       model.LLM
+	moderator Moderator
+	cfg       Config
+}
+
+func (m *moderatedModel) Name() string {
+	return m.llm.Name()
+}
+
+func (m *moderatedModel) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {
+		contents := m.contentsToCheck(req)
+		if len(contents) == 0 {
+			for resp, err := range m.llm.GenerateContent(ctx, req, stream) {
+				if !yield(resp, err) {
+					return
+				}
+			}
+			return
+		}
+
+		var metadata map[string]any
+		result, err := m.moderator.Check(ctx, contents)
+		switch {
+		case err != nil && !m.cfg.FailOpen:
+			yield(nil, fmt.Errorf("moderation check failed: %w", err))
+			return
+		case err != nil:
+			metadata = map[string]any{"error": err.Error()}
+		case result.Decision == DecisionBlock:
+			refusal := m.cfg.Refusal
+			if refusal == nil {
+				refusal = DefaultRefusal()
+			}
+			resp := *refusal
+			resp.CustomMetadata = annotate(resp.CustomMetadata, resultMetadata(result))
+			yield(&resp, nil)
+			return
+		default:
+			metadata = resultMetadata(result)
+		}
+
+		for resp, err := range m.llm.GenerateContent(ctx, req, stream) {
+			if resp != nil {
+				resp.CustomMetadata = annotate(resp.CustomMetadata, metadata)
+			}
+			if !yield(resp, err) {
+				return
+			}
+		}
+	}
+}
+
+// contentsToCheck returns the contents of req that must be moderated.
+func (m *moderatedModel) contentsToCheck(req *model.LLMRequest) []*genai.Content {
+	if m.cfg.CheckHistory {
+		return req.Contents
+	}
+	if len(req.Contents) == 0 {
+		return nil
+	}
+	last := req.Contents[len(req.Contents)-1]
+	if last == nil || last.Role != genai.RoleUser {
+		return nil
+	}
+	for _, p := range last.Parts {
+		if p != nil && p.FunctionResponse != nil {
+			return nil
+		}
+	}
+	return []*genai.Content{last}
+}
+
+func resultMetadata(result Result) map[string]any {
+	return map[string]any{
+		"decision":   string(result.Decision),
+		"scores":     result.Scores,
+		"categories": result.Categories,
+	}
+}
+
+// annotate returns a copy of metadata with the moderation result added.
+func annotate(metadata map[string]any, result map[string]any) map[string]any {
+	out := make(map[string]any, len(metadata)+1)
+	maps.Copy(out, metadata)
+	out[MetadataKey] = result
+	return out
+}
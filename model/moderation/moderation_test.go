@@ -0,0 +1,245 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package moderation_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openai/openai-go/v3/option"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/httpx"
+	"google.golang.org/adk/internal/testutil"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/model/moderation"
+	"google.golang.org/adk/model/openai"
+)
+
+// moderationServer stubs the OpenAI moderations endpoint. Inputs containing
+// "bad" are flagged. It records the inputs and the agent names it received.
+type moderationServer struct {
+	*httptest.Server
+	fail   bool
+	inputs [][]string
+	agents []string
+}
+
+func newModerationServer(t *testing.T) *moderationServer {
+	s := &moderationServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/moderations" {
+			http.NotFound(w, r)
+			return
+		}
+		if s.fail {
+			http.Error(w, `{"error": {"message": "unavailable"}}`, http.StatusInternalServerError)
+			return
+		}
+		var req struct {
+			Input []string `json:"input"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.inputs = append(s.inputs, req.Input)
+		s.agents = append(s.agents, r.Header.Get("X-Agent-Name"))
+
+		var results []map[string]any
+		for _, input := range req.Input {
+			flagged := input == "bad"
+			score := 0.01
+			if flagged {
+				score = 0.98
+			}
+			results = append(results, map[string]any{
+				"flagged":         flagged,
+				"categories":      map[string]bool{"violence": flagged, "hate": false},
+				"category_scores": map[string]float64{"violence": score, "hate": 0.02},
+			})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"id":      "modr-1",
+			"model":   "omni-moderation-latest",
+			"results": results,
+		})
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *moderationServer) moderator() moderation.Moderator {
+	return openai.NewModerator("omni-moderation-latest",
+		option.WithBaseURL(s.URL),
+		option.WithAPIKey("test"),
+		option.WithMaxRetries(0))
+}
+
+func TestWrap(t *testing.T) {
+	refusal := &model.LLMResponse{Content: genai.NewContentFromText("refused", genai.RoleModel)}
+
+	testCases := []struct {
+		name         string
+		cfg          moderation.Config
+		fail         bool
+		contents     []*genai.Content
+		wantInputs   [][]string
+		wantText     string
+		wantMetadata map[string]any
+		// wantMetadataErr expects the metadata to report the moderator error.
+		wantMetadataErr bool
+		wantErr         bool
+	}{
+		{
+			name:       "allow",
+			contents:   []*genai.Content{genai.NewContentFromText("hello", genai.RoleUser)},
+			wantInputs: [][]string{{"hello"}},
+			wantText:   "model answer",
+			wantMetadata: map[string]any{
+				"decision":   "allow",
+				"scores":     map[string]float64{"violence": 0.01, "hate": 0.02},
+				"categories": []string(nil),
+			},
+		},
+		{
+			name:       "block",
+			cfg:        moderation.Config{Refusal: refusal},
+			contents:   []*genai.Content{genai.NewContentFromText("bad", genai.RoleUser)},
+			wantInputs: [][]string{{"bad"}},
+			wantText:   "refused",
+			wantMetadata: map[string]any{
+				"decision":   "block",
+				"scores":     map[string]float64{"violence": 0.98, "hate": 0.02},
+				"categories": []string{"violence"},
+			},
+		},
+		{
+			name: "only newest user content is checked",
+			contents: []*genai.Content{
+				genai.NewContentFromText("bad", genai.RoleUser),
+				genai.NewContentFromText("previous answer", genai.RoleModel),
+				genai.NewContentFromText("hello", genai.RoleUser),
+			},
+			wantInputs: [][]string{{"hello"}},
+			wantText:   "model answer",
+			wantMetadata: map[string]any{
+				"decision":   "allow",
+				"scores":     map[string]float64{"violence": 0.01, "hate": 0.02},
+				"categories": []string(nil),
+			},
+		},
+		{
+			name: "whole history is checked",
+			cfg:  moderation.Config{CheckHistory: true},
+			contents: []*genai.Content{
+				genai.NewContentFromText("bad", genai.RoleUser),
+				genai.NewContentFromText("previous answer", genai.RoleModel),
+				genai.NewContentFromText("hello", genai.RoleUser),
+			},
+			wantInputs: [][]string{{"bad", "previous answer", "hello"}},
+			wantText:   "I can't help with that request.",
+			wantMetadata: map[string]any{
+				"decision":   "block",
+				"scores":     map[string]float64{"violence": 0.98, "hate": 0.02},
+				"categories": []string{"violence"},
+			},
+		},
+		{
+			name: "function responses are not checked",
+			contents: []*genai.Content{
+				genai.NewContentFromText("hello", genai.RoleUser),
+				genai.NewContentFromFunctionCall("lookup", nil, genai.RoleModel),
+				genai.NewContentFromFunctionResponse("lookup", map[string]any{"result": "bad"}, genai.RoleUser),
+			},
+			wantText: "model answer",
+		},
+		{
+			name:            "fail open",
+			cfg:             moderation.Config{FailOpen: true},
+			fail:            true,
+			contents:        []*genai.Content{genai.NewContentFromText("hello", genai.RoleUser)},
+			wantText:        "model answer",
+			wantMetadataErr: true,
+		},
+		{
+			name:     "fail closed",
+			fail:     true,
+			contents: []*genai.Content{genai.NewContentFromText("hello", genai.RoleUser)},
+			wantErr:  true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := newModerationServer(t)
+			server.fail = tc.fail
+			testModel := &testutil.MockModel{Responses: []*genai.Content{genai.NewContentFromText("model answer", genai.RoleModel)}}
+			llm := moderation.Wrap(testModel, server.moderator(), tc.cfg)
+
+			var resps []*model.LLMResponse
+			var gotErr error
+			for resp, err := range llm.GenerateContent(t.Context(), &model.LLMRequest{Contents: tc.contents}, false) {
+				if err != nil {
+					gotErr = err
+					break
+				}
+				resps = append(resps, resp)
+			}
+			if (gotErr != nil) != tc.wantErr {
+				t.Fatalf("GenerateContent() error = %v, wantErr %v", gotErr, tc.wantErr)
+			}
+			if diff := cmp.Diff(tc.wantInputs, server.inputs); diff != "" {
+				t.Errorf("moderated inputs mismatch (-want +got):\n%s", diff)
+			}
+			if tc.wantErr {
+				if len(testModel.Requests) != 0 {
+					t.Error("model was called although moderation failed closed")
+				}
+				return
+			}
+			if len(resps) != 1 {
+				t.Fatalf("got %d responses, want 1", len(resps))
+			}
+			if got := resps[0].Content.Parts[0].Text; got != tc.wantText {
+				t.Errorf("response text = %q, want %q", got, tc.wantText)
+			}
+			got, _ := resps[0].CustomMetadata[moderation.MetadataKey].(map[string]any)
+			if tc.wantMetadataErr {
+				if errMsg, _ := got["error"].(string); errMsg == "" {
+					t.Errorf("moderation metadata = %v, want an error", got)
+				}
+				return
+			}
+			if diff := cmp.Diff(tc.wantMetadata, got); diff != "" {
+				t.Errorf("moderation metadata mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestNewModerator_Metadata(t *testing.T) {
+	server := newModerationServer(t)
+	ctx := httpx.NewContext(t.Context(), httpx.Metadata{AgentName: "agent"})
+	if _, err := server.moderator().Check(ctx, []*genai.Content{genai.NewContentFromText("hello", genai.RoleUser)}); err != nil {
+		t.Fatalf("Check() failed: %v", err)
+	}
+	if diff := cmp.Diff([]string{"agent"}, server.agents); diff != "" {
+		t.Errorf("agent names mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,166 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ollama
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"google.golang.org/adk/httpx"
+)
+
+const defaultBaseURL = "http://localhost:11434"
+
+// Client sends the requests of the models to the chat endpoint of an Ollama
+// server.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	keepAlive  *time.Duration
+}
+
+// Option configures a [Client].
+type Option func(*Client)
+
+// WithBaseURL sets the URL of the Ollama server. Defaults to the OLLAMA_HOST
+// environment variable, or else to http://localhost:11434.
+func WithBaseURL(url string) Option {
+	return func(c *Client) { c.baseURL = strings.TrimSuffix(url, "/") }
+}
+
+// WithHTTPClient sets the HTTP client sending the requests. Defaults to
+// httpx.DefaultClient, which propagates the metadata of the invocations in
+// their headers.
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *Client) { c.httpClient = client }
+}
+
+// WithKeepAlive sets how long the server keeps the model loaded after a
+// request: zero unloads it at once, and a negative duration keeps it
+// loaded until the server stops. By default, the server decides.
+func WithKeepAlive(d time.Duration) Option {
+	return func(c *Client) { c.keepAlive = &d }
+}
+
+// NewClient returns a client configured by opts.
+func NewClient(opts ...Option) *Client {
+	c := &Client{
+		baseURL:    defaultBaseURL,
+		httpClient: httpx.DefaultClient,
+	}
+	if host := os.Getenv("OLLAMA_HOST"); host != "" {
+		if !strings.Contains(host, "://") {
+			host = "http://" + host
+		}
+		c.baseURL = strings.TrimSuffix(host, "/")
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Error is an error returned by the Ollama server.
+type Error struct {
+	// StatusCode is the HTTP status of the response, zero for the errors
+	// reported in a stream.
+	StatusCode int
+	// Message describes the error.
+	Message string
+}
+
+// Error implements error.
+func (e *Error) Error() string {
+	if e.StatusCode == 0 {
+		return "ollama: " + e.Message
+	}
+	return fmt.Sprintf("ollama: %d: %s", e.StatusCode, e.Message)
+}
+
+// keepAliveValue returns the keep_alive parameter of the requests: a
+// duration, or -1 to keep the model loaded.
+func (c *Client) keepAliveValue() any {
+	switch {
+	case c.keepAlive == nil:
+		return nil
+	case *c.keepAlive < 0:
+		return -1
+	default:
+		return c.keepAlive.String()
+	}
+}
+
+// chat sends body to the chat endpoint, and returns its responses: the
+// only one of a non-streamed request, or the chunks of a stream.
+func (c *Client) chat(ctx context.Context, body *chatRequest) iter.Seq2[*chatResponse, error] {
+	return func(yield func(*chatResponse, error) bool) {
+		body.KeepAlive = c.keepAliveValue()
+		b, err := json.Marshal(body)
+		if err != nil {
+			yield(nil, fmt.Errorf("failed to encode the request: %w", err))
+			return
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/api/chat", bytes.NewReader(b))
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			b, _ := io.ReadAll(resp.Body)
+			apiErr := &Error{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(b))}
+			var e struct {
+				Error string `json:"error"`
+			}
+			if json.Unmarshal(b, &e) == nil && e.Error != "" {
+				apiErr.Message = e.Error
+			}
+			yield(nil, apiErr)
+			return
+		}
+		// The chunks of the streams are sent as newline-delimited JSON.
+		dec := json.NewDecoder(resp.Body)
+		for {
+			var chunk chatResponse
+			if err := dec.Decode(&chunk); err == io.EOF {
+				return
+			} else if err != nil {
+				yield(nil, fmt.Errorf("failed to decode the response: %w", err))
+				return
+			}
+			if chunk.Error != "" {
+				yield(nil, &Error{Message: chunk.Error})
+				return
+			}
+			if !yield(&chunk, nil) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ollama
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+
+	"google.golang.org/adk/model"
+)
+
+// ErrorCode classifies an error returned by the Ollama client. Server
+// errors are classified by their status code; network errors, e.g. of a
+// server that is not running, are reported as unavailable.
+func ErrorCode(err error) model.ErrorCode {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		switch status := apiErr.StatusCode; {
+		case status == 0:
+			// The errors of the streams have no status.
+			return model.ErrorCodeUnknown
+		case status == http.StatusTooManyRequests:
+			return model.ErrorCodeRateLimited
+		case status == http.StatusUnauthorized || status == http.StatusForbidden:
+			return model.ErrorCodeAuthFailed
+		case status >= 500:
+			return model.ErrorCodeUnavailable
+		case status >= 400:
+			return model.ErrorCodeInvalidRequest
+		default:
+			return model.ErrorCodeUnknown
+		}
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return model.ErrorCodeUnknown
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return model.ErrorCodeUnavailable
+	}
+	return model.ErrorCodeUnknown
+}
+
+// classify wraps err in a [model.Error] carrying its code.
+func classify(err error) error {
+	return &model.Error{Code: ErrorCode(err), Err: err}
+}
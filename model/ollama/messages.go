@@ -0,0 +1,76 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ollama
+
+// The types below are the subset of the chat API used by the adapter.
+
+type chatRequest struct {
+	Model     string     `json:"model"`
+	Messages  []message  `json:"messages"`
+	Tools     []toolSpec `json:"tools,omitempty"`
+	Format    any        `json:"format,omitempty"`
+	Options   *options   `json:"options,omitempty"`
+	Stream    bool       `json:"stream"`
+	Think     *bool      `json:"think,omitempty"`
+	KeepAlive any        `json:"keep_alive,omitempty"`
+}
+
+type message struct {
+	Role      string     `json:"role"`
+	Content   string     `json:"content"`
+	Thinking  string     `json:"thinking,omitempty"`
+	Images    [][]byte   `json:"images,omitempty"`
+	ToolCalls []toolCall `json:"tool_calls,omitempty"`
+	// ToolName is the name of the function whose result is the content of
+	// a tool message.
+	ToolName string `json:"tool_name,omitempty"`
+}
+
+type toolCall struct {
+	Function struct {
+		Name      string         `json:"name"`
+		Arguments map[string]any `json:"arguments"`
+	} `json:"function"`
+}
+
+type toolSpec struct {
+	Type     string       `json:"type"`
+	Function functionSpec `json:"function"`
+}
+
+type functionSpec struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Parameters  any    `json:"parameters"`
+}
+
+type options struct {
+	Temperature *float32 `json:"temperature,omitempty"`
+	TopP        *float32 `json:"top_p,omitempty"`
+	TopK        *int64   `json:"top_k,omitempty"`
+	NumPredict  int64    `json:"num_predict,omitempty"`
+	Stop        []string `json:"stop,omitempty"`
+	Seed        *int32   `json:"seed,omitempty"`
+}
+
+type chatResponse struct {
+	Model           string  `json:"model"`
+	Message         message `json:"message"`
+	Done            bool    `json:"done"`
+	DoneReason      string  `json:"done_reason"`
+	PromptEvalCount int64   `json:"prompt_eval_count"`
+	EvalCount       int64   `json:"eval_count"`
+	Error           string  `json:"error"`
+}
@@ -0,0 +1,298 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ollama implements the [model.LLM] interface for the models served
+// by Ollama, with its native chat API.
+package ollama
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"strings"
+
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/internal/llmconv"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/strictness"
+)
+
+type ollamaModel struct {
+	name   string
+	client *Client
+}
+
+// NewModel returns the model modelName of a client created with opts, e.g.
+// "llama3.2". The model must have been pulled by the server.
+func NewModel(ctx context.Context, modelName string, opts ...Option) (model.LLM, error) {
+	return NewModelWithClient(modelName, NewClient(opts...)), nil
+}
+
+// NewModelWithClient returns the model modelName called with client, so
+// that a client can be shared by several models.
+func NewModelWithClient(modelName string, client *Client) model.LLM {
+	return &ollamaModel{name: modelName, client: client}
+}
+
+func (m *ollamaModel) Name() string {
+	return m.name
+}
+
+// GenerateContent calls the model. Streamed, the text is yielded as partial
+// responses, followed by the complete response of the turn: its whole text
+// and its function calls, with the finish reason and the usage.
+func (m *ollamaModel) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {
+		llmconv.MaybeAppendUserContent(req)
+		body, err := newRequest(ctx, req, m.name)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		body.Stream = stream
+		var final chatResponse
+		var text, thinking strings.Builder
+		for chunk, err := range m.client.chat(ctx, body) {
+			if err != nil {
+				yield(nil, fmt.Errorf("failed to generate content: %w", classify(err)))
+				return
+			}
+			text.WriteString(chunk.Message.Content)
+			thinking.WriteString(chunk.Message.Thinking)
+			final.Message.ToolCalls = append(final.Message.ToolCalls, chunk.Message.ToolCalls...)
+			if chunk.Done {
+				final.Done, final.DoneReason = true, chunk.DoneReason
+				final.PromptEvalCount, final.EvalCount = chunk.PromptEvalCount, chunk.EvalCount
+				break
+			}
+			if resp := partial(chunk); resp != nil && !yield(resp, nil) {
+				return
+			}
+		}
+		if !final.Done {
+			yield(nil, fmt.Errorf("failed to generate content: the response ended before the model was done"))
+			return
+		}
+		final.Message.Content, final.Message.Thinking = text.String(), thinking.String()
+		yield(convertResponse(&final), nil)
+	}
+}
+
+// newRequest converts req to a request of the chat API. The parts and the
+// parameters that cannot be sent to Ollama are dropped, which is reported
+// with the strictness policy of ctx.
+func newRequest(ctx context.Context, req *model.LLMRequest, modelName string) (*chatRequest, error) {
+	body := &chatRequest{Model: modelName}
+	cfg := req.Config
+	if cfg != nil && cfg.SystemInstruction != nil {
+		if text := joinText(cfg.SystemInstruction.Parts); text != "" {
+			body.Messages = append(body.Messages, message{Role: "system", Content: text})
+		}
+	}
+	messages, err := convertContents(ctx, req.Contents)
+	if err != nil {
+		return nil, err
+	}
+	body.Messages = append(body.Messages, messages...)
+	if cfg == nil {
+		return body, nil
+	}
+
+	body.Options = &options{
+		Temperature: cfg.Temperature,
+		TopP:        cfg.TopP,
+		NumPredict:  int64(cfg.MaxOutputTokens),
+		Stop:        cfg.StopSequences,
+		Seed:        cfg.Seed,
+	}
+	if cfg.TopK != nil {
+		topK := int64(*cfg.TopK)
+		body.Options.TopK = &topK
+	}
+	if cfg.CandidateCount > 1 {
+		if err := strictness.Report(ctx, strictness.Conversion, "ollama", "ignored candidate_count: not supported by Ollama"); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.ThinkingConfig != nil && cfg.ThinkingConfig.IncludeThoughts {
+		think := true
+		body.Think = &think
+	}
+	switch {
+	case cfg.ResponseJsonSchema != nil:
+		body.Format = cfg.ResponseJsonSchema
+	case cfg.ResponseSchema != nil:
+		body.Format = llmconv.SchemaMap(cfg.ResponseSchema)
+	case cfg.ResponseMIMEType == "application/json":
+		body.Format = "json"
+	}
+	if body.Tools, err = convertTools(ctx, cfg.Tools); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+// convertContents converts the contents to messages: the contents of the
+// model are sent as assistant messages, with their function calls, and the
+// function responses as tool messages.
+func convertContents(ctx context.Context, contents []*genai.Content) ([]message, error) {
+	var messages []message
+	for _, content := range contents {
+		if content == nil {
+			continue
+		}
+		msg := message{Role: "user"}
+		if content.Role == genai.RoleModel {
+			msg.Role = "assistant"
+		}
+		var texts []string
+		for _, part := range content.Parts {
+			if part == nil || part.Thought {
+				continue
+			}
+			switch {
+			case part.Text != "":
+				texts = append(texts, part.Text)
+			case part.FunctionCall != nil:
+				var call toolCall
+				call.Function.Name = part.FunctionCall.Name
+				call.Function.Arguments = part.FunctionCall.Args
+				msg.ToolCalls = append(msg.ToolCalls, call)
+			case part.FunctionResponse != nil:
+				result, err := json.Marshal(part.FunctionResponse.Response)
+				if err != nil {
+					return nil, fmt.Errorf("failed to encode the response of %q: %w", part.FunctionResponse.Name, err)
+				}
+				messages = append(messages, message{Role: "tool", Content: string(result), ToolName: part.FunctionResponse.Name})
+			case part.InlineData != nil && strings.HasPrefix(part.InlineData.MIMEType, "image/"):
+				msg.Images = append(msg.Images, part.InlineData.Data)
+			case part.InlineData != nil:
+				if err := drop(ctx, "inline data", part.InlineData.MIMEType); err != nil {
+					return nil, err
+				}
+			case part.FileData != nil:
+				if err := drop(ctx, "file data", part.FileData.FileURI); err != nil {
+					return nil, err
+				}
+			}
+		}
+		msg.Content = strings.Join(texts, "\n")
+		if msg.Content != "" || len(msg.Images) > 0 || len(msg.ToolCalls) > 0 {
+			messages = append(messages, msg)
+		}
+	}
+	return messages, nil
+}
+
+// joinText returns the text of the parts other than thoughts, one per line.
+func joinText(parts []*genai.Part) string {
+	var texts []string
+	for _, part := range parts {
+		if part != nil && part.Text != "" && !part.Thought {
+			texts = append(texts, part.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// convertTools converts the function declarations of tools. The other
+// tools, e.g. Google Search, are dropped.
+func convertTools(ctx context.Context, tools []*genai.Tool) ([]toolSpec, error) {
+	var specs []toolSpec
+	for _, t := range tools {
+		if t == nil {
+			continue
+		}
+		if len(t.FunctionDeclarations) == 0 {
+			if err := drop(ctx, "tool", "other than functions"); err != nil {
+				return nil, err
+			}
+		}
+		for _, decl := range t.FunctionDeclarations {
+			var parameters any = map[string]any{"type": "object", "properties": map[string]any{}}
+			switch {
+			case decl.ParametersJsonSchema != nil:
+				parameters = decl.ParametersJsonSchema
+			case decl.Parameters != nil:
+				parameters = llmconv.SchemaMap(decl.Parameters)
+			}
+			specs = append(specs, toolSpec{
+				Type:     "function",
+				Function: functionSpec{Name: decl.Name, Description: decl.Description, Parameters: parameters},
+			})
+		}
+	}
+	return specs, nil
+}
+
+// drop reports that the adapter dropped a part or a tool which cannot be
+// sent to Ollama, with the strictness policy of ctx.
+func drop(ctx context.Context, kind, detail string) error {
+	return strictness.Report(ctx, strictness.Conversion, "ollama", "dropped %s %s: not supported by Ollama", kind, detail)
+}
+
+// partial converts a chunk of a stream to a partial response carrying its
+// text and its thinking, nil if it carries neither.
+func partial(chunk *chatResponse) *model.LLMResponse {
+	content := &genai.Content{Role: genai.RoleModel}
+	if chunk.Message.Thinking != "" {
+		content.Parts = append(content.Parts, &genai.Part{Text: chunk.Message.Thinking, Thought: true})
+	}
+	if chunk.Message.Content != "" {
+		content.Parts = append(content.Parts, &genai.Part{Text: chunk.Message.Content})
+	}
+	if len(content.Parts) == 0 {
+		return nil
+	}
+	return &model.LLMResponse{Content: content, Partial: true}
+}
+
+// convertResponse converts the complete response of the model.
+func convertResponse(resp *chatResponse) *model.LLMResponse {
+	content := &genai.Content{Role: genai.RoleModel}
+	if resp.Message.Thinking != "" {
+		content.Parts = append(content.Parts, &genai.Part{Text: resp.Message.Thinking, Thought: true})
+	}
+	if resp.Message.Content != "" {
+		content.Parts = append(content.Parts, &genai.Part{Text: resp.Message.Content})
+	}
+	for _, call := range resp.Message.ToolCalls {
+		content.Parts = append(content.Parts, &genai.Part{FunctionCall: &genai.FunctionCall{Name: call.Function.Name, Args: call.Function.Arguments}})
+	}
+	return &model.LLMResponse{
+		Content: content,
+		UsageMetadata: &genai.GenerateContentResponseUsageMetadata{
+			PromptTokenCount:     int32(resp.PromptEvalCount),
+			CandidatesTokenCount: int32(resp.EvalCount),
+			TotalTokenCount:      int32(resp.PromptEvalCount + resp.EvalCount),
+		},
+		FinishReason: finishReason(resp.DoneReason),
+		TurnComplete: true,
+	}
+}
+
+func finishReason(reason string) genai.FinishReason {
+	switch reason {
+	case "stop":
+		return genai.FinishReasonStop
+	case "length":
+		return genai.FinishReasonMaxTokens
+	case "":
+		return genai.FinishReasonUnspecified
+	default:
+		return genai.FinishReasonOther
+	}
+}
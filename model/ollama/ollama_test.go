@@ -0,0 +1,233 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ollama_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/model/modeltest"
+	"google.golang.org/adk/model/ollama"
+)
+
+// reply returns a handler answering the chat requests with status and body.
+func reply(status int, body string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		fmt.Fprint(w, body)
+	}
+}
+
+func TestModel_Request(t *testing.T) {
+	var got map[string]any
+	srv := modeltest.NewServer(t, "/api/chat", &got, reply(http.StatusOK, `{"message":{"role":"assistant","content":"ok"},"done":true,"done_reason":"stop"}`))
+	llm, err := ollama.NewModel(t.Context(), "llama3.2", ollama.WithBaseURL(srv.URL), ollama.WithKeepAlive(-1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	temperature, topK := float32(0.5), float32(40)
+	req := &model.LLMRequest{
+		Contents: []*genai.Content{
+			{Role: genai.RoleUser, Parts: []*genai.Part{
+				genai.NewPartFromText("what is this?"),
+				genai.NewPartFromBytes([]byte("png"), "image/png"),
+			}},
+			genai.NewContentFromFunctionCall("lookup", map[string]any{"q": "cat"}, genai.RoleModel),
+			genai.NewContentFromFunctionResponse("lookup", map[string]any{"result": "a cat"}, genai.RoleUser),
+		},
+		Config: &genai.GenerateContentConfig{
+			SystemInstruction: genai.NewContentFromText("Be brief.", genai.RoleUser),
+			MaxOutputTokens:   100,
+			Temperature:       &temperature,
+			TopK:              &topK,
+			StopSequences:     []string{"END"},
+			Tools: []*genai.Tool{{FunctionDeclarations: []*genai.FunctionDeclaration{{
+				Name:        "lookup",
+				Description: "looks things up",
+				Parameters: &genai.Schema{
+					Type: genai.TypeObject,
+					Properties: map[string]*genai.Schema{
+						"q":     {Type: genai.TypeString},
+						"since": {Type: genai.TypeString, Format: "date", Nullable: genai.Ptr(true)},
+						"limit": {AnyOf: []*genai.Schema{{Type: genai.TypeInteger}, {Type: genai.TypeString}}},
+					},
+					Required: []string{"q"},
+				},
+			}}}},
+		},
+	}
+	if _, err := model.Collect(llm.GenerateContent(t.Context(), req, false)); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]any{
+		"model":      "llama3.2",
+		"stream":     false,
+		"keep_alive": float64(-1),
+		"options": map[string]any{
+			"temperature": 0.5,
+			"top_k":       float64(40),
+			"num_predict": float64(100),
+			"stop":        []any{"END"},
+		},
+		"messages": []any{
+			map[string]any{"role": "system", "content": "Be brief."},
+			map[string]any{"role": "user", "content": "what is this?", "images": []any{"cG5n"}},
+			map[string]any{"role": "assistant", "content": "", "tool_calls": []any{
+				map[string]any{"function": map[string]any{"name": "lookup", "arguments": map[string]any{"q": "cat"}}},
+			}},
+			map[string]any{"role": "tool", "content": `{"result":"a cat"}`, "tool_name": "lookup"},
+		},
+		"tools": []any{map[string]any{
+			"type": "function",
+			"function": map[string]any{
+				"name":        "lookup",
+				"description": "looks things up",
+				"parameters": map[string]any{
+					"type": "object",
+					"properties": map[string]any{
+						"q":     map[string]any{"type": "string"},
+						"since": map[string]any{"type": []any{"string", "null"}, "format": "date"},
+						"limit": map[string]any{"anyOf": []any{map[string]any{"type": "integer"}, map[string]any{"type": "string"}}},
+					},
+					"required": []any{"q"},
+				},
+			},
+		}},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("request mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestModel_Response(t *testing.T) {
+	var got map[string]any
+	srv := modeltest.NewServer(t, "/api/chat", &got, reply(http.StatusOK, `{
+		"model": "llama3.2",
+		"message": {"role": "assistant", "content": "Let me look.", "tool_calls": [{"function": {"name": "lookup", "arguments": {"q": "cat"}}}]},
+		"done": true,
+		"done_reason": "stop",
+		"prompt_eval_count": 26,
+		"eval_count": 12
+	}`))
+	llm, err := ollama.NewModel(t.Context(), "llama3.2", ollama.WithBaseURL(srv.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := model.Collect(llm.GenerateContent(t.Context(), &model.LLMRequest{Contents: []*genai.Content{genai.NewContentFromText("hi", genai.RoleUser)}}, false))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &model.LLMResponse{
+		Content: &genai.Content{Role: genai.RoleModel, Parts: []*genai.Part{
+			{Text: "Let me look."},
+			{FunctionCall: &genai.FunctionCall{Name: "lookup", Args: map[string]any{"q": "cat"}}},
+		}},
+		UsageMetadata: &genai.GenerateContentResponseUsageMetadata{
+			PromptTokenCount:     26,
+			CandidatesTokenCount: 12,
+			TotalTokenCount:      38,
+		},
+		TurnComplete: true,
+		FinishReason: genai.FinishReasonStop,
+	}
+	if diff := cmp.Diff(want, resp); diff != "" {
+		t.Errorf("response mismatch (-want +got):\n%s", diff)
+	}
+	if _, ok := got["keep_alive"]; ok {
+		t.Errorf("keep_alive = %v, want none by default", got["keep_alive"])
+	}
+}
+
+func TestModel_Stream(t *testing.T) {
+	var got map[string]any
+	srv := modeltest.NewServer(t, "/api/chat", &got, reply(http.StatusOK, `{"message":{"role":"assistant","content":"A "},"done":false}
+{"message":{"role":"assistant","content":"cat."},"done":false}
+{"message":{"role":"assistant","content":""},"done":true,"done_reason":"length","prompt_eval_count":5,"eval_count":2}
+`))
+	llm, err := ollama.NewModel(t.Context(), "llama3.2", ollama.WithBaseURL(srv.URL), ollama.WithKeepAlive(5*time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var partials []*model.LLMResponse
+	resp, err := model.TeeCollect(llm.GenerateContent(t.Context(), &model.LLMRequest{Contents: []*genai.Content{genai.NewContentFromText("hi", genai.RoleUser)}}, true), func(partial *model.LLMResponse) error {
+		partials = append(partials, partial)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got["stream"] != true || got["keep_alive"] != "5m0s" {
+		t.Errorf("stream, keep_alive = %v, %v, want true, 5m0s", got["stream"], got["keep_alive"])
+	}
+	wantPartials := []*model.LLMResponse{
+		{Content: genai.NewContentFromText("A ", genai.RoleModel), Partial: true},
+		{Content: genai.NewContentFromText("cat.", genai.RoleModel), Partial: true},
+	}
+	if diff := cmp.Diff(wantPartials, partials); diff != "" {
+		t.Errorf("partial responses mismatch (-want +got):\n%s", diff)
+	}
+	want := &model.LLMResponse{
+		Content: genai.NewContentFromText("A cat.", genai.RoleModel),
+		UsageMetadata: &genai.GenerateContentResponseUsageMetadata{
+			PromptTokenCount:     5,
+			CandidatesTokenCount: 2,
+			TotalTokenCount:      7,
+		},
+		TurnComplete: true,
+		FinishReason: genai.FinishReasonMaxTokens,
+	}
+	if diff := cmp.Diff(want, resp); diff != "" {
+		t.Errorf("response mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestModel_Errors(t *testing.T) {
+	var got map[string]any
+	notFound := modeltest.NewServer(t, "/api/chat", &got, reply(http.StatusNotFound, `{"error":"model \"llama9\" not found, try pulling it first"}`))
+	stopped := httptest.NewServer(http.NotFoundHandler())
+	stopped.Close()
+
+	tests := []struct {
+		name string
+		url  string
+		want model.ErrorCode
+	}{
+		{"ModelNotFound", notFound.URL, model.ErrorCodeInvalidRequest},
+		{"ServerNotRunning", stopped.URL, model.ErrorCodeUnavailable},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			llm, err := ollama.NewModel(t.Context(), "llama9", ollama.WithBaseURL(tc.url))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, err = range llm.GenerateContent(t.Context(), &model.LLMRequest{}, false) {
+			}
+			if code := model.CodeOf(err); code != tc.want {
+				t.Errorf("CodeOf(%v) = %s, want %s", err, code, tc.want)
+			}
+		})
+	}
+}
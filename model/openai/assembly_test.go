@@ -0,0 +1,49 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openai_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/model/assembly"
+	"google.golang.org/adk/model/openai"
+)
+
+func TestLLMRequest2ChatCompletionNewParams_TracesDrops(t *testing.T) {
+	req := &model.LLMRequest{
+		Model: "gpt-4o",
+		Contents: []*genai.Content{
+			{Role: genai.RoleUser, Parts: []*genai.Part{genai.NewPartFromText("look"), genai.NewPartFromBytes([]byte("pdf"), "application/pdf"), {ExecutableCode: &genai.ExecutableCode{Code: "print(1)", Language: genai.LanguagePython}}, {}}},
+			{Role: "narrator", Parts: []*genai.Part{genai.NewPartFromText("aside")}},
+		},
+	}
+	tr, end := assembly.Start(assembly.NewContext(t.Context(), &assembly.Recorder{}), req)
+	defer end()
+	if _, err := openai.LLMRequest2ChatCompletionNewParams(req); err != nil {
+		t.Fatal(err)
+	}
+	want := []assembly.Entry{
+		{Component: "openai", Operation: "drop inline data", Detail: "of content 0", BytesDelta: -44, TokensDelta: -11},
+		{Component: "openai", Operation: "drop part", Detail: "of content 0", BytesDelta: -58, TokensDelta: -15},
+		{Component: "openai", Operation: "drop content", Detail: `with role "narrator"`, BytesDelta: -9, TokensDelta: -3},
+	}
+	if diff := cmp.Diff(want, tr.Entries()); diff != "" {
+		t.Errorf("entries mismatch (-want +got):\n%s", diff)
+	}
+}
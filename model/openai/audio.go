@@ -0,0 +1,147 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openai
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/openai/openai-go/v3"
+	"google.golang.org/genai"
+)
+
+// defaultVoice is the voice of the audio responses when the speech config
+// names none.
+const defaultVoice = "alloy"
+
+// The MIME types of the audio returned by the model: the complete responses
+// are requested as WAV, and the streamed ones as raw 16-bit PCM, mono, at
+// 24kHz, the only format which can be streamed.
+const (
+	wavMIMEType   = "audio/wav"
+	pcm16MIMEType = "audio/pcm;rate=24000"
+)
+
+// audioFormat returns the input_audio format of the audio blob, or "" if it
+// cannot be sent.
+func audioFormat(blob *genai.Blob) string {
+	mimeType, _, _ := strings.Cut(blob.MIMEType, ";")
+	switch strings.TrimSpace(strings.ToLower(mimeType)) {
+	case "audio/wav", "audio/x-wav", "audio/wave", "audio/vnd.wave":
+		return "wav"
+	case "audio/mpeg", "audio/mp3":
+		return "mp3"
+	default:
+		return ""
+	}
+}
+
+func isAudio(blob *genai.Blob) bool {
+	return strings.HasPrefix(blob.MIMEType, "audio/")
+}
+
+// audioPart returns the audio blob, in a format returned by audioFormat, as
+// an input_audio content part.
+func audioPart(blob *genai.Blob) openai.ChatCompletionContentPartUnionParam {
+	return openai.InputAudioContentPart(openai.ChatCompletionContentPartInputAudioInputAudioParam{
+		Data:   base64.StdEncoding.EncodeToString(blob.Data),
+		Format: audioFormat(blob),
+	})
+}
+
+// audioPlaceholder returns the text replacing the audio blob, which cannot
+// be sent.
+func audioPlaceholder(blob *genai.Blob, userRole bool) string {
+	if userRole {
+		return fmt.Sprintf("[%s audio of %d bytes omitted: only wav and mp3 audio are supported]", blob.MIMEType, len(blob.Data))
+	}
+	return fmt.Sprintf("[%s audio of %d bytes omitted: audio is only supported in user messages]", blob.MIMEType, len(blob.Data))
+}
+
+// wantsAudio reports whether cfg asks for audio responses.
+func wantsAudio(cfg *genai.GenerateContentConfig) bool {
+	return slices.ContainsFunc(cfg.ResponseModalities, func(m string) bool { return strings.EqualFold(m, string(genai.ModalityAudio)) })
+}
+
+// applyAudioOutput asks for audio responses, along with their transcript,
+// if cfg has the AUDIO response modality. The voice is the prebuilt voice
+// of the speech config, or defaultVoice.
+func applyAudioOutput(params *openai.ChatCompletionNewParams, cfg *genai.GenerateContentConfig) {
+	if !wantsAudio(cfg) {
+		return
+	}
+	voice := defaultVoice
+	if sc := cfg.SpeechConfig; sc != nil && sc.VoiceConfig != nil && sc.VoiceConfig.PrebuiltVoiceConfig != nil && sc.VoiceConfig.PrebuiltVoiceConfig.VoiceName != "" {
+		voice = sc.VoiceConfig.PrebuiltVoiceConfig.VoiceName
+	}
+	params.Modalities = []string{"text", "audio"}
+	params.Audio = openai.ChatCompletionAudioParam{
+		Voice:  openai.ChatCompletionAudioParamVoice(voice),
+		Format: openai.ChatCompletionAudioParamFormatWAV,
+	}
+}
+
+// unmappedModalities reports whether cfg has response modalities other
+// than TEXT and AUDIO, or a speech config which is not mapped.
+func unmappedModalities(cfg *genai.GenerateContentConfig) (modalities, speech bool) {
+	for _, m := range cfg.ResponseModalities {
+		if !strings.EqualFold(m, string(genai.ModalityText)) && !strings.EqualFold(m, string(genai.ModalityAudio)) {
+			modalities = true
+		}
+	}
+	if sc := cfg.SpeechConfig; sc != nil {
+		speech = !wantsAudio(cfg) || sc.MultiSpeakerVoiceConfig != nil || sc.LanguageCode != ""
+	}
+	return modalities, speech
+}
+
+// audioParts returns the parts of the audio of a response: its transcript,
+// and its data as inline data of the given MIME type.
+func audioParts(data, transcript, mimeType string) ([]*genai.Part, error) {
+	var parts []*genai.Part
+	if transcript != "" {
+		parts = append(parts, genai.NewPartFromText(transcript))
+	}
+	if data != "" {
+		b, err := base64.StdEncoding.DecodeString(data)
+		if err != nil {
+			return parts, fmt.Errorf("invalid audio data: %w", err)
+		}
+		parts = append(parts, genai.NewPartFromBytes(b, mimeType))
+	}
+	return parts, nil
+}
+
+// chunkAudio returns the audio of a streamed delta, which the client does
+// not decode.
+func chunkAudio(delta openai.ChatCompletionChunkChoiceDelta) (data, transcript string) {
+	// The extra fields are never reported valid: only their raw value
+	// tells whether they are present.
+	field, ok := delta.JSON.ExtraFields["audio"]
+	if !ok || field.Raw() == "" {
+		return "", ""
+	}
+	var audio struct {
+		Data       string `json:"data"`
+		Transcript string `json:"transcript"`
+	}
+	if err := json.Unmarshal([]byte(field.Raw()), &audio); err != nil {
+		return "", ""
+	}
+	return audio.Data, audio.Transcript
+}
@@ -0,0 +1,290 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openai_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openai/openai-go/v3/option"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/model/openai"
+)
+
+func TestLLMRequest2ChatCompletionNewParams_Audio(t *testing.T) {
+	wav := []byte("RIFF")
+	tests := []struct {
+		name     string
+		contents []*genai.Content
+		want     []any
+	}{
+		{
+			name: "UserAudio",
+			contents: []*genai.Content{{Role: genai.RoleUser, Parts: []*genai.Part{
+				genai.NewPartFromText("transcribe"),
+				genai.NewPartFromBytes(wav, "audio/wav"),
+				genai.NewPartFromBytes([]byte{0xff, 0xfb}, "audio/mpeg"),
+			}}},
+			want: []any{
+				map[string]any{"role": "user", "content": []any{
+					map[string]any{"type": "text", "text": "transcribe"},
+					map[string]any{"type": "input_audio", "input_audio": map[string]any{"data": "UklGRg==", "format": "wav"}},
+					map[string]any{"type": "input_audio", "input_audio": map[string]any{"data": "//s=", "format": "mp3"}},
+				}},
+			},
+		},
+		{
+			name: "UnsupportedFormat",
+			contents: []*genai.Content{{Role: genai.RoleUser, Parts: []*genai.Part{
+				genai.NewPartFromBytes([]byte("OggS"), "audio/ogg"),
+			}}},
+			want: []any{
+				map[string]any{"role": "user", "content": "[audio/ogg audio of 4 bytes omitted: only wav and mp3 audio are supported]"},
+			},
+		},
+		{
+			name: "ModelAudio",
+			contents: []*genai.Content{
+				{Role: genai.RoleModel, Parts: []*genai.Part{genai.NewPartFromText("hello"), genai.NewPartFromBytes(wav, "audio/wav")}},
+			},
+			want: []any{
+				map[string]any{"role": "assistant", "content": "hello"},
+				map[string]any{"role": "assistant", "content": "[audio/wav audio of 4 bytes omitted: audio is only supported in user messages]"},
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.want, messages(t, tc.contents...)); diff != "" {
+				t.Errorf("messages mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestLLMRequest2ChatCompletionNewParams_AudioOutput(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  *genai.GenerateContentConfig
+		want map[string]any
+	}{
+		{
+			name: "DefaultVoice",
+			cfg:  &genai.GenerateContentConfig{ResponseModalities: []string{"TEXT", "AUDIO"}},
+			want: map[string]any{
+				"modalities": []any{"text", "audio"},
+				"audio":      map[string]any{"voice": "alloy", "format": "wav"},
+			},
+		},
+		{
+			name: "Voice",
+			cfg: &genai.GenerateContentConfig{
+				ResponseModalities: []string{"AUDIO"},
+				SpeechConfig:       &genai.SpeechConfig{VoiceConfig: &genai.VoiceConfig{PrebuiltVoiceConfig: &genai.PrebuiltVoiceConfig{VoiceName: "coral"}}},
+			},
+			want: map[string]any{
+				"modalities": []any{"text", "audio"},
+				"audio":      map[string]any{"voice": "coral", "format": "wav"},
+			},
+		},
+		{
+			name: "Text",
+			cfg:  &genai.GenerateContentConfig{ResponseModalities: []string{"TEXT"}},
+			want: map[string]any{},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			params, err := openai.LLMRequest2ChatCompletionNewParams(&model.LLMRequest{
+				Model:    "gpt-4o-audio-preview",
+				Contents: []*genai.Content{genai.NewContentFromText("hi", genai.RoleUser)},
+				Config:   tc.cfg,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, err := json.Marshal(params)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var body map[string]any
+			if err := json.Unmarshal(b, &body); err != nil {
+				t.Fatal(err)
+			}
+			got := map[string]any{}
+			for _, key := range []string{"modalities", "audio"} {
+				if v, ok := body[key]; ok {
+					got[key] = v
+				}
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("audio parameters mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestModel_AudioOutput(t *testing.T) {
+	wav, pcm := []byte("RIFF"), []byte{1, 2, 3, 4}
+	var format string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Stream bool `json:"stream"`
+			Audio  struct {
+				Format string `json:"format"`
+			} `json:"audio"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		format = body.Audio.Format
+		if body.Stream {
+			w.Header().Set("Content-Type", "text/event-stream")
+			for _, delta := range []string{
+				`{"role": "assistant", "audio": {"id": "a1", "transcript": "Hel"}}`,
+				fmt.Sprintf(`{"audio": {"id": "a1", "data": %q, "transcript": "lo"}}`, base64.StdEncoding.EncodeToString(pcm)),
+			} {
+				fmt.Fprintf(w, "data: {\"id\": \"c1\", \"object\": \"chat.completion.chunk\", \"model\": \"gpt-4o-audio-preview\", \"choices\": [{\"index\": 0, \"delta\": %s}]}\n\n", delta)
+			}
+			fmt.Fprint(w, "data: {\"id\": \"c1\", \"object\": \"chat.completion.chunk\", \"model\": \"gpt-4o-audio-preview\", \"choices\": [{\"index\": 0, \"delta\": {}, \"finish_reason\": \"stop\"}]}\n\ndata: [DONE]\n\n")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"id": "c1", "object": "chat.completion", "model": "gpt-4o-audio-preview",
+			"choices": [{"index": 0, "finish_reason": "stop", "message": {"role": "assistant", "content": null,
+				"audio": {"id": "a1", "data": %q, "expires_at": 1, "transcript": "Hello"}}}],
+			"usage": {"prompt_tokens": 30, "completion_tokens": 50, "total_tokens": 80,
+				"prompt_tokens_details": {"audio_tokens": 20}, "completion_tokens_details": {"audio_tokens": 40}}}`, base64.StdEncoding.EncodeToString(wav))
+	}))
+	defer srv.Close()
+	llm, err := openai.NewModel(t.Context(), "gpt-4o-audio-preview", option.WithBaseURL(srv.URL), option.WithAPIKey("key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := &model.LLMRequest{
+		Model:    "gpt-4o-audio-preview",
+		Contents: []*genai.Content{genai.NewContentFromText("say hello", genai.RoleUser)},
+		Config:   &genai.GenerateContentConfig{ResponseModalities: []string{"AUDIO"}},
+	}
+
+	t.Run("Complete", func(t *testing.T) {
+		var got []*model.LLMResponse
+		for resp, err := range llm.GenerateContent(t.Context(), req, false) {
+			if err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, resp)
+		}
+		if format != "wav" {
+			t.Errorf("requested audio format = %q, want wav", format)
+		}
+		if len(got) != 1 {
+			t.Fatalf("got %d responses, want 1", len(got))
+		}
+		want := &genai.Content{Role: genai.RoleModel, Parts: []*genai.Part{genai.NewPartFromText("Hello"), genai.NewPartFromBytes(wav, "audio/wav")}}
+		if diff := cmp.Diff(want, got[0].Content); diff != "" {
+			t.Errorf("content mismatch (-want +got):\n%s", diff)
+		}
+		usage := got[0].UsageMetadata
+		wantPrompt := []*genai.ModalityTokenCount{{Modality: genai.MediaModalityText, TokenCount: 10}, {Modality: genai.MediaModalityAudio, TokenCount: 20}}
+		wantCandidates := []*genai.ModalityTokenCount{{Modality: genai.MediaModalityText, TokenCount: 10}, {Modality: genai.MediaModalityAudio, TokenCount: 40}}
+		if diff := cmp.Diff(wantPrompt, usage.PromptTokensDetails); diff != "" {
+			t.Errorf("PromptTokensDetails mismatch (-want +got):\n%s", diff)
+		}
+		if diff := cmp.Diff(wantCandidates, usage.CandidatesTokensDetails); diff != "" {
+			t.Errorf("CandidatesTokensDetails mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("Stream", func(t *testing.T) {
+		var got []*genai.Part
+		for resp, err := range llm.GenerateContent(t.Context(), req, true) {
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.Partial {
+				got = append(got, resp.Content.Parts...)
+			}
+		}
+		if format != "pcm16" {
+			t.Errorf("requested audio format = %q, want pcm16", format)
+		}
+		want := []*genai.Part{genai.NewPartFromText("Hel"), genai.NewPartFromText("lo"), genai.NewPartFromBytes(pcm, "audio/pcm;rate=24000")}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("streamed parts mismatch (-want +got):\n%s", diff)
+		}
+	})
+}
+
+func TestModel_AudioUsage(t *testing.T) {
+	want := &genai.GenerateContentResponseUsageMetadata{
+		PromptTokenCount:     120,
+		CandidatesTokenCount: 45,
+		TotalTokenCount:      165,
+		PromptTokensDetails: []*genai.ModalityTokenCount{
+			{Modality: genai.MediaModalityText, TokenCount: 40},
+			{Modality: genai.MediaModalityAudio, TokenCount: 80},
+		},
+		CandidatesTokensDetails: []*genai.ModalityTokenCount{
+			{Modality: genai.MediaModalityText, TokenCount: 15},
+			{Modality: genai.MediaModalityAudio, TokenCount: 30},
+		},
+	}
+	for _, tc := range []struct {
+		name        string
+		stream      bool
+		fixture     string
+		contentType string
+	}{
+		{"Complete", false, "audio_usage.json", "application/json"},
+		{"Stream", true, "audio_usage.sse", "text/event-stream"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fixture, err := os.ReadFile(filepath.Join("testdata", tc.fixture))
+			if err != nil {
+				t.Fatal(err)
+			}
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tc.contentType)
+				w.Write(fixture)
+			}))
+			defer srv.Close()
+			llm, err := openai.NewModel(t.Context(), "gpt-4o-audio-preview", option.WithBaseURL(srv.URL), option.WithAPIKey("key"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req := &model.LLMRequest{Contents: []*genai.Content{genai.NewContentFromText("say hello in French", genai.RoleUser)}}
+			var got *genai.GenerateContentResponseUsageMetadata
+			for resp, err := range llm.GenerateContent(t.Context(), req, tc.stream) {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if resp.UsageMetadata != nil {
+					got = resp.UsageMetadata
+				}
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("UsageMetadata mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openai
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+
+	"github.com/openai/openai-go/v3/option"
+
+	"google.golang.org/adk/model"
+)
+
+// AzureConfig configures the models created by [NewAzureModel].
+type AzureConfig struct {
+	// Endpoint is the endpoint of the Azure OpenAI resource, e.g.
+	// "https://my-resource.openai.azure.com". Required.
+	Endpoint string
+	// Deployment is the name of the deployment of the model. Defaults to
+	// the name of the model.
+	Deployment string
+	// APIVersion is the version of the Azure OpenAI API sent in the
+	// api-version query parameter, e.g. "2024-10-21". Required.
+	APIVersion string
+	// APIKey is sent in the api-key header, instead of the bearer token of
+	// OpenAI. Optional: the requests can be authenticated by the options
+	// instead, e.g. with a Microsoft Entra ID token.
+	APIKey string
+	// Model configures the model as for [NewModelWithConfig].
+	Model Config
+}
+
+// NewAzureModel returns a model served by an Azure OpenAI deployment.
+// modelName is the logical name of the model, returned by Name. The
+// requests are sent to the deployment-scoped paths of the endpoint; the
+// responses, the streaming and the errors are handled as for [NewModel].
+func NewAzureModel(ctx context.Context, modelName string, cfg AzureConfig, opts ...option.RequestOption) (model.LLM, error) {
+	if cfg.Endpoint == "" {
+		return nil, errors.New("azure endpoint is required")
+	}
+	if cfg.APIVersion == "" {
+		return nil, errors.New("azure API version is required")
+	}
+	deployment := cfg.Deployment
+	if deployment == "" {
+		deployment = modelName
+	}
+	azureOpts := []option.RequestOption{
+		option.WithBaseURL(strings.TrimSuffix(cfg.Endpoint, "/") + "/openai/deployments/" + url.PathEscape(deployment) + "/"),
+		option.WithQuery("api-version", cfg.APIVersion),
+		// The bearer token of the OPENAI_API_KEY environment variable must
+		// not be sent to Azure. A token set by opts is kept.
+		option.WithHeaderDel("authorization"),
+	}
+	if cfg.APIKey != "" {
+		azureOpts = append(azureOpts, option.WithHeader("api-key", cfg.APIKey))
+	}
+	return NewModelWithConfig(ctx, modelName, cfg.Model, append(azureOpts, opts...)...)
+}
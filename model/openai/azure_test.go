@@ -0,0 +1,130 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openai_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/openai/openai-go/v3/option"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/model/openai"
+)
+
+func TestNewAzureModel(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "sk-openai")
+	type request struct {
+		path, apiVersion, apiKey, authorization string
+	}
+	var got []request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, request{r.URL.Path, r.URL.Query().Get("api-version"), r.Header.Get("api-key"), r.Header.Get("Authorization")})
+		if strings.Contains(r.URL.Path, "limited") {
+			w.WriteHeader(http.StatusTooManyRequests)
+			fmt.Fprint(w, `{"error": {"code": "429", "message": "Rate limit is exceeded."}}`)
+			return
+		}
+		var body struct {
+			Stream bool `json:"stream"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if body.Stream {
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprint(w, "data: {\"id\":\"c1\",\"object\":\"chat.completion.chunk\",\"model\":\"gpt-4o\",\"choices\":[{\"index\":0,\"delta\":{\"content\":\"hi\"},\"finish_reason\":\"stop\"}]}\n\n")
+			fmt.Fprint(w, "data: {\"id\":\"c1\",\"object\":\"chat.completion.chunk\",\"model\":\"gpt-4o\",\"choices\":[],\"usage\":{\"prompt_tokens\":3,\"completion_tokens\":1,\"total_tokens\":4}}\n\n")
+			fmt.Fprint(w, "data: [DONE]\n\n")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id": "c1", "object": "chat.completion", "model": "gpt-4o", "choices": [{"index": 0, "finish_reason": "stop", "message": {"role": "assistant", "content": "hi"}}], "usage": {"prompt_tokens": 3, "completion_tokens": 1, "total_tokens": 4}}`)
+	}))
+	defer srv.Close()
+
+	llm, err := openai.NewAzureModel(t.Context(), "gpt-4o", openai.AzureConfig{
+		Endpoint:   srv.URL + "/",
+		Deployment: "prod gpt-4o",
+		APIVersion: "2024-10-21",
+		APIKey:     "azure-key",
+	}, option.WithMaxRetries(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if llm.Name() != "gpt-4o" {
+		t.Errorf("Name() = %q, want gpt-4o", llm.Name())
+	}
+	req := &model.LLMRequest{Model: "gpt-4o", Contents: []*genai.Content{genai.NewContentFromText("hello", genai.RoleUser)}}
+	for _, stream := range []bool{false, true} {
+		got = nil
+		var usage *genai.GenerateContentResponseUsageMetadata
+		for resp, err := range llm.GenerateContent(t.Context(), req, stream) {
+			if err != nil {
+				t.Fatalf("GenerateContent(stream=%v) failed: %v", stream, err)
+			}
+			if resp.UsageMetadata != nil {
+				usage = resp.UsageMetadata
+			}
+		}
+		want := request{path: "/openai/deployments/prod gpt-4o/chat/completions", apiVersion: "2024-10-21", apiKey: "azure-key"}
+		if len(got) != 1 || got[0] != want {
+			t.Errorf("GenerateContent(stream=%v) requests = %+v, want %+v", stream, got, want)
+		}
+		if usage == nil || usage.TotalTokenCount != 4 {
+			t.Errorf("GenerateContent(stream=%v) usage = %+v, want 4 total tokens", stream, usage)
+		}
+	}
+
+	// The errors are classified as for OpenAI.
+	limited, err := openai.NewAzureModel(t.Context(), "gpt-4o", openai.AzureConfig{Endpoint: srv.URL, Deployment: "limited", APIVersion: "2024-10-21"}, openai.WithRetry(openai.RetryConfig{MaxAttempts: 1}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = nil
+	for _, stream := range []bool{false, true} {
+		var gotErr error
+		for _, err := range limited.GenerateContent(t.Context(), req, stream) {
+			gotErr = err
+		}
+		if code := model.CodeOf(gotErr); code != model.ErrorCodeRateLimited {
+			t.Errorf("GenerateContent(stream=%v) error code = %q (%v), want %q", stream, code, gotErr, model.ErrorCodeRateLimited)
+		}
+	}
+	// Without an API key, the OpenAI key is not sent either.
+	for _, r := range got {
+		if r.authorization != "" {
+			t.Errorf("request without an API key has Authorization %q, want none", r.authorization)
+		}
+	}
+
+	// A token set by the options is sent.
+	entra, err := openai.NewAzureModel(t.Context(), "gpt-4o", openai.AzureConfig{Endpoint: srv.URL, APIVersion: "2024-10-21"}, option.WithHeader("Authorization", "Bearer entra-token"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = nil
+	for _, err := range entra.GenerateContent(t.Context(), req, false) {
+		if err != nil {
+			t.Fatalf("GenerateContent() failed: %v", err)
+		}
+	}
+	if len(got) != 1 || got[0].authorization != "Bearer entra-token" {
+		t.Errorf("GenerateContent() requests = %+v, want the Authorization of the options", got)
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openai
+
+import (
+	"context"
+	"errors"
+
+	"github.com/openai/openai-go/v3/option"
+
+	"google.golang.org/adk/model"
+)
+
+// SystemRole is the role of the messages carrying the system instruction.
+type SystemRole string
+
+const (
+	// SystemRoleAuto sends the system instruction as developer messages to
+	// the reasoning models, which expect them, and as system messages to
+	// the other models.
+	SystemRoleAuto SystemRole = ""
+	// SystemRoleSystem always sends the system instruction as system
+	// messages, which all the OpenAI-compatible servers accept.
+	SystemRoleSystem SystemRole = "system"
+	// SystemRoleDeveloper always sends the system instruction as developer
+	// messages.
+	SystemRoleDeveloper SystemRole = "developer"
+)
+
+// developer reports whether the system instruction is sent as developer
+// messages, given whether the model is a reasoning model.
+func (r SystemRole) developer(reasoning bool) bool {
+	switch r {
+	case SystemRoleSystem:
+		return false
+	case SystemRoleDeveloper:
+		return true
+	default:
+		return reasoning
+	}
+}
+
+// CompatibilityOptions configures the models created by
+// [NewCompatibleModel]. The zero value suits most of the OpenAI-compatible
+// servers, e.g. Ollama, vLLM, OpenRouter or LM Studio: it only sends the
+// parameters that they all accept.
+type CompatibilityOptions struct {
+	// IncludeUsage asks the streams for their usage, with
+	// stream_options.include_usage, which some servers reject. By default,
+	// the streamed responses carry no usage.
+	IncludeUsage bool
+	// DeveloperRole sends the system instruction as developer messages, as
+	// expected by the OpenAI reasoning models. By default, it is sent as
+	// system messages, which some servers require.
+	DeveloperRole bool
+	// StrictFunctions declares the functions strict, see
+	// [Config.StrictFunctions], for the servers supporting it.
+	StrictFunctions bool
+	// StrictConfig makes the requests setting parameters that cannot be
+	// sent fail, see [Config.StrictConfig]. By default, these parameters
+	// are ignored and reported with the strictness policy of the context.
+	StrictConfig bool
+	// SeparateMessages sends the adjacent messages with the same role
+	// separately. By default, they are merged, see [Config.MergeMessages],
+	// since some servers reject them.
+	SeparateMessages bool
+	// Model configures the model as for [NewModelWithConfig]. The options
+	// above override its fields.
+	Model Config
+}
+
+// NewCompatibleModel returns the model modelName served by the
+// OpenAI-compatible server at baseURL, e.g. "http://localhost:11434/v1/"
+// for Ollama. The requests only send the parameters enabled by caps; the
+// responses, the streaming and the errors are handled as for [NewModel].
+func NewCompatibleModel(ctx context.Context, modelName, baseURL string, caps CompatibilityOptions, opts ...option.RequestOption) (model.LLM, error) {
+	if baseURL == "" {
+		return nil, errors.New("base URL is required")
+	}
+	cfg := caps.Model
+	cfg.DisableStreamUsage = !caps.IncludeUsage
+	cfg.SystemRole = SystemRoleSystem
+	if caps.DeveloperRole {
+		cfg.SystemRole = SystemRoleDeveloper
+	}
+	cfg.StrictFunctions = caps.StrictFunctions
+	cfg.StrictConfig = caps.StrictConfig
+	cfg.MergeMessages = !caps.SeparateMessages
+	return NewModelWithConfig(ctx, modelName, cfg, append([]option.RequestOption{option.WithBaseURL(baseURL)}, opts...)...)
+}
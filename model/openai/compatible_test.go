@@ -0,0 +1,167 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openai_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openai/openai-go/v3/option"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/model/openai"
+)
+
+// compatibleServer is a minimal OpenAI-compatible server, returning no
+// usage, which records the body of the last request.
+func compatibleServer(t *testing.T, body *map[string]any) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*body = nil
+		_ = json.NewDecoder(r.Body).Decode(body)
+		if (*body)["stream"] != true {
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"id": "c1", "object": "chat.completion", "model": "llama3", "choices": [{"index": 0, "finish_reason": "stop", "message": {"role": "assistant", "content": "ok"}}]}`)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, `data: {"id":"c1","object":"chat.completion.chunk","model":"llama3","choices":[{"index":0,"delta":{"role":"assistant","content":"ok"},"finish_reason":"stop"}]}`+"\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestNewCompatibleModel(t *testing.T) {
+	var body map[string]any
+	srv := compatibleServer(t, &body)
+	req := func() *model.LLMRequest {
+		return &model.LLMRequest{
+			Contents: []*genai.Content{
+				genai.NewContentFromText("hi", genai.RoleUser),
+				genai.NewContentFromText("there", genai.RoleUser),
+			},
+			Config: &genai.GenerateContentConfig{
+				SystemInstruction: genai.NewContentFromText("be brief", ""),
+				TopK:              genai.Ptr[float32](40),
+				Tools:             []*genai.Tool{{FunctionDeclarations: []*genai.FunctionDeclaration{{Name: "get_time", Description: "returns the time"}}}},
+			},
+		}
+	}
+	generate := func(t *testing.T, caps openai.CompatibilityOptions, stream bool) ([]*model.LLMResponse, error) {
+		t.Helper()
+		llm, err := openai.NewCompatibleModel(t.Context(), "llama3", srv.URL, caps, option.WithAPIKey("key"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var responses []*model.LLMResponse
+		for resp, err := range llm.GenerateContent(t.Context(), req(), stream) {
+			if err != nil {
+				return nil, err
+			}
+			responses = append(responses, resp)
+		}
+		return responses, nil
+	}
+	function := func() any {
+		tools, _ := body["tools"].([]any)
+		tool, _ := tools[0].(map[string]any)
+		return tool["function"]
+	}
+
+	t.Run("Default", func(t *testing.T) {
+		for _, stream := range []bool{false, true} {
+			responses, err := generate(t, openai.CompatibilityOptions{}, stream)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, ok := body["stream_options"]; ok {
+				t.Errorf("stream_options = %v, want none", body["stream_options"])
+			}
+			for _, resp := range responses {
+				if resp.UsageMetadata != nil {
+					t.Errorf("UsageMetadata = %+v, want nil for a server returning no usage", resp.UsageMetadata)
+				}
+			}
+			want := []any{
+				map[string]any{"role": "system", "content": "be brief"},
+				map[string]any{"role": "user", "content": []any{
+					map[string]any{"type": "text", "text": "hi"},
+					map[string]any{"type": "text", "text": "there"},
+				}},
+			}
+			if diff := cmp.Diff(want, body["messages"]); diff != "" {
+				t.Errorf("messages mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(map[string]any{"name": "get_time", "description": "returns the time"}, function()); diff != "" {
+				t.Errorf("function mismatch (-want +got):\n%s", diff)
+			}
+		}
+	})
+	t.Run("IncludeUsage", func(t *testing.T) {
+		if _, err := generate(t, openai.CompatibilityOptions{IncludeUsage: true}, true); err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(map[string]any{"include_usage": true}, body["stream_options"]); diff != "" {
+			t.Errorf("stream_options mismatch (-want +got):\n%s", diff)
+		}
+	})
+	t.Run("DeveloperRole", func(t *testing.T) {
+		if _, err := generate(t, openai.CompatibilityOptions{DeveloperRole: true}, false); err != nil {
+			t.Fatal(err)
+		}
+		messages, _ := body["messages"].([]any)
+		if diff := cmp.Diff(map[string]any{"role": "developer", "content": "be brief"}, messages[0]); diff != "" {
+			t.Errorf("system instruction mismatch (-want +got):\n%s", diff)
+		}
+	})
+	t.Run("StrictFunctions", func(t *testing.T) {
+		if _, err := generate(t, openai.CompatibilityOptions{StrictFunctions: true}, false); err != nil {
+			t.Fatal(err)
+		}
+		fn, _ := function().(map[string]any)
+		if fn["strict"] != true {
+			t.Errorf("function = %v, want it strict", fn)
+		}
+	})
+	t.Run("StrictConfig", func(t *testing.T) {
+		if _, err := generate(t, openai.CompatibilityOptions{StrictConfig: true}, false); err == nil {
+			t.Error("GenerateContent() with top_k succeeded, want an error")
+		}
+	})
+	t.Run("SeparateMessages", func(t *testing.T) {
+		if _, err := generate(t, openai.CompatibilityOptions{SeparateMessages: true}, false); err != nil {
+			t.Fatal(err)
+		}
+		want := []any{
+			map[string]any{"role": "system", "content": "be brief"},
+			map[string]any{"role": "user", "content": "hi"},
+			map[string]any{"role": "user", "content": "there"},
+		}
+		if diff := cmp.Diff(want, body["messages"]); diff != "" {
+			t.Errorf("messages mismatch (-want +got):\n%s", diff)
+		}
+	})
+	t.Run("NoBaseURL", func(t *testing.T) {
+		if _, err := openai.NewCompatibleModel(t.Context(), "llama3", "", openai.CompatibilityOptions{}); err == nil {
+			t.Error("NewCompatibleModel() without base URL succeeded, want an error")
+		}
+	})
+}
@@ -0,0 +1,48 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openai
+
+import (
+	"cmp"
+	"encoding/base64"
+	"fmt"
+
+	"github.com/openai/openai-go/v3"
+	"github.com/openai/openai-go/v3/packages/param"
+	"google.golang.org/genai"
+)
+
+// maxFileSize is the largest file accepted by OpenAI as a file content
+// part.
+const maxFileSize = 32 << 20
+
+// isPDF reports whether blob is a PDF document.
+func isPDF(blob *genai.Blob) bool {
+	return blob.MIMEType == "application/pdf"
+}
+
+// filePart returns the document blob as a file content part, with a base64
+// data URL and the display name of the blob as file name. It fails if the
+// document is larger than OpenAI accepts.
+func filePart(blob *genai.Blob) (openai.ChatCompletionContentPartUnionParam, error) {
+	if len(blob.Data) > maxFileSize {
+		return openai.ChatCompletionContentPartUnionParam{}, fmt.Errorf("%s file of %d bytes is too large: OpenAI accepts files of at most %d bytes", blob.MIMEType, len(blob.Data), maxFileSize)
+	}
+	url := "data:" + blob.MIMEType + ";base64," + base64.StdEncoding.EncodeToString(blob.Data)
+	return openai.FileContentPart(openai.ChatCompletionContentPartFileFileParam{
+		FileData: param.NewOpt(url),
+		Filename: param.NewOpt(cmp.Or(blob.DisplayName, "document.pdf")),
+	}), nil
+}
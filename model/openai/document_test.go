@@ -0,0 +1,105 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openai_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openai/openai-go/v3/option"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/model/openai"
+)
+
+func TestModel_FileInput(t *testing.T) {
+	var got []any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Messages []any `json:"messages"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		got = body.Messages
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id": "c1", "object": "chat.completion", "model": "gpt-4o", "choices": [{"index": 0, "finish_reason": "stop", "message": {"role": "assistant", "content": "ok"}}]}`)
+	}))
+	defer srv.Close()
+
+	pdf := &genai.Part{InlineData: &genai.Blob{Data: []byte("%PDF-1.7"), MIMEType: "application/pdf", DisplayName: "report.pdf"}}
+	tests := []struct {
+		name      string
+		fileInput bool
+		part      *genai.Part
+		want      []any
+		wantErr   string
+	}{
+		{
+			name:      "Enabled",
+			fileInput: true,
+			part:      pdf,
+			want: []any{map[string]any{"role": "user", "content": []any{
+				map[string]any{"type": "text", "text": "summarize"},
+				map[string]any{"type": "file", "file": map[string]any{"filename": "report.pdf", "file_data": "data:application/pdf;base64,JVBERi0xLjc="}},
+			}}},
+		},
+		{
+			name: "Disabled",
+			part: pdf,
+			want: []any{
+				map[string]any{"role": "user", "content": "summarize"},
+				map[string]any{"role": "user", "content": "[application/pdf data of 8 bytes omitted: only images are supported]"},
+			},
+		},
+		{
+			name:      "TooLarge",
+			fileInput: true,
+			part:      genai.NewPartFromBytes(make([]byte, 32<<20+1), "application/pdf"),
+			wantErr:   "OpenAI accepts files of at most 33554432 bytes",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got = nil
+			llm, err := openai.NewModelWithConfig(t.Context(), "gpt-4o", openai.Config{FileInput: tc.fileInput}, option.WithBaseURL(srv.URL), option.WithAPIKey("key"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req := &model.LLMRequest{
+				Model:    "gpt-4o",
+				Contents: []*genai.Content{{Role: genai.RoleUser, Parts: []*genai.Part{genai.NewPartFromText("summarize"), tc.part}}},
+			}
+			for _, err := range llm.GenerateContent(t.Context(), req, false) {
+				if tc.wantErr != "" {
+					if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+						t.Errorf("GenerateContent() error = %v, want an error containing %q", err, tc.wantErr)
+					}
+					continue
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("messages mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
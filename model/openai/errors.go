@@ -0,0 +1,108 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openai
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+
+	"github.com/openai/openai-go/v3"
+
+	"google.golang.org/adk/model"
+)
+
+// ErrorCode classifies an error returned by the OpenAI client. API errors
+// are classified by their status code and error code; network errors and
+// idle streams are reported as unavailable.
+func ErrorCode(err error) model.ErrorCode {
+	var apiErr *openai.Error
+	if errors.As(err, &apiErr) {
+		return apiErrorCode(apiErr.StatusCode, apiErr.Type, apiErr.Code)
+	}
+	if errors.Is(err, ErrStreamIdle) {
+		return model.ErrorCodeUnavailable
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return model.ErrorCodeUnknown
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return model.ErrorCodeUnavailable
+	}
+	return model.ErrorCodeUnknown
+}
+
+// apiErrorCode classifies an OpenAI API error. The type and code of the
+// error take precedence over the status code, which is less specific.
+func apiErrorCode(status int, errType, code string) model.ErrorCode {
+	switch {
+	case code == "insufficient_quota" || errType == "insufficient_quota":
+		return model.ErrorCodeQuotaExceeded
+	case code == "context_length_exceeded" || code == "string_above_max_length":
+		return model.ErrorCodeContextTooLong
+	case code == "array_above_max_length":
+		// Reported for requests declaring more tools than allowed.
+		return model.ErrorCodeRequestTooComplex
+	case code == "content_filter" || code == "content_policy_violation":
+		return model.ErrorCodeSafetyBlocked
+	case code == "invalid_api_key" || errType == "authentication_error":
+		return model.ErrorCodeAuthFailed
+	case code == "rate_limit_exceeded":
+		return model.ErrorCodeRateLimited
+	}
+	switch {
+	case status == http.StatusTooManyRequests:
+		return model.ErrorCodeRateLimited
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return model.ErrorCodeAuthFailed
+	case status == http.StatusRequestEntityTooLarge:
+		return model.ErrorCodeContextTooLong
+	case status >= 500:
+		return model.ErrorCodeUnavailable
+	case status >= 400:
+		return model.ErrorCodeInvalidRequest
+	default:
+		return model.ErrorCodeUnknown
+	}
+}
+
+// rejectedResponse returns the response reporting err if it is the
+// rejection of the request by the content filter. Like the prompts blocked
+// by the other providers, it is a response carrying an error code rather
+// than a failed call: the flows and callbacks handle it as a blocked
+// response. The other errors are returned as errors, classified by
+// [classify].
+func rejectedResponse(err error) (*model.LLMResponse, bool) {
+	var apiErr *openai.Error
+	if !errors.As(err, &apiErr) || ErrorCode(err) != model.ErrorCodeSafetyBlocked {
+		return nil, false
+	}
+	msg := apiErr.Message
+	if msg == "" {
+		msg = "The request was blocked by the content filter."
+	}
+	return &model.LLMResponse{
+		ErrorCode:    string(model.ErrorCodeSafetyBlocked),
+		ErrorMessage: msg,
+		TurnComplete: true,
+	}, true
+}
+
+// classify wraps err in a [model.Error] carrying its code.
+func classify(err error) error {
+	return &model.Error{Code: ErrorCode(err), Err: err}
+}
@@ -0,0 +1,146 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openai_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	oai "github.com/openai/openai-go/v3"
+	"github.com/openai/openai-go/v3/option"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/model/openai"
+)
+
+// newErrorServer returns a server failing the chat completions with the
+// given status and error body.
+func newErrorServer(t *testing.T, status int, errType, code string) *httptest.Server {
+	t.Helper()
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"error": map[string]any{"message": "failed", "type": errType, "code": code},
+		})
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestErrorCode(t *testing.T) {
+	testCases := []struct {
+		name    string
+		status  int
+		errType string
+		code    string
+		want    model.ErrorCode
+	}{
+		{"RateLimited", http.StatusTooManyRequests, "requests", "rate_limit_exceeded", model.ErrorCodeRateLimited},
+		{"QuotaExceeded", http.StatusTooManyRequests, "insufficient_quota", "insufficient_quota", model.ErrorCodeQuotaExceeded},
+		{"ContextTooLong", http.StatusBadRequest, "invalid_request_error", "context_length_exceeded", model.ErrorCodeContextTooLong},
+		{"TooManyTools", http.StatusBadRequest, "invalid_request_error", "array_above_max_length", model.ErrorCodeRequestTooComplex},
+		{"SafetyBlocked", http.StatusBadRequest, "invalid_request_error", "content_policy_violation", model.ErrorCodeSafetyBlocked},
+		{"ContentFilter", http.StatusBadRequest, "invalid_request_error", "content_filter", model.ErrorCodeSafetyBlocked},
+		{"InvalidRequest", http.StatusBadRequest, "invalid_request_error", "", model.ErrorCodeInvalidRequest},
+		{"AuthFailed", http.StatusUnauthorized, "invalid_request_error", "invalid_api_key", model.ErrorCodeAuthFailed},
+		{"Unavailable", http.StatusServiceUnavailable, "server_error", "", model.ErrorCodeUnavailable},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := newErrorServer(t, tc.status, tc.errType, tc.code)
+			llm, err := openai.NewModel(t.Context(), "gpt-4o",
+				option.WithBaseURL(s.URL),
+				option.WithAPIKey("test"),
+				openai.WithRetry(openai.RetryConfig{MaxAttempts: 1}))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, stream := range []bool{false, true} {
+				var (
+					gotErr  error
+					gotResp *model.LLMResponse
+				)
+				for resp, err := range llm.GenerateContent(t.Context(), &model.LLMRequest{
+					Contents: []*genai.Content{genai.NewContentFromText("hello", genai.RoleUser)},
+				}, stream) {
+					if err != nil {
+						gotErr = err
+					}
+					if resp != nil {
+						gotResp = resp
+					}
+				}
+				// The requests rejected by the content filter get a response
+				// carrying the code, the other failures an error.
+				if tc.want == model.ErrorCodeSafetyBlocked {
+					if gotErr != nil || gotResp == nil || model.ErrorCode(gotResp.ErrorCode) != tc.want || gotResp.ErrorMessage != "failed" {
+						t.Errorf("GenerateContent(stream=%v) = (%+v, %v), want a response with code %q", stream, gotResp, gotErr, tc.want)
+					}
+					continue
+				}
+				if got := model.CodeOf(gotErr); got != tc.want {
+					t.Errorf("GenerateContent(stream=%v) error code = %q (%v), want %q", stream, got, gotErr, tc.want)
+				}
+			}
+		})
+	}
+}
+
+func TestErrorCode_Network(t *testing.T) {
+	s := httptest.NewServer(http.NotFoundHandler())
+	s.Close()
+	llm, err := openai.NewModel(t.Context(), "gpt-4o", option.WithBaseURL(s.URL), option.WithAPIKey("test"), openai.WithRetry(openai.RetryConfig{MaxAttempts: 1}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, err := range llm.GenerateContent(t.Context(), &model.LLMRequest{
+		Contents: []*genai.Content{genai.NewContentFromText("hello", genai.RoleUser)},
+	}, false) {
+		if got := model.CodeOf(err); got != model.ErrorCodeUnavailable {
+			t.Errorf("GenerateContent() error code = %q (%v), want %q", got, err, model.ErrorCodeUnavailable)
+		}
+	}
+	if got := openai.ErrorCode(errors.New("boom")); got != model.ErrorCodeUnknown {
+		t.Errorf("ErrorCode() = %q, want %q", got, model.ErrorCodeUnknown)
+	}
+}
+
+func TestChatCompletion2LLMResponse_ErrorCode(t *testing.T) {
+	testCases := []struct {
+		name string
+		body string
+		want model.ErrorCode
+	}{
+		{"NoChoices", `{"id": "1", "choices": []}`, model.ErrorCodeUnknown},
+		{"ContentFilter", `{"id": "1", "choices": [{"index": 0, "finish_reason": "content_filter", "message": {"role": "assistant", "content": ""}}]}`, model.ErrorCodeSafetyBlocked},
+		{"Stop", `{"id": "1", "choices": [{"index": 0, "finish_reason": "stop", "message": {"role": "assistant", "content": "hi"}}]}`, ""},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var completion oai.ChatCompletion
+			if err := json.Unmarshal([]byte(tc.body), &completion); err != nil {
+				t.Fatal(err)
+			}
+			if got := model.ErrorCode(openai.ChatCompletion2LLMResponse(&completion).ErrorCode); got != tc.want {
+				t.Errorf("ErrorCode = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openai
+
+import (
+	"encoding/json"
+
+	"github.com/openai/openai-go/v3"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/model"
+)
+
+// EstimatedUsageMetadataKey is the key of the custom metadata marking the
+// usage of a streamed response as estimated, see
+// [Config.EstimateStreamUsage].
+const EstimatedUsageMetadataKey = "openai_estimated_usage"
+
+// usageEstimator estimates the usage of a stream which reports none, at
+// about four bytes per token: the prompt from the JSON encoding of the
+// messages and tools of the request, and the output from the text and the
+// function calls streamed.
+type usageEstimator struct {
+	promptBytes int
+	outputBytes int
+	// reported is set if the stream reported its usage.
+	reported bool
+}
+
+func newUsageEstimator(body *openai.ChatCompletionNewParams) *usageEstimator {
+	e := &usageEstimator{}
+	if b, err := json.Marshal(body.Messages); err == nil {
+		e.promptBytes += len(b)
+	}
+	if b, err := json.Marshal(body.Tools); err == nil && len(body.Tools) > 0 {
+		e.promptBytes += len(b)
+	}
+	return e
+}
+
+// wrap returns a function yielding the responses of the stream, and
+// counting their output.
+func (e *usageEstimator) wrap(yield func(*model.LLMResponse, error) bool) func(*model.LLMResponse, error) bool {
+	return func(resp *model.LLMResponse, err error) bool {
+		if resp != nil {
+			e.add(resp)
+		}
+		return yield(resp, err)
+	}
+}
+
+func (e *usageEstimator) add(resp *model.LLMResponse) {
+	if resp.UsageMetadata != nil {
+		e.reported = true
+	}
+	if resp.Content == nil {
+		return
+	}
+	for _, part := range resp.Content.Parts {
+		e.outputBytes += len(part.Text)
+		if part.FunctionCall != nil {
+			if b, err := json.Marshal(part.FunctionCall.Args); err == nil {
+				e.outputBytes += len(part.FunctionCall.Name) + len(b)
+			}
+		}
+	}
+}
+
+// response returns the response carrying the estimated usage of the
+// stream, marked as estimated, or nil if the stream reported its usage.
+func (e *usageEstimator) response() *model.LLMResponse {
+	if e.reported {
+		return nil
+	}
+	prompt, output := int32((e.promptBytes+3)/4), int32((e.outputBytes+3)/4)
+	return &model.LLMResponse{
+		UsageMetadata: &genai.GenerateContentResponseUsageMetadata{
+			PromptTokenCount:     prompt,
+			CandidatesTokenCount: output,
+			TotalTokenCount:      prompt + output,
+		},
+		CustomMetadata: map[string]any{EstimatedUsageMetadataKey: true},
+		TurnComplete:   true,
+	}
+}
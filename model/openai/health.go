@@ -0,0 +1,96 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openai
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/openai/openai-go/v3"
+)
+
+// HealthCheckConfig configures the health probe of an OpenAI model.
+type HealthCheckConfig struct {
+	// ProbeModel is the model sent a one-token completion to check the
+	// backend. If empty, the models are listed instead, which does not use
+	// any quota but does not check that a model can be used.
+	ProbeModel string
+	// Timeout limits the duration of a probe. Defaults to 10 seconds.
+	Timeout time.Duration
+	// Interval is how long the result of a probe is reused, so that
+	// frequent readiness checks do not call the API each time. Defaults to
+	// 30 seconds.
+	Interval time.Duration
+	// Now returns the current time. Defaults to time.Now.
+	Now func() time.Time
+}
+
+// healthProbe caches the result of the last health check.
+type healthProbe struct {
+	cfg HealthCheckConfig
+
+	mu        sync.Mutex
+	checkedAt time.Time
+	err       error
+}
+
+func newHealthProbe(cfg HealthCheckConfig) *healthProbe {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = 30 * time.Second
+	}
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+	return &healthProbe{cfg: cfg}
+}
+
+// HealthCheck implements model.HealthChecker. The result of a probe is
+// reused for the configured interval; the concurrent checks wait for the
+// probe in progress.
+func (o *openaiModel) HealthCheck(ctx context.Context) error {
+	p := o.health
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if now := p.cfg.Now(); !p.checkedAt.IsZero() && now.Sub(p.checkedAt) < p.cfg.Interval {
+		return p.err
+	}
+
+	probeCtx, cancel := context.WithTimeout(ctx, p.cfg.Timeout)
+	defer cancel()
+	var err error
+	if p.cfg.ProbeModel == "" {
+		_, err = o.client.Models.List(probeCtx)
+	} else {
+		_, err = o.client.Chat.Completions.New(probeCtx, openai.ChatCompletionNewParams{
+			Model:               p.cfg.ProbeModel,
+			Messages:            []openai.ChatCompletionMessageParamUnion{openai.UserMessage("ping")},
+			MaxCompletionTokens: openai.Int(1),
+		})
+	}
+	if err != nil {
+		err = fmt.Errorf("OpenAI health check failed: %w", classify(err))
+	}
+	if ctx.Err() != nil {
+		// The caller gave up: the backend was not checked.
+		return err
+	}
+	p.checkedAt, p.err = p.cfg.Now(), err
+	return err
+}
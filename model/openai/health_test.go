@@ -0,0 +1,143 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openai_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/openai/openai-go/v3/option"
+
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/model/openai"
+)
+
+// stubBackend serves the models list and the chat completions, failing with
+// status if it is not zero.
+type stubBackend struct {
+	status   atomic.Int32
+	requests atomic.Int32
+	paths    []string
+}
+
+func (b *stubBackend) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	b.requests.Add(1)
+	b.paths = append(b.paths, r.URL.Path)
+	w.Header().Set("Content-Type", "application/json")
+	if status := int(b.status.Load()); status != 0 {
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(map[string]any{"error": map[string]any{"message": "invalid key", "type": "authentication_error", "code": "invalid_api_key"}})
+		return
+	}
+	switch r.URL.Path {
+	case "/models":
+		_ = json.NewEncoder(w).Encode(map[string]any{"object": "list", "data": []any{}})
+	case "/chat/completions":
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if body["max_completion_tokens"] != 1.0 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"id": "c1", "object": "chat.completion", "model": body["model"],
+			"choices": []any{map[string]any{"index": 0, "finish_reason": "length", "message": map[string]any{"role": "assistant", "content": "p"}}},
+		})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func newHealthChecker(t *testing.T, backend *stubBackend, cfg openai.HealthCheckConfig) model.HealthChecker {
+	t.Helper()
+	server := httptest.NewServer(backend)
+	t.Cleanup(server.Close)
+	llm, err := openai.NewModelWithConfig(t.Context(), "gpt-4o", openai.Config{HealthCheck: cfg},
+		option.WithBaseURL(server.URL), option.WithAPIKey("key"), option.WithMaxRetries(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checker, ok := llm.(model.HealthChecker)
+	if !ok {
+		t.Fatal("OpenAI model does not implement model.HealthChecker")
+	}
+	return checker
+}
+
+func TestHealthCheck(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		cfg      openai.HealthCheckConfig
+		wantPath string
+	}{
+		{"ListModels", openai.HealthCheckConfig{}, "/models"},
+		{"ProbeModel", openai.HealthCheckConfig{ProbeModel: "gpt-4o-mini"}, "/chat/completions"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			backend := &stubBackend{}
+			if err := newHealthChecker(t, backend, tc.cfg).HealthCheck(t.Context()); err != nil {
+				t.Fatalf("HealthCheck() failed: %v", err)
+			}
+			if len(backend.paths) != 1 || backend.paths[0] != tc.wantPath {
+				t.Errorf("requested paths = %v, want %s", backend.paths, tc.wantPath)
+			}
+		})
+	}
+}
+
+func TestHealthCheck_Unhealthy(t *testing.T) {
+	backend := &stubBackend{}
+	backend.status.Store(http.StatusUnauthorized)
+	err := newHealthChecker(t, backend, openai.HealthCheckConfig{}).HealthCheck(t.Context())
+	var modelErr *model.Error
+	if !errors.As(err, &modelErr) || modelErr.Code != model.ErrorCodeAuthFailed {
+		t.Errorf("HealthCheck() error = %v, want an authentication failure", err)
+	}
+}
+
+func TestHealthCheck_Interval(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	backend := &stubBackend{}
+	checker := newHealthChecker(t, backend, openai.HealthCheckConfig{Interval: time.Minute, Now: func() time.Time { return now }})
+
+	check := func(t *testing.T, wantErr bool, wantRequests int32) {
+		t.Helper()
+		if err := checker.HealthCheck(t.Context()); (err != nil) != wantErr {
+			t.Errorf("HealthCheck() error = %v, want error %v", err, wantErr)
+		}
+		if got := backend.requests.Load(); got != wantRequests {
+			t.Errorf("requests = %d, want %d", got, wantRequests)
+		}
+	}
+
+	check(t, false, 1)
+	// The result is reused within the interval, even if the backend fails.
+	backend.status.Store(http.StatusUnauthorized)
+	now = now.Add(30 * time.Second)
+	check(t, false, 1)
+	now = now.Add(30 * time.Second)
+	check(t, true, 2)
+	// Failures are cached too.
+	backend.status.Store(0)
+	now = now.Add(59 * time.Second)
+	check(t, true, 2)
+	now = now.Add(time.Second)
+	check(t, false, 3)
+}
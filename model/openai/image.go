@@ -0,0 +1,54 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openai
+
+import "google.golang.org/genai"
+
+// ImageDetail is the detail level of the images sent to the model, which
+// trades the understanding of their details for their tokens.
+type ImageDetail string
+
+const (
+	// ImageDetailDefault sends the images without a detail level, which
+	// OpenAI handles as ImageDetailAuto.
+	ImageDetailDefault ImageDetail = ""
+	// ImageDetailAuto lets the model choose the detail level of the images
+	// from their size.
+	ImageDetailAuto ImageDetail = "auto"
+	// ImageDetailLow sends a low resolution version of the images, for a
+	// small fixed number of tokens, e.g. for screenshots.
+	ImageDetailLow ImageDetail = "low"
+	// ImageDetailHigh sends the images at a high resolution, for more
+	// tokens.
+	ImageDetailHigh ImageDetail = "high"
+)
+
+// imageDetail returns the detail level of the image of part: the level
+// mapped from its media resolution if it has one, or else fallback. The low
+// resolution maps to ImageDetailLow, the high ones to ImageDetailHigh and
+// the others to ImageDetailAuto.
+func imageDetail(part *genai.Part, fallback ImageDetail) string {
+	if part.MediaResolution == nil || part.MediaResolution.Level == "" {
+		return string(fallback)
+	}
+	switch part.MediaResolution.Level {
+	case genai.PartMediaResolutionLevelMediaResolutionLow:
+		return string(ImageDetailLow)
+	case genai.PartMediaResolutionLevelMediaResolutionHigh, genai.PartMediaResolutionLevelMediaResolutionUltraHigh:
+		return string(ImageDetailHigh)
+	default:
+		return string(ImageDetailAuto)
+	}
+}
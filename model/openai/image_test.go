@@ -0,0 +1,101 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openai_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openai/openai-go/v3/option"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/model/openai"
+)
+
+func TestModel_ImageDetail(t *testing.T) {
+	var got []any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Messages []struct {
+				Content []struct {
+					ImageURL map[string]any `json:"image_url"`
+				} `json:"content"`
+			} `json:"messages"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		got = nil
+		for _, part := range body.Messages[0].Content {
+			got = append(got, part.ImageURL["detail"])
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id": "c1", "object": "chat.completion", "model": "gpt-4o", "choices": [{"index": 0, "finish_reason": "stop", "message": {"role": "assistant", "content": "ok"}}]}`)
+	}))
+	defer srv.Close()
+
+	png := []byte("\x89PNG")
+	withResolution := func(part *genai.Part, level genai.PartMediaResolutionLevel) *genai.Part {
+		part.MediaResolution = &genai.PartMediaResolution{Level: level}
+		return part
+	}
+	parts := []*genai.Part{
+		genai.NewPartFromBytes(png, "image/png"),
+		withResolution(genai.NewPartFromBytes(png, "image/png"), genai.PartMediaResolutionLevelMediaResolutionLow),
+		withResolution(genai.NewPartFromURI("https://example.com/chart.png", "image/png"), genai.PartMediaResolutionLevelMediaResolutionHigh),
+		withResolution(genai.NewPartFromBytes(png, "image/png"), genai.PartMediaResolutionLevelMediaResolutionMedium),
+		genai.NewPartFromURI("https://example.com/cat.jpg", "image/jpeg"),
+	}
+	tests := []struct {
+		name   string
+		detail openai.ImageDetail
+		want   []any
+	}{
+		{
+			name: "Default",
+			want: []any{nil, "low", "high", "auto", nil},
+		},
+		{
+			name:   "Low",
+			detail: openai.ImageDetailLow,
+			want:   []any{"low", "low", "high", "auto", "low"},
+		},
+		{
+			name:   "High",
+			detail: openai.ImageDetailHigh,
+			want:   []any{"high", "low", "high", "auto", "high"},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			llm, err := openai.NewModelWithConfig(t.Context(), "gpt-4o", openai.Config{ImageDetail: tc.detail}, option.WithBaseURL(srv.URL), option.WithAPIKey("key"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req := &model.LLMRequest{Model: "gpt-4o", Contents: []*genai.Content{{Role: genai.RoleUser, Parts: parts}}}
+			for _, err := range llm.GenerateContent(t.Context(), req, false) {
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("image detail levels mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openai
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"maps"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/openai/openai-go/v3"
+	"github.com/openai/openai-go/v3/packages/param"
+	"github.com/openai/openai-go/v3/shared"
+
+	"google.golang.org/adk/strictness"
+)
+
+// UserLabel is the key of the label of the requests sent as their user
+// field, which identifies the end user to OpenAI.
+const UserLabel = "user_id"
+
+// The limits of the metadata of the requests.
+const (
+	maxMetadataPairs = 16
+	maxMetadataKey   = 64
+	maxMetadataValue = 512
+)
+
+// applyLabels sends the labels of a request: UserLabel as its user, and the
+// others but PredictionLabel as its metadata if metadata is set. The keys are sanitized and the
+// keys and values truncated to the limits of OpenAI; the labels beyond the
+// first 16, in the order of their keys, are dropped and reported with the
+// strictness policy of ctx.
+func applyLabels(ctx context.Context, params *openai.ChatCompletionNewParams, labels map[string]string, metadata bool) error {
+	if user := labels[UserLabel]; user != "" {
+		params.User = param.NewOpt(truncate(user, maxMetadataValue))
+	}
+	if !metadata {
+		return nil
+	}
+	for _, key := range slices.Sorted(maps.Keys(labels)) {
+		if key == UserLabel || key == PredictionLabel {
+			continue
+		}
+		name := metadataKey(key)
+		if _, ok := params.Metadata[name]; ok || len(params.Metadata) == maxMetadataPairs {
+			if err := strictness.Report(ctx, strictness.Conversion, "openai", "dropped label %q: only %d distinct labels are sent as metadata", key, maxMetadataPairs); err != nil {
+				return err
+			}
+			continue
+		}
+		if params.Metadata == nil {
+			params.Metadata = shared.Metadata{}
+		}
+		params.Metadata[name] = truncate(labels[key], maxMetadataValue)
+	}
+	return nil
+}
+
+// metadataKey returns the metadata key of the label key: its characters
+// other than ASCII letters, digits, '_', '-', '.' and ':' are replaced by
+// '_', and the keys too long are truncated and suffixed with a hash of the
+// label key, so that the truncated keys stay distinct.
+func metadataKey(key string) string {
+	sanitized := strings.Map(func(r rune) rune {
+		switch {
+		case 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z', '0' <= r && r <= '9', strings.ContainsRune("_-.:", r):
+			return r
+		default:
+			return '_'
+		}
+	}, key)
+	if sanitized == "" {
+		sanitized = "_"
+	}
+	if len(sanitized) <= maxMetadataKey {
+		return sanitized
+	}
+	sum := sha256.Sum256([]byte(key))
+	suffix := "-" + hex.EncodeToString(sum[:4])
+	return sanitized[:maxMetadataKey-len(suffix)] + suffix
+}
+
+// truncate returns s truncated to n characters.
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
@@ -0,0 +1,124 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openai_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openai/openai-go/v3/option"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/model/openai"
+	"google.golang.org/adk/strictness"
+)
+
+func TestModel_Labels(t *testing.T) {
+	var body map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body = nil
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id": "c1", "object": "chat.completion", "model": "gpt-4o", "choices": [{"index": 0, "finish_reason": "stop", "message": {"role": "assistant", "content": "ok"}}]}`)
+	}))
+	defer srv.Close()
+
+	longKey := strings.Repeat("k", 70)
+	many := map[string]string{}
+	wantMany := map[string]any{}
+	for i := range 18 {
+		key := fmt.Sprintf("label_%02d", i)
+		many[key] = "v"
+		if i < 16 {
+			wantMany[key] = "v"
+		}
+	}
+	tests := []struct {
+		name         string
+		cfg          openai.Config
+		labels       map[string]string
+		wantUser     any
+		wantMetadata any
+		wantWarnings []strictness.Warning
+	}{
+		{name: "no labels"},
+		{name: "empty labels", labels: map[string]string{}},
+		{
+			name:         "user and metadata",
+			labels:       map[string]string{openai.UserLabel: "u-42", "tenant": "acme", "experiment": "b"},
+			wantUser:     "u-42",
+			wantMetadata: map[string]any{"tenant": "acme", "experiment": "b"},
+		},
+		{
+			name:     "metadata disabled",
+			cfg:      openai.Config{DisableLabelMetadata: true},
+			labels:   map[string]string{openai.UserLabel: "u-42", "tenant": "acme"},
+			wantUser: "u-42",
+		},
+		{
+			name:   "sanitized",
+			labels: map[string]string{"team name/é": "core", longKey: strings.Repeat("é", 600)},
+			wantMetadata: map[string]any{
+				"team_name__":                         "core",
+				strings.Repeat("k", 55) + "-5c6ced41": strings.Repeat("é", 512),
+			},
+		},
+		{
+			name:         "too many",
+			labels:       many,
+			wantMetadata: wantMany,
+			wantWarnings: []strictness.Warning{
+				{Category: strictness.Conversion, Component: "openai", Message: `dropped label "label_16": only 16 distinct labels are sent as metadata`},
+				{Category: strictness.Conversion, Component: "openai", Message: `dropped label "label_17": only 16 distinct labels are sent as metadata`},
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			llm, err := openai.NewModelWithConfig(t.Context(), "gpt-4o", tc.cfg, option.WithBaseURL(srv.URL), option.WithAPIKey("key"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			collector := &strictness.Collector{}
+			ctx := strictness.NewContext(t.Context(), &strictness.Policy{Level: strictness.Warn})
+			ctx = strictness.WithCollector(ctx, collector)
+			req := &model.LLMRequest{
+				Model:    "gpt-4o",
+				Contents: []*genai.Content{genai.NewContentFromText("hi", genai.RoleUser)},
+				Config:   &genai.GenerateContentConfig{Labels: tc.labels},
+			}
+			for _, err := range llm.GenerateContent(ctx, req, false) {
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			if diff := cmp.Diff(tc.wantUser, body["user"]); diff != "" {
+				t.Errorf("user mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.wantMetadata, body["metadata"]); diff != "" {
+				t.Errorf("metadata mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.wantWarnings, collector.Warnings()); diff != "" {
+				t.Errorf("warnings mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openai
+
+import (
+	"github.com/openai/openai-go/v3"
+	"github.com/openai/openai-go/v3/packages/param"
+)
+
+// mergeMessages coalesces the adjacent messages with the same role into
+// one message carrying their content parts, in order, for the backends
+// rejecting consecutive messages with the same role. The assistant messages
+// are merged into the next one unless they call tools; the tool messages,
+// which answer distinct calls, are not merged.
+func mergeMessages(messages []openai.ChatCompletionMessageParamUnion) []openai.ChatCompletionMessageParamUnion {
+	merged := make([]openai.ChatCompletionMessageParamUnion, 0, len(messages))
+	for _, msg := range messages {
+		if n := len(merged); n > 0 {
+			if m, ok := mergeMessage(merged[n-1], msg); ok {
+				merged[n-1] = m
+				continue
+			}
+		}
+		merged = append(merged, msg)
+	}
+	return merged
+}
+
+// mergeMessage returns the message merging prev and msg, and whether they
+// can be merged. prev is not modified.
+func mergeMessage(prev, msg openai.ChatCompletionMessageParamUnion) (openai.ChatCompletionMessageParamUnion, bool) {
+	switch {
+	case prev.OfUser != nil && msg.OfUser != nil && prev.OfUser.Name == msg.OfUser.Name:
+		m := *prev.OfUser
+		m.Content = openai.ChatCompletionUserMessageParamContentUnion{
+			OfArrayOfContentParts: append(userParts(prev.OfUser.Content), userParts(msg.OfUser.Content)...),
+		}
+		return openai.ChatCompletionMessageParamUnion{OfUser: &m}, true
+	case prev.OfAssistant != nil && msg.OfAssistant != nil && len(prev.OfAssistant.ToolCalls) == 0 && prev.OfAssistant.Name == msg.OfAssistant.Name:
+		m := *msg.OfAssistant
+		m.Content = openai.ChatCompletionAssistantMessageParamContentUnion{
+			OfArrayOfContentParts: append(assistantParts(prev.OfAssistant.Content), assistantParts(msg.OfAssistant.Content)...),
+		}
+		return openai.ChatCompletionMessageParamUnion{OfAssistant: &m}, true
+	case prev.OfSystem != nil && msg.OfSystem != nil && prev.OfSystem.Name == msg.OfSystem.Name:
+		m := *prev.OfSystem
+		m.Content = openai.ChatCompletionSystemMessageParamContentUnion{
+			OfArrayOfContentParts: append(textParts(prev.OfSystem.Content.OfString, prev.OfSystem.Content.OfArrayOfContentParts), textParts(msg.OfSystem.Content.OfString, msg.OfSystem.Content.OfArrayOfContentParts)...),
+		}
+		return openai.ChatCompletionMessageParamUnion{OfSystem: &m}, true
+	case prev.OfDeveloper != nil && msg.OfDeveloper != nil && prev.OfDeveloper.Name == msg.OfDeveloper.Name:
+		m := *prev.OfDeveloper
+		m.Content = openai.ChatCompletionDeveloperMessageParamContentUnion{
+			OfArrayOfContentParts: append(textParts(prev.OfDeveloper.Content.OfString, prev.OfDeveloper.Content.OfArrayOfContentParts), textParts(msg.OfDeveloper.Content.OfString, msg.OfDeveloper.Content.OfArrayOfContentParts)...),
+		}
+		return openai.ChatCompletionMessageParamUnion{OfDeveloper: &m}, true
+	}
+	return msg, false
+}
+
+// userParts returns the content parts of the content of a user message.
+func userParts(content openai.ChatCompletionUserMessageParamContentUnion) []openai.ChatCompletionContentPartUnionParam {
+	if content.OfString.Valid() {
+		return []openai.ChatCompletionContentPartUnionParam{openai.TextContentPart(content.OfString.Value)}
+	}
+	return append([]openai.ChatCompletionContentPartUnionParam(nil), content.OfArrayOfContentParts...)
+}
+
+// assistantParts returns the content parts of the content of an assistant
+// message, which has none if it only calls tools.
+func assistantParts(content openai.ChatCompletionAssistantMessageParamContentUnion) []openai.ChatCompletionAssistantMessageParamContentArrayOfContentPartUnion {
+	if content.OfString.Valid() {
+		return []openai.ChatCompletionAssistantMessageParamContentArrayOfContentPartUnion{{
+			OfText: &openai.ChatCompletionContentPartTextParam{Text: content.OfString.Value},
+		}}
+	}
+	return append([]openai.ChatCompletionAssistantMessageParamContentArrayOfContentPartUnion(nil), content.OfArrayOfContentParts...)
+}
+
+// textParts returns the content parts of the content of a system or
+// developer message, given as a string or as text parts.
+func textParts(text param.Opt[string], parts []openai.ChatCompletionContentPartTextParam) []openai.ChatCompletionContentPartTextParam {
+	if text.Valid() {
+		return []openai.ChatCompletionContentPartTextParam{{Text: text.Value}}
+	}
+	return append([]openai.ChatCompletionContentPartTextParam(nil), parts...)
+}
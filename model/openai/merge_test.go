@@ -0,0 +1,123 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openai_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openai/openai-go/v3/option"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/model/openai"
+)
+
+func TestModel_MergeMessages(t *testing.T) {
+	var got []any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Messages []any `json:"messages"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		got = body.Messages
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id": "c1", "object": "chat.completion", "model": "gpt-4o", "choices": [{"index": 0, "finish_reason": "stop", "message": {"role": "assistant", "content": "ok"}}]}`)
+	}))
+	defer srv.Close()
+
+	text := func(s string) map[string]any { return map[string]any{"type": "text", "text": s} }
+	call := &genai.Part{FunctionCall: &genai.FunctionCall{ID: "call_1", Name: "get_weather", Args: map[string]any{"city": "Paris"}}}
+	tests := []struct {
+		name     string
+		contents []*genai.Content
+		want     []any
+	}{
+		{
+			name: "TextOnly",
+			contents: []*genai.Content{
+				genai.NewContentFromText("read the report", genai.RoleUser),
+				genai.NewContentFromText("[artifact report.txt]", genai.RoleUser),
+				genai.NewContentFromText("Q3 revenue grew.", genai.RoleUser),
+				genai.NewContentFromText("Checking.", genai.RoleModel),
+				{Role: genai.RoleModel, Parts: []*genai.Part{genai.NewPartFromText("Let me look."), call}},
+				{Role: genai.RoleUser, Parts: []*genai.Part{{FunctionResponse: &genai.FunctionResponse{ID: "call_1", Name: "get_weather", Response: map[string]any{"sky": "clear"}}}}},
+				genai.NewContentFromText("thanks", genai.RoleUser),
+			},
+			want: []any{
+				map[string]any{"role": "system", "content": []any{text("be brief"), text("be polite")}},
+				map[string]any{"role": "user", "content": []any{text("read the report"), text("[artifact report.txt]"), text("Q3 revenue grew.")}},
+				map[string]any{
+					"role":    "assistant",
+					"content": []any{text("Checking."), text("Let me look.")},
+					"tool_calls": []any{map[string]any{
+						"id":       "call_1",
+						"type":     "function",
+						"function": map[string]any{"name": "get_weather", "arguments": `{"city":"Paris"}`},
+					}},
+				},
+				map[string]any{"role": "tool", "tool_call_id": "call_1", "content": `{"sky":"clear"}`},
+				map[string]any{"role": "user", "content": "thanks"},
+			},
+		},
+		{
+			name: "TextAndImages",
+			contents: []*genai.Content{
+				genai.NewContentFromText("compare", genai.RoleUser),
+				{Role: genai.RoleUser, Parts: []*genai.Part{genai.NewPartFromBytes([]byte("\x89PNG"), "image/png"), genai.NewPartFromText("with")}},
+				{Role: genai.RoleUser, Parts: []*genai.Part{genai.NewPartFromURI("https://example.com/cat.jpg", "image/jpeg")}},
+				genai.NewContentFromText("please", genai.RoleUser),
+			},
+			want: []any{
+				map[string]any{"role": "system", "content": []any{text("be brief"), text("be polite")}},
+				map[string]any{"role": "user", "content": []any{
+					text("compare"),
+					map[string]any{"type": "image_url", "image_url": map[string]any{"url": "data:image/png;base64,iVBORw=="}},
+					text("with"),
+					map[string]any{"type": "image_url", "image_url": map[string]any{"url": "https://example.com/cat.jpg"}},
+					text("please"),
+				}},
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			llm, err := openai.NewModelWithConfig(t.Context(), "gpt-4o", openai.Config{MergeMessages: true}, option.WithBaseURL(srv.URL), option.WithAPIKey("key"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req := &model.LLMRequest{
+				Model:    "gpt-4o",
+				Contents: tc.contents,
+				Config: &genai.GenerateContentConfig{SystemInstruction: &genai.Content{Parts: []*genai.Part{
+					genai.NewPartFromText("be brief"),
+					genai.NewPartFromText("be polite"),
+				}}},
+			}
+			for _, err := range llm.GenerateContent(t.Context(), req, false) {
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("messages mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/openai/openai-go/v3"
+	"github.com/openai/openai-go/v3/option"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/httpx"
+	"google.golang.org/adk/model/moderation"
+)
+
+type moderator struct {
+	model  string
+	client *openai.Client
+}
+
+// NewModerator returns a [moderation.Moderator] backed by the OpenAI
+// moderations API. It accepts the same request options as [NewModel] and
+// sends the requests with httpx.DefaultClient as well.
+//
+// The modelName specifies the moderation model, e.g. "omni-moderation-latest".
+// Contents flagged by the API are blocked.
+func NewModerator(modelName string, opts ...option.RequestOption) moderation.Moderator {
+	client := openai.NewClient(append([]option.RequestOption{option.WithHTTPClient(httpx.DefaultClient)}, opts...)...)
+	return &moderator{
+		model:  modelName,
+		client: &client,
+	}
+}
+
+// Check implements moderation.Moderator. Only the text parts are classified.
+func (m *moderator) Check(ctx context.Context, contents []*genai.Content) (moderation.Result, error) {
+	var texts []string
+	for _, c := range contents {
+		if c == nil {
+			continue
+		}
+		for _, p := range c.Parts {
+			if p != nil && p.Text != "" && !p.Thought {
+				texts = append(texts, p.Text)
+			}
+		}
+	}
+	result := moderation.Result{Decision: moderation.DecisionAllow, Scores: map[string]float64{}}
+	if len(texts) == 0 {
+		return result, nil
+	}
+
+	resp, err := m.client.Moderations.New(ctx, openai.ModerationNewParams{
+		Model: m.model,
+		Input: openai.ModerationNewParamsInputUnion{OfStringArray: texts},
+	})
+	if err != nil {
+		return moderation.Result{}, fmt.Errorf("failed to call moderations API: %w", err)
+	}
+
+	flagged := make(map[string]bool)
+	for _, r := range resp.Results {
+		var scores map[string]float64
+		if err := json.Unmarshal([]byte(r.CategoryScores.RawJSON()), &scores); err != nil {
+			return moderation.Result{}, fmt.Errorf("failed to parse moderation scores: %w", err)
+		}
+		// Keep the highest score of every category across the inputs.
+		for category, score := range scores {
+			result.Scores[category] = max(result.Scores[category], score)
+		}
+		var categories map[string]bool
+		if err := json.Unmarshal([]byte(r.Categories.RawJSON()), &categories); err != nil {
+			return moderation.Result{}, fmt.Errorf("failed to parse moderation categories: %w", err)
+		}
+		for category, ok := range categories {
+			if ok {
+				flagged[category] = true
+			}
+		}
+		if r.Flagged {
+			result.Decision = moderation.DecisionBlock
+		}
+	}
+	result.Categories = slices.Sorted(maps.Keys(flagged))
+	return result, nil
+}
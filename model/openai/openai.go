@@ -1,30 +1,264 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
 package openai
 
 import (
+	"cmp"
 	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"iter"
+	"maps"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/openai/openai-go/v3"
 	"github.com/openai/openai-go/v3/option"
 	"github.com/openai/openai-go/v3/packages/param"
 	"github.com/openai/openai-go/v3/shared"
+	"google.golang.org/adk/httpx"
+	"google.golang.org/adk/internal/llmconv"
 	"google.golang.org/adk/model"
+	"google.golang.org/adk/model/assembly"
+	"google.golang.org/adk/strictness"
 	"google.golang.org/genai"
 )
 
 type openaiModel struct {
 	name   string
 	client *openai.Client
+	health *healthProbe
+	cfg    Config
+	retry  RetryConfig
+	// requestOpts are the options of the requests of the calls.
+	requestOpts []option.RequestOption
+	// idle is the idle timeout of the streams, negative if disabled.
+	idle time.Duration
 }
 
+// NewModel returns the model modelName of a client created with opts. The
+// failed calls are retried by the client, unless a policy is set with
+// [WithRetry], see [RetryConfig].
+//
+// The client sends the requests with httpx.DefaultClient, which propagates
+// the metadata of the invocations in their headers, unless opts set another
+// client with option.WithHTTPClient.
 func NewModel(ctx context.Context, modelName string, opts ...option.RequestOption) (model.LLM, error) {
-	client := openai.NewClient(opts...)
+	return NewModelWithConfig(ctx, modelName, Config{}, opts...)
+}
+
+// ModelPattern is the pattern of the names of the OpenAI models, registered
+// by [RegisterDefaults].
+const ModelPattern = `^gpt-|^o[0-9]|^chatgpt-`
+
+// RegisterDefaults registers the OpenAI models for [model.Resolve], created
+// with opts.
+func RegisterDefaults(opts ...option.RequestOption) {
+	model.Register(ModelPattern, func(ctx context.Context, name string) (model.LLM, error) {
+		return NewModel(ctx, name, opts...)
+	})
+}
+
+// NewModelWithClient returns the model modelName called with client, so
+// that a preconfigured client, e.g. with a custom HTTP transport, can be
+// shared by several models.
+func NewModelWithClient(modelName string, client *openai.Client) model.LLM {
+	return newModel(modelName, client, Config{})
+}
+
+// Config configures the models created by [NewModelWithConfig].
+type Config struct {
+	// HealthCheck configures the health probe of the model, see
+	// [model.HealthChecker].
+	HealthCheck HealthCheckConfig
+	// ResolveFileData returns the content of the files referenced by the
+	// gs:// URIs of the FileData parts, which are then sent inline.
+	// Optional: if nil, the requests with gs:// URIs fail.
+	ResolveFileData func(ctx context.Context, data *genai.FileData) (*genai.Blob, error)
+	// ReasoningEffort is sent as the reasoning_effort of every request,
+	// overriding the effort mapped from the thinking config of the request.
+	// Unlike the mapped effort, it is sent whatever the model. Optional.
+	ReasoningEffort shared.ReasoningEffort
+	// Family overrides the family of the model detected from its name, e.g.
+	// for an Azure deployment whose name does not tell. Optional.
+	Family ModelFamily
+	// AggregateStream makes the streamed responses end with the complete
+	// response of the turn, like those of the Gemini models: its whole text,
+	// audio and function calls, with the finish reason and the usage. The
+	// deltas are all streamed as partial responses. By default, the last
+	// delta is streamed as the complete response, with the function calls,
+	// followed by the usage.
+	AggregateStream bool
+	// TokenLimit overrides the parameter carrying the maximum number of
+	// output tokens chosen from the name of the model. Whatever the
+	// parameter, the requests rejecting it are retried once with the other.
+	// Optional.
+	TokenLimit TokenLimit
+	// StrictFunctions declares the functions strict, so that the arguments
+	// of their calls follow their parameters schema. The optional parameters
+	// are declared required and nullable, as required by the strict mode,
+	// and their null arguments are removed from the calls. The functions
+	// whose schema cannot be made strict are declared non-strict, which is
+	// logged and reported with the strictness policy of the context.
+	StrictFunctions bool
+	// StrictFunctionOverrides overrides StrictFunctions for the functions
+	// of the given names. Optional.
+	StrictFunctionOverrides map[string]bool
+	// IncludeThoughts sends the thought parts of the contents and of the
+	// system instruction, e.g. produced by a Gemini model earlier in the
+	// session, as text, for debugging. By default, they are left out, like
+	// the parts only carrying a thought signature.
+	IncludeThoughts bool
+	// DisableLabelMetadata disables the metadata of the requests, carrying
+	// the labels of their config other than [UserLabel], for the endpoints
+	// rejecting it.
+	DisableLabelMetadata bool
+	// StrictConfig makes the requests whose config sets parameters that
+	// OpenAI cannot express, e.g. top_k or an unsupported response MIME
+	// type, fail. By default, these parameters are ignored and reported with
+	// the strictness policy of the context.
+	StrictConfig bool
+	// Retry configures the retries of the calls failing with a transient
+	// error. The zero value leaves the retries to the client, see
+	// RetryConfig.
+	Retry RetryConfig
+	// DisableUserContent disables the user contents appended to the requests
+	// whose history is empty or does not end with a user turn. The requests
+	// are then sent as built by the agent, and those with no message at all,
+	// which the API rejects, fail.
+	DisableUserContent bool
+	// InitialUserText is the text of the user content appended to the
+	// requests with an empty history. Optional: defaults to an instruction to
+	// handle the requests of the system instruction.
+	InitialUserText string
+	// ContinuationUserText is the text of the user content appended to the
+	// requests whose history does not end with a user turn. Optional:
+	// defaults to an instruction to continue processing the previous
+	// requests.
+	ContinuationUserText string
+	// MergeMessages coalesces the adjacent messages with the same role, e.g.
+	// the user contents appended by the artifact tools, into one message
+	// carrying their content parts, in order. It is required by the
+	// OpenAI-compatible backends rejecting consecutive messages with the same
+	// role, and saves the overhead of the messages.
+	MergeMessages bool
+	// StreamIdleTimeout bounds the wait for the next chunk of a streamed
+	// response, including the first one. A stream sending no chunk for that
+	// long is closed, and the call fails with [ErrStreamIdle]. Defaults to 60
+	// seconds; negative disables the timeout.
+	StreamIdleTimeout time.Duration
+	// ImageDetail is the detail level of the images sent to the model. The
+	// media resolution of an image part overrides it for that image.
+	// Optional: by default, OpenAI chooses the level of each image.
+	ImageDetail ImageDetail
+	// DisableParallelToolCalls makes the model call at most one tool per
+	// turn, for the tools which must be called in order. The requests
+	// declaring tools are then sent with parallel_tool_calls set to false.
+	// By default, the parameter is not sent, and the model may call several
+	// tools in a turn.
+	DisableParallelToolCalls bool
+	// FileInput sends the PDF documents of the user contents, e.g. loaded
+	// from artifacts, as file content parts, for the models accepting them,
+	// e.g. gpt-4o. The requests with documents larger than 32 MiB fail. By
+	// default, the documents are replaced by a textual placeholder.
+	FileInput bool
+	// DisableStreamUsage disables the usage of the streams, requested with
+	// stream_options.include_usage, for the servers rejecting it. The
+	// streamed responses then carry no usage.
+	DisableStreamUsage bool
+	// SystemRole overrides the role of the messages carrying the system
+	// instruction chosen from the family of the model. Optional.
+	SystemRole SystemRole
+	// ExtraFields are added to the body of every request, overriding the
+	// parameters converted from the request, e.g. for logit_bias, store or
+	// service_tier, which the config of the requests cannot express.
+	// Optional.
+	ExtraFields map[string]any
+	// RequestMutator modifies the parameters of every request, once they
+	// are converted from the request, including the streaming options, and
+	// just before they are sent. Optional.
+	RequestMutator func(*openai.ChatCompletionNewParams)
+	// EstimateStreamUsage estimates the usage of the streams ending without
+	// one, e.g. from the OpenAI-compatible servers ignoring
+	// stream_options.include_usage, from the size of the request and of the
+	// output. The estimated usage is streamed last, like the usage reported
+	// by OpenAI, with [EstimatedUsageMetadataKey] set in its custom
+	// metadata. By default, these streams carry no usage.
+	EstimateStreamUsage bool
+}
+
+const (
+	defaultInitialUserText      = "Handle the requests as specified in the System Instruction."
+	defaultContinuationUserText = "Continue processing previous requests as instructed. Exit or provide a summary if no more outputs are needed."
+)
+
+// configOptionValue carries a setting of the Config of the model in the
+// options of NewModel, e.g. WithRetry. It is not applied to the client.
+type configOptionValue struct {
+	option.RequestOption
+	apply func(*Config)
+}
 
+// configOption returns the option applying apply to the Config of the
+// model.
+func configOption(apply func(*Config)) option.RequestOption {
+	return configOptionValue{RequestOption: option.WithMiddleware(), apply: apply}
+}
+
+// WithExtraFields adds fields to the body of the requests of the model
+// created by [NewModel], [NewModelWithConfig] or [NewAzureModel],
+// overriding [Config.ExtraFields].
+func WithExtraFields(fields map[string]any) option.RequestOption {
+	return configOption(func(c *Config) { c.ExtraFields = fields })
+}
+
+// WithRequestMutator sets the function modifying the parameters of the
+// requests of the model created by [NewModel], [NewModelWithConfig] or
+// [NewAzureModel], overriding [Config.RequestMutator].
+func WithRequestMutator(mutate func(*openai.ChatCompletionNewParams)) option.RequestOption {
+	return configOption(func(c *Config) { c.RequestMutator = mutate })
+}
+
+// NewModelWithConfig is like [NewModel], with the model configured by cfg.
+func NewModelWithConfig(ctx context.Context, modelName string, cfg Config, opts ...option.RequestOption) (model.LLM, error) {
+	clientOpts := make([]option.RequestOption, 0, len(opts)+1)
+	clientOpts = append(clientOpts, option.WithHTTPClient(httpx.DefaultClient))
+	for _, opt := range opts {
+		if c, ok := opt.(configOptionValue); ok {
+			c.apply(&cfg)
+			continue
+		}
+		clientOpts = append(clientOpts, opt)
+	}
+	client := openai.NewClient(clientOpts...)
+	return newModel(modelName, &client, cfg), nil
+}
+
+func newModel(modelName string, client *openai.Client, cfg Config) *openaiModel {
 	return &openaiModel{
-		name:   modelName,
-		client: &client,
-	}, nil
+		name:        modelName,
+		client:      client,
+		health:      newHealthProbe(cfg.HealthCheck),
+		cfg:         cfg,
+		retry:       cfg.Retry.withDefaults(),
+		requestOpts: cfg.Retry.requestOptions(),
+		idle:        cmp.Or(cfg.StreamIdleTimeout, defaultStreamIdleTimeout),
+	}
 }
 
 func (o *openaiModel) Name() string {
@@ -32,94 +266,349 @@ func (o *openaiModel) Name() string {
 }
 
 func (o *openaiModel) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
-	o.maybeAppendUserContent(req)
-
-	body, err := LLMRequest2ChatCompletionNewParams(req)
+	err := o.maybeAppendUserContent(req)
+	if err == nil {
+		err = o.resolveFileData(ctx, req)
+	}
+	var body *openai.ChatCompletionNewParams
+	if err == nil {
+		body, err = newParams(ctx, req, o.name, o.cfg)
+	}
+	if err == nil && o.cfg.ReasoningEffort != "" {
+		body.ReasoningEffort = o.cfg.ReasoningEffort
+	}
+	if err == nil && stream {
+		o.setStreamOptions(body)
+	}
+	if err == nil {
+		o.customize(body)
+	}
 	if err != nil {
 		return func(yield func(*model.LLMResponse, error) bool) {
 			yield(nil, err)
 		}
 	}
 
+	var responses iter.Seq2[*model.LLMResponse, error]
 	if stream {
-		return o.generateStream(ctx, body)
+		responses = o.generateStream(ctx, body)
+	} else {
+		responses = func(yield func(*model.LLMResponse, error) bool) {
+			resp, err := o.generate(ctx, body)
+			yield(resp, err)
+		}
 	}
-
-	return func(yield func(*model.LLMResponse, error) bool) {
-		resp, err := o.generate(ctx, body)
-		yield(resp, err)
+	if schemas := strictSchemas(req, body); len(schemas) > 0 {
+		responses = dropAddedNulls(responses, schemas)
 	}
+	return responses
 }
 
 func (o *openaiModel) generate(ctx context.Context, body *openai.ChatCompletionNewParams) (*model.LLMResponse, error) {
-	chatCompletion, err := o.client.Chat.Completions.New(ctx, *body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate content: %w", err)
+	swapped := false
+	for attempt := 1; ; attempt++ {
+		chatCompletion, err := o.client.Chat.Completions.New(ctx, *body, o.requestOpts...)
+		if err == nil {
+			return ChatCompletion2LLMResponse(chatCompletion), nil
+		}
+		if resp, ok := rejectedResponse(err); ok {
+			return resp, nil
+		}
+		if !swapped && swapTokenLimit(body, err) {
+			swapped = true
+			continue
+		}
+		if !o.retry.wait(ctx, err, attempt) {
+			return nil, fmt.Errorf("failed to generate content: %w", classify(err))
+		}
 	}
-	resp := ChatCompletion2LLMResponse(chatCompletion)
-	return resp, nil
 }
 
-func (o *openaiModel) generateStream(ctx context.Context, body *openai.ChatCompletionNewParams) iter.Seq2[*model.LLMResponse, error] {
-	body.StreamOptions = openai.ChatCompletionStreamOptionsParam{
-		IncludeUsage: param.NewOpt(true),
+// setStreamOptions sets the parameters of the streamed requests.
+func (o *openaiModel) setStreamOptions(body *openai.ChatCompletionNewParams) {
+	if !o.cfg.DisableStreamUsage {
+		body.StreamOptions = openai.ChatCompletionStreamOptionsParam{
+			IncludeUsage: param.NewOpt(true),
+		}
 	}
+	if body.Audio.Format != "" {
+		// The streamed audio can only be raw PCM.
+		body.Audio.Format = openai.ChatCompletionAudioParamFormatPcm16
+	}
+}
 
-	stream := o.client.Chat.Completions.NewStreaming(ctx, *body)
+// customize applies the extra fields and the request mutator of the config
+// to body, last, so that they override the converted parameters.
+func (o *openaiModel) customize(body *openai.ChatCompletionNewParams) {
+	if len(o.cfg.ExtraFields) > 0 {
+		body.SetExtraFields(maps.Clone(o.cfg.ExtraFields))
+	}
+	if o.cfg.RequestMutator != nil {
+		o.cfg.RequestMutator(body)
+	}
+}
 
+func (o *openaiModel) generateStream(ctx context.Context, body *openai.ChatCompletionNewParams) iter.Seq2[*model.LLMResponse, error] {
 	return func(yield func(*model.LLMResponse, error) bool) {
-		defer stream.Close()
-
-		for stream.Next() {
-			chunk := stream.Current()
-			resp := convertChunk(chunk)
-			if resp != nil {
-				if !yield(resp, nil) {
+		swapped := false
+		for attempt := 1; ; attempt++ {
+			var state chunkState
+			emit := yield
+			var aggregator *streamAggregator
+			if o.cfg.AggregateStream {
+				aggregator = &streamAggregator{}
+				emit = aggregator.wrap(yield)
+			}
+			var estimator *usageEstimator
+			if o.cfg.EstimateStreamUsage {
+				estimator = newUsageEstimator(body)
+				emit = estimator.wrap(emit)
+			}
+			yielded, stopped, err := o.stream(ctx, body, &state, emit)
+			if stopped {
+				return
+			}
+			if err != nil {
+				if resp, ok := rejectedResponse(err); ok && !yielded {
+					yield(resp, nil)
+					return
+				}
+				if !yielded && !swapped && swapTokenLimit(body, err) {
+					swapped = true
+					continue
+				}
+				// The partial output delivered cannot be taken back: the
+				// call is only retried if there is none.
+				if yielded || !o.retry.wait(ctx, err, attempt) {
+					yield(nil, fmt.Errorf("failed to generate stream content: %w", classify(err)))
+					return
+				}
+				continue
+			}
+			// The stream ended without a finish reason: the calls collected are
+			// returned if they are complete.
+			if state.calls.pending() {
+				resp := &model.LLMResponse{
+					Content:      &genai.Content{Role: genai.RoleModel},
+					TurnComplete: true,
+				}
+				flushToolCalls(resp, &state.calls)
+				if !emit(resp, nil) {
+					return
+				}
+			}
+			if estimator != nil {
+				if resp := estimator.response(); resp != nil && !emit(resp, nil) {
 					return
 				}
 			}
+			if aggregator != nil {
+				if resp := aggregator.close(); resp != nil {
+					yield(resp, nil)
+				}
+			}
+			return
 		}
+	}
+}
 
-		if err := stream.Err(); err != nil {
-			yield(nil, fmt.Errorf("failed to generate stream content: %w", err))
+// stream sends a streamed request, and yields the responses converted from
+// its chunks. It reports whether a response was yielded, whether yield
+// asked to stop, and the error of the stream.
+func (o *openaiModel) stream(ctx context.Context, body *openai.ChatCompletionNewParams, state *chunkState, yield func(*model.LLMResponse, error) bool) (yielded, stopped bool, err error) {
+	var candidates *streamCandidates
+	if body.N.Value > 1 {
+		candidates = &streamCandidates{}
+	}
+	ctx, watchdog := newIdleWatchdog(ctx, o.idle)
+	defer watchdog.stop()
+	stream := o.client.Chat.Completions.NewStreaming(ctx, *body, o.requestOpts...)
+	defer stream.Close()
+	for stream.Next() {
+		// The time spent by the caller on the responses is not idle.
+		watchdog.pause()
+		chunk := stream.Current()
+		responses := []*model.LLMResponse{convertChunk(chunk, state)}
+		if candidates != nil {
+			responses = candidates.convert(chunk, responses[0])
 		}
+		for _, resp := range responses {
+			if resp == nil {
+				continue
+			}
+			yielded = true
+			if !yield(resp, nil) {
+				return yielded, true, nil
+			}
+		}
+		watchdog.resume()
+	}
+	if err := stream.Err(); err != nil {
+		return yielded, false, watchdog.err(err)
 	}
+	if resp := candidates.release(); resp != nil {
+		yielded = true
+		if !yield(resp, nil) {
+			return yielded, true, nil
+		}
+	}
+	return yielded, false, nil
 }
 
-func (o *openaiModel) maybeAppendUserContent(req *model.LLMRequest) {
+// resolveFileData replaces the FileData parts of req with gs:// URIs by
+// the inline data returned by Config.ResolveFileData. The contents are
+// copied, not modified.
+func (o *openaiModel) resolveFileData(ctx context.Context, req *model.LLMRequest) error {
+	if o.cfg.ResolveFileData == nil {
+		return nil
+	}
+	for i, content := range req.Contents {
+		if content == nil {
+			continue
+		}
+		var resolved *genai.Content
+		for j, part := range content.Parts {
+			if part == nil || part.FileData == nil || !strings.HasPrefix(part.FileData.FileURI, "gs://") {
+				continue
+			}
+			blob, err := o.cfg.ResolveFileData(ctx, part.FileData)
+			if err != nil {
+				return fmt.Errorf("content %d, part %d: failed to resolve %s: %w", i, j, part.FileData.FileURI, err)
+			}
+			if resolved == nil {
+				resolved = &genai.Content{Role: content.Role, Parts: slices.Clone(content.Parts)}
+				req.Contents[i] = resolved
+			}
+			resolved.Parts[j] = &genai.Part{InlineData: blob}
+		}
+	}
+	return nil
+}
+
+// maybeAppendUserContent appends a user content to req if its history is
+// empty or does not end with a user turn, so that the model can continue to
+// output. If the user contents are disabled, it only fails for the requests
+// with no message to send.
+func (o *openaiModel) maybeAppendUserContent(req *model.LLMRequest) error {
+	if o.cfg.DisableUserContent {
+		if len(req.Contents) == 0 && (req.Config == nil || req.Config.SystemInstruction == nil) {
+			return fmt.Errorf("request to model %q has no contents and no system instruction: the API requires at least one message, and the user contents are disabled", cmp.Or(req.Model, o.name))
+		}
+		return nil
+	}
+
+	defer assembly.Step(req, "openai", "append user content", "")()
+
 	if len(req.Contents) == 0 {
-		req.Contents = append(req.Contents, genai.NewContentFromText("Handle the requests as specified in the System Instruction.", "user"))
+		req.Contents = append(req.Contents, genai.NewContentFromText(cmp.Or(o.cfg.InitialUserText, defaultInitialUserText), "user"))
 	}
 
 	if last := req.Contents[len(req.Contents)-1]; last != nil && last.Role != "user" {
-		req.Contents = append(req.Contents, genai.NewContentFromText("Continue processing previous requests as instructed. Exit or provide a summary if no more outputs are needed.", "user"))
+		req.Contents = append(req.Contents, genai.NewContentFromText(cmp.Or(o.cfg.ContinuationUserText, defaultContinuationUserText), "user"))
 	}
+	return nil
 }
 
 func LLMRequest2ChatCompletionNewParams(req *model.LLMRequest) (*openai.ChatCompletionNewParams, error) {
+	return newParams(context.Background(), req, "", Config{})
+}
+
+// newParams converts req, sent to the model of req, or to the model
+// modelName if req names none. The data dropped by the conversion is
+// reported with the strictness policy of ctx.
+func newParams(ctx context.Context, req *model.LLMRequest, modelName string, opts Config) (*openai.ChatCompletionNewParams, error) {
+	modelName = cmp.Or(req.Model, modelName)
+	if modelName == "" {
+		return nil, errors.New("no model to send the request to: the request names no model, and neither does the adapter")
+	}
 	params := &openai.ChatCompletionNewParams{
-		Model: shared.ChatModel(req.Model),
+		Model: shared.ChatModel(modelName),
 	}
-	if err := applyGenerationConfig(params, req.Config); err != nil {
+	reasoning := opts.Family.isReasoning(modelName)
+	if err := applyGenerationConfig(ctx, params, req.Config, reasoning, opts.StrictConfig); err != nil {
 		return nil, err
 	}
+	if params.MaxTokens.Valid() {
+		setTokenLimit(params, params.MaxTokens.Value, opts.usesMaxCompletionTokens(modelName))
+	}
 
-	contents := covertContents(req.Contents)
+	contents, err := covertContents(ctx, req.Contents, assembly.ForRequest(req), opts)
+	if err != nil {
+		return nil, err
+	}
 	params.Messages = append(params.Messages, contents...)
+	if req.Config != nil {
+		// Some OpenAI-compatible backends reject the system messages which
+		// are not first.
+		params.Messages = append(covertSystemMessage(req.Config.SystemInstruction, opts.SystemRole.developer(reasoning), opts.IncludeThoughts), params.Messages...)
+		tools, err := convertTools(ctx, req.Config.Tools, opts)
+		if err != nil {
+			return nil, err
+		}
+		params.Tools = tools
+		// parallel_tool_calls is rejected without tools.
+		if opts.DisableParallelToolCalls && len(tools) > 0 {
+			params.ParallelToolCalls = param.NewOpt(false)
+		}
+		if err := applyWebSearch(params, req.Config.Tools); err != nil {
+			return nil, err
+		}
+		if err := applyLabels(ctx, params, req.Config.Labels, !opts.DisableLabelMetadata); err != nil {
+			return nil, err
+		}
+		if err := applyPrediction(params, req.Config.Labels); err != nil {
+			return nil, err
+		}
+		if err := applyToolConfig(params, req.Config.ToolConfig); err != nil {
+			return nil, err
+		}
+	}
+	if opts.MergeMessages {
+		params.Messages = mergeMessages(params.Messages)
+	}
 	return params, nil
 }
 
-func covertContents(contents []*genai.Content) []openai.ChatCompletionMessageParamUnion {
+// covertContents converts contents to messages. The texts become one
+// message each, except in the model turns calling functions: a turn becomes
+// a single assistant message carrying its text and its tool calls. The
+// function responses become tool messages. The user contents with inline
+// images, wav or mp3 audio, or PDF documents if opts accepts files, become a
+// single user message carrying their texts, images, audio and files, in
+// order; the other inline data, and the images and audio of the other
+// roles, are replaced by a textual placeholder. The images referenced by
+// HTTP(S) FileData URIs are sent the same way, by URL; the other URIs are an
+// error.
+// The images are sent with the detail level of their part, or else of opts.
+// The parts and contents that cannot be converted are dropped, recorded in
+// trace and reported with the strictness policy of ctx. The thought parts
+// are left out unless opts includes them; the parts only carrying a thought
+// signature always are.
+func covertContents(ctx context.Context, contents []*genai.Content, trace *assembly.Trace, opts Config) ([]openai.ChatCompletionMessageParamUnion, error) {
 	var (
+		dropErr   error
 		messages  []openai.ChatCompletionMessageParamUnion
 		texts     []string
+		parts     []openai.ChatCompletionContentPartUnionParam
+		hasMedia  bool
+		calls     []*genai.FunctionCall
+		responses []*genai.FunctionResponse
+		ids       = llmconv.CallIDs{Prefix: "call_"}
 		curRole   genai.Role
 		flushText = func() {
+			if hasMedia {
+				messages = append(messages, openai.UserMessage(parts))
+				texts, parts, hasMedia = texts[:0], nil, false
+				return
+			}
+			parts = nil
 			if len(texts) == 0 {
 				return
 			}
 			msg := newMessages(curRole, texts)
 			if msg == nil {
+				dropErr = drop(ctx, trace, "content", fmt.Sprintf("with role %q", curRole), texts)
+				texts = texts[:0]
 				return
 			}
 			messages = append(messages, msg...)
@@ -127,27 +616,174 @@ func covertContents(contents []*genai.Content) []openai.ChatCompletionMessagePar
 		}
 	)
 
-	for _, content := range contents {
+	for i, content := range contents {
 		if content == nil || len(content.Parts) == 0 {
 			continue
 		}
 		curRole = genai.Role(content.Role)
-		for _, part := range content.Parts {
+		for j, part := range content.Parts {
 			switch {
-			case part == nil:
+			case part == nil || isThoughtSignature(part) || (part.Thought && !opts.IncludeThoughts):
 				continue
+			case part.FunctionCall != nil:
+				calls = append(calls, ids.Call(part.FunctionCall))
+			case part.FunctionResponse != nil:
+				responses = append(responses, ids.Response(part.FunctionResponse))
+			case part.InlineData != nil:
+				userRole := curRole == "" || curRole == genai.RoleUser
+				if isImage(part.InlineData) && userRole {
+					parts = append(parts, imagePart(part.InlineData, imageDetail(part, opts.ImageDetail)))
+					hasMedia = true
+					continue
+				}
+				if isAudio(part.InlineData) && userRole && audioFormat(part.InlineData) != "" {
+					parts = append(parts, audioPart(part.InlineData))
+					hasMedia = true
+					continue
+				}
+				if isPDF(part.InlineData) && userRole && opts.FileInput {
+					file, err := filePart(part.InlineData)
+					if err != nil {
+						return nil, fmt.Errorf("content %d, part %d: %w", i, j, err)
+					}
+					parts = append(parts, file)
+					hasMedia = true
+					continue
+				}
+				text := inlineDataPlaceholder(part.InlineData)
+				if isAudio(part.InlineData) {
+					text = audioPlaceholder(part.InlineData, userRole)
+				}
+				if err := drop(ctx, trace, "inline data", fmt.Sprintf("of content %d", i), part.InlineData); err != nil {
+					return nil, err
+				}
+				texts = append(texts, text)
+				parts = append(parts, openai.TextContentPart(text))
+			case part.FileData != nil:
+				uri := part.FileData.FileURI
+				if !strings.HasPrefix(uri, "https://") && !strings.HasPrefix(uri, "http://") {
+					return nil, fmt.Errorf("content %d, part %d: file URI %q is not supported: only HTTP(S) URIs can be sent to OpenAI, gs:// URIs require Config.ResolveFileData", i, j, uri)
+				}
+				mimeType := part.FileData.MIMEType
+				if (mimeType == "" || strings.HasPrefix(mimeType, "image/")) && (curRole == "" || curRole == genai.RoleUser) {
+					parts = append(parts, openai.ImageContentPart(openai.ChatCompletionContentPartImageImageURLParam{URL: uri, Detail: imageDetail(part, opts.ImageDetail)}))
+					hasMedia = true
+					continue
+				}
+				text := fileDataPlaceholder(part.FileData)
+				if err := drop(ctx, trace, "file data", fmt.Sprintf("of content %d", i), part.FileData); err != nil {
+					return nil, err
+				}
+				texts = append(texts, text)
+				parts = append(parts, openai.TextContentPart(text))
 			case part.Text != "":
 				texts = append(texts, part.Text)
+				parts = append(parts, openai.TextContentPart(part.Text))
+			case (trace != nil || strictness.Enabled(ctx, strictness.Conversion)) && !isEmptyPart(part):
+				if err := drop(ctx, trace, "part", fmt.Sprintf("of content %d", i), part); err != nil {
+					return nil, err
+				}
 			}
 		}
+		if len(calls) > 0 {
+			msg, err := assistantToolCallMessage(strings.Join(texts, ""), calls)
+			if err != nil {
+				return nil, err
+			}
+			messages = append(messages, msg)
+			texts, parts, hasMedia, calls = texts[:0], nil, false, calls[:0]
+		}
+		for _, resp := range responses {
+			msg, err := toolMessage(resp)
+			if err != nil {
+				return nil, err
+			}
+			messages = append(messages, msg)
+		}
+		responses = responses[:0]
 		flushText()
+		if dropErr != nil {
+			return nil, dropErr
+		}
 
 	}
 
-	return messages
+	return messages, nil
 }
 
-func covertSystemMessage(systemInstruction *genai.Content) []openai.ChatCompletionMessageParamUnion {
+func isImage(blob *genai.Blob) bool {
+	return strings.HasPrefix(blob.MIMEType, "image/")
+}
+
+// imagePart returns the image blob as an image content part, with a base64
+// data URL and the given detail level, if any.
+func imagePart(blob *genai.Blob, detail string) openai.ChatCompletionContentPartUnionParam {
+	url := "data:" + blob.MIMEType + ";base64," + base64.StdEncoding.EncodeToString(blob.Data)
+	return openai.ImageContentPart(openai.ChatCompletionContentPartImageImageURLParam{URL: url, Detail: detail})
+}
+
+// inlineDataPlaceholder returns the text replacing the inline data blob,
+// which cannot be sent.
+func inlineDataPlaceholder(blob *genai.Blob) string {
+	if isImage(blob) {
+		return fmt.Sprintf("[%s image of %d bytes omitted: images are only supported in user messages]", blob.MIMEType, len(blob.Data))
+	}
+	return fmt.Sprintf("[%s data of %d bytes omitted: only images are supported]", blob.MIMEType, len(blob.Data))
+}
+
+// fileDataPlaceholder returns the text replacing the file referenced by
+// data, which cannot be sent.
+func fileDataPlaceholder(data *genai.FileData) string {
+	if data.MIMEType == "" || strings.HasPrefix(data.MIMEType, "image/") {
+		return fmt.Sprintf("[image %s omitted: images are only supported in user messages]", data.FileURI)
+	}
+	return fmt.Sprintf("[%s file %s omitted: only images are supported]", data.MIMEType, data.FileURI)
+}
+
+// isEmptyPart reports whether part carries nothing.
+func isEmptyPart(part *genai.Part) bool {
+	b, err := json.Marshal(part)
+	return err == nil && string(b) == "{}"
+}
+
+// isThoughtSignature reports whether part only carries a thought signature,
+// which is only meaningful to the Gemini models.
+func isThoughtSignature(part *genai.Part) bool {
+	if len(part.ThoughtSignature) == 0 {
+		return false
+	}
+	p := *part
+	p.ThoughtSignature = nil
+	return isEmptyPart(&p)
+}
+
+// drop records in trace that the adapter dropped v, which cannot be sent to
+// OpenAI, and reports it with the strictness policy of ctx.
+func drop(ctx context.Context, trace *assembly.Trace, kind, detail string, v any) error {
+	if err := strictness.Report(ctx, strictness.Conversion, "openai", "dropped %s %s: not supported by OpenAI", kind, detail); err != nil {
+		return err
+	}
+	if trace == nil {
+		return nil
+	}
+	size := 0
+	if b, err := json.Marshal(v); err == nil {
+		size = len(b)
+	}
+	trace.Record(assembly.Entry{
+		Component:   "openai",
+		Operation:   "drop " + kind,
+		Detail:      detail,
+		BytesDelta:  -size,
+		TokensDelta: -(size + 3) / 4,
+	})
+	return nil
+}
+
+// covertSystemMessage converts the system instruction to system messages,
+// or to developer messages if developer is set, e.g. for the reasoning
+// models, which expect them instead.
+func covertSystemMessage(systemInstruction *genai.Content, developer, thoughts bool) []openai.ChatCompletionMessageParamUnion {
 	var messages []openai.ChatCompletionMessageParamUnion
 
 	if systemInstruction == nil || len(systemInstruction.Parts) == 0 {
@@ -156,8 +792,10 @@ func covertSystemMessage(systemInstruction *genai.Content) []openai.ChatCompleti
 
 	for _, part := range systemInstruction.Parts {
 		switch {
-		case part == nil:
+		case part == nil || (part.Thought && !thoughts):
 			continue
+		case part.Text != "" && developer:
+			messages = append(messages, openai.DeveloperMessage(part.Text))
 		case part.Text != "":
 			messages = append(messages, openai.SystemMessage(part.Text))
 		}
@@ -189,20 +827,70 @@ func newMessages(role genai.Role, texts []string) []openai.ChatCompletionMessage
 	return messages
 }
 
+// SystemFingerprintMetadataKey is the key of the custom metadata holding
+// the system fingerprint of the responses, which identifies the backend
+// configuration that generated them. Requests with the same seed are only
+// expected to be answered the same way while the fingerprint is unchanged.
+const SystemFingerprintMetadataKey = "openai_system_fingerprint"
+
 func ChatCompletion2LLMResponse(resp *openai.ChatCompletion) *model.LLMResponse {
 	if resp == nil {
 		return nil
 	}
-	usageMetadata := convertUsage(resp.Usage)
+	// Some OpenAI-compatible servers return no usage.
+	var usageMetadata *genai.GenerateContentResponseUsageMetadata
+	if resp.JSON.Usage.Valid() {
+		usageMetadata = convertUsage(resp.Usage)
+	}
 	if len(resp.Choices) == 0 {
-		return &model.LLMResponse{
+		llmResponse := &model.LLMResponse{
 			UsageMetadata: usageMetadata,
-			ErrorCode:     "UNKNOWN_ERROR",
+			ErrorCode:     string(model.ErrorCodeUnknown),
 			ErrorMessage:  "Unknown error.",
 		}
+		setPredictionUsage(llmResponse, resp.Usage)
+		return llmResponse
 	}
 
-	choice := resp.Choices[0]
+	// The response is the first choice; with several choices, all of them
+	// are returned as candidates, ordered by index.
+	choices := slices.SortedStableFunc(slices.Values(resp.Choices), func(a, b openai.ChatCompletionChoice) int { return cmp.Compare(a.Index, b.Index) })
+	llmResponse := convertChoice(choices[0])
+	llmResponse.UsageMetadata = usageMetadata
+	if len(choices) > 1 {
+		for _, choice := range choices {
+			c := convertChoice(choice)
+			llmResponse.Candidates = append(llmResponse.Candidates, &genai.Candidate{
+				Index:             int32(choice.Index),
+				Content:           c.Content,
+				FinishReason:      c.FinishReason,
+				FinishMessage:     c.ErrorMessage,
+				GroundingMetadata: c.GroundingMetadata,
+				CitationMetadata:  c.CitationMetadata,
+			})
+		}
+	}
+	setResponseMetadata(llmResponse, resp.ID, resp.SystemFingerprint)
+	setPredictionUsage(llmResponse, resp.Usage)
+	return llmResponse
+}
+
+// setResponseMetadata records the ID and the system fingerprint of the
+// response in the custom metadata of resp, if they are set.
+func setResponseMetadata(resp *model.LLMResponse, id, fingerprint string) {
+	for key, value := range map[string]string{model.ResponseIDMetadataKey: id, SystemFingerprintMetadataKey: fingerprint} {
+		if value == "" {
+			continue
+		}
+		if resp.CustomMetadata == nil {
+			resp.CustomMetadata = map[string]any{}
+		}
+		resp.CustomMetadata[key] = value
+	}
+}
+
+// convertChoice converts a choice of a response, without its usage.
+func convertChoice(choice openai.ChatCompletionChoice) *model.LLMResponse {
 	message := choice.Message
 	content := &genai.Content{
 		Role: genai.RoleModel,
@@ -210,26 +898,64 @@ func ChatCompletion2LLMResponse(resp *openai.ChatCompletion) *model.LLMResponse
 	if message.Content != "" {
 		content.Parts = append(content.Parts, &genai.Part{Text: message.Content})
 	}
+	if message.Refusal != "" {
+		content.Parts = append(content.Parts, &genai.Part{Text: message.Refusal})
+	}
 
-	return &model.LLMResponse{
-		Content:       content,
-		UsageMetadata: usageMetadata,
-		FinishReason:  finishReason(choice.FinishReason),
+	llmResponse := &model.LLMResponse{
+		Content:           content,
+		FinishReason:      finishReason(choice.FinishReason),
+		GroundingMetadata: groundingMetadata(message.Content, message.Annotations),
+		CitationMetadata:  citationMetadata(message.Content, message.Annotations),
+	}
+	audio, err := audioParts(message.Audio.Data, message.Audio.Transcript, wavMIMEType)
+	content.Parts = append(content.Parts, audio...)
+	calls, callErr := functionCallParts(message.ToolCalls)
+	if err == nil {
+		err = callErr
 	}
+	if err != nil {
+		llmResponse.ErrorCode = string(model.ErrorCodeUnknown)
+		llmResponse.ErrorMessage = err.Error()
+	}
+	content.Parts = append(content.Parts, calls...)
+	setFilteredError(llmResponse, choice.FinishReason)
+	setRefusal(llmResponse, message.Refusal)
+	return llmResponse
 }
 
-func convertChunk(chunk openai.ChatCompletionChunk) *model.LLMResponse {
+// chunkState is the state of the conversion of the chunks of a streamed
+// response, for its first candidate.
+type chunkState struct {
+	// calls collects the fragments of the tool calls, returned with the
+	// finish reason.
+	calls toolCallAccumulator
+	// refusal collects the deltas of the refusal of the model, if it
+	// declined to answer.
+	refusal strings.Builder
+}
+
+// convertChunk converts a chunk of a streamed response, collecting in state
+// what is returned with the finish reason.
+func convertChunk(chunk openai.ChatCompletionChunk, state *chunkState) *model.LLMResponse {
 	if len(chunk.Choices) == 0 {
 		if chunk.JSON.Usage.Valid() {
-			return &model.LLMResponse{
+			resp := &model.LLMResponse{
 				UsageMetadata: convertUsage(chunk.Usage),
 				TurnComplete:  true,
 			}
+			setPredictionUsage(resp, chunk.Usage)
+			return resp
 		}
 		return nil
 	}
 
-	choice := chunk.Choices[0]
+	// The other candidates are demultiplexed by streamCandidates.
+	i := slices.IndexFunc(chunk.Choices, func(c openai.ChatCompletionChunkChoice) bool { return c.Index == 0 })
+	if i < 0 {
+		return nil
+	}
+	choice := chunk.Choices[i]
 	delta := choice.Delta
 
 	content := &genai.Content{
@@ -239,38 +965,116 @@ func convertChunk(chunk openai.ChatCompletionChunk) *model.LLMResponse {
 	if delta.Content != "" {
 		content.Parts = append(content.Parts, &genai.Part{Text: delta.Content})
 	}
+	// The refusal is streamed like the text.
+	if delta.Refusal != "" {
+		content.Parts = append(content.Parts, &genai.Part{Text: delta.Refusal})
+		state.refusal.WriteString(delta.Refusal)
+	}
+	data, transcript := chunkAudio(delta)
+	audio, audioErr := audioParts(data, transcript, pcm16MIMEType)
+	content.Parts = append(content.Parts, audio...)
 
-	// TODO: 阶段3 - 处理 delta.ToolCalls (增量 function call)
+	state.calls.add(delta.ToolCalls)
+	// The chunks only carrying the role or fragments of the tool calls
+	// yield no partial response.
+	if len(content.Parts) == 0 && choice.FinishReason == "" && audioErr == nil {
+		return nil
+	}
 
 	resp := &model.LLMResponse{
 		Content: content,
 		Partial: true,
 	}
+	if audioErr != nil {
+		resp.ErrorCode = string(model.ErrorCodeUnknown)
+		resp.ErrorMessage = audioErr.Error()
+	}
 
-	// 检查是否是最后一个 choice chunk
+	// The chunk finishing the choice completes the turn.
 	if choice.FinishReason != "" {
 		resp.TurnComplete = true
 		resp.Partial = false
 		resp.FinishReason = finishReason(choice.FinishReason)
-		if chunk.JSON.Usage.Valid() { // ← 添加检查
+		flushToolCalls(resp, &state.calls)
+		setFilteredError(resp, choice.FinishReason)
+		setRefusal(resp, state.refusal.String())
+		if chunk.JSON.Usage.Valid() {
 			resp.UsageMetadata = convertUsage(chunk.Usage)
+			setPredictionUsage(resp, chunk.Usage)
 		}
+		setResponseMetadata(resp, chunk.ID, chunk.SystemFingerprint)
 	}
 
 	return resp
 }
 
+// flushToolCalls adds the calls collected to the content of resp, or
+// reports an error if their arguments are invalid.
+func flushToolCalls(resp *model.LLMResponse, calls *toolCallAccumulator) {
+	if !calls.pending() {
+		return
+	}
+	parts, err := calls.flush()
+	if err != nil {
+		resp.ErrorCode = string(model.ErrorCodeUnknown)
+		resp.ErrorMessage = err.Error()
+		return
+	}
+	resp.Content.Parts = append(resp.Content.Parts, parts...)
+}
+
+// setFilteredError reports the responses cut by the content filter as
+// blocked for safety.
+func setFilteredError(resp *model.LLMResponse, reason string) {
+	if reason == "content_filter" {
+		resp.ErrorCode = string(model.ErrorCodeSafetyBlocked)
+		resp.ErrorMessage = "The response was blocked by the content filter."
+	}
+}
+
+// setRefusal reports the responses of a model declining to answer as
+// blocked for safety, with the refusal of the model as error message.
+func setRefusal(resp *model.LLMResponse, refusal string) {
+	if refusal == "" {
+		return
+	}
+	resp.FinishReason = genai.FinishReasonSafety
+	resp.ErrorCode = string(model.ErrorCodeRefusal)
+	resp.ErrorMessage = refusal
+}
+
 func convertUsage(usage openai.CompletionUsage) *genai.GenerateContentResponseUsageMetadata {
 	metadata := &genai.GenerateContentResponseUsageMetadata{
 		PromptTokenCount:     int32(usage.PromptTokens),
 		CandidatesTokenCount: int32(usage.CompletionTokens),
 		TotalTokenCount:      int32(usage.TotalTokens),
 		ThoughtsTokenCount:   int32(usage.CompletionTokensDetails.ReasoningTokens),
+		// The cached tokens are included in the prompt tokens.
+		CachedContentTokenCount: int32(usage.PromptTokensDetails.CachedTokens),
+	}
+	// The tokens are only detailed by modality for the audio models, which
+	// report their audio tokens: the other tokens are counted as text,
+	// including the images, which OpenAI does not report apart.
+	if n := usage.PromptTokensDetails.AudioTokens; n > 0 {
+		metadata.PromptTokensDetails = modalityTokenCounts(usage.PromptTokens, n)
+	}
+	if n := usage.CompletionTokensDetails.AudioTokens; n > 0 {
+		metadata.CandidatesTokensDetails = modalityTokenCounts(usage.CompletionTokens, n)
 	}
 
 	return metadata
 }
 
+// modalityTokenCounts returns the counts by modality of total tokens,
+// of which audio are audio tokens.
+func modalityTokenCounts(total, audio int64) []*genai.ModalityTokenCount {
+	var counts []*genai.ModalityTokenCount
+	if text := total - audio; text > 0 {
+		counts = append(counts, &genai.ModalityTokenCount{Modality: genai.MediaModalityText, TokenCount: int32(text)})
+	}
+	return append(counts, &genai.ModalityTokenCount{Modality: genai.MediaModalityAudio, TokenCount: int32(audio)})
+}
+
 func finishReason(reason string) genai.FinishReason {
 	switch reason {
 	case "stop":
@@ -288,18 +1092,26 @@ func finishReason(reason string) genai.FinishReason {
 	}
 }
 
-func applyGenerationConfig(params *openai.ChatCompletionNewParams, cfg *genai.GenerateContentConfig) error {
+func applyGenerationConfig(ctx context.Context, params *openai.ChatCompletionNewParams, cfg *genai.GenerateContentConfig, reasoning, strict bool) error {
 	if cfg == nil {
 		return nil
 	}
-	if cfg.Temperature != nil {
+	if err := reportUnmappedConfig(ctx, reasoning, cfg); err != nil {
+		return err
+	}
+	if err := dropSamplingParams(ctx, string(params.Model), cfg, reasoning); err != nil {
+		return err
+	}
+	if cfg.Temperature != nil && !reasoning {
 		params.Temperature = param.NewOpt(float64(*cfg.Temperature))
 	}
-	if cfg.TopP != nil {
+	if cfg.TopP != nil && !reasoning {
 		params.TopP = param.NewOpt(float64(*cfg.TopP))
 	}
 	if cfg.TopK != nil {
-		return fmt.Errorf("top_k is not supported")
+		if err := unsupportedConfig(ctx, strict, "config field top_k"); err != nil {
+			return err
+		}
 	}
 	if cfg.MaxOutputTokens > 0 {
 		params.MaxTokens = param.NewOpt(int64(cfg.MaxOutputTokens))
@@ -307,6 +1119,9 @@ func applyGenerationConfig(params *openai.ChatCompletionNewParams, cfg *genai.Ge
 	if len(cfg.StopSequences) > 0 {
 		params.Stop = openai.ChatCompletionNewParamsStopUnion{OfStringArray: cfg.StopSequences}
 	}
+	if cfg.Seed != nil {
+		params.Seed = param.NewOpt(int64(*cfg.Seed))
+	}
 	if cfg.CandidateCount > 1 {
 		params.N = param.NewOpt(int64(cfg.CandidateCount))
 	}
@@ -323,12 +1138,47 @@ func applyGenerationConfig(params *openai.ChatCompletionNewParams, cfg *genai.Ge
 			params.TopLogprobs = param.NewOpt(int64(1))
 		}
 	}
-	if cfg.SystemInstruction != nil {
-		inst := covertSystemMessage(cfg.SystemInstruction)
-		params.Messages = append(params.Messages, inst...)
+	applyReasoningEffort(params, cfg.ThinkingConfig, reasoning)
+	applyAudioOutput(params, cfg)
+	return applyResponseFormat(ctx, params, cfg, strict)
+}
+
+// unsupportedConfig fails for a parameter of the config that OpenAI cannot
+// express if strict. Otherwise the parameter is ignored: it reports it and
+// only fails if the strictness policy of ctx says so.
+func unsupportedConfig(ctx context.Context, strict bool, what string) error {
+	if strict {
+		return fmt.Errorf("%s is not supported", what)
+	}
+	return strictness.Report(ctx, strictness.Conversion, "openai", "ignored %s: not supported by OpenAI", what)
+}
+
+// reportUnmappedConfig reports the fields of cfg which have no OpenAI
+// equivalent and are ignored. The thinking config is only mapped for the
+// reasoning models, and never includes the thoughts.
+func reportUnmappedConfig(ctx context.Context, reasoning bool, cfg *genai.GenerateContentConfig) error {
+	if !strictness.Enabled(ctx, strictness.Conversion) {
+		return nil
 	}
-	if cfg.ResponseMIMEType != "" && cfg.ResponseMIMEType != "text/plain" {
-		return fmt.Errorf("response_mime_type is not supported")
+	modalities, speech := unmappedModalities(cfg)
+	for _, field := range []struct {
+		name string
+		set  bool
+	}{
+		{"safety_settings", len(cfg.SafetySettings) > 0},
+		{"thinking_config", cfg.ThinkingConfig != nil && !reasoning},
+		{"thinking_config.include_thoughts", cfg.ThinkingConfig != nil && cfg.ThinkingConfig.IncludeThoughts && reasoning},
+		{"response_modalities", modalities},
+		{"speech_config", speech},
+		{"cached_content", cfg.CachedContent != ""},
+		{"media_resolution", cfg.MediaResolution != ""},
+	} {
+		if !field.set {
+			continue
+		}
+		if err := strictness.Report(ctx, strictness.Conversion, "openai", "ignored config field %s: not supported by OpenAI", field.name); err != nil {
+			return err
+		}
 	}
 	return nil
 }
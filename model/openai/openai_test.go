@@ -0,0 +1,1043 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openai_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	openaisdk "github.com/openai/openai-go/v3"
+	"github.com/openai/openai-go/v3/option"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/httpx"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/model/openai"
+	"google.golang.org/adk/strictness"
+)
+
+// responseID is the custom metadata of the complete responses of the fake
+// servers, whose responses have the ID "c1".
+var responseID = map[string]any{model.ResponseIDMetadataKey: "c1"}
+
+// messages returns the messages of the request converted by the adapter,
+// as JSON values.
+func messages(t *testing.T, contents ...*genai.Content) []any {
+	t.Helper()
+	params, err := openai.LLMRequest2ChatCompletionNewParams(&model.LLMRequest{Model: "gpt-4o", Contents: contents})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := json.Marshal(params.Messages)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []any
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	return got
+}
+
+func TestLLMRequest2ChatCompletionNewParams_Images(t *testing.T) {
+	png := []byte("\x89PNG")
+	const pngURL = "data:image/png;base64,iVBORw=="
+	tests := []struct {
+		name     string
+		contents []*genai.Content
+		want     []any
+	}{
+		{
+			name: "TextAndImages",
+			contents: []*genai.Content{{Role: genai.RoleUser, Parts: []*genai.Part{
+				genai.NewPartFromText("what is in"),
+				genai.NewPartFromBytes(png, "image/png"),
+				genai.NewPartFromText("and in"),
+				genai.NewPartFromBytes([]byte{0xff, 0xd8}, "image/jpeg"),
+			}}},
+			want: []any{
+				map[string]any{"role": "user", "content": []any{
+					map[string]any{"type": "text", "text": "what is in"},
+					map[string]any{"type": "image_url", "image_url": map[string]any{"url": pngURL}},
+					map[string]any{"type": "text", "text": "and in"},
+					map[string]any{"type": "image_url", "image_url": map[string]any{"url": "data:image/jpeg;base64,/9g="}},
+				}},
+			},
+		},
+		{
+			name: "ImageOnly",
+			contents: []*genai.Content{
+				genai.NewContentFromText("hi", genai.RoleUser),
+				{Role: genai.RoleUser, Parts: []*genai.Part{genai.NewPartFromBytes(png, "image/png")}},
+			},
+			want: []any{
+				map[string]any{"role": "user", "content": "hi"},
+				map[string]any{"role": "user", "content": []any{
+					map[string]any{"type": "image_url", "image_url": map[string]any{"url": pngURL}},
+				}},
+			},
+		},
+		{
+			name: "ModelImage",
+			contents: []*genai.Content{
+				genai.NewContentFromText("draw a cat", genai.RoleUser),
+				{Role: genai.RoleModel, Parts: []*genai.Part{genai.NewPartFromText("here it is"), genai.NewPartFromBytes(png, "image/png")}},
+				genai.NewContentFromText("thanks", genai.RoleUser),
+			},
+			want: []any{
+				map[string]any{"role": "user", "content": "draw a cat"},
+				map[string]any{"role": "assistant", "content": "here it is"},
+				map[string]any{"role": "assistant", "content": "[image/png image of 4 bytes omitted: images are only supported in user messages]"},
+				map[string]any{"role": "user", "content": "thanks"},
+			},
+		},
+		{
+			name: "NonImage",
+			contents: []*genai.Content{{Role: genai.RoleUser, Parts: []*genai.Part{
+				genai.NewPartFromText("summarize"),
+				genai.NewPartFromBytes([]byte("%PDF"), "application/pdf"),
+			}}},
+			want: []any{
+				map[string]any{"role": "user", "content": "summarize"},
+				map[string]any{"role": "user", "content": "[application/pdf data of 4 bytes omitted: only images are supported]"},
+			},
+		},
+		{
+			name: "NonImageWithImage",
+			contents: []*genai.Content{{Role: genai.RoleUser, Parts: []*genai.Part{
+				genai.NewPartFromBytes(png, "image/png"),
+				genai.NewPartFromBytes([]byte("%PDF"), "application/pdf"),
+			}}},
+			want: []any{
+				map[string]any{"role": "user", "content": []any{
+					map[string]any{"type": "image_url", "image_url": map[string]any{"url": pngURL}},
+					map[string]any{"type": "text", "text": "[application/pdf data of 4 bytes omitted: only images are supported]"},
+				}},
+			},
+		},
+		{
+			name: "FileDataURLs",
+			contents: []*genai.Content{{Role: genai.RoleUser, Parts: []*genai.Part{
+				genai.NewPartFromText("compare"),
+				genai.NewPartFromURI("https://example.com/a.png", "image/png"),
+				genai.NewPartFromURI("http://example.com/b", ""),
+				genai.NewPartFromURI("https://example.com/c.pdf", "application/pdf"),
+			}}},
+			want: []any{
+				map[string]any{"role": "user", "content": []any{
+					map[string]any{"type": "text", "text": "compare"},
+					map[string]any{"type": "image_url", "image_url": map[string]any{"url": "https://example.com/a.png"}},
+					map[string]any{"type": "image_url", "image_url": map[string]any{"url": "http://example.com/b"}},
+					map[string]any{"type": "text", "text": "[application/pdf file https://example.com/c.pdf omitted: only images are supported]"},
+				}},
+			},
+		},
+		{
+			name: "ImageThenToolCall",
+			contents: []*genai.Content{{Role: genai.RoleUser, Parts: []*genai.Part{
+				genai.NewPartFromBytes(png, "image/png"),
+				{FunctionCall: &genai.FunctionCall{ID: "call_1", Name: "lookup", Args: map[string]any{"q": "cat"}}},
+				genai.NewPartFromText("what is it?"),
+			}}},
+			// The image does not leave an empty user message behind.
+			want: []any{
+				map[string]any{
+					"role":    "assistant",
+					"content": "what is it?",
+					"tool_calls": []any{map[string]any{
+						"id":       "call_1",
+						"type":     "function",
+						"function": map[string]any{"name": "lookup", "arguments": `{"q":"cat"}`},
+					}},
+				},
+			},
+		},
+		{
+			name: "ModelFileData",
+			contents: []*genai.Content{
+				{Role: genai.RoleModel, Parts: []*genai.Part{genai.NewPartFromURI("https://example.com/a.png", "image/png")}},
+			},
+			want: []any{
+				map[string]any{"role": "assistant", "content": "[image https://example.com/a.png omitted: images are only supported in user messages]"},
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.want, messages(t, tc.contents...)); diff != "" {
+				t.Errorf("messages mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestLLMRequest2ChatCompletionNewParams_UnsupportedFileData(t *testing.T) {
+	for _, uri := range []string{"gs://bucket/a.png", "file:///tmp/a.png"} {
+		t.Run(uri, func(t *testing.T) {
+			req := &model.LLMRequest{Model: "gpt-4o", Contents: []*genai.Content{
+				genai.NewContentFromText("hi", genai.RoleUser),
+				{Role: genai.RoleUser, Parts: []*genai.Part{genai.NewPartFromText("look"), genai.NewPartFromURI(uri, "image/png")}},
+			}}
+			_, err := openai.LLMRequest2ChatCompletionNewParams(req)
+			if err == nil || !strings.Contains(err.Error(), "content 1, part 1") || !strings.Contains(err.Error(), uri) {
+				t.Errorf("LLMRequest2ChatCompletionNewParams() error = %v, want an error naming the part and its URI", err)
+			}
+		})
+	}
+}
+
+func TestLLMRequest2ChatCompletionNewParams_SystemInstruction(t *testing.T) {
+	params, err := openai.LLMRequest2ChatCompletionNewParams(&model.LLMRequest{
+		Model: "gpt-4o",
+		Contents: []*genai.Content{
+			genai.NewContentFromText("hi", genai.RoleUser),
+			genai.NewContentFromText("hello", genai.RoleModel),
+			genai.NewContentFromText("what time is it?", genai.RoleUser),
+		},
+		Config: &genai.GenerateContentConfig{
+			SystemInstruction: &genai.Content{Parts: []*genai.Part{genai.NewPartFromText("be brief"), genai.NewPartFromText("be kind")}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := json.Marshal(params.Messages)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []any
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	want := []any{
+		map[string]any{"role": "system", "content": "be brief"},
+		map[string]any{"role": "system", "content": "be kind"},
+		map[string]any{"role": "user", "content": "hi"},
+		map[string]any{"role": "assistant", "content": "hello"},
+		map[string]any{"role": "user", "content": "what time is it?"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("messages mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestLLMRequest2ChatCompletionNewParams_Seed(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		seed *int32
+		want any
+	}{
+		{"Set", genai.Ptr[int32](42), float64(42)},
+		{"Zero", genai.Ptr[int32](0), float64(0)},
+		{"Nil", nil, nil},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			params, err := openai.LLMRequest2ChatCompletionNewParams(&model.LLMRequest{
+				Model:    "gpt-4o",
+				Contents: []*genai.Content{genai.NewContentFromText("hi", genai.RoleUser)},
+				Config:   &genai.GenerateContentConfig{Seed: tc.seed},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, err := json.Marshal(params)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var body map[string]any
+			if err := json.Unmarshal(b, &body); err != nil {
+				t.Fatal(err)
+			}
+			seed, ok := body["seed"]
+			if tc.want == nil && ok {
+				t.Errorf("seed = %v, want no seed", seed)
+			} else if diff := cmp.Diff(tc.want, seed); diff != "" {
+				t.Errorf("seed mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestChatCompletion2LLMResponse_ResponseMetadata(t *testing.T) {
+	var completion openaisdk.ChatCompletion
+	if err := json.Unmarshal([]byte(`{"id": "chatcmpl-B9MHDbslfkBeAs8l4bebGdFOJ6PeG", "object": "chat.completion", "model": "gpt-4o", "system_fingerprint": "fp_44709d6fcb",
+		"choices": [{"index": 0, "finish_reason": "stop", "message": {"role": "assistant", "content": "hi"}}]}`), &completion); err != nil {
+		t.Fatal(err)
+	}
+	resp := openai.ChatCompletion2LLMResponse(&completion)
+	want := map[string]any{
+		model.ResponseIDMetadataKey:         "chatcmpl-B9MHDbslfkBeAs8l4bebGdFOJ6PeG",
+		openai.SystemFingerprintMetadataKey: "fp_44709d6fcb",
+	}
+	if diff := cmp.Diff(want, resp.CustomMetadata); diff != "" {
+		t.Errorf("CustomMetadata mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestModel_CachedTokens(t *testing.T) {
+	// A single line, as a server-sent event.
+	const usage = `{"prompt_tokens": 2048, "completion_tokens": 10, "total_tokens": 2058, "prompt_tokens_details": {"cached_tokens": 1920, "audio_tokens": 0}}`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Stream bool `json:"stream"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if body.Stream {
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprint(w, `data: {"id": "c1", "object": "chat.completion.chunk", "model": "gpt-4o", "choices": [{"index": 0, "delta": {"content": "ok"}, "finish_reason": "stop"}]}`+"\n\n")
+			fmt.Fprintf(w, `data: {"id": "c1", "object": "chat.completion.chunk", "model": "gpt-4o", "choices": [], "usage": %s}`+"\n\ndata: [DONE]\n\n", usage)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"id": "c1", "object": "chat.completion", "model": "gpt-4o", "choices": [{"index": 0, "finish_reason": "stop", "message": {"role": "assistant", "content": "ok"}}], "usage": %s}`, usage)
+	}))
+	defer srv.Close()
+	llm, err := openai.NewModel(t.Context(), "gpt-4o", option.WithBaseURL(srv.URL), option.WithAPIKey("key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &genai.GenerateContentResponseUsageMetadata{
+		PromptTokenCount:        2048,
+		CachedContentTokenCount: 1920,
+		CandidatesTokenCount:    10,
+		TotalTokenCount:         2058,
+	}
+	for _, stream := range []bool{false, true} {
+		t.Run(fmt.Sprintf("stream=%v", stream), func(t *testing.T) {
+			req := &model.LLMRequest{Model: "gpt-4o", Contents: []*genai.Content{genai.NewContentFromText("hi", genai.RoleUser)}}
+			var got *genai.GenerateContentResponseUsageMetadata
+			for resp, err := range llm.GenerateContent(t.Context(), req, stream) {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if resp.UsageMetadata != nil {
+					got = resp.UsageMetadata
+				}
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("UsageMetadata mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestModel_ResolveFileData(t *testing.T) {
+	var got []any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Messages []any `json:"messages"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		got = body.Messages
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id": "c1", "object": "chat.completion", "model": "gpt-4o", "choices": [{"index": 0, "finish_reason": "stop", "message": {"role": "assistant", "content": "a cat"}}]}`)
+	}))
+	defer srv.Close()
+
+	var resolved []string
+	llm, err := openai.NewModelWithConfig(t.Context(), "gpt-4o", openai.Config{
+		ResolveFileData: func(ctx context.Context, data *genai.FileData) (*genai.Blob, error) {
+			resolved = append(resolved, data.FileURI)
+			return &genai.Blob{MIMEType: data.MIMEType, Data: []byte("\x89PNG")}, nil
+		},
+	}, option.WithBaseURL(srv.URL), option.WithAPIKey("key"), option.WithMaxRetries(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	part := genai.NewPartFromURI("gs://bucket/cat.png", "image/png")
+	content := &genai.Content{Role: genai.RoleUser, Parts: []*genai.Part{genai.NewPartFromText("what is it?"), part}}
+	req := &model.LLMRequest{Model: "gpt-4o", Contents: []*genai.Content{content}}
+	for _, err := range llm.GenerateContent(t.Context(), req, false) {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := []any{
+		map[string]any{"role": "user", "content": []any{
+			map[string]any{"type": "text", "text": "what is it?"},
+			map[string]any{"type": "image_url", "image_url": map[string]any{"url": "data:image/png;base64,iVBORw=="}},
+		}},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("messages mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"gs://bucket/cat.png"}, resolved); diff != "" {
+		t.Errorf("resolved URIs mismatch (-want +got):\n%s", diff)
+	}
+	// The content of the request is not modified.
+	if content.Parts[1] != part || part.InlineData != nil {
+		t.Error("the request content was modified")
+	}
+}
+
+func TestModel_UserContent(t *testing.T) {
+	var got []any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Messages []any `json:"messages"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		got = body.Messages
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id": "c1", "object": "chat.completion", "model": "gpt-4o", "choices": [{"index": 0, "finish_reason": "stop", "message": {"role": "assistant", "content": "ok"}}]}`)
+	}))
+	defer srv.Close()
+
+	system := func(text string) any { return map[string]any{"role": "system", "content": text} }
+	user := func(text string) any { return map[string]any{"role": "user", "content": text} }
+	assistant := func(text string) any { return map[string]any{"role": "assistant", "content": text} }
+	tests := []struct {
+		name     string
+		cfg      openai.Config
+		contents []*genai.Content
+		want     []any
+	}{
+		{
+			name: "empty history",
+			want: []any{system("be brief"), user("Handle the requests as specified in the System Instruction.")},
+		},
+		{
+			name:     "model turn last",
+			contents: []*genai.Content{genai.NewContentFromText("hi", genai.RoleUser), genai.NewContentFromText("hello", genai.RoleModel)},
+			want: []any{system("be brief"), user("hi"), assistant("hello"),
+				user("Continue processing previous requests as instructed. Exit or provide a summary if no more outputs are needed.")},
+		},
+		{
+			name: "custom texts",
+			cfg:  openai.Config{InitialUserText: "Commence.", ContinuationUserText: "Continue."},
+			want: []any{system("be brief"), user("Commence.")},
+		},
+		{
+			name:     "custom continuation",
+			cfg:      openai.Config{InitialUserText: "Commence.", ContinuationUserText: "Continue."},
+			contents: []*genai.Content{genai.NewContentFromText("hello", genai.RoleModel)},
+			want:     []any{system("be brief"), assistant("hello"), user("Continue.")},
+		},
+		{
+			name:     "disabled",
+			cfg:      openai.Config{DisableUserContent: true},
+			contents: []*genai.Content{genai.NewContentFromText("hi", genai.RoleUser), genai.NewContentFromText("hello", genai.RoleModel)},
+			want:     []any{system("be brief"), user("hi"), assistant("hello")},
+		},
+		{
+			name: "disabled with an empty history",
+			cfg:  openai.Config{DisableUserContent: true},
+			want: []any{system("be brief")},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			llm, err := openai.NewModelWithConfig(t.Context(), "gpt-4o", tc.cfg, option.WithBaseURL(srv.URL), option.WithAPIKey("key"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got = nil
+			req := &model.LLMRequest{
+				Model:    "gpt-4o",
+				Contents: tc.contents,
+				Config:   &genai.GenerateContentConfig{SystemInstruction: genai.NewContentFromText("be brief", genai.RoleUser)},
+			}
+			for _, err := range llm.GenerateContent(t.Context(), req, false) {
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("messages mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	t.Run("disabled without messages", func(t *testing.T) {
+		llm, err := openai.NewModelWithConfig(t.Context(), "gpt-4o", openai.Config{DisableUserContent: true}, option.WithBaseURL(srv.URL), option.WithAPIKey("key"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = nil
+		for _, err := range llm.GenerateContent(t.Context(), &model.LLMRequest{Model: "gpt-4o"}, false) {
+			if err == nil {
+				t.Error("GenerateContent() succeeded, want an error")
+			}
+		}
+		if got != nil {
+			t.Errorf("request sent with messages %v, want none sent", got)
+		}
+	})
+}
+
+func TestModel_Thoughts(t *testing.T) {
+	var got []any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Messages []any `json:"messages"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		got = body.Messages
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id": "c1", "object": "chat.completion", "model": "gpt-4o", "choices": [{"index": 0, "finish_reason": "stop", "message": {"role": "assistant", "content": "ok"}}]}`)
+	}))
+	defer srv.Close()
+
+	thought := func(text string) *genai.Part { return &genai.Part{Text: text, Thought: true} }
+	req := func() *model.LLMRequest {
+		return &model.LLMRequest{
+			Model: "gpt-4o",
+			Contents: []*genai.Content{
+				genai.NewContentFromText("weather in Paris?", genai.RoleUser),
+				{Role: genai.RoleModel, Parts: []*genai.Part{
+					thought("The user wants the weather."),
+					{ThoughtSignature: []byte("sig")},
+					genai.NewPartFromText("Sunny."),
+				}},
+				genai.NewContentFromText("and tomorrow?", genai.RoleUser),
+			},
+			Config: &genai.GenerateContentConfig{SystemInstruction: &genai.Content{Parts: []*genai.Part{
+				genai.NewPartFromText("be brief"),
+				thought("Brevity matters."),
+			}}},
+		}
+	}
+	tests := []struct {
+		name string
+		cfg  openai.Config
+		want []any
+	}{
+		{
+			name: "default",
+			want: []any{
+				map[string]any{"role": "system", "content": "be brief"},
+				map[string]any{"role": "user", "content": "weather in Paris?"},
+				map[string]any{"role": "assistant", "content": "Sunny."},
+				map[string]any{"role": "user", "content": "and tomorrow?"},
+			},
+		},
+		{
+			name: "included",
+			cfg:  openai.Config{IncludeThoughts: true},
+			want: []any{
+				map[string]any{"role": "system", "content": "be brief"},
+				map[string]any{"role": "system", "content": "Brevity matters."},
+				map[string]any{"role": "user", "content": "weather in Paris?"},
+				map[string]any{"role": "assistant", "content": "The user wants the weather."},
+				map[string]any{"role": "assistant", "content": "Sunny."},
+				map[string]any{"role": "user", "content": "and tomorrow?"},
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			llm, err := openai.NewModelWithConfig(t.Context(), "gpt-4o", tc.cfg, option.WithBaseURL(srv.URL), option.WithAPIKey("key"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, err := range llm.GenerateContent(t.Context(), req(), false) {
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("messages mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestModel_ModelName(t *testing.T) {
+	var got any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		got = body["model"]
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id": "c1", "object": "chat.completion", "model": "gpt-4o", "choices": [{"index": 0, "finish_reason": "stop", "message": {"role": "assistant", "content": "ok"}}]}`)
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name         string
+		modelName    string
+		requestModel string
+		want         any
+		wantErr      bool
+	}{
+		{name: "adapter model", modelName: "gpt-4o", want: "gpt-4o"},
+		{name: "request model", modelName: "gpt-4o", requestModel: "gpt-4o-mini", want: "gpt-4o-mini"},
+		{name: "request model only", requestModel: "gpt-4o-mini", want: "gpt-4o-mini"},
+		{name: "no model", wantErr: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got = nil
+			llm, err := openai.NewModel(t.Context(), tc.modelName, option.WithBaseURL(srv.URL), option.WithAPIKey("key"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req := &model.LLMRequest{Model: tc.requestModel, Contents: []*genai.Content{genai.NewContentFromText("hi", genai.RoleUser)}}
+			var gotErr error
+			for _, err := range llm.GenerateContent(t.Context(), req, false) {
+				gotErr = err
+			}
+			if tc.wantErr {
+				if gotErr == nil || !strings.Contains(gotErr.Error(), "no model") {
+					t.Errorf("GenerateContent() error = %v, want an error naming the missing model", gotErr)
+				}
+			} else if gotErr != nil {
+				t.Fatalf("GenerateContent() failed: %v", gotErr)
+			}
+			if got != tc.want {
+				t.Errorf("model = %v, want %v", got, tc.want)
+			}
+		})
+	}
+
+	if _, err := openai.LLMRequest2ChatCompletionNewParams(&model.LLMRequest{}); err == nil {
+		t.Error("LLMRequest2ChatCompletionNewParams() of a request naming no model succeeded, want an error")
+	}
+}
+
+func TestNewModelWithClient(t *testing.T) {
+	var models []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Model  string `json:"model"`
+			Stream bool   `json:"stream"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		models = append(models, body.Model)
+		if body.Stream {
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprintf(w, "data: {\"id\":\"c1\",\"object\":\"chat.completion.chunk\",\"model\":%q,\"choices\":[{\"index\":0,\"delta\":{\"content\":\"hi\"},\"finish_reason\":\"stop\"}]}\n\n", body.Model)
+			fmt.Fprint(w, "data: [DONE]\n\n")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"id": "c1", "object": "chat.completion", "model": %q, "choices": [{"index": 0, "finish_reason": "stop", "message": {"role": "assistant", "content": "hi"}}]}`, body.Model)
+	}))
+	defer srv.Close()
+
+	// The models share the client and its base URL.
+	client := openaisdk.NewClient(option.WithBaseURL(srv.URL), option.WithAPIKey("test"), option.WithMaxRetries(0))
+	for _, name := range []string{"gpt-4o", "gpt-4o-mini"} {
+		llm := openai.NewModelWithClient(name, &client)
+		req := &model.LLMRequest{Model: name, Contents: []*genai.Content{genai.NewContentFromText("hello", genai.RoleUser)}}
+		for _, stream := range []bool{false, true} {
+			var text string
+			for resp, err := range llm.GenerateContent(t.Context(), req, stream) {
+				if err != nil {
+					t.Fatalf("GenerateContent(%s, stream=%v) failed: %v", name, stream, err)
+				}
+				if !resp.Partial && resp.Content != nil {
+					text = resp.Content.Parts[0].Text
+				}
+			}
+			if text != "hi" {
+				t.Errorf("GenerateContent(%s, stream=%v) text = %q, want hi", name, stream, text)
+			}
+		}
+	}
+	if diff := cmp.Diff([]string{"gpt-4o", "gpt-4o", "gpt-4o-mini", "gpt-4o-mini"}, models); diff != "" {
+		t.Errorf("requested models mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestModel_OutboundHeaders(t *testing.T) {
+	var headers http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = r.Header.Clone()
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id": "c1", "object": "chat.completion", "model": "gpt-4o", "choices": [{"index": 0, "finish_reason": "stop", "message": {"role": "assistant", "content": "ok"}}]}`)
+	}))
+	defer srv.Close()
+
+	for _, tc := range []struct {
+		name string
+		opts []option.RequestOption
+		want map[string]string
+	}{
+		{
+			name: "Default",
+			want: map[string]string{"X-Request-ID": "e-1", "X-Session-Hash": httpx.Hash(nil, "s1"), "X-Agent-Name": "agent"},
+		},
+		{
+			name: "CustomClient",
+			opts: []option.RequestOption{option.WithHTTPClient(&http.Client{})},
+			want: map[string]string{},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := append([]option.RequestOption{option.WithBaseURL(srv.URL), option.WithAPIKey("key")}, tc.opts...)
+			llm, err := openai.NewModel(t.Context(), "gpt-4o", opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ctx := httpx.NewContext(t.Context(), httpx.Metadata{SessionID: "s1", InvocationID: "e-1", AgentName: "agent"})
+			req := &model.LLMRequest{Model: "gpt-4o", Contents: []*genai.Content{genai.NewContentFromText("hi", genai.RoleUser)}}
+			for _, err := range llm.GenerateContent(ctx, req, false) {
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			got := map[string]string{}
+			for _, name := range []string{"X-Request-ID", "X-Session-Hash", "X-Agent-Name"} {
+				if v := headers.Get(name); v != "" {
+					got[name] = v
+				}
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("headers mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestModel_Candidates(t *testing.T) {
+	var n int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			N int `json:"n"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		n = body.N
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id": "c1", "object": "chat.completion", "model": "gpt-4o", "choices": [
+			{"index": 2, "finish_reason": "length", "message": {"role": "assistant", "content": "a tabby"}},
+			{"index": 0, "finish_reason": "stop", "message": {"role": "assistant", "content": "a cat"}},
+			{"index": 1, "finish_reason": "content_filter", "message": {"role": "assistant", "content": "a"}}],
+			"usage": {"prompt_tokens": 5, "completion_tokens": 9, "total_tokens": 14}}`)
+	}))
+	defer srv.Close()
+
+	llm, err := openai.NewModel(t.Context(), "gpt-4o", option.WithBaseURL(srv.URL), option.WithAPIKey("key"), option.WithMaxRetries(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := &model.LLMRequest{
+		Model:    "gpt-4o",
+		Contents: []*genai.Content{genai.NewContentFromText("what is it?", genai.RoleUser)},
+		Config:   &genai.GenerateContentConfig{CandidateCount: 3},
+	}
+	var responses []*model.LLMResponse
+	for resp, err := range llm.GenerateContent(t.Context(), req, false) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		responses = append(responses, resp)
+	}
+	if n != 3 {
+		t.Errorf("n = %d, want 3", n)
+	}
+	if len(responses) != 1 {
+		t.Fatalf("got %d responses, want 1", len(responses))
+	}
+	resp := responses[0]
+	want := []*genai.Candidate{
+		{Index: 0, Content: genai.NewContentFromText("a cat", genai.RoleModel), FinishReason: genai.FinishReasonStop},
+		{Index: 1, Content: genai.NewContentFromText("a", genai.RoleModel), FinishReason: genai.FinishReasonSafety, FinishMessage: "The response was blocked by the content filter."},
+		{Index: 2, Content: genai.NewContentFromText("a tabby", genai.RoleModel), FinishReason: genai.FinishReasonMaxTokens},
+	}
+	if diff := cmp.Diff(want, resp.Candidates); diff != "" {
+		t.Errorf("candidates mismatch (-want +got):\n%s", diff)
+	}
+	// The response itself is the first candidate, with the usage of all.
+	if diff := cmp.Diff(want[0].Content, resp.Content); diff != "" {
+		t.Errorf("content mismatch (-want +got):\n%s", diff)
+	}
+	if resp.FinishReason != genai.FinishReasonStop || resp.ErrorCode != "" {
+		t.Errorf("response finish reason = %q, error code = %q, want STOP and no error", resp.FinishReason, resp.ErrorCode)
+	}
+	if resp.UsageMetadata == nil || resp.UsageMetadata.TotalTokenCount != 14 {
+		t.Errorf("UsageMetadata = %+v, want 14 total tokens", resp.UsageMetadata)
+	}
+}
+
+func TestModel_Strictness(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id": "c1", "object": "chat.completion", "model": "gpt-4o", "choices": [{"index": 0, "finish_reason": "stop", "message": {"role": "assistant", "content": "ok"}}]}`)
+	}))
+	defer srv.Close()
+	llm, err := openai.NewModel(t.Context(), "gpt-4o", option.WithBaseURL(srv.URL), option.WithAPIKey("key"), option.WithMaxRetries(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pdf := &genai.Content{Role: genai.RoleUser, Parts: []*genai.Part{
+		genai.NewPartFromText("summarize"),
+		genai.NewPartFromBytes([]byte("%PDF"), "application/pdf"),
+	}}
+	tests := []struct {
+		level        strictness.Level
+		wantWarnings []strictness.Warning
+		wantErr      bool
+	}{
+		{level: strictness.Silent},
+		{
+			level: strictness.Warn,
+			wantWarnings: []strictness.Warning{
+				{Category: strictness.Conversion, Component: "openai", Message: "dropped inline data of content 0: not supported by OpenAI"},
+			},
+		},
+		{level: strictness.Error, wantErr: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.level.String(), func(t *testing.T) {
+			requests = 0
+			collector := &strictness.Collector{}
+			ctx := strictness.NewContext(t.Context(), &strictness.Policy{Level: tc.level})
+			ctx = strictness.WithCollector(ctx, collector)
+			req := &model.LLMRequest{Model: "gpt-4o", Contents: []*genai.Content{pdf}}
+			var gotErr error
+			for _, err := range llm.GenerateContent(ctx, req, false) {
+				gotErr = err
+			}
+			if tc.wantErr {
+				if !errors.Is(gotErr, strictness.ErrDropped) {
+					t.Errorf("GenerateContent() error = %v, want ErrDropped", gotErr)
+				}
+				if requests != 0 {
+					t.Errorf("%d requests sent, want none", requests)
+				}
+			} else if gotErr != nil {
+				t.Fatalf("GenerateContent() failed: %v", gotErr)
+			}
+			if diff := cmp.Diff(tc.wantWarnings, collector.Warnings()); diff != "" {
+				t.Errorf("warnings mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestModel_StrictConfig(t *testing.T) {
+	var body map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id": "c1", "object": "chat.completion", "model": "gpt-4o", "choices": [{"index": 0, "finish_reason": "stop", "message": {"role": "assistant", "content": "ok"}}]}`)
+	}))
+	defer srv.Close()
+
+	config := &genai.GenerateContentConfig{
+		Temperature:      genai.Ptr[float32](0.5),
+		TopK:             genai.Ptr[float32](40),
+		ResponseMIMEType: "text/x.enum",
+	}
+	tests := []struct {
+		name         string
+		strict       bool
+		wantWarnings []strictness.Warning
+		wantErr      bool
+	}{
+		{
+			name: "lenient",
+			wantWarnings: []strictness.Warning{
+				{Category: strictness.Conversion, Component: "openai", Message: "ignored config field top_k: not supported by OpenAI"},
+				{Category: strictness.Conversion, Component: "openai", Message: `ignored response MIME type "text/x.enum": not supported by OpenAI`},
+			},
+		},
+		{name: "strict", strict: true, wantErr: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			llm, err := openai.NewModelWithConfig(t.Context(), "gpt-4o", openai.Config{StrictConfig: tc.strict}, option.WithBaseURL(srv.URL), option.WithAPIKey("key"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			body = nil
+			collector := &strictness.Collector{}
+			ctx := strictness.NewContext(t.Context(), &strictness.Policy{Level: strictness.Warn})
+			ctx = strictness.WithCollector(ctx, collector)
+			req := &model.LLMRequest{
+				Model:    "gpt-4o",
+				Contents: []*genai.Content{genai.NewContentFromText("hi", genai.RoleUser)},
+				Config:   config,
+			}
+			var gotErr error
+			for _, err := range llm.GenerateContent(ctx, req, false) {
+				gotErr = err
+			}
+			if tc.wantErr {
+				if gotErr == nil {
+					t.Error("GenerateContent() succeeded, want an error")
+				}
+				if body != nil {
+					t.Error("request sent, want none")
+				}
+				return
+			}
+			if gotErr != nil {
+				t.Fatalf("GenerateContent() failed: %v", gotErr)
+			}
+			if got := body["temperature"]; got != 0.5 {
+				t.Errorf("temperature = %v, want 0.5", got)
+			}
+			for _, key := range []string{"top_k", "response_format"} {
+				if got, ok := body[key]; ok {
+					t.Errorf("%s = %v, want none", key, got)
+				}
+			}
+			if diff := cmp.Diff(tc.wantWarnings, collector.Warnings()); diff != "" {
+				t.Errorf("warnings mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestModel_Refusal(t *testing.T) {
+	const refusal = "I can't help with that."
+	chunk := func(delta, finish string) string {
+		return fmt.Sprintf(`data: {"id":"c1","object":"chat.completion.chunk","model":"gpt-4o","choices":[{"index":0,"delta":%s,"finish_reason":%s}]}`+"\n\n", delta, finish)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Stream bool `json:"stream"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if !body.Stream {
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"id": "c1", "object": "chat.completion", "model": "gpt-4o", "choices": [{"index": 0, "finish_reason": "stop", "message": {"role": "assistant", "content": null, "refusal": %q}}]}`, refusal)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, chunk(`{"role":"assistant","refusal":"I can't "}`, "null"))
+		fmt.Fprint(w, chunk(`{"refusal":"help with that."}`, `"stop"`))
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name   string
+		stream bool
+		want   []*model.LLMResponse
+	}{
+		{
+			name: "Response",
+			want: []*model.LLMResponse{{
+				Content:        genai.NewContentFromText(refusal, genai.RoleModel),
+				FinishReason:   genai.FinishReasonSafety,
+				ErrorCode:      string(model.ErrorCodeRefusal),
+				ErrorMessage:   refusal,
+				CustomMetadata: responseID,
+			}},
+		},
+		{
+			name:   "Stream",
+			stream: true,
+			want: []*model.LLMResponse{
+				{Content: genai.NewContentFromText("I can't ", genai.RoleModel), Partial: true},
+				{
+					Content:        genai.NewContentFromText("help with that.", genai.RoleModel),
+					TurnComplete:   true,
+					FinishReason:   genai.FinishReasonSafety,
+					ErrorCode:      string(model.ErrorCodeRefusal),
+					ErrorMessage:   refusal,
+					CustomMetadata: responseID,
+				},
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			llm, err := openai.NewModel(t.Context(), "gpt-4o", option.WithBaseURL(srv.URL), option.WithAPIKey("key"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req := &model.LLMRequest{Model: "gpt-4o", Contents: []*genai.Content{genai.NewContentFromText("hi", genai.RoleUser)}}
+			var got []*model.LLMResponse
+			for resp, err := range llm.GenerateContent(t.Context(), req, tc.stream) {
+				if err != nil {
+					t.Fatal(err)
+				}
+				got = append(got, resp)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("responses mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestModel_ExtraFields(t *testing.T) {
+	var got map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = nil
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		if got["stream"] == true {
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprint(w, `data: {"id":"c1","object":"chat.completion.chunk","model":"gpt-4o","choices":[{"index":0,"delta":{"role":"assistant","content":"ok"},"finish_reason":"stop"}]}`+"\n\ndata: [DONE]\n\n")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id": "c1", "object": "chat.completion", "model": "gpt-4o", "choices": [{"index": 0, "finish_reason": "stop", "message": {"role": "assistant", "content": "ok"}}]}`)
+	}))
+	defer srv.Close()
+	req := func() *model.LLMRequest {
+		return &model.LLMRequest{
+			Model:    "gpt-4o",
+			Contents: []*genai.Content{genai.NewContentFromText("hi", genai.RoleUser)},
+			Config:   &genai.GenerateContentConfig{Temperature: genai.Ptr[float32](0.5)},
+		}
+	}
+	generate := func(t *testing.T, stream bool, opts ...option.RequestOption) {
+		t.Helper()
+		llm, err := openai.NewModel(t.Context(), "gpt-4o", append([]option.RequestOption{option.WithBaseURL(srv.URL), option.WithAPIKey("key")}, opts...)...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, err := range llm.GenerateContent(t.Context(), req(), stream) {
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	t.Run("ExtraFields", func(t *testing.T) {
+		generate(t, false, openai.WithExtraFields(map[string]any{
+			"logit_bias":   map[string]any{"50256": -100},
+			"service_tier": "flex",
+			"store":        true,
+		}))
+		for key, want := range map[string]any{
+			"logit_bias":   map[string]any{"50256": float64(-100)},
+			"service_tier": "flex",
+			"store":        true,
+			"temperature":  0.5,
+		} {
+			if diff := cmp.Diff(want, got[key]); diff != "" {
+				t.Errorf("%s mismatch (-want +got):\n%s", key, diff)
+			}
+		}
+	})
+	t.Run("RequestMutator", func(t *testing.T) {
+		generate(t, true, openai.WithRequestMutator(func(params *openaisdk.ChatCompletionNewParams) {
+			params.StreamOptions = openaisdk.ChatCompletionStreamOptionsParam{}
+			params.Temperature = openaisdk.Float(1)
+		}))
+		if _, ok := got["stream_options"]; ok {
+			t.Errorf("stream_options = %v, want none", got["stream_options"])
+		}
+		if diff := cmp.Diff(any(1.0), got["temperature"]); diff != "" {
+			t.Errorf("temperature mismatch (-want +got):\n%s", diff)
+		}
+	})
+}
@@ -0,0 +1,75 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openai
+
+import (
+	"errors"
+
+	"github.com/openai/openai-go/v3"
+	"github.com/openai/openai-go/v3/packages/param"
+
+	"google.golang.org/adk/model"
+)
+
+// PredictionLabel is the key of the label of the requests carrying their
+// predicted output, e.g. the document that the model is asked to edit. It
+// is sent as the prediction of the request, which lets the model skip the
+// generation of the predicted tokens, rather than as metadata. Predictions
+// are supported by the streamed requests, but not by the requests for
+// several candidates, which fail.
+//
+// The label is only understood by this adapter: the other providers, e.g.
+// Vertex AI, may reject the long label values. It is best set by a
+// callback before the calls of the OpenAI models.
+const PredictionLabel = "openai_prediction"
+
+// The keys of the custom metadata holding the numbers of tokens of the
+// prediction of a request which appeared, or did not, in its response.
+// They are set in the response carrying the usage, when the usage reports
+// them.
+const (
+	AcceptedPredictionTokensMetadataKey = "openai_accepted_prediction_tokens"
+	RejectedPredictionTokensMetadataKey = "openai_rejected_prediction_tokens"
+)
+
+// applyPrediction sends the predicted output of a request, carried by the
+// PredictionLabel of labels, if any.
+func applyPrediction(params *openai.ChatCompletionNewParams, labels map[string]string) error {
+	prediction, ok := labels[PredictionLabel]
+	if !ok {
+		return nil
+	}
+	if params.N.Value > 1 {
+		return errors.New("a prediction cannot be sent with a request for several candidates")
+	}
+	params.Prediction = openai.ChatCompletionPredictionContentParam{
+		Content: openai.ChatCompletionPredictionContentContentUnionParam{OfString: param.NewOpt(prediction)},
+	}
+	return nil
+}
+
+// setPredictionUsage records in the custom metadata of resp the numbers of
+// tokens of the prediction accepted and rejected, if usage reports them.
+func setPredictionUsage(resp *model.LLMResponse, usage openai.CompletionUsage) {
+	details := usage.CompletionTokensDetails
+	if !details.JSON.AcceptedPredictionTokens.Valid() && !details.JSON.RejectedPredictionTokens.Valid() {
+		return
+	}
+	if resp.CustomMetadata == nil {
+		resp.CustomMetadata = map[string]any{}
+	}
+	resp.CustomMetadata[AcceptedPredictionTokensMetadataKey] = details.AcceptedPredictionTokens
+	resp.CustomMetadata[RejectedPredictionTokensMetadataKey] = details.RejectedPredictionTokens
+}
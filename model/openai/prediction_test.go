@@ -0,0 +1,103 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openai_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openai/openai-go/v3/option"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/model/openai"
+)
+
+func TestModel_Prediction(t *testing.T) {
+	const (
+		document = "func add(a, b int) int { return a + b }"
+		usage    = `{"prompt_tokens":20,"completion_tokens":12,"total_tokens":32,"completion_tokens_details":{"accepted_prediction_tokens":10,"rejected_prediction_tokens":2}}`
+	)
+	var got map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = nil
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		if got["stream"] != true {
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"id": "c1", "object": "chat.completion", "model": "gpt-4o", "choices": [{"index": 0, "finish_reason": "stop", "message": {"role": "assistant", "content": "done"}}], "usage": %s}`, usage)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, `data: {"id":"c1","object":"chat.completion.chunk","model":"gpt-4o","choices":[{"index":0,"delta":{"role":"assistant","content":"done"},"finish_reason":"stop"}]}`+"\n\n")
+		fmt.Fprintf(w, `data: {"id":"c1","object":"chat.completion.chunk","model":"gpt-4o","choices":[],"usage":%s}`+"\n\n", usage)
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer srv.Close()
+	llm, err := openai.NewModel(t.Context(), "gpt-4o", option.WithBaseURL(srv.URL), option.WithAPIKey("key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := func(candidates int32) *model.LLMRequest {
+		return &model.LLMRequest{
+			Model:    "gpt-4o",
+			Contents: []*genai.Content{genai.NewContentFromText("rename add to sum", genai.RoleUser)},
+			Config: &genai.GenerateContentConfig{
+				CandidateCount: candidates,
+				Labels:         map[string]string{openai.PredictionLabel: document, "team": "editor"},
+			},
+		}
+	}
+	wantMetadata := map[string]any{
+		openai.AcceptedPredictionTokensMetadataKey: int64(10),
+		openai.RejectedPredictionTokensMetadataKey: int64(2),
+	}
+
+	for _, stream := range []bool{false, true} {
+		t.Run(fmt.Sprintf("stream=%v", stream), func(t *testing.T) {
+			var metadata map[string]any
+			for resp, err := range llm.GenerateContent(t.Context(), req(0), stream) {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if resp.UsageMetadata != nil {
+					metadata = resp.CustomMetadata
+				}
+			}
+			if diff := cmp.Diff(map[string]any{"type": "content", "content": document}, got["prediction"]); diff != "" {
+				t.Errorf("prediction mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(map[string]any{"team": "editor"}, got["metadata"]); diff != "" {
+				t.Errorf("metadata mismatch (-want +got):\n%s", diff)
+			}
+			// Only the complete response carries its ID.
+			delete(metadata, model.ResponseIDMetadataKey)
+			if diff := cmp.Diff(wantMetadata, metadata); diff != "" {
+				t.Errorf("custom metadata of the usage mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	t.Run("Candidates", func(t *testing.T) {
+		for _, err := range llm.GenerateContent(t.Context(), req(2), false) {
+			if err == nil {
+				t.Error("GenerateContent() succeeded, want an error for a prediction with several candidates")
+			}
+		}
+	})
+}
@@ -0,0 +1,287 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/openai/openai-go/v3"
+	"github.com/openai/openai-go/v3/option"
+	"github.com/openai/openai-go/v3/packages/param"
+	"github.com/openai/openai-go/v3/shared"
+
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/strictness"
+)
+
+// Capabilities are the features of an OpenAI-compatible server detected by
+// [NewProbedModel].
+type Capabilities struct {
+	// StreamUsage reports whether the streams return their usage when asked
+	// with stream_options.include_usage.
+	StreamUsage bool
+	// Tools reports whether the requests declaring tools are accepted.
+	Tools bool
+	// JSONMode reports whether the requests with a JSON response_format are
+	// accepted, i.e. whether the response schema of the requests can be
+	// enforced.
+	JSONMode bool
+	// ContextLength is the maximum number of tokens of the context of the
+	// model advertised by the server, zero if unknown.
+	ContextLength int
+	// Probed reports whether the capabilities were probed. If the probe
+	// failed, the capabilities are the conservative defaults: no stream
+	// usage, no JSON mode and tools supported.
+	Probed bool
+	// ProbedAt is when the server was probed.
+	ProbedAt time.Time
+}
+
+// CapabilitiesReporter is implemented by the models created by
+// [NewProbedModel], so that the flows can adapt to their server, e.g. not
+// enforce the output schema of an agent when JSON mode is not supported.
+type CapabilitiesReporter interface {
+	Capabilities() Capabilities
+}
+
+// ProbeConfig configures the probe of [NewProbedModel].
+type ProbeConfig struct {
+	// TTL is how long the capabilities probed for a base URL and a model
+	// are reused by the models created later. Defaults to 1 hour. The failed
+	// probes are not reused.
+	TTL time.Duration
+	// Timeout limits the duration of the probe. Defaults to 30 seconds.
+	Timeout time.Duration
+	// Now returns the current time. Defaults to time.Now.
+	Now func() time.Time
+}
+
+func (c ProbeConfig) withDefaults() ProbeConfig {
+	if c.TTL <= 0 {
+		c.TTL = time.Hour
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = 30 * time.Second
+	}
+	if c.Now == nil {
+		c.Now = time.Now
+	}
+	return c
+}
+
+// conservativeCapabilities are the capabilities assumed when the probe
+// fails: the parameters that some servers reject are not sent, but the
+// tools are, since the agents cannot work without them.
+var conservativeCapabilities = Capabilities{Tools: true}
+
+// probeCache caches the capabilities probed, by base URL and model.
+var probeCache = struct {
+	sync.Mutex
+	entries map[probeKey]Capabilities
+}{entries: map[probeKey]Capabilities{}}
+
+type probeKey struct {
+	baseURL, model string
+}
+
+// NewProbedModel is like [NewCompatibleModel], with the features of the
+// server at baseURL detected by a probe instead of configured: the model is
+// listed, for its context length, and tiny completions check whether the
+// streams return their usage, and whether the tools and JSON mode are
+// supported. The probe overrides caps.IncludeUsage. The requests declaring
+// tools, or setting a response schema, which the server does not support,
+// are sent without them, which is reported with the strictness policy of
+// the context.
+//
+// The capabilities are cached for the base URL and the model, see
+// [ProbeConfig.TTL]. A failed probe does not fail the creation of the model:
+// it is logged, and the model assumes conservative capabilities. The
+// capabilities are returned by the Capabilities method of the model, see
+// [CapabilitiesReporter].
+func NewProbedModel(ctx context.Context, modelName, baseURL string, caps CompatibilityOptions, probe ProbeConfig, opts ...option.RequestOption) (model.LLM, error) {
+	llm, err := NewCompatibleModel(ctx, modelName, baseURL, caps, opts...)
+	if err != nil {
+		return nil, err
+	}
+	m := llm.(*openaiModel)
+	c := cachedCapabilities(ctx, m.client, probeKey{baseURL: baseURL, model: modelName}, probe.withDefaults())
+	m.cfg.DisableStreamUsage = !c.StreamUsage
+	return &probedModel{openaiModel: m, caps: c}, nil
+}
+
+// cachedCapabilities returns the capabilities of key, probed with client
+// unless they are cached.
+func cachedCapabilities(ctx context.Context, client *openai.Client, key probeKey, cfg ProbeConfig) Capabilities {
+	probeCache.Lock()
+	c, ok := probeCache.entries[key]
+	probeCache.Unlock()
+	if ok && cfg.Now().Sub(c.ProbedAt) < cfg.TTL {
+		return c
+	}
+
+	c, err := probeCapabilities(ctx, client, key.model, cfg)
+	if err != nil {
+		log.Printf("Probe of model %q at %s failed, assuming conservative capabilities: %v", key.model, key.baseURL, err)
+		return conservativeCapabilities
+	}
+	probeCache.Lock()
+	probeCache.entries[key] = c
+	probeCache.Unlock()
+	return c
+}
+
+// probeCapabilities probes the capabilities of modelName. It only fails if
+// the server cannot be reached, or rejects the simplest completion; the
+// features rejected are reported unsupported.
+func probeCapabilities(ctx context.Context, client *openai.Client, modelName string, cfg ProbeConfig) (Capabilities, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+	defer cancel()
+
+	var c Capabilities
+	// Some servers do not list their models: the context length is then
+	// unknown.
+	if page, err := client.Models.List(ctx, disableClientRetries); err == nil {
+		for _, m := range page.Data {
+			if m.ID == modelName {
+				c.ContextLength = contextLength(m.RawJSON())
+				break
+			}
+		}
+	}
+
+	if _, err := client.Chat.Completions.New(ctx, probeParams(modelName), disableClientRetries); err != nil {
+		return Capabilities{}, fmt.Errorf("probe completion failed: %w", classify(err))
+	}
+
+	params := probeParams(modelName)
+	params.Tools = []openai.ChatCompletionToolUnionParam{openai.ChatCompletionFunctionTool(shared.FunctionDefinitionParam{
+		Name:       "probe",
+		Parameters: shared.FunctionParameters{"type": "object", "properties": map[string]any{}},
+	})}
+	_, err := client.Chat.Completions.New(ctx, params, disableClientRetries)
+	c.Tools = err == nil
+
+	params = probeParams(modelName)
+	params.ResponseFormat = openai.ChatCompletionNewParamsResponseFormatUnion{OfJSONObject: &shared.ResponseFormatJSONObjectParam{}}
+	_, err = client.Chat.Completions.New(ctx, params, disableClientRetries)
+	c.JSONMode = err == nil
+
+	params = probeParams(modelName)
+	params.StreamOptions = openai.ChatCompletionStreamOptionsParam{IncludeUsage: param.NewOpt(true)}
+	stream := client.Chat.Completions.NewStreaming(ctx, params, disableClientRetries)
+	for stream.Next() {
+		if stream.Current().JSON.Usage.Valid() {
+			c.StreamUsage = true
+		}
+	}
+	if stream.Err() != nil {
+		// The servers rejecting stream_options fail the stream.
+		c.StreamUsage = false
+	}
+	stream.Close()
+
+	if ctx.Err() != nil {
+		return Capabilities{}, fmt.Errorf("probe timed out: %w", ctx.Err())
+	}
+	c.Probed = true
+	c.ProbedAt = cfg.Now()
+	return c, nil
+}
+
+// probeParams returns the parameters of a one-token completion of
+// modelName. The prompt asks for JSON, as required by JSON mode.
+func probeParams(modelName string) openai.ChatCompletionNewParams {
+	return openai.ChatCompletionNewParams{
+		Model:     shared.ChatModel(modelName),
+		Messages:  []openai.ChatCompletionMessageParamUnion{openai.UserMessage("Reply with an empty JSON object.")},
+		MaxTokens: param.NewOpt(int64(1)),
+	}
+}
+
+// contextLength returns the context length advertised in the raw JSON of a
+// model, under the field used by OpenRouter, vLLM, Groq or LM Studio, zero
+// if none.
+func contextLength(raw string) int {
+	var fields struct {
+		ContextLength    int `json:"context_length"`
+		MaxModelLen      int `json:"max_model_len"`
+		ContextWindow    int `json:"context_window"`
+		MaxContextLength int `json:"max_context_length"`
+	}
+	if err := json.Unmarshal([]byte(raw), &fields); err != nil {
+		return 0
+	}
+	for _, n := range []int{fields.ContextLength, fields.MaxModelLen, fields.ContextWindow, fields.MaxContextLength} {
+		if n > 0 {
+			return n
+		}
+	}
+	return 0
+}
+
+// probedModel is a model whose requests are adapted to the capabilities of
+// its server.
+type probedModel struct {
+	*openaiModel
+	caps Capabilities
+}
+
+// Capabilities implements [CapabilitiesReporter].
+func (p *probedModel) Capabilities() Capabilities {
+	return p.caps
+}
+
+func (p *probedModel) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	if err := p.adapt(ctx, req); err != nil {
+		return func(yield func(*model.LLMResponse, error) bool) {
+			yield(nil, err)
+		}
+	}
+	return p.openaiModel.GenerateContent(ctx, req, stream)
+}
+
+// adapt removes from the config of req the tools and the response schema
+// that the server does not support. The config is copied, not modified.
+func (p *probedModel) adapt(ctx context.Context, req *model.LLMRequest) error {
+	if req.Config == nil {
+		return nil
+	}
+	cfg := *req.Config
+	dropped := false
+	if !p.caps.Tools && len(cfg.Tools) > 0 {
+		if err := strictness.Report(ctx, strictness.Conversion, "openai", "dropped %d tools: not supported by the server", len(cfg.Tools)); err != nil {
+			return err
+		}
+		cfg.Tools, cfg.ToolConfig = nil, nil
+		dropped = true
+	}
+	if !p.caps.JSONMode && (cfg.ResponseSchema != nil || cfg.ResponseJsonSchema != nil || cfg.ResponseMIMEType == "application/json") {
+		if err := strictness.Report(ctx, strictness.Conversion, "openai", "dropped response schema: JSON mode not supported by the server"); err != nil {
+			return err
+		}
+		cfg.ResponseSchema, cfg.ResponseJsonSchema, cfg.ResponseMIMEType = nil, nil, ""
+		dropped = true
+	}
+	if dropped {
+		req.Config = &cfg
+	}
+	return nil
+}
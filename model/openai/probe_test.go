@@ -0,0 +1,209 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openai_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/openai/openai-go/v3/option"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/model/openai"
+)
+
+// serverFeatures are the features of a fake OpenAI-compatible server.
+type serverFeatures struct {
+	streamUsage bool
+	tools       bool
+	jsonMode    bool
+	// models is the body of GET /models, which fails if empty.
+	models string
+}
+
+// featureServer is a fake OpenAI-compatible server with features, which
+// rejects the requests using the others. It records the body of the last
+// completion request, and counts the requests.
+type featureServer struct {
+	*httptest.Server
+	body     map[string]any
+	requests atomic.Int32
+}
+
+func newFeatureServer(t *testing.T, features serverFeatures) *featureServer {
+	t.Helper()
+	s := &featureServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.requests.Add(1)
+		if strings.HasSuffix(r.URL.Path, "/models") {
+			if features.models == "" {
+				http.NotFound(w, r)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, features.models)
+			return
+		}
+		s.body = nil
+		_ = json.NewDecoder(r.Body).Decode(&s.body)
+		_, tools := s.body["tools"]
+		_, format := s.body["response_format"]
+		_, options := s.body["stream_options"]
+		if tools && !features.tools || format && !features.jsonMode {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error": {"message": "unsupported parameter", "type": "invalid_request_error"}}`)
+			return
+		}
+		if s.body["stream"] != true {
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"id": "c1", "object": "chat.completion", "model": "llama3", "choices": [{"index": 0, "finish_reason": "stop", "message": {"role": "assistant", "content": "ok"}}]}`)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, `data: {"id":"c1","object":"chat.completion.chunk","model":"llama3","choices":[{"index":0,"delta":{"role":"assistant","content":"ok"},"finish_reason":"stop"}]}`+"\n\n")
+		if options && features.streamUsage {
+			fmt.Fprint(w, `data: {"id":"c1","object":"chat.completion.chunk","model":"llama3","choices":[],"usage":{"prompt_tokens":3,"completion_tokens":1,"total_tokens":4}}`+"\n\n")
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestNewProbedModel(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		features serverFeatures
+		want     openai.Capabilities
+	}{
+		{
+			name: "vLLM",
+			features: serverFeatures{
+				streamUsage: true,
+				tools:       true,
+				jsonMode:    true,
+				models:      `{"object": "list", "data": [{"id": "other", "object": "model", "max_model_len": 4096}, {"id": "llama3", "object": "model", "max_model_len": 8192}]}`,
+			},
+			want: openai.Capabilities{StreamUsage: true, Tools: true, JSONMode: true, ContextLength: 8192, Probed: true},
+		},
+		{
+			name: "OpenRouter",
+			features: serverFeatures{
+				streamUsage: true,
+				tools:       true,
+				models:      `{"data": [{"id": "llama3", "context_length": 131072}]}`,
+			},
+			want: openai.Capabilities{StreamUsage: true, Tools: true, ContextLength: 131072, Probed: true},
+		},
+		{
+			name:     "Minimal",
+			features: serverFeatures{},
+			want:     openai.Capabilities{Probed: true},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := newFeatureServer(t, tc.features)
+			llm, err := openai.NewProbedModel(t.Context(), "llama3", srv.URL, openai.CompatibilityOptions{}, openai.ProbeConfig{}, option.WithAPIKey("key"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := llm.(openai.CapabilitiesReporter).Capabilities()
+			if diff := cmp.Diff(tc.want, got, cmpopts.IgnoreFields(openai.Capabilities{}, "ProbedAt")); diff != "" {
+				t.Errorf("Capabilities() mismatch (-want +got):\n%s", diff)
+			}
+
+			req := &model.LLMRequest{
+				Contents: []*genai.Content{genai.NewContentFromText("hi", genai.RoleUser)},
+				Config: &genai.GenerateContentConfig{
+					Tools:            []*genai.Tool{{FunctionDeclarations: []*genai.FunctionDeclaration{{Name: "get_time"}}}},
+					ResponseMIMEType: "application/json",
+				},
+			}
+			var usage *genai.GenerateContentResponseUsageMetadata
+			for resp, err := range llm.GenerateContent(t.Context(), req, true) {
+				if err != nil {
+					t.Fatalf("GenerateContent() failed, the request was not adapted to the server: %v", err)
+				}
+				if resp.UsageMetadata != nil {
+					usage = resp.UsageMetadata
+				}
+			}
+			if _, ok := srv.body["stream_options"]; ok != tc.want.StreamUsage {
+				t.Errorf("stream_options sent = %t, want %t", ok, tc.want.StreamUsage)
+			}
+			if (usage != nil) != tc.want.StreamUsage {
+				t.Errorf("UsageMetadata = %+v, want usage = %t", usage, tc.want.StreamUsage)
+			}
+			if _, ok := srv.body["tools"]; ok != tc.want.Tools {
+				t.Errorf("tools sent = %t, want %t", ok, tc.want.Tools)
+			}
+			if _, ok := srv.body["response_format"]; ok != tc.want.JSONMode {
+				t.Errorf("response_format sent = %t, want %t", ok, tc.want.JSONMode)
+			}
+		})
+	}
+}
+
+func TestNewProbedModel_Unreachable(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "down", http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(srv.Close)
+
+	llm, err := openai.NewProbedModel(t.Context(), "llama3", srv.URL, openai.CompatibilityOptions{}, openai.ProbeConfig{Timeout: time.Second}, option.WithAPIKey("key"))
+	if err != nil {
+		t.Fatalf("NewProbedModel() failed: %v, want the conservative capabilities", err)
+	}
+	want := openai.Capabilities{Tools: true}
+	if diff := cmp.Diff(want, llm.(openai.CapabilitiesReporter).Capabilities()); diff != "" {
+		t.Errorf("Capabilities() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestNewProbedModel_Cache(t *testing.T) {
+	srv := newFeatureServer(t, serverFeatures{tools: true})
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	cfg := openai.ProbeConfig{TTL: time.Minute, Now: func() time.Time { return now }}
+	probe := func() int32 {
+		t.Helper()
+		before := srv.requests.Load()
+		if _, err := openai.NewProbedModel(t.Context(), "llama3", srv.URL, openai.CompatibilityOptions{}, cfg, option.WithAPIKey("key")); err != nil {
+			t.Fatal(err)
+		}
+		return srv.requests.Load() - before
+	}
+
+	if n := probe(); n == 0 {
+		t.Fatal("first model: the server was not probed")
+	}
+	now = now.Add(30 * time.Second)
+	if n := probe(); n != 0 {
+		t.Errorf("second model within the TTL: %d requests, want the cached capabilities", n)
+	}
+	now = now.Add(time.Minute)
+	if n := probe(); n == 0 {
+		t.Error("third model after the TTL: the server was not probed again")
+	}
+}
@@ -0,0 +1,156 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openai
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	"github.com/openai/openai-go/v3"
+	"github.com/openai/openai-go/v3/shared"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/strictness"
+)
+
+// The largest thinking budgets mapped to the low and medium reasoning
+// efforts. The larger budgets map to the high effort.
+const (
+	lowEffortBudget    = 2048
+	mediumEffortBudget = 8192
+)
+
+// ModelFamily tells how the requests are adapted to a model.
+type ModelFamily string
+
+const (
+	// FamilyAuto detects the family of the model from its name.
+	FamilyAuto ModelFamily = ""
+	// FamilyChat is the family of the chat models, e.g. gpt-4o: the system
+	// instruction is sent as system messages.
+	FamilyChat ModelFamily = "chat"
+	// FamilyReasoning is the family of the reasoning models, e.g. o3-mini:
+	// the system instruction is sent as developer messages, the thinking
+	// config is mapped to the reasoning effort, and the temperature and the
+	// top_p, which the models reject, are not sent.
+	FamilyReasoning ModelFamily = "reasoning"
+)
+
+// isReasoning reports whether the named model is a reasoning model.
+func (f ModelFamily) isReasoning(name string) bool {
+	switch f {
+	case FamilyChat:
+		return false
+	case FamilyReasoning:
+		return true
+	default:
+		return isReasoningModel(name)
+	}
+}
+
+// applyReasoningEffort sets the reasoning_effort of params from the thinking
+// config of cfg, if the model is a reasoning model. A thinking budget of 0
+// maps to the lowest effort supported by the model, the budgets up to
+// lowEffortBudget to low, up to mediumEffortBudget to medium, and the larger
+// ones to high. A dynamic budget (-1) keeps the default effort of the model.
+// Without a budget, the thinking level maps to the effort of the same name.
+func applyReasoningEffort(params *openai.ChatCompletionNewParams, cfg *genai.ThinkingConfig, reasoning bool) {
+	name := string(params.Model)
+	if cfg == nil || !reasoning {
+		return
+	}
+	minimal := shared.ReasoningEffortLow
+	if supportsMinimalEffort(name) {
+		minimal = shared.ReasoningEffortMinimal
+	}
+	var effort shared.ReasoningEffort
+	switch budget := cfg.ThinkingBudget; {
+	case budget == nil:
+		switch cfg.ThinkingLevel {
+		case genai.ThinkingLevelMinimal:
+			effort = minimal
+		case genai.ThinkingLevelLow:
+			effort = shared.ReasoningEffortLow
+		case genai.ThinkingLevelMedium:
+			effort = shared.ReasoningEffortMedium
+		case genai.ThinkingLevelHigh:
+			effort = shared.ReasoningEffortHigh
+		}
+	case *budget < 0:
+	case *budget == 0:
+		effort = minimal
+	case *budget <= lowEffortBudget:
+		effort = shared.ReasoningEffortLow
+	case *budget <= mediumEffortBudget:
+		effort = shared.ReasoningEffortMedium
+	default:
+		effort = shared.ReasoningEffortHigh
+	}
+	params.ReasoningEffort = effort
+}
+
+// dropSamplingParams logs and reports the temperature and the top_p of cfg,
+// which the reasoning models reject, and which are not sent to them.
+func dropSamplingParams(ctx context.Context, modelName string, cfg *genai.GenerateContentConfig, reasoning bool) error {
+	if !reasoning {
+		return nil
+	}
+	for _, field := range []struct {
+		name string
+		set  bool
+	}{
+		{"temperature", cfg.Temperature != nil},
+		{"top_p", cfg.TopP != nil},
+	} {
+		if !field.set {
+			continue
+		}
+		log.Printf("Ignoring the %s not supported by the reasoning model %s", field.name, modelName)
+		if err := strictness.Report(ctx, strictness.Conversion, "openai", "ignored config field %s: not supported by the reasoning model %s", field.name, modelName); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isReasoningModel reports whether the named model accepts the
+// reasoning_effort parameter: the o-series models and the GPT-5 models,
+// except their chat variants.
+func isReasoningModel(name string) bool {
+	name = baseModelName(name)
+	for _, prefix := range []string{"o1", "o3", "o4"} {
+		if rest, ok := strings.CutPrefix(name, prefix); ok && (rest == "" || rest[0] == '-') {
+			return true
+		}
+	}
+	return supportsMinimalEffort(name)
+}
+
+// supportsMinimalEffort reports whether the named model accepts the minimal
+// reasoning effort.
+func supportsMinimalEffort(name string) bool {
+	name = baseModelName(name)
+	return strings.HasPrefix(name, "gpt-5") && !strings.Contains(name, "-chat")
+}
+
+// baseModelName returns the name of the model without the prefix of its
+// provider, e.g. "o3-mini" for "openai/o3-mini".
+func baseModelName(name string) string {
+	if i := strings.LastIndexByte(name, '/'); i >= 0 {
+		name = name[i+1:]
+	}
+	return strings.ToLower(name)
+}
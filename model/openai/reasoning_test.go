@@ -0,0 +1,225 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openai_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openai/openai-go/v3/option"
+	"github.com/openai/openai-go/v3/shared"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/model/openai"
+	"google.golang.org/adk/strictness"
+)
+
+func TestLLMRequest2ChatCompletionNewParams_ReasoningEffort(t *testing.T) {
+	tests := []struct {
+		name   string
+		model  string
+		config *genai.ThinkingConfig
+		want   shared.ReasoningEffort
+	}{
+		{name: "zero budget", model: "o3-mini", config: &genai.ThinkingConfig{ThinkingBudget: genai.Ptr[int32](0)}, want: "low"},
+		{name: "zero budget minimal", model: "gpt-5-mini", config: &genai.ThinkingConfig{ThinkingBudget: genai.Ptr[int32](0)}, want: "minimal"},
+		{name: "small budget", model: "o4-mini", config: &genai.ThinkingConfig{ThinkingBudget: genai.Ptr[int32](1024)}, want: "low"},
+		{name: "medium budget", model: "o1", config: &genai.ThinkingConfig{ThinkingBudget: genai.Ptr[int32](4096)}, want: "medium"},
+		{name: "large budget", model: "openai/o3", config: &genai.ThinkingConfig{ThinkingBudget: genai.Ptr[int32](24576)}, want: "high"},
+		{name: "dynamic budget", model: "o3", config: &genai.ThinkingConfig{ThinkingBudget: genai.Ptr[int32](-1)}},
+		{name: "level", model: "o3", config: &genai.ThinkingConfig{ThinkingLevel: genai.ThinkingLevelHigh}, want: "high"},
+		{name: "non-reasoning model", model: "gpt-4o", config: &genai.ThinkingConfig{ThinkingBudget: genai.Ptr[int32](1024)}},
+		{name: "chat variant", model: "gpt-5-chat-latest", config: &genai.ThinkingConfig{ThinkingBudget: genai.Ptr[int32](1024)}},
+		{name: "no thinking config", model: "o3"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			params, err := openai.LLMRequest2ChatCompletionNewParams(&model.LLMRequest{
+				Model:    tc.model,
+				Contents: []*genai.Content{genai.NewContentFromText("think", genai.RoleUser)},
+				Config:   &genai.GenerateContentConfig{ThinkingConfig: tc.config},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if params.ReasoningEffort != tc.want {
+				t.Errorf("reasoning effort = %q, want %q", params.ReasoningEffort, tc.want)
+			}
+		})
+	}
+}
+
+func TestModel_ReasoningEffort(t *testing.T) {
+	ignoredThinkingConfig := []strictness.Warning{
+		{Category: strictness.Conversion, Component: "openai", Message: "ignored config field thinking_config: not supported by OpenAI"},
+	}
+	var body map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body = nil
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id": "c1", "object": "chat.completion", "model": "o3", "choices": [{"index": 0, "finish_reason": "stop", "message": {"role": "assistant", "content": "ok"}}]}`)
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name         string
+		model        string
+		override     shared.ReasoningEffort
+		want         any
+		wantWarnings []strictness.Warning
+	}{
+		{name: "mapped", model: "o3", want: "medium"},
+		{name: "override", model: "o3", override: "high", want: "high"},
+		{name: "override of an unknown model", model: "my-reasoner", override: "low", want: "low", wantWarnings: ignoredThinkingConfig},
+		{name: "non-reasoning model", model: "gpt-4o", wantWarnings: ignoredThinkingConfig},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			llm, err := openai.NewModelWithConfig(t.Context(), tc.model, openai.Config{ReasoningEffort: tc.override},
+				option.WithBaseURL(srv.URL), option.WithAPIKey("key"), option.WithMaxRetries(0))
+			if err != nil {
+				t.Fatal(err)
+			}
+			collector := &strictness.Collector{}
+			ctx := strictness.NewContext(t.Context(), &strictness.Policy{Level: strictness.Warn})
+			ctx = strictness.WithCollector(ctx, collector)
+			req := &model.LLMRequest{
+				Model:    tc.model,
+				Contents: []*genai.Content{genai.NewContentFromText("think", genai.RoleUser)},
+				Config:   &genai.GenerateContentConfig{ThinkingConfig: &genai.ThinkingConfig{ThinkingBudget: genai.Ptr[int32](4096)}},
+			}
+			for _, err := range llm.GenerateContent(ctx, req, false) {
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got := body["reasoning_effort"]; got != tc.want {
+				t.Errorf("reasoning_effort = %v, want %v", got, tc.want)
+			}
+			if diff := cmp.Diff(tc.wantWarnings, collector.Warnings()); diff != "" {
+				t.Errorf("warnings mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestModel_Family(t *testing.T) {
+	var body map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body = nil
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id": "c1", "object": "chat.completion", "model": "o3-mini", "choices": [{"index": 0, "finish_reason": "stop", "message": {"role": "assistant", "content": "ok"}}]}`)
+	}))
+	defer srv.Close()
+
+	ignoredSampling := func(model string) []strictness.Warning {
+		return []strictness.Warning{
+			{Category: strictness.Conversion, Component: "openai", Message: "ignored config field temperature: not supported by the reasoning model " + model},
+			{Category: strictness.Conversion, Component: "openai", Message: "ignored config field top_p: not supported by the reasoning model " + model},
+		}
+	}
+	tests := []struct {
+		name            string
+		model           string
+		family          openai.ModelFamily
+		wantRole        string
+		wantTemperature any
+		wantTopP        any
+		wantWarnings    []strictness.Warning
+	}{
+		{name: "chat model", model: "gpt-4o", wantRole: "system", wantTemperature: 0.5, wantTopP: 0.75},
+		{name: "reasoning model", model: "o3-mini", wantRole: "developer", wantWarnings: ignoredSampling("o3-mini")},
+		{name: "reasoning override", model: "my-deployment", family: openai.FamilyReasoning, wantRole: "developer", wantWarnings: ignoredSampling("my-deployment")},
+		{name: "chat override", model: "o3-mini", family: openai.FamilyChat, wantRole: "system", wantTemperature: 0.5, wantTopP: 0.75},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			llm, err := openai.NewModelWithConfig(t.Context(), tc.model, openai.Config{Family: tc.family},
+				option.WithBaseURL(srv.URL), option.WithAPIKey("key"), option.WithMaxRetries(0))
+			if err != nil {
+				t.Fatal(err)
+			}
+			collector := &strictness.Collector{}
+			ctx := strictness.NewContext(t.Context(), &strictness.Policy{Level: strictness.Warn})
+			ctx = strictness.WithCollector(ctx, collector)
+			req := &model.LLMRequest{
+				Model:    tc.model,
+				Contents: []*genai.Content{genai.NewContentFromText("hi", genai.RoleUser)},
+				Config: &genai.GenerateContentConfig{
+					SystemInstruction: genai.NewContentFromText("be brief", genai.RoleUser),
+					Temperature:       genai.Ptr[float32](0.5),
+					TopP:              genai.Ptr[float32](0.75),
+				},
+			}
+			for _, err := range llm.GenerateContent(ctx, req, false) {
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			messages, _ := body["messages"].([]any)
+			if len(messages) == 0 {
+				t.Fatalf("messages = %v, want the system instruction first", body["messages"])
+			}
+			if got := messages[0].(map[string]any)["role"]; got != tc.wantRole {
+				t.Errorf("role of the system instruction = %v, want %v", got, tc.wantRole)
+			}
+			if got := body["temperature"]; got != tc.wantTemperature {
+				t.Errorf("temperature = %v, want %v", got, tc.wantTemperature)
+			}
+			if got := body["top_p"]; got != tc.wantTopP {
+				t.Errorf("top_p = %v, want %v", got, tc.wantTopP)
+			}
+			if diff := cmp.Diff(tc.wantWarnings, collector.Warnings()); diff != "" {
+				t.Errorf("warnings mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestModel_StreamReasoningTokens(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"id\":\"c1\",\"object\":\"chat.completion.chunk\",\"model\":\"o3\",\"choices\":[{\"index\":0,\"delta\":{\"content\":\"ok\"},\"finish_reason\":\"stop\"}]}\n\n")
+		fmt.Fprint(w, "data: {\"id\":\"c1\",\"object\":\"chat.completion.chunk\",\"model\":\"o3\",\"choices\":[],\"usage\":{\"prompt_tokens\":5,\"completion_tokens\":40,\"total_tokens\":45,\"completion_tokens_details\":{\"reasoning_tokens\":32}}}\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer srv.Close()
+	llm, err := openai.NewModel(t.Context(), "o3", option.WithBaseURL(srv.URL), option.WithAPIKey("key"), option.WithMaxRetries(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var usage *genai.GenerateContentResponseUsageMetadata
+	req := &model.LLMRequest{Model: "o3", Contents: []*genai.Content{genai.NewContentFromText("think", genai.RoleUser)}}
+	for resp, err := range llm.GenerateContent(t.Context(), req, true) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.UsageMetadata != nil {
+			usage = resp.UsageMetadata
+		}
+	}
+	want := &genai.GenerateContentResponseUsageMetadata{PromptTokenCount: 5, CandidatesTokenCount: 40, TotalTokenCount: 45, ThoughtsTokenCount: 32}
+	if diff := cmp.Diff(want, usage); diff != "" {
+		t.Errorf("usage mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,210 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+
+	"github.com/openai/openai-go/v3"
+	"github.com/openai/openai-go/v3/shared"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/internal/llmconv"
+)
+
+// defaultResponseFormatName is the name of the JSON schema response formats
+// whose schema has no usable title.
+const defaultResponseFormatName = "response"
+
+// responseFormatName matches the names allowed for the response formats.
+var responseFormatName = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// unsupportedStrictKeywords are the JSON schema keywords that the strict
+// mode of the structured outputs rejects.
+var unsupportedStrictKeywords = []string{
+	"allOf", "not", "if", "then", "else", "dependentRequired", "dependentSchemas",
+	"patternProperties", "unevaluatedProperties", "propertyNames", "minProperties", "maxProperties",
+	"unevaluatedItems", "contains", "minContains", "maxContains", "uniqueItems",
+	"minLength", "maxLength",
+}
+
+// applyResponseFormat sets the response_format of params from the response
+// MIME type and schema of cfg. application/json without a schema maps to a
+// json_object response format. With a schema, ResponseJsonSchema taking
+// precedence over ResponseSchema, it maps to a strict json_schema response
+// format; the schemas that the strict mode cannot express are an error. The
+// other MIME types are ignored, see [unsupportedConfig].
+func applyResponseFormat(ctx context.Context, params *openai.ChatCompletionNewParams, cfg *genai.GenerateContentConfig, strict bool) error {
+	switch cfg.ResponseMIMEType {
+	case "", "text/plain":
+		return nil
+	case "application/json":
+	default:
+		return unsupportedConfig(ctx, strict, fmt.Sprintf("response MIME type %q", cfg.ResponseMIMEType))
+	}
+
+	var schema map[string]any
+	switch {
+	case cfg.ResponseJsonSchema != nil:
+		b, err := json.Marshal(cfg.ResponseJsonSchema)
+		if err != nil {
+			return fmt.Errorf("invalid response JSON schema: %w", err)
+		}
+		if err := json.Unmarshal(b, &schema); err != nil {
+			return fmt.Errorf("invalid response JSON schema: %w", err)
+		}
+	case cfg.ResponseSchema != nil:
+		schema = llmconv.SchemaMap(cfg.ResponseSchema)
+	default:
+		params.ResponseFormat = openai.ChatCompletionNewParamsResponseFormatUnion{OfJSONObject: &shared.ResponseFormatJSONObjectParam{}}
+		return nil
+	}
+
+	if typ, _ := schema["type"].(string); typ != "object" {
+		return fmt.Errorf("invalid response schema: the root must be an object, got type %v", schema["type"])
+	}
+	if err := strictSchema("", schema); err != nil {
+		return fmt.Errorf("response schema cannot be used in strict mode: %w", err)
+	}
+	name, _ := schema["title"].(string)
+	if !responseFormatName.MatchString(name) {
+		name = defaultResponseFormatName
+	}
+	params.ResponseFormat = openai.ChatCompletionNewParamsResponseFormatUnion{OfJSONSchema: &shared.ResponseFormatJSONSchemaParam{
+		JSONSchema: shared.ResponseFormatJSONSchemaJSONSchemaParam{
+			Name:   name,
+			Schema: schema,
+			Strict: openai.Bool(true),
+		},
+	}}
+	return nil
+}
+
+// strictSchema checks that the JSON schema s, at path, can be used in strict
+// mode, and closes its objects by setting additionalProperties to false, as
+// required by the strict mode. s is modified in place.
+func strictSchema(path string, s map[string]any) error {
+	at := func() string {
+		if path == "" {
+			return "the root"
+		}
+		return path
+	}
+	for _, keyword := range unsupportedStrictKeywords {
+		if _, ok := s[keyword]; ok {
+			return fmt.Errorf("keyword %q at %s is not supported", keyword, at())
+		}
+	}
+	if isObjectSchema(s) {
+		switch additional := s["additionalProperties"].(type) {
+		case nil:
+		case bool:
+			if additional {
+				return fmt.Errorf("additional properties at %s are not supported", at())
+			}
+		default:
+			return fmt.Errorf("additional properties at %s are not supported", at())
+		}
+		s["additionalProperties"] = false
+		properties, _ := s["properties"].(map[string]any)
+		required := stringList(s["required"])
+		for _, name := range slices.Sorted(maps.Keys(properties)) {
+			if !slices.Contains(required, name) {
+				return fmt.Errorf("property %q at %s is optional: all the properties must be required", name, at())
+			}
+			sub, ok := properties[name].(map[string]any)
+			if !ok {
+				return fmt.Errorf("property %q at %s has an invalid schema", name, at())
+			}
+			if err := strictSchema(joinSchemaPath(path, name), sub); err != nil {
+				return err
+			}
+		}
+	}
+	if items, ok := s["items"].(map[string]any); ok {
+		if err := strictSchema(path+"[]", items); err != nil {
+			return err
+		}
+	}
+	if anyOf, ok := s["anyOf"].([]any); ok {
+		for i, v := range anyOf {
+			sub, ok := v.(map[string]any)
+			if !ok {
+				return fmt.Errorf("anyOf at %s has an invalid schema", at())
+			}
+			if err := strictSchema(fmt.Sprintf("%s(anyOf %d)", path, i), sub); err != nil {
+				return err
+			}
+		}
+	}
+	for _, keyword := range []string{"$defs", "definitions"} {
+		defs, _ := s[keyword].(map[string]any)
+		for _, name := range slices.Sorted(maps.Keys(defs)) {
+			sub, ok := defs[name].(map[string]any)
+			if !ok {
+				return fmt.Errorf("definition %q at %s has an invalid schema", name, at())
+			}
+			if err := strictSchema(keyword+"."+name, sub); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// isObjectSchema reports whether the JSON schema s describes objects.
+func isObjectSchema(s map[string]any) bool {
+	if _, ok := s["properties"]; ok {
+		return true
+	}
+	switch typ := s["type"].(type) {
+	case string:
+		return typ == "object"
+	case []any:
+		return slices.Contains(typ, any("object"))
+	case []string:
+		return slices.Contains(typ, "object")
+	}
+	return false
+}
+
+func joinSchemaPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// stringList returns v, a []string or a []any of strings, as a []string.
+func stringList(v any) []string {
+	switch v := v.(type) {
+	case []string:
+		return v
+	case []any:
+		var list []string
+		for _, s := range v {
+			if s, ok := s.(string); ok {
+				list = append(list, s)
+			}
+		}
+		return list
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openai_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/model/openai"
+)
+
+func TestLLMRequest2ChatCompletionNewParams_ResponseFormat(t *testing.T) {
+	weather := &genai.Schema{
+		Type:  genai.TypeObject,
+		Title: "Weather",
+		Properties: map[string]*genai.Schema{
+			"city": {Type: genai.TypeString, Description: "The city."},
+			"unit": {Type: genai.TypeString, Enum: []string{"celsius", "fahrenheit"}},
+			"days": {Type: genai.TypeArray, Items: &genai.Schema{
+				Type: genai.TypeObject,
+				Properties: map[string]*genai.Schema{
+					"date": {Type: genai.TypeString, Format: "date"},
+					"high": {Type: genai.TypeNumber, Nullable: genai.Ptr(true)},
+				},
+				Required: []string{"date", "high"},
+			}},
+		},
+		Required: []string{"city", "unit", "days"},
+	}
+	jsonSchema := map[string]any{
+		"type":       "object",
+		"title":      "not a valid name",
+		"properties": map[string]any{"answer": map[string]any{"type": "string"}},
+		"required":   []any{"answer"},
+	}
+	tests := []struct {
+		name    string
+		config  *genai.GenerateContentConfig
+		want    any
+		wantErr bool
+	}{
+		{name: "Text", config: &genai.GenerateContentConfig{ResponseMIMEType: "text/plain"}},
+		{name: "SchemaWithoutJSON", config: &genai.GenerateContentConfig{ResponseSchema: weather}},
+		{
+			name:   "JSONObject",
+			config: &genai.GenerateContentConfig{ResponseMIMEType: "application/json"},
+			want:   map[string]any{"type": "json_object"},
+		},
+		{
+			name:   "Schema",
+			config: &genai.GenerateContentConfig{ResponseMIMEType: "application/json", ResponseSchema: weather},
+			want: map[string]any{"type": "json_schema", "json_schema": map[string]any{
+				"name":   "Weather",
+				"strict": true,
+				"schema": map[string]any{
+					"type":  "object",
+					"title": "Weather",
+					"properties": map[string]any{
+						"city": map[string]any{"type": "string", "description": "The city."},
+						"unit": map[string]any{"type": "string", "enum": []any{"celsius", "fahrenheit"}},
+						"days": map[string]any{"type": "array", "items": map[string]any{
+							"type": "object",
+							"properties": map[string]any{
+								"date": map[string]any{"type": "string", "format": "date"},
+								"high": map[string]any{"type": []any{"number", "null"}},
+							},
+							"required":             []any{"date", "high"},
+							"additionalProperties": false,
+						}},
+					},
+					"required":             []any{"city", "unit", "days"},
+					"additionalProperties": false,
+				},
+			}},
+		},
+		{
+			name: "JSONSchemaTakesPrecedence",
+			config: &genai.GenerateContentConfig{
+				ResponseMIMEType:   "application/json",
+				ResponseSchema:     weather,
+				ResponseJsonSchema: jsonSchema,
+			},
+			want: map[string]any{"type": "json_schema", "json_schema": map[string]any{
+				"name":   "response",
+				"strict": true,
+				"schema": map[string]any{
+					"type":                 "object",
+					"title":                "not a valid name",
+					"properties":           map[string]any{"answer": map[string]any{"type": "string"}},
+					"required":             []any{"answer"},
+					"additionalProperties": false,
+				},
+			}},
+		},
+		{name: "UnsupportedMIMEType", config: &genai.GenerateContentConfig{ResponseMIMEType: "text/x.enum"}},
+		{
+			name:    "RootNotObject",
+			config:  &genai.GenerateContentConfig{ResponseMIMEType: "application/json", ResponseSchema: &genai.Schema{Type: genai.TypeString}},
+			wantErr: true,
+		},
+		{
+			name: "OptionalProperty",
+			config: &genai.GenerateContentConfig{ResponseMIMEType: "application/json", ResponseSchema: &genai.Schema{
+				Type:       genai.TypeObject,
+				Properties: map[string]*genai.Schema{"a": {Type: genai.TypeString}, "b": {Type: genai.TypeString}},
+				Required:   []string{"a"},
+			}},
+			wantErr: true,
+		},
+		{
+			name: "UnsupportedKeyword",
+			config: &genai.GenerateContentConfig{ResponseMIMEType: "application/json", ResponseSchema: &genai.Schema{
+				Type:       genai.TypeObject,
+				Properties: map[string]*genai.Schema{"a": {Type: genai.TypeString, MinLength: genai.Ptr[int64](3)}},
+				Required:   []string{"a"},
+			}},
+			wantErr: true,
+		},
+		{
+			name: "AdditionalProperties",
+			config: &genai.GenerateContentConfig{ResponseMIMEType: "application/json", ResponseJsonSchema: map[string]any{
+				"type":                 "object",
+				"additionalProperties": map[string]any{"type": "string"},
+			}},
+			wantErr: true,
+		},
+		{
+			name: "NestedAllOf",
+			config: &genai.GenerateContentConfig{ResponseMIMEType: "application/json", ResponseJsonSchema: map[string]any{
+				"type":       "object",
+				"properties": map[string]any{"a": map[string]any{"allOf": []any{map[string]any{"type": "string"}}}},
+				"required":   []any{"a"},
+			}},
+			wantErr: true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := &model.LLMRequest{
+				Model:    "gpt-4o",
+				Contents: []*genai.Content{genai.NewContentFromText("hi", genai.RoleUser)},
+				Config:   tc.config,
+			}
+			params, err := openai.LLMRequest2ChatCompletionNewParams(req)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("LLMRequest2ChatCompletionNewParams() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			b, err := json.Marshal(params)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got struct {
+				ResponseFormat any `json:"response_format"`
+			}
+			if err := json.Unmarshal(b, &got); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.want, got.ResponseFormat); diff != "" {
+				t.Errorf("response_format mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
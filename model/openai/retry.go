@@ -0,0 +1,165 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openai
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/openai/openai-go/v3"
+	"github.com/openai/openai-go/v3/option"
+
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/runtimedeps"
+)
+
+func init() {
+	model.RegisterRetryClassifier(classifyRetry)
+}
+
+// classifyRetry is the [model.RetryClassifier] of the errors of the OpenAI
+// client, so that model.WithRetry retries the same errors as [RetryConfig].
+func classifyRetry(err error) (retryable, ok bool) {
+	var apiErr *openai.Error
+	if errors.As(err, &apiErr) || errors.Is(err, syscall.ECONNRESET) || errors.Is(err, ErrStreamIdle) {
+		return isTransient(err), true
+	}
+	return false, false
+}
+
+// RetryConfig configures the retries of the calls failing with a transient
+// error: a rate limit (429, unless the quota is exhausted), a server error
+// (500, 502, 503 or 529), a connection reset or an idle stream. The delay
+// before a retry is the Retry-After of the response if there is one, or
+// else the backoff, randomized by the jitter. A streamed call is only
+// retried if none of its chunks was yielded.
+//
+// By default, the calls are retried by the OpenAI client, as configured by
+// option.WithMaxRetries. A policy with MaxAttempts set replaces the retries
+// of the client, which are then disabled for the calls of the model. A
+// MaxAttempts of 1 disables all the retries, e.g. for a model wrapped by
+// model.WithRetry, which retries the same errors, so that the requests are
+// not multiplied.
+type RetryConfig struct {
+	// MaxAttempts bounds the number of requests of a call, including the
+	// first one. Zero leaves the retries to the client.
+	MaxAttempts int
+	// Backoff is the delay before the first retry; it doubles with every
+	// retry. Defaults to 500ms.
+	Backoff time.Duration
+	// MaxBackoff caps the delay between the retries, including the delays
+	// asked by Retry-After. Defaults to 30 seconds.
+	MaxBackoff time.Duration
+	// Jitter is the fraction of the backoff randomly added or removed.
+	// Defaults to 0.2; negative disables the jitter.
+	Jitter float64
+}
+
+func (c RetryConfig) withDefaults() RetryConfig {
+	if c.MaxAttempts <= 0 {
+		c.MaxAttempts = 1
+	}
+	if c.Backoff <= 0 {
+		c.Backoff = 500 * time.Millisecond
+	}
+	if c.MaxBackoff <= 0 {
+		c.MaxBackoff = 30 * time.Second
+	}
+	if c.Jitter == 0 {
+		c.Jitter = 0.2
+	}
+	return c
+}
+
+// WithRetry configures the retries of the model created by [NewModel],
+// [NewModelWithConfig] or [NewAzureModel], overriding [Config.Retry].
+func WithRetry(cfg RetryConfig) option.RequestOption {
+	return configOption(func(c *Config) { c.Retry = cfg })
+}
+
+// disableClientRetries disables the retries of the client for a request,
+// e.g. of a probe or of a call retried by the model.
+var disableClientRetries = option.WithMaxRetries(0)
+
+// requestOptions returns the options of the requests of the calls: the
+// retries of the client are disabled if the policy replaces them.
+func (c RetryConfig) requestOptions() []option.RequestOption {
+	if c.MaxAttempts > 0 {
+		return []option.RequestOption{disableClientRetries}
+	}
+	return nil
+}
+
+// wait waits before retrying the attempt-th request of a call, which
+// failed with err. It reports false, without waiting, if the call must not
+// be retried.
+func (c RetryConfig) wait(ctx context.Context, err error, attempt int) bool {
+	if attempt >= c.MaxAttempts || !isTransient(err) || ctx.Err() != nil {
+		return false
+	}
+	deps := runtimedeps.FromContext(ctx)
+	delay, ok := retryAfter(err, deps.Now())
+	if !ok {
+		delay = c.Backoff << (attempt - 1)
+		if delay <= 0 || delay > c.MaxBackoff {
+			delay = c.MaxBackoff
+		}
+		if c.Jitter > 0 {
+			delay = deps.Jitter(delay, c.Jitter)
+		}
+	}
+	return deps.Sleep(ctx, min(delay, c.MaxBackoff)) == nil
+}
+
+// isTransient reports whether err is a rate limit, a server error, a
+// connection reset or an idle stream.
+func isTransient(err error) bool {
+	var apiErr *openai.Error
+	if errors.As(err, &apiErr) {
+		switch apiErr.StatusCode {
+		case http.StatusTooManyRequests:
+			return apiErr.Code != "insufficient_quota" && apiErr.Type != "insufficient_quota"
+		case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, 529:
+			return true
+		}
+		return false
+	}
+	return errors.Is(err, syscall.ECONNRESET) || errors.Is(err, ErrStreamIdle)
+}
+
+// retryAfter returns the delay asked by the retry-after-ms or Retry-After
+// header of the response of err, if any.
+func retryAfter(err error, now time.Time) (time.Duration, bool) {
+	var apiErr *openai.Error
+	if !errors.As(err, &apiErr) || apiErr.Response == nil {
+		return 0, false
+	}
+	header := apiErr.Response.Header
+	if ms, err := strconv.ParseFloat(header.Get("retry-after-ms"), 64); err == nil && ms >= 0 {
+		return time.Duration(ms * float64(time.Millisecond)), true
+	}
+	value := header.Get("Retry-After")
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds >= 0 {
+		return time.Duration(seconds * float64(time.Second)), true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(at.Sub(now), 0), true
+	}
+	return 0, false
+}
@@ -0,0 +1,316 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openai_test
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openai/openai-go/v3/option"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/model/openai"
+	"google.golang.org/adk/runtimedeps"
+)
+
+const retryChunk = `data: {"id": "c1", "object": "chat.completion.chunk", "model": "gpt-4o", "choices": [{"index": 0, "delta": {"role": "assistant", "content": "hi"}}]}` + "\n\n"
+
+// resetConnection resets the connection of w after writing prefix.
+func resetConnection(t *testing.T, w http.ResponseWriter, prefix string) {
+	t.Helper()
+	conn, _, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Fprint(conn, prefix)
+	_ = conn.(*net.TCPConn).SetLinger(0)
+	_ = conn.Close()
+}
+
+func TestModel_Retry(t *testing.T) {
+	tests := []struct {
+		name   string
+		cfg    openai.RetryConfig
+		stream bool
+		// fail fails the n-th request, and reports whether it did.
+		fail         func(t *testing.T, w http.ResponseWriter, n int) bool
+		wantRequests int
+		wantWait     time.Duration
+		wantCode     model.ErrorCode
+	}{
+		{
+			name: "RetryAfter",
+			cfg:  openai.RetryConfig{MaxAttempts: 3},
+			fail: func(t *testing.T, w http.ResponseWriter, n int) bool {
+				if n > 2 {
+					return false
+				}
+				w.Header().Set("Retry-After", "2")
+				w.WriteHeader(http.StatusTooManyRequests)
+				return true
+			},
+			wantRequests: 3,
+			wantWait:     4 * time.Second,
+		},
+		{
+			name: "RetryAfterCapped",
+			cfg:  openai.RetryConfig{MaxAttempts: 3, MaxBackoff: time.Second},
+			fail: func(t *testing.T, w http.ResponseWriter, n int) bool {
+				if n > 1 {
+					return false
+				}
+				w.Header().Set("Retry-After", "60")
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return true
+			},
+			wantRequests: 2,
+			wantWait:     time.Second,
+		},
+		{
+			name: "Backoff",
+			cfg:  openai.RetryConfig{MaxAttempts: 4, Backoff: time.Second, Jitter: -1},
+			fail: func(t *testing.T, w http.ResponseWriter, n int) bool {
+				if n > 3 {
+					return false
+				}
+				w.WriteHeader(529)
+				return true
+			},
+			wantRequests: 4,
+			wantWait:     7 * time.Second,
+		},
+		{
+			name: "Exhausted",
+			cfg:  openai.RetryConfig{MaxAttempts: 3, Backoff: time.Second, Jitter: -1},
+			fail: func(t *testing.T, w http.ResponseWriter, n int) bool {
+				w.WriteHeader(http.StatusBadGateway)
+				return true
+			},
+			wantRequests: 3,
+			wantWait:     3 * time.Second,
+			wantCode:     model.ErrorCodeUnavailable,
+		},
+		{
+			name: "NotTransient",
+			cfg:  openai.RetryConfig{MaxAttempts: 3},
+			fail: func(t *testing.T, w http.ResponseWriter, n int) bool {
+				w.WriteHeader(http.StatusBadRequest)
+				return true
+			},
+			wantRequests: 1,
+			wantCode:     model.ErrorCodeInvalidRequest,
+		},
+		{
+			name: "QuotaExceeded",
+			cfg:  openai.RetryConfig{MaxAttempts: 3},
+			fail: func(t *testing.T, w http.ResponseWriter, n int) bool {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusTooManyRequests)
+				fmt.Fprint(w, `{"error": {"message": "quota", "type": "insufficient_quota", "code": "insufficient_quota"}}`)
+				return true
+			},
+			wantRequests: 1,
+			wantCode:     model.ErrorCodeQuotaExceeded,
+		},
+		{
+			name:   "StreamBeforeChunks",
+			cfg:    openai.RetryConfig{MaxAttempts: 3, Backoff: time.Second, Jitter: -1},
+			stream: true,
+			fail: func(t *testing.T, w http.ResponseWriter, n int) bool {
+				if n > 1 {
+					return false
+				}
+				resetConnection(t, w, "")
+				return true
+			},
+			wantRequests: 2,
+			wantWait:     time.Second,
+		},
+		{
+			name:   "StreamAfterChunks",
+			cfg:    openai.RetryConfig{MaxAttempts: 3},
+			stream: true,
+			fail: func(t *testing.T, w http.ResponseWriter, n int) bool {
+				resetConnection(t, w, "HTTP/1.1 200 OK\r\nContent-Type: text/event-stream\r\n\r\n"+retryChunk)
+				return true
+			},
+			wantRequests: 1,
+			wantCode:     model.ErrorCodeUnavailable,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			requests := 0
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				if tc.fail(t, w, requests) {
+					return
+				}
+				if tc.stream {
+					w.Header().Set("Content-Type", "text/event-stream")
+					fmt.Fprint(w, retryChunk+"data: [DONE]\n\n")
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				fmt.Fprint(w, `{"id": "c1", "object": "chat.completion", "model": "gpt-4o", "choices": [{"index": 0, "finish_reason": "stop", "message": {"role": "assistant", "content": "hi"}}]}`)
+			}))
+			defer srv.Close()
+			llm, err := openai.NewModel(t.Context(), "gpt-4o", option.WithBaseURL(srv.URL), option.WithAPIKey("key"), openai.WithRetry(tc.cfg))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+			clock := runtimedeps.NewFrozenClock(start)
+			ctx := runtimedeps.NewContext(t.Context(), runtimedeps.Seeded(1, clock))
+			req := &model.LLMRequest{Model: "gpt-4o", Contents: []*genai.Content{genai.NewContentFromText("hello", genai.RoleUser)}}
+			var texts []string
+			var gotErr error
+			for resp, err := range llm.GenerateContent(ctx, req, tc.stream) {
+				if err != nil {
+					gotErr = err
+					break
+				}
+				if resp.Partial && resp.Content != nil && len(resp.Content.Parts) > 0 {
+					texts = append(texts, resp.Content.Parts[0].Text)
+				}
+			}
+			if tc.wantCode != "" {
+				if got := model.CodeOf(gotErr); got != tc.wantCode {
+					t.Errorf("GenerateContent() error = %v, want code %s", gotErr, tc.wantCode)
+				}
+			} else if gotErr != nil {
+				t.Fatalf("GenerateContent() failed: %v", gotErr)
+			}
+			if requests != tc.wantRequests {
+				t.Errorf("%d requests sent, want %d", requests, tc.wantRequests)
+			}
+			if got := clock.Now().Sub(start); got != tc.wantWait {
+				t.Errorf("waited %v, want %v", got, tc.wantWait)
+			}
+			if tc.stream {
+				// The chunk yielded before a reset is not yielded again.
+				if diff := cmp.Diff([]string{"hi"}, texts); diff != "" {
+					t.Errorf("streamed texts mismatch (-want +got):\n%s", diff)
+				}
+			}
+		})
+	}
+}
+
+func TestModel_RetryJitter(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+	llm, err := openai.NewModel(t.Context(), "gpt-4o", option.WithBaseURL(srv.URL), option.WithAPIKey("key"), openai.WithRetry(openai.RetryConfig{MaxAttempts: 3}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := runtimedeps.NewFrozenClock(start)
+	ctx := runtimedeps.NewContext(t.Context(), runtimedeps.Seeded(1, clock))
+	req := &model.LLMRequest{Model: "gpt-4o", Contents: []*genai.Content{genai.NewContentFromText("hello", genai.RoleUser)}}
+	for _, err := range llm.GenerateContent(ctx, req, false) {
+		if err == nil {
+			t.Fatal("GenerateContent() succeeded, want an error")
+		}
+	}
+	// 3 attempts, waiting 500ms then 1s by default, each ±20%.
+	if requests != 3 {
+		t.Errorf("%d requests sent, want 3", requests)
+	}
+	if got := clock.Now().Sub(start); got < 1200*time.Millisecond || got > 1800*time.Millisecond {
+		t.Errorf("waited %v, want 1.5s ±20%%", got)
+	}
+}
+
+func TestModel_WithRetry(t *testing.T) {
+	for _, tc := range []struct {
+		name         string
+		status       int
+		wantRequests int
+	}{
+		{"Transient", http.StatusServiceUnavailable, 2},
+		{"NotTransient", http.StatusBadRequest, 1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			requests := 0
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				if requests == 1 {
+					w.WriteHeader(tc.status)
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				fmt.Fprint(w, `{"id": "c1", "object": "chat.completion", "model": "gpt-4o", "choices": [{"index": 0, "finish_reason": "stop", "message": {"role": "assistant", "content": "hi"}}]}`)
+			}))
+			defer srv.Close()
+			// The retries of the model are disabled: the calls are only
+			// retried by the wrapper.
+			llm, err := openai.NewModel(t.Context(), "gpt-4o", option.WithBaseURL(srv.URL), option.WithAPIKey("key"), openai.WithRetry(openai.RetryConfig{MaxAttempts: 1}))
+			if err != nil {
+				t.Fatal(err)
+			}
+			wrapped := model.WithRetry(llm, model.RetryPolicy{MaxAttempts: 3})
+
+			clock := runtimedeps.NewFrozenClock(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+			ctx := runtimedeps.NewContext(t.Context(), runtimedeps.Seeded(1, clock))
+			req := &model.LLMRequest{Model: "gpt-4o", Contents: []*genai.Content{genai.NewContentFromText("hello", genai.RoleUser)}}
+			for range wrapped.GenerateContent(ctx, req, false) {
+			}
+			if requests != tc.wantRequests {
+				t.Errorf("%d requests sent, want %d", requests, tc.wantRequests)
+			}
+		})
+	}
+}
+
+func TestModel_ClientRetries(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.Header().Set("Retry-After-Ms", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id": "c1", "object": "chat.completion", "model": "gpt-4o", "choices": [{"index": 0, "finish_reason": "stop", "message": {"role": "assistant", "content": "hi"}}]}`)
+	}))
+	defer srv.Close()
+	// Without a policy, the calls are retried by the client.
+	llm, err := openai.NewModel(t.Context(), "gpt-4o", option.WithBaseURL(srv.URL), option.WithAPIKey("key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := &model.LLMRequest{Model: "gpt-4o", Contents: []*genai.Content{genai.NewContentFromText("hello", genai.RoleUser)}}
+	for _, err := range llm.GenerateContent(t.Context(), req, false) {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if requests != 2 {
+		t.Errorf("%d requests sent, want 2", requests)
+	}
+}
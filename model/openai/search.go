@@ -0,0 +1,123 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openai
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/openai/openai-go/v3"
+	"google.golang.org/genai"
+)
+
+// supportsWebSearch reports whether the named model searches the web, like
+// gpt-4o-search-preview or gpt-5-search-api.
+func supportsWebSearch(name string) bool {
+	name = baseModelName(name)
+	return strings.Contains(name, "-search-preview") || strings.Contains(name, "-search-api")
+}
+
+// applyWebSearch maps the Google Search tools of req to the web search of
+// params. The models which do not search the web are an error.
+func applyWebSearch(params *openai.ChatCompletionNewParams, tools []*genai.Tool) error {
+	for _, t := range tools {
+		if t == nil || (t.GoogleSearch == nil && t.GoogleSearchRetrieval == nil) {
+			continue
+		}
+		if !supportsWebSearch(string(params.Model)) {
+			return fmt.Errorf("google search is not supported by model %q: only the search models, e.g. gpt-4o-search-preview, search the web", params.Model)
+		}
+		// An empty web_search_options would not be sent: the default
+		// context size is set instead.
+		params.WebSearchOptions = openai.ChatCompletionNewParamsWebSearchOptions{SearchContextSize: "medium"}
+		return nil
+	}
+	return nil
+}
+
+// groundingMetadata converts the URL citations of the annotations of a
+// message with the given text to grounding metadata: a chunk per cited web
+// page, and the segments of the text citing them. It returns nil if there
+// is no citation.
+func groundingMetadata(text string, annotations []openai.ChatCompletionMessageAnnotation) *genai.GroundingMetadata {
+	var metadata *genai.GroundingMetadata
+	chunks := make(map[string]int32)
+	for _, a := range annotations {
+		if a.Type != "url_citation" || a.URLCitation.URL == "" {
+			continue
+		}
+		if metadata == nil {
+			metadata = &genai.GroundingMetadata{}
+		}
+		citation := a.URLCitation
+		i, ok := chunks[citation.URL]
+		if !ok {
+			i = int32(len(metadata.GroundingChunks))
+			chunks[citation.URL] = i
+			metadata.GroundingChunks = append(metadata.GroundingChunks, &genai.GroundingChunk{
+				Web: &genai.GroundingChunkWeb{URI: citation.URL, Title: citation.Title},
+			})
+		}
+		metadata.GroundingSupports = append(metadata.GroundingSupports, &genai.GroundingSupport{
+			Segment:               textSegment(text, citation.StartIndex, citation.EndIndex),
+			GroundingChunkIndices: []int32{i},
+		})
+	}
+	return metadata
+}
+
+// citationMetadata converts the URL citations of annotations to citations,
+// with the byte indexes of text they cover. It returns nil if there is
+// none.
+func citationMetadata(text string, annotations []openai.ChatCompletionMessageAnnotation) *genai.CitationMetadata {
+	var metadata *genai.CitationMetadata
+	for _, a := range annotations {
+		if a.Type != "url_citation" || a.URLCitation.URL == "" {
+			continue
+		}
+		if metadata == nil {
+			metadata = &genai.CitationMetadata{}
+		}
+		segment := textSegment(text, a.URLCitation.StartIndex, a.URLCitation.EndIndex)
+		metadata.Citations = append(metadata.Citations, &genai.Citation{
+			StartIndex: segment.StartIndex,
+			EndIndex:   segment.EndIndex,
+			URI:        a.URLCitation.URL,
+			Title:      a.URLCitation.Title,
+		})
+	}
+	return metadata
+}
+
+// textSegment returns the segment of text between the character indexes
+// start and end, with byte indexes like the segments of Gemini. The indexes
+// are clamped to text.
+func textSegment(text string, start, end int64) *genai.Segment {
+	runes := []rune(text)
+	clamp := func(i int64) int {
+		return int(max(0, min(i, int64(len(runes)))))
+	}
+	from, to := clamp(start), clamp(end)
+	if to < from {
+		to = from
+	}
+	byteStart := len(string(runes[:from]))
+	byteEnd := byteStart + len(string(runes[from:to]))
+	return &genai.Segment{
+		StartIndex: int32(byteStart),
+		EndIndex:   int32(byteEnd),
+		Text:       string(runes[from:to]),
+	}
+}
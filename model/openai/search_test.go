@@ -0,0 +1,114 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openai_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openai/openai-go/v3/option"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/model/openai"
+)
+
+func TestModel_GoogleSearch(t *testing.T) {
+	var body map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id": "c1", "object": "chat.completion", "model": "gpt-4o-search-preview", "choices": [{"index": 0, "finish_reason": "stop", "message": {
+			"role": "assistant", "content": "Zürich: 12°C. Paris: 15°C.",
+			"annotations": [
+				{"type": "url_citation", "url_citation": {"start_index": 0, "end_index": 13, "url": "https://weather.example/zurich", "title": "Zürich"}},
+				{"type": "url_citation", "url_citation": {"start_index": 14, "end_index": 26, "url": "https://weather.example/paris", "title": "Paris"}},
+				{"type": "url_citation", "url_citation": {"start_index": 0, "end_index": 6, "url": "https://weather.example/zurich", "title": "Zürich"}}
+			]}}]}`)
+	}))
+	defer srv.Close()
+
+	req := func(name string) *model.LLMRequest {
+		return &model.LLMRequest{
+			Model:    name,
+			Contents: []*genai.Content{genai.NewContentFromText("weather?", genai.RoleUser)},
+			Config:   &genai.GenerateContentConfig{Tools: []*genai.Tool{{GoogleSearch: &genai.GoogleSearch{}}}},
+		}
+	}
+
+	t.Run("search model", func(t *testing.T) {
+		llm, err := openai.NewModel(t.Context(), "gpt-4o-search-preview", option.WithBaseURL(srv.URL), option.WithAPIKey("key"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got *model.LLMResponse
+		for resp, err := range llm.GenerateContent(t.Context(), req("gpt-4o-search-preview"), false) {
+			if err != nil {
+				t.Fatal(err)
+			}
+			got = resp
+		}
+		if diff := cmp.Diff(map[string]any{"search_context_size": "medium"}, body["web_search_options"]); diff != "" {
+			t.Errorf("web_search_options mismatch (-want +got):\n%s", diff)
+		}
+		if _, ok := body["tools"]; ok {
+			t.Errorf("tools = %v, want none", body["tools"])
+		}
+		zurich := &genai.Segment{StartIndex: 0, EndIndex: 15, Text: "Zürich: 12°C."}
+		paris := &genai.Segment{StartIndex: 16, EndIndex: 29, Text: "Paris: 15°C."}
+		wantGrounding := &genai.GroundingMetadata{
+			GroundingChunks: []*genai.GroundingChunk{
+				{Web: &genai.GroundingChunkWeb{URI: "https://weather.example/zurich", Title: "Zürich"}},
+				{Web: &genai.GroundingChunkWeb{URI: "https://weather.example/paris", Title: "Paris"}},
+			},
+			GroundingSupports: []*genai.GroundingSupport{
+				{Segment: zurich, GroundingChunkIndices: []int32{0}},
+				{Segment: paris, GroundingChunkIndices: []int32{1}},
+				{Segment: &genai.Segment{StartIndex: 0, EndIndex: 7, Text: "Zürich"}, GroundingChunkIndices: []int32{0}},
+			},
+		}
+		if diff := cmp.Diff(wantGrounding, got.GroundingMetadata); diff != "" {
+			t.Errorf("grounding metadata mismatch (-want +got):\n%s", diff)
+		}
+		wantCitations := &genai.CitationMetadata{Citations: []*genai.Citation{
+			{StartIndex: 0, EndIndex: 15, URI: "https://weather.example/zurich", Title: "Zürich"},
+			{StartIndex: 16, EndIndex: 29, URI: "https://weather.example/paris", Title: "Paris"},
+			{StartIndex: 0, EndIndex: 7, URI: "https://weather.example/zurich", Title: "Zürich"},
+		}}
+		if diff := cmp.Diff(wantCitations, got.CitationMetadata); diff != "" {
+			t.Errorf("citation metadata mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("unsupported model", func(t *testing.T) {
+		body = nil
+		llm, err := openai.NewModel(t.Context(), "gpt-4o", option.WithBaseURL(srv.URL), option.WithAPIKey("key"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, err := range llm.GenerateContent(t.Context(), req("gpt-4o"), false) {
+			if err == nil {
+				t.Error("GenerateContent() succeeded, want an error")
+			}
+		}
+		if body != nil {
+			t.Error("request sent, want none")
+		}
+	})
+}
@@ -0,0 +1,340 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openai
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/openai/openai-go/v3"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/model"
+)
+
+// streamAggregator aggregates the responses streamed for a turn into the
+// complete response ending the stream, see [Config.AggregateStream]. The
+// deltas are still streamed as partial responses, including the last one,
+// carried by the chunk with the finish reason.
+type streamAggregator struct {
+	text  strings.Builder
+	audio []*genai.Part
+	calls []*genai.Part
+	// final is the response converted from the chunk with the finish
+	// reason, if any, carrying the metadata of the turn.
+	final *model.LLMResponse
+	usage *genai.GenerateContentResponseUsageMetadata
+	// usageMetadata is the custom metadata of the response carrying the
+	// usage after the finish reason, e.g. the tokens of the prediction.
+	usageMetadata map[string]any
+}
+
+// wrap returns a function yielding the responses to stream for the
+// responses converted from the chunks, and aggregating them.
+func (a *streamAggregator) wrap(yield func(*model.LLMResponse, error) bool) func(*model.LLMResponse, error) bool {
+	return func(resp *model.LLMResponse, err error) bool {
+		if resp = a.add(resp); resp == nil {
+			return true
+		}
+		return yield(resp, err)
+	}
+}
+
+// add aggregates resp and returns the partial response to stream for it,
+// or nil if there is none.
+func (a *streamAggregator) add(resp *model.LLMResponse) *model.LLMResponse {
+	if resp.UsageMetadata != nil {
+		a.usage = resp.UsageMetadata
+	}
+	if resp.Partial {
+		if resp.Content != nil {
+			a.collect(resp.Content.Parts)
+		}
+		return resp
+	}
+	if resp.Content == nil {
+		// The usage chunk following the finish reason.
+		a.usageMetadata = resp.CustomMetadata
+		return nil
+	}
+	a.final = resp
+	var delta []*genai.Part
+	for _, part := range resp.Content.Parts {
+		if part.FunctionCall != nil {
+			a.calls = append(a.calls, part)
+		} else {
+			delta = append(delta, part)
+		}
+	}
+	if len(delta) == 0 {
+		return nil
+	}
+	a.collect(delta)
+	return &model.LLMResponse{
+		Content: &genai.Content{Role: genai.RoleModel, Parts: delta},
+		Partial: true,
+	}
+}
+
+// collect adds the parts of a delta. The consecutive audio data of the same
+// MIME type are concatenated.
+func (a *streamAggregator) collect(parts []*genai.Part) {
+	for _, part := range parts {
+		switch {
+		case part.Text != "":
+			a.text.WriteString(part.Text)
+		case part.InlineData != nil:
+			if n := len(a.audio); n > 0 && a.audio[n-1].InlineData.MIMEType == part.InlineData.MIMEType {
+				last := a.audio[n-1].InlineData
+				a.audio[n-1] = genai.NewPartFromBytes(bytes.Join([][]byte{last.Data, part.InlineData.Data}, nil), last.MIMEType)
+				continue
+			}
+			a.audio = append(a.audio, part)
+		}
+	}
+}
+
+// close returns the complete response of the turn: its whole text, audio
+// and function calls, with the finish reason, the error and the usage of
+// the turn. It returns nil if nothing was streamed.
+func (a *streamAggregator) close() *model.LLMResponse {
+	if a.final == nil && a.text.Len() == 0 && len(a.audio) == 0 {
+		return nil
+	}
+	resp := &model.LLMResponse{}
+	if a.final != nil {
+		*resp = *a.final
+	}
+	content := &genai.Content{Role: genai.RoleModel}
+	if a.text.Len() > 0 {
+		content.Parts = append(content.Parts, genai.NewPartFromText(a.text.String()))
+	}
+	content.Parts = append(content.Parts, a.audio...)
+	content.Parts = append(content.Parts, a.calls...)
+	resp.Content = content
+	resp.Partial = false
+	resp.TurnComplete = true
+	resp.UsageMetadata = a.usage
+	if len(a.usageMetadata) > 0 {
+		resp.CustomMetadata = maps.Clone(resp.CustomMetadata)
+		if resp.CustomMetadata == nil {
+			resp.CustomMetadata = map[string]any{}
+		}
+		maps.Copy(resp.CustomMetadata, a.usageMetadata)
+	}
+	return resp
+}
+
+// streamCandidates demultiplexes the choices of the chunks of a stream
+// requesting several candidates, which interleaves their deltas. The
+// deltas of the first candidate are streamed as the responses, as with a
+// single candidate; those of the others are streamed as partial responses
+// without content, which the flows skip. Every partial response carries its
+// delta in its Candidates, with the index of its candidate. The response
+// ending the first candidate is held until the others end: it then carries
+// all the candidates, aggregated and ordered by index, like the response of
+// a call which is not streamed.
+type streamCandidates struct {
+	candidates map[int64]*streamedCandidate
+	// final is the held response ending the first candidate.
+	final *model.LLMResponse
+}
+
+// streamedCandidate is the aggregation of the deltas of a candidate.
+type streamedCandidate struct {
+	text  strings.Builder
+	parts []*genai.Part
+	calls toolCallAccumulator
+	// refusal is the refusal of the model, streamed like the text.
+	refusal strings.Builder
+	// reason is the finish reason of the candidate, and message the error
+	// ending it, if any.
+	reason  genai.FinishReason
+	message string
+}
+
+func (s *streamCandidates) candidate(index int64) *streamedCandidate {
+	if s.candidates == nil {
+		s.candidates = make(map[int64]*streamedCandidate)
+	}
+	c, ok := s.candidates[index]
+	if !ok {
+		c = &streamedCandidate{}
+		s.candidates[index] = c
+	}
+	return c
+}
+
+// convert returns the responses to stream for chunk, given first, the
+// response converted from the chunk for the first candidate, if any.
+func (s *streamCandidates) convert(chunk openai.ChatCompletionChunk, first *model.LLMResponse) []*model.LLMResponse {
+	var responses []*model.LLMResponse
+	if first != nil {
+		if first.Content == nil {
+			// The usage follows the ends of all the candidates.
+			responses = append(responses, s.release())
+		}
+		responses = append(responses, s.first(first))
+	}
+	for _, choice := range chunk.Choices {
+		if choice.Index != 0 {
+			responses = append(responses, s.other(choice))
+		}
+	}
+	return responses
+}
+
+// first aggregates resp, converted for the first candidate, and returns
+// the response to stream for it, or nil if it is held.
+func (s *streamCandidates) first(resp *model.LLMResponse) *model.LLMResponse {
+	if resp.Content == nil {
+		return resp
+	}
+	c := s.candidate(0)
+	for _, part := range resp.Content.Parts {
+		if part.Text != "" && !part.Thought {
+			c.text.WriteString(part.Text)
+		} else {
+			c.parts = append(c.parts, part)
+		}
+	}
+	if !resp.Partial {
+		c.reason, c.message = resp.FinishReason, resp.ErrorMessage
+		s.final = resp
+		return nil
+	}
+	resp.Candidates = []*genai.Candidate{{Content: resp.Content}}
+	return resp
+}
+
+// other aggregates the choice of another candidate, and returns the
+// partial response to stream for its delta, or nil if there is none.
+func (s *streamCandidates) other(choice openai.ChatCompletionChunkChoice) *model.LLMResponse {
+	c := s.candidate(choice.Index)
+	text := choice.Delta.Content + choice.Delta.Refusal
+	c.text.WriteString(text)
+	c.refusal.WriteString(choice.Delta.Refusal)
+	c.calls.add(choice.Delta.ToolCalls)
+	if choice.FinishReason != "" {
+		resp := &model.LLMResponse{Content: &genai.Content{}, FinishReason: finishReason(choice.FinishReason)}
+		flushToolCalls(resp, &c.calls)
+		setFilteredError(resp, choice.FinishReason)
+		setRefusal(resp, c.refusal.String())
+		c.parts = append(c.parts, resp.Content.Parts...)
+		c.reason, c.message = resp.FinishReason, resp.ErrorMessage
+	}
+	if text == "" {
+		return nil
+	}
+	return &model.LLMResponse{
+		Partial: true,
+		Candidates: []*genai.Candidate{{
+			Index:   int32(choice.Index),
+			Content: genai.NewContentFromText(text, genai.RoleModel),
+		}},
+	}
+}
+
+// release returns the held response ending the first candidate, carrying
+// all the candidates, or nil if there is none.
+func (s *streamCandidates) release() *model.LLMResponse {
+	if s == nil || s.final == nil {
+		return nil
+	}
+	resp := s.final
+	s.final = nil
+	for _, index := range slices.Sorted(maps.Keys(s.candidates)) {
+		c := s.candidates[index]
+		content := &genai.Content{Role: genai.RoleModel}
+		if c.text.Len() > 0 {
+			content.Parts = append(content.Parts, genai.NewPartFromText(c.text.String()))
+		}
+		content.Parts = append(content.Parts, c.parts...)
+		resp.Candidates = append(resp.Candidates, &genai.Candidate{
+			Index:         int32(index),
+			Content:       content,
+			FinishReason:  c.reason,
+			FinishMessage: c.message,
+		})
+	}
+	return resp
+}
+
+// ErrStreamIdle is the error of the streamed calls whose stream sent no
+// chunk for the idle timeout, see [Config.StreamIdleTimeout]. It is
+// classified as [model.ErrorCodeUnavailable], and the calls are retried, if
+// configured, when no response was yielded.
+var ErrStreamIdle = errors.New("the stream sent no chunk for the idle timeout")
+
+const defaultStreamIdleTimeout = 60 * time.Second
+
+// idleWatchdog closes a stream sending no chunk for its timeout, by
+// canceling the context of its request. The nil watchdog, for the disabled
+// timeouts, does nothing.
+type idleWatchdog struct {
+	ctx     context.Context
+	cancel  context.CancelCauseFunc
+	timeout time.Duration
+	timer   *time.Timer
+}
+
+// newIdleWatchdog returns the context of a stream, derived from ctx, and
+// its started watchdog, or ctx and nil if timeout is negative.
+func newIdleWatchdog(ctx context.Context, timeout time.Duration) (context.Context, *idleWatchdog) {
+	if timeout < 0 {
+		return ctx, nil
+	}
+	ctx, cancel := context.WithCancelCause(ctx)
+	w := &idleWatchdog{ctx: ctx, cancel: cancel, timeout: timeout}
+	w.timer = time.AfterFunc(timeout, func() { cancel(ErrStreamIdle) })
+	return ctx, w
+}
+
+// pause stops the watchdog until resume is called.
+func (w *idleWatchdog) pause() {
+	if w != nil {
+		w.timer.Stop()
+	}
+}
+
+// resume restarts the watchdog for a full timeout.
+func (w *idleWatchdog) resume() {
+	if w != nil {
+		w.timer.Reset(w.timeout)
+	}
+}
+
+// stop stops the watchdog and releases the context of the stream.
+func (w *idleWatchdog) stop() {
+	if w != nil {
+		w.timer.Stop()
+		w.cancel(nil)
+	}
+}
+
+// err returns the error of the stream: err, or an [ErrStreamIdle] if the
+// watchdog closed the stream.
+func (w *idleWatchdog) err(err error) error {
+	if w != nil && errors.Is(context.Cause(w.ctx), ErrStreamIdle) {
+		return fmt.Errorf("no chunk received for %v: %w", w.timeout, ErrStreamIdle)
+	}
+	return err
+}
@@ -0,0 +1,383 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openai_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openai/openai-go/v3/option"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/model/openai"
+)
+
+func TestModel_AggregateStream(t *testing.T) {
+	const usage = `data: {"id":"c1","object":"chat.completion.chunk","model":"gpt-4o","choices":[],"usage":{"prompt_tokens":5,"completion_tokens":3,"total_tokens":8}}` + "\n\n"
+	chunk := func(delta, finish string) string {
+		return fmt.Sprintf(`data: {"id":"c1","object":"chat.completion.chunk","model":"gpt-4o","choices":[{"index":0,"delta":%s,"finish_reason":%s}]}`+"\n\n", delta, finish)
+	}
+	wantUsage := &genai.GenerateContentResponseUsageMetadata{PromptTokenCount: 5, CandidatesTokenCount: 3, TotalTokenCount: 8}
+	call := &genai.Part{FunctionCall: &genai.FunctionCall{ID: "call_1", Name: "get_weather", Args: map[string]any{"city": "Paris"}}}
+	for _, tc := range []struct {
+		name   string
+		chunks []string
+		want   []*model.LLMResponse
+	}{
+		{
+			name: "Text",
+			chunks: []string{
+				chunk(`{"role":"assistant","content":"Hel"}`, "null"),
+				chunk(`{"content":"lo"}`, "null"),
+				chunk(`{"content":"!"}`, `"stop"`),
+				usage,
+			},
+			want: []*model.LLMResponse{
+				{Content: genai.NewContentFromText("Hel", genai.RoleModel), Partial: true},
+				{Content: genai.NewContentFromText("lo", genai.RoleModel), Partial: true},
+				{Content: genai.NewContentFromText("!", genai.RoleModel), Partial: true},
+				{
+					Content:        genai.NewContentFromText("Hello!", genai.RoleModel),
+					CustomMetadata: responseID,
+					TurnComplete:   true,
+					FinishReason:   genai.FinishReasonStop,
+					UsageMetadata:  wantUsage,
+				},
+			},
+		},
+		{
+			name: "ToolCalls",
+			chunks: []string{
+				chunk(`{"role":"assistant","content":"Checking."}`, "null"),
+				chunk(`{"tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"get_weather","arguments":"{\"city\":"}}]}`, "null"),
+				chunk(`{"tool_calls":[{"index":0,"function":{"arguments":"\"Paris\"}"}}]}`, `"tool_calls"`),
+				usage,
+			},
+			want: []*model.LLMResponse{
+				{Content: genai.NewContentFromText("Checking.", genai.RoleModel), Partial: true},
+				{
+					Content:        &genai.Content{Role: genai.RoleModel, Parts: []*genai.Part{genai.NewPartFromText("Checking."), call}},
+					CustomMetadata: responseID,
+					TurnComplete:   true,
+					FinishReason:   genai.FinishReasonStop,
+					UsageMetadata:  wantUsage,
+				},
+			},
+		},
+		{
+			name: "NoFinishReason",
+			chunks: []string{
+				chunk(`{"role":"assistant","content":"Hel"}`, "null"),
+				chunk(`{"content":"lo"}`, "null"),
+			},
+			want: []*model.LLMResponse{
+				{Content: genai.NewContentFromText("Hel", genai.RoleModel), Partial: true},
+				{Content: genai.NewContentFromText("lo", genai.RoleModel), Partial: true},
+				{Content: genai.NewContentFromText("Hello", genai.RoleModel), TurnComplete: true},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/event-stream")
+				for _, c := range tc.chunks {
+					fmt.Fprint(w, c)
+				}
+				fmt.Fprint(w, "data: [DONE]\n\n")
+			}))
+			defer server.Close()
+			llm, err := openai.NewModelWithConfig(t.Context(), "gpt-4o", openai.Config{AggregateStream: true}, option.WithBaseURL(server.URL), option.WithAPIKey("key"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req := &model.LLMRequest{Model: "gpt-4o", Contents: []*genai.Content{genai.NewContentFromText("hi", genai.RoleUser)}}
+			var got []*model.LLMResponse
+			for resp, err := range llm.GenerateContent(t.Context(), req, true) {
+				if err != nil {
+					t.Fatal(err)
+				}
+				got = append(got, resp)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("responses mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestModel_AggregateStreamStop(t *testing.T) {
+	closed := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, `data: {"id":"c1","object":"chat.completion.chunk","model":"gpt-4o","choices":[{"index":0,"delta":{"content":"Hel"},"finish_reason":null}]}`+"\n\n")
+		w.(http.Flusher).Flush()
+		// The stream never ends: only the client closes it.
+		<-r.Context().Done()
+		close(closed)
+	}))
+	defer server.Close()
+	llm, err := openai.NewModelWithConfig(t.Context(), "gpt-4o", openai.Config{AggregateStream: true}, option.WithBaseURL(server.URL), option.WithAPIKey("key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := &model.LLMRequest{Model: "gpt-4o", Contents: []*genai.Content{genai.NewContentFromText("hi", genai.RoleUser)}}
+	for resp, err := range llm.GenerateContent(t.Context(), req, true) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !resp.Partial {
+			t.Errorf("response = %+v, want a partial response", resp)
+		}
+		break
+	}
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Error("the stream was not closed when the consumer stopped")
+	}
+}
+
+func TestModel_StreamCandidates(t *testing.T) {
+	chunk := func(index int, delta, finish string) string {
+		return fmt.Sprintf(`data: {"id":"c1","object":"chat.completion.chunk","model":"gpt-4o","choices":[{"index":%d,"delta":%s,"finish_reason":%s}]}`+"\n\n", index, delta, finish)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, c := range []string{
+			chunk(0, `{"role":"assistant","content":"a "}`, "null"),
+			chunk(1, `{"role":"assistant","content":"the "}`, "null"),
+			chunk(1, `{"content":"tabby"}`, "null"),
+			chunk(0, `{"content":"cat"}`, `"stop"`),
+			chunk(1, `{}`, `"length"`),
+			`data: {"id":"c1","object":"chat.completion.chunk","model":"gpt-4o","choices":[],"usage":{"prompt_tokens":5,"completion_tokens":4,"total_tokens":9}}` + "\n\n",
+		} {
+			fmt.Fprint(w, c)
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	text := func(s string) *genai.Content { return genai.NewContentFromText(s, genai.RoleModel) }
+	usage := &genai.GenerateContentResponseUsageMetadata{PromptTokenCount: 5, CandidatesTokenCount: 4, TotalTokenCount: 9}
+	for _, tc := range []struct {
+		name      string
+		aggregate bool
+		want      []*model.LLMResponse
+	}{
+		{
+			name: "Deltas",
+			want: []*model.LLMResponse{
+				{Content: text("a "), Partial: true, Candidates: []*genai.Candidate{{Content: text("a ")}}},
+				{Partial: true, Candidates: []*genai.Candidate{{Index: 1, Content: text("the ")}}},
+				{Partial: true, Candidates: []*genai.Candidate{{Index: 1, Content: text("tabby")}}},
+				{
+					Content:        text("cat"),
+					CustomMetadata: responseID,
+					TurnComplete:   true,
+					FinishReason:   genai.FinishReasonStop,
+					Candidates: []*genai.Candidate{
+						{Index: 0, Content: text("a cat"), FinishReason: genai.FinishReasonStop},
+						{Index: 1, Content: text("the tabby"), FinishReason: genai.FinishReasonMaxTokens},
+					},
+				},
+				{UsageMetadata: usage, TurnComplete: true},
+			},
+		},
+		{
+			name:      "Aggregated",
+			aggregate: true,
+			want: []*model.LLMResponse{
+				{Content: text("a "), Partial: true, Candidates: []*genai.Candidate{{Content: text("a ")}}},
+				{Partial: true, Candidates: []*genai.Candidate{{Index: 1, Content: text("the ")}}},
+				{Partial: true, Candidates: []*genai.Candidate{{Index: 1, Content: text("tabby")}}},
+				{Content: text("cat"), Partial: true},
+				{
+					Content:        text("a cat"),
+					CustomMetadata: responseID,
+					TurnComplete:   true,
+					FinishReason:   genai.FinishReasonStop,
+					UsageMetadata:  usage,
+					Candidates: []*genai.Candidate{
+						{Index: 0, Content: text("a cat"), FinishReason: genai.FinishReasonStop},
+						{Index: 1, Content: text("the tabby"), FinishReason: genai.FinishReasonMaxTokens},
+					},
+				},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			llm, err := openai.NewModelWithConfig(t.Context(), "gpt-4o", openai.Config{AggregateStream: tc.aggregate}, option.WithBaseURL(server.URL), option.WithAPIKey("key"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req := &model.LLMRequest{
+				Model:    "gpt-4o",
+				Contents: []*genai.Content{genai.NewContentFromText("what is it?", genai.RoleUser)},
+				Config:   &genai.GenerateContentConfig{CandidateCount: 2},
+			}
+			var got []*model.LLMResponse
+			for resp, err := range llm.GenerateContent(t.Context(), req, true) {
+				if err != nil {
+					t.Fatal(err)
+				}
+				got = append(got, resp)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("responses mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestModel_StreamIdleTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, `data: {"id":"c1","object":"chat.completion.chunk","model":"gpt-4o","choices":[{"index":0,"delta":{"role":"assistant","content":"Hel"},"finish_reason":null}]}`+"\n\n")
+		w.(http.Flusher).Flush()
+		// The stream stalls until the client gives up.
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+	llm, err := openai.NewModelWithConfig(t.Context(), "gpt-4o", openai.Config{StreamIdleTimeout: 50 * time.Millisecond}, option.WithBaseURL(server.URL), option.WithAPIKey("key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := &model.LLMRequest{Model: "gpt-4o", Contents: []*genai.Content{genai.NewContentFromText("hi", genai.RoleUser)}}
+
+	var got []*model.LLMResponse
+	var gotErr error
+	for resp, err := range llm.GenerateContent(t.Context(), req, true) {
+		if err != nil {
+			gotErr = err
+			break
+		}
+		got = append(got, resp)
+	}
+	want := []*model.LLMResponse{{Content: genai.NewContentFromText("Hel", genai.RoleModel), Partial: true}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("responses mismatch (-want +got):\n%s", diff)
+	}
+	if !errors.Is(gotErr, openai.ErrStreamIdle) {
+		t.Errorf("GenerateContent() error = %v, want %v", gotErr, openai.ErrStreamIdle)
+	}
+	if code := model.CodeOf(gotErr); code != model.ErrorCodeUnavailable {
+		t.Errorf("CodeOf(error) = %q, want %q", code, model.ErrorCodeUnavailable)
+	}
+}
+
+func TestModel_StreamSkipsEmptyChunks(t *testing.T) {
+	chunk := func(delta, finish string) string {
+		return fmt.Sprintf(`data: {"id":"c1","object":"chat.completion.chunk","model":"gpt-4o","choices":[{"index":0,"delta":%s,"finish_reason":%s}]}`+"\n\n", delta, finish)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, c := range []string{
+			chunk(`{"role":"assistant","content":""}`, "null"),
+			chunk(`{"content":"Hel"}`, "null"),
+			chunk(`{"content":"lo"}`, "null"),
+			chunk(`{}`, `"stop"`),
+		} {
+			fmt.Fprint(w, c)
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+	llm, err := openai.NewModel(t.Context(), "gpt-4o", option.WithBaseURL(server.URL), option.WithAPIKey("key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := &model.LLMRequest{Model: "gpt-4o", Contents: []*genai.Content{genai.NewContentFromText("hi", genai.RoleUser)}}
+	var got []*model.LLMResponse
+	for resp, err := range llm.GenerateContent(t.Context(), req, true) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, resp)
+	}
+	want := []*model.LLMResponse{
+		{Content: genai.NewContentFromText("Hel", genai.RoleModel), Partial: true},
+		{Content: genai.NewContentFromText("lo", genai.RoleModel), Partial: true},
+		{Content: &genai.Content{Role: genai.RoleModel}, CustomMetadata: responseID, TurnComplete: true, FinishReason: genai.FinishReasonStop},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("responses mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestModel_EstimateStreamUsage(t *testing.T) {
+	const usage = `data: {"id":"c1","object":"chat.completion.chunk","model":"gpt-4o","choices":[],"usage":{"prompt_tokens":5,"completion_tokens":3,"total_tokens":8}}` + "\n\n"
+	chunk := func(delta, finish string) string {
+		return fmt.Sprintf(`data: {"id":"c1","object":"chat.completion.chunk","model":"gpt-4o","choices":[{"index":0,"delta":%s,"finish_reason":%s}]}`+"\n\n", delta, finish)
+	}
+	text := []string{chunk(`{"role":"assistant","content":"Hello"}`, "null"), chunk(`{"content":" world!"}`, `"stop"`)}
+	for _, tc := range []struct {
+		name          string
+		chunks        []string
+		aggregate     bool
+		wantEstimated bool
+	}{
+		{name: "Reported", chunks: append(text, usage)},
+		{name: "Estimated", chunks: text, wantEstimated: true},
+		{name: "EstimatedAggregated", chunks: text, aggregate: true, wantEstimated: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/event-stream")
+				for _, c := range tc.chunks {
+					fmt.Fprint(w, c)
+				}
+				fmt.Fprint(w, "data: [DONE]\n\n")
+			}))
+			defer server.Close()
+			cfg := openai.Config{EstimateStreamUsage: true, AggregateStream: tc.aggregate}
+			llm, err := openai.NewModelWithConfig(t.Context(), "gpt-4o", cfg, option.WithBaseURL(server.URL), option.WithAPIKey("key"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req := &model.LLMRequest{Model: "gpt-4o", Contents: []*genai.Content{genai.NewContentFromText("say hello to the world", genai.RoleUser)}}
+			var last *model.LLMResponse
+			for resp, err := range llm.GenerateContent(t.Context(), req, true) {
+				if err != nil {
+					t.Fatal(err)
+				}
+				last = resp
+			}
+			usage := last.UsageMetadata
+			if usage == nil {
+				t.Fatal("the last response carries no usage")
+			}
+			if got := last.CustomMetadata[openai.EstimatedUsageMetadataKey] == true; got != tc.wantEstimated {
+				t.Errorf("usage estimated = %v, want %v", got, tc.wantEstimated)
+			}
+			if !tc.wantEstimated {
+				want := &genai.GenerateContentResponseUsageMetadata{PromptTokenCount: 5, CandidatesTokenCount: 3, TotalTokenCount: 8}
+				if diff := cmp.Diff(want, usage); diff != "" {
+					t.Errorf("UsageMetadata mismatch (-want +got):\n%s", diff)
+				}
+				return
+			}
+			// "Hello world!" is 12 bytes, estimated as 3 tokens.
+			if usage.CandidatesTokenCount != 3 || usage.PromptTokenCount <= 0 || usage.TotalTokenCount != usage.PromptTokenCount+usage.CandidatesTokenCount {
+				t.Errorf("UsageMetadata = %+v, want an estimate of 3 output tokens", usage)
+			}
+		})
+	}
+}
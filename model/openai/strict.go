@@ -0,0 +1,243 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"log"
+	"maps"
+	"slices"
+
+	"github.com/openai/openai-go/v3"
+	"github.com/openai/openai-go/v3/shared"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/internal/llmconv"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/strictness"
+)
+
+// strictFunction reports whether the function name is declared strict.
+func (c Config) strictFunction(name string) bool {
+	if strict, ok := c.StrictFunctionOverrides[name]; ok {
+		return strict
+	}
+	return c.StrictFunctions
+}
+
+// makeStrict declares the function def strict, with its parameters schema
+// made strict. If the schema cannot be made strict, def is left non-strict:
+// this is logged and reported with the strictness policy of ctx.
+func makeStrict(ctx context.Context, def *shared.FunctionDefinitionParam) error {
+	parameters, err := strictParameters(def.Parameters)
+	if err != nil {
+		log.Printf("Function %q declared non-strict: %v", def.Name, err)
+		return strictness.Report(ctx, strictness.Conversion, "openai", "function %q declared non-strict: %v", def.Name, err)
+	}
+	def.Parameters = parameters
+	def.Strict = openai.Bool(true)
+	return nil
+}
+
+// strictParameters returns a copy of the parameters schema s made strict:
+// the optional properties are required and nullable, and the objects are
+// closed. No parameters are an empty object.
+func strictParameters(s shared.FunctionParameters) (shared.FunctionParameters, error) {
+	if s == nil {
+		return shared.FunctionParameters{
+			"type":                 "object",
+			"properties":           map[string]any{},
+			"required":             []any{},
+			"additionalProperties": false,
+		}, nil
+	}
+	b, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	var strict map[string]any
+	if err := json.Unmarshal(b, &strict); err != nil {
+		return nil, err
+	}
+	if !isObjectSchema(strict) {
+		return nil, fmt.Errorf("the root must be an object, got type %v", strict["type"])
+	}
+	requireAll(strict)
+	if err := strictSchema("", strict); err != nil {
+		return nil, err
+	}
+	return strict, nil
+}
+
+// requireAll makes the optional properties of the objects of the JSON
+// schema s required and nullable. s is modified in place.
+func requireAll(s map[string]any) {
+	if isObjectSchema(s) {
+		properties, _ := s["properties"].(map[string]any)
+		required := stringList(s["required"])
+		list := make([]any, 0, len(properties))
+		for _, name := range required {
+			list = append(list, name)
+		}
+		for _, name := range slices.Sorted(maps.Keys(properties)) {
+			if slices.Contains(required, name) {
+				continue
+			}
+			if sub, ok := properties[name].(map[string]any); ok {
+				properties[name] = nullable(sub)
+			}
+			list = append(list, name)
+		}
+		if properties != nil {
+			s["required"] = list
+		}
+		for _, sub := range properties {
+			if sub, ok := sub.(map[string]any); ok {
+				requireAll(sub)
+			}
+		}
+	}
+	if items, ok := s["items"].(map[string]any); ok {
+		requireAll(items)
+	}
+	anyOf, _ := s["anyOf"].([]any)
+	for _, sub := range anyOf {
+		if sub, ok := sub.(map[string]any); ok {
+			requireAll(sub)
+		}
+	}
+	for _, keyword := range []string{"$defs", "definitions"} {
+		defs, _ := s[keyword].(map[string]any)
+		for _, sub := range defs {
+			if sub, ok := sub.(map[string]any); ok {
+				requireAll(sub)
+			}
+		}
+	}
+}
+
+// nullable returns the JSON schema s also accepting null.
+func nullable(s map[string]any) map[string]any {
+	if enum, ok := s["enum"].([]any); ok && !slices.Contains(enum, nil) {
+		s["enum"] = append(enum, nil)
+	}
+	switch typ := s["type"].(type) {
+	case string:
+		if typ != "null" {
+			s["type"] = []any{typ, "null"}
+		}
+		return s
+	case []any:
+		if !slices.Contains(typ, any("null")) {
+			s["type"] = append(typ, "null")
+		}
+		return s
+	}
+	if anyOf, ok := s["anyOf"].([]any); ok {
+		s["anyOf"] = append(anyOf, map[string]any{"type": "null"})
+		return s
+	}
+	return map[string]any{"anyOf": []any{s, map[string]any{"type": "null"}}}
+}
+
+// strictSchemas returns the parameters schemas, as declared, of the
+// functions of req declared strict in params, by name.
+func strictSchemas(req *model.LLMRequest, params *openai.ChatCompletionNewParams) map[string]map[string]any {
+	var schemas map[string]map[string]any
+	for _, t := range params.Tools {
+		f := t.GetFunction()
+		if f == nil || !f.Strict.Value {
+			continue
+		}
+		for _, tool := range req.Config.Tools {
+			if tool == nil {
+				continue
+			}
+			for _, decl := range tool.FunctionDeclarations {
+				if decl == nil || decl.Name != f.Name {
+					continue
+				}
+				if s, err := llmconv.ParametersSchema(decl); err == nil && s != nil {
+					if schemas == nil {
+						schemas = make(map[string]map[string]any)
+					}
+					schemas[f.Name] = s
+				}
+			}
+		}
+	}
+	return schemas
+}
+
+// dropAddedNulls removes from the calls of the responses the null arguments
+// of the parameters that are optional in their declared schemas, which the
+// strict mode made nullable: the functions get them as omitted.
+func dropAddedNulls(responses iter.Seq2[*model.LLMResponse, error], schemas map[string]map[string]any) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {
+		for resp, err := range responses {
+			if resp != nil {
+				contents := []*genai.Content{resp.Content}
+				for _, c := range resp.Candidates {
+					contents = append(contents, c.Content)
+				}
+				for _, content := range contents {
+					if content == nil {
+						continue
+					}
+					for _, part := range content.Parts {
+						if call := part.FunctionCall; call != nil && schemas[call.Name] != nil {
+							dropNulls(call.Args, schemas[call.Name])
+						}
+					}
+				}
+			}
+			if !yield(resp, err) {
+				return
+			}
+		}
+	}
+}
+
+// dropNulls removes the null values of value, an argument, for the
+// optional properties of its JSON schema s.
+func dropNulls(value any, s map[string]any) {
+	switch v := value.(type) {
+	case map[string]any:
+		properties, _ := s["properties"].(map[string]any)
+		required := stringList(s["required"])
+		for name, sub := range properties {
+			arg, ok := v[name]
+			if !ok {
+				continue
+			}
+			if arg == nil && !slices.Contains(required, name) {
+				delete(v, name)
+				continue
+			}
+			if sub, ok := sub.(map[string]any); ok {
+				dropNulls(arg, sub)
+			}
+		}
+	case []any:
+		if items, ok := s["items"].(map[string]any); ok {
+			for _, item := range v {
+				dropNulls(item, items)
+			}
+		}
+	}
+}
@@ -0,0 +1,191 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openai_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openai/openai-go/v3/option"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/internal/toolinternal"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/model/openai"
+	"google.golang.org/adk/strictness"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+)
+
+type forecastArgs struct {
+	City string `json:"city"`
+	Unit string `json:"unit,omitempty"`
+	Days *int   `json:"days,omitempty"`
+}
+
+func TestModel_StrictFunctions(t *testing.T) {
+	forecast, err := functiontool.New(functiontool.Config{Name: "get_forecast"}, func(_ tool.Context, args forecastArgs) (map[string]any, error) {
+		return map[string]any{"city": args.City, "unit": args.Unit, "days": args.Days == nil}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	forecastTool := forecast.(toolinternal.FunctionTool)
+	unsupported := &genai.FunctionDeclaration{Name: "lookup", ParametersJsonSchema: map[string]any{
+		"type":              "object",
+		"patternProperties": map[string]any{"^x-": map[string]any{"type": "string"}},
+	}}
+	noParameters := &genai.FunctionDeclaration{Name: "now"}
+
+	var tools []any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Tools []any `json:"tools"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		tools = body.Tools
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id": "c1", "object": "chat.completion", "model": "gpt-4o", "choices": [{"index": 0, "finish_reason": "tool_calls", "message": {"role": "assistant",
+			"tool_calls": [{"id": "call_1", "type": "function", "function": {"name": "get_forecast", "arguments": "{\"city\":\"Paris\",\"unit\":null,\"days\":null}"}}]}}]}`)
+	}))
+	defer srv.Close()
+
+	strictForecast := map[string]any{"type": "function", "function": map[string]any{
+		"name":   "get_forecast",
+		"strict": true,
+		"parameters": map[string]any{
+			"type":                 "object",
+			"required":             []any{"city", "days", "unit"},
+			"additionalProperties": false,
+			"properties": map[string]any{
+				"city": map[string]any{"type": "string"},
+				"days": map[string]any{"type": []any{"null", "integer"}},
+				"unit": map[string]any{"type": []any{"string", "null"}},
+			},
+		},
+	}}
+	tests := []struct {
+		name         string
+		cfg          openai.Config
+		wantTools    []any
+		wantArgs     map[string]any
+		wantWarnings []strictness.Warning
+	}{
+		{
+			name: "strict",
+			cfg:  openai.Config{StrictFunctions: true},
+			wantTools: []any{
+				strictForecast,
+				map[string]any{"type": "function", "function": map[string]any{
+					"name":       "lookup",
+					"parameters": map[string]any{"type": "object", "patternProperties": map[string]any{"^x-": map[string]any{"type": "string"}}},
+				}},
+				map[string]any{"type": "function", "function": map[string]any{
+					"name":       "now",
+					"strict":     true,
+					"parameters": map[string]any{"type": "object", "properties": map[string]any{}, "required": []any{}, "additionalProperties": false},
+				}},
+			},
+			wantArgs: map[string]any{"city": "Paris"},
+			wantWarnings: []strictness.Warning{
+				{Category: strictness.Conversion, Component: "openai", Message: `function "lookup" declared non-strict: keyword "patternProperties" at the root is not supported`},
+			},
+		},
+		{
+			name: "override",
+			cfg:  openai.Config{StrictFunctionOverrides: map[string]bool{"get_forecast": true}},
+			wantTools: []any{
+				strictForecast,
+				map[string]any{"type": "function", "function": map[string]any{
+					"name":       "lookup",
+					"parameters": map[string]any{"type": "object", "patternProperties": map[string]any{"^x-": map[string]any{"type": "string"}}},
+				}},
+				map[string]any{"type": "function", "function": map[string]any{"name": "now"}},
+			},
+			wantArgs: map[string]any{"city": "Paris"},
+		},
+		{
+			name: "non-strict",
+			wantTools: []any{
+				map[string]any{"type": "function", "function": map[string]any{
+					"name": "get_forecast",
+					"parameters": map[string]any{
+						"type":                 "object",
+						"required":             []any{"city"},
+						"additionalProperties": false,
+						"properties": map[string]any{
+							"city": map[string]any{"type": "string"},
+							"days": map[string]any{"type": []any{"null", "integer"}},
+							"unit": map[string]any{"type": "string"},
+						},
+					},
+				}},
+				map[string]any{"type": "function", "function": map[string]any{
+					"name":       "lookup",
+					"parameters": map[string]any{"type": "object", "patternProperties": map[string]any{"^x-": map[string]any{"type": "string"}}},
+				}},
+				map[string]any{"type": "function", "function": map[string]any{"name": "now"}},
+			},
+			wantArgs: map[string]any{"city": "Paris", "unit": nil, "days": nil},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			llm, err := openai.NewModelWithConfig(t.Context(), "gpt-4o", tc.cfg, option.WithBaseURL(srv.URL), option.WithAPIKey("key"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			collector := &strictness.Collector{}
+			ctx := strictness.NewContext(t.Context(), &strictness.Policy{Level: strictness.Warn})
+			ctx = strictness.WithCollector(ctx, collector)
+			req := &model.LLMRequest{
+				Model:    "gpt-4o",
+				Contents: []*genai.Content{genai.NewContentFromText("weather in Paris?", genai.RoleUser)},
+				Config: &genai.GenerateContentConfig{Tools: []*genai.Tool{{
+					FunctionDeclarations: []*genai.FunctionDeclaration{forecastTool.Declaration(), unsupported, noParameters},
+				}}},
+			}
+			var args map[string]any
+			for resp, err := range llm.GenerateContent(ctx, req, false) {
+				if err != nil {
+					t.Fatal(err)
+				}
+				args = resp.Content.Parts[0].FunctionCall.Args
+			}
+			if diff := cmp.Diff(tc.wantTools, tools); diff != "" {
+				t.Errorf("tools mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.wantArgs, args); diff != "" {
+				t.Errorf("arguments mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.wantWarnings, collector.Warnings()); diff != "" {
+				t.Errorf("warnings mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	// The arguments of the strict calls are accepted by the function.
+	result, err := forecastTool.Run(nil, map[string]any{"city": "Paris"})
+	if err != nil {
+		t.Fatalf("Run() failed: %v", err)
+	}
+	if diff := cmp.Diff(map[string]any{"city": "Paris", "unit": "", "days": true}, result); diff != "" {
+		t.Errorf("result mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openai
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/openai/openai-go/v3"
+	"github.com/openai/openai-go/v3/packages/param"
+)
+
+// TokenLimit is the parameter carrying the maximum number of output tokens
+// of the requests.
+type TokenLimit string
+
+const (
+	// TokenLimitAuto chooses the parameter from the name of the model:
+	// max_completion_tokens for the reasoning models and the gpt-4.1 and
+	// gpt-5 models, max_tokens for the others.
+	TokenLimitAuto TokenLimit = ""
+	// TokenLimitMaxTokens is the max_tokens parameter, which the recent
+	// models reject.
+	TokenLimitMaxTokens TokenLimit = "max_tokens"
+	// TokenLimitMaxCompletionTokens is the max_completion_tokens parameter,
+	// which some OpenAI-compatible backends do not support.
+	TokenLimitMaxCompletionTokens TokenLimit = "max_completion_tokens"
+)
+
+// usesMaxCompletionTokens reports whether the requests to the named model
+// carry max_completion_tokens.
+func (c Config) usesMaxCompletionTokens(name string) bool {
+	switch c.TokenLimit {
+	case TokenLimitMaxTokens:
+		return false
+	case TokenLimitMaxCompletionTokens:
+		return true
+	}
+	if c.Family.isReasoning(name) {
+		return true
+	}
+	name = baseModelName(name)
+	return strings.HasPrefix(name, "gpt-4.1") || strings.HasPrefix(name, "gpt-5")
+}
+
+// setTokenLimit sets the maximum number of output tokens of params, n, in
+// max_completion_tokens or in max_tokens.
+func setTokenLimit(params *openai.ChatCompletionNewParams, n int64, maxCompletionTokens bool) {
+	if maxCompletionTokens {
+		params.MaxCompletionTokens = param.NewOpt(n)
+		params.MaxTokens = param.Opt[int64]{}
+	} else {
+		params.MaxTokens = param.NewOpt(n)
+		params.MaxCompletionTokens = param.Opt[int64]{}
+	}
+}
+
+// swapTokenLimit moves the maximum number of output tokens of params to the
+// other parameter if err is the rejection of the parameter set, so that the
+// request can be retried. It reports whether it did.
+func swapTokenLimit(params *openai.ChatCompletionNewParams, err error) bool {
+	var apiErr *openai.Error
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		return false
+	}
+	rejected := func(name string) bool {
+		return apiErr.Param == name || strings.Contains(apiErr.Message, "'"+name+"'")
+	}
+	switch {
+	case params.MaxTokens.Valid() && rejected("max_tokens"):
+		setTokenLimit(params, params.MaxTokens.Value, true)
+	case params.MaxCompletionTokens.Valid() && rejected("max_completion_tokens"):
+		setTokenLimit(params, params.MaxCompletionTokens.Value, false)
+	default:
+		return false
+	}
+	return true
+}
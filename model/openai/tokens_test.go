@@ -0,0 +1,139 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openai_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openai/openai-go/v3/option"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/model/openai"
+)
+
+// tokenLimits returns the max_tokens and max_completion_tokens of body.
+func tokenLimits(body map[string]any) []any {
+	return []any{body["max_tokens"], body["max_completion_tokens"]}
+}
+
+func TestModel_TokenLimit(t *testing.T) {
+	var body map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body = nil
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id": "c1", "object": "chat.completion", "model": "gpt-4o", "choices": [{"index": 0, "finish_reason": "stop", "message": {"role": "assistant", "content": "ok"}}]}`)
+	}))
+	defer srv.Close()
+
+	for _, tc := range []struct {
+		name  string
+		model string
+		limit openai.TokenLimit
+		want  []any
+	}{
+		{name: "chat model", model: "gpt-4o", want: []any{100.0, nil}},
+		{name: "reasoning model", model: "o3-mini", want: []any{nil, 100.0}},
+		{name: "gpt-4.1", model: "gpt-4.1-mini", want: []any{nil, 100.0}},
+		{name: "gpt-5", model: "openai/gpt-5", want: []any{nil, 100.0}},
+		{name: "max_tokens override", model: "o3-mini", limit: openai.TokenLimitMaxTokens, want: []any{100.0, nil}},
+		{name: "max_completion_tokens override", model: "my-deployment", limit: openai.TokenLimitMaxCompletionTokens, want: []any{nil, 100.0}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			llm, err := openai.NewModelWithConfig(t.Context(), tc.model, openai.Config{TokenLimit: tc.limit}, option.WithBaseURL(srv.URL), option.WithAPIKey("key"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req := &model.LLMRequest{
+				Model:    tc.model,
+				Contents: []*genai.Content{genai.NewContentFromText("hi", genai.RoleUser)},
+				Config:   &genai.GenerateContentConfig{MaxOutputTokens: 100},
+			}
+			for _, err := range llm.GenerateContent(t.Context(), req, false) {
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			if diff := cmp.Diff(tc.want, tokenLimits(body)); diff != "" {
+				t.Errorf("max_tokens and max_completion_tokens mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestModel_TokenLimitFallback(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		model    string
+		rejected string
+		want     [][]any
+	}{
+		{name: "max_tokens rejected", model: "gpt-4o", rejected: "max_tokens", want: [][]any{{100.0, nil}, {nil, 100.0}}},
+		{name: "max_completion_tokens rejected", model: "o3-mini", rejected: "max_completion_tokens", want: [][]any{{nil, 100.0}, {100.0, nil}}},
+	} {
+		for _, stream := range []bool{false, true} {
+			t.Run(fmt.Sprintf("%s/stream=%v", tc.name, stream), func(t *testing.T) {
+				var got [][]any
+				srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					var body map[string]any
+					_ = json.NewDecoder(r.Body).Decode(&body)
+					got = append(got, tokenLimits(body))
+					if _, ok := body[tc.rejected]; ok {
+						w.Header().Set("Content-Type", "application/json")
+						w.WriteHeader(http.StatusBadRequest)
+						fmt.Fprintf(w, `{"error": {"message": "Unsupported parameter: '%s' is not supported with this model.", "type": "invalid_request_error", "param": "%s", "code": "unsupported_parameter"}}`, tc.rejected, tc.rejected)
+						return
+					}
+					if stream {
+						w.Header().Set("Content-Type", "text/event-stream")
+						fmt.Fprint(w, `data: {"id":"c1","object":"chat.completion.chunk","model":"gpt-4o","choices":[{"index":0,"delta":{"content":"ok"},"finish_reason":"stop"}]}`+"\n\ndata: [DONE]\n\n")
+						return
+					}
+					w.Header().Set("Content-Type", "application/json")
+					fmt.Fprint(w, `{"id": "c1", "object": "chat.completion", "model": "gpt-4o", "choices": [{"index": 0, "finish_reason": "stop", "message": {"role": "assistant", "content": "ok"}}]}`)
+				}))
+				defer srv.Close()
+				llm, err := openai.NewModel(t.Context(), tc.model, option.WithBaseURL(srv.URL), option.WithAPIKey("key"), openai.WithRetry(openai.RetryConfig{MaxAttempts: 1}))
+				if err != nil {
+					t.Fatal(err)
+				}
+				req := &model.LLMRequest{
+					Model:    tc.model,
+					Contents: []*genai.Content{genai.NewContentFromText("hi", genai.RoleUser)},
+					Config:   &genai.GenerateContentConfig{MaxOutputTokens: 100},
+				}
+				var text string
+				for resp, err := range llm.GenerateContent(t.Context(), req, stream) {
+					if err != nil {
+						t.Fatal(err)
+					}
+					text = resp.Content.Parts[0].Text
+				}
+				if text != "ok" {
+					t.Errorf("text = %q, want ok", text)
+				}
+				if diff := cmp.Diff(tc.want, got); diff != "" {
+					t.Errorf("token limits of the requests mismatch (-want +got):\n%s", diff)
+				}
+			})
+		}
+	}
+}
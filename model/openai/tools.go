@@ -0,0 +1,271 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/openai/openai-go/v3"
+	"github.com/openai/openai-go/v3/packages/param"
+	"github.com/openai/openai-go/v3/shared"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/internal/llmconv"
+)
+
+// convertTools converts the function declarations of tools to OpenAI
+// function tools, strict as configured by opts.
+func convertTools(ctx context.Context, tools []*genai.Tool, opts Config) ([]openai.ChatCompletionToolUnionParam, error) {
+	var params []openai.ChatCompletionToolUnionParam
+	for _, t := range tools {
+		if t == nil {
+			continue
+		}
+		for _, decl := range t.FunctionDeclarations {
+			if decl == nil {
+				continue
+			}
+			parameters, err := llmconv.ParametersSchema(decl)
+			if err != nil {
+				return nil, fmt.Errorf("failed to convert the parameters of function %q: %w", decl.Name, err)
+			}
+			def := shared.FunctionDefinitionParam{
+				Name:       decl.Name,
+				Parameters: parameters,
+			}
+			if decl.Description != "" {
+				def.Description = param.NewOpt(decl.Description)
+			}
+			if opts.strictFunction(decl.Name) {
+				if err := makeStrict(ctx, &def); err != nil {
+					return nil, err
+				}
+			}
+			params = append(params, openai.ChatCompletionFunctionTool(def))
+		}
+	}
+	return params, nil
+}
+
+// applyToolConfig sets the tool_choice of params from the function calling
+// config of cfg. Mode AUTO maps to "auto", NONE to "none" and ANY to
+// "required", or to the named function if exactly one function is allowed.
+// With several allowed functions, the declared tools are restricted to them.
+// The tools must be converted first.
+func applyToolConfig(params *openai.ChatCompletionNewParams, cfg *genai.ToolConfig) error {
+	if cfg == nil || cfg.FunctionCallingConfig == nil {
+		return nil
+	}
+	fcc := cfg.FunctionCallingConfig
+	allowed := fcc.AllowedFunctionNames
+	switch fcc.Mode {
+	case "", genai.FunctionCallingConfigModeUnspecified, genai.FunctionCallingConfigModeAuto, genai.FunctionCallingConfigModeNone:
+		if len(allowed) > 0 {
+			return fmt.Errorf("allowed function names are only supported with function calling mode %s, got mode %q", genai.FunctionCallingConfigModeAny, fcc.Mode)
+		}
+		// tool_choice is rejected without tools, which cannot be called
+		// anyway.
+		if len(params.Tools) == 0 {
+			return nil
+		}
+		switch fcc.Mode {
+		case genai.FunctionCallingConfigModeAuto:
+			params.ToolChoice = openai.ChatCompletionToolChoiceOptionUnionParam{OfAuto: param.NewOpt("auto")}
+		case genai.FunctionCallingConfigModeNone:
+			params.ToolChoice = openai.ChatCompletionToolChoiceOptionUnionParam{OfAuto: param.NewOpt("none")}
+		}
+		return nil
+	case genai.FunctionCallingConfigModeAny:
+	default:
+		return fmt.Errorf("function calling mode %q is not supported", fcc.Mode)
+	}
+
+	if len(params.Tools) == 0 {
+		return fmt.Errorf("function calling mode %s requires declared functions", fcc.Mode)
+	}
+	declared := make(map[string]bool, len(params.Tools))
+	for _, t := range params.Tools {
+		if f := t.GetFunction(); f != nil {
+			declared[f.Name] = true
+		}
+	}
+	for _, name := range allowed {
+		if !declared[name] {
+			return fmt.Errorf("allowed function %q is not declared", name)
+		}
+	}
+	switch len(allowed) {
+	case 0:
+		params.ToolChoice = openai.ChatCompletionToolChoiceOptionUnionParam{OfAuto: param.NewOpt("required")}
+	case 1:
+		params.ToolChoice = openai.ToolChoiceOptionFunctionToolChoice(openai.ChatCompletionNamedToolChoiceFunctionParam{Name: allowed[0]})
+	default:
+		params.Tools = slices.DeleteFunc(params.Tools, func(t openai.ChatCompletionToolUnionParam) bool {
+			f := t.GetFunction()
+			return f == nil || !slices.Contains(allowed, f.Name)
+		})
+		params.ToolChoice = openai.ChatCompletionToolChoiceOptionUnionParam{OfAuto: param.NewOpt("required")}
+	}
+	return nil
+}
+
+// functionCallParts converts the function tool calls of a message to
+// function call parts, which keep the IDs of the calls.
+func functionCallParts(calls []openai.ChatCompletionMessageToolCallUnion) ([]*genai.Part, error) {
+	var parts []*genai.Part
+	for _, call := range calls {
+		if call.Type != "" && call.Type != "function" {
+			continue
+		}
+		part, err := functionCallPart(call.ID, call.Function.Name, call.Function.Arguments)
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, part)
+	}
+	return parts, nil
+}
+
+// functionCallPart returns the part of a function call with its JSON
+// encoded arguments. Empty arguments are an empty object.
+func functionCallPart(id, name, arguments string) (*genai.Part, error) {
+	args := make(map[string]any)
+	if strings.TrimSpace(arguments) != "" {
+		if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+			return nil, fmt.Errorf("invalid arguments of the call to function %q: %w", name, err)
+		}
+	}
+	return &genai.Part{FunctionCall: &genai.FunctionCall{ID: id, Name: name, Args: args}}, nil
+}
+
+// toolCallAccumulator collects the fragments of the tool calls streamed in
+// the chunks of a response, by index. The calls are complete once the
+// finish reason arrives.
+type toolCallAccumulator struct {
+	calls map[int64]*streamedToolCall
+}
+
+type streamedToolCall struct {
+	id, name string
+	// arguments are the concatenated fragments of the JSON arguments. They
+	// are only parsed once complete, since a fragment may end anywhere,
+	// e.g. within a string or a multi-byte character.
+	arguments strings.Builder
+}
+
+// add adds the fragments of a chunk.
+func (a *toolCallAccumulator) add(deltas []openai.ChatCompletionChunkChoiceDeltaToolCall) {
+	for _, d := range deltas {
+		if d.Type != "" && d.Type != "function" {
+			continue
+		}
+		if a.calls == nil {
+			a.calls = make(map[int64]*streamedToolCall)
+		}
+		call, ok := a.calls[d.Index]
+		if !ok {
+			call = &streamedToolCall{}
+			a.calls[d.Index] = call
+		}
+		// The ID and the name come with the first fragment, but some
+		// compatible servers repeat them.
+		if d.ID != "" {
+			call.id = d.ID
+		}
+		if d.Function.Name != "" {
+			call.name = d.Function.Name
+		}
+		call.arguments.WriteString(d.Function.Arguments)
+	}
+}
+
+// pending reports whether calls were collected and not yet flushed.
+func (a *toolCallAccumulator) pending() bool {
+	return len(a.calls) > 0
+}
+
+// flush returns the parts of the collected calls, ordered by index, and
+// resets the accumulator.
+func (a *toolCallAccumulator) flush() ([]*genai.Part, error) {
+	indexes := slices.Sorted(maps.Keys(a.calls))
+	calls := a.calls
+	a.calls = nil
+	parts := make([]*genai.Part, 0, len(indexes))
+	for _, i := range indexes {
+		call := calls[i]
+		part, err := functionCallPart(call.id, call.name, call.arguments.String())
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, part)
+	}
+	return parts, nil
+}
+
+// toolCallParams converts the function calls of a model turn to the tool
+// calls of an assistant message. The arguments are JSON encoded.
+func toolCallParams(calls []*genai.FunctionCall) ([]openai.ChatCompletionMessageToolCallUnionParam, error) {
+	params := make([]openai.ChatCompletionMessageToolCallUnionParam, 0, len(calls))
+	for _, call := range calls {
+		args := []byte("{}")
+		if call.Args != nil {
+			var err error
+			if args, err = json.Marshal(call.Args); err != nil {
+				return nil, fmt.Errorf("failed to encode the arguments of the call to %q: %w", call.Name, err)
+			}
+		}
+		params = append(params, openai.ChatCompletionMessageToolCallUnionParam{
+			OfFunction: &openai.ChatCompletionMessageFunctionToolCallParam{
+				ID: call.ID,
+				Function: openai.ChatCompletionMessageFunctionToolCallFunctionParam{
+					Name:      call.Name,
+					Arguments: string(args),
+				},
+			},
+		})
+	}
+	return params, nil
+}
+
+// assistantToolCallMessage returns the assistant message carrying text, if
+// any, and the tool calls of calls.
+func assistantToolCallMessage(text string, calls []*genai.FunctionCall) (openai.ChatCompletionMessageParamUnion, error) {
+	toolCalls, err := toolCallParams(calls)
+	if err != nil {
+		return openai.ChatCompletionMessageParamUnion{}, err
+	}
+	msg := &openai.ChatCompletionAssistantMessageParam{ToolCalls: toolCalls}
+	if text != "" {
+		msg.Content.OfString = param.NewOpt(text)
+	}
+	return openai.ChatCompletionMessageParamUnion{OfAssistant: msg}, nil
+}
+
+// toolMessage returns the tool message carrying the JSON encoded response.
+func toolMessage(resp *genai.FunctionResponse) (openai.ChatCompletionMessageParamUnion, error) {
+	content, err := json.Marshal(resp.Response)
+	if err != nil {
+		return openai.ChatCompletionMessageParamUnion{}, fmt.Errorf("failed to encode the response of %q: %w", resp.Name, err)
+	}
+	if resp.Response == nil {
+		content = []byte("{}")
+	}
+	return openai.ToolMessage(string(content), resp.ID), nil
+}
@@ -0,0 +1,629 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openai_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	oai "github.com/openai/openai-go/v3"
+	"github.com/openai/openai-go/v3/option"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/internal/testutil"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/model/openai"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+)
+
+func TestLLMRequest2ChatCompletionNewParams_Tools(t *testing.T) {
+	req := &model.LLMRequest{
+		Model:    "gpt-4o",
+		Contents: []*genai.Content{genai.NewContentFromText("hi", genai.RoleUser)},
+		Config: &genai.GenerateContentConfig{Tools: []*genai.Tool{
+			{FunctionDeclarations: []*genai.FunctionDeclaration{
+				{
+					Name:        "get_weather",
+					Description: "returns the weather",
+					Parameters: &genai.Schema{
+						Type: genai.TypeObject,
+						Properties: map[string]*genai.Schema{
+							"city":  {Type: genai.TypeString, Description: "the city"},
+							"days":  {Type: genai.TypeInteger, Minimum: genai.Ptr(1.0), Nullable: genai.Ptr(true)},
+							"units": {Type: genai.TypeArray, Items: &genai.Schema{Type: genai.TypeString, Enum: []string{"C", "F"}}},
+						},
+						Required: []string{"city"},
+					},
+				},
+				{Name: "now"},
+			}},
+			{FunctionDeclarations: []*genai.FunctionDeclaration{{
+				Name:                 "lookup",
+				ParametersJsonSchema: map[string]any{"type": "object", "properties": map[string]any{"q": map[string]any{"type": "string"}}},
+			}}},
+		}},
+	}
+	params, err := openai.LLMRequest2ChatCompletionNewParams(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := json.Marshal(params.Tools)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []any
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	want := []any{
+		map[string]any{"type": "function", "function": map[string]any{
+			"name":        "get_weather",
+			"description": "returns the weather",
+			"parameters": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"city":  map[string]any{"type": "string", "description": "the city"},
+					"days":  map[string]any{"type": []any{"integer", "null"}, "minimum": 1.0},
+					"units": map[string]any{"type": "array", "items": map[string]any{"type": "string", "enum": []any{"C", "F"}}},
+				},
+				"required": []any{"city"},
+			},
+		}},
+		map[string]any{"type": "function", "function": map[string]any{"name": "now"}},
+		map[string]any{"type": "function", "function": map[string]any{
+			"name":       "lookup",
+			"parameters": map[string]any{"type": "object", "properties": map[string]any{"q": map[string]any{"type": "string"}}},
+		}},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("tools mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestLLMRequest2ChatCompletionNewParams_ToolConfig(t *testing.T) {
+	tools := []*genai.Tool{{FunctionDeclarations: []*genai.FunctionDeclaration{{Name: "a"}, {Name: "b"}, {Name: "c"}}}}
+	config := func(mode genai.FunctionCallingConfigMode, allowed ...string) *genai.ToolConfig {
+		return &genai.ToolConfig{FunctionCallingConfig: &genai.FunctionCallingConfig{Mode: mode, AllowedFunctionNames: allowed}}
+	}
+	tests := []struct {
+		name       string
+		tools      []*genai.Tool
+		toolConfig *genai.ToolConfig
+		wantChoice any
+		wantTools  []string
+		wantErr    bool
+	}{
+		{name: "NoConfig", tools: tools, wantTools: []string{"a", "b", "c"}},
+		{name: "Unspecified", tools: tools, toolConfig: config(genai.FunctionCallingConfigModeUnspecified), wantTools: []string{"a", "b", "c"}},
+		{name: "Auto", tools: tools, toolConfig: config(genai.FunctionCallingConfigModeAuto), wantChoice: "auto", wantTools: []string{"a", "b", "c"}},
+		{name: "None", tools: tools, toolConfig: config(genai.FunctionCallingConfigModeNone), wantChoice: "none", wantTools: []string{"a", "b", "c"}},
+		{name: "Any", tools: tools, toolConfig: config(genai.FunctionCallingConfigModeAny), wantChoice: "required", wantTools: []string{"a", "b", "c"}},
+		{
+			name:       "AnyOneAllowed",
+			tools:      tools,
+			toolConfig: config(genai.FunctionCallingConfigModeAny, "b"),
+			wantChoice: map[string]any{"type": "function", "function": map[string]any{"name": "b"}},
+			wantTools:  []string{"a", "b", "c"},
+		},
+		{name: "AnySeveralAllowed", tools: tools, toolConfig: config(genai.FunctionCallingConfigModeAny, "c", "a"), wantChoice: "required", wantTools: []string{"a", "c"}},
+		{name: "AllowedNotDeclared", tools: tools, toolConfig: config(genai.FunctionCallingConfigModeAny, "a", "d"), wantErr: true},
+		{name: "AllowedWithAuto", tools: tools, toolConfig: config(genai.FunctionCallingConfigModeAuto, "a"), wantErr: true},
+		{name: "Validated", tools: tools, toolConfig: config(genai.FunctionCallingConfigModeValidated), wantErr: true},
+		{name: "AutoWithoutTools", toolConfig: config(genai.FunctionCallingConfigModeAuto)},
+		{name: "NoneWithoutTools", toolConfig: config(genai.FunctionCallingConfigModeNone)},
+		{name: "AnyWithoutTools", toolConfig: config(genai.FunctionCallingConfigModeAny), wantErr: true},
+		{name: "AnyOneAllowedWithoutTools", toolConfig: config(genai.FunctionCallingConfigModeAny, "a"), wantErr: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := &model.LLMRequest{
+				Model:    "gpt-4o",
+				Contents: []*genai.Content{genai.NewContentFromText("hi", genai.RoleUser)},
+				Config:   &genai.GenerateContentConfig{Tools: tc.tools, ToolConfig: tc.toolConfig},
+			}
+			params, err := openai.LLMRequest2ChatCompletionNewParams(req)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("LLMRequest2ChatCompletionNewParams() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			b, err := json.Marshal(params)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got struct {
+				ToolChoice any `json:"tool_choice"`
+				Tools      []struct {
+					Function struct {
+						Name string `json:"name"`
+					} `json:"function"`
+				} `json:"tools"`
+			}
+			if err := json.Unmarshal(b, &got); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.wantChoice, got.ToolChoice); diff != "" {
+				t.Errorf("tool_choice mismatch (-want +got):\n%s", diff)
+			}
+			var names []string
+			for _, tl := range got.Tools {
+				names = append(names, tl.Function.Name)
+			}
+			if diff := cmp.Diff(tc.wantTools, names); diff != "" {
+				t.Errorf("tools mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestChatCompletion2LLMResponse_ToolCalls(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		message       string
+		wantParts     []*genai.Part
+		wantErrorCode model.ErrorCode
+	}{
+		{
+			name: "Calls",
+			message: `{"role": "assistant", "content": "Let me check.", "tool_calls": [
+				{"id": "call_1", "type": "function", "function": {"name": "get_weather", "arguments": "{\"city\": \"London\"}"}},
+				{"id": "call_2", "type": "function", "function": {"name": "now", "arguments": ""}}]}`,
+			wantParts: []*genai.Part{
+				{Text: "Let me check."},
+				{FunctionCall: &genai.FunctionCall{ID: "call_1", Name: "get_weather", Args: map[string]any{"city": "London"}}},
+				{FunctionCall: &genai.FunctionCall{ID: "call_2", Name: "now", Args: map[string]any{}}},
+			},
+		},
+		{
+			name: "InvalidArguments",
+			message: `{"role": "assistant", "tool_calls": [
+				{"id": "call_1", "type": "function", "function": {"name": "get_weather", "arguments": "{\"city\": "}}]}`,
+			wantErrorCode: model.ErrorCodeUnknown,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var completion oai.ChatCompletion
+			body := `{"id": "c1", "object": "chat.completion", "model": "gpt-4o", "choices": [{"index": 0, "finish_reason": "tool_calls", "message": ` + tc.message + `}]}`
+			if err := json.Unmarshal([]byte(body), &completion); err != nil {
+				t.Fatal(err)
+			}
+			resp := openai.ChatCompletion2LLMResponse(&completion)
+			if diff := cmp.Diff(tc.wantParts, resp.Content.Parts); diff != "" {
+				t.Errorf("parts mismatch (-want +got):\n%s", diff)
+			}
+			if model.ErrorCode(resp.ErrorCode) != tc.wantErrorCode {
+				t.Errorf("ErrorCode = %q, want %q", resp.ErrorCode, tc.wantErrorCode)
+			}
+		})
+	}
+}
+
+// toolCallingBackend answers the first chat completion with calls to
+// get_weather for two cities, and the next ones with text.
+type toolCallingBackend struct {
+	mu    sync.Mutex
+	tools [][]string
+}
+
+func (b *toolCallingBackend) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Tools []struct {
+			Function struct {
+				Name string `json:"name"`
+			} `json:"function"`
+		} `json:"tools"`
+	}
+	_ = json.NewDecoder(r.Body).Decode(&body)
+	b.mu.Lock()
+	var names []string
+	for _, t := range body.Tools {
+		names = append(names, t.Function.Name)
+	}
+	b.tools = append(b.tools, names)
+	first := len(b.tools) == 1
+	b.mu.Unlock()
+
+	message := map[string]any{"role": "assistant", "content": "It is sunny in both cities."}
+	finish := "stop"
+	if first {
+		message = map[string]any{"role": "assistant", "tool_calls": []any{
+			map[string]any{"id": "call_1", "type": "function", "function": map[string]any{"name": "get_weather", "arguments": `{"city":"London"}`}},
+			map[string]any{"id": "call_2", "type": "function", "function": map[string]any{"name": "get_weather", "arguments": `{"city":"Paris"}`}},
+		}}
+		finish = "tool_calls"
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"id": "c1", "object": "chat.completion", "model": "gpt-4o",
+		"choices": []any{map[string]any{"index": 0, "finish_reason": finish, "message": message}},
+	})
+}
+
+func TestModel_ToolCalling(t *testing.T) {
+	backend := &toolCallingBackend{}
+	server := httptest.NewServer(backend)
+	defer server.Close()
+	llm, err := openai.NewModel(t.Context(), "gpt-4o", option.WithBaseURL(server.URL), option.WithAPIKey("key"), option.WithMaxRetries(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type weatherArgs struct {
+		City string `json:"city"`
+	}
+	var (
+		mu     sync.Mutex
+		cities []string
+	)
+	weather, err := functiontool.New(functiontool.Config{Name: "get_weather", Description: "returns the weather"}, func(_ tool.Context, args weatherArgs) (map[string]any, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		cities = append(cities, args.City)
+		return map[string]any{"weather": "sunny"}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := llmagent.New(llmagent.Config{Name: "weather_agent", Model: llm, Tools: []tool.Tool{weather}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	events, err := testutil.CollectEvents(testutil.NewTestAgentRunner(t, a).Run(t, "session", "weather in London and Paris?"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	slices.Sort(cities)
+	if diff := cmp.Diff([]string{"London", "Paris"}, cities); diff != "" {
+		t.Errorf("tool calls mismatch (-want +got):\n%s", diff)
+	}
+	var responses []*genai.FunctionResponse
+	for _, ev := range events {
+		for _, p := range ev.Content.Parts {
+			if p.FunctionResponse != nil {
+				responses = append(responses, p.FunctionResponse)
+			}
+		}
+	}
+	want := []*genai.FunctionResponse{
+		{ID: "call_1", Name: "get_weather", Response: map[string]any{"weather": "sunny"}},
+		{ID: "call_2", Name: "get_weather", Response: map[string]any{"weather": "sunny"}},
+	}
+	if diff := cmp.Diff(want, responses); diff != "" {
+		t.Errorf("function responses mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([][]string{{"get_weather"}, {"get_weather"}}, backend.tools); diff != "" {
+		t.Errorf("declared tools mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestLLMRequest2ChatCompletionNewParams_FunctionCallHistory(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		message string
+		want    map[string]any
+	}{
+		{
+			name: "Calls",
+			message: `{"role": "assistant", "tool_calls": [
+				{"id": "call_1", "type": "function", "function": {"name": "get_weather", "arguments": "{\"city\":\"London\"}"}},
+				{"id": "call_2", "type": "function", "function": {"name": "now", "arguments": ""}}]}`,
+			want: map[string]any{"role": "assistant", "tool_calls": []any{
+				map[string]any{"id": "call_1", "type": "function", "function": map[string]any{"name": "get_weather", "arguments": `{"city":"London"}`}},
+				map[string]any{"id": "call_2", "type": "function", "function": map[string]any{"name": "now", "arguments": "{}"}},
+			}},
+		},
+		{
+			name: "TextAndCall",
+			message: `{"role": "assistant", "content": "Let me check.", "tool_calls": [
+				{"id": "call_1", "type": "function", "function": {"name": "get_weather", "arguments": "{\"city\":\"London\"}"}}]}`,
+			want: map[string]any{"role": "assistant", "content": "Let me check.", "tool_calls": []any{
+				map[string]any{"id": "call_1", "type": "function", "function": map[string]any{"name": "get_weather", "arguments": `{"city":"London"}`}},
+			}},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var completion oai.ChatCompletion
+			body := `{"id": "c1", "object": "chat.completion", "model": "gpt-4o", "choices": [{"index": 0, "finish_reason": "tool_calls", "message": ` + tc.message + `}]}`
+			if err := json.Unmarshal([]byte(body), &completion); err != nil {
+				t.Fatal(err)
+			}
+			resp := openai.ChatCompletion2LLMResponse(&completion)
+			req := &model.LLMRequest{
+				Model: "gpt-4o",
+				Contents: []*genai.Content{
+					genai.NewContentFromText("what is the weather?", genai.RoleUser),
+					resp.Content,
+					genai.NewContentFromText("thanks", genai.RoleUser),
+				},
+			}
+			params, err := openai.LLMRequest2ChatCompletionNewParams(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, err := json.Marshal(params.Messages)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []any
+			if err := json.Unmarshal(b, &got); err != nil {
+				t.Fatal(err)
+			}
+			want := []any{
+				map[string]any{"role": "user", "content": "what is the weather?"},
+				tc.want,
+				map[string]any{"role": "user", "content": "thanks"},
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("messages mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestLLMRequest2ChatCompletionNewParams_FunctionResponses(t *testing.T) {
+	call := func(id, name, city string) *genai.Part {
+		return &genai.Part{FunctionCall: &genai.FunctionCall{ID: id, Name: name, Args: map[string]any{"city": city}}}
+	}
+	response := func(id, name, weather string) *genai.Part {
+		return &genai.Part{FunctionResponse: &genai.FunctionResponse{ID: id, Name: name, Response: map[string]any{"weather": weather}}}
+	}
+	toolCall := func(id, name, city string) any {
+		return map[string]any{"id": id, "type": "function", "function": map[string]any{"name": name, "arguments": `{"city":"` + city + `"}`}}
+	}
+	toolMessage := func(id, weather string) any {
+		return map[string]any{"role": "tool", "tool_call_id": id, "content": `{"weather":"` + weather + `"}`}
+	}
+	question := genai.NewContentFromText("weather?", genai.RoleUser)
+	for _, tc := range []struct {
+		name     string
+		contents []*genai.Content
+		want     []any
+	}{
+		{
+			name: "MultipleResponses",
+			contents: []*genai.Content{
+				question,
+				{Role: genai.RoleModel, Parts: []*genai.Part{call("call_a", "weather", "London"), call("call_b", "weather", "Paris")}},
+				{Role: genai.RoleUser, Parts: []*genai.Part{response("call_a", "weather", "rain"), response("call_b", "weather", "sun")}},
+			},
+			want: []any{
+				map[string]any{"role": "user", "content": "weather?"},
+				map[string]any{"role": "assistant", "tool_calls": []any{toolCall("call_a", "weather", "London"), toolCall("call_b", "weather", "Paris")}},
+				toolMessage("call_a", "rain"),
+				toolMessage("call_b", "sun"),
+			},
+		},
+		{
+			name: "ResponsesOutOfOrder",
+			contents: []*genai.Content{
+				question,
+				{Role: genai.RoleModel, Parts: []*genai.Part{call("call_a", "weather", "London"), call("call_b", "forecast", "Paris")}},
+				{Role: genai.RoleUser, Parts: []*genai.Part{response("call_b", "forecast", "sun")}},
+				{Role: genai.RoleUser, Parts: []*genai.Part{response("call_a", "weather", "rain")}},
+			},
+			want: []any{
+				map[string]any{"role": "user", "content": "weather?"},
+				map[string]any{"role": "assistant", "tool_calls": []any{toolCall("call_a", "weather", "London"), toolCall("call_b", "forecast", "Paris")}},
+				toolMessage("call_b", "sun"),
+				toolMessage("call_a", "rain"),
+			},
+		},
+		{
+			name: "MissingIDs",
+			contents: []*genai.Content{
+				question,
+				{Role: genai.RoleModel, Parts: []*genai.Part{call("", "weather", "London"), call("", "forecast", "Paris"), call("", "weather", "Rome")}},
+				{Role: genai.RoleUser, Parts: []*genai.Part{response("", "forecast", "sun"), response("", "weather", "rain"), response("", "weather", "fog")}},
+				{Role: genai.RoleModel, Parts: []*genai.Part{call("", "weather", "Oslo")}},
+				{Role: genai.RoleUser, Parts: []*genai.Part{response("", "weather", "snow")}},
+			},
+			want: []any{
+				map[string]any{"role": "user", "content": "weather?"},
+				map[string]any{"role": "assistant", "tool_calls": []any{toolCall("call_0", "weather", "London"), toolCall("call_1", "forecast", "Paris"), toolCall("call_2", "weather", "Rome")}},
+				toolMessage("call_1", "sun"),
+				toolMessage("call_0", "rain"),
+				toolMessage("call_2", "fog"),
+				map[string]any{"role": "assistant", "tool_calls": []any{toolCall("call_3", "weather", "Oslo")}},
+				toolMessage("call_3", "snow"),
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			params, err := openai.LLMRequest2ChatCompletionNewParams(&model.LLMRequest{Model: "gpt-4o", Contents: tc.contents})
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, err := json.Marshal(params.Messages)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []any
+			if err := json.Unmarshal(b, &got); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("messages mismatch (-want +got):\n%s", diff)
+			}
+			// The history is not modified.
+			for _, p := range tc.contents[1].Parts {
+				if tc.name == "MissingIDs" && p.FunctionCall.ID != "" {
+					t.Errorf("call ID = %q, want the history unchanged", p.FunctionCall.ID)
+				}
+			}
+		})
+	}
+}
+
+// streamServer returns a server streaming chunks, with the delta of every
+// chunk of the first choice and the finish reason of the last, if any.
+func streamServer(t *testing.T, finish string, deltas ...string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for i, delta := range deltas {
+			reason := "null"
+			if i == len(deltas)-1 && finish != "" {
+				reason = `"` + finish + `"`
+			}
+			fmt.Fprintf(w, "data: {\"id\":\"c1\",\"object\":\"chat.completion.chunk\",\"model\":\"gpt-4o\",\"choices\":[{\"index\":0,\"delta\":%s,\"finish_reason\":%s}]}\n\n", delta, reason)
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestModel_StreamToolCalls(t *testing.T) {
+	toolDelta := func(index int, id, name, args string) string {
+		b, err := json.Marshal(map[string]any{"tool_calls": []any{map[string]any{
+			"index": index, "id": id, "type": "function", "function": map[string]any{"name": name, "arguments": args},
+		}}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+	interleaved := []string{
+		`{"role":"assistant","content":"Checking."}`,
+		toolDelta(0, "call_1", "get_weather", `{"city":"Z`),
+		toolDelta(1, "call_2", "get_weather", `{"city":"Pa`),
+		toolDelta(0, "", "", `ürich","note":"say \`),
+		toolDelta(1, "", "", `ris"}`),
+		toolDelta(0, "", "", `"hi\""}`),
+	}
+	calls := []*genai.Part{
+		{FunctionCall: &genai.FunctionCall{ID: "call_1", Name: "get_weather", Args: map[string]any{"city": "Zürich", "note": `say "hi"`}}},
+		{FunctionCall: &genai.FunctionCall{ID: "call_2", Name: "get_weather", Args: map[string]any{"city": "Paris"}}},
+	}
+	for _, tc := range []struct {
+		name   string
+		finish string
+		deltas []string
+		want   []*model.LLMResponse
+	}{
+		{
+			name:   "Interleaved",
+			finish: "tool_calls",
+			deltas: append(slices.Clone(interleaved), `{}`),
+			want: []*model.LLMResponse{
+				{Content: genai.NewContentFromText("Checking.", genai.RoleModel), Partial: true},
+				{Content: &genai.Content{Role: genai.RoleModel, Parts: calls}, CustomMetadata: responseID, TurnComplete: true, FinishReason: genai.FinishReasonStop},
+			},
+		},
+		{
+			name:   "FinishWithLastFragment",
+			finish: "tool_calls",
+			deltas: interleaved,
+			want: []*model.LLMResponse{
+				{Content: genai.NewContentFromText("Checking.", genai.RoleModel), Partial: true},
+				{Content: &genai.Content{Role: genai.RoleModel, Parts: calls}, CustomMetadata: responseID, TurnComplete: true, FinishReason: genai.FinishReasonStop},
+			},
+		},
+		{
+			name:   "NoFinishReason",
+			deltas: interleaved,
+			want: []*model.LLMResponse{
+				{Content: genai.NewContentFromText("Checking.", genai.RoleModel), Partial: true},
+				{Content: &genai.Content{Role: genai.RoleModel, Parts: calls}, TurnComplete: true},
+			},
+		},
+		{
+			name:   "Truncated",
+			deltas: interleaved[:4],
+			want: []*model.LLMResponse{
+				{Content: genai.NewContentFromText("Checking.", genai.RoleModel), Partial: true},
+				{
+					Content:      &genai.Content{Role: genai.RoleModel},
+					TurnComplete: true,
+					ErrorCode:    string(model.ErrorCodeUnknown),
+					ErrorMessage: `invalid arguments of the call to function "get_weather": unexpected end of JSON input`,
+				},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			server := streamServer(t, tc.finish, tc.deltas...)
+			llm, err := openai.NewModel(t.Context(), "gpt-4o", option.WithBaseURL(server.URL), option.WithAPIKey("key"), option.WithMaxRetries(0))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req := &model.LLMRequest{Model: "gpt-4o", Contents: []*genai.Content{genai.NewContentFromText("weather?", genai.RoleUser)}}
+			var got []*model.LLMResponse
+			for resp, err := range llm.GenerateContent(t.Context(), req, true) {
+				if err != nil {
+					t.Fatal(err)
+				}
+				got = append(got, resp)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("responses mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestModel_DisableParallelToolCalls(t *testing.T) {
+	var got map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = nil
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id": "c1", "object": "chat.completion", "model": "gpt-4o", "choices": [{"index": 0, "finish_reason": "stop", "message": {"role": "assistant", "content": "ok"}}]}`)
+	}))
+	defer srv.Close()
+
+	tools := []*genai.Tool{{FunctionDeclarations: []*genai.FunctionDeclaration{{Name: "get_weather", Description: "returns the weather"}}}}
+	tests := []struct {
+		name    string
+		disable bool
+		tools   []*genai.Tool
+		want    any
+	}{
+		{name: "Default", tools: tools},
+		{name: "Disabled", disable: true, tools: tools, want: false},
+		{name: "DisabledWithoutTools", disable: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			llm, err := openai.NewModelWithConfig(t.Context(), "gpt-4o", openai.Config{DisableParallelToolCalls: tc.disable}, option.WithBaseURL(srv.URL), option.WithAPIKey("key"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req := &model.LLMRequest{
+				Model:    "gpt-4o",
+				Contents: []*genai.Content{genai.NewContentFromText("weather in Paris?", genai.RoleUser)},
+				Config:   &genai.GenerateContentConfig{Tools: tc.tools},
+			}
+			for _, err := range llm.GenerateContent(t.Context(), req, false) {
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			if diff := cmp.Diff(tc.want, got["parallel_tool_calls"]); diff != "" {
+				t.Errorf("parallel_tool_calls mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,168 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package piimask
+
+import (
+	"math/big"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Detector finds one kind of PII in text.
+//
+// Custom detectors can be built by providing a Kind and a Pattern, and
+// optionally a Validate function to discard false positives.
+type Detector struct {
+	// Kind names the detected PII, e.g. "EMAIL". It is used in the tokens
+	// that replace the detected values, so it should be short and uppercase.
+	Kind string
+	// Pattern matches the candidate values.
+	Pattern *regexp.Regexp
+	// Validate reports whether a candidate matched by Pattern is really PII.
+	// Optional: if nil, every match is PII.
+	Validate func(match string) bool
+}
+
+// Email detects email addresses.
+var Email = Detector{
+	Kind:    "EMAIL",
+	Pattern: regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}`),
+}
+
+// Phone detects phone numbers in the E.164 format, e.g. "+14155552671".
+var Phone = Detector{
+	Kind:    "PHONE",
+	Pattern: regexp.MustCompile(`\+[1-9][0-9]{7,14}\b`),
+}
+
+// CreditCard detects payment card numbers, optionally grouped by spaces or
+// dashes, that pass the Luhn checksum.
+var CreditCard = Detector{
+	Kind:     "CARD",
+	Pattern:  regexp.MustCompile(`\b[0-9](?:[ -]?[0-9]){12,18}\b`),
+	Validate: luhnValid,
+}
+
+// IBAN detects international bank account numbers that pass the ISO 13616
+// mod-97 checksum.
+var IBAN = Detector{
+	Kind:     "IBAN",
+	Pattern:  regexp.MustCompile(`\b[A-Z]{2}[0-9]{2}(?: ?[A-Z0-9]){11,30}\b`),
+	Validate: ibanValid,
+}
+
+// NationalID detects US social security numbers in the "123-45-6789" form.
+var NationalID = Detector{
+	Kind:     "NATIONAL_ID",
+	Pattern:  regexp.MustCompile(`\b[0-9]{3}-[0-9]{2}-[0-9]{4}\b`),
+	Validate: ssnValid,
+}
+
+// DefaultDetectors returns the built-in detectors.
+func DefaultDetectors() []Detector {
+	return []Detector{Email, Phone, CreditCard, IBAN, NationalID}
+}
+
+// match is a PII value found in a text.
+type match struct {
+	start, end int
+	kind       string
+}
+
+// findAll returns the non-overlapping PII values found in text, ordered by
+// position. When matches of several detectors overlap, the one of the
+// detector listed first wins.
+func findAll(detectors []Detector, text string) []match {
+	var matches []match
+	for _, d := range detectors {
+		for _, loc := range d.Pattern.FindAllStringIndex(text, -1) {
+			if d.Validate != nil && !d.Validate(text[loc[0]:loc[1]]) {
+				continue
+			}
+			overlaps := slices.ContainsFunc(matches, func(m match) bool {
+				return loc[0] < m.end && m.start < loc[1]
+			})
+			if !overlaps {
+				matches = append(matches, match{start: loc[0], end: loc[1], kind: d.Kind})
+			}
+		}
+	}
+	slices.SortFunc(matches, func(a, b match) int { return a.start - b.start })
+	return matches
+}
+
+func luhnValid(s string) bool {
+	digits := stripSeparators(s)
+	if len(digits) < 13 || len(digits) > 19 {
+		return false
+	}
+	sum := 0
+	double := false
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+func ibanValid(s string) bool {
+	iban := stripSeparators(s)
+	if len(iban) < 15 || len(iban) > 34 {
+		return false
+	}
+	// Move the country code and check digits to the end and convert letters
+	// to numbers (A=10, ..., Z=35).
+	var sb strings.Builder
+	for _, r := range iban[4:] + iban[:4] {
+		switch {
+		case r >= '0' && r <= '9':
+			sb.WriteRune(r)
+		case r >= 'A' && r <= 'Z':
+			sb.WriteString(big.NewInt(int64(r-'A') + 10).String())
+		default:
+			return false
+		}
+	}
+	n, ok := new(big.Int).SetString(sb.String(), 10)
+	if !ok {
+		return false
+	}
+	return new(big.Int).Mod(n, big.NewInt(97)).Int64() == 1
+}
+
+func ssnValid(s string) bool {
+	area, group, serial := s[0:3], s[4:6], s[7:11]
+	if area == "000" || area == "666" || area[0] == '9' {
+		return false
+	}
+	return group != "00" && serial != "0000"
+}
+
+func stripSeparators(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == ' ' || r == '-' {
+			return -1
+		}
+		return r
+	}, s)
+}
@@ -0,0 +1,351 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package piimask masks personally identifiable information (PII) in the
+// requests sent to a model.
+//
+// A [Masker] is installed on an agent with its BeforeModel and AfterModel
+// callbacks:
+//
+//	m, err := piimask.New(piimask.Config{MaskToolResults: true})
+//	...
+//	a, err := llmagent.New(llmagent.Config{
+//		...
+//		BeforeModelCallbacks: []llmagent.BeforeModelCallback{m.BeforeModel},
+//		AfterModelCallbacks:  []llmagent.AfterModelCallback{m.AfterModel},
+//	})
+//
+// With [StrategyTokenize], every detected value is replaced by a token like
+// "[EMAIL_1]" before the request leaves the process, and the token to value
+// map is kept in the session state. AfterModel replaces the tokens found in
+// the model response, including the arguments of function calls, with the
+// original values, so the user and the tools see the real data.
+package piimask
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/session"
+)
+
+// StateKey is the session state key holding the token to value map.
+const StateKey = "piimask:tokens"
+
+// Strategy decides how detected values are masked.
+type Strategy int
+
+const (
+	// StrategyTokenize replaces every value with a deterministic token that
+	// can be un-masked in the model response.
+	StrategyTokenize Strategy = iota
+	// StrategyRedact irreversibly replaces every value with a placeholder
+	// naming its kind, e.g. "[REDACTED_EMAIL]".
+	StrategyRedact
+)
+
+// Config is the configuration of a [Masker].
+type Config struct {
+	// Detectors find the values to mask.
+	// Optional: if nil, DefaultDetectors() is used.
+	Detectors []Detector
+	// Strategy decides how detected values are masked.
+	Strategy Strategy
+	// MaskToolResults masks the function responses sent to the model too.
+	// Otherwise only the text and the function call arguments of the
+	// conversation are masked.
+	MaskToolResults bool
+	// ExemptTools lists the tools whose function calls and responses are
+	// never masked.
+	ExemptTools []string
+	// ExemptFields lists argument and result fields, at any depth, whose
+	// values are never masked.
+	ExemptFields []string
+	// UnmaskToolArgs decides which tools receive the original values in their
+	// arguments. The others receive the tokens.
+	// Optional: if nil, all tools receive the original values.
+	UnmaskToolArgs func(toolName string) bool
+}
+
+// Masker masks PII in model requests and un-masks it in model responses.
+type Masker struct {
+	detectors      []Detector
+	strategy       Strategy
+	maskResults    bool
+	exemptTools    map[string]bool
+	exemptFields   map[string]bool
+	unmaskToolArgs func(toolName string) bool
+}
+
+// New creates a [Masker] from the given config.
+func New(cfg Config) (*Masker, error) {
+	detectors := cfg.Detectors
+	if detectors == nil {
+		detectors = DefaultDetectors()
+	}
+	for _, d := range detectors {
+		if d.Kind == "" || d.Pattern == nil {
+			return nil, errors.New("piimask: detector Kind and Pattern must be set")
+		}
+	}
+	m := &Masker{
+		detectors:      detectors,
+		strategy:       cfg.Strategy,
+		maskResults:    cfg.MaskToolResults,
+		exemptTools:    make(map[string]bool),
+		exemptFields:   make(map[string]bool),
+		unmaskToolArgs: cfg.UnmaskToolArgs,
+	}
+	for _, name := range cfg.ExemptTools {
+		m.exemptTools[name] = true
+	}
+	for _, name := range cfg.ExemptFields {
+		m.exemptFields[name] = true
+	}
+	return m, nil
+}
+
+// BeforeModel masks the PII in the request contents. It has the signature of
+// llmagent.BeforeModelCallback.
+func (m *Masker) BeforeModel(ctx agent.CallbackContext, req *model.LLMRequest) (*model.LLMResponse, error) {
+	tokens, err := loadTokens(ctx.State())
+	if err != nil {
+		return nil, err
+	}
+	before := len(tokens)
+
+	for _, content := range req.Contents {
+		if content == nil {
+			continue
+		}
+		for _, part := range content.Parts {
+			m.maskPart(part, tokens)
+		}
+	}
+
+	if m.strategy == StrategyTokenize && len(tokens) != before {
+		state := make(map[string]any, len(tokens))
+		for token, value := range tokens {
+			state[token] = value
+		}
+		if err := ctx.State().Set(StateKey, state); err != nil {
+			return nil, fmt.Errorf("piimask: failed to save tokens: %w", err)
+		}
+	}
+	return nil, nil
+}
+
+// AfterModel replaces the tokens in the model response with the original
+// values. It has the signature of llmagent.AfterModelCallback.
+//
+// A token split across two partial streaming responses is not un-masked in
+// the partial responses, only in the final aggregated one.
+func (m *Masker) AfterModel(ctx agent.CallbackContext, resp *model.LLMResponse, respErr error) (*model.LLMResponse, error) {
+	if respErr != nil || resp == nil || resp.Content == nil || m.strategy != StrategyTokenize {
+		return nil, nil
+	}
+	tokens, err := loadTokens(ctx.ReadonlyState())
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+	oldnew := make([]string, 0, 2*len(tokens))
+	for _, token := range slices.Sorted(maps.Keys(tokens)) {
+		oldnew = append(oldnew, token, tokens[token])
+	}
+	r := strings.NewReplacer(oldnew...)
+
+	changed := false
+	parts := make([]*genai.Part, 0, len(resp.Content.Parts))
+	for _, part := range resp.Content.Parts {
+		if part == nil {
+			parts = append(parts, part)
+			continue
+		}
+		switch {
+		case part.Text != "":
+			if text := r.Replace(part.Text); text != part.Text {
+				p := *part
+				p.Text = text
+				part = &p
+				changed = true
+			}
+		case part.FunctionCall != nil:
+			fc := part.FunctionCall
+			if m.unmaskToolArgs != nil && !m.unmaskToolArgs(fc.Name) {
+				break
+			}
+			replaced := false
+			replace := func(s string) string {
+				out := r.Replace(s)
+				replaced = replaced || out != s
+				return out
+			}
+			args, ok := walkStrings(fc.Args, replace, nil).(map[string]any)
+			if ok && replaced {
+				newFC := *fc
+				newFC.Args = args
+				p := *part
+				p.FunctionCall = &newFC
+				part = &p
+				changed = true
+			}
+		}
+		parts = append(parts, part)
+	}
+	if !changed {
+		return nil, nil
+	}
+	newResp := *resp
+	newContent := *resp.Content
+	newContent.Parts = parts
+	newResp.Content = &newContent
+	return &newResp, nil
+}
+
+func (m *Masker) maskPart(part *genai.Part, tokens map[string]string) {
+	if part == nil {
+		return
+	}
+	mask := func(s string) string { return m.maskText(s, tokens) }
+	switch {
+	case part.Text != "":
+		part.Text = mask(part.Text)
+	case part.FunctionCall != nil:
+		if m.exemptTools[part.FunctionCall.Name] {
+			return
+		}
+		if args, ok := walkStrings(part.FunctionCall.Args, mask, m.exemptFields).(map[string]any); ok {
+			part.FunctionCall.Args = args
+		}
+	case part.FunctionResponse != nil:
+		if !m.maskResults || m.exemptTools[part.FunctionResponse.Name] {
+			return
+		}
+		if resp, ok := walkStrings(part.FunctionResponse.Response, mask, m.exemptFields).(map[string]any); ok {
+			part.FunctionResponse.Response = resp
+		}
+	}
+}
+
+// maskText replaces every PII value in text. New tokens are added to tokens.
+func (m *Masker) maskText(text string, tokens map[string]string) string {
+	matches := findAll(m.detectors, text)
+	if len(matches) == 0 {
+		return text
+	}
+	var sb strings.Builder
+	last := 0
+	for _, match := range matches {
+		sb.WriteString(text[last:match.start])
+		sb.WriteString(m.token(match.kind, text[match.start:match.end], tokens))
+		last = match.end
+	}
+	sb.WriteString(text[last:])
+	return sb.String()
+}
+
+// token returns the token for the given value. The same value always gets
+// the same token within a session.
+func (m *Masker) token(kind, value string, tokens map[string]string) string {
+	if m.strategy == StrategyRedact {
+		return "[REDACTED_" + kind + "]"
+	}
+	n := 0
+	prefix := "[" + kind + "_"
+	for token, v := range tokens {
+		if v == value && strings.HasPrefix(token, prefix) {
+			return token
+		}
+		if strings.HasPrefix(token, prefix) {
+			n++
+		}
+	}
+	token := fmt.Sprintf("%s%d]", prefix, n+1)
+	tokens[token] = value
+	return token
+}
+
+// loadTokens returns a copy of the token to value map stored in the state.
+func loadTokens(state session.ReadonlyState) (map[string]string, error) {
+	tokens := make(map[string]string)
+	v, err := state.Get(StateKey)
+	if errors.Is(err, session.ErrStateKeyNotExist) {
+		return tokens, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("piimask: failed to load tokens: %w", err)
+	}
+	switch v := v.(type) {
+	case map[string]any:
+		for token, value := range v {
+			if s, ok := value.(string); ok {
+				tokens[token] = s
+			}
+		}
+	case map[string]string:
+		maps.Copy(tokens, v)
+	default:
+		return nil, fmt.Errorf("piimask: unexpected type %T for state key %q", v, StateKey)
+	}
+	return tokens, nil
+}
+
+// walkStrings returns a copy of v with fn applied to every string value.
+// Values of map fields named in skip are copied unchanged.
+func walkStrings(v any, fn func(string) string, skip map[string]bool) any {
+	switch v := v.(type) {
+	case string:
+		return fn(v)
+	case map[string]any:
+		if v == nil {
+			return v
+		}
+		out := make(map[string]any, len(v))
+		for k, val := range v {
+			if skip[k] {
+				out[k] = val
+				continue
+			}
+			out[k] = walkStrings(val, fn, skip)
+		}
+		return out
+	case []any:
+		if v == nil {
+			return v
+		}
+		out := make([]any, len(v))
+		for i, val := range v {
+			out[i] = walkStrings(val, fn, skip)
+		}
+		return out
+	case []string:
+		out := make([]string, len(v))
+		for i, val := range v {
+			out[i] = fn(val)
+		}
+		return out
+	default:
+		return v
+	}
+}
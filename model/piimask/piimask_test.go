@@ -0,0 +1,266 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package piimask_test
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/internal/testutil"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/model/piimask"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+)
+
+func TestDetectors(t *testing.T) {
+	employeeID := piimask.Detector{
+		Kind:    "EMPLOYEE_ID",
+		Pattern: regexp.MustCompile(`\bEMP-[0-9]{6}\b`),
+	}
+
+	testCases := []struct {
+		name      string
+		detectors []piimask.Detector
+		input     string
+		want      string
+	}{
+		{
+			name:  "email",
+			input: "write to jane.doe+work@mail.example.co.uk today",
+			want:  "write to [EMAIL_1] today",
+		},
+		{
+			name:  "phone",
+			input: "call +14155552671 or 555-1234",
+			want:  "call [PHONE_1] or 555-1234",
+		},
+		{
+			name:  "luhn valid card",
+			input: "card 4111 1111 1111 1111 and 4111-1111-1111-1111",
+			want:  "card [CARD_1] and [CARD_2]",
+		},
+		{
+			name:  "luhn invalid card",
+			input: "order 4111 1111 1111 1112",
+			want:  "order 4111 1111 1111 1112",
+		},
+		{
+			name:  "iban",
+			input: "pay to DE89370400440532013000, not DE00370400440532013000",
+			want:  "pay to [IBAN_1], not DE00370400440532013000",
+		},
+		{
+			name:  "national id",
+			input: "ssn 123-45-6789, not 000-12-3456",
+			want:  "ssn [NATIONAL_ID_1], not 000-12-3456",
+		},
+		{
+			name:      "custom detector",
+			detectors: []piimask.Detector{employeeID},
+			input:     "employee EMP-123456 wrote to jane@example.com",
+			want:      "employee [EMPLOYEE_ID_1] wrote to jane@example.com",
+		},
+		{
+			name:  "same value same token",
+			input: "jane@example.com, john@example.com, jane@example.com",
+			want:  "[EMAIL_1], [EMAIL_2], [EMAIL_1]",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m, err := piimask.New(piimask.Config{Detectors: tc.detectors})
+			if err != nil {
+				t.Fatal(err)
+			}
+			testModel := &testutil.MockModel{Responses: []*genai.Content{genai.NewContentFromText("ok", genai.RoleModel)}}
+			runAgent(t, m, testModel, nil, tc.input)
+
+			if got := requestText(testModel.Requests[0]); got != tc.want {
+				t.Errorf("masked request = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestMasker_RoundTrip(t *testing.T) {
+	m, err := piimask.New(piimask.Config{MaskToolResults: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var gotTo string
+	sendEmail := newTool(t, "send_email", func(ctx tool.Context, args emailArgs) (map[string]string, error) {
+		gotTo = args.To
+		return map[string]string{"status": "sent to " + args.To}, nil
+	})
+	testModel := &testutil.MockModel{
+		Responses: []*genai.Content{
+			genai.NewContentFromFunctionCall("send_email", map[string]any{"to": "[EMAIL_1]"}, genai.RoleModel),
+			genai.NewContentFromText("Done, I wrote to [EMAIL_1].", genai.RoleModel),
+		},
+	}
+
+	events := runAgent(t, m, testModel, []tool.Tool{sendEmail}, "Please email jane@example.com")
+
+	if gotTo != "jane@example.com" {
+		t.Errorf("tool received %q, want the un-masked email", gotTo)
+	}
+	if got, want := events[len(events)-1].Content.Parts[0].Text, "Done, I wrote to jane@example.com."; got != want {
+		t.Errorf("final response = %q, want %q", got, want)
+	}
+	for i, req := range testModel.Requests {
+		if b, _ := json.Marshal(req.Contents); strings.Contains(string(b), "jane@example.com") {
+			t.Errorf("request %d leaked the email: %s", i, b)
+		}
+	}
+	wantResult := map[string]any{"status": "sent to [EMAIL_1]"}
+	if diff := cmp.Diff(wantResult, functionResponse(testModel.Requests[1])); diff != "" {
+		t.Errorf("tool result sent to model mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestMasker_Exemptions(t *testing.T) {
+	testCases := []struct {
+		name       string
+		cfg        piimask.Config
+		wantTo     string
+		wantResult map[string]any
+	}{
+		{
+			name:       "tool results not masked",
+			cfg:        piimask.Config{},
+			wantTo:     "jane@example.com",
+			wantResult: map[string]any{"status": "sent to jane@example.com", "cc": "john@example.com"},
+		},
+		{
+			name:       "exempt tool",
+			cfg:        piimask.Config{MaskToolResults: true, ExemptTools: []string{"send_email"}},
+			wantTo:     "jane@example.com",
+			wantResult: map[string]any{"status": "sent to jane@example.com", "cc": "john@example.com"},
+		},
+		{
+			name:       "exempt field",
+			cfg:        piimask.Config{MaskToolResults: true, ExemptFields: []string{"cc"}},
+			wantTo:     "jane@example.com",
+			wantResult: map[string]any{"status": "sent to [EMAIL_1]", "cc": "john@example.com"},
+		},
+		{
+			name: "tool not allowed to see values",
+			cfg: piimask.Config{MaskToolResults: true, UnmaskToolArgs: func(string) bool {
+				return false
+			}},
+			wantTo:     "[EMAIL_1]",
+			wantResult: map[string]any{"status": "sent to [EMAIL_1]", "cc": "[EMAIL_2]"},
+		},
+		{
+			name:       "redaction",
+			cfg:        piimask.Config{MaskToolResults: true, Strategy: piimask.StrategyRedact},
+			wantTo:     "[EMAIL_1]",
+			wantResult: map[string]any{"status": "sent to [EMAIL_1]", "cc": "[REDACTED_EMAIL]"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m, err := piimask.New(tc.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var gotTo string
+			sendEmail := newTool(t, "send_email", func(ctx tool.Context, args emailArgs) (map[string]string, error) {
+				gotTo = args.To
+				return map[string]string{"status": "sent to " + args.To, "cc": "john@example.com"}, nil
+			})
+			testModel := &testutil.MockModel{
+				Responses: []*genai.Content{
+					genai.NewContentFromFunctionCall("send_email", map[string]any{"to": "[EMAIL_1]"}, genai.RoleModel),
+					genai.NewContentFromText("Done.", genai.RoleModel),
+				},
+			}
+
+			runAgent(t, m, testModel, []tool.Tool{sendEmail}, "Please email jane@example.com")
+
+			if gotTo != tc.wantTo {
+				t.Errorf("tool received %q, want %q", gotTo, tc.wantTo)
+			}
+			if diff := cmp.Diff(tc.wantResult, functionResponse(testModel.Requests[1])); diff != "" {
+				t.Errorf("tool result sent to model mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+type emailArgs struct {
+	To string `json:"to"`
+}
+
+func newTool(t *testing.T, name string, fn functiontool.Func[emailArgs, map[string]string]) tool.Tool {
+	t.Helper()
+	tl, err := functiontool.New(functiontool.Config{Name: name, Description: name}, fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tl
+}
+
+func runAgent(t *testing.T, m *piimask.Masker, testModel model.LLM, tools []tool.Tool, input string) []*session.Event {
+	t.Helper()
+	a, err := llmagent.New(llmagent.Config{
+		Name:                 "agent",
+		Model:                testModel,
+		Tools:                tools,
+		BeforeModelCallbacks: []llmagent.BeforeModelCallback{m.BeforeModel},
+		AfterModelCallbacks:  []llmagent.AfterModelCallback{m.AfterModel},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	runner := testutil.NewTestAgentRunner(t, a)
+	events, err := testutil.CollectEvents(runner.Run(t, "session", input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return events
+}
+
+func requestText(req *model.LLMRequest) string {
+	var texts []string
+	for _, c := range req.Contents {
+		for _, p := range c.Parts {
+			if p.Text != "" {
+				texts = append(texts, p.Text)
+			}
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+func functionResponse(req *model.LLMRequest) map[string]any {
+	for _, c := range req.Contents {
+		for _, p := range c.Parts {
+			if p.FunctionResponse != nil {
+				return p.FunctionResponse.Response
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,259 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"sync"
+	"time"
+
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/runtimedeps"
+)
+
+// RateLimitConfig configures [WithRateLimit]. The zero values of the limits
+// disable them.
+type RateLimitConfig struct {
+	// RequestsPerMinute limits the rate of the calls.
+	RequestsPerMinute int
+	// TokensPerMinute limits the rate of the tokens of the calls, their
+	// prompt and their output. The tokens of a call are estimated before it
+	// by EstimateTokens, and corrected once it returned its usage.
+	TokensPerMinute int
+	// MaxConcurrent limits the number of calls in progress, i.e. whose
+	// responses are still being consumed.
+	MaxConcurrent int
+	// EstimateTokens estimates the tokens of a call of req. Defaults to
+	// EstimateRequestTokens.
+	EstimateTokens func(req *LLMRequest) int
+	// Clock tells the time and waits for the budget. Defaults to the real
+	// clock.
+	Clock runtimedeps.Clock
+}
+
+// EstimateRequestTokens estimates the tokens of a call of req: its texts,
+// counted as one token per 4 bytes, and the maximum number of output tokens
+// of its config.
+func EstimateRequestTokens(req *LLMRequest) int {
+	n := 0
+	for _, content := range req.Contents {
+		n += textLength(content)
+	}
+	if req.Config != nil {
+		n += textLength(req.Config.SystemInstruction)
+	}
+	n = (n + 3) / 4
+	if req.Config != nil {
+		n += int(req.Config.MaxOutputTokens)
+	}
+	return n
+}
+
+// textLength returns the length of the texts of content.
+func textLength(content *genai.Content) int {
+	if content == nil {
+		return 0
+	}
+	n := 0
+	for _, part := range content.Parts {
+		if part != nil {
+			n += len(part.Text)
+		}
+	}
+	return n
+}
+
+// RateLimitUtilization is the use of the budget of a [RateLimitedModel].
+// The fractions exceed 1 when the calls used more tokens than available.
+type RateLimitUtilization struct {
+	// Requests is the fraction of the request budget in use.
+	Requests float64
+	// Tokens is the fraction of the token budget in use.
+	Tokens float64
+	// Concurrent is the number of calls in progress.
+	Concurrent int
+}
+
+// RateLimitedModel is a model whose calls are throttled to stay within a
+// budget.
+type RateLimitedModel struct {
+	llm      LLM
+	clock    runtimedeps.Clock
+	estimate func(req *LLMRequest) int
+	// requests and tokens are nil for the budgets not limited.
+	requests *tokenBucket
+	tokens   *tokenBucket
+	// slots holds a value per call in progress; nil if not limited.
+	slots chan struct{}
+}
+
+var _ LLM = (*RateLimitedModel)(nil)
+
+// WithRateLimit returns llm with its calls throttled by cfg. The calls wait,
+// until their context is done, for the budget to be available: the budgets
+// are token buckets refilled continuously, holding a minute of budget. All
+// the calls of the returned model share the budget, e.g. those of the agents
+// sharing it.
+func WithRateLimit(llm LLM, cfg RateLimitConfig) *RateLimitedModel {
+	m := &RateLimitedModel{llm: llm, clock: cfg.Clock, estimate: cfg.EstimateTokens}
+	if m.clock == nil {
+		m.clock = runtimedeps.RealClock{}
+	}
+	if m.estimate == nil {
+		m.estimate = EstimateRequestTokens
+	}
+	if cfg.RequestsPerMinute > 0 {
+		m.requests = newTokenBucket(cfg.RequestsPerMinute)
+	}
+	if cfg.TokensPerMinute > 0 {
+		m.tokens = newTokenBucket(cfg.TokensPerMinute)
+	}
+	if cfg.MaxConcurrent > 0 {
+		m.slots = make(chan struct{}, cfg.MaxConcurrent)
+	}
+	return m
+}
+
+// Name implements LLM.
+func (m *RateLimitedModel) Name() string {
+	return m.llm.Name()
+}
+
+// GenerateContent implements LLM. The call fails with the error of its
+// context if it is done before the budget is available.
+func (m *RateLimitedModel) GenerateContent(ctx context.Context, req *LLMRequest, stream bool) iter.Seq2[*LLMResponse, error] {
+	return func(yield func(*LLMResponse, error) bool) {
+		if m.slots != nil {
+			select {
+			case m.slots <- struct{}{}:
+				defer func() { <-m.slots }()
+			case <-ctx.Done():
+				yield(nil, fmt.Errorf("waiting for the rate limit: %w", ctx.Err()))
+				return
+			}
+		}
+		if err := m.requests.take(ctx, m.clock, 1); err != nil {
+			yield(nil, fmt.Errorf("waiting for the rate limit: %w", err))
+			return
+		}
+		estimate := float64(m.estimate(req))
+		if err := m.tokens.take(ctx, m.clock, estimate); err != nil {
+			m.requests.give(m.clock, 1)
+			yield(nil, fmt.Errorf("waiting for the rate limit: %w", err))
+			return
+		}
+
+		var usage *genai.GenerateContentResponseUsageMetadata
+		defer func() {
+			if usage != nil && usage.TotalTokenCount > 0 {
+				m.tokens.give(m.clock, estimate-float64(usage.TotalTokenCount))
+			}
+		}()
+		for resp, err := range m.llm.GenerateContent(ctx, req, stream) {
+			if resp != nil && resp.UsageMetadata != nil {
+				usage = resp.UsageMetadata
+			}
+			if !yield(resp, err) {
+				return
+			}
+		}
+	}
+}
+
+// Utilization returns the current use of the budget.
+func (m *RateLimitedModel) Utilization() RateLimitUtilization {
+	now := m.clock.Now()
+	return RateLimitUtilization{
+		Requests:   m.requests.utilization(now),
+		Tokens:     m.tokens.utilization(now),
+		Concurrent: len(m.slots),
+	}
+}
+
+// tokenBucket is a token bucket holding a minute of budget, refilled
+// continuously. Its methods do nothing on a nil bucket.
+type tokenBucket struct {
+	mu       sync.Mutex
+	capacity float64
+	// tokens is negative when the calls used more than their estimate.
+	tokens float64
+	// last is when the tokens were last refilled, zero before the first
+	// use.
+	last time.Time
+}
+
+func newTokenBucket(perMinute int) *tokenBucket {
+	return &tokenBucket{capacity: float64(perMinute), tokens: float64(perMinute)}
+}
+
+// refill adds the tokens accumulated until now. b.mu must be held.
+func (b *tokenBucket) refill(now time.Time) {
+	if !b.last.IsZero() && now.After(b.last) {
+		b.tokens = min(b.capacity, b.tokens+b.capacity*now.Sub(b.last).Minutes())
+	}
+	if b.last.IsZero() || now.After(b.last) {
+		b.last = now
+	}
+}
+
+// take waits until n tokens are available, and takes them. The calls
+// needing more than the capacity wait for a full bucket.
+func (b *tokenBucket) take(ctx context.Context, clock runtimedeps.Clock, n float64) error {
+	if b == nil {
+		return nil
+	}
+	n = min(n, b.capacity)
+	for {
+		b.mu.Lock()
+		b.refill(clock.Now())
+		missing := n - b.tokens
+		// The refills are not exact.
+		if missing <= 1e-9 {
+			b.tokens -= n
+			b.mu.Unlock()
+			return nil
+		}
+		b.mu.Unlock()
+		wait := time.Duration(missing / b.capacity * float64(time.Minute))
+		if err := clock.Sleep(ctx, max(wait, time.Millisecond)); err != nil {
+			return err
+		}
+	}
+}
+
+// give gives n tokens back, or takes them if n is negative.
+func (b *tokenBucket) give(clock runtimedeps.Clock, n float64) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill(clock.Now())
+	b.tokens = min(b.capacity, b.tokens+n)
+}
+
+// utilization returns the fraction of the bucket used at now.
+func (b *tokenBucket) utilization(now time.Time) float64 {
+	if b == nil {
+		return 0
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill(now)
+	return 1 - b.tokens/b.capacity
+}
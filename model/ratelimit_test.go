@@ -0,0 +1,161 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model_test
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"math"
+	"testing"
+	"time"
+
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/runtimedeps"
+)
+
+// usageLLM is a model whose calls use tokens tokens.
+type usageLLM struct {
+	tokens int32
+	calls  int
+}
+
+func (m *usageLLM) Name() string { return "usage" }
+
+func (m *usageLLM) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	m.calls++
+	return func(yield func(*model.LLMResponse, error) bool) {
+		yield(&model.LLMResponse{
+			Content:       genai.NewContentFromText("ok", genai.RoleModel),
+			UsageMetadata: &genai.GenerateContentResponseUsageMetadata{TotalTokenCount: m.tokens},
+		}, nil)
+	}
+}
+
+func call(ctx context.Context, llm model.LLM) error {
+	for _, err := range llm.GenerateContent(ctx, &model.LLMRequest{}, false) {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func approx(a, b float64) bool {
+	return math.Abs(a-b) < 1e-6
+}
+
+func TestWithRateLimit_Requests(t *testing.T) {
+	clock := runtimedeps.NewFrozenClock(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	start := clock.Now()
+	llm := model.WithRateLimit(&usageLLM{}, model.RateLimitConfig{RequestsPerMinute: 2, Clock: clock})
+
+	for range 2 {
+		if err := call(t.Context(), llm); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := llm.Utilization().Requests; !approx(got, 1) {
+		t.Errorf("Utilization().Requests = %v, want 1", got)
+	}
+	if clock.Now() != start {
+		t.Errorf("waited %v for the calls within the budget", clock.Now().Sub(start))
+	}
+	// The third call waits for a request to be refilled.
+	if err := call(t.Context(), llm); err != nil {
+		t.Fatal(err)
+	}
+	if got := clock.Now().Sub(start); got < 30*time.Second || got > 30*time.Second+time.Millisecond {
+		t.Errorf("waited %v, want 30s", got)
+	}
+}
+
+func TestWithRateLimit_Tokens(t *testing.T) {
+	clock := runtimedeps.NewFrozenClock(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	start := clock.Now()
+	inner := &usageLLM{tokens: 150}
+	llm := model.WithRateLimit(inner, model.RateLimitConfig{
+		TokensPerMinute: 200,
+		EstimateTokens:  func(*model.LLMRequest) int { return 100 },
+		Clock:           clock,
+	})
+
+	// The first call is estimated to use 100 tokens, and reconciled to 150.
+	if err := call(t.Context(), llm); err != nil {
+		t.Fatal(err)
+	}
+	if got := llm.Utilization().Tokens; !approx(got, 0.75) {
+		t.Errorf("Utilization().Tokens = %v, want 0.75 once reconciled with the usage", got)
+	}
+	// The second call waits for 50 tokens, 15 seconds.
+	if err := call(t.Context(), llm); err != nil {
+		t.Fatal(err)
+	}
+	if got := clock.Now().Sub(start); got < 15*time.Second || got > 15*time.Second+time.Millisecond {
+		t.Errorf("waited %v, want 15s", got)
+	}
+}
+
+func TestWithRateLimit_Canceled(t *testing.T) {
+	inner := &usageLLM{}
+	llm := model.WithRateLimit(inner, model.RateLimitConfig{RequestsPerMinute: 1})
+	if err := call(t.Context(), llm); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := call(ctx, llm)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("GenerateContent() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("GenerateContent() returned after %v, want the wait to end with the context", elapsed)
+	}
+	if inner.calls != 1 {
+		t.Errorf("model called %d times, want 1", inner.calls)
+	}
+	// The canceled call did not use the budget.
+	if got := llm.Utilization().Requests; got > 1 {
+		t.Errorf("Utilization().Requests = %v, want at most 1", got)
+	}
+}
+
+func TestWithRateLimit_MaxConcurrent(t *testing.T) {
+	llm := model.WithRateLimit(&usageLLM{}, model.RateLimitConfig{MaxConcurrent: 1})
+
+	// The first call is in progress until its responses are consumed.
+	next, stop := iter.Pull2(llm.GenerateContent(t.Context(), &model.LLMRequest{}, true))
+	if _, err, ok := next(); !ok || err != nil {
+		t.Fatalf("first call: ok = %t, error = %v", ok, err)
+	}
+	if got := llm.Utilization().Concurrent; got != 1 {
+		t.Errorf("Utilization().Concurrent = %d, want 1", got)
+	}
+
+	ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+	defer cancel()
+	if err := call(ctx, llm); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("second call error = %v, want %v while the first one is in progress", err, context.DeadlineExceeded)
+	}
+
+	stop()
+	if err := call(t.Context(), llm); err != nil {
+		t.Errorf("third call failed once the first one ended: %v", err)
+	}
+}
@@ -0,0 +1,387 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"iter"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+
+	"google.golang.org/genai"
+)
+
+// RecorderMode is the mode of a [Recorder].
+type RecorderMode int
+
+const (
+	// RecorderReplay serves the calls from the cassette, without calling
+	// the model.
+	RecorderReplay RecorderMode = iota
+	// RecorderRecord sends the calls to the model, and records them in the
+	// cassette, replacing its previous content.
+	RecorderRecord
+)
+
+// RecorderMatch is how a [Recorder] in replay mode matches the requests with
+// the recorded ones.
+type RecorderMatch int
+
+const (
+	// MatchExact matches the requests with the same model, contents and
+	// config.
+	MatchExact RecorderMatch = iota
+	// MatchContents matches the requests with the same contents, whatever
+	// their model and config, e.g. so that editing the description of a tool
+	// does not require recording again.
+	MatchContents
+)
+
+// RecorderConfig configures [NewRecorder].
+type RecorderConfig struct {
+	// Mode is the mode of the recorder.
+	Mode RecorderMode
+	// Path is the path of the cassette, a JSON file.
+	Path string
+	// Match is how the requests are matched in replay mode.
+	Match RecorderMatch
+}
+
+// Recorder is a model recording the calls of another model in a cassette,
+// and replaying them, so that the agents can be tested end-to-end without
+// calling the model, e.g. in CI.
+//
+// In record mode, every call is sent to the model, and its request, its
+// responses, e.g. the partial responses of a stream, and its error are
+// written to the cassette once it ended. In replay mode, a call is served
+// the responses and the error of the first recorded call not replayed yet
+// whose request matches its own, and whose streaming is the same. A call
+// matching none fails with a [*ReplayMismatchError] describing how its
+// request differs from the next recorded one.
+type Recorder struct {
+	llm LLM
+	cfg RecorderConfig
+
+	mu       sync.Mutex
+	cassette cassette
+	// replayed tells which recorded calls were replayed.
+	replayed []bool
+}
+
+var _ LLM = (*Recorder)(nil)
+
+type cassette struct {
+	Calls []*recordedCall `json:"calls"`
+}
+
+type recordedCall struct {
+	Request   *recordedRequest `json:"request"`
+	Stream    bool             `json:"stream,omitempty"`
+	Responses []*LLMResponse   `json:"responses"`
+	// Error is the message of the error of the call, if it failed, and
+	// ErrorCode its code.
+	Error     string    `json:"error,omitempty"`
+	ErrorCode ErrorCode `json:"errorCode,omitempty"`
+}
+
+type recordedRequest struct {
+	Model    string                       `json:"model,omitempty"`
+	Contents []*genai.Content             `json:"contents"`
+	Config   *genai.GenerateContentConfig `json:"config,omitempty"`
+}
+
+// NewRecorder returns a recorder of llm. In replay mode, the cassette is
+// read, and llm is not used: it may be nil.
+func NewRecorder(llm LLM, cfg RecorderConfig) (*Recorder, error) {
+	r := &Recorder{llm: llm, cfg: cfg}
+	switch cfg.Mode {
+	case RecorderRecord:
+		if llm == nil {
+			return nil, errors.New("a model is required to record")
+		}
+		if err := r.save(); err != nil {
+			return nil, err
+		}
+	case RecorderReplay:
+		b, err := os.ReadFile(cfg.Path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the cassette: %w", err)
+		}
+		if err := json.Unmarshal(b, &r.cassette); err != nil {
+			return nil, fmt.Errorf("failed to decode the cassette %s: %w", cfg.Path, err)
+		}
+		r.replayed = make([]bool, len(r.cassette.Calls))
+	default:
+		return nil, fmt.Errorf("unknown recorder mode %d", cfg.Mode)
+	}
+	return r, nil
+}
+
+// Name implements LLM. It is the name of the model recorded, or "replay" in
+// replay mode without a model.
+func (r *Recorder) Name() string {
+	if r.llm == nil {
+		return "replay"
+	}
+	return r.llm.Name()
+}
+
+// GenerateContent implements LLM.
+func (r *Recorder) GenerateContent(ctx context.Context, req *LLMRequest, stream bool) iter.Seq2[*LLMResponse, error] {
+	recorded := &recordedRequest{Model: req.Model, Contents: req.Contents, Config: req.Config}
+	if r.cfg.Mode == RecorderReplay {
+		return r.replay(recorded, stream)
+	}
+	return func(yield func(*LLMResponse, error) bool) {
+		// The request is encoded before the model can modify it.
+		b, err := json.Marshal(recorded)
+		if err != nil {
+			yield(nil, fmt.Errorf("failed to record the request: %w", err))
+			return
+		}
+		call := &recordedCall{Request: &recordedRequest{}, Stream: stream}
+		if err := json.Unmarshal(b, call.Request); err != nil {
+			yield(nil, fmt.Errorf("failed to record the request: %w", err))
+			return
+		}
+		stopped := false
+		for resp, err := range r.llm.GenerateContent(ctx, req, stream) {
+			if err != nil {
+				call.Error, call.ErrorCode = err.Error(), CodeOf(err)
+				var modelErr *Error
+				if errors.As(err, &modelErr) && modelErr.Err != nil {
+					// The code is recorded apart.
+					call.Error = modelErr.Err.Error()
+				}
+			} else if clone, cloneErr := cloneResponse(resp); cloneErr == nil {
+				call.Responses = append(call.Responses, clone)
+			}
+			if !yield(resp, err) {
+				stopped = true
+				break
+			}
+		}
+		r.mu.Lock()
+		r.cassette.Calls = append(r.cassette.Calls, call)
+		err = r.save()
+		r.mu.Unlock()
+		if err != nil && !stopped {
+			yield(nil, err)
+		}
+	}
+}
+
+// save writes the cassette. r.mu must be held, unless r is being created.
+func (r *Recorder) save() error {
+	b, err := json.MarshalIndent(r.cassette, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode the cassette: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(r.cfg.Path), 0o755); err != nil {
+		return fmt.Errorf("failed to write the cassette: %w", err)
+	}
+	if err := os.WriteFile(r.cfg.Path, append(b, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write the cassette: %w", err)
+	}
+	return nil
+}
+
+func (r *Recorder) replay(req *recordedRequest, stream bool) iter.Seq2[*LLMResponse, error] {
+	return func(yield func(*LLMResponse, error) bool) {
+		call, err := r.match(req, stream)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		for _, resp := range call.Responses {
+			// The responses are copied, so that the caller cannot modify
+			// the cassette.
+			clone, err := cloneResponse(resp)
+			if err != nil {
+				yield(nil, fmt.Errorf("failed to replay a response: %w", err))
+				return
+			}
+			if !yield(clone, nil) {
+				return
+			}
+		}
+		if call.Error != "" {
+			yield(nil, &Error{Code: cmp.Or(call.ErrorCode, ErrorCodeUnknown), Err: errors.New(call.Error)})
+		}
+	}
+}
+
+// match returns the first recorded call not replayed yet matching req and
+// stream, marking it replayed.
+func (r *Recorder) match(req *recordedRequest, stream bool) (*recordedCall, error) {
+	key, err := r.key(req)
+	if err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	next := -1
+	for i, call := range r.cassette.Calls {
+		if r.replayed[i] {
+			continue
+		}
+		if next < 0 {
+			next = i
+		}
+		if call.Stream != stream {
+			continue
+		}
+		if callKey, err := r.key(call.Request); err == nil && callKey == key {
+			r.replayed[i] = true
+			return call, nil
+		}
+	}
+	if next < 0 {
+		return nil, &ReplayMismatchError{Call: len(r.cassette.Calls), Diff: "no recorded call left to replay"}
+	}
+	diff := diffRequests(r.cassette.Calls[next].Request, req)
+	if r.cassette.Calls[next].Stream != stream {
+		diff = append(diff, fmt.Sprintf("- stream: %t", !stream), fmt.Sprintf("+ stream: %t", stream))
+	}
+	return nil, &ReplayMismatchError{Call: next, Diff: strings.Join(diff, "\n")}
+}
+
+// key returns the key matching req.
+func (r *Recorder) key(req *recordedRequest) (string, error) {
+	var v any = req
+	if r.cfg.Match == MatchContents {
+		v = req.Contents
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode the request: %w", err)
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// ReplayMismatchError is the error of a call of a [Recorder] in replay mode
+// whose request matches no recorded call.
+type ReplayMismatchError struct {
+	// Call is the index of the next recorded call, which the request was
+	// compared with.
+	Call int
+	// Diff describes the differences between the recorded request, on the
+	// lines starting with "-", and the request, on the lines starting with
+	// "+", by the path of their fields.
+	Diff string
+}
+
+// Error implements error.
+func (e *ReplayMismatchError) Error() string {
+	return fmt.Sprintf("request does not match recorded call %d (-recorded +got):\n%s", e.Call, e.Diff)
+}
+
+// diffRequests returns the lines describing the differences between the
+// recorded request want and got.
+func diffRequests(want, got *recordedRequest) []string {
+	var diff []string
+	diffJSON(&diff, "", jsonValue(want), jsonValue(got))
+	if len(diff) == 0 {
+		// The requests only differ in the fields ignored by the match.
+		diff = append(diff, "  (no difference in the encoded requests)")
+	}
+	return diff
+}
+
+// jsonValue returns v encoded and decoded as a generic JSON value.
+func jsonValue(v any) any {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("<%v>", err)
+	}
+	var decoded any
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		return fmt.Sprintf("<%v>", err)
+	}
+	return decoded
+}
+
+// diffJSON appends to diff the differences between the JSON values want and
+// got at path.
+func diffJSON(diff *[]string, path string, want, got any) {
+	switch w := want.(type) {
+	case map[string]any:
+		if g, ok := got.(map[string]any); ok {
+			keys := make([]string, 0, len(w)+len(g))
+			for k := range w {
+				keys = append(keys, k)
+			}
+			for k := range g {
+				if _, ok := w[k]; !ok {
+					keys = append(keys, k)
+				}
+			}
+			slices.Sort(keys)
+			for _, k := range keys {
+				diffJSON(diff, joinPath(path, k), w[k], g[k])
+			}
+			return
+		}
+	case []any:
+		if g, ok := got.([]any); ok {
+			for i := range max(len(w), len(g)) {
+				var wi, gi any
+				if i < len(w) {
+					wi = w[i]
+				}
+				if i < len(g) {
+					gi = g[i]
+				}
+				diffJSON(diff, fmt.Sprintf("%s[%d]", path, i), wi, gi)
+			}
+			return
+		}
+	}
+	if reflect.DeepEqual(want, got) {
+		return
+	}
+	if want != nil {
+		*diff = append(*diff, fmt.Sprintf("- %s: %s", cmp.Or(path, "request"), encodeJSON(want)))
+	}
+	if got != nil {
+		*diff = append(*diff, fmt.Sprintf("+ %s: %s", cmp.Or(path, "request"), encodeJSON(got)))
+	}
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func encodeJSON(v any) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
@@ -0,0 +1,144 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model_test
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/model"
+)
+
+func weatherRequest(city string) *model.LLMRequest {
+	return &model.LLMRequest{
+		Model:    "gemini-2.5-flash",
+		Contents: []*genai.Content{genai.NewContentFromText("weather in "+city+"?", genai.RoleUser)},
+		Config: &genai.GenerateContentConfig{
+			SystemInstruction: genai.NewContentFromText("be brief", ""),
+			Tools:             []*genai.Tool{{FunctionDeclarations: []*genai.FunctionDeclaration{{Name: "get_weather", Description: "returns the weather"}}}},
+		},
+	}
+}
+
+func TestRecorder_Replay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	inner := &fixedLLM{responses: []*model.LLMResponse{
+		{Content: genai.NewContentFromText("Sun", genai.RoleModel), Partial: true},
+		{Content: genai.NewContentFromText("ny", genai.RoleModel), Partial: true},
+		{Content: &genai.Content{Role: genai.RoleModel, Parts: []*genai.Part{{FunctionCall: &genai.FunctionCall{Name: "get_weather", Args: map[string]any{"city": "Paris"}}}}}, TurnComplete: true},
+	}}
+	recorder, err := model.NewRecorder(inner, model.RecorderConfig{Mode: model.RecorderRecord, Path: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := responses(t, t.Context(), recorder, weatherRequest("Paris"), true)
+	responses(t, t.Context(), recorder, weatherRequest("Paris"), false)
+
+	replayer, err := model.NewRecorder(nil, model.RecorderConfig{Path: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The calls are matched by their request and their streaming, not by
+	// their order.
+	if got := responses(t, t.Context(), replayer, weatherRequest("Paris"), false); len(got) != 3 {
+		t.Errorf("replayed %d responses for the unary call, want 3", len(got))
+	}
+	got := responses(t, t.Context(), replayer, weatherRequest("Paris"), true)
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("replayed responses mismatch (-want +got):\n%s", diff)
+	}
+	if inner.calls != 2 {
+		t.Errorf("model called %d times, want 2, when recording only", inner.calls)
+	}
+}
+
+func TestRecorder_ReplayError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	recorder, err := model.NewRecorder(&scriptedLLM{calls: []scriptedCall{{texts: []string{"hel"}, err: errUnavailable}}}, model.RecorderConfig{Mode: model.RecorderRecord, Path: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range recorder.GenerateContent(t.Context(), weatherRequest("Paris"), true) {
+	}
+
+	replayer, err := model.NewRecorder(nil, model.RecorderConfig{Path: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var texts []string
+	for resp, err := range replayer.GenerateContent(t.Context(), weatherRequest("Paris"), true) {
+		if err != nil {
+			if got := model.CodeOf(err); got != model.ErrorCodeUnavailable || err.Error() != errUnavailable.Error() {
+				t.Errorf("replayed error = %v (%s), want %v", err, got, errUnavailable)
+			}
+			continue
+		}
+		texts = append(texts, resp.Content.Parts[0].Text)
+	}
+	if len(texts) != 1 || texts[0] != "hel" {
+		t.Errorf("replayed texts = %q, want the partial text before the error", texts)
+	}
+}
+
+func TestRecorder_Mismatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	inner := &fixedLLM{responses: []*model.LLMResponse{{Content: genai.NewContentFromText("Sunny", genai.RoleModel)}}}
+	recorder, err := model.NewRecorder(inner, model.RecorderConfig{Mode: model.RecorderRecord, Path: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	responses(t, t.Context(), recorder, weatherRequest("Paris"), false)
+
+	edited := weatherRequest("Paris")
+	edited.Config.Tools[0].FunctionDeclarations[0].Description = "returns the forecast"
+
+	t.Run("Exact", func(t *testing.T) {
+		replayer, err := model.NewRecorder(nil, model.RecorderConfig{Path: path})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, err := range replayer.GenerateContent(t.Context(), edited, false) {
+			var mismatch *model.ReplayMismatchError
+			if !errors.As(err, &mismatch) {
+				t.Fatalf("GenerateContent() error = %v, want a ReplayMismatchError", err)
+			}
+			wantDiff := `- config.tools[0].functionDeclarations[0].description: "returns the weather"` + "\n" +
+				`+ config.tools[0].functionDeclarations[0].description: "returns the forecast"`
+			if mismatch.Call != 0 || mismatch.Diff != wantDiff {
+				t.Errorf("mismatch = call %d, diff:\n%s\nwant call 0, diff:\n%s", mismatch.Call, mismatch.Diff, wantDiff)
+			}
+		}
+	})
+	t.Run("Contents", func(t *testing.T) {
+		replayer, err := model.NewRecorder(nil, model.RecorderConfig{Path: path, Match: model.MatchContents})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := responses(t, t.Context(), replayer, edited, false); len(got) != 1 || got[0].Content.Parts[0].Text != "Sunny" {
+			t.Errorf("responses = %+v, want the recorded one", got)
+		}
+		// The recorded calls are only replayed once.
+		for _, err := range replayer.GenerateContent(t.Context(), edited, false) {
+			if err == nil || !strings.Contains(err.Error(), "no recorded call left") {
+				t.Errorf("GenerateContent() error = %v, want no recorded call left", err)
+			}
+		}
+	})
+}
@@ -0,0 +1,105 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Factory creates the model named name, matched by the pattern it was
+// registered with by [Register].
+type Factory func(ctx context.Context, name string) (LLM, error)
+
+type registration struct {
+	pattern string
+	re      *regexp.Regexp
+	factory Factory
+}
+
+// registry holds the factories, the latest registered last.
+var registry struct {
+	sync.RWMutex
+	list []registration
+}
+
+// Register registers factory for the model names matched by pattern, a
+// regular expression, e.g. `^gpt-|^o[0-9]`, so that [Resolve] returns the
+// models of these names. The packages of the providers register their
+// models with their RegisterDefaults function.
+//
+// The patterns registered last take precedence, so that an application can
+// override the factories registered by the providers. Registering a
+// pattern again replaces its factory, and gives it the precedence. Register
+// panics if pattern is not a valid regular expression.
+func Register(pattern string, factory Factory) {
+	re := regexp.MustCompile(pattern)
+	registry.Lock()
+	defer registry.Unlock()
+	registry.list = slices.DeleteFunc(registry.list, func(r registration) bool { return r.pattern == pattern })
+	registry.list = append(registry.list, registration{pattern: pattern, re: re, factory: factory})
+}
+
+// Unregister removes the factory registered for pattern, if any.
+func Unregister(pattern string) {
+	registry.Lock()
+	defer registry.Unlock()
+	registry.list = slices.DeleteFunc(registry.list, func(r registration) bool { return r.pattern == pattern })
+}
+
+// UnknownModelError is the error of [Resolve] for the names matched by no
+// registered pattern.
+type UnknownModelError struct {
+	Name string
+	// Patterns are the patterns registered, by decreasing precedence.
+	Patterns []string
+}
+
+// Error implements error.
+func (e *UnknownModelError) Error() string {
+	if len(e.Patterns) == 0 {
+		return fmt.Sprintf("unknown model %q: no model registered, see model.Register", e.Name)
+	}
+	return fmt.Sprintf("unknown model %q: it matches none of the registered patterns %s", e.Name, strings.Join(e.Patterns, ", "))
+}
+
+// Resolve returns the model name, created by the factory of the registered
+// pattern matching it with the highest precedence, see [Register]. It fails
+// with an [*UnknownModelError] if no pattern matches name, and with the
+// error of the factory if it fails.
+func Resolve(ctx context.Context, name string) (LLM, error) {
+	registry.RLock()
+	list := slices.Clone(registry.list)
+	registry.RUnlock()
+
+	for _, r := range slices.Backward(list) {
+		if r.re.MatchString(name) {
+			llm, err := r.factory(ctx, name)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create model %q: %w", name, err)
+			}
+			return llm, nil
+		}
+	}
+	patterns := make([]string, 0, len(list))
+	for _, r := range slices.Backward(list) {
+		patterns = append(patterns, r.pattern)
+	}
+	return nil, &UnknownModelError{Name: name, Patterns: patterns}
+}
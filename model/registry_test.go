@@ -0,0 +1,101 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"google.golang.org/adk/model"
+)
+
+// register registers a factory of scripted models named after their
+// factory for pattern, until the test ends.
+func register(t *testing.T, pattern, factory string) {
+	t.Helper()
+	model.Register(pattern, func(ctx context.Context, name string) (model.LLM, error) {
+		return &scriptedLLM{name: factory + ":" + name}, nil
+	})
+	t.Cleanup(func() { model.Unregister(pattern) })
+}
+
+func TestResolve(t *testing.T) {
+	register(t, `^test-gpt-|^test-o[0-9]`, "openai")
+	register(t, `^test-gemini-`, "gemini")
+	// Registered last, the override takes precedence.
+	register(t, `^test-gemini-2\.5-pro$`, "override")
+
+	for name, want := range map[string]string{
+		"test-gpt-4o":           "openai:test-gpt-4o",
+		"test-o3-mini":          "openai:test-o3-mini",
+		"test-gemini-2.5-flash": "gemini:test-gemini-2.5-flash",
+		"test-gemini-2.5-pro":   "override:test-gemini-2.5-pro",
+	} {
+		llm, err := model.Resolve(t.Context(), name)
+		if err != nil {
+			t.Fatalf("Resolve(%q) error = %v", name, err)
+		}
+		if llm.Name() != want {
+			t.Errorf("Resolve(%q) = %q, want %q", name, llm.Name(), want)
+		}
+	}
+
+	// Registering a pattern again replaces its factory, and gives it the
+	// precedence.
+	register(t, `^test-gemini-`, "replaced")
+	llm, err := model.Resolve(t.Context(), "test-gemini-2.5-pro")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "replaced:test-gemini-2.5-pro"; llm.Name() != want {
+		t.Errorf("Resolve() = %q after replacing, want %q", llm.Name(), want)
+	}
+}
+
+func TestResolve_Errors(t *testing.T) {
+	register(t, `^test-gpt-`, "openai")
+	errFactory := errors.New("no API key")
+	model.Register(`^test-broken-`, func(ctx context.Context, name string) (model.LLM, error) {
+		return nil, errFactory
+	})
+	t.Cleanup(func() { model.Unregister(`^test-broken-`) })
+
+	_, err := model.Resolve(t.Context(), "test-llama-3")
+	var unknown *model.UnknownModelError
+	if !errors.As(err, &unknown) {
+		t.Fatalf("Resolve() error = %v, want an UnknownModelError", err)
+	}
+	if unknown.Name != "test-llama-3" || len(unknown.Patterns) < 2 || unknown.Patterns[0] != `^test-broken-` || unknown.Patterns[1] != `^test-gpt-` {
+		t.Errorf("error = %+v, want the known patterns by decreasing precedence", unknown)
+	}
+	if !strings.Contains(err.Error(), "^test-gpt-") {
+		t.Errorf("error = %q, want the known patterns listed", err)
+	}
+
+	if _, err := model.Resolve(t.Context(), "test-broken-1"); !errors.Is(err, errFactory) {
+		t.Errorf("Resolve() error = %v, want the error of the factory", err)
+	}
+}
+
+func TestRegister_InvalidPattern(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Register() did not panic for an invalid pattern")
+		}
+	}()
+	model.Register(`^gpt-(`, nil)
+}
@@ -0,0 +1,235 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"maps"
+	"sync"
+	"time"
+
+	"google.golang.org/adk/runtimedeps"
+)
+
+// RetryAttemptsMetadataKey is the key of the custom metadata of the
+// responses of a model wrapped by [WithRetry] holding the number of the
+// attempt which produced them, starting at 1.
+const RetryAttemptsMetadataKey = "adk_retry_attempts"
+
+// RetryClassifier tells whether err, returned by a model, is transient. ok
+// is false if the classifier does not know err, which is then left to the
+// next classifier.
+type RetryClassifier func(err error) (retryable, ok bool)
+
+var retryClassifiers struct {
+	sync.RWMutex
+	list []RetryClassifier
+}
+
+// RegisterRetryClassifier registers c, so that the adapters can tell which
+// of their errors are transient, e.g. from their init function. The
+// classifiers are consulted in the order of their registration, before the
+// code of the error.
+func RegisterRetryClassifier(c RetryClassifier) {
+	retryClassifiers.Lock()
+	defer retryClassifiers.Unlock()
+	retryClassifiers.list = append(retryClassifiers.list, c)
+}
+
+// IsRetryableError reports whether the call which failed with err may
+// succeed if retried: if a registered [RetryClassifier] says so, or else if
+// the code of err is retryable, see [IsRetryable]. The context errors are
+// never retryable.
+func IsRetryableError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	retryClassifiers.RLock()
+	classifiers := retryClassifiers.list
+	retryClassifiers.RUnlock()
+	for _, c := range classifiers {
+		if retryable, ok := c(err); ok {
+			return retryable
+		}
+	}
+	return IsRetryable(CodeOf(err))
+}
+
+// RetryPolicy configures the retries of [WithRetry]. The delay before a
+// retry is the backoff, doubling with every retry, randomized by the jitter.
+type RetryPolicy struct {
+	// MaxAttempts bounds the number of calls of the model, including the
+	// first one. Defaults to 3; 1 disables the retries.
+	MaxAttempts int
+	// Backoff is the delay before the first retry. Defaults to 500ms.
+	Backoff time.Duration
+	// MaxBackoff caps the delay between the retries. Defaults to 30
+	// seconds.
+	MaxBackoff time.Duration
+	// Jitter is the fraction of the backoff randomly added or removed.
+	// Defaults to 0.2; negative disables the jitter.
+	Jitter float64
+	// AttemptTimeout limits the duration of every call, including the
+	// consumption of its responses. A call timing out is retried. Optional.
+	AttemptTimeout time.Duration
+	// Retryable reports whether a call failing with err is retried.
+	// Defaults to IsRetryableError.
+	Retryable func(err error) bool
+	// OnRetry is called before waiting to retry a call, with the number of
+	// the attempt which failed, its error and the delay before the retry.
+	// Optional.
+	OnRetry func(attempt int, err error, delay time.Duration)
+}
+
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = 3
+	}
+	if p.Backoff <= 0 {
+		p.Backoff = 500 * time.Millisecond
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = 30 * time.Second
+	}
+	if p.Jitter == 0 {
+		p.Jitter = 0.2
+	}
+	if p.Retryable == nil {
+		p.Retryable = IsRetryableError
+	}
+	return p
+}
+
+// RetryError is returned by a model wrapped by [WithRetry] for a call which
+// still failed after being retried.
+type RetryError struct {
+	// Attempts is the number of calls of the model.
+	Attempts int
+	// Err is the error of the last call.
+	Err error
+}
+
+// Error implements error.
+func (e *RetryError) Error() string {
+	return fmt.Sprintf("model call failed after %d attempts: %v", e.Attempts, e.Err)
+}
+
+// Unwrap returns the error of the last call.
+func (e *RetryError) Unwrap() error {
+	return e.Err
+}
+
+type retryModel struct {
+	llm    LLM
+	policy RetryPolicy
+}
+
+// WithRetry returns llm retrying the calls failing with a transient error,
+// whatever its provider. A call is only retried if it failed before
+// yielding any response, since the responses delivered cannot be taken
+// back: a stream failing midway fails. The responses carry the number of
+// their attempt in their custom metadata under [RetryAttemptsMetadataKey].
+//
+// The waits are done with the clock of [runtimedeps.FromContext], and end
+// as soon as the context is done, which fails the call with the error of
+// the last attempt.
+func WithRetry(llm LLM, policy RetryPolicy) LLM {
+	return &retryModel{llm: llm, policy: policy.withDefaults()}
+}
+
+// Name implements LLM.
+func (m *retryModel) Name() string {
+	return m.llm.Name()
+}
+
+// GenerateContent implements LLM.
+func (m *retryModel) GenerateContent(ctx context.Context, req *LLMRequest, stream bool) iter.Seq2[*LLMResponse, error] {
+	return func(yield func(*LLMResponse, error) bool) {
+		deps := runtimedeps.FromContext(ctx)
+		for attempt := 1; ; attempt++ {
+			err := m.attempt(ctx, req, stream, attempt, yield)
+			if err == nil {
+				return
+			}
+			if attempt >= m.policy.MaxAttempts || ctx.Err() != nil {
+				if attempt > 1 {
+					err = &RetryError{Attempts: attempt, Err: err}
+				}
+				yield(nil, err)
+				return
+			}
+			delay := min(m.policy.Backoff<<(attempt-1), m.policy.MaxBackoff)
+			if delay <= 0 {
+				// The backoff overflowed.
+				delay = m.policy.MaxBackoff
+			}
+			if m.policy.Jitter > 0 {
+				delay = deps.Jitter(delay, m.policy.Jitter)
+			}
+			if m.policy.OnRetry != nil {
+				m.policy.OnRetry(attempt, err, delay)
+			}
+			if deps.Sleep(ctx, delay) != nil {
+				yield(nil, &RetryError{Attempts: attempt, Err: err})
+				return
+			}
+		}
+	}
+}
+
+// attempt calls the model, and yields its responses. It returns the error
+// of the call if it failed before yielding any response with an error to
+// retry; the error is then not yielded.
+func (m *retryModel) attempt(ctx context.Context, req *LLMRequest, stream bool, attempt int, yield func(*LLMResponse, error) bool) error {
+	attemptCtx := ctx
+	if m.policy.AttemptTimeout > 0 {
+		var cancel context.CancelFunc
+		attemptCtx, cancel = context.WithTimeout(ctx, m.policy.AttemptTimeout)
+		defer cancel()
+	}
+	yielded := false
+	for resp, err := range m.llm.GenerateContent(attemptCtx, req, stream) {
+		if err != nil && !yielded && m.retryable(ctx, attemptCtx, err) {
+			return err
+		}
+		yielded = true
+		if resp != nil {
+			resp.CustomMetadata = maps.Clone(resp.CustomMetadata)
+			if resp.CustomMetadata == nil {
+				resp.CustomMetadata = make(map[string]any)
+			}
+			resp.CustomMetadata[RetryAttemptsMetadataKey] = attempt
+		}
+		if !yield(resp, err) {
+			return nil
+		}
+	}
+	return nil
+}
+
+// retryable reports whether err, returned by an attempt with attemptCtx,
+// is retried. The attempts timing out are, unless ctx is done.
+func (m *retryModel) retryable(ctx, attemptCtx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if attemptCtx.Err() != nil {
+		return true
+	}
+	return m.policy.Retryable(err)
+}
@@ -0,0 +1,219 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model_test
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"iter"
+	"testing"
+	"time"
+
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/runtimedeps"
+)
+
+var (
+	errUnavailable = &model.Error{Code: model.ErrorCodeUnavailable, Err: errors.New("overloaded")}
+	errInvalid     = &model.Error{Code: model.ErrorCodeInvalidRequest, Err: errors.New("bad request")}
+)
+
+// scriptedCall is the outcome of a call of a scriptedLLM: the texts it
+// yields, then its error, if any.
+type scriptedCall struct {
+	texts []string
+	err   error
+	// block makes the call wait for the end of its context before failing.
+	block bool
+}
+
+// scriptedLLM is a model whose calls follow a script, the last call being
+// repeated. It records the model of the requests.
+type scriptedLLM struct {
+	name   string
+	calls  []scriptedCall
+	n      int
+	models []string
+}
+
+func (m *scriptedLLM) Name() string { return cmp.Or(m.name, "scripted") }
+
+func (m *scriptedLLM) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	call := m.calls[min(m.n, len(m.calls)-1)]
+	m.n++
+	m.models = append(m.models, req.Model)
+	return func(yield func(*model.LLMResponse, error) bool) {
+		for _, text := range call.texts {
+			if !yield(&model.LLMResponse{Content: genai.NewContentFromText(text, genai.RoleModel), Partial: stream}, nil) {
+				return
+			}
+		}
+		if call.block {
+			<-ctx.Done()
+			yield(nil, ctx.Err())
+			return
+		}
+		if call.err != nil {
+			yield(nil, call.err)
+		}
+	}
+}
+
+// generate returns the texts and the attempts of the responses of llm, and
+// its error.
+func generate(ctx context.Context, llm model.LLM) (texts []string, attempts []any, err error) {
+	for resp, err := range llm.GenerateContent(ctx, &model.LLMRequest{}, true) {
+		if err != nil {
+			return texts, attempts, err
+		}
+		texts = append(texts, resp.Content.Parts[0].Text)
+		attempts = append(attempts, resp.CustomMetadata[model.RetryAttemptsMetadataKey])
+	}
+	return texts, attempts, nil
+}
+
+func frozenContext(t *testing.T) (context.Context, *runtimedeps.FrozenClock) {
+	clock := runtimedeps.NewFrozenClock(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	return runtimedeps.NewContext(t.Context(), runtimedeps.Seeded(1, clock)), clock
+}
+
+func TestWithRetry_SuccessAfterRetry(t *testing.T) {
+	ctx, clock := frozenContext(t)
+	start := clock.Now()
+	llm := &scriptedLLM{calls: []scriptedCall{{err: errUnavailable}, {err: errUnavailable}, {texts: []string{"hello", " world"}}}}
+	var retries []int
+	wrapped := model.WithRetry(llm, model.RetryPolicy{
+		Backoff: time.Second,
+		Jitter:  -1,
+		OnRetry: func(attempt int, err error, delay time.Duration) { retries = append(retries, attempt) },
+	})
+
+	texts, attempts, err := generate(ctx, wrapped)
+	if err != nil {
+		t.Fatalf("GenerateContent() failed: %v", err)
+	}
+	if len(texts) != 2 || texts[0] != "hello" || texts[1] != " world" {
+		t.Errorf("texts = %q, want the texts of the third call", texts)
+	}
+	for _, a := range attempts {
+		if a != 3 {
+			t.Errorf("attempt metadata = %v, want 3", a)
+		}
+	}
+	if len(retries) != 2 || retries[0] != 1 || retries[1] != 2 {
+		t.Errorf("OnRetry called for attempts %v, want [1 2]", retries)
+	}
+	// The backoff doubles: 1s, then 2s.
+	if got := clock.Now().Sub(start); got != 3*time.Second {
+		t.Errorf("waited %v, want 3s", got)
+	}
+}
+
+func TestWithRetry_Exhausted(t *testing.T) {
+	ctx, _ := frozenContext(t)
+	llm := &scriptedLLM{calls: []scriptedCall{{err: errUnavailable}}}
+	_, _, err := generate(ctx, model.WithRetry(llm, model.RetryPolicy{MaxAttempts: 4}))
+
+	var retryErr *model.RetryError
+	if !errors.As(err, &retryErr) || retryErr.Attempts != 4 {
+		t.Fatalf("GenerateContent() error = %v, want a RetryError after 4 attempts", err)
+	}
+	if got := model.CodeOf(err); got != model.ErrorCodeUnavailable {
+		t.Errorf("CodeOf() = %q, want the code of the last error", got)
+	}
+	if llm.n != 4 {
+		t.Errorf("model called %d times, want 4", llm.n)
+	}
+}
+
+func TestWithRetry_NotRetryable(t *testing.T) {
+	ctx, _ := frozenContext(t)
+	llm := &scriptedLLM{calls: []scriptedCall{{err: errInvalid}, {texts: []string{"unexpected"}}}}
+	_, _, err := generate(ctx, model.WithRetry(llm, model.RetryPolicy{}))
+	if err != errInvalid {
+		t.Errorf("GenerateContent() error = %v, want %v", err, errInvalid)
+	}
+	if llm.n != 1 {
+		t.Errorf("model called %d times, want 1", llm.n)
+	}
+}
+
+func TestWithRetry_NoRetryAfterPartialStream(t *testing.T) {
+	ctx, _ := frozenContext(t)
+	llm := &scriptedLLM{calls: []scriptedCall{{texts: []string{"hel"}, err: errUnavailable}, {texts: []string{"hello"}}}}
+	texts, attempts, err := generate(ctx, model.WithRetry(llm, model.RetryPolicy{}))
+	if err != errUnavailable {
+		t.Errorf("GenerateContent() error = %v, want %v", err, errUnavailable)
+	}
+	if len(texts) != 1 || texts[0] != "hel" || attempts[0] != 1 {
+		t.Errorf("texts = %q with attempts %v, want the partial text of the first attempt", texts, attempts)
+	}
+	if llm.n != 1 {
+		t.Errorf("model called %d times, want 1", llm.n)
+	}
+}
+
+func TestWithRetry_AttemptTimeout(t *testing.T) {
+	llm := &scriptedLLM{calls: []scriptedCall{{block: true}, {texts: []string{"ok"}}}}
+	texts, _, err := generate(t.Context(), model.WithRetry(llm, model.RetryPolicy{AttemptTimeout: 10 * time.Millisecond, Backoff: time.Millisecond}))
+	if err != nil {
+		t.Fatalf("GenerateContent() failed: %v", err)
+	}
+	if len(texts) != 1 || texts[0] != "ok" {
+		t.Errorf("texts = %q, want the text of the second attempt", texts)
+	}
+}
+
+func TestWithRetry_ContextCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	llm := &scriptedLLM{calls: []scriptedCall{{err: errUnavailable}}}
+	wrapped := model.WithRetry(llm, model.RetryPolicy{
+		Backoff: time.Hour,
+		OnRetry: func(int, error, time.Duration) { cancel() },
+	})
+	start := time.Now()
+	_, _, err := generate(ctx, wrapped)
+	if !errors.Is(err, errUnavailable) {
+		t.Errorf("GenerateContent() error = %v, want the error of the attempt", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Minute {
+		t.Errorf("GenerateContent() returned after %v, want no wait once canceled", elapsed)
+	}
+	if llm.n != 1 {
+		t.Errorf("model called %d times, want 1", llm.n)
+	}
+}
+
+func TestRegisterRetryClassifier(t *testing.T) {
+	errCustom := errors.New("provider hiccup")
+	model.RegisterRetryClassifier(func(err error) (bool, bool) {
+		if errors.Is(err, errCustom) {
+			return true, true
+		}
+		return false, false
+	})
+	if !model.IsRetryableError(errCustom) {
+		t.Error("IsRetryableError() = false for an error classified retryable")
+	}
+	if model.IsRetryableError(errInvalid) {
+		t.Error("IsRetryableError() = true for an invalid request")
+	}
+	if model.IsRetryableError(context.DeadlineExceeded) {
+		t.Error("IsRetryableError() = true for a context error")
+	}
+}
@@ -0,0 +1,364 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package scheduler shares the capacity of a model fairly between the
+// sessions or users calling it.
+//
+// [New] wraps a [model.LLM] with global concurrency and requests per minute
+// limits. Requests waiting for capacity are queued per identity, and the
+// queues are served by weighted round-robin, so that a few chatty sessions
+// cannot monopolize the model.
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"sync"
+	"time"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/runtimedeps"
+)
+
+// ErrBackpressure is wrapped by the errors of the requests rejected because
+// the queue of their identity is full. Applications can turn it into a
+// "system busy" message.
+var ErrBackpressure = errors.New("model scheduler queue is full")
+
+// BackpressureError is returned for a request rejected because the queue of
+// its identity is full.
+type BackpressureError struct {
+	Identity string
+	// Depth is the depth of the queue when the request was rejected.
+	Depth int
+}
+
+// Error implements error.
+func (e *BackpressureError) Error() string {
+	return fmt.Sprintf("%v: identity %q has %d queued requests", ErrBackpressure, e.Identity, e.Depth)
+}
+
+// Unwrap returns ErrBackpressure.
+func (e *BackpressureError) Unwrap() error {
+	return ErrBackpressure
+}
+
+type identityKey struct{}
+
+// WithIdentity returns a context whose model requests are scheduled under
+// the given identity.
+func WithIdentity(ctx context.Context, identity string) context.Context {
+	return context.WithValue(ctx, identityKey{}, identity)
+}
+
+// DefaultIdentity returns the identity set by [WithIdentity]. Otherwise, if
+// ctx is an agent invocation context, which is the case for the requests of
+// an agent, it returns the ID of the session.
+func DefaultIdentity(ctx context.Context) string {
+	if identity, ok := ctx.Value(identityKey{}).(string); ok {
+		return identity
+	}
+	if ictx, ok := ctx.(agent.InvocationContext); ok && ictx.Session() != nil {
+		return ictx.Session().ID()
+	}
+	return ""
+}
+
+// Config is the configuration of a [Scheduler].
+type Config struct {
+	// MaxConcurrent bounds the number of requests running at the same time.
+	// A streaming request holds its slot until the stream ends.
+	// Zero means unlimited.
+	MaxConcurrent int
+	// RequestsPerMinute bounds the number of requests started within any
+	// minute. Zero means unlimited.
+	RequestsPerMinute int
+	// Weights sets the share of capacity of identities. Identities default
+	// to a weight of 1.
+	Weights map[string]int
+	// MaxQueueWait protects against starvation: a request that waited longer
+	// is served before the round-robin order. Zero disables the protection.
+	MaxQueueWait time.Duration
+	// MaxQueueDepth bounds the number of queued requests of every identity.
+	// Excess requests fail with a [BackpressureError]. Zero means unlimited.
+	MaxQueueDepth int
+	// Identity returns the identity of a request.
+	// Optional: if nil, DefaultIdentity is used.
+	Identity func(ctx context.Context) string
+	// Clock tells the time of the queue waits and the requests per minute.
+	// Defaults to the real clock.
+	Clock runtimedeps.Clock
+}
+
+// Metrics is the scheduling metrics of an identity.
+type Metrics struct {
+	// QueueDepth is the number of requests currently queued.
+	QueueDepth int
+	// Dispatched is the number of requests dispatched to the model.
+	Dispatched int
+	// Rejected is the number of requests rejected with a BackpressureError.
+	Rejected int
+	// Promoted is the number of requests served early by the starvation
+	// protection.
+	Promoted int
+	// TotalWait and MaxWait are the total and the maximum time spent in the
+	// queue by the dispatched requests.
+	TotalWait, MaxWait time.Duration
+}
+
+// Scheduler is a [model.LLM] sharing the capacity of another model fairly
+// between identities.
+type Scheduler struct {
+	llm model.LLM
+	cfg Config
+
+	mu       sync.Mutex
+	inFlight int
+	// starts holds the start times of the requests of the last minute.
+	starts []time.Time
+	// retry is the timer dispatching the queued requests once the requests
+	// per minute limit allows it.
+	retry  *time.Timer
+	queues map[string]*queue
+	// order lists the identities in order of first appearance, to break
+	// round-robin ties deterministically.
+	order []string
+}
+
+type queue struct {
+	waiting []*waiter
+	// current is the smooth weighted round-robin counter of the identity.
+	current int
+	metrics Metrics
+}
+
+type waiter struct {
+	identity string
+	enqueued time.Time
+	ready    chan struct{}
+	granted  bool
+}
+
+var _ model.LLM = (*Scheduler)(nil)
+
+// New returns a Scheduler in front of llm.
+func New(llm model.LLM, cfg Config) *Scheduler {
+	if cfg.Identity == nil {
+		cfg.Identity = DefaultIdentity
+	}
+	if cfg.Clock == nil {
+		cfg.Clock = runtimedeps.RealClock{}
+	}
+	return &Scheduler{
+		llm:    llm,
+		cfg:    cfg,
+		queues: make(map[string]*queue),
+	}
+}
+
+// Name implements model.LLM.
+func (s *Scheduler) Name() string {
+	return s.llm.Name()
+}
+
+// GenerateContent implements model.LLM. The request waits for capacity
+// before it is sent to the wrapped model.
+func (s *Scheduler) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {
+		if err := s.acquire(ctx, s.cfg.Identity(ctx)); err != nil {
+			yield(nil, err)
+			return
+		}
+		defer s.release()
+		for resp, err := range s.llm.GenerateContent(ctx, req, stream) {
+			if !yield(resp, err) {
+				return
+			}
+		}
+	}
+}
+
+// Metrics returns the scheduling metrics of every identity seen so far.
+func (s *Scheduler) Metrics() map[string]Metrics {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	metrics := make(map[string]Metrics, len(s.queues))
+	for identity, q := range s.queues {
+		m := q.metrics
+		m.QueueDepth = len(q.waiting)
+		metrics[identity] = m
+	}
+	return metrics
+}
+
+func (s *Scheduler) acquire(ctx context.Context, identity string) error {
+	s.mu.Lock()
+	q, ok := s.queues[identity]
+	if !ok {
+		q = &queue{}
+		s.queues[identity] = q
+		s.order = append(s.order, identity)
+	}
+	if s.cfg.MaxQueueDepth > 0 && len(q.waiting) >= s.cfg.MaxQueueDepth {
+		q.metrics.Rejected++
+		depth := len(q.waiting)
+		s.mu.Unlock()
+		return &BackpressureError{Identity: identity, Depth: depth}
+	}
+	w := &waiter{identity: identity, enqueued: s.cfg.Clock.Now(), ready: make(chan struct{})}
+	q.waiting = append(q.waiting, w)
+	s.dispatchLocked()
+	s.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		return nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if w.granted {
+			// The slot was granted concurrently: hand it over.
+			s.inFlight--
+			s.dispatchLocked()
+		} else {
+			q.waiting = removeWaiter(q.waiting, w)
+		}
+		return ctx.Err()
+	}
+}
+
+func (s *Scheduler) release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.inFlight--
+	s.dispatchLocked()
+}
+
+// dispatchLocked grants the free capacity to the queued requests. It
+// expects s.mu to be held.
+func (s *Scheduler) dispatchLocked() {
+	for s.cfg.MaxConcurrent <= 0 || s.inFlight < s.cfg.MaxConcurrent {
+		now := s.cfg.Clock.Now()
+		if s.cfg.RequestsPerMinute > 0 {
+			for len(s.starts) > 0 && now.Sub(s.starts[0]) >= time.Minute {
+				s.starts = s.starts[1:]
+			}
+			if len(s.starts) >= s.cfg.RequestsPerMinute {
+				s.scheduleRetryLocked(s.starts[0].Add(time.Minute).Sub(now))
+				return
+			}
+		}
+
+		w, promoted := s.nextLocked(now)
+		if w == nil {
+			return
+		}
+		q := s.queues[w.identity]
+		q.waiting = q.waiting[1:]
+		if len(q.waiting) == 0 {
+			q.current = 0
+		}
+		wait := now.Sub(w.enqueued)
+		q.metrics.Dispatched++
+		q.metrics.TotalWait += wait
+		q.metrics.MaxWait = max(q.metrics.MaxWait, wait)
+		if promoted {
+			q.metrics.Promoted++
+		}
+
+		s.inFlight++
+		if s.cfg.RequestsPerMinute > 0 {
+			s.starts = append(s.starts, now)
+		}
+		w.granted = true
+		close(w.ready)
+	}
+}
+
+// nextLocked picks the next request to dispatch, and reports whether it was
+// promoted by the starvation protection. Only the heads of the queues are
+// candidates, so the requests of an identity are served in order.
+func (s *Scheduler) nextLocked(now time.Time) (*waiter, bool) {
+	if s.cfg.MaxQueueWait > 0 {
+		var oldest *waiter
+		for _, identity := range s.order {
+			q := s.queues[identity]
+			if len(q.waiting) == 0 {
+				continue
+			}
+			if head := q.waiting[0]; now.Sub(head.enqueued) >= s.cfg.MaxQueueWait && (oldest == nil || head.enqueued.Before(oldest.enqueued)) {
+				oldest = head
+			}
+		}
+		if oldest != nil {
+			return oldest, true
+		}
+	}
+
+	// Smooth weighted round-robin: every active identity earns its weight,
+	// and the richest one is served and pays the total.
+	var best *queue
+	var bestIdentity string
+	total := 0
+	for _, identity := range s.order {
+		q := s.queues[identity]
+		if len(q.waiting) == 0 {
+			continue
+		}
+		weight := s.weight(identity)
+		q.current += weight
+		total += weight
+		if best == nil || q.current > best.current {
+			best, bestIdentity = q, identity
+		}
+	}
+	if best == nil {
+		return nil, false
+	}
+	best.current -= total
+	return s.queues[bestIdentity].waiting[0], false
+}
+
+func (s *Scheduler) weight(identity string) int {
+	if w, ok := s.cfg.Weights[identity]; ok && w > 0 {
+		return w
+	}
+	return 1
+}
+
+// scheduleRetryLocked dispatches the queued requests after d. It expects
+// s.mu to be held.
+func (s *Scheduler) scheduleRetryLocked(d time.Duration) {
+	if s.retry != nil {
+		return
+	}
+	s.retry = time.AfterFunc(d, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.retry = nil
+		s.dispatchLocked()
+	})
+}
+
+func removeWaiter(waiting []*waiter, w *waiter) []*waiter {
+	for i, other := range waiting {
+		if other == w {
+			return append(waiting[:i], waiting[i+1:]...)
+		}
+	}
+	return waiting
+}
@@ -0,0 +1,308 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler_test
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/model/scheduler"
+	"google.golang.org/adk/runtimedeps"
+)
+
+const blocker = "blocker"
+
+// recordingModel records the identities of the requests in the order they
+// are dispatched. The requests of the blocker identity wait for release.
+type recordingModel struct {
+	delay   time.Duration
+	release chan struct{}
+
+	mu    sync.Mutex
+	order []string
+}
+
+func newRecordingModel(delay time.Duration) *recordingModel {
+	return &recordingModel{delay: delay, release: make(chan struct{})}
+}
+
+func (m *recordingModel) Name() string {
+	return "recording"
+}
+
+func (m *recordingModel) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {
+		identity := scheduler.DefaultIdentity(ctx)
+		if identity == blocker {
+			<-m.release
+		} else {
+			m.mu.Lock()
+			m.order = append(m.order, identity)
+			m.mu.Unlock()
+			time.Sleep(m.delay)
+		}
+		yield(&model.LLMResponse{Content: genai.NewContentFromText("ok", genai.RoleModel)}, nil)
+	}
+}
+
+func (m *recordingModel) dispatched() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]string(nil), m.order...)
+}
+
+func generate(s *scheduler.Scheduler, identity string) error {
+	ctx := scheduler.WithIdentity(context.Background(), identity)
+	for _, err := range s.GenerateContent(ctx, &model.LLMRequest{}, false) {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hold occupies the only slot of s until the model is released.
+func hold(t *testing.T, s *scheduler.Scheduler, wg *sync.WaitGroup) {
+	t.Helper()
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if err := generate(s, blocker); err != nil {
+			t.Errorf("blocker request failed: %v", err)
+		}
+	}()
+	waitFor(t, func(m map[string]scheduler.Metrics) bool { return m[blocker].Dispatched == 1 }, s)
+}
+
+// enqueue starts n requests of identity and waits until they are queued.
+func enqueue(t *testing.T, s *scheduler.Scheduler, wg *sync.WaitGroup, identity string, n int) {
+	t.Helper()
+	for range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := generate(s, identity); err != nil {
+				t.Errorf("request of %q failed: %v", identity, err)
+			}
+		}()
+	}
+	waitFor(t, func(m map[string]scheduler.Metrics) bool { return m[identity].QueueDepth == n }, s)
+}
+
+func waitFor(t *testing.T, cond func(map[string]scheduler.Metrics) bool, s *scheduler.Scheduler) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond(s.Metrics()) {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out, metrics: %+v", s.Metrics())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestScheduler_Fairness(t *testing.T) {
+	llm := newRecordingModel(0)
+	s := scheduler.New(llm, scheduler.Config{MaxConcurrent: 1})
+
+	var wg sync.WaitGroup
+	hold(t, s, &wg)
+	// Skewed arrivals: A sends six times as many requests as C.
+	enqueue(t, s, &wg, "A", 30)
+	enqueue(t, s, &wg, "B", 10)
+	enqueue(t, s, &wg, "C", 5)
+	close(llm.release)
+	wg.Wait()
+
+	order := llm.dispatched()
+	if len(order) != 45 {
+		t.Fatalf("dispatched %d requests, want 45", len(order))
+	}
+	// While all the identities have queued requests, they share the model
+	// equally.
+	counts := make(map[string]int)
+	for _, identity := range order[:15] {
+		counts[identity]++
+	}
+	for _, identity := range []string{"A", "B", "C"} {
+		if got := counts[identity]; got < 4 || got > 6 {
+			t.Errorf("identity %q got %d of the first 15 dispatches, want about 5 (order: %v)", identity, got, order[:15])
+		}
+	}
+
+	metrics := s.Metrics()
+	for identity, want := range map[string]int{"A": 30, "B": 10, "C": 5} {
+		if got := metrics[identity]; got.Dispatched != want || got.QueueDepth != 0 || got.MaxWait <= 0 {
+			t.Errorf("Metrics()[%q] = %+v, want %d dispatched, an empty queue and a wait time", identity, got, want)
+		}
+	}
+}
+
+func TestScheduler_Weights(t *testing.T) {
+	llm := newRecordingModel(0)
+	s := scheduler.New(llm, scheduler.Config{MaxConcurrent: 1, Weights: map[string]int{"A": 2}})
+
+	var wg sync.WaitGroup
+	hold(t, s, &wg)
+	enqueue(t, s, &wg, "A", 4)
+	enqueue(t, s, &wg, "B", 4)
+	close(llm.release)
+	wg.Wait()
+
+	want := []string{"A", "B", "A", "A", "B", "A", "B", "B"}
+	if diff := cmp.Diff(want, llm.dispatched()); diff != "" {
+		t.Errorf("dispatch order mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestScheduler_StarvationPromotion(t *testing.T) {
+	for _, tc := range []struct {
+		name         string
+		maxQueueWait time.Duration
+		wantB        int
+	}{
+		// Without the protection, B waits until A has no queued requests.
+		{name: "Disabled", wantB: 10},
+		{name: "Enabled", maxQueueWait: 30 * time.Millisecond, wantB: 0},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			llm := newRecordingModel(10 * time.Millisecond)
+			clock := runtimedeps.NewFrozenClock(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+			s := scheduler.New(llm, scheduler.Config{
+				MaxConcurrent: 1,
+				Weights:       map[string]int{"A": 100},
+				MaxQueueWait:  tc.maxQueueWait,
+				Clock:         clock,
+			})
+
+			var wg sync.WaitGroup
+			hold(t, s, &wg)
+			enqueue(t, s, &wg, "B", 1)
+			enqueue(t, s, &wg, "A", 10)
+			clock.Advance(40 * time.Millisecond)
+			close(llm.release)
+			wg.Wait()
+
+			order := llm.dispatched()
+			got := -1
+			for i, identity := range order {
+				if identity == "B" {
+					got = i
+				}
+			}
+			if got != tc.wantB {
+				t.Errorf("B dispatched at position %d, want %d (order: %v)", got, tc.wantB, order)
+			}
+			if tc.maxQueueWait > 0 && s.Metrics()["B"].Promoted != 1 {
+				t.Errorf("Metrics()[B].Promoted = %d, want 1", s.Metrics()["B"].Promoted)
+			}
+		})
+	}
+}
+
+func TestScheduler_QueueDepthCap(t *testing.T) {
+	llm := newRecordingModel(0)
+	s := scheduler.New(llm, scheduler.Config{MaxConcurrent: 1, MaxQueueDepth: 2})
+
+	var wg sync.WaitGroup
+	hold(t, s, &wg)
+	enqueue(t, s, &wg, "A", 2)
+
+	err := generate(s, "A")
+	var backpressure *scheduler.BackpressureError
+	if !errors.As(err, &backpressure) {
+		t.Fatalf("GenerateContent() error = %v, want a BackpressureError", err)
+	}
+	if !errors.Is(err, scheduler.ErrBackpressure) {
+		t.Errorf("GenerateContent() error = %v, want ErrBackpressure", err)
+	}
+	if diff := cmp.Diff(&scheduler.BackpressureError{Identity: "A", Depth: 2}, backpressure); diff != "" {
+		t.Errorf("BackpressureError mismatch (-want +got):\n%s", diff)
+	}
+	// Other identities are not affected.
+	enqueue(t, s, &wg, "B", 1)
+
+	close(llm.release)
+	wg.Wait()
+
+	metrics := s.Metrics()
+	if got := metrics["A"]; got.Rejected != 1 || got.Dispatched != 2 {
+		t.Errorf("Metrics()[A] = %+v, want 1 rejected and 2 dispatched", got)
+	}
+}
+
+func TestScheduler_Cancel(t *testing.T) {
+	llm := newRecordingModel(0)
+	s := scheduler.New(llm, scheduler.Config{MaxConcurrent: 1})
+
+	var wg sync.WaitGroup
+	hold(t, s, &wg)
+
+	ctx, cancel := context.WithCancel(scheduler.WithIdentity(t.Context(), "A"))
+	errc := make(chan error, 1)
+	go func() {
+		for _, err := range s.GenerateContent(ctx, &model.LLMRequest{}, false) {
+			errc <- err
+		}
+	}()
+	waitFor(t, func(m map[string]scheduler.Metrics) bool { return m["A"].QueueDepth == 1 }, s)
+	cancel()
+	if err := <-errc; !errors.Is(err, context.Canceled) {
+		t.Errorf("GenerateContent() error = %v, want %v", err, context.Canceled)
+	}
+	if got := s.Metrics()["A"].QueueDepth; got != 0 {
+		t.Errorf("Metrics()[A].QueueDepth = %d after cancel, want 0", got)
+	}
+
+	close(llm.release)
+	wg.Wait()
+	if err := generate(s, "B"); err != nil {
+		t.Errorf("GenerateContent() after cancel failed: %v", err)
+	}
+}
+
+func TestScheduler_RequestsPerMinute(t *testing.T) {
+	llm := newRecordingModel(0)
+	s := scheduler.New(llm, scheduler.Config{RequestsPerMinute: 2})
+
+	for range 2 {
+		if err := generate(s, "A"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ctx, cancel := context.WithTimeout(scheduler.WithIdentity(t.Context(), "A"), 50*time.Millisecond)
+	defer cancel()
+	for _, err := range s.GenerateContent(ctx, &model.LLMRequest{}, false) {
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("GenerateContent() over the limit error = %v, want %v", err, context.DeadlineExceeded)
+		}
+	}
+}
+
+func TestDefaultIdentity(t *testing.T) {
+	if got := scheduler.DefaultIdentity(scheduler.WithIdentity(t.Context(), "user-1")); got != "user-1" {
+		t.Errorf("DefaultIdentity() = %q, want %q", got, "user-1")
+	}
+	if got := scheduler.DefaultIdentity(t.Context()); got != "" {
+		t.Errorf("DefaultIdentity() = %q, want empty", got)
+	}
+}
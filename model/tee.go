@@ -0,0 +1,265 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"errors"
+	"iter"
+	"sync"
+)
+
+// ErrSlowConsumer is yielded to a secondary consumer of [Tee] that was
+// canceled because its buffer overflowed under [SlowConsumerCancel].
+var ErrSlowConsumer = errors.New("tee consumer canceled: buffer overflow")
+
+// SlowConsumerPolicy decides what [Tee] does when a secondary consumer's
+// buffer is full.
+type SlowConsumerPolicy int
+
+const (
+	// SlowConsumerBlock blocks the source, and therefore the primary
+	// consumer, until the secondary consumer makes room in its buffer.
+	SlowConsumerBlock SlowConsumerPolicy = iota
+	// SlowConsumerDropOldest discards the oldest buffered item of the slow
+	// consumer to make room for the new one.
+	SlowConsumerDropOldest
+	// SlowConsumerCancel discards the buffer of the slow consumer, delivers
+	// [ErrSlowConsumer] to it and detaches it from the source.
+	SlowConsumerCancel
+)
+
+// DefaultTeeBufferSize is the per-consumer buffer bound used when
+// TeeConfig.BufferSize is not set.
+const DefaultTeeBufferSize = 16
+
+// TeeConfig configures [TeeWithConfig].
+type TeeConfig struct {
+	// BufferSize bounds the number of items buffered for every secondary
+	// consumer. Optional: if zero, DefaultTeeBufferSize is used.
+	BufferSize int
+	// Policy decides what to do with a secondary consumer whose buffer is
+	// full.
+	Policy SlowConsumerPolicy
+	// DrainOnPrimaryStop lets secondary consumers receive the items that were
+	// already buffered for them when the primary consumer stops early.
+	// Otherwise secondary consumers end immediately.
+	DrainOnPrimaryStop bool
+}
+
+// Tee splits src into n sequences using the default TeeConfig. See
+// [TeeWithConfig].
+func Tee(src iter.Seq2[*LLMResponse, error], n int) []iter.Seq2[*LLMResponse, error] {
+	return TeeWithConfig(src, n, TeeConfig{})
+}
+
+// TeeWithConfig splits src into n sequences that all observe the items of src
+// in order, including errors.
+//
+// The first returned sequence is the primary one. It drives src from the
+// caller's goroutine: every item is handed to the buffers of the secondary
+// consumers and then yielded, so the primary consumer sees every item with no
+// added goroutine hop. The primary sequence must be consumed, otherwise the
+// secondary sequences never receive anything.
+//
+// The remaining sequences are secondary ones, typically consumed from other
+// goroutines. Each one has its own bounded buffer that is handled according
+// to cfg.Policy when it gets full. A secondary consumer that stops early is
+// detached and never slows the source down again.
+//
+// Every returned sequence can be iterated only once. When src ends, or the
+// primary consumer stops, the secondary sequences end after they drained
+// their buffers (see TeeConfig.DrainOnPrimaryStop). No goroutines are started,
+// so nothing is left behind once all consumers return.
+func TeeWithConfig(src iter.Seq2[*LLMResponse, error], n int, cfg TeeConfig) []iter.Seq2[*LLMResponse, error] {
+	if n <= 0 {
+		return nil
+	}
+	if cfg.BufferSize <= 0 {
+		cfg.BufferSize = DefaultTeeBufferSize
+	}
+
+	consumers := make([]*teeConsumer, n-1)
+	for i := range consumers {
+		consumers[i] = newTeeConsumer(cfg)
+	}
+
+	seqs := make([]iter.Seq2[*LLMResponse, error], 0, n)
+	seqs = append(seqs, primarySeq(src, consumers, cfg))
+	for _, c := range consumers {
+		seqs = append(seqs, c.seq)
+	}
+	return seqs
+}
+
+func primarySeq(src iter.Seq2[*LLMResponse, error], consumers []*teeConsumer, cfg TeeConfig) iter.Seq2[*LLMResponse, error] {
+	var once sync.Once
+	return func(yield func(*LLMResponse, error) bool) {
+		started := false
+		once.Do(func() { started = true })
+		if !started {
+			return
+		}
+
+		completed := false
+		defer func() {
+			drain := completed || cfg.DrainOnPrimaryStop
+			for _, c := range consumers {
+				c.close(drain)
+			}
+		}()
+
+		for resp, err := range src {
+			for _, c := range consumers {
+				c.push(teeItem{resp: resp, err: err})
+			}
+			if !yield(resp, err) {
+				return
+			}
+		}
+		completed = true
+	}
+}
+
+type teeItem struct {
+	resp *LLMResponse
+	err  error
+}
+
+// teeConsumer is the buffer of a secondary consumer.
+type teeConsumer struct {
+	policy SlowConsumerPolicy
+	limit  int
+
+	mu   sync.Mutex
+	cond *sync.Cond
+	// buf holds the items not yet yielded to the consumer.
+	buf []teeItem
+	// closed is set when the source will not push anymore.
+	closed bool
+	// detached is set when the consumer stopped or was canceled.
+	detached bool
+	// canceled is set when the consumer overflowed under SlowConsumerCancel.
+	canceled bool
+	started  bool
+}
+
+func newTeeConsumer(cfg TeeConfig) *teeConsumer {
+	c := &teeConsumer{
+		policy: cfg.Policy,
+		limit:  cfg.BufferSize,
+	}
+	c.cond = sync.NewCond(&c.mu)
+	return c
+}
+
+func (c *teeConsumer) push(item teeItem) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.detached || c.closed {
+		return
+	}
+	if len(c.buf) >= c.limit {
+		switch c.policy {
+		case SlowConsumerDropOldest:
+			c.buf[0] = teeItem{}
+			c.buf = c.buf[1:]
+		case SlowConsumerCancel:
+			c.buf = nil
+			c.canceled = true
+			c.detached = true
+			c.cond.Broadcast()
+			return
+		default:
+			for len(c.buf) >= c.limit && !c.detached {
+				c.cond.Wait()
+			}
+			if c.detached {
+				return
+			}
+		}
+	}
+	c.buf = append(c.buf, item)
+	c.cond.Broadcast()
+}
+
+// close marks the end of the source. Unless drain is set, the items that were
+// not yet consumed are discarded.
+func (c *teeConsumer) close(drain bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.closed = true
+	if !drain {
+		c.buf = nil
+	}
+	c.cond.Broadcast()
+}
+
+// next blocks until an item is available. ok is false once the consumer
+// should stop.
+func (c *teeConsumer) next() (item teeItem, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for len(c.buf) == 0 && !c.closed && !c.detached {
+		c.cond.Wait()
+	}
+	if c.canceled {
+		c.canceled = false
+		return teeItem{err: ErrSlowConsumer}, true
+	}
+	if len(c.buf) == 0 || c.detached {
+		return teeItem{}, false
+	}
+	item = c.buf[0]
+	c.buf[0] = teeItem{}
+	c.buf = c.buf[1:]
+	// Wake up the source if it is blocked on a full buffer.
+	c.cond.Broadcast()
+	return item, true
+}
+
+// detach stops delivering items to the consumer and releases the source if
+// it is blocked on it.
+func (c *teeConsumer) detach() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.detached = true
+	c.buf = nil
+	c.cond.Broadcast()
+}
+
+func (c *teeConsumer) seq(yield func(*LLMResponse, error) bool) {
+	c.mu.Lock()
+	started := c.started
+	c.started = true
+	c.mu.Unlock()
+	if started {
+		return
+	}
+	defer c.detach()
+
+	for {
+		item, ok := c.next()
+		if !ok {
+			return
+		}
+		if !yield(item.resp, item.err) {
+			return
+		}
+	}
+}
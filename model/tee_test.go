@@ -0,0 +1,291 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model_test
+
+import (
+	"errors"
+	"iter"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/model"
+)
+
+// teeSource yields one text response per element of texts. An empty text
+// yields errTeeSource instead. stopped is set if the consumer stopped early.
+func teeSource(texts []string, produced *atomic.Int32, stopped *atomic.Bool) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {
+		for _, text := range texts {
+			if produced != nil {
+				produced.Add(1)
+			}
+			var ok bool
+			if text == "" {
+				ok = yield(nil, errTeeSource)
+			} else {
+				ok = yield(&model.LLMResponse{Content: genai.NewContentFromText(text, genai.RoleModel)}, nil)
+			}
+			if !ok {
+				if stopped != nil {
+					stopped.Store(true)
+				}
+				return
+			}
+		}
+	}
+}
+
+var errTeeSource = errors.New("source error")
+
+// collect returns the texts of the responses in seq, "ERR: <msg>" for errors.
+func collect(seq iter.Seq2[*model.LLMResponse, error], limit int) []string {
+	var got []string
+	for resp, err := range seq {
+		if err != nil {
+			got = append(got, "ERR: "+err.Error())
+		} else {
+			got = append(got, resp.Content.Parts[0].Text)
+		}
+		if limit > 0 && len(got) == limit {
+			break
+		}
+	}
+	return got
+}
+
+func TestTee(t *testing.T) {
+	texts := []string{"a", "b", "c", "d", "e"}
+
+	seqs := model.Tee(teeSource(texts, nil, nil), 3)
+	if len(seqs) != 3 {
+		t.Fatalf("Tee() returned %d sequences, want 3", len(seqs))
+	}
+
+	var wg sync.WaitGroup
+	secondary := make([][]string, 2)
+	for i, seq := range seqs[1:] {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			secondary[i] = collect(seq, 0)
+		}()
+	}
+	primary := collect(seqs[0], 0)
+	wg.Wait()
+
+	if diff := cmp.Diff(texts, primary); diff != "" {
+		t.Errorf("primary mismatch (-want +got):\n%s", diff)
+	}
+	for i, got := range secondary {
+		if diff := cmp.Diff(texts, got); diff != "" {
+			t.Errorf("secondary %d mismatch (-want +got):\n%s", i, diff)
+		}
+	}
+
+	// Sequences can be iterated only once.
+	if got := collect(seqs[0], 0); len(got) != 0 {
+		t.Errorf("second iteration of primary = %v, want nothing", got)
+	}
+}
+
+func TestTee_SlowConsumerPolicies(t *testing.T) {
+	texts := []string{"a", "b", "c", "d", "e"}
+
+	testCases := []struct {
+		name   string
+		policy model.SlowConsumerPolicy
+		want   []string
+	}{
+		{
+			name:   "drop oldest",
+			policy: model.SlowConsumerDropOldest,
+			want:   []string{"d", "e"},
+		},
+		{
+			name:   "cancel",
+			policy: model.SlowConsumerCancel,
+			want:   []string{"ERR: " + model.ErrSlowConsumer.Error()},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			seqs := model.TeeWithConfig(teeSource(texts, nil, nil), 2, model.TeeConfig{
+				BufferSize: 2,
+				Policy:     tc.policy,
+			})
+
+			// The secondary does not read until the primary is done, so its
+			// buffer overflows.
+			primary := collect(seqs[0], 0)
+			if diff := cmp.Diff(texts, primary); diff != "" {
+				t.Errorf("primary mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.want, collect(seqs[1], 0)); diff != "" {
+				t.Errorf("secondary mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestTee_SlowConsumerBlock(t *testing.T) {
+	texts := []string{"a", "b", "c", "d", "e"}
+	var produced atomic.Int32
+
+	seqs := model.TeeWithConfig(teeSource(texts, &produced, nil), 2, model.TeeConfig{
+		BufferSize: 1,
+		Policy:     model.SlowConsumerBlock,
+	})
+
+	primaryDone := make(chan []string)
+	go func() {
+		primaryDone <- collect(seqs[0], 0)
+	}()
+
+	// "a" fills the buffer, "b" blocks the source.
+	time.Sleep(50 * time.Millisecond)
+	if got := produced.Load(); got != 2 {
+		t.Errorf("source produced %d items while the secondary was blocked, want 2", got)
+	}
+
+	secondary := collect(seqs[1], 0)
+	primary := <-primaryDone
+
+	if diff := cmp.Diff(texts, primary); diff != "" {
+		t.Errorf("primary mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(texts, secondary); diff != "" {
+		t.Errorf("secondary mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestTee_SecondaryStopDoesNotBlockSource(t *testing.T) {
+	texts := []string{"a", "b", "c", "d", "e"}
+
+	seqs := model.TeeWithConfig(teeSource(texts, nil, nil), 2, model.TeeConfig{
+		BufferSize: 1,
+		Policy:     model.SlowConsumerBlock,
+	})
+
+	secondaryDone := make(chan []string)
+	go func() {
+		secondaryDone <- collect(seqs[1], 1)
+	}()
+
+	primary := collect(seqs[0], 0)
+	if diff := cmp.Diff(texts, primary); diff != "" {
+		t.Errorf("primary mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"a"}, <-secondaryDone); diff != "" {
+		t.Errorf("secondary mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestTee_ErrorPropagation(t *testing.T) {
+	texts := []string{"a", "", "b"}
+	want := []string{"a", "ERR: " + errTeeSource.Error(), "b"}
+
+	seqs := model.Tee(teeSource(texts, nil, nil), 2)
+	primary := collect(seqs[0], 0)
+	secondary := collect(seqs[1], 0)
+
+	if diff := cmp.Diff(want, primary); diff != "" {
+		t.Errorf("primary mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(want, secondary); diff != "" {
+		t.Errorf("secondary mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestTee_PrimaryEarlyExit(t *testing.T) {
+	texts := []string{"a", "b", "c", "d", "e"}
+
+	testCases := []struct {
+		name  string
+		drain bool
+		want  []string
+	}{
+		{
+			name:  "drain",
+			drain: true,
+			want:  []string{"a", "b"},
+		},
+		{
+			name:  "no drain",
+			drain: false,
+			want:  nil,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var stopped atomic.Bool
+			seqs := model.TeeWithConfig(teeSource(texts, nil, &stopped), 2, model.TeeConfig{
+				DrainOnPrimaryStop: tc.drain,
+			})
+
+			primary := collect(seqs[0], 2)
+			if diff := cmp.Diff([]string{"a", "b"}, primary); diff != "" {
+				t.Errorf("primary mismatch (-want +got):\n%s", diff)
+			}
+			if !stopped.Load() {
+				t.Error("source was not stopped after the primary consumer stopped")
+			}
+			if diff := cmp.Diff(tc.want, collect(seqs[1], 0)); diff != "" {
+				t.Errorf("secondary mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestTee_NoGoroutineLeak(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	for _, policy := range []model.SlowConsumerPolicy{model.SlowConsumerBlock, model.SlowConsumerDropOldest, model.SlowConsumerCancel} {
+		texts := []string{"a", "b", "c", "d", "e", "f", "g", "h"}
+		seqs := model.TeeWithConfig(teeSource(texts, nil, nil), 4, model.TeeConfig{
+			BufferSize: 2,
+			Policy:     policy,
+		})
+
+		var wg sync.WaitGroup
+		for i, seq := range seqs[1:] {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				// The first secondary consumer stops early, the others read everything.
+				limit := 0
+				if i == 0 {
+					limit = 1
+				}
+				collect(seq, limit)
+			}()
+		}
+		collect(seqs[0], 3)
+		wg.Wait()
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before {
+		if time.Now().After(deadline) {
+			t.Fatalf("goroutines leaked: before %d, after %d", before, runtime.NumGoroutine())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,290 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package prompt defines agent instructions as versioned templates, kept
+// outside of the code and resolved on every request.
+//
+// A [TemplateStore] holds the versions of the templates; [FSStore] reads
+// them from a directory and [InMemoryStore] keeps them in memory. An
+// [Instruction] renders a template against the [Vars] of the request, and is
+// installed as the instruction provider of an agent:
+//
+//	instr, err := prompt.NewInstruction(ctx, prompt.Config{Store: store, Name: "support"})
+//	...
+//	a, err := llmagent.New(llmagent.Config{
+//		...
+//		InstructionProvider: instr.Provide,
+//	})
+//
+// Templates use the text/template syntax, e.g.
+//
+//	You help {{.UserID}}, a {{.State.tier}} customer, on {{date .Now}}.
+//
+// with a restricted function set: the text/template builtins except call,
+// and upper, lower, trim, join, default and date.
+package prompt
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"google.golang.org/adk/agent"
+)
+
+// Vars is the variable context the templates are rendered against.
+type Vars struct {
+	// State is a view of the session state, including the app: and user:
+	// keys, e.g. {{.State.tier}} or {{index .State "user:name"}}.
+	State map[string]any
+	// The identifiers of the request.
+	AppName, UserID, SessionID, AgentName, InvocationID string
+	// Now is the time of the request.
+	Now time.Time
+}
+
+// funcs are the functions available to the templates, in addition to the
+// builtins of text/template.
+var funcs = template.FuncMap{
+	// call would let the templates run the functions found in the state.
+	"call": func(any, ...any) (any, error) {
+		return nil, errors.New("call is not available in prompt templates")
+	},
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"trim":  strings.TrimSpace,
+	// join joins the items of a list, e.g. {{join ", " .State.topics}}.
+	"join": func(sep string, items any) (string, error) {
+		switch items := items.(type) {
+		case []string:
+			return strings.Join(items, sep), nil
+		case []any:
+			s := make([]string, len(items))
+			for i, item := range items {
+				s[i] = fmt.Sprint(item)
+			}
+			return strings.Join(s, sep), nil
+		default:
+			return "", fmt.Errorf("cannot join %T", items)
+		}
+	},
+	// default returns value, or def if value is empty.
+	"default": func(def, value any) any {
+		if value == nil || value == "" {
+			return def
+		}
+		return value
+	},
+	// date formats a time, by default as a date.
+	"date": func(t time.Time, layout ...string) string {
+		if len(layout) > 0 {
+			return t.Format(layout[0])
+		}
+		return t.Format(time.DateOnly)
+	},
+}
+
+// Config is the configuration of an [Instruction].
+type Config struct {
+	// Store holds the template. The versions are expected not to change:
+	// they are parsed once.
+	Store TemplateStore
+	// Name is the name of the template.
+	Name string
+	// Version is the version of the template. Empty selects the default
+	// version of the store, looked up on every request, so that changing the
+	// default takes effect on the next turn.
+	Version string
+	// VersionStateKey, if set, is a session state key whose value, when it is
+	// a non-empty string, overrides Version, e.g. to roll a version out to
+	// some sessions.
+	VersionStateKey string
+	// Strict makes the rendering fail when the template refers to a state
+	// key that does not exist. Otherwise the missing keys render empty.
+	Strict bool
+	// Now returns the time of the requests.
+	// Optional: if nil, time.Now is used.
+	Now func() time.Time
+}
+
+// Resolution identifies the template that served a request.
+type Resolution struct {
+	Name    string
+	Version string
+	// VarsHash is a hash of the variables the template was rendered with,
+	// except the time.
+	VarsHash string
+}
+
+// String returns name@version#hash.
+func (r Resolution) String() string {
+	return r.Name + "@" + r.Version + "#" + r.VarsHash
+}
+
+// maxResolutions bounds the number of invocations whose resolution is kept.
+const maxResolutions = 256
+
+// Instruction renders a prompt template as the instruction of an agent.
+type Instruction struct {
+	cfg Config
+
+	mu sync.Mutex
+	// parsed caches the parsed templates by name@version.
+	parsed map[string]*template.Template
+	// resolutions holds the resolutions of the recent invocations, in order.
+	resolutions      map[string]Resolution
+	resolutionsOrder []string
+}
+
+// NewInstruction returns an Instruction rendering the configured template.
+// It loads and parses the configured version, or the default one, so that
+// the errors of the template surface when the agent is built.
+func NewInstruction(ctx context.Context, cfg Config) (*Instruction, error) {
+	if cfg.Store == nil {
+		return nil, errors.New("prompt template store is required")
+	}
+	if cfg.Name == "" {
+		return nil, errors.New("prompt template name is required")
+	}
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+	i := &Instruction{cfg: cfg, parsed: make(map[string]*template.Template), resolutions: make(map[string]Resolution)}
+	if _, _, err := i.template(ctx, cfg.Version); err != nil {
+		return nil, err
+	}
+	return i, nil
+}
+
+// template returns the parsed version of the template, and its version.
+func (i *Instruction) template(ctx context.Context, version string) (*template.Template, string, error) {
+	t, err := i.cfg.Store.Get(ctx, i.cfg.Name, version)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get prompt template %q: %w", i.cfg.Name, err)
+	}
+	id := t.Name + "@" + t.Version
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if parsed, ok := i.parsed[id]; ok {
+		return parsed, t.Version, nil
+	}
+	missingKey := "missingkey=default"
+	if i.cfg.Strict {
+		missingKey = "missingkey=error"
+	}
+	parsed, err := template.New(id).Funcs(funcs).Option(missingKey).Parse(t.Text)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to parse prompt template %s: %w", id, err)
+	}
+	i.parsed[id] = parsed
+	return parsed, t.Version, nil
+}
+
+// Provide is an llmagent.InstructionProvider rendering the template for
+// the request.
+func (i *Instruction) Provide(ctx agent.ReadonlyContext) (string, error) {
+	version := i.cfg.Version
+	if i.cfg.VersionStateKey != "" {
+		if v, err := ctx.ReadonlyState().Get(i.cfg.VersionStateKey); err == nil {
+			if s, ok := v.(string); ok && s != "" {
+				version = s
+			}
+		}
+	}
+	tmpl, version, err := i.template(ctx, version)
+	if err != nil {
+		return "", err
+	}
+
+	vars := Vars{
+		State:        make(map[string]any),
+		AppName:      ctx.AppName(),
+		UserID:       ctx.UserID(),
+		SessionID:    ctx.SessionID(),
+		AgentName:    ctx.AgentName(),
+		InvocationID: ctx.InvocationID(),
+		Now:          i.cfg.Now(),
+	}
+	if state := ctx.ReadonlyState(); state != nil {
+		maps.Insert(vars.State, state.All())
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, vars); err != nil {
+		return "", fmt.Errorf("failed to render prompt template %s@%s: %w", i.cfg.Name, version, err)
+	}
+	text := b.String()
+	if !i.cfg.Strict {
+		// The missing keys of the state are rendered as "<no value>".
+		text = strings.ReplaceAll(text, "<no value>", "")
+	}
+
+	i.record(ctx.InvocationID(), Resolution{Name: i.cfg.Name, Version: version, VarsHash: varsHash(vars)})
+	return text, nil
+}
+
+// Resolved returns the resolution of the template for the given
+// invocation, if the instruction was rendered for it recently.
+func (i *Instruction) Resolved(invocationID string) (Resolution, bool) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	r, ok := i.resolutions[invocationID]
+	return r, ok
+}
+
+// Fingerprint returns the resolution of the template for the invocation of
+// ctx as a string, or an empty string if the instruction was not rendered
+// for it. It can be included in the configuration fingerprint of the agent,
+// e.g. in the Fingerprint function of feedbacktool.Config, so that audits
+// show which version of the prompt served a request.
+func (i *Instruction) Fingerprint(ctx agent.ReadonlyContext) string {
+	if r, ok := i.Resolved(ctx.InvocationID()); ok {
+		return r.String()
+	}
+	return ""
+}
+
+func (i *Instruction) record(invocationID string, r Resolution) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if _, ok := i.resolutions[invocationID]; !ok {
+		i.resolutionsOrder = append(i.resolutionsOrder, invocationID)
+		if len(i.resolutionsOrder) > maxResolutions {
+			delete(i.resolutions, i.resolutionsOrder[0])
+			i.resolutionsOrder = i.resolutionsOrder[1:]
+		}
+	}
+	i.resolutions[invocationID] = r
+}
+
+// varsHash returns a short hash of the variables, except the time and the
+// invocation ID, which change on every request.
+func varsHash(v Vars) string {
+	v.Now, v.InvocationID = time.Time{}, ""
+	// json.Marshal sorts map keys, so the hash is stable.
+	data, err := json.Marshal(v)
+	if err != nil {
+		data = fmt.Append(nil, v)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:6])
+}
@@ -0,0 +1,254 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prompt_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/internal/testutil"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/prompt"
+)
+
+func TestInMemoryStore(t *testing.T) {
+	store := prompt.NewInMemoryStore()
+	store.Put(prompt.Template{Name: "support", Version: "v1", Text: "one", Metadata: map[string]string{"author": "ana"}})
+	store.Put(prompt.Template{Name: "support", Version: "v2", Text: "two"})
+
+	for _, tc := range []struct {
+		version string
+		want    string
+	}{
+		{"", "one"},
+		{"v1", "one"},
+		{"v2", "two"},
+	} {
+		got, err := store.Get(t.Context(), "support", tc.version)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Text != tc.want {
+			t.Errorf("Get(%q) text = %q, want %q", tc.version, got.Text, tc.want)
+		}
+	}
+	if err := store.SetDefault("support", "v2"); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := store.Get(t.Context(), "support", ""); got.Version != "v2" {
+		t.Errorf("Get() of the default version = %q, want v2", got.Version)
+	}
+	if _, err := store.Get(t.Context(), "support", "v3"); !errors.Is(err, prompt.ErrNotFound) {
+		t.Errorf("Get() of a missing version error = %v, want ErrNotFound", err)
+	}
+	if err := store.SetDefault("billing", "v1"); !errors.Is(err, prompt.ErrNotFound) {
+		t.Errorf("SetDefault() of a missing template error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestFSStore(t *testing.T) {
+	store := prompt.FSStore(fstest.MapFS{
+		"support/default":   {Data: []byte("v2\n")},
+		"support/v1.tmpl":   {Data: []byte("one")},
+		"support/v2.tmpl":   {Data: []byte("two")},
+		"support/v2.json":   {Data: []byte(`{"author": "ana"}`)},
+		"nodefault/v1.tmpl": {Data: []byte("one")},
+	})
+
+	got, err := store.Get(t.Context(), "support", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &prompt.Template{Name: "support", Version: "v2", Text: "two", Metadata: map[string]string{"author": "ana"}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Get() mismatch (-want +got):\n%s", diff)
+	}
+	if got, err := store.Get(t.Context(), "support", "v1"); err != nil || got.Text != "one" {
+		t.Errorf("Get(v1) = (%+v, %v), want the first version", got, err)
+	}
+	for _, tc := range []struct{ name, version string }{
+		{"support", "v3"},
+		{"nodefault", ""},
+		{"missing", "v1"},
+	} {
+		if _, err := store.Get(t.Context(), tc.name, tc.version); !errors.Is(err, prompt.ErrNotFound) {
+			t.Errorf("Get(%q, %q) error = %v, want ErrNotFound", tc.name, tc.version, err)
+		}
+	}
+	if _, err := store.Get(t.Context(), "../support", "v1"); err == nil {
+		t.Error("Get() of an invalid name succeeded, want an error")
+	}
+}
+
+var now = time.Date(2025, 3, 14, 9, 30, 0, 0, time.UTC)
+
+// newRunner returns a runner of an agent whose instruction is instr.
+func newRunner(t *testing.T, instr *prompt.Instruction, state map[string]any, turns int) (*testutil.TestAgentRunner, *testutil.MockModel) {
+	t.Helper()
+	llm := &testutil.MockModel{}
+	for range turns {
+		llm.Responses = append(llm.Responses, genai.NewContentFromText("ok", genai.RoleModel))
+	}
+	a, err := llmagent.New(llmagent.Config{Name: "helper", Model: llm, InstructionProvider: instr.Provide})
+	if err != nil {
+		t.Fatal(err)
+	}
+	runner := testutil.NewTestAgentRunner(t, a)
+	runner.SetInitSessionState(state)
+	return runner, llm
+}
+
+func instruction(t *testing.T, llm *testutil.MockModel, i int) string {
+	t.Helper()
+	req := llm.Requests[i]
+	if req.Config == nil || req.Config.SystemInstruction == nil {
+		t.Fatalf("request %d has no system instruction", i)
+	}
+	return req.Config.SystemInstruction.Parts[0].Text
+}
+
+func TestInstruction_Render(t *testing.T) {
+	store := prompt.NewInMemoryStore()
+	store.Put(prompt.Template{Name: "support", Version: "v1", Text: `Help {{.UserID}} ({{upper .State.tier}}) on {{date .Now}} about {{join ", " .State.topics}}.{{.State.note}}`})
+
+	for _, tc := range []struct {
+		name    string
+		strict  bool
+		state   map[string]any
+		want    string
+		wantErr bool
+	}{
+		{
+			name:  "AllVariables",
+			state: map[string]any{"tier": "gold", "topics": []any{"billing", "refunds"}, "note": " Be brief."},
+			want:  "Help test_user (GOLD) on 2025-03-14 about billing, refunds. Be brief.",
+		},
+		{
+			name:  "LenientMissingKey",
+			state: map[string]any{"tier": "gold", "topics": []string{"billing"}},
+			want:  "Help test_user (GOLD) on 2025-03-14 about billing.",
+		},
+		{
+			name:    "StrictMissingKey",
+			strict:  true,
+			state:   map[string]any{"tier": "gold", "topics": []string{"billing"}},
+			wantErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			instr, err := prompt.NewInstruction(t.Context(), prompt.Config{Store: store, Name: "support", Strict: tc.strict, Now: func() time.Time { return now }})
+			if err != nil {
+				t.Fatal(err)
+			}
+			runner, llm := newRunner(t, instr, tc.state, 1)
+			_, err = testutil.CollectEvents(runner.Run(t, "session", "hi"))
+			if tc.wantErr {
+				if err == nil {
+					t.Error("Run() succeeded, want a rendering error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := instruction(t, llm, 0); got != tc.want {
+				t.Errorf("instruction = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestInstruction_Errors(t *testing.T) {
+	store := prompt.NewInMemoryStore()
+	store.Put(prompt.Template{Name: "broken", Version: "v1", Text: "Hello {{.UserID"})
+	store.Put(prompt.Template{Name: "caller", Version: "v1", Text: `{{call .State.fn}}`})
+
+	// The default version is validated eagerly.
+	if _, err := prompt.NewInstruction(t.Context(), prompt.Config{Store: store, Name: "broken"}); err == nil {
+		t.Error("NewInstruction() of a broken template succeeded, want an error")
+	}
+	if _, err := prompt.NewInstruction(t.Context(), prompt.Config{Store: store, Name: "missing"}); !errors.Is(err, prompt.ErrNotFound) {
+		t.Errorf("NewInstruction() of a missing template error = %v, want ErrNotFound", err)
+	}
+
+	// call is not available to the templates.
+	instr, err := prompt.NewInstruction(t.Context(), prompt.Config{Store: store, Name: "caller"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	runner, _ := newRunner(t, instr, map[string]any{"fn": "x"}, 1)
+	if _, err := testutil.CollectEvents(runner.Run(t, "session", "hi")); err == nil || !strings.Contains(err.Error(), "call is not available") {
+		t.Errorf("Run() error = %v, want call to be unavailable", err)
+	}
+}
+
+func TestInstruction_VersionSwitch(t *testing.T) {
+	store := prompt.NewInMemoryStore()
+	store.Put(prompt.Template{Name: "support", Version: "v1", Text: "Version one for {{.State.tier}}."})
+	store.Put(prompt.Template{Name: "support", Version: "v2", Text: "Version two for {{.State.tier}}."})
+	instr, err := prompt.NewInstruction(t.Context(), prompt.Config{Store: store, Name: "support"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var fingerprints []string
+	llm := &testutil.MockModel{Responses: []*genai.Content{
+		genai.NewContentFromText("ok", genai.RoleModel),
+		genai.NewContentFromText("ok", genai.RoleModel),
+	}}
+	a, err := llmagent.New(llmagent.Config{
+		Name:                "helper",
+		Model:               llm,
+		InstructionProvider: instr.Provide,
+		AfterModelCallbacks: []llmagent.AfterModelCallback{func(ctx agent.CallbackContext, resp *model.LLMResponse, err error) (*model.LLMResponse, error) {
+			fingerprints = append(fingerprints, instr.Fingerprint(ctx))
+			return nil, nil
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	runner := testutil.NewTestAgentRunner(t, a)
+	runner.SetInitSessionState(map[string]any{"tier": "gold"})
+
+	if _, err := testutil.CollectEvents(runner.Run(t, "session", "hi")); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SetDefault("support", "v2"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := testutil.CollectEvents(runner.Run(t, "session", "hi again")); err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff([]string{"Version one for gold.", "Version two for gold."}, []string{instruction(t, llm, 0), instruction(t, llm, 1)}); diff != "" {
+		t.Errorf("instructions mismatch (-want +got):\n%s", diff)
+	}
+	if len(fingerprints) != 2 || !strings.HasPrefix(fingerprints[0], "support@v1#") || !strings.HasPrefix(fingerprints[1], "support@v2#") {
+		t.Errorf("fingerprints = %q, want support@v1 then support@v2", fingerprints)
+	}
+	// The variables did not change: only the version differs.
+	if _, hash0, _ := strings.Cut(fingerprints[0], "#"); !strings.HasSuffix(fingerprints[1], "#"+hash0) {
+		t.Errorf("fingerprints = %q, want the same variables hash", fingerprints)
+	}
+}
@@ -0,0 +1,156 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prompt
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"path"
+	"strings"
+	"sync"
+)
+
+// ErrNotFound is returned by the stores for an unknown template or version.
+var ErrNotFound = errors.New("prompt template not found")
+
+// Template is a version of a prompt template.
+type Template struct {
+	Name    string
+	Version string
+	// Text is the text/template source of the template.
+	Text string
+	// Metadata is free-form information about the template, e.g. its author
+	// or the change it introduced.
+	Metadata map[string]string
+}
+
+// TemplateStore stores the versions of the prompt templates.
+type TemplateStore interface {
+	// Get returns the given version of the template named name. An empty
+	// version selects the default version of the template.
+	Get(ctx context.Context, name, version string) (*Template, error)
+}
+
+// InMemoryStore is a TemplateStore keeping the templates in memory. It is
+// safe for concurrent use.
+type InMemoryStore struct {
+	mu        sync.RWMutex
+	templates map[string]map[string]Template
+	defaults  map[string]string
+}
+
+// NewInMemoryStore returns an empty InMemoryStore.
+func NewInMemoryStore() *InMemoryStore {
+	return &InMemoryStore{templates: make(map[string]map[string]Template), defaults: make(map[string]string)}
+}
+
+// Put adds a version of a template. The first version of a template is its
+// default version.
+func (s *InMemoryStore) Put(t Template) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.templates[t.Name] == nil {
+		s.templates[t.Name] = make(map[string]Template)
+	}
+	t.Metadata = maps.Clone(t.Metadata)
+	s.templates[t.Name][t.Version] = t
+	if _, ok := s.defaults[t.Name]; !ok {
+		s.defaults[t.Name] = t.Version
+	}
+}
+
+// SetDefault sets the default version of a template. The requests resolved
+// afterwards use it.
+func (s *InMemoryStore) SetDefault(name, version string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.templates[name][version]; !ok {
+		return fmt.Errorf("%w: %s@%s", ErrNotFound, name, version)
+	}
+	s.defaults[name] = version
+	return nil
+}
+
+// Get implements TemplateStore.
+func (s *InMemoryStore) Get(_ context.Context, name, version string) (*Template, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if version == "" {
+		version = s.defaults[name]
+	}
+	t, ok := s.templates[name][version]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s@%s", ErrNotFound, name, version)
+	}
+	t.Metadata = maps.Clone(t.Metadata)
+	return &t, nil
+}
+
+// FSStore returns a TemplateStore reading the templates from fsys, e.g. an
+// os.DirFS or an embed.FS. Every template is a directory holding:
+//   - <version>.tmpl, the text of every version;
+//   - <version>.json, optionally, the metadata of the version as a JSON
+//     object of strings;
+//   - default, the name of the default version.
+//
+// The files are read on every lookup, so that updates of the directory are
+// picked up by the next requests.
+func FSStore(fsys fs.FS) TemplateStore {
+	return &fsStore{fsys: fsys}
+}
+
+type fsStore struct {
+	fsys fs.FS
+}
+
+// Get implements TemplateStore.
+func (s *fsStore) Get(_ context.Context, name, version string) (*Template, error) {
+	if !fs.ValidPath(name) || strings.Contains(name, "/") || (version != "" && (!fs.ValidPath(version) || strings.Contains(version, "/"))) {
+		return nil, fmt.Errorf("invalid template name or version %q@%q", name, version)
+	}
+	if version == "" {
+		data, err := fs.ReadFile(s.fsys, path.Join(name, "default"))
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("%w: %s has no default version", ErrNotFound, name)
+		}
+		if err != nil {
+			return nil, err
+		}
+		version = strings.TrimSpace(string(data))
+	}
+	text, err := fs.ReadFile(s.fsys, path.Join(name, version+".tmpl"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s@%s", ErrNotFound, name, version)
+	}
+	if err != nil {
+		return nil, err
+	}
+	t := &Template{Name: name, Version: version, Text: string(text)}
+	metadata, err := fs.ReadFile(s.fsys, path.Join(name, version+".json"))
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return nil, err
+	default:
+		if err := json.Unmarshal(metadata, &t.Metadata); err != nil {
+			return nil, fmt.Errorf("invalid metadata of %s@%s: %w", name, version, err)
+		}
+	}
+	return t, nil
+}
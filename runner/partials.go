@@ -0,0 +1,104 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"context"
+
+	"google.golang.org/adk/internal/llminternal"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/session"
+)
+
+// PartialPolicy controls which of the partial events streamed by the agents
+// are appended to the session. It does not change the events yielded by
+// [Runner.Run]: the live consumers of the stream always get all of them. The
+// partial events selected are appended with a context marked by
+// session.KeepPartials, so that the session service stores them.
+//
+// Whatever the policy, the event ending a streamed response is stored with
+// the aggregated contents of the response, and the stored partial events are
+// left out of the history sent to the models.
+type PartialPolicy int
+
+const (
+	// DropPartials stores none of the partial events.
+	DropPartials PartialPolicy = iota
+	// SampledPartials stores every Nth partial event of a streamed response,
+	// see [Config.PartialSampleInterval].
+	SampledPartials
+	// AllPartials stores all the partial events, e.g. for a precise replay of
+	// the streams.
+	AllPartials
+)
+
+// defaultPartialSampleInterval is the default of
+// [Config.PartialSampleInterval].
+const defaultPartialSampleInterval = 10
+
+// partialRecorder selects the events of a run to store in the session,
+// following a PartialPolicy.
+type partialRecorder struct {
+	policy   PartialPolicy
+	interval int
+	// partials holds the partial responses streamed by each author since its
+	// last complete event.
+	partials map[string][]*model.LLMResponse
+}
+
+func newPartialRecorder(policy PartialPolicy, interval int) *partialRecorder {
+	if interval <= 0 {
+		interval = defaultPartialSampleInterval
+	}
+	return &partialRecorder{policy: policy, interval: interval, partials: make(map[string][]*model.LLMResponse)}
+}
+
+// record returns the event to store for event, or nil if it is not stored.
+func (r *partialRecorder) record(event *session.Event) *session.Event {
+	partials := r.partials[event.Author]
+	if event.Partial {
+		r.partials[event.Author] = append(partials, &event.LLMResponse)
+		switch r.policy {
+		case AllPartials:
+			return event
+		case SampledPartials:
+			if (len(partials)+1)%r.interval == 0 {
+				return event
+			}
+		}
+		return nil
+	}
+
+	delete(r.partials, event.Author)
+	if len(partials) == 0 {
+		return event
+	}
+	aggregated := llminternal.AggregatePartials(partials, &event.LLMResponse)
+	if aggregated == &event.LLMResponse {
+		return event
+	}
+	stored := *event
+	stored.LLMResponse = *aggregated
+	return &stored
+}
+
+// appendContext returns the context appending event to the session: the
+// session services ignore the partial events unless told to keep them.
+func appendContext(ctx context.Context, event *session.Event) context.Context {
+	if event.Partial {
+		return session.KeepPartials(ctx)
+	}
+	return ctx
+}
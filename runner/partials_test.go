@@ -0,0 +1,186 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"context"
+	"iter"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/session"
+)
+
+// streamingModel streams "Hello world!" in the first turn, and records the
+// request of the second turn.
+type streamingModel struct {
+	// aggregated makes the stream end with the aggregated response, like the
+	// Gemini adapter does; otherwise it ends with the last delta.
+	aggregated bool
+	turn       int
+	secondReq  *model.LLMRequest
+}
+
+func (m *streamingModel) Name() string { return "streaming" }
+
+func (m *streamingModel) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {
+		m.turn++
+		if m.turn > 1 {
+			m.secondReq = req
+			yield(&model.LLMResponse{Content: genai.NewContentFromText("bye", genai.RoleModel)}, nil)
+			return
+		}
+		for _, delta := range []string{"Hel", "lo", " ", "wor", "ld"} {
+			if !yield(&model.LLMResponse{Content: genai.NewContentFromText(delta, genai.RoleModel), Partial: true}, nil) {
+				return
+			}
+		}
+		final := &model.LLMResponse{
+			Content:       genai.NewContentFromText("!", genai.RoleModel),
+			FinishReason:  genai.FinishReasonStop,
+			UsageMetadata: &genai.GenerateContentResponseUsageMetadata{TotalTokenCount: 12},
+			TurnComplete:  true,
+		}
+		if m.aggregated {
+			final.Content = genai.NewContentFromText("Hello world!", genai.RoleModel)
+		}
+		yield(final, nil)
+	}
+}
+
+func TestRunner_PartialPolicy(t *testing.T) {
+	type storedEvent struct {
+		Text    string
+		Partial bool
+		Usage   int32
+		Finish  genai.FinishReason
+	}
+	wantFinal := storedEvent{Text: "Hello world!", Usage: 12, Finish: genai.FinishReasonStop}
+
+	testCases := []struct {
+		name     string
+		policy   PartialPolicy
+		interval int
+		want     []storedEvent
+	}{
+		{
+			name:   "DropPartials",
+			policy: DropPartials,
+			want:   []storedEvent{wantFinal},
+		},
+		{
+			name:     "SampledPartials",
+			policy:   SampledPartials,
+			interval: 2,
+			want:     []storedEvent{{Text: "lo", Partial: true}, {Text: "wor", Partial: true}, wantFinal},
+		},
+		{
+			name:   "AllPartials",
+			policy: AllPartials,
+			want: []storedEvent{
+				{Text: "Hel", Partial: true}, {Text: "lo", Partial: true}, {Text: " ", Partial: true},
+				{Text: "wor", Partial: true}, {Text: "ld", Partial: true}, wantFinal,
+			},
+		},
+	}
+
+	for _, aggregated := range []bool{false, true} {
+		shape := "Deltas"
+		if aggregated {
+			shape = "Aggregated"
+		}
+		var wantContents []*genai.Content
+		for _, tc := range testCases {
+			t.Run(shape+"/"+tc.name, func(t *testing.T) {
+				ctx := t.Context()
+				llm := &streamingModel{aggregated: aggregated}
+				a, err := llmagent.New(llmagent.Config{Name: "agent", Model: llm})
+				if err != nil {
+					t.Fatal(err)
+				}
+				sessionService := session.InMemoryService()
+				r, err := New(Config{
+					AppName:               "app",
+					Agent:                 a,
+					SessionService:        sessionService,
+					PartialEvents:         tc.policy,
+					PartialSampleInterval: tc.interval,
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if _, err := sessionService.Create(ctx, &session.CreateRequest{AppName: "app", UserID: "user", SessionID: "session"}); err != nil {
+					t.Fatal(err)
+				}
+				run := func(msg string) []*session.Event {
+					var events []*session.Event
+					cfg := agent.RunConfig{StreamingMode: agent.StreamingModeSSE}
+					for ev, err := range r.Run(ctx, "user", "session", genai.NewContentFromText(msg, genai.RoleUser), cfg) {
+						if err != nil {
+							t.Fatal(err)
+						}
+						events = append(events, ev)
+					}
+					return events
+				}
+
+				// The live consumers get all the events, whatever the policy.
+				if got := run("hi"); len(got) != 6 {
+					t.Errorf("Run() yielded %d events, want 6", len(got))
+				}
+
+				resp, err := sessionService.Get(ctx, &session.GetRequest{AppName: "app", UserID: "user", SessionID: "session"})
+				if err != nil {
+					t.Fatal(err)
+				}
+				var got []storedEvent
+				for ev := range resp.Session.Events().All() {
+					if ev.Author == "user" {
+						continue
+					}
+					stored := storedEvent{Partial: ev.Partial, Finish: ev.FinishReason}
+					for _, p := range ev.Content.Parts {
+						stored.Text += p.Text
+					}
+					if ev.UsageMetadata != nil {
+						stored.Usage = ev.UsageMetadata.TotalTokenCount
+					}
+					got = append(got, stored)
+				}
+				if diff := cmp.Diff(tc.want, got); diff != "" {
+					t.Errorf("stored events mismatch (-want +got):\n%s", diff)
+				}
+
+				// The history of the next turn is the same for all policies.
+				run("bye")
+				if wantContents == nil {
+					wantContents = llm.secondReq.Contents
+					if got := wantContents[1].Parts[0].Text; !strings.Contains(got, "Hello world!") {
+						t.Errorf("history answer = %q, want the aggregated text", got)
+					}
+				} else if diff := cmp.Diff(wantContents, llm.secondReq.Contents); diff != "" {
+					t.Errorf("next turn contents mismatch with DropPartials (-want +got):\n%s", diff)
+				}
+			})
+		}
+	}
+}
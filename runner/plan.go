@@ -0,0 +1,84 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"context"
+	"fmt"
+	"iter"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/plan"
+	"google.golang.org/adk/session"
+)
+
+// ApprovePlan approves the plan proposed in the session, with the steps and
+// edited arguments of approval, and resumes the agent that proposed it: the
+// approved steps run in order, and the model gets their results. The
+// approval is rejected, and the agent does not run, if the plan is not
+// waiting for a decision or if approval is invalid, see [plan.Plan.Approve].
+func (r *Runner) ApprovePlan(ctx context.Context, userID, sessionID, planID string, approval plan.Approval, cfg agent.RunConfig) iter.Seq2[*session.Event, error] {
+	return r.decidePlan(ctx, userID, sessionID, planID, func(p *plan.Plan) error {
+		return p.Approve(approval)
+	}, cfg)
+}
+
+// RejectPlan rejects the plan proposed in the session, and resumes the agent
+// that proposed it: the model is told that none of the steps ran, and why.
+func (r *Runner) RejectPlan(ctx context.Context, userID, sessionID, planID, reason string, cfg agent.RunConfig) iter.Seq2[*session.Event, error] {
+	return r.decidePlan(ctx, userID, sessionID, planID, func(p *plan.Plan) error {
+		return p.Reject(reason)
+	}, cfg)
+}
+
+// decidePlan records the decision on the plan in the session, and runs the
+// agent that proposed it.
+func (r *Runner) decidePlan(ctx context.Context, userID, sessionID, planID string, decide func(*plan.Plan) error, cfg agent.RunConfig) iter.Seq2[*session.Event, error] {
+	return func(yield func(*session.Event, error) bool) {
+		resp, err := r.sessionService.Get(ctx, &session.GetRequest{
+			AppName:   r.appName,
+			UserID:    userID,
+			SessionID: sessionID,
+		})
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		p, err := plan.Get(resp.Session.State(), planID)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		agentToRun := findAgent(r.rootAgent, p.Agent)
+		if agentToRun == nil {
+			yield(nil, fmt.Errorf("failed to find agent %q of plan %q", p.Agent, planID))
+			return
+		}
+		if err := decide(p); err != nil {
+			yield(nil, err)
+			return
+		}
+		delta, err := p.StateDelta()
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		for ev, err := range r.run(ctx, userID, sessionID, nil, delta, agentToRun, cfg) {
+			if !yield(ev, err) {
+				return
+			}
+		}
+	}
+}
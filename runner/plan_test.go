@@ -0,0 +1,250 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"context"
+	"iter"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/agent/plan"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+)
+
+// planningModel answers with its responses in order, and records the
+// requests.
+type planningModel struct {
+	responses []*genai.Content
+	requests  []*model.LLMRequest
+}
+
+func (m *planningModel) Name() string { return "scripted" }
+
+func (m *planningModel) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {
+		m.requests = append(m.requests, req)
+		resp := m.responses[0]
+		m.responses = m.responses[1:]
+		yield(&model.LLMResponse{Content: resp}, nil)
+	}
+}
+
+// functionResponses returns the function responses of the last request of
+// m, by name.
+func (m *planningModel) functionResponses() map[string]map[string]any {
+	responses := make(map[string]map[string]any)
+	contents := m.requests[len(m.requests)-1].Contents
+	for _, p := range contents[len(contents)-1].Parts {
+		if p.FunctionResponse != nil {
+			responses[p.FunctionResponse.Name] = p.FunctionResponse.Response
+		}
+	}
+	return responses
+}
+
+type lookupArgs struct {
+	Owner string `json:"owner"`
+}
+
+type lookupResult struct {
+	Account string `json:"account"`
+}
+
+type transferArgs struct {
+	To     string `json:"to"`
+	Amount int    `json:"amount"`
+}
+
+type transferResult struct {
+	OK bool `json:"ok"`
+}
+
+type planFixture struct {
+	runner    *Runner
+	model     *planningModel
+	transfers []transferArgs
+}
+
+func newPlanFixture(t *testing.T, responses ...*genai.Content) *planFixture {
+	t.Helper()
+	f := &planFixture{model: &planningModel{responses: responses}}
+	lookup, err := functiontool.New(functiontool.Config{Name: "lookup", Description: "looks up the account of an owner"}, func(_ tool.Context, args lookupArgs) (lookupResult, error) {
+		return lookupResult{Account: "acct-" + args.Owner}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	transfer, err := functiontool.New(functiontool.Config{Name: "transfer", Description: "transfers money"}, func(_ tool.Context, args transferArgs) (transferResult, error) {
+		f.transfers = append(f.transfers, args)
+		return transferResult{OK: true}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := llmagent.New(llmagent.Config{Name: "agent", Model: f.model, Tools: []tool.Tool{lookup, transfer}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessionService := session.InMemoryService()
+	if _, err := sessionService.Create(t.Context(), &session.CreateRequest{AppName: "app", UserID: "user", SessionID: "session"}); err != nil {
+		t.Fatal(err)
+	}
+	if f.runner, err = New(Config{AppName: "app", Agent: a, SessionService: sessionService}); err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+// propose runs the agent, and returns the proposed plan.
+func (f *planFixture) propose(t *testing.T) *plan.Plan {
+	t.Helper()
+	var proposed *plan.Plan
+	for ev, err := range f.runner.Run(t.Context(), "user", "session", genai.NewContentFromText("pay bob", genai.RoleUser), agent.RunConfig{ProposePlans: true}) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p := plan.FromEvent(ev); p != nil {
+			proposed = p
+		}
+	}
+	if proposed == nil {
+		t.Fatal("no plan was proposed")
+	}
+	return proposed
+}
+
+// final collects events, and returns the text of the final response.
+func final(t *testing.T, events iter.Seq2[*session.Event, error]) string {
+	t.Helper()
+	var text string
+	for ev, err := range events {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ev.IsFinalResponse() && ev.Content != nil {
+			text = ev.Content.Parts[0].Text
+		}
+	}
+	return text
+}
+
+func paymentCalls() *genai.Content {
+	return &genai.Content{Role: genai.RoleModel, Parts: []*genai.Part{
+		{FunctionCall: &genai.FunctionCall{ID: "c1", Name: "lookup", Args: map[string]any{"owner": "bob"}}},
+		{FunctionCall: &genai.FunctionCall{ID: "c2", Name: "transfer", Args: map[string]any{"to": "${step1.account}", "amount": 100}}},
+	}}
+}
+
+func TestRunner_ApprovePlan(t *testing.T) {
+	f := newPlanFixture(t, paymentCalls(), genai.NewContentFromText("paid bob", genai.RoleModel))
+	proposed := f.propose(t)
+
+	var steps []plan.Step
+	for _, step := range proposed.Steps {
+		steps = append(steps, plan.Step{ID: step.ID, CallID: step.CallID, Tool: step.Tool, Args: step.Args, DependsOn: step.DependsOn})
+	}
+	want := []plan.Step{
+		{ID: "step1", CallID: "c1", Tool: "lookup", Args: map[string]any{"owner": "bob"}},
+		{ID: "step2", CallID: "c2", Tool: "transfer", Args: map[string]any{"to": "${step1.account}", "amount": 100}, DependsOn: []string{"step1"}},
+	}
+	if diff := cmp.Diff(want, steps); diff != "" {
+		t.Errorf("proposed steps mismatch (-want +got):\n%s", diff)
+	}
+	if len(f.transfers) != 0 {
+		t.Fatalf("transfers before approval = %v, want none", f.transfers)
+	}
+	if !strings.Contains(f.model.requests[0].Config.SystemInstruction.Parts[len(f.model.requests[0].Config.SystemInstruction.Parts)-1].Text, "${stepN}") {
+		t.Error("the request does not explain the references to the steps")
+	}
+
+	// An edit violating the schema is refused.
+	invalid := plan.Approval{Args: map[string]map[string]any{"step2": {"to": "acct-bob", "amount": "fifty"}}}
+	for _, err := range f.runner.ApprovePlan(t.Context(), "user", "session", proposed.ID, invalid, agent.RunConfig{}) {
+		if err == nil {
+			t.Fatal("ApprovePlan() with invalid arguments succeeded, want an error")
+		}
+	}
+
+	approval := plan.Approval{Args: map[string]map[string]any{"step2": {"to": "${step1.account}", "amount": 50}}}
+	if got := final(t, f.runner.ApprovePlan(t.Context(), "user", "session", proposed.ID, approval, agent.RunConfig{})); got != "paid bob" {
+		t.Errorf("final response = %q, want %q", got, "paid bob")
+	}
+	if diff := cmp.Diff([]transferArgs{{To: "acct-bob", Amount: 50}}, f.transfers); diff != "" {
+		t.Errorf("transfers mismatch (-want +got):\n%s", diff)
+	}
+	wantResponses := map[string]map[string]any{"lookup": {"account": "acct-bob"}, "transfer": {"ok": true}}
+	if diff := cmp.Diff(wantResponses, f.model.functionResponses()); diff != "" {
+		t.Errorf("function responses mismatch (-want +got):\n%s", diff)
+	}
+
+	// The plan is executed: it cannot be approved again.
+	for _, err := range f.runner.ApprovePlan(t.Context(), "user", "session", proposed.ID, plan.Approval{}, agent.RunConfig{}) {
+		if err == nil {
+			t.Error("ApprovePlan() of an executed plan succeeded, want an error")
+		}
+	}
+}
+
+func TestRunner_ApprovePlanPartially(t *testing.T) {
+	f := newPlanFixture(t, paymentCalls(), genai.NewContentFromText("found bob", genai.RoleModel))
+	proposed := f.propose(t)
+
+	// step2 depends on step1.
+	for _, err := range f.runner.ApprovePlan(t.Context(), "user", "session", proposed.ID, plan.Approval{Steps: []string{"step2"}}, agent.RunConfig{}) {
+		if err == nil {
+			t.Fatal("ApprovePlan() without a dependency succeeded, want an error")
+		}
+	}
+
+	approval := plan.Approval{Steps: []string{"step1"}, Reason: "transfers are disabled"}
+	if got := final(t, f.runner.ApprovePlan(t.Context(), "user", "session", proposed.ID, approval, agent.RunConfig{})); got != "found bob" {
+		t.Errorf("final response = %q, want %q", got, "found bob")
+	}
+	if len(f.transfers) != 0 {
+		t.Errorf("transfers = %v, want none", f.transfers)
+	}
+	wantResponses := map[string]map[string]any{
+		"lookup":   {"account": "acct-bob"},
+		"transfer": {"error": "This call was not approved and did not run. Reason: transfers are disabled"},
+	}
+	if diff := cmp.Diff(wantResponses, f.model.functionResponses()); diff != "" {
+		t.Errorf("function responses mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestRunner_RejectPlan(t *testing.T) {
+	f := newPlanFixture(t, paymentCalls(), genai.NewContentFromText("I did not pay bob", genai.RoleModel))
+	proposed := f.propose(t)
+
+	if got := final(t, f.runner.RejectPlan(t.Context(), "user", "session", proposed.ID, "bob was paid yesterday", agent.RunConfig{})); got != "I did not pay bob" {
+		t.Errorf("final response = %q, want %q", got, "I did not pay bob")
+	}
+	if len(f.transfers) != 0 {
+		t.Errorf("transfers = %v, want none", f.transfers)
+	}
+	rejected := map[string]any{"error": "The plan was rejected and this call did not run. Reason: bob was paid yesterday"}
+	if diff := cmp.Diff(map[string]map[string]any{"lookup": rejected, "transfer": rejected}, f.model.functionResponses()); diff != "" {
+		t.Errorf("function responses mismatch (-want +got):\n%s", diff)
+	}
+}
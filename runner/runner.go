@@ -20,11 +20,13 @@ import (
 	"fmt"
 	"iter"
 	"log"
+	"maps"
 
 	"google.golang.org/genai"
 
 	"google.golang.org/adk/agent"
 	"google.golang.org/adk/artifact"
+	"google.golang.org/adk/httpx"
 	"google.golang.org/adk/internal/agent/parentmap"
 	"google.golang.org/adk/internal/agent/runconfig"
 	artifactinternal "google.golang.org/adk/internal/artifact"
@@ -32,9 +34,11 @@ import (
 	"google.golang.org/adk/internal/llminternal"
 	imemory "google.golang.org/adk/internal/memory"
 	"google.golang.org/adk/internal/sessioninternal"
+	"google.golang.org/adk/internal/telemetry"
 	"google.golang.org/adk/memory"
 	"google.golang.org/adk/model"
 	"google.golang.org/adk/session"
+	"google.golang.org/adk/strictness"
 )
 
 // Config is used to create a [Runner].
@@ -48,6 +52,15 @@ type Config struct {
 	ArtifactService artifact.Service
 	// optional
 	MemoryService memory.Service
+
+	// PartialEvents controls which streamed partial events are stored in
+	// the session. Defaults to DropPartials.
+	PartialEvents PartialPolicy
+	// PartialSampleInterval is the interval between the partial events stored
+	// with SampledPartials. Defaults to 10.
+	PartialSampleInterval int
+	// Tracing enables the invocation traces. Optional.
+	Tracing *TracingConfig
 }
 
 // New creates a new [Runner].
@@ -65,6 +78,15 @@ func New(cfg Config) (*Runner, error) {
 		return nil, fmt.Errorf("failed to create agent tree: %w", err)
 	}
 
+	var tracing *TracingConfig
+	if cfg.Tracing != nil {
+		tracing = new(TracingConfig)
+		*tracing = *cfg.Tracing
+		if tracing.ConfigFingerprint == "" {
+			tracing.ConfigFingerprint = configFingerprint(cfg.Agent)
+		}
+	}
+
 	return &Runner{
 		appName:         cfg.AppName,
 		rootAgent:       cfg.Agent,
@@ -72,6 +94,9 @@ func New(cfg Config) (*Runner, error) {
 		artifactService: cfg.ArtifactService,
 		memoryService:   cfg.MemoryService,
 		parents:         parents,
+		partialPolicy:   cfg.PartialEvents,
+		sampleInterval:  cfg.PartialSampleInterval,
+		tracing:         tracing,
 	}, nil
 }
 
@@ -85,16 +110,25 @@ type Runner struct {
 	artifactService artifact.Service
 	memoryService   memory.Service
 
-	parents parentmap.Map
+	parents        parentmap.Map
+	partialPolicy  PartialPolicy
+	sampleInterval int
+	tracing        *TracingConfig
 }
 
 // Run runs the agent for the given user input, yielding events from agents.
 // For each user message it finds the proper agent within an agent tree to
 // continue the conversation within the session.
 func (r *Runner) Run(ctx context.Context, userID, sessionID string, msg *genai.Content, cfg agent.RunConfig) iter.Seq2[*session.Event, error] {
+	return r.run(ctx, userID, sessionID, msg, nil, nil, cfg)
+}
+
+// run runs agentToRun, or the agent found by findAgentToRun if nil. The
+// user event appended to the session carries msg and stateDelta; it is
+// omitted if both are empty.
+func (r *Runner) run(ctx context.Context, userID, sessionID string, msg *genai.Content, stateDelta map[string]any, agentToRun agent.Agent, cfg agent.RunConfig) iter.Seq2[*session.Event, error] {
 	// TODO(hakim): we need to validate whether cfg is compatible with the Agent.
 	//   see adk-python/src/google/adk/runners.py Runner._new_invocation_context.
-	// TODO: setup tracer.
 	return func(yield func(*session.Event, error) bool) {
 		resp, err := r.sessionService.Get(ctx, &session.GetRequest{
 			AppName:   r.appName,
@@ -108,12 +142,31 @@ func (r *Runner) Run(ctx context.Context, userID, sessionID string, msg *genai.C
 
 		session := resp.Session
 
-		agentToRun, err := r.findAgentToRun(session)
-		if err != nil {
-			yield(nil, err)
-			return
+		agentToRun := agentToRun
+		if agentToRun == nil {
+			if agentToRun, err = r.findAgentToRun(session); err != nil {
+				yield(nil, err)
+				return
+			}
+		}
+
+		var inv *telemetry.Invocation
+		if r.tracing != nil {
+			ctx, inv = r.startInvocation(ctx, session, agentToRun)
 		}
 
+		// The warnings of the data dropped during the invocation are
+		// attached to its final responses.
+		warnings := &strictness.Collector{}
+		ctx = strictness.WithCollector(ctx, warnings)
+		exposed := 0
+
+		// The agents add the invocation and their name to the metadata.
+		ctx = httpx.NewContext(ctx, httpx.Metadata{
+			AppName:   session.AppName(),
+			UserID:    session.UserID(),
+			SessionID: session.ID(),
+		})
 		ctx = parentmap.ToContext(ctx, r.parents)
 		ctx = runconfig.ToContext(ctx, &runconfig.RunConfig{
 			StreamingMode: runconfig.StreamingMode(cfg.StreamingMode),
@@ -148,25 +201,42 @@ func (r *Runner) Run(ctx context.Context, userID, sessionID string, msg *genai.C
 			RunConfig:   &cfg,
 		})
 
-		if err := r.appendMessageToSession(ctx, session, msg, cfg.SaveInputBlobsAsArtifacts); err != nil {
+		var traceRefs string
+		if inv != nil {
+			inv.SetInvocationID(ctx.InvocationID())
+			defer r.endInvocation(inv, ctx.InvocationID())
+			traceRefs = inv.Refs()
+		}
+
+		if err := r.appendMessageToSession(ctx, session, msg, stateDelta, cfg.SaveInputBlobsAsArtifacts, traceRefs); err != nil {
+			inv.RecordError(err)
 			yield(nil, err)
 			return
 		}
 
+		recorder := newPartialRecorder(r.partialPolicy, r.sampleInterval)
 		for event, err := range agentToRun.Run(ctx) {
 			if err != nil {
+				inv.RecordError(err)
 				if !yield(event, err) {
 					return
 				}
 				continue
 			}
 
-			// only commit non-partial event to a session service
-			if !event.LLMResponse.Partial {
-				if err := r.sessionService.AppendEvent(ctx, session, event); err != nil {
+			if event.IsFinalResponse() {
+				collected := warnings.Warnings()
+				strictness.Attach(event, collected[exposed:])
+				exposed = len(collected)
+			}
+
+			if stored := recorder.record(event); stored != nil {
+				if err := r.sessionService.AppendEvent(appendContext(ctx, stored), session, stored); err != nil {
+					inv.RecordError(err)
 					yield(nil, fmt.Errorf("failed to add event to session: %w", err))
 					return
 				}
+				inv.RecordEvent(stored)
 			}
 
 			if !yield(event, nil) {
@@ -176,13 +246,16 @@ func (r *Runner) Run(ctx context.Context, userID, sessionID string, msg *genai.C
 	}
 }
 
-func (r *Runner) appendMessageToSession(ctx agent.InvocationContext, storedSession session.Session, msg *genai.Content, saveInputBlobsAsArtifacts bool) error {
-	if msg == nil {
+// appendMessageToSession appends the user message and the state delta to
+// the session. traceRefs, if not empty, are stored in the session state
+// under LastTraceStateKey.
+func (r *Runner) appendMessageToSession(ctx agent.InvocationContext, storedSession session.Session, msg *genai.Content, stateDelta map[string]any, saveInputBlobsAsArtifacts bool, traceRefs string) error {
+	if msg == nil && len(stateDelta) == 0 {
 		return nil
 	}
 
 	artifactsService := ctx.Artifacts()
-	if artifactsService != nil && saveInputBlobsAsArtifacts {
+	if msg != nil && artifactsService != nil && saveInputBlobsAsArtifacts {
 		for i, part := range msg.Parts {
 			if part.InlineData == nil {
 				continue
@@ -198,9 +271,13 @@ func (r *Runner) appendMessageToSession(ctx agent.InvocationContext, storedSessi
 		}
 	}
 
-	event := session.NewEvent(ctx.InvocationID())
+	event := session.NewEventWithContext(ctx, ctx.InvocationID())
 
 	event.Author = "user"
+	maps.Copy(event.Actions.StateDelta, stateDelta)
+	if traceRefs != "" {
+		event.Actions.StateDelta[LastTraceStateKey] = traceRefs
+	}
 	event.LLMResponse = model.LLMResponse{
 		Content: msg,
 	}
@@ -0,0 +1,88 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"context"
+	"iter"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/strictness"
+)
+
+// droppingModel reports a dropped part, and answers.
+type droppingModel struct{}
+
+func (droppingModel) Name() string { return "dropping" }
+
+func (droppingModel) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {
+		if err := strictness.Report(ctx, strictness.Conversion, "dropping", "dropped a part"); err != nil {
+			yield(nil, err)
+			return
+		}
+		yield(&model.LLMResponse{Content: genai.NewContentFromText("done", genai.RoleModel)}, nil)
+	}
+}
+
+func TestRunner_StrictnessWarnings(t *testing.T) {
+	a, err := llmagent.New(llmagent.Config{
+		Name:       "agent",
+		Model:      droppingModel{},
+		Strictness: &strictness.Policy{Level: strictness.Warn},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessionService := session.InMemoryService()
+	if _, err := sessionService.Create(t.Context(), &session.CreateRequest{AppName: "app", UserID: "user", SessionID: "session"}); err != nil {
+		t.Fatal(err)
+	}
+	r, err := New(Config{AppName: "app", Agent: a, SessionService: sessionService})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var final *session.Event
+	for ev, err := range r.Run(t.Context(), "user", "session", genai.NewContentFromText("hi", genai.RoleUser), agent.RunConfig{}) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ev.IsFinalResponse() {
+			final = ev
+		}
+	}
+	want := []strictness.Warning{{Category: strictness.Conversion, Component: "dropping", Message: "dropped a part"}}
+	if diff := cmp.Diff(want, strictness.Warnings(final)); diff != "" {
+		t.Errorf("warnings of the final event mismatch (-want +got):\n%s", diff)
+	}
+
+	// The warnings are stored with the event.
+	resp, err := sessionService.Get(t.Context(), &session.GetRequest{AppName: "app", UserID: "user", SessionID: "session"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := resp.Session.Events()
+	if diff := cmp.Diff(want, strictness.Warnings(events.At(events.Len()-1))); diff != "" {
+		t.Errorf("warnings of the stored event mismatch (-want +got):\n%s", diff)
+	}
+}
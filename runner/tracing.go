@@ -0,0 +1,125 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"slices"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/internal/llminternal"
+	"google.golang.org/adk/internal/telemetry"
+	"google.golang.org/adk/session"
+)
+
+// LastTraceStateKey is the session state key where the references to the
+// trace of the last traced invocation of the session are stored, so that the
+// next invocation links to it.
+const LastTraceStateKey = "_adk_last_trace"
+
+// TracingConfig enables the invocation traces of a [Runner].
+//
+// Each invocation gets a root span, which parents the spans of its model and
+// tool calls. The state changes and the artifact operations of its events are
+// recorded as span events, with the state keys but not their values. At
+// completion, the root span gets the name of the agent, a hash of the session
+// ID, the fingerprint of the configuration, the total tokens, the cost and
+// the final error code, if any. The root span links to the root span of the
+// previous invocation of the session, found in the session state under
+// LastTraceStateKey, which is updated with the user message.
+type TracingConfig struct {
+	// Sampler decides which invocations are traced, independently of the
+	// sampler of the application. Nil traces all the invocations. When an
+	// invocation is dropped, all its spans are parented on an unsampled span
+	// context, so that the parent-based samplers drop them too.
+	Sampler sdktrace.Sampler
+	// Cost returns the cost of an invocation, e.g. computed by a
+	// [google.golang.org/adk/agent/budget.Budget]. Optional.
+	Cost func(invocationID string) (float64, bool)
+	// ConfigFingerprint identifies the configuration of the application.
+	// Defaults to a hash of the agent tree: the names, descriptions,
+	// instructions, models and tools of the agents.
+	ConfigFingerprint string
+}
+
+// startInvocation starts the root span of an invocation of agentToRun.
+func (r *Runner) startInvocation(ctx context.Context, s session.Session, agentToRun agent.Agent) (context.Context, *telemetry.Invocation) {
+	previous, _ := s.State().Get(LastTraceStateKey)
+	previousRefs, _ := previous.(string)
+	return telemetry.StartInvocation(ctx, telemetry.InvocationConfig{
+		Sampler:           r.tracing.Sampler,
+		Previous:          previousRefs,
+		AgentName:         agentToRun.Name(),
+		SessionIDHash:     hashSessionID(s.ID()),
+		ConfigFingerprint: r.tracing.ConfigFingerprint,
+	})
+}
+
+// endInvocation ends the root span of the invocation with the given ID.
+func (r *Runner) endInvocation(inv *telemetry.Invocation, invocationID string) {
+	var cost float64
+	var hasCost bool
+	if r.tracing.Cost != nil {
+		cost, hasCost = r.tracing.Cost(invocationID)
+	}
+	inv.End(cost, hasCost)
+}
+
+// hashSessionID returns a short hash of a session ID, which keeps the
+// invocations of a session together without revealing its ID.
+func hashSessionID(id string) string {
+	sum := sha256.Sum256([]byte(id))
+	return hex.EncodeToString(sum[:8])
+}
+
+// configFingerprint returns a short hash of the configuration of the agent
+// tree rooted at root.
+func configFingerprint(root agent.Agent) string {
+	h := sha256.New()
+	writeAgentConfig(h, root)
+	return hex.EncodeToString(h.Sum(nil)[:8])
+}
+
+func writeAgentConfig(h hash.Hash, a agent.Agent) {
+	fmt.Fprintf(h, "agent %q %q\n", a.Name(), a.Description())
+	if llmAgent, ok := a.(llminternal.Agent); ok {
+		state := llminternal.Reveal(llmAgent)
+		if state.Model != nil {
+			fmt.Fprintf(h, "model %q\n", state.Model.Name())
+		}
+		fmt.Fprintf(h, "instruction %q\nglobal instruction %q\noutput key %q\n", state.Instruction, state.GlobalInstruction, state.OutputKey)
+		var tools []string
+		for _, t := range state.Tools {
+			tools = append(tools, t.Name())
+		}
+		slices.Sort(tools)
+		fmt.Fprintf(h, "tools %q\n", tools)
+		if state.GenerateContentConfig != nil {
+			if b, err := json.Marshal(state.GenerateContentConfig); err == nil {
+				fmt.Fprintf(h, "config %s\n", b)
+			}
+		}
+	}
+	for _, sub := range a.SubAgents() {
+		writeAgentConfig(h, sub)
+	}
+}
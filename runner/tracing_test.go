@@ -0,0 +1,285 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"context"
+	"iter"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/artifact"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+)
+
+// scriptedModel returns its responses in turn, and then fails with err.
+type scriptedModel struct {
+	responses []*model.LLMResponse
+	err       error
+}
+
+func (m *scriptedModel) Name() string { return "scripted" }
+
+func (m *scriptedModel) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {
+		if len(m.responses) == 0 {
+			yield(nil, m.err)
+			return
+		}
+		resp := m.responses[0]
+		m.responses = m.responses[1:]
+		yield(resp, nil)
+	}
+}
+
+func usage(tokens int32) *genai.GenerateContentResponseUsageMetadata {
+	return &genai.GenerateContentResponseUsageMetadata{TotalTokenCount: tokens}
+}
+
+// recordSpans records the spans of the global tracer provider.
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	return recorder
+}
+
+func newTracedRunner(t *testing.T, llm model.LLM, tracing *TracingConfig) *Runner {
+	t.Helper()
+	remember, err := functiontool.New(functiontool.Config{Name: "remember", Description: "remembers a color"}, func(ctx tool.Context, args struct {
+		Color string `json:"color"`
+	}) (map[string]any, error) {
+		if err := ctx.State().Set("color", args.Color); err != nil {
+			return nil, err
+		}
+		if _, err := ctx.Artifacts().Save(ctx, "note.txt", genai.NewPartFromText(args.Color)); err != nil {
+			return nil, err
+		}
+		return map[string]any{"ok": true}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := llmagent.New(llmagent.Config{Name: "painter", Model: llm, Tools: []tool.Tool{remember}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessionService := session.InMemoryService()
+	if _, err := sessionService.Create(t.Context(), &session.CreateRequest{AppName: "app", UserID: "user", SessionID: "session"}); err != nil {
+		t.Fatal(err)
+	}
+	r, err := New(Config{
+		AppName:         "app",
+		Agent:           a,
+		SessionService:  sessionService,
+		ArtifactService: artifact.InMemoryService(),
+		Tracing:         tracing,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func runOnce(t *testing.T, r *Runner) error {
+	t.Helper()
+	for _, err := range r.Run(t.Context(), "user", "session", genai.NewContentFromText("paint it", genai.RoleUser), agent.RunConfig{}) {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// spanNode is a span and its children, by name.
+type spanNode struct {
+	Name     string
+	Children []spanNode
+}
+
+func spanTree(spans []sdktrace.ReadOnlySpan, parent sdktrace.ReadOnlySpan) []spanNode {
+	var nodes []spanNode
+	for _, s := range spans {
+		if (parent == nil && !s.Parent().IsValid()) || (parent != nil && s.Parent().SpanID() == parent.SpanContext().SpanID()) {
+			nodes = append(nodes, spanNode{Name: s.Name(), Children: spanTree(spans, s)})
+		}
+	}
+	return nodes
+}
+
+func attributes(s sdktrace.ReadOnlySpan) map[string]any {
+	m := make(map[string]any)
+	for _, kv := range s.Attributes() {
+		m[string(kv.Key)] = kv.Value.AsInterface()
+	}
+	return m
+}
+
+func TestRunner_Tracing(t *testing.T) {
+	recorder := recordSpans(t)
+	call := &genai.Content{Role: genai.RoleModel, Parts: []*genai.Part{genai.NewPartFromFunctionCall("remember", map[string]any{"color": "blue"})}}
+	llm := &scriptedModel{responses: []*model.LLMResponse{
+		{Content: call, UsageMetadata: usage(10)},
+		{Content: genai.NewContentFromText("done", genai.RoleModel), UsageMetadata: usage(5)},
+		{Content: genai.NewContentFromText("again", genai.RoleModel), UsageMetadata: usage(7)},
+	}}
+	r := newTracedRunner(t, llm, &TracingConfig{
+		Cost: func(string) (float64, bool) { return 0.25, true },
+	})
+
+	if err := runOnce(t, r); err != nil {
+		t.Fatal(err)
+	}
+	if err := runOnce(t, r); err != nil {
+		t.Fatal(err)
+	}
+
+	spans := recorder.Ended()
+	wantTree := []spanNode{
+		{Name: "invocation", Children: []spanNode{{Name: "call_llm"}, {Name: "execute_tool remember"}, {Name: "execute_tool (merged)"}, {Name: "call_llm"}}},
+		{Name: "invocation", Children: []spanNode{{Name: "call_llm"}}},
+	}
+	if diff := cmp.Diff(wantTree, spanTree(spans, nil)); diff != "" {
+		t.Fatalf("span tree mismatch (-want +got):\n%s", diff)
+	}
+
+	var roots []sdktrace.ReadOnlySpan
+	for _, s := range spans {
+		if s.Name() == "invocation" {
+			roots = append(roots, s)
+		}
+	}
+	first, second := roots[0], roots[1]
+	for _, s := range spans {
+		if s.Parent().IsValid() && s.SpanContext().TraceID() != s.Parent().TraceID() {
+			t.Errorf("span %q is not in the trace of its parent", s.Name())
+		}
+	}
+
+	got := attributes(first)
+	invocationID, _ := got["gcp.vertex.agent.invocation_id"].(string)
+	if invocationID == "" {
+		t.Errorf("root span has no invocation ID: %v", got)
+	}
+	want := map[string]any{
+		"gcp.vertex.agent.agent_name":         "painter",
+		"gcp.vertex.agent.invocation_id":      invocationID,
+		"gcp.vertex.agent.session_id_hash":    hashSessionID("session"),
+		"gcp.vertex.agent.config_fingerprint": r.tracing.ConfigFingerprint,
+		"gcp.vertex.agent.total_token_count":  int64(15),
+		"gcp.vertex.agent.cost":               0.25,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("root span attributes mismatch (-want +got):\n%s", diff)
+	}
+	if got := attributes(second)["gcp.vertex.agent.total_token_count"]; got != int64(7) {
+		t.Errorf("total tokens of the second invocation = %v, want 7", got)
+	}
+
+	type spanEvent struct {
+		Name       string
+		Attributes map[string]any
+	}
+	var events []spanEvent
+	for _, ev := range first.Events() {
+		attrs := make(map[string]any)
+		for _, kv := range ev.Attributes {
+			if kv.Key != "gcp.vertex.agent.event_id" {
+				attrs[string(kv.Key)] = kv.Value.AsInterface()
+			}
+		}
+		events = append(events, spanEvent{Name: ev.Name, Attributes: attrs})
+	}
+	wantEvents := []spanEvent{
+		{Name: "state_change", Attributes: map[string]any{"gcp.vertex.agent.state_keys": []string{"color"}}},
+		{Name: "artifact_operation", Attributes: map[string]any{"gcp.vertex.agent.artifact_name": "note.txt", "gcp.vertex.agent.artifact_version": int64(1)}},
+	}
+	if diff := cmp.Diff(wantEvents, events); diff != "" {
+		t.Errorf("root span events mismatch (-want +got):\n%s", diff)
+	}
+
+	if first.SpanContext().TraceID() == second.SpanContext().TraceID() {
+		t.Error("both invocations are in the same trace, want one trace per invocation")
+	}
+	if links := first.Links(); len(links) != 0 {
+		t.Errorf("first invocation links = %v, want none", links)
+	}
+	links := second.Links()
+	if len(links) != 1 {
+		t.Fatalf("second invocation links = %v, want one", links)
+	}
+	if links[0].SpanContext.TraceID() != first.SpanContext().TraceID() || links[0].SpanContext.SpanID() != first.SpanContext().SpanID() {
+		t.Errorf("second invocation links to %v, want the root span of the first invocation %v", links[0].SpanContext, first.SpanContext())
+	}
+	if diff := cmp.Diff([]attribute.KeyValue{attribute.String("gcp.vertex.agent.link_type", "previous_invocation")}, links[0].Attributes, cmp.Comparer(func(a, b attribute.Value) bool { return a == b })); diff != "" {
+		t.Errorf("link attributes mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestRunner_TracingError(t *testing.T) {
+	recorder := recordSpans(t)
+	llm := &scriptedModel{err: &model.Error{Code: model.ErrorCodeRateLimited, Err: context.DeadlineExceeded}}
+	r := newTracedRunner(t, llm, &TracingConfig{ConfigFingerprint: "v42"})
+
+	if err := runOnce(t, r); err == nil {
+		t.Fatal("Run() succeeded, want an error")
+	}
+	var root sdktrace.ReadOnlySpan
+	for _, s := range recorder.Ended() {
+		if s.Name() == "invocation" {
+			root = s
+		}
+	}
+	if root == nil {
+		t.Fatal("no invocation span")
+	}
+	got := attributes(root)
+	if got["gcp.vertex.agent.error_code"] != "RATE_LIMITED" || got["gcp.vertex.agent.config_fingerprint"] != "v42" {
+		t.Errorf("root span attributes = %v, want error code RATE_LIMITED and fingerprint v42", got)
+	}
+	if _, ok := got["gcp.vertex.agent.cost"]; ok {
+		t.Errorf("root span attributes = %v, want no cost", got)
+	}
+	if root.Status().Code != codes.Error {
+		t.Errorf("root span status = %v, want an error", root.Status())
+	}
+}
+
+func TestRunner_TracingSampler(t *testing.T) {
+	recorder := recordSpans(t)
+	llm := &scriptedModel{responses: []*model.LLMResponse{{Content: genai.NewContentFromText("done", genai.RoleModel)}}}
+	r := newTracedRunner(t, llm, &TracingConfig{Sampler: sdktrace.NeverSample()})
+
+	if err := runOnce(t, r); err != nil {
+		t.Fatal(err)
+	}
+	if spans := recorder.Ended(); len(spans) != 0 {
+		t.Errorf("dropped invocation recorded %d spans, want none", len(spans))
+	}
+}
@@ -0,0 +1,195 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package runtimedeps provides the sources of time, randomness and
+// identifiers of the components, so that runs can be made reproducible.
+//
+// By default, the components use the real clock, random jitter and random
+// UUIDs. A replay or an evaluation injects deterministic [Deps] in the
+// context of the run instead: with a [FrozenClock] and a seed, the same
+// scripted run produces the same event IDs, timestamps and retry timing.
+//
+//	clock := runtimedeps.NewFrozenClock(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+//	ctx = runtimedeps.NewContext(ctx, runtimedeps.Seeded(42, clock))
+//	for ev, err := range r.Run(ctx, userID, sessionID, msg, agent.RunConfig{}) {
+//		...
+//	}
+package runtimedeps
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Clock tells the time and waits.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+	// Sleep waits for d, or until ctx is done.
+	Sleep(ctx context.Context, d time.Duration) error
+}
+
+// RealClock is the wall clock.
+type RealClock struct{}
+
+// Now returns time.Now().
+func (RealClock) Now() time.Time {
+	return time.Now()
+}
+
+// Sleep waits for d, or until ctx is done.
+func (RealClock) Sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// FrozenClock is a clock that only moves when advanced. Sleeping advances it
+// instead of waiting, so that the waits of the components, e.g. the retry
+// delays, are recorded in the time without slowing the run down.
+type FrozenClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewFrozenClock returns a clock frozen at now.
+func NewFrozenClock(now time.Time) *FrozenClock {
+	return &FrozenClock{now: now}
+}
+
+// Now returns the time of the clock.
+func (c *FrozenClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Advance moves the clock forward by d.
+func (c *FrozenClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// Sleep advances the clock by d, unless ctx is done.
+func (c *FrozenClock) Sleep(ctx context.Context, d time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	c.Advance(d)
+	return nil
+}
+
+// Deps are the sources of time, randomness and identifiers of a run. They
+// are safe for concurrent use.
+type Deps struct {
+	clock Clock
+
+	// mu guards rand and ids. They are nil for the real dependencies.
+	mu   sync.Mutex
+	rand *rand.Rand
+	ids  io.Reader
+}
+
+var realDeps = &Deps{clock: RealClock{}}
+
+// Real returns the real dependencies: the wall clock, random jitter and
+// random UUIDs. They are used when none are injected.
+func Real() *Deps {
+	return realDeps
+}
+
+// Seeded returns deterministic dependencies: the randomness and the
+// identifiers are derived from seed, and the time is told by clock. The
+// identifiers are UUIDs generated in sequence: concurrent components get
+// them in the order they ask for them. A nil clock is the real clock.
+func Seeded(seed uint64, clock Clock) *Deps {
+	if clock == nil {
+		clock = RealClock{}
+	}
+	var key [32]byte
+	binary.LittleEndian.PutUint64(key[:], seed)
+	ids := rand.NewChaCha8(key)
+	key[31] = 1
+	return &Deps{clock: clock, rand: rand.New(rand.NewChaCha8(key)), ids: ids}
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying d.
+func NewContext(ctx context.Context, d *Deps) context.Context {
+	return context.WithValue(ctx, contextKey{}, d)
+}
+
+// FromContext returns the dependencies carried by ctx, or the real ones.
+func FromContext(ctx context.Context) *Deps {
+	if ctx != nil {
+		if d, ok := ctx.Value(contextKey{}).(*Deps); ok && d != nil {
+			return d
+		}
+	}
+	return realDeps
+}
+
+// Clock returns the clock.
+func (d *Deps) Clock() Clock {
+	return d.clock
+}
+
+// Now returns the current time of the clock.
+func (d *Deps) Now() time.Time {
+	return d.clock.Now()
+}
+
+// Sleep waits for dur on the clock, or until ctx is done.
+func (d *Deps) Sleep(ctx context.Context, dur time.Duration) error {
+	return d.clock.Sleep(ctx, dur)
+}
+
+// NewID returns a new identifier, a UUID.
+func (d *Deps) NewID() string {
+	if d.ids == nil {
+		return uuid.NewString()
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return uuid.Must(uuid.NewRandomFromReader(d.ids)).String()
+}
+
+// Float64 returns a random number in [0, 1).
+func (d *Deps) Float64() float64 {
+	if d.rand == nil {
+		return rand.Float64()
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.rand.Float64()
+}
+
+// Jitter returns dur randomly varied by up to fraction of it in both
+// directions, e.g. by up to 20% for 0.2.
+func (d *Deps) Jitter(dur time.Duration, fraction float64) time.Duration {
+	return dur + time.Duration(float64(dur)*fraction*(2*d.Float64()-1))
+}
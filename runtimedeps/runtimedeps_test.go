@@ -0,0 +1,169 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtimedeps_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"testing"
+	"time"
+
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/internal/testutil"
+	"google.golang.org/adk/runner"
+	"google.golang.org/adk/runtimedeps"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+)
+
+var start = time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+// runScenario runs a scripted invocation calling a tool with the given
+// dependencies, and returns its events serialized.
+func runScenario(t *testing.T, deps *runtimedeps.Deps) []byte {
+	t.Helper()
+	ctx := runtimedeps.NewContext(t.Context(), deps)
+	lookup, err := functiontool.New(functiontool.Config{Name: "lookup", Description: "looks things up"}, func(ctx tool.Context, args struct{}) (map[string]any, error) {
+		// The tool takes time.
+		if err := runtimedeps.FromContext(ctx).Sleep(ctx, time.Second); err != nil {
+			return nil, err
+		}
+		return map[string]any{"found": true}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := llmagent.New(llmagent.Config{
+		Name: "agent",
+		Model: &testutil.MockModel{Responses: []*genai.Content{
+			genai.NewContentFromFunctionCall("lookup", map[string]any{}, genai.RoleModel),
+			genai.NewContentFromText("found it", genai.RoleModel),
+		}},
+		Tools: []tool.Tool{lookup},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	service := session.InMemoryService()
+	created, err := service.Create(ctx, &session.CreateRequest{AppName: "app", UserID: "user"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := runner.New(runner.Config{AppName: "app", Agent: a, SessionService: service})
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := []any{created.Session.ID()}
+	for ev, err := range r.Run(ctx, "user", created.Session.ID(), genai.NewContentFromText("look it up", genai.RoleUser), agent.RunConfig{}) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		events = append(events, ev)
+	}
+	b, err := json.MarshalIndent(events, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+var uuidPattern = regexp.MustCompile(`[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}`)
+
+// normalizeIDs replaces the UUIDs of b by their order of appearance.
+func normalizeIDs(b []byte) []byte {
+	ids := make(map[string]string)
+	return uuidPattern.ReplaceAllFunc(b, func(id []byte) []byte {
+		if _, ok := ids[string(id)]; !ok {
+			ids[string(id)] = fmt.Sprintf("<id-%d>", len(ids))
+		}
+		return []byte(ids[string(id)])
+	})
+}
+
+func TestSeeded_Replay(t *testing.T) {
+	first := runScenario(t, runtimedeps.Seeded(42, runtimedeps.NewFrozenClock(start)))
+	second := runScenario(t, runtimedeps.Seeded(42, runtimedeps.NewFrozenClock(start)))
+	if !bytes.Equal(first, second) {
+		t.Errorf("runs with the same seed differ:\n%s\n---\n%s", first, second)
+	}
+	if !bytes.Contains(first, []byte(`"Timestamp": "2025-01-02T03:04:06Z"`)) {
+		t.Errorf("events = %s, want the timestamps of the frozen clock advanced by the tool", first)
+	}
+
+	// Another seed changes the IDs, and only them.
+	other := runScenario(t, runtimedeps.Seeded(7, runtimedeps.NewFrozenClock(start)))
+	if bytes.Equal(first, other) {
+		t.Error("runs with different seeds are identical, want different IDs")
+	}
+	if got, want := normalizeIDs(other), normalizeIDs(first); !bytes.Equal(got, want) {
+		t.Errorf("runs with different seeds differ beyond their IDs:\n%s\n---\n%s", want, got)
+	}
+}
+
+func TestReal(t *testing.T) {
+	deps := runtimedeps.FromContext(context.Background())
+	if deps != runtimedeps.Real() {
+		t.Error("FromContext() without dependencies is not Real()")
+	}
+	if deps.NewID() == deps.NewID() {
+		t.Error("NewID() returned the same ID twice")
+	}
+	if now := deps.Now(); time.Since(now) > time.Minute {
+		t.Errorf("Now() = %v, want the current time", now)
+	}
+}
+
+func TestSeeded_Jitter(t *testing.T) {
+	jitters := func(seed uint64) []time.Duration {
+		deps := runtimedeps.Seeded(seed, nil)
+		var got []time.Duration
+		for range 10 {
+			j := deps.Jitter(time.Second, 0.2)
+			if j < 800*time.Millisecond || j > 1200*time.Millisecond {
+				t.Errorf("Jitter(1s, 0.2) = %v, want within 20%%", j)
+			}
+			got = append(got, j)
+		}
+		return got
+	}
+	if a, b := jitters(1), jitters(1); fmt.Sprint(a) != fmt.Sprint(b) {
+		t.Errorf("jitters with the same seed differ: %v, %v", a, b)
+	}
+	if a, b := jitters(1), jitters(2); fmt.Sprint(a) == fmt.Sprint(b) {
+		t.Errorf("jitters with different seeds are identical: %v", a)
+	}
+}
+
+func TestFrozenClock_Sleep(t *testing.T) {
+	clock := runtimedeps.NewFrozenClock(start)
+	if err := clock.Sleep(t.Context(), time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := clock.Now(), start.Add(time.Hour); !got.Equal(want) {
+		t.Errorf("Now() after Sleep() = %v, want %v", got, want)
+	}
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	if err := clock.Sleep(ctx, time.Hour); err == nil {
+		t.Error("Sleep() with a canceled context succeeded, want an error")
+	}
+}
@@ -25,8 +25,9 @@ import (
 
 // EventActions represent a data model for session.EventActions
 type EventActions struct {
-	StateDelta    map[string]any   `json:"stateDelta"`
-	ArtifactDelta map[string]int64 `json:"artifactDelta"`
+	StateDelta    map[string]any    `json:"stateDelta"`
+	StateOps      []session.StateOp `json:"stateOps,omitempty"`
+	ArtifactDelta map[string]int64  `json:"artifactDelta"`
 }
 
 // Event represents a single event in a session.
@@ -67,6 +68,7 @@ func ToSessionEvent(event Event) *session.Event {
 		},
 		Actions: session.EventActions{
 			StateDelta:    event.Actions.StateDelta,
+			StateOps:      event.Actions.StateOps,
 			ArtifactDelta: event.Actions.ArtifactDelta,
 		},
 	}
@@ -90,6 +92,7 @@ func FromSessionEvent(event session.Event) Event {
 		ErrorMessage:       event.LLMResponse.ErrorMessage,
 		Actions: EventActions{
 			StateDelta:    event.Actions.StateDelta,
+			StateOps:      event.Actions.StateOps,
 			ArtifactDelta: event.Actions.ArtifactDelta,
 		},
 	}
@@ -0,0 +1,208 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// ErrStateConflict is matched by the [ConflictError] errors.
+var ErrStateConflict = errors.New("session state conflict")
+
+// ConflictError is returned by AppendEvent for an event whose state changes
+// were computed against a stale version of the session state, e.g. by an
+// invocation running concurrently with another one of the same session,
+// and could not be committed. The event is not appended.
+type ConflictError struct {
+	SessionID string
+	// BaseVersion is the version of the state the event was computed
+	// against, and CurrentVersion the version of the stored state.
+	BaseVersion, CurrentVersion int64
+	// Keys are the state keys changed concurrently, in order.
+	Keys []string
+}
+
+// Error implements error.
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("session %q state conflict: the state changed from version %d to %d, concurrently changed keys: %s",
+		e.SessionID, e.BaseVersion, e.CurrentVersion, strings.Join(e.Keys, ", "))
+}
+
+// Is reports whether target is ErrStateConflict.
+func (e *ConflictError) Is(target error) bool {
+	return target == ErrStateConflict
+}
+
+// ConflictPolicy is how a session service commits the state changes of an
+// event computed against a stale version of the state.
+type ConflictPolicy int
+
+const (
+	// ConflictMerge rebases the state changes on the current state, key by
+	// key, with a three-way merge between the value the event was computed
+	// against, the value of the event and the current value: the keys that
+	// were not changed concurrently, the values equal to the current ones,
+	// and the keys set back to the value the event was computed against
+	// merge cleanly. The operations of [EventActions.StateOps] always merge,
+	// since they apply to the current value. The other values set on the
+	// keys changed concurrently are conflicts: the event is rejected with a
+	// [ConflictError].
+	ConflictMerge ConflictPolicy = iota
+	// ConflictReject rejects the state changes of all the events computed
+	// against a stale version with a [ConflictError].
+	ConflictReject
+	// ConflictOverwrite commits the state changes as they are: the last
+	// write wins, as with the services not detecting conflicts.
+	ConflictOverwrite
+)
+
+// VersionedSession is implemented by the sessions whose service detects the
+// conflicts between the state changes of concurrent invocations. The state
+// has a version, incremented by every event changing it.
+type VersionedSession interface {
+	Session
+	// StateVersion returns the version of the state of the session.
+	StateVersion() int64
+	// StateStale reports whether state changes were committed since the
+	// session was read, e.g. by a concurrent invocation, so that its state
+	// may be stale.
+	StateStale() bool
+	// RefreshState reads the state of the session again. The changes not
+	// yet committed with an event are discarded, except for the temporary
+	// keys.
+	RefreshState(context.Context) error
+}
+
+// InMemoryConfig configures the service created by
+// [InMemoryServiceWithConfig].
+type InMemoryConfig struct {
+	// ConflictPolicy is the policy of the events computed against a stale
+	// version of the state. Defaults to ConflictMerge.
+	ConflictPolicy ConflictPolicy
+	// StateHistory is the number of versions of the state whose changes are
+	// kept for the merges. Staler events are conflicts. Defaults to 100.
+	StateHistory int
+}
+
+// stateChange records the values replaced by a version of the state, to
+// find the values an event was computed against.
+type stateChange struct {
+	version int64
+	prior   map[string]priorValue
+}
+
+type priorValue struct {
+	value   any
+	existed bool
+}
+
+// changedStateKeys returns the keys of the state changed by event, in order,
+// without the temporary keys.
+func changedStateKeys(event *Event) []string {
+	var keys []string
+	for key := range event.Actions.StateDelta {
+		if !strings.HasPrefix(key, KeyPrefixTemp) {
+			keys = append(keys, key)
+		}
+	}
+	for _, op := range event.Actions.StateOps {
+		if !strings.HasPrefix(op.Key, KeyPrefixTemp) && !slices.Contains(keys, op.Key) {
+			keys = append(keys, op.Key)
+		}
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// rebase rebases the state changes of event, computed against the version
+// base of stored, on its current state as configured by the policy. The
+// values of the delta equal to those the event was computed against are
+// removed from it, to keep the current ones.
+func (s *inMemoryService) rebase(stored *session, event *Event, base int64) error {
+	conflict := func(keys []string) error {
+		return &ConflictError{SessionID: stored.id.sessionID, BaseVersion: base, CurrentVersion: stored.version, Keys: keys}
+	}
+	switch s.cfg.ConflictPolicy {
+	case ConflictOverwrite:
+		return nil
+	case ConflictReject:
+		return conflict(changedStateKeys(event))
+	}
+
+	// The history must cover all the versions after base.
+	if len(stored.history) == 0 || stored.history[0].version > base+1 {
+		return conflict(changedStateKeys(event))
+	}
+	current := s.mergeStates(stored.state, stored.id.appName, stored.id.userID)
+	var conflicts, unchanged []string
+	for key, ours := range event.Actions.StateDelta {
+		if strings.HasPrefix(key, KeyPrefixTemp) {
+			continue
+		}
+		prior, changed := stored.priorValue(key, base)
+		if !changed {
+			continue
+		}
+		theirs, exists := current[key]
+		switch {
+		case exists && reflect.DeepEqual(ours, theirs):
+		case prior.existed && reflect.DeepEqual(ours, prior.value):
+			unchanged = append(unchanged, key)
+		default:
+			conflicts = append(conflicts, key)
+		}
+	}
+	if len(conflicts) > 0 {
+		slices.Sort(conflicts)
+		return conflict(conflicts)
+	}
+	for _, key := range unchanged {
+		delete(event.Actions.StateDelta, key)
+	}
+	return nil
+}
+
+// priorValue returns the value of key at the version base, if it changed
+// since.
+func (s *session) priorValue(key string, base int64) (priorValue, bool) {
+	for _, change := range s.history {
+		if change.version <= base {
+			continue
+		}
+		if prior, ok := change.prior[key]; ok {
+			return prior, true
+		}
+	}
+	return priorValue{}, false
+}
+
+// recordChange records the values of the keys changed by the next version
+// of the state, in the merged state current.
+func (s *session) recordChange(current map[string]any, keys []string, maxHistory int) {
+	prior := make(map[string]priorValue, len(keys))
+	for _, key := range keys {
+		value, ok := current[key]
+		prior[key] = priorValue{value: value, existed: ok}
+	}
+	s.history = append(s.history, stateChange{version: s.version + 1, prior: prior})
+	if over := len(s.history) - maxHistory; over > 0 {
+		s.history = slices.Delete(s.history, 0, over)
+	}
+}
@@ -0,0 +1,260 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session_test
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"google.golang.org/adk/session"
+)
+
+// conflictFixture reads the same session twice, as two concurrent
+// invocations do.
+type conflictFixture struct {
+	service session.Service
+	a, b    session.Session
+}
+
+func newConflictFixture(t *testing.T, cfg session.InMemoryConfig) *conflictFixture {
+	t.Helper()
+	service := session.InMemoryServiceWithConfig(cfg)
+	if _, err := service.Create(t.Context(), &session.CreateRequest{AppName: "app", UserID: "user", SessionID: "s", State: map[string]any{"k": "initial"}}); err != nil {
+		t.Fatal(err)
+	}
+	return &conflictFixture{service: service, a: get(t, service), b: get(t, service)}
+}
+
+func get(t *testing.T, service session.Service) session.Session {
+	t.Helper()
+	resp, err := service.Get(t.Context(), &session.GetRequest{AppName: "app", UserID: "user", SessionID: "s"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.Session
+}
+
+func (f *conflictFixture) append(t *testing.T, sess session.Session, delta map[string]any, edit func(*session.EventActions)) error {
+	t.Helper()
+	event := session.NewEvent("invocation")
+	event.Actions.StateDelta = delta
+	if edit != nil {
+		edit(&event.Actions)
+	}
+	return f.service.AppendEvent(t.Context(), sess, event)
+}
+
+func (f *conflictFixture) state(t *testing.T) map[string]any {
+	t.Helper()
+	return maps.Collect(get(t, f.service).State().All())
+}
+
+func TestInMemoryService_ConflictMerge(t *testing.T) {
+	t.Run("DisjointKeys", func(t *testing.T) {
+		f := newConflictFixture(t, session.InMemoryConfig{})
+		if err := f.append(t, f.a, map[string]any{"x": "a"}, nil); err != nil {
+			t.Fatal(err)
+		}
+		if !f.b.(session.VersionedSession).StateStale() {
+			t.Error("StateStale() = false after a concurrent commit, want true")
+		}
+		if err := f.append(t, f.b, map[string]any{"y": "b"}, nil); err != nil {
+			t.Fatalf("AppendEvent() of disjoint keys failed: %v", err)
+		}
+		want := map[string]any{"k": "initial", "x": "a", "y": "b"}
+		if diff := cmp.Diff(want, f.state(t)); diff != "" {
+			t.Errorf("stored state mismatch (-want +got):\n%s", diff)
+		}
+		// The stale session gets the changes committed concurrently.
+		if diff := cmp.Diff(want, maps.Collect(f.b.State().All())); diff != "" {
+			t.Errorf("rebased session state mismatch (-want +got):\n%s", diff)
+		}
+		if got := f.b.(session.VersionedSession).StateVersion(); got != 2 {
+			t.Errorf("StateVersion() = %d, want 2", got)
+		}
+	})
+
+	t.Run("OverlappingKeys", func(t *testing.T) {
+		f := newConflictFixture(t, session.InMemoryConfig{})
+		if err := f.append(t, f.a, map[string]any{"k": "a"}, nil); err != nil {
+			t.Fatal(err)
+		}
+		err := f.append(t, f.b, map[string]any{"k": "b", "y": "b"}, nil)
+		var conflict *session.ConflictError
+		if !errors.As(err, &conflict) || !errors.Is(err, session.ErrStateConflict) {
+			t.Fatalf("AppendEvent() error = %v, want a ConflictError", err)
+		}
+		want := &session.ConflictError{SessionID: "s", BaseVersion: 0, CurrentVersion: 1, Keys: []string{"k"}}
+		if diff := cmp.Diff(want, conflict); diff != "" {
+			t.Errorf("ConflictError mismatch (-want +got):\n%s", diff)
+		}
+		// Nothing of the rejected event is committed.
+		if diff := cmp.Diff(map[string]any{"k": "a"}, f.state(t)); diff != "" {
+			t.Errorf("stored state mismatch (-want +got):\n%s", diff)
+		}
+		if got := get(t, f.service).Events().Len(); got != 1 {
+			t.Errorf("stored events = %d, want 1", got)
+		}
+
+		// Once refreshed, the session commits.
+		if err := f.b.(session.VersionedSession).RefreshState(t.Context()); err != nil {
+			t.Fatal(err)
+		}
+		if err := f.append(t, f.b, map[string]any{"k": "b"}, nil); err != nil {
+			t.Fatalf("AppendEvent() after RefreshState() failed: %v", err)
+		}
+		if diff := cmp.Diff(map[string]any{"k": "b"}, f.state(t)); diff != "" {
+			t.Errorf("stored state mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("SameValues", func(t *testing.T) {
+		f := newConflictFixture(t, session.InMemoryConfig{})
+		if err := f.append(t, f.a, map[string]any{"k": "same"}, nil); err != nil {
+			t.Fatal(err)
+		}
+		// The value set concurrently and the value read, set back, merge.
+		if err := f.append(t, f.b, map[string]any{"k": "same"}, nil); err != nil {
+			t.Fatalf("AppendEvent() of the same value failed: %v", err)
+		}
+		c := get(t, f.service)
+		if err := f.append(t, get(t, f.service), map[string]any{"k": "a"}, nil); err != nil {
+			t.Fatal(err)
+		}
+		if err := f.append(t, c, map[string]any{"k": "same", "y": "c"}, nil); err != nil {
+			t.Fatalf("AppendEvent() of the value read failed: %v", err)
+		}
+		if diff := cmp.Diff(map[string]any{"k": "a", "y": "c"}, f.state(t)); diff != "" {
+			t.Errorf("stored state mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("StateOps", func(t *testing.T) {
+		f := newConflictFixture(t, session.InMemoryConfig{})
+		for _, sess := range []session.Session{f.a, f.b} {
+			err := f.append(t, sess, nil, func(a *session.EventActions) {
+				a.IncrementState("counter", 1)
+				a.AppendState("log", sess == f.a)
+			})
+			if err != nil {
+				t.Fatalf("AppendEvent() of state ops failed: %v", err)
+			}
+		}
+		want := map[string]any{"k": "initial", "counter": int64(2), "log": []any{true, false}}
+		if diff := cmp.Diff(want, f.state(t)); diff != "" {
+			t.Errorf("stored state mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("TemporaryKeys", func(t *testing.T) {
+		f := newConflictFixture(t, session.InMemoryConfig{})
+		if err := f.append(t, f.a, map[string]any{"k": "a"}, nil); err != nil {
+			t.Fatal(err)
+		}
+		// Temporary keys are not committed: they cannot conflict.
+		if err := f.append(t, f.b, map[string]any{session.KeyPrefixTemp + "k": "b"}, nil); err != nil {
+			t.Fatalf("AppendEvent() of temporary keys failed: %v", err)
+		}
+		if !f.b.(session.VersionedSession).StateStale() {
+			t.Error("StateStale() = false after an event without state changes, want true")
+		}
+	})
+
+	t.Run("HistoryExhausted", func(t *testing.T) {
+		f := newConflictFixture(t, session.InMemoryConfig{StateHistory: 2})
+		for i := range 3 {
+			if err := f.append(t, f.a, map[string]any{fmt.Sprint("x", i): i}, nil); err != nil {
+				t.Fatal(err)
+			}
+		}
+		err := f.append(t, f.b, map[string]any{"y": "b"}, nil)
+		if !errors.Is(err, session.ErrStateConflict) {
+			t.Errorf("AppendEvent() older than the history error = %v, want a conflict", err)
+		}
+	})
+}
+
+func TestInMemoryService_ConflictReject(t *testing.T) {
+	f := newConflictFixture(t, session.InMemoryConfig{ConflictPolicy: session.ConflictReject})
+	if err := f.append(t, f.a, map[string]any{"x": "a"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.append(t, f.b, map[string]any{"y": "b"}, nil); !errors.Is(err, session.ErrStateConflict) {
+		t.Errorf("AppendEvent() of a stale session error = %v, want a conflict", err)
+	}
+	// Events without state changes are not rejected.
+	if err := f.append(t, f.b, nil, nil); err != nil {
+		t.Errorf("AppendEvent() without state changes failed: %v", err)
+	}
+	if diff := cmp.Diff(map[string]any{"k": "initial", "x": "a"}, f.state(t)); diff != "" {
+		t.Errorf("stored state mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestInMemoryService_ConflictOverwrite(t *testing.T) {
+	f := newConflictFixture(t, session.InMemoryConfig{ConflictPolicy: session.ConflictOverwrite})
+	if err := f.append(t, f.a, map[string]any{"k": "a", "x": "a"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.append(t, f.b, map[string]any{"k": "b"}, nil); err != nil {
+		t.Fatalf("AppendEvent() failed: %v", err)
+	}
+	if diff := cmp.Diff(map[string]any{"k": "b", "x": "a"}, f.state(t)); diff != "" {
+		t.Errorf("stored state mismatch (-want +got):\n%s", diff)
+	}
+}
+
+// TestInMemoryService_ConcurrentAppends checks that no update is lost by
+// concurrent invocations retrying their conflicts.
+func TestInMemoryService_ConcurrentAppends(t *testing.T) {
+	f := newConflictFixture(t, session.InMemoryConfig{})
+	const n = 20
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				sess := get(t, f.service)
+				err := f.append(t, sess, map[string]any{"k": i, fmt.Sprint("key", i): i}, func(a *session.EventActions) {
+					a.IncrementState("counter", 1)
+				})
+				if err == nil {
+					return
+				}
+				if !errors.Is(err, session.ErrStateConflict) {
+					t.Errorf("AppendEvent() failed: %v", err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	state := f.state(t)
+	if got := state["counter"]; got != int64(n) {
+		t.Errorf("counter = %v, want %d", got, n)
+	}
+	for i := range n {
+		if got := state[fmt.Sprint("key", i)]; got != i {
+			t.Errorf("key%d = %v, want %d", i, got, i)
+		}
+	}
+}
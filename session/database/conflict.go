@@ -0,0 +1,210 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"google.golang.org/adk/session"
+)
+
+// stateChange records the values replaced by a version of the state, to
+// find the values an event was computed against.
+type stateChange struct {
+	Version int64                 `json:"version"`
+	Prior   map[string]priorValue `json:"prior"`
+}
+
+type priorValue struct {
+	Value   any  `json:"value"`
+	Existed bool `json:"existed"`
+}
+
+// changedStateKeys returns the keys of the state changed by event, in order,
+// without the temporary keys.
+func changedStateKeys(event *session.Event) []string {
+	var keys []string
+	for key := range event.Actions.StateDelta {
+		if !strings.HasPrefix(key, session.KeyPrefixTemp) {
+			keys = append(keys, key)
+		}
+	}
+	for _, op := range event.Actions.StateOps {
+		if !strings.HasPrefix(op.Key, session.KeyPrefixTemp) && !slices.Contains(keys, op.Key) {
+			keys = append(keys, op.Key)
+		}
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// commitVersion commits the next version of the state of stored, changed by
+// the keys of event. current is the merged state of stored. The state
+// changes computed against a stale version are first rebased on current as
+// configured by the conflict policy. It reports whether sess read a stale
+// version of the state.
+func (s *databaseService) commitVersion(tx *gorm.DB, stored *storageSession, current map[string]any, sess *localSession, event *session.Event, keys []string) (bool, error) {
+	base := sess.StateVersion()
+	event.BaseStateVersion = base
+	stale := base != stored.StateVersion
+	if stale {
+		if err := s.rebase(stored, current, event, base); err != nil {
+			return false, err
+		}
+	}
+
+	history, err := stored.history()
+	if err != nil {
+		return false, err
+	}
+	prior := make(map[string]priorValue, len(keys))
+	for _, key := range keys {
+		value, ok := current[key]
+		prior[key] = priorValue{Value: value, Existed: ok}
+	}
+	history = append(history, stateChange{Version: stored.StateVersion + 1, Prior: prior})
+	if over := len(history) - s.cfg.StateHistory; over > 0 {
+		history = slices.Delete(history, 0, over)
+	}
+	if stored.StateHistory, err = json.Marshal(history); err != nil {
+		return false, fmt.Errorf("failed to marshal state history: %w", err)
+	}
+
+	// The version is only incremented if no event was committed since stored
+	// was read, e.g. by a concurrent transaction.
+	result := tx.Model(&storageSession{}).
+		Where(&storageSession{AppName: stored.AppName, UserID: stored.UserID, ID: stored.ID}).
+		Where("state_version = ?", stored.StateVersion).
+		Update("state_version", stored.StateVersion+1)
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to update state version: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return false, &session.ConflictError{SessionID: stored.ID, BaseVersion: base, CurrentVersion: stored.StateVersion + 1, Keys: keys}
+	}
+	stored.StateVersion++
+	return stale, nil
+}
+
+// rebase rebases the state changes of event, computed against the version
+// base of stored, on its current state as configured by the policy. The
+// values of the delta equal to those the event was computed against are
+// removed from it, to keep the current ones.
+func (s *databaseService) rebase(stored *storageSession, current map[string]any, event *session.Event, base int64) error {
+	conflict := func(keys []string) error {
+		return &session.ConflictError{SessionID: stored.ID, BaseVersion: base, CurrentVersion: stored.StateVersion, Keys: keys}
+	}
+	switch s.cfg.ConflictPolicy {
+	case session.ConflictOverwrite:
+		return nil
+	case session.ConflictReject:
+		return conflict(changedStateKeys(event))
+	}
+
+	// The history must cover all the versions after base.
+	history, err := stored.history()
+	if err != nil {
+		return err
+	}
+	if len(history) == 0 || history[0].Version > base+1 {
+		return conflict(changedStateKeys(event))
+	}
+	var conflicts, unchanged []string
+	for key, ours := range event.Actions.StateDelta {
+		if strings.HasPrefix(key, session.KeyPrefixTemp) {
+			continue
+		}
+		prior, changed := priorValueOf(history, key, base)
+		if !changed {
+			continue
+		}
+		theirs, exists := current[key]
+		switch {
+		case exists && jsonEqual(ours, theirs):
+		case prior.Existed && jsonEqual(ours, prior.Value):
+			unchanged = append(unchanged, key)
+		default:
+			conflicts = append(conflicts, key)
+		}
+	}
+	if len(conflicts) > 0 {
+		slices.Sort(conflicts)
+		return conflict(conflicts)
+	}
+	for _, key := range unchanged {
+		delete(event.Actions.StateDelta, key)
+	}
+	return nil
+}
+
+// priorValueOf returns the value of key at the version base, if it changed
+// since.
+func priorValueOf(history []stateChange, key string, base int64) (priorValue, bool) {
+	for _, change := range history {
+		if change.Version <= base {
+			continue
+		}
+		if prior, ok := change.Prior[key]; ok {
+			return prior, true
+		}
+	}
+	return priorValue{}, false
+}
+
+// jsonEqual reports whether a and b have the same JSON encoding: the values
+// read from the database are JSON decoded, so that an int set by an event
+// is read back as a float64.
+func jsonEqual(a, b any) bool {
+	ja, errA := json.Marshal(a)
+	jb, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(ja, jb)
+}
+
+// history decodes the changes of the last versions of the state.
+func (s *storageSession) history() ([]stateChange, error) {
+	if len(s.StateHistory) == 0 {
+		return nil, nil
+	}
+	var history []stateChange
+	if err := json.Unmarshal(s.StateHistory, &history); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal state history: %w", err)
+	}
+	return history, nil
+}
+
+// readState returns the merged state of the stored session and its version.
+func (s *databaseService) readState(ctx context.Context, appName, userID, sessionID string) (map[string]any, int64, error) {
+	db := s.db.WithContext(ctx)
+	var stored storageSession
+	if err := db.Where(&storageSession{AppName: appName, UserID: userID, ID: sessionID}).First(&stored).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to get session: %w", err)
+	}
+	storageApp, err := fetchStorageAppState(db, appName)
+	if err != nil {
+		return nil, 0, err
+	}
+	storageUser, err := fetchStorageUserState(db, appName, userID)
+	if err != nil {
+		return nil, 0, err
+	}
+	return mergeStates(storageApp.State, storageUser.State, stored.State), stored.StateVersion, nil
+}
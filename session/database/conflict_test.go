@@ -0,0 +1,169 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"errors"
+	"maps"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"google.golang.org/adk/session"
+)
+
+// conflictFixture reads the same session twice, as two concurrent
+// invocations do.
+type conflictFixture struct {
+	service *databaseService
+	a, b    session.Session
+}
+
+func newConflictFixture(t *testing.T, policy session.ConflictPolicy) *conflictFixture {
+	t.Helper()
+	service := emptyService(t)
+	service.cfg.ConflictPolicy = policy
+	if _, err := service.Create(t.Context(), &session.CreateRequest{AppName: "app", UserID: "user", SessionID: "s", State: map[string]any{"k": "initial"}}); err != nil {
+		t.Fatal(err)
+	}
+	return &conflictFixture{service: service, a: getSession(t, service), b: getSession(t, service)}
+}
+
+func getSession(t *testing.T, service *databaseService) session.Session {
+	t.Helper()
+	resp, err := service.Get(t.Context(), &session.GetRequest{AppName: "app", UserID: "user", SessionID: "s"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.Session
+}
+
+func (f *conflictFixture) append(t *testing.T, sess session.Session, delta map[string]any) error {
+	t.Helper()
+	event := session.NewEvent("invocation")
+	event.Actions.StateDelta = delta
+	return f.service.AppendEvent(t.Context(), sess, event)
+}
+
+func (f *conflictFixture) state(t *testing.T) map[string]any {
+	t.Helper()
+	return maps.Collect(getSession(t, f.service).State().All())
+}
+
+func Test_databaseService_ConflictMerge(t *testing.T) {
+	t.Run("DisjointKeys", func(t *testing.T) {
+		f := newConflictFixture(t, session.ConflictMerge)
+		if err := f.append(t, f.a, map[string]any{"x": "a"}); err != nil {
+			t.Fatal(err)
+		}
+		if !f.b.(session.VersionedSession).StateStale() {
+			t.Error("StateStale() = false after a concurrent commit, want true")
+		}
+		if err := f.append(t, f.b, map[string]any{"y": "b"}); err != nil {
+			t.Fatalf("AppendEvent() of disjoint keys failed: %v", err)
+		}
+		want := map[string]any{"k": "initial", "x": "a", "y": "b"}
+		if diff := cmp.Diff(want, f.state(t)); diff != "" {
+			t.Errorf("stored state mismatch (-want +got):\n%s", diff)
+		}
+		// The stale session gets the changes committed concurrently.
+		if diff := cmp.Diff(want, maps.Collect(f.b.State().All())); diff != "" {
+			t.Errorf("rebased session state mismatch (-want +got):\n%s", diff)
+		}
+		if got := f.b.(session.VersionedSession).StateVersion(); got != 2 {
+			t.Errorf("StateVersion() = %d, want 2", got)
+		}
+	})
+
+	t.Run("OverlappingKeys", func(t *testing.T) {
+		f := newConflictFixture(t, session.ConflictMerge)
+		if err := f.append(t, f.a, map[string]any{"k": "a"}); err != nil {
+			t.Fatal(err)
+		}
+		err := f.append(t, f.b, map[string]any{"k": "b", "y": "b"})
+		var conflict *session.ConflictError
+		if !errors.As(err, &conflict) || !errors.Is(err, session.ErrStateConflict) {
+			t.Fatalf("AppendEvent() error = %v, want a ConflictError", err)
+		}
+		want := &session.ConflictError{SessionID: "s", BaseVersion: 0, CurrentVersion: 1, Keys: []string{"k"}}
+		if diff := cmp.Diff(want, conflict); diff != "" {
+			t.Errorf("ConflictError mismatch (-want +got):\n%s", diff)
+		}
+		// Nothing of the rejected event is committed.
+		if diff := cmp.Diff(map[string]any{"k": "a"}, f.state(t)); diff != "" {
+			t.Errorf("stored state mismatch (-want +got):\n%s", diff)
+		}
+		if got := getSession(t, f.service).Events().Len(); got != 1 {
+			t.Errorf("stored events = %d, want 1", got)
+		}
+
+		// Once refreshed, the session commits.
+		if err := f.b.(session.VersionedSession).RefreshState(t.Context()); err != nil {
+			t.Fatal(err)
+		}
+		if f.b.(session.VersionedSession).StateStale() {
+			t.Error("StateStale() = true after RefreshState(), want false")
+		}
+		if err := f.append(t, f.b, map[string]any{"k": "b"}); err != nil {
+			t.Fatalf("AppendEvent() after RefreshState() failed: %v", err)
+		}
+		if diff := cmp.Diff(map[string]any{"k": "b"}, f.state(t)); diff != "" {
+			t.Errorf("stored state mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("SameValues", func(t *testing.T) {
+		f := newConflictFixture(t, session.ConflictMerge)
+		// The values read back from the database are JSON decoded.
+		if err := f.append(t, f.a, map[string]any{"n": 1}); err != nil {
+			t.Fatal(err)
+		}
+		if err := f.append(t, f.b, map[string]any{"n": 1}); err != nil {
+			t.Fatalf("AppendEvent() of the same value failed: %v", err)
+		}
+		if diff := cmp.Diff(map[string]any{"k": "initial", "n": float64(1)}, f.state(t)); diff != "" {
+			t.Errorf("stored state mismatch (-want +got):\n%s", diff)
+		}
+	})
+}
+
+func Test_databaseService_ConflictPolicies(t *testing.T) {
+	t.Run("Reject", func(t *testing.T) {
+		f := newConflictFixture(t, session.ConflictReject)
+		if err := f.append(t, f.a, map[string]any{"x": "a"}); err != nil {
+			t.Fatal(err)
+		}
+		if err := f.append(t, f.b, map[string]any{"y": "b"}); !errors.Is(err, session.ErrStateConflict) {
+			t.Fatalf("AppendEvent() error = %v, want a ConflictError", err)
+		}
+		// The events not changing the state are not versioned.
+		if err := f.append(t, f.b, nil); err != nil {
+			t.Fatalf("AppendEvent() without state changes failed: %v", err)
+		}
+	})
+
+	t.Run("Overwrite", func(t *testing.T) {
+		f := newConflictFixture(t, session.ConflictOverwrite)
+		if err := f.append(t, f.a, map[string]any{"k": "a"}); err != nil {
+			t.Fatal(err)
+		}
+		if err := f.append(t, f.b, map[string]any{"k": "b"}); err != nil {
+			t.Fatalf("AppendEvent() failed: %v", err)
+		}
+		if diff := cmp.Diff(map[string]any{"k": "b"}, f.state(t)); diff != "" {
+			t.Errorf("stored state mismatch (-want +got):\n%s", diff)
+		}
+	})
+}
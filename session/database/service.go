@@ -22,15 +22,26 @@ import (
 	"strings"
 	"time"
 
-	"github.com/google/uuid"
 	"gorm.io/gorm"
 
+	"google.golang.org/adk/runtimedeps"
 	"google.golang.org/adk/session"
 )
 
 // databaseService is an database implementation of sessionService.Service.
 type databaseService struct {
-	db *gorm.DB
+	db  *gorm.DB
+	cfg Config
+}
+
+// Config configures the service created by [NewSessionServiceWithConfig].
+type Config struct {
+	// ConflictPolicy is the policy of the events computed against a stale
+	// version of the state. Defaults to session.ConflictMerge.
+	ConflictPolicy session.ConflictPolicy
+	// StateHistory is the number of versions of the state whose changes are
+	// kept for the merges. Staler events are conflicts. Defaults to 100.
+	StateHistory int
 }
 
 // NewSessionService creates a new [session.Service] implementation that uses a
@@ -42,11 +53,21 @@ type databaseService struct {
 // It returns the new [session.Service] or an error if the database connection
 // [gorm.Open] fails.
 func NewSessionService(dialector gorm.Dialector, opts ...gorm.Option) (session.Service, error) {
+	return NewSessionServiceWithConfig(dialector, Config{}, opts...)
+}
+
+// NewSessionServiceWithConfig is like [NewSessionService], with the service
+// configured by cfg. The sessions of the service implement
+// [session.VersionedSession].
+func NewSessionServiceWithConfig(dialector gorm.Dialector, cfg Config, opts ...gorm.Option) (session.Service, error) {
+	if cfg.StateHistory <= 0 {
+		cfg.StateHistory = 100
+	}
 	db, err := gorm.Open(dialector, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("error creating database session service: %w", err)
 	}
-	return &databaseService{db: db}, nil
+	return &databaseService{db: db, cfg: cfg}, nil
 }
 
 // AutoMigrate runs the GORM auto-migration tool to ensure the database schema
@@ -73,9 +94,10 @@ func (s *databaseService) Create(ctx context.Context, req *session.CreateRequest
 		return nil, fmt.Errorf("app_name and user_id are required")
 	}
 
+	deps := runtimedeps.FromContext(ctx)
 	sessionID := req.SessionID
 	if sessionID == "" {
-		sessionID = uuid.NewString()
+		sessionID = deps.NewID()
 	}
 
 	stateMap := req.State
@@ -87,7 +109,8 @@ func (s *databaseService) Create(ctx context.Context, req *session.CreateRequest
 		userID:    req.UserID,
 		sessionID: sessionID,
 		state:     stateMap,
-		updatedAt: time.Now(),
+		updatedAt: deps.Now(),
+		service:   s,
 	}
 	createdSession, err := createStorageSession(val)
 	if err != nil {
@@ -199,6 +222,7 @@ func (s *databaseService) Get(ctx context.Context, req *session.GetRequest) (*se
 	if err != nil {
 		return nil, fmt.Errorf("failed to map storage object: %w", err)
 	}
+	responseSession.service = s
 
 	// We fetched in DESC order to get the most recent ones (due to LIMIT).
 	// Now we reverse them to be in chronological ASC order for the response.
@@ -272,12 +296,13 @@ func (s *databaseService) List(ctx context.Context, req *session.ListRequest) (*
 	// Create response sessions, transform the storageSessions into
 	responseSessions := make([]session.Session, 0, len(foundSessions))
 	for _, storage := range foundSessions {
-		s := storage
-		sess, err := createSessionFromStorageSession(&s)
+		stored := storage
+		sess, err := createSessionFromStorageSession(&stored)
 		if err != nil {
 			// If we encounter a single mapping error, we fail the whole request.
-			return nil, fmt.Errorf("failed to map storage object for session %s: %w", s.ID, err)
+			return nil, fmt.Errorf("failed to map storage object for session %s: %w", stored.ID, err)
 		}
+		sess.service = s
 
 		userState, ok := userStates[sess.UserID()]
 		if !ok {
@@ -324,7 +349,7 @@ func (s *databaseService) AppendEvent(ctx context.Context, curSession session.Se
 		return fmt.Errorf("event is nil")
 	}
 	// ignore partial events
-	if event.Partial {
+	if event.Partial && !session.KeepsPartials(ctx) {
 		return nil
 	}
 
@@ -340,18 +365,36 @@ func (s *databaseService) AppendEvent(ctx context.Context, curSession session.Se
 	}
 
 	// applyChanges and persist them
-	err := s.applyEvent(ctx, sess, event)
+	current, err := s.applyEvent(ctx, sess, event)
 	if err != nil {
 		return err
 	}
 
 	// append it to session
-	return sess.appendEvent(event)
+	if err := sess.appendEvent(event); err != nil {
+		return err
+	}
+	if current != nil {
+		// The session read the stale state: it gets the changes committed
+		// concurrently.
+		sess.mu.Lock()
+		sess.refreshState(current)
+		sess.mu.Unlock()
+	}
+	return nil
 }
 
 // applyEvent fetches the session, validates it, applies state changes from an
-// event, and saves the event atomically.
-func (s *databaseService) applyEvent(ctx context.Context, session *localSession, event *session.Event) error {
+// event, and saves the event atomically. The state changes computed against
+// a stale version of the state are committed as configured by the conflict
+// policy. If the session read a stale state, applyEvent returns the merged
+// state stored.
+func (s *databaseService) applyEvent(ctx context.Context, session *localSession, event *session.Event) (map[string]any, error) {
+	var (
+		changed bool
+		version int64
+		current map[string]any
+	)
 	// Wrap database operations in a single transaction.
 	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		// Fetch the session object from storage.
@@ -365,18 +408,6 @@ func (s *databaseService) applyEvent(ctx context.Context, session *localSession,
 			return fmt.Errorf("failed to get session: %w", err)
 		}
 
-		// Ensure the session object is not stale.
-		// We use UnixMicro() for microsecond-level precision, matching the Python code.
-		storageUpdateTime := storageSess.UpdateTime.UnixMicro()
-		sessionUpdateTime := session.updatedAt.UnixMicro()
-		if storageUpdateTime > sessionUpdateTime {
-			return fmt.Errorf(
-				"stale session error: last update time from request (%s) is older than in database (%s)",
-				time.Unix(0, sessionUpdateTime).Format(time.RFC3339Nano),
-				time.Unix(0, storageUpdateTime).Format(time.RFC3339Nano),
-			)
-		}
-
 		// Fetch App and User states.
 		storageApp, err := fetchStorageAppState(tx, session.AppName())
 		if err != nil {
@@ -387,6 +418,17 @@ func (s *databaseService) applyEvent(ctx context.Context, session *localSession,
 			return err
 		}
 
+		// Commit the next version of the state, rebasing the changes
+		// computed against a stale version.
+		stale := false
+		if keys := changedStateKeys(event); len(keys) > 0 {
+			merged := mergeStates(storageApp.State, storageUser.State, storageSess.State)
+			if stale, err = s.commitVersion(tx, &storageSess, merged, session, event, keys); err != nil {
+				return err
+			}
+			changed, version = true, storageSess.StateVersion
+		}
+
 		appDelta, userDelta, sessionDelta := extractStateDeltas(event.Actions.StateDelta)
 
 		// Merge state deltas and update the storage objects.
@@ -407,6 +449,31 @@ func (s *databaseService) applyEvent(ctx context.Context, session *localSession,
 			maps.Copy(storageSess.State, sessionDelta)
 			// The session state update will be saved along with the event timestamp update.
 		}
+		appOps, userOps, sessionOps := extractStateOps(event.Actions.StateOps)
+		if len(appOps) > 0 {
+			if storageApp.State == nil {
+				storageApp.State = make(map[string]any)
+			}
+			applyStateOps(storageApp.State, appOps)
+			if err := tx.Save(&storageApp).Error; err != nil {
+				return fmt.Errorf("failed to save app state: %w", err)
+			}
+		}
+		if len(userOps) > 0 {
+			if storageUser.State == nil {
+				storageUser.State = make(map[string]any)
+			}
+			applyStateOps(storageUser.State, userOps)
+			if err := tx.Save(&storageUser).Error; err != nil {
+				return fmt.Errorf("failed to save user state: %w", err)
+			}
+		}
+		if len(sessionOps) > 0 {
+			if storageSess.State == nil {
+				storageSess.State = make(map[string]any)
+			}
+			applyStateOps(storageSess.State, sessionOps)
+		}
 
 		// Create the new event record in the database.
 		storageEv, err := createStorageEvent(session, event)
@@ -424,11 +491,22 @@ func (s *databaseService) applyEvent(ctx context.Context, session *localSession,
 		}
 
 		session.updatedAt = storageSess.UpdateTime
+		if stale {
+			current = mergeStates(storageApp.State, storageUser.State, storageSess.State)
+		}
 
 		return nil // Returning nil commits the transaction.
 	})
+	if err != nil {
+		return nil, err
+	}
 
-	return err
+	if changed {
+		session.mu.Lock()
+		session.version = version
+		session.mu.Unlock()
+	}
+	return current, nil
 }
 
 func fetchStorageAppState(tx *gorm.DB, appName string) (*storageAppState, error) {
@@ -471,6 +549,27 @@ func fetchAllAppStorageUserState(tx *gorm.DB, appName string) (map[string]*stora
 	return statesByUserId, nil
 }
 
+// applyStateOps is session.ApplyStateOps, which is shadowed by the
+// parameters named session.
+var applyStateOps = session.ApplyStateOps
+
+// extractStateOps splits state operations into the operations on the app,
+// user, and session states based on key prefixes, like extractStateDeltas.
+func extractStateOps(ops []session.StateOp) (appOps, userOps, sessionOps []session.StateOp) {
+	for _, op := range ops {
+		if key, ok := strings.CutPrefix(op.Key, session.KeyPrefixApp); ok {
+			op.Key = key
+			appOps = append(appOps, op)
+		} else if key, ok := strings.CutPrefix(op.Key, session.KeyPrefixUser); ok {
+			op.Key = key
+			userOps = append(userOps, op)
+		} else if !strings.HasPrefix(op.Key, session.KeyPrefixTemp) {
+			sessionOps = append(sessionOps, op)
+		}
+	}
+	return appOps, userOps, sessionOps
+}
+
 // extractStateDeltas splits a single state delta map into three separate maps
 // for app, user, and session states based on key prefixes.
 // Temporary keys (starting with TempStatePrefix) are ignored.
@@ -15,6 +15,7 @@
 package database
 
 import (
+	"fmt"
 	"maps"
 	"strconv"
 	"testing"
@@ -174,9 +175,6 @@ func Test_databaseService_Get(t *testing.T) {
 			t.Fatalf("setupGetRespectsUserID failed to get session1: %v", err)
 		}
 
-		// Update 'updatedAt' to pass stale validation on append
-		session1.Session.(*localSession).updatedAt = time.Now()
-
 		err = s.AppendEvent(t.Context(), session1.Session.(*localSession), &session.Event{
 			ID:     "event_for_user1",
 			Author: "user",
@@ -205,7 +203,6 @@ func Test_databaseService_Get(t *testing.T) {
 		}
 
 		for i := 1; i <= numTestEvents; i++ {
-			created.Session.(*localSession).updatedAt = time.Now()
 			event := &session.Event{
 				ID:          strconv.Itoa(i),
 				Author:      "user",
@@ -350,7 +347,7 @@ func Test_databaseService_Get(t *testing.T) {
 			if tt.wantResponse != nil {
 				if diff := cmp.Diff(tt.wantResponse, got,
 					cmp.AllowUnexported(localSession{}),
-					cmpopts.IgnoreFields(localSession{}, "mu", "updatedAt")); diff != "" {
+					cmpopts.IgnoreFields(localSession{}, "mu", "updatedAt", "version", "service")); diff != "" {
 					t.Errorf("Get session mismatch: (-want +got):\n%s", diff)
 				}
 			}
@@ -460,7 +457,7 @@ func Test_databaseService_List(t *testing.T) {
 				// Sort slices for stable comparison
 				opts := []cmp.Option{
 					cmp.AllowUnexported(localSession{}),
-					cmpopts.IgnoreFields(localSession{}, "mu", "updatedAt"),
+					cmpopts.IgnoreFields(localSession{}, "mu", "updatedAt", "version", "service"),
 					cmpopts.SortSlices(func(a, b session.Session) bool {
 						return a.ID() < b.ID()
 					}),
@@ -723,7 +720,6 @@ func Test_databaseService_AppendEvent(t *testing.T) {
 
 			s := tt.setup(t)
 
-			tt.session.updatedAt = time.Now() // set updatedAt value to pass stale validation
 			err := s.AppendEvent(ctx, tt.session, tt.event)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("databaseService.AppendEvent() error = %v, wantErr %v", err, tt.wantErr)
@@ -751,7 +747,7 @@ func Test_databaseService_AppendEvent(t *testing.T) {
 			// Define comparison options
 			opts := []cmp.Option{
 				cmp.AllowUnexported(localSession{}),
-				cmpopts.IgnoreFields(localSession{}, "mu", "updatedAt"),
+				cmpopts.IgnoreFields(localSession{}, "mu", "updatedAt", "version", "service"),
 				cmpopts.IgnoreFields(session.Event{}, "Timestamp"),
 				// Add sorters if event order is not guaranteed
 				cmpopts.SortSlices(func(a, b *session.Event) bool {
@@ -882,6 +878,53 @@ func Test_databaseService_StateManagement(t *testing.T) {
 			t.Errorf("Expected 'sk' key in stored event, but was missing or wrong value")
 		}
 	})
+
+	t.Run("state_ops_are_applied_to_each_scope", func(t *testing.T) {
+		s := emptyService(t)
+		s1, _ := s.Create(ctx, &session.CreateRequest{AppName: appName, UserID: "u1", SessionID: "s1", State: map[string]any{"progress": 1}})
+		s1.Session.(*localSession).updatedAt = time.Now()
+		for i := range 2 {
+			var actions session.EventActions
+			actions.IncrementState("progress", 2)
+			actions.AppendState("user:seen", i)
+			actions.MergeState("app:config", map[string]any{fmt.Sprintf("k%d", i): i})
+			actions.AppendState("temp:scratch", i)
+			err := s.AppendEvent(ctx, s1.Session.(*localSession), &session.Event{
+				ID:      fmt.Sprintf("event%d", i),
+				Actions: actions,
+			})
+			if err != nil {
+				t.Fatalf("Failed to appendEvent: %v", err)
+			}
+		}
+
+		// The local session applies the operations like the storage does.
+		wantLocal := map[string]any{
+			"progress":   int64(5),
+			"user:seen":  []any{0, 1},
+			"app:config": map[string]any{"k0": 0, "k1": 1},
+		}
+		if diff := cmp.Diff(wantLocal, maps.Collect(s1.Session.State().All())); diff != "" {
+			t.Errorf("local session state mismatch (-want +got):\n%s", diff)
+		}
+
+		// The stored values went through a JSON encoding.
+		want := map[string]any{
+			"progress":   float64(5),
+			"user:seen":  []any{float64(0), float64(1)},
+			"app:config": map[string]any{"k0": float64(0), "k1": float64(1)},
+		}
+		got, err := s.Get(ctx, &session.GetRequest{AppName: appName, UserID: "u1", SessionID: "s1"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(want, maps.Collect(got.Session.State().All())); diff != "" {
+			t.Errorf("stored state mismatch (-want +got):\n%s", diff)
+		}
+		if ops := got.Session.Events().At(0).Actions.StateOps; len(ops) != 3 {
+			t.Errorf("stored event has %d state operations, want 3 without the temporary one", len(ops))
+		}
+	})
 }
 
 func serviceDbWithData(t *testing.T) *databaseService {
@@ -1015,3 +1058,37 @@ func emptyService(t *testing.T) *databaseService {
 	}
 	return dbservice
 }
+
+func Test_databaseService_KeepPartials(t *testing.T) {
+	for _, keep := range []bool{false, true} {
+		t.Run(fmt.Sprintf("KeepPartials=%v", keep), func(t *testing.T) {
+			ctx := t.Context()
+			if keep {
+				ctx = session.KeepPartials(ctx)
+			}
+			s := emptyService(t)
+			created, err := s.Create(ctx, &session.CreateRequest{AppName: "app", UserID: "user", SessionID: "session"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, partial := range []bool{true, false} {
+				event := &session.Event{ID: fmt.Sprintf("event_%v", partial), Author: "model", LLMResponse: model.LLMResponse{Partial: partial}}
+				if err := s.AppendEvent(ctx, created.Session, event); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got, err := s.Get(ctx, &session.GetRequest{AppName: "app", UserID: "user", SessionID: "session"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := 1
+			if keep {
+				want = 2
+			}
+			if n := got.Session.Events().Len(); n != want {
+				t.Errorf("Get() returned %d events, want %d", n, want)
+			}
+		})
+	}
+}
@@ -15,8 +15,10 @@
 package database
 
 import (
+	"context"
 	"fmt"
 	"iter"
+	"maps"
 	"strings"
 	"sync"
 	"time"
@@ -35,6 +37,11 @@ type localSession struct {
 	events    []*session.Event
 	state     map[string]any
 	updatedAt time.Time
+	// version is the version of the state read by the session.
+	version int64
+
+	// service is the service storing the session.
+	service *databaseService
 }
 
 func (s *localSession) ID() string {
@@ -68,10 +75,6 @@ func (s *localSession) LastUpdateTime() time.Time {
 }
 
 func (s *localSession) appendEvent(event *session.Event) error {
-	if event.Partial {
-		return nil
-	}
-
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -85,6 +88,52 @@ func (s *localSession) appendEvent(event *session.Event) error {
 	return nil
 }
 
+// StateVersion implements session.VersionedSession.
+func (s *localSession) StateVersion() int64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.version
+}
+
+// StateStale implements session.VersionedSession. It reports false if the
+// version of the stored session cannot be read.
+func (s *localSession) StateStale() bool {
+	if s.service == nil {
+		return false
+	}
+	var stored storageSession
+	err := s.service.db.Select("state_version").
+		Where(&storageSession{AppName: s.appName, UserID: s.userID, ID: s.sessionID}).
+		First(&stored).Error
+	return err == nil && stored.StateVersion != s.StateVersion()
+}
+
+// RefreshState implements session.VersionedSession.
+func (s *localSession) RefreshState(ctx context.Context) error {
+	if s.service == nil {
+		return nil
+	}
+	current, version, err := s.service.readState(ctx, s.appName, s.userID, s.sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to refresh the session state: %w", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.refreshState(current)
+	s.version = version
+	return nil
+}
+
+// refreshState replaces the state of the session by current, keeping the
+// temporary keys of the invocation. The map is updated in place, for the
+// values returned by State to see the changes. The caller must hold s.mu.
+func (s *localSession) refreshState(current map[string]any) {
+	maps.DeleteFunc(s.state, func(key string, _ any) bool {
+		return !strings.HasPrefix(key, session.KeyPrefixTemp)
+	})
+	maps.Copy(s.state, current)
+}
+
 type events []*session.Event
 
 func (e events) All() iter.Seq[*session.Event] {
@@ -151,7 +200,7 @@ func (s *state) Set(key string, value any) error {
 
 // TrimTempDeltaState removes temporary state delta keys from the event.
 func trimTempDeltaState(event *session.Event) *session.Event {
-	if len(event.Actions.StateDelta) == 0 {
+	if len(event.Actions.StateDelta) == 0 && len(event.Actions.StateOps) == 0 {
 		return event
 	}
 
@@ -165,13 +214,20 @@ func trimTempDeltaState(event *session.Event) *session.Event {
 
 	// Replace the old map with the newly filtered one.
 	event.Actions.StateDelta = filteredStateDelta
+	var filteredStateOps []session.StateOp
+	for _, op := range event.Actions.StateOps {
+		if !strings.HasPrefix(op.Key, session.KeyPrefixTemp) {
+			filteredStateOps = append(filteredStateOps, op)
+		}
+	}
+	event.Actions.StateOps = filteredStateOps
 
 	return event
 }
 
 // updateSessionState updates the session state based on the event state delta.
 func updateSessionState(sess *localSession, event *session.Event) error {
-	if event.Actions.StateDelta == nil {
+	if event.Actions.StateDelta == nil && len(event.Actions.StateOps) == 0 {
 		return nil // Nothing to do
 	}
 
@@ -186,6 +242,7 @@ func updateSessionState(sess *localSession, event *session.Event) error {
 		}
 		sess.state[key] = value
 	}
+	session.ApplyStateOps(sess.state, event.Actions.StateOps)
 
 	return nil
 }
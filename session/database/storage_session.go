@@ -33,6 +33,11 @@ type storageSession struct {
 	State      stateMap
 	CreateTime time.Time `gorm:"precision:6"`
 	UpdateTime time.Time `gorm:"precision:6"`
+	// StateVersion is the version of the state, incremented by every event
+	// changing it. StateHistory holds the JSON encoded changes of its last
+	// versions, for the merges of the stale events.
+	StateVersion int64
+	StateHistory dynamicJSON
 
 	// Has-Many relationship: A session has many events.
 	Events []storageEvent `gorm:"foreignKey:AppName,UserID,SessionID;references:AppName,UserID,ID"`
@@ -50,8 +55,8 @@ func createStorageSession(s *localSession) (*storageSession, error) {
 		AppName:    s.appName,
 		ID:         s.sessionID,
 		State:      s.state,
-		CreateTime: time.Now(),
-		UpdateTime: time.Now(),
+		CreateTime: s.updatedAt,
+		UpdateTime: s.updatedAt,
 	}, nil
 }
 
@@ -63,6 +68,7 @@ func createSessionFromStorageSession(storage *storageSession) (*localSession, er
 		sessionID: storage.ID,
 		state:     storage.State,
 		updatedAt: storage.UpdateTime,
+		version:   storage.StateVersion,
 	}, nil
 }
 
@@ -0,0 +1,457 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eventhook_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/httpx"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/session/eventhook"
+	"google.golang.org/adk/tool/longrunning"
+)
+
+func newSession(t *testing.T, svc session.Service, id string) session.Session {
+	t.Helper()
+	resp, err := svc.Create(t.Context(), &session.CreateRequest{AppName: "app", UserID: "user", SessionID: id})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.Session
+}
+
+func newEvent(id, author string, parts ...*genai.Part) *session.Event {
+	ev := session.NewEvent("inv")
+	ev.ID, ev.Author = id, author
+	if len(parts) > 0 {
+		ev.Content = &genai.Content{Role: genai.RoleModel, Parts: parts}
+	}
+	return ev
+}
+
+// recorder is a subscription callback recording the batches.
+type recorder struct {
+	mu      sync.Mutex
+	batches [][]eventhook.Payload
+}
+
+func (r *recorder) callback(_ context.Context, events []eventhook.Payload) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.batches = append(r.batches, events)
+	return nil
+}
+
+func (r *recorder) ids() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var ids []string
+	for _, b := range r.batches {
+		for _, p := range b {
+			ids = append(ids, p.ID)
+		}
+	}
+	return ids
+}
+
+func closeHub(t *testing.T, hub *eventhook.Hub) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(t.Context(), 10*time.Second)
+	defer cancel()
+	if err := hub.Close(ctx); err != nil {
+		t.Fatalf("Close() failed: %v", err)
+	}
+}
+
+func TestFilter(t *testing.T) {
+	escalation := newEvent("escalation", "agent", genai.NewPartFromText("I need help"))
+	escalation.Actions.Escalate = true
+	artifact := newEvent("artifact", "agent")
+	artifact.Actions.ArtifactDelta = map[string]int64{"report.pdf": 2}
+	failure := newEvent("failure", "agent", genai.NewPartFromFunctionResponse("charge", map[string]any{"error": "card declined"}))
+	events := []*session.Event{
+		newEvent("user", "user", genai.NewPartFromText("hi")),
+		newEvent("call", "agent", genai.NewPartFromFunctionCall("charge", map[string]any{"amount": 10})),
+		failure,
+		newEvent("lookup", "helper", genai.NewPartFromFunctionResponse("lookup", map[string]any{"result": "ok"})),
+		escalation,
+		artifact,
+	}
+	partial := newEvent("partial", "agent", genai.NewPartFromText("I ne"))
+	partial.Partial = true
+	events = append(events, partial)
+
+	for _, tc := range []struct {
+		name   string
+		filter eventhook.Filter
+		want   []string
+	}{
+		{"All", eventhook.Filter{}, []string{"user", "call", "failure", "lookup", "escalation", "artifact"}},
+		{"Types", eventhook.Filter{Types: []eventhook.EventType{eventhook.EventEscalation, eventhook.EventArtifact, eventhook.EventToolFailure}}, []string{"failure", "escalation", "artifact"}},
+		{"ToolNames", eventhook.Filter{ToolNames: []string{"charge"}}, []string{"call", "failure"}},
+		{"Authors", eventhook.Filter{Authors: []string{"helper", "user"}}, []string{"user", "lookup"}},
+		{"Combined", eventhook.Filter{Types: []eventhook.EventType{eventhook.EventFunctionResponse}, ToolNames: []string{"charge"}}, []string{"failure"}},
+		{"Match", eventhook.Filter{Match: func(ev *session.Event) bool { return strings.HasPrefix(ev.ID, "a") }}, []string{"artifact"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			hub := eventhook.New(eventhook.Config{})
+			rec := &recorder{}
+			if err := hub.Subscribe(eventhook.Subscription{Name: "sub", Filter: tc.filter, Callback: rec.callback}); err != nil {
+				t.Fatal(err)
+			}
+			sess := newSession(t, session.InMemoryService(), "s")
+			for _, ev := range events {
+				hub.Publish(sess, ev)
+			}
+			closeHub(t, hub)
+			if diff := cmp.Diff(tc.want, rec.ids()); diff != "" {
+				t.Errorf("delivered events mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestPayload(t *testing.T) {
+	hub := eventhook.New(eventhook.Config{MaxInlineBytes: 16})
+	rec := &recorder{}
+	if err := hub.Subscribe(eventhook.Subscription{Name: "sub", Callback: rec.callback}); err != nil {
+		t.Fatal(err)
+	}
+	ev := newEvent("ev", "agent",
+		genai.NewPartFromText("a text longer than the limit"),
+		genai.NewPartFromBytes([]byte("png"), "image/png"),
+		genai.NewPartFromFunctionCall("search", map[string]any{"q": "go"}),
+		genai.NewPartFromFunctionResponse("fetch", map[string]any{"error": "timeout", "details": "the upstream server did not answer"}),
+	)
+	ev.Actions.ArtifactDelta = map[string]int64{"report.pdf": 3}
+	ev.Actions.StateDelta = map[string]any{"b": 1, "a": 2}
+	ev.Actions.TransferToAgent = "helper"
+	hub.Publish(newSession(t, session.InMemoryService(), "s"), ev)
+	closeHub(t, hub)
+
+	want := [][]eventhook.Payload{{{
+		ID:           "ev",
+		AppName:      "app",
+		UserID:       "user",
+		SessionID:    "s",
+		InvocationID: "inv",
+		Author:       "agent",
+		Timestamp:    ev.Timestamp,
+		Types: []eventhook.EventType{eventhook.EventMessage, eventhook.EventFunctionCall, eventhook.EventFunctionResponse,
+			eventhook.EventToolFailure, eventhook.EventArtifact, eventhook.EventStateChange, eventhook.EventTransfer},
+		Text:              "a text longer th",
+		TextElided:        true,
+		BlobsElided:       1,
+		FunctionCalls:     []eventhook.FunctionCall{{Name: "search", Args: map[string]any{"q": "go"}}},
+		FunctionResponses: []eventhook.FunctionResponse{{Name: "fetch", Error: "timeout", ResponseElided: true}},
+		Artifacts:         map[string]int64{"report.pdf": 3},
+		StateKeys:         []string{"a", "b"},
+		TransferToAgent:   "helper",
+	}}}
+	if diff := cmp.Diff(want, rec.batches); diff != "" {
+		t.Errorf("payloads mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestBatching(t *testing.T) {
+	hub := eventhook.New(eventhook.Config{})
+	rec := &recorder{}
+	if err := hub.Subscribe(eventhook.Subscription{Name: "sub", Callback: rec.callback, BatchSize: 3, BatchWait: time.Hour}); err != nil {
+		t.Fatal(err)
+	}
+	sess := newSession(t, session.InMemoryService(), "s")
+	for i := range 7 {
+		hub.Publish(sess, newEvent(fmt.Sprint(i), "agent", genai.NewPartFromText("hi")))
+	}
+	// The last batch is not full: it is delivered when the hub is closed.
+	closeHub(t, hub)
+
+	var sizes []int
+	for _, b := range rec.batches {
+		sizes = append(sizes, len(b))
+	}
+	if diff := cmp.Diff([]int{3, 3, 1}, sizes); diff != "" {
+		t.Errorf("batch sizes mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"0", "1", "2", "3", "4", "5", "6"}, rec.ids()); diff != "" {
+		t.Errorf("delivered events mismatch (-want +got):\n%s", diff)
+	}
+	if stats, _ := hub.Stats("sub"); stats.Delivered != 7 {
+		t.Errorf("Stats().Delivered = %d, want 7", stats.Delivered)
+	}
+}
+
+func TestWebhook_Retry(t *testing.T) {
+	secret := []byte("secret")
+	var (
+		mu       sync.Mutex
+		attempts int
+		received []eventhook.Payload
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if !longrunning.Verify(secret, body, r.Header.Get(longrunning.SignatureHeader)) {
+			t.Errorf("invalid signature %q", r.Header.Get(longrunning.SignatureHeader))
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer token" {
+			t.Errorf("Authorization header = %q, want the configured header", got)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		attempts++
+		switch {
+		case r.URL.Path == "/rejected":
+			w.WriteHeader(http.StatusBadRequest)
+		case attempts <= 2:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			var b eventhook.Body
+			if err := json.Unmarshal(body, &b); err != nil {
+				t.Errorf("invalid body: %v", err)
+			}
+			received = append(received, b.Events...)
+		}
+	}))
+	defer server.Close()
+
+	deliver := func(t *testing.T, path string) eventhook.Stats {
+		t.Helper()
+		hub := eventhook.New(eventhook.Config{})
+		err := hub.Subscribe(eventhook.Subscription{
+			Name:        "sub",
+			Webhook:     &eventhook.Webhook{URL: server.URL + path, Secret: secret, Headers: map[string]string{"Authorization": "Bearer token"}},
+			BatchSize:   2,
+			MaxAttempts: 3,
+			Backoff:     time.Millisecond,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		sess := newSession(t, session.InMemoryService(), "s")
+		hub.Publish(sess, newEvent("a", "agent", genai.NewPartFromText("a")))
+		hub.Publish(sess, newEvent("b", "agent", genai.NewPartFromText("b")))
+		closeHub(t, hub)
+		return mustStats(t, hub, "sub")
+	}
+
+	// The batch is delivered at the third attempt.
+	if diff := cmp.Diff(eventhook.Stats{Delivered: 2}, deliver(t, "/retried")); diff != "" {
+		t.Errorf("Stats() mismatch (-want +got):\n%s", diff)
+	}
+	mu.Lock()
+	if attempts != 3 {
+		t.Errorf("attempts = %d, want 3", attempts)
+	}
+	var ids []string
+	for _, p := range received {
+		ids = append(ids, p.ID)
+	}
+	if diff := cmp.Diff([]string{"a", "b"}, ids); diff != "" {
+		t.Errorf("received events mismatch (-want +got):\n%s", diff)
+	}
+	mu.Unlock()
+
+	// The rejected batch is not retried.
+	if diff := cmp.Diff(eventhook.Stats{Failed: 2}, deliver(t, "/rejected")); diff != "" {
+		t.Errorf("Stats() of the rejected batch mismatch (-want +got):\n%s", diff)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if attempts != 4 {
+		t.Errorf("attempts = %d, want 4", attempts)
+	}
+}
+
+func TestWebhook_OutboundHeaders(t *testing.T) {
+	headers := make(chan http.Header, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers <- r.Header.Clone()
+	}))
+	defer server.Close()
+
+	for _, tc := range []struct {
+		name   string
+		client *http.Client
+		want   map[string]string
+	}{
+		{
+			name: "Default",
+			want: map[string]string{"X-Request-ID": "inv", "X-Session-Hash": httpx.Hash(nil, "s"), "X-Agent-Name": "agent"},
+		},
+		{
+			name:   "CustomClient",
+			client: &http.Client{},
+			want:   map[string]string{},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			hub := eventhook.New(eventhook.Config{Client: tc.client})
+			if err := hub.Subscribe(eventhook.Subscription{Name: "sub", Webhook: &eventhook.Webhook{URL: server.URL}}); err != nil {
+				t.Fatal(err)
+			}
+			hub.Publish(newSession(t, session.InMemoryService(), "s"), newEvent("a", "agent", genai.NewPartFromText("a")))
+			closeHub(t, hub)
+
+			h := <-headers
+			got := map[string]string{}
+			for _, name := range []string{"X-Request-ID", "X-Session-Hash", "X-Agent-Name"} {
+				if v := h.Get(name); v != "" {
+					got[name] = v
+				}
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("headers mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func mustStats(t *testing.T, hub *eventhook.Hub, name string) eventhook.Stats {
+	t.Helper()
+	stats, ok := hub.Stats(name)
+	if !ok {
+		t.Fatalf("Stats(%q) found no subscription", name)
+	}
+	return stats
+}
+
+func TestOverflow(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		overflow eventhook.Overflow
+		want     []string
+	}{
+		{"DropNewest", eventhook.DropNewest, []string{"0", "1", "2"}},
+		{"DropOldest", eventhook.DropOldest, []string{"0", "3", "4"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			started, release := make(chan struct{}), make(chan struct{})
+			rec := &recorder{}
+			callback := func(ctx context.Context, events []eventhook.Payload) error {
+				if events[0].ID == "0" {
+					started <- struct{}{}
+					<-release
+				}
+				return rec.callback(ctx, events)
+			}
+			hub := eventhook.New(eventhook.Config{})
+			if err := hub.Subscribe(eventhook.Subscription{Name: "slow", Callback: callback, QueueSize: 2, Overflow: tc.overflow}); err != nil {
+				t.Fatal(err)
+			}
+			sess := newSession(t, session.InMemoryService(), "s")
+			hub.Publish(sess, newEvent("0", "agent", genai.NewPartFromText("0")))
+			<-started
+
+			// The subscriber is stuck: publishing must not block.
+			for i := 1; i < 5; i++ {
+				hub.Publish(sess, newEvent(fmt.Sprint(i), "agent", genai.NewPartFromText("x")))
+			}
+			if diff := cmp.Diff(eventhook.Stats{Dropped: 2, Queued: 2}, mustStats(t, hub, "slow")); diff != "" {
+				t.Errorf("Stats() while stuck mismatch (-want +got):\n%s", diff)
+			}
+			close(release)
+			closeHub(t, hub)
+			if diff := cmp.Diff(tc.want, rec.ids()); diff != "" {
+				t.Errorf("delivered events mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(eventhook.Stats{Delivered: 3, Dropped: 2}, mustStats(t, hub, "slow")); diff != "" {
+				t.Errorf("Stats() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSessionService_Ordering(t *testing.T) {
+	const sessions, events = 8, 50
+	hub := eventhook.New(eventhook.Config{})
+	recorders := []*recorder{{}, {}}
+	for i, rec := range recorders {
+		if err := hub.Subscribe(eventhook.Subscription{Name: fmt.Sprint("sub", i), Callback: rec.callback, BatchSize: i*4 + 1}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	svc := hub.SessionService(session.InMemoryService())
+
+	var wg sync.WaitGroup
+	for s := range sessions {
+		sess := newSession(t, svc, fmt.Sprint("s", s))
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range events {
+				ev := newEvent(fmt.Sprintf("%s-%d", sess.ID(), i), "agent", genai.NewPartFromText("x"))
+				if err := svc.AppendEvent(t.Context(), sess, ev); err != nil {
+					t.Errorf("AppendEvent() failed: %v", err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	closeHub(t, hub)
+
+	for i, rec := range recorders {
+		got := make(map[string][]string)
+		for _, id := range rec.ids() {
+			sessionID, _, _ := strings.Cut(id, "-")
+			got[sessionID] = append(got[sessionID], id)
+		}
+		want := make(map[string][]string)
+		for s := range sessions {
+			for j := range events {
+				want[fmt.Sprint("s", s)] = append(want[fmt.Sprint("s", s)], fmt.Sprintf("s%d-%d", s, j))
+			}
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("events of subscriber %d mismatch (-want +got):\n%s", i, diff)
+		}
+	}
+}
+
+func TestSubscribe_Invalid(t *testing.T) {
+	hub := eventhook.New(eventhook.Config{})
+	defer closeHub(t, hub)
+	callback := func(context.Context, []eventhook.Payload) error { return nil }
+	if err := hub.Subscribe(eventhook.Subscription{Name: "sub", Callback: callback}); err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		name string
+		sub  eventhook.Subscription
+	}{
+		{"NoName", eventhook.Subscription{Callback: callback}},
+		{"Duplicate", eventhook.Subscription{Name: "sub", Callback: callback}},
+		{"NoTarget", eventhook.Subscription{Name: "other"}},
+		{"BothTargets", eventhook.Subscription{Name: "other", Callback: callback, Webhook: &eventhook.Webhook{URL: "http://example.com"}}},
+		{"NoURL", eventhook.Subscription{Name: "other", Webhook: &eventhook.Webhook{}}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := hub.Subscribe(tc.sub); err == nil {
+				t.Error("Subscribe() succeeded, want an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,417 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package eventhook delivers the events of the sessions to subscribers as
+// they are appended, so that external systems can react to escalations,
+// saved artifacts or tool failures without polling the sessions.
+//
+// A [Hub] is installed with its session service wrapper:
+//
+//	hub := eventhook.New(eventhook.Config{})
+//	defer hub.Close(ctx)
+//	err := hub.Subscribe(eventhook.Subscription{
+//		Name:    "escalations",
+//		Filter:  eventhook.Filter{Types: []eventhook.EventType{eventhook.EventEscalation}},
+//		Webhook: &eventhook.Webhook{URL: "https://example.com/hooks/adk", Secret: secret},
+//	})
+//	...
+//	r, err := runner.New(runner.Config{
+//		...
+//		SessionService: hub.SessionService(sessionService),
+//	})
+//
+// The events are delivered asynchronously: each subscription has a bounded
+// queue and a worker, so that a slow subscriber never blocks the
+// conversation. The events of a session are delivered to a subscriber in the
+// order they were appended.
+package eventhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"google.golang.org/adk/httpx"
+	"google.golang.org/adk/session"
+)
+
+// Overflow is the policy of a subscription whose queue is full.
+type Overflow int
+
+const (
+	// DropNewest drops the events published while the queue is full.
+	DropNewest Overflow = iota
+	// DropOldest drops the oldest queued event to make room for the new
+	// one.
+	DropOldest
+)
+
+// Subscription is a subscriber of a [Hub].
+type Subscription struct {
+	// Name identifies the subscription in the hub.
+	Name   string
+	Filter Filter
+	// Callback, if set, receives the batches of events in process. It is
+	// called by a single goroutine. A batch is retried if it returns an
+	// error.
+	Callback func(ctx context.Context, events []Payload) error
+	// Webhook, if set, receives the batches of events over HTTP. Exactly
+	// one of Callback and Webhook must be set.
+	Webhook *Webhook
+
+	// BatchSize is the maximum number of events per delivery. Defaults to
+	// 1.
+	BatchSize int
+	// BatchWait is how long a delivery waits for the batch to fill up
+	// after its first event. Zero delivers the events already queued
+	// without waiting.
+	BatchWait time.Duration
+	// QueueSize is the maximum number of queued events. Defaults to 1000.
+	QueueSize int
+	// Overflow is the policy when the queue is full.
+	Overflow Overflow
+	// MaxAttempts is the maximum number of attempts of a delivery.
+	// Defaults to 5.
+	MaxAttempts int
+	// Backoff is the delay before the first retry, doubled at each retry.
+	// Defaults to 1s.
+	Backoff time.Duration
+	// MaxBackoff caps the delay between the retries. Defaults to 30s.
+	MaxBackoff time.Duration
+}
+
+// Stats are the delivery counters of a subscription.
+type Stats struct {
+	// Delivered is the number of events delivered.
+	Delivered int64
+	// Dropped is the number of events dropped because the queue was full.
+	Dropped int64
+	// Failed is the number of events whose delivery failed after all the
+	// attempts, or was rejected by the webhook.
+	Failed int64
+	// Queued is the number of events waiting for delivery.
+	Queued int
+}
+
+// Config is the configuration of a [Hub].
+type Config struct {
+	// Client sends the webhook requests. Defaults to a client with a 10s
+	// timeout, propagating the metadata of the invocation of the first
+	// event of each delivery in its headers, see httpx.Transport.
+	Client *http.Client
+	// MaxInlineBytes is the size above which the text, the arguments and
+	// the responses of the events are left out of the payloads. Defaults to
+	// 4096.
+	MaxInlineBytes int
+}
+
+// Hub delivers the events of the sessions to the subscriptions.
+type Hub struct {
+	cfg    Config
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu     sync.RWMutex
+	subs   map[string]*subscriber
+	closed bool
+}
+
+// New returns a Hub with the given configuration.
+func New(cfg Config) *Hub {
+	if cfg.Client == nil {
+		cfg.Client = &http.Client{Timeout: 10 * time.Second, Transport: httpx.DefaultClient.Transport}
+	}
+	if cfg.MaxInlineBytes <= 0 {
+		cfg.MaxInlineBytes = 4096
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Hub{cfg: cfg, ctx: ctx, cancel: cancel, subs: make(map[string]*subscriber)}
+}
+
+// Subscribe adds a subscription, and starts delivering the events published
+// from now on.
+func (h *Hub) Subscribe(sub Subscription) error {
+	if sub.Name == "" {
+		return errors.New("subscription name is required")
+	}
+	if (sub.Callback == nil) == (sub.Webhook == nil) {
+		return fmt.Errorf("subscription %q must have either a callback or a webhook", sub.Name)
+	}
+	if sub.Webhook != nil && sub.Webhook.URL == "" {
+		return fmt.Errorf("webhook URL of subscription %q is required", sub.Name)
+	}
+	if sub.BatchSize <= 0 {
+		sub.BatchSize = 1
+	}
+	if sub.QueueSize <= 0 {
+		sub.QueueSize = 1000
+	}
+	if sub.MaxAttempts <= 0 {
+		sub.MaxAttempts = 5
+	}
+	if sub.Backoff <= 0 {
+		sub.Backoff = time.Second
+	}
+	if sub.MaxBackoff <= 0 {
+		sub.MaxBackoff = 30 * time.Second
+	}
+	if sub.Webhook != nil {
+		client := h.cfg.Client
+		wh := sub.Webhook
+		sub.Callback = func(ctx context.Context, events []Payload) error {
+			return wh.post(ctx, client, events)
+		}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return errors.New("hub is closed")
+	}
+	if _, ok := h.subs[sub.Name]; ok {
+		return fmt.Errorf("subscription %q already exists", sub.Name)
+	}
+	s := &subscriber{sub: sub, ready: make(chan struct{}, 1), stop: make(chan struct{}), done: make(chan struct{})}
+	h.subs[sub.Name] = s
+	go s.run(h.ctx)
+	return nil
+}
+
+// Unsubscribe removes the subscription with the given name. The events
+// already queued are dropped; the delivery in progress, if any, completes.
+func (h *Hub) Unsubscribe(name string) {
+	h.mu.Lock()
+	s, ok := h.subs[name]
+	delete(h.subs, name)
+	h.mu.Unlock()
+	if ok {
+		close(s.stop)
+	}
+}
+
+// Stats returns the counters of the subscription with the given name.
+func (h *Hub) Stats(name string) (Stats, bool) {
+	h.mu.RLock()
+	s, ok := h.subs[name]
+	h.mu.RUnlock()
+	if !ok {
+		return Stats{}, false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats := s.stats
+	stats.Queued = len(s.queue)
+	return stats, true
+}
+
+// Publish queues ev, appended to sess, for the matching subscriptions. It
+// never blocks. The partial events are not published.
+func (h *Hub) Publish(sess session.Session, ev *session.Event) {
+	if ev == nil || ev.Partial {
+		return
+	}
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if h.closed || len(h.subs) == 0 {
+		return
+	}
+	types := Types(ev)
+	var payload *Payload
+	for _, s := range h.subs {
+		if !s.sub.Filter.matches(ev, types) {
+			continue
+		}
+		if payload == nil {
+			p := newPayload(sess, ev, types, h.cfg.MaxInlineBytes)
+			payload = &p
+		}
+		s.enqueue(*payload)
+	}
+}
+
+// Close stops accepting events and waits until the queued events are
+// delivered, or ctx is done: the remaining deliveries are then abandoned.
+func (h *Hub) Close(ctx context.Context) error {
+	h.mu.Lock()
+	h.closed = true
+	subs := make([]*subscriber, 0, len(h.subs))
+	for _, s := range h.subs {
+		subs = append(subs, s)
+	}
+	h.mu.Unlock()
+	defer h.cancel()
+
+	for _, s := range subs {
+		s.mu.Lock()
+		s.draining = true
+		s.mu.Unlock()
+		s.signal()
+	}
+	for _, s := range subs {
+		select {
+		case <-s.done:
+		case <-ctx.Done():
+			h.cancel()
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// SessionService returns a session service delegating to s, which
+// publishes the events once they are appended.
+func (h *Hub) SessionService(s session.Service) session.Service {
+	return &sessionService{Service: s, hub: h}
+}
+
+type sessionService struct {
+	session.Service
+	hub *Hub
+}
+
+func (s *sessionService) AppendEvent(ctx context.Context, sess session.Session, ev *session.Event) error {
+	if err := s.Service.AppendEvent(ctx, sess, ev); err != nil {
+		return err
+	}
+	s.hub.Publish(sess, ev)
+	return nil
+}
+
+// subscriber queues and delivers the events of a subscription. A single
+// worker delivers the events, in the order they were queued.
+type subscriber struct {
+	sub   Subscription
+	ready chan struct{}
+	stop  chan struct{}
+	done  chan struct{}
+
+	mu       sync.Mutex
+	queue    []Payload
+	stats    Stats
+	draining bool
+}
+
+func (s *subscriber) enqueue(p Payload) {
+	s.mu.Lock()
+	if len(s.queue) >= s.sub.QueueSize {
+		s.stats.Dropped++
+		if s.sub.Overflow != DropOldest {
+			s.mu.Unlock()
+			return
+		}
+		s.queue = s.queue[1:]
+	}
+	s.queue = append(s.queue, p)
+	s.mu.Unlock()
+	s.signal()
+}
+
+func (s *subscriber) signal() {
+	select {
+	case s.ready <- struct{}{}:
+	default:
+	}
+}
+
+// take removes the next batch from the queue. It reports false once the
+// queue is empty and the subscriber is draining.
+func (s *subscriber) take(ctx context.Context) ([]Payload, bool) {
+	var deadline <-chan time.Time
+	for {
+		s.mu.Lock()
+		n, draining := len(s.queue), s.draining
+		if n >= s.sub.BatchSize || (n > 0 && (draining || s.sub.BatchWait <= 0)) {
+			batch := s.pop()
+			s.mu.Unlock()
+			return batch, true
+		}
+		if n == 0 && draining {
+			s.mu.Unlock()
+			return nil, false
+		}
+		s.mu.Unlock()
+		if n > 0 && deadline == nil {
+			timer := time.NewTimer(s.sub.BatchWait)
+			defer timer.Stop()
+			deadline = timer.C
+		}
+		select {
+		case <-s.ready:
+		case <-deadline:
+			s.mu.Lock()
+			batch := s.pop()
+			s.mu.Unlock()
+			return batch, true
+		case <-s.stop:
+			return nil, false
+		case <-ctx.Done():
+			return nil, false
+		}
+	}
+}
+
+func (s *subscriber) pop() []Payload {
+	n := min(len(s.queue), s.sub.BatchSize)
+	batch := make([]Payload, n)
+	copy(batch, s.queue)
+	s.queue = s.queue[n:]
+	return batch
+}
+
+func (s *subscriber) run(ctx context.Context) {
+	defer close(s.done)
+	for {
+		batch, ok := s.take(ctx)
+		if !ok {
+			return
+		}
+		err := s.deliver(ctx, batch)
+		s.mu.Lock()
+		if err != nil {
+			s.stats.Failed += int64(len(batch))
+		} else {
+			s.stats.Delivered += int64(len(batch))
+		}
+		s.mu.Unlock()
+	}
+}
+
+// deliver delivers batch, retrying the transient failures.
+func (s *subscriber) deliver(ctx context.Context, batch []Payload) error {
+	backoff := s.sub.Backoff
+	for attempt := 1; ; attempt++ {
+		err := s.sub.Callback(ctx, batch)
+		if err == nil {
+			return nil
+		}
+		var permanent *permanentError
+		if errors.As(err, &permanent) || attempt >= s.sub.MaxAttempts {
+			return err
+		}
+		timer := time.NewTimer(backoff)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-s.stop:
+			timer.Stop()
+			return err
+		}
+		backoff = min(2*backoff, s.sub.MaxBackoff)
+	}
+}
@@ -0,0 +1,288 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eventhook
+
+import (
+	"encoding/json"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"google.golang.org/adk/session"
+)
+
+// EventType classifies the events for the filters of the subscriptions. An
+// event can have several types.
+type EventType string
+
+const (
+	// EventUserMessage is the type of the events authored by the user.
+	EventUserMessage EventType = "user_message"
+	// EventMessage is the type of the events of the agents with text.
+	EventMessage EventType = "message"
+	// EventFunctionCall is the type of the events calling tools.
+	EventFunctionCall EventType = "function_call"
+	// EventFunctionResponse is the type of the events with tool results.
+	EventFunctionResponse EventType = "function_response"
+	// EventToolFailure is the type of the events with a tool result
+	// reporting an error.
+	EventToolFailure EventType = "tool_failure"
+	// EventArtifact is the type of the events saving artifacts.
+	EventArtifact EventType = "artifact"
+	// EventStateChange is the type of the events changing the session
+	// state.
+	EventStateChange EventType = "state_change"
+	// EventEscalation is the type of the events escalating to a higher
+	// level agent.
+	EventEscalation EventType = "escalation"
+	// EventTransfer is the type of the events transferring to another
+	// agent.
+	EventTransfer EventType = "transfer"
+	// EventError is the type of the events carrying a model error.
+	EventError EventType = "error"
+)
+
+// Types returns the types of ev.
+func Types(ev *session.Event) []EventType {
+	var types []EventType
+	if ev.Author == "user" {
+		types = append(types, EventUserMessage)
+	}
+	var text, calls, responses, failures bool
+	if ev.Content != nil {
+		for _, p := range ev.Content.Parts {
+			switch {
+			case p == nil:
+			case p.Text != "" && !p.Thought:
+				text = true
+			case p.FunctionCall != nil:
+				calls = true
+			case p.FunctionResponse != nil:
+				responses = true
+				if _, ok := p.FunctionResponse.Response["error"]; ok {
+					failures = true
+				}
+			}
+		}
+	}
+	if text && ev.Author != "user" {
+		types = append(types, EventMessage)
+	}
+	if calls {
+		types = append(types, EventFunctionCall)
+	}
+	if responses {
+		types = append(types, EventFunctionResponse)
+	}
+	if failures {
+		types = append(types, EventToolFailure)
+	}
+	if len(ev.Actions.ArtifactDelta) > 0 {
+		types = append(types, EventArtifact)
+	}
+	if len(ev.Actions.StateDelta) > 0 || len(ev.Actions.StateOps) > 0 {
+		types = append(types, EventStateChange)
+	}
+	if ev.Actions.Escalate {
+		types = append(types, EventEscalation)
+	}
+	if ev.Actions.TransferToAgent != "" {
+		types = append(types, EventTransfer)
+	}
+	if ev.ErrorCode != "" {
+		types = append(types, EventError)
+	}
+	return types
+}
+
+// Filter selects the events delivered to a subscription. The zero Filter
+// selects all the events.
+type Filter struct {
+	// Types selects the events having one of the types.
+	Types []EventType
+	// ToolNames selects the events calling one of the tools, or with a
+	// result of one of them.
+	ToolNames []string
+	// Authors selects the events authored by one of the agents, or "user".
+	Authors []string
+	// Match, if set, selects the events for which it returns true.
+	Match func(ev *session.Event) bool
+}
+
+func (f *Filter) matches(ev *session.Event, types []EventType) bool {
+	if len(f.Types) > 0 && !slices.ContainsFunc(types, func(t EventType) bool { return slices.Contains(f.Types, t) }) {
+		return false
+	}
+	if len(f.Authors) > 0 && !slices.Contains(f.Authors, ev.Author) {
+		return false
+	}
+	if len(f.ToolNames) > 0 {
+		found := false
+		if ev.Content != nil {
+			for _, p := range ev.Content.Parts {
+				if p == nil {
+					continue
+				}
+				if (p.FunctionCall != nil && slices.Contains(f.ToolNames, p.FunctionCall.Name)) ||
+					(p.FunctionResponse != nil && slices.Contains(f.ToolNames, p.FunctionResponse.Name)) {
+					found = true
+					break
+				}
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return f.Match == nil || f.Match(ev)
+}
+
+// Payload is the JSON representation of an event delivered to the
+// subscribers. The large contents are elided: the subscribers can read them
+// from the session, by event ID, and the artifacts by name.
+type Payload struct {
+	ID           string      `json:"id"`
+	AppName      string      `json:"app_name"`
+	UserID       string      `json:"user_id"`
+	SessionID    string      `json:"session_id"`
+	InvocationID string      `json:"invocation_id"`
+	Author       string      `json:"author"`
+	Branch       string      `json:"branch,omitempty"`
+	Timestamp    time.Time   `json:"timestamp"`
+	Types        []EventType `json:"types"`
+
+	Text string `json:"text,omitempty"`
+	// TextElided is set if the text was longer than the inline limit.
+	TextElided bool `json:"text_elided,omitempty"`
+	// BlobsElided is the number of inline blobs of the event, which are
+	// never included.
+	BlobsElided       int                `json:"blobs_elided,omitempty"`
+	FunctionCalls     []FunctionCall     `json:"function_calls,omitempty"`
+	FunctionResponses []FunctionResponse `json:"function_responses,omitempty"`
+
+	// Artifacts are the versions of the artifacts saved by the event, by
+	// name.
+	Artifacts map[string]int64 `json:"artifacts,omitempty"`
+	// StateKeys are the keys of the state changed by the event.
+	StateKeys       []string `json:"state_keys,omitempty"`
+	Escalate        bool     `json:"escalate,omitempty"`
+	TransferToAgent string   `json:"transfer_to_agent,omitempty"`
+	ErrorCode       string   `json:"error_code,omitempty"`
+	ErrorMessage    string   `json:"error_message,omitempty"`
+}
+
+// FunctionCall is a tool call of a [Payload].
+type FunctionCall struct {
+	ID         string         `json:"id,omitempty"`
+	Name       string         `json:"name"`
+	Args       map[string]any `json:"args,omitempty"`
+	ArgsElided bool           `json:"args_elided,omitempty"`
+}
+
+// FunctionResponse is a tool result of a [Payload].
+type FunctionResponse struct {
+	ID   string `json:"id,omitempty"`
+	Name string `json:"name"`
+	// Error is the error reported by the tool, if any.
+	Error          string         `json:"error,omitempty"`
+	Response       map[string]any `json:"response,omitempty"`
+	ResponseElided bool           `json:"response_elided,omitempty"`
+}
+
+// newPayload returns the payload of ev, appended to s. The text, arguments
+// and responses larger than maxInline bytes are elided.
+func newPayload(s session.Session, ev *session.Event, types []EventType, maxInline int) Payload {
+	p := Payload{
+		ID:              ev.ID,
+		AppName:         s.AppName(),
+		UserID:          s.UserID(),
+		SessionID:       s.ID(),
+		InvocationID:    ev.InvocationID,
+		Author:          ev.Author,
+		Branch:          ev.Branch,
+		Timestamp:       ev.Timestamp,
+		Types:           types,
+		Artifacts:       maps.Clone(ev.Actions.ArtifactDelta),
+		Escalate:        ev.Actions.Escalate,
+		TransferToAgent: ev.Actions.TransferToAgent,
+		ErrorCode:       ev.ErrorCode,
+		ErrorMessage:    ev.ErrorMessage,
+	}
+	if ev.Content != nil {
+		var text strings.Builder
+		for _, part := range ev.Content.Parts {
+			switch {
+			case part == nil:
+			case part.Text != "" && !part.Thought:
+				text.WriteString(part.Text)
+			case part.InlineData != nil:
+				p.BlobsElided++
+			case part.FunctionCall != nil:
+				fc := FunctionCall{ID: part.FunctionCall.ID, Name: part.FunctionCall.Name}
+				fc.Args, fc.ArgsElided = inline(part.FunctionCall.Args, maxInline)
+				p.FunctionCalls = append(p.FunctionCalls, fc)
+			case part.FunctionResponse != nil:
+				fr := FunctionResponse{ID: part.FunctionResponse.ID, Name: part.FunctionResponse.Name}
+				if err, ok := part.FunctionResponse.Response["error"]; ok {
+					fr.Error = truncate(toString(err), maxInline)
+				}
+				fr.Response, fr.ResponseElided = inline(part.FunctionResponse.Response, maxInline)
+				p.FunctionResponses = append(p.FunctionResponses, fr)
+			}
+		}
+		if text.Len() > maxInline {
+			p.TextElided = true
+		}
+		p.Text = truncate(text.String(), maxInline)
+	}
+	keys := make(map[string]bool)
+	for k := range ev.Actions.StateDelta {
+		keys[k] = true
+	}
+	for _, op := range ev.Actions.StateOps {
+		keys[op.Key] = true
+	}
+	p.StateKeys = slices.Sorted(maps.Keys(keys))
+	return p
+}
+
+// inline returns m if its JSON encoding fits in maxInline bytes, and
+// reports whether it was elided.
+func inline(m map[string]any, maxInline int) (map[string]any, bool) {
+	if len(m) == 0 {
+		return nil, false
+	}
+	b, err := json.Marshal(m)
+	if err != nil || len(b) > maxInline {
+		return nil, true
+	}
+	return m, false
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return strings.ToValidUTF8(s[:n], "")
+}
+
+func toString(v any) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	b, _ := json.Marshal(v)
+	return string(b)
+}
@@ -0,0 +1,101 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eventhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"google.golang.org/adk/httpx"
+	"google.golang.org/adk/tool/longrunning"
+)
+
+// Webhook is an HTTP endpoint receiving the events of a subscription. The
+// events are posted as a JSON object {"events": [...]} of [Payload]s.
+//
+// The requests rejected with a 4xx status other than 408 and 429 are not
+// retried.
+type Webhook struct {
+	URL string
+	// Secret, if set, signs the requests: the longrunning.SignatureHeader
+	// header holds the HMAC-SHA256 of the body, see longrunning.Verify.
+	Secret []byte
+	// Headers are added to the requests.
+	Headers map[string]string
+}
+
+// Body is the JSON body posted to the webhooks.
+type Body struct {
+	Events []Payload `json:"events"`
+}
+
+// permanentError is the error of a delivery that must not be retried.
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+func (wh *Webhook) post(ctx context.Context, client *http.Client, events []Payload) error {
+	body, err := json.Marshal(Body{Events: events})
+	if err != nil {
+		return &permanentError{err}
+	}
+	if len(events) > 0 {
+		p := events[0]
+		ctx = httpx.NewContext(ctx, httpx.Metadata{
+			AppName:      p.AppName,
+			UserID:       p.UserID,
+			SessionID:    p.SessionID,
+			InvocationID: p.InvocationID,
+			AgentName:    p.Author,
+		})
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, wh.URL, bytes.NewReader(body))
+	if err != nil {
+		return &permanentError{err}
+	}
+	for k, v := range wh.Headers {
+		req.Header.Set(k, v)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if len(wh.Secret) > 0 {
+		req.Header.Set(longrunning.SignatureHeader, longrunning.Sign(wh.Secret, body))
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusRequestTimeout || resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	default:
+		return &permanentError{fmt.Errorf("webhook rejected the events with status %d", resp.StatusCode)}
+	}
+}
@@ -25,11 +25,11 @@ import (
 	"sync"
 	"time"
 
-	"github.com/google/uuid"
 	"rsc.io/omap"
 	"rsc.io/ordered"
 
 	"google.golang.org/adk/internal/sessionutils"
+	"google.golang.org/adk/runtimedeps"
 )
 
 type stateMap map[string]any
@@ -37,6 +37,7 @@ type stateMap map[string]any
 // inMemoryService is an in-memory implementation of sessionService.Service.
 // Thread-safe.
 type inMemoryService struct {
+	cfg       InMemoryConfig
 	mu        sync.RWMutex
 	sessions  omap.Map[string, *session] // session.ID) -> storedSession
 	userState map[string]map[string]stateMap
@@ -50,7 +51,7 @@ func (s *inMemoryService) Create(ctx context.Context, req *CreateRequest) (*Crea
 
 	sessionID := req.SessionID
 	if sessionID == "" {
-		sessionID = uuid.NewString()
+		sessionID = runtimedeps.FromContext(ctx).NewID()
 	}
 
 	key := id{
@@ -74,7 +75,7 @@ func (s *inMemoryService) Create(ctx context.Context, req *CreateRequest) (*Crea
 	val := &session{
 		id:        key,
 		state:     state,
-		updatedAt: time.Now(),
+		updatedAt: runtimedeps.FromContext(ctx).Now(),
 	}
 
 	s.sessions.Set(encodedKey, val)
@@ -83,7 +84,7 @@ func (s *inMemoryService) Create(ctx context.Context, req *CreateRequest) (*Crea
 	userState := s.updateUserState(userDelta, req.AppName, req.UserID)
 	val.state = sessionutils.MergeStates(appState, userState, state)
 
-	copiedSession := copySessionWithoutStateAndEvents(val)
+	copiedSession := s.copySession(val)
 	copiedSession.state = maps.Clone(val.state)
 	copiedSession.events = slices.Clone(val.events)
 
@@ -112,7 +113,7 @@ func (s *inMemoryService) Get(ctx context.Context, req *GetRequest) (*GetRespons
 		return nil, fmt.Errorf("session %+v not found", req.SessionID)
 	}
 
-	copiedSession := copySessionWithoutStateAndEvents(res)
+	copiedSession := s.copySession(res)
 	copiedSession.state = s.mergeStates(res.state, appName, userID)
 
 	filteredEvents := res.events
@@ -166,7 +167,7 @@ func (s *inMemoryService) List(ctx context.Context, req *ListRequest) (*ListResp
 		if key.appName != appName && key.userID != userID {
 			break
 		}
-		copiedSession := copySessionWithoutStateAndEvents(storedSession)
+		copiedSession := s.copySession(storedSession)
 		copiedSession.state = s.mergeStates(storedSession.state, appName, storedSession.UserID())
 		sessions = append(sessions, copiedSession)
 	}
@@ -201,7 +202,7 @@ func (s *inMemoryService) AppendEvent(ctx context.Context, curSession Session, e
 	if event == nil {
 		return fmt.Errorf("event is nil")
 	}
-	if event.Partial {
+	if event.Partial && !KeepsPartials(ctx) {
 		return nil
 	}
 
@@ -218,6 +219,21 @@ func (s *inMemoryService) AppendEvent(ctx context.Context, curSession Session, e
 		return fmt.Errorf("session not found, cannot apply event")
 	}
 
+	// The state changes computed against a stale version of the state are
+	// committed as configured by the conflict policy.
+	changedKeys := changedStateKeys(event)
+	stale := false
+	if len(changedKeys) > 0 {
+		event.BaseStateVersion = sess.version
+		if sess.version != stored_session.version {
+			if err := s.rebase(stored_session, event, sess.version); err != nil {
+				return err
+			}
+			stale = true
+		}
+		stored_session.recordChange(s.mergeStates(stored_session.state, sess.id.appName, sess.id.userID), changedKeys, s.cfg.StateHistory)
+	}
+
 	// update the in-memory session
 	if err := sess.appendEvent(event); err != nil {
 		return fmt.Errorf("fail to set state on appendEvent: %w", err)
@@ -232,6 +248,24 @@ func (s *inMemoryService) AppendEvent(ctx context.Context, curSession Session, e
 		s.updateUserState(userDelta, curSession.AppName(), curSession.UserID())
 		maps.Copy(stored_session.state, sessionDelta)
 	}
+	if len(event.Actions.StateOps) > 0 {
+		applyScopedStateOps(
+			s.updateAppState(nil, curSession.AppName()),
+			s.updateUserState(nil, curSession.AppName(), curSession.UserID()),
+			stored_session.state,
+			event.Actions.StateOps)
+	}
+	if len(changedKeys) > 0 {
+		stored_session.version++
+		sess.mu.Lock()
+		sess.version = stored_session.version
+		if stale {
+			// The session read the stale state: it gets the changes
+			// committed concurrently.
+			sess.refreshState(s.mergeStates(stored_session.state, sess.id.appName, sess.id.userID))
+		}
+		sess.mu.Unlock()
+	}
 	return nil
 }
 
@@ -292,6 +326,17 @@ type session struct {
 	events    []*Event
 	state     map[string]any
 	updatedAt time.Time
+
+	// version is the version of the state: the number of events that
+	// changed it.
+	version int64
+	// history records the changes of the last versions of the state of the
+	// stored sessions.
+	history []stateChange
+	// service and stored are the service and the stored session a copy
+	// was read from.
+	service *inMemoryService
+	stored  *session
 }
 
 func (s *session) ID() string {
@@ -324,11 +369,50 @@ func (s *session) LastUpdateTime() time.Time {
 	return s.updatedAt
 }
 
-func (s *session) appendEvent(event *Event) error {
-	if event.Partial {
+// StateVersion implements VersionedSession.
+func (s *session) StateVersion() int64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.version
+}
+
+// StateStale implements VersionedSession.
+func (s *session) StateStale() bool {
+	if s.stored == nil {
+		return false
+	}
+	s.service.mu.RLock()
+	defer s.service.mu.RUnlock()
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.stored.version != s.version
+}
+
+// RefreshState implements VersionedSession.
+func (s *session) RefreshState(ctx context.Context) error {
+	if s.stored == nil {
 		return nil
 	}
+	s.service.mu.RLock()
+	defer s.service.mu.RUnlock()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.refreshState(s.service.mergeStates(s.stored.state, s.id.appName, s.id.userID))
+	s.version = s.stored.version
+	return nil
+}
+
+// refreshState replaces the state of the session by current, keeping the
+// temporary keys of the invocation. The map is updated in place, for the
+// values returned by State to see the changes.
+func (s *session) refreshState(current stateMap) {
+	maps.DeleteFunc(s.state, func(key string, _ any) bool {
+		return !strings.HasPrefix(key, KeyPrefixTemp)
+	})
+	maps.Copy(s.state, current)
+}
 
+func (s *session) appendEvent(event *Event) error {
 	processedEvent := trimTempDeltaState(event)
 	if err := updateSessionState(s, processedEvent); err != nil {
 		return fmt.Errorf("error on appendEvent: %w", err)
@@ -405,7 +489,7 @@ func (s *state) Set(key string, value any) error {
 
 // trimTempDeltaState removes temporary state delta keys from the event.
 func trimTempDeltaState(event *Event) *Event {
-	if len(event.Actions.StateDelta) == 0 {
+	if len(event.Actions.StateDelta) == 0 && len(event.Actions.StateOps) == 0 {
 		return event
 	}
 
@@ -419,13 +503,14 @@ func trimTempDeltaState(event *Event) *Event {
 
 	// Replace the old map with the newly filtered one.
 	event.Actions.StateDelta = filteredStateDelta
+	event.Actions.StateOps = trimTempStateOps(event.Actions.StateOps)
 
 	return event
 }
 
 // updateSessionState updates the session state based on the event state delta.
 func updateSessionState(session *session, event *Event) error {
-	if event.Actions.StateDelta == nil {
+	if event.Actions.StateDelta == nil && len(event.Actions.StateOps) == 0 {
 		return nil // Nothing to do
 	}
 
@@ -444,10 +529,21 @@ func updateSessionState(session *session, event *Event) error {
 			return fmt.Errorf("error on updateSessionState state: %w", err)
 		}
 	}
+	for _, op := range event.Actions.StateOps {
+		if strings.HasPrefix(op.Key, KeyPrefixTemp) {
+			continue
+		}
+		current, _ := state.Get(op.Key)
+		if err := state.Set(op.Key, applyStateOp(current, op)); err != nil {
+			return fmt.Errorf("error on updateSessionState state: %w", err)
+		}
+	}
 	return nil
 }
 
-func copySessionWithoutStateAndEvents(sess *session) *session {
+// copySession returns a copy of the stored session sess, without its state
+// and events.
+func (s *inMemoryService) copySession(sess *session) *session {
 	return &session{
 		id: id{
 			appName:   sess.id.appName,
@@ -455,7 +551,13 @@ func copySessionWithoutStateAndEvents(sess *session) *session {
 			sessionID: sess.id.sessionID,
 		},
 		updatedAt: sess.updatedAt,
+		version:   sess.version,
+		service:   s,
+		stored:    sess,
 	}
 }
 
-var _ Service = (*inMemoryService)(nil)
+var (
+	_ Service          = (*inMemoryService)(nil)
+	_ VersionedSession = (*session)(nil)
+)
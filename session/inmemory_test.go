@@ -15,6 +15,7 @@
 package session
 
 import (
+	"fmt"
 	"maps"
 	"strconv"
 	"strings"
@@ -355,7 +356,7 @@ func Test_databaseService_Get(t *testing.T) {
 				if diff := cmp.Diff(tt.wantResponse, got,
 					cmp.AllowUnexported(session{}),
 					cmp.AllowUnexported(id{}),
-					cmpopts.IgnoreFields(session{}, "mu", "updatedAt")); diff != "" {
+					cmpopts.IgnoreFields(session{}, "mu", "updatedAt", "history", "service", "stored")); diff != "" {
 					t.Errorf("Get session mismatch: (-want +got):\n%s", diff)
 				}
 			}
@@ -493,7 +494,7 @@ func Test_databaseService_List(t *testing.T) {
 				opts := []cmp.Option{
 					cmp.AllowUnexported(session{}),
 					cmp.AllowUnexported(id{}),
-					cmpopts.IgnoreFields(session{}, "mu", "updatedAt"),
+					cmpopts.IgnoreFields(session{}, "mu", "updatedAt", "history", "service", "stored"),
 					cmpopts.SortSlices(func(a, b Session) bool {
 						return a.ID() < b.ID()
 					}),
@@ -713,6 +714,7 @@ func Test_databaseService_AppendEvent(t *testing.T) {
 					userID:    "user1",
 					sessionID: "session1",
 				},
+				version: 1,
 				events: []*Event{
 					{
 						ID:                 "event_complete",
@@ -816,7 +818,7 @@ func Test_databaseService_AppendEvent(t *testing.T) {
 			opts := []cmp.Option{
 				cmp.AllowUnexported(session{}),
 				cmp.AllowUnexported(id{}),
-				cmpopts.IgnoreFields(session{}, "mu", "updatedAt"),
+				cmpopts.IgnoreFields(session{}, "mu", "updatedAt", "history", "service", "stored"),
 				cmpopts.IgnoreFields(Event{}, "Timestamp"),
 				// Add sorters if event order is not guaranteed
 				cmpopts.SortSlices(func(a, b *Event) bool {
@@ -1049,3 +1051,37 @@ func Test_inMemoryService_CreateConcurrentAccess(t *testing.T) {
 		t.Errorf("expected %d 'already exists' errors, but got %d", expectedErrors, errorCount.Load())
 	}
 }
+
+func Test_inMemoryService_KeepPartials(t *testing.T) {
+	for _, keep := range []bool{false, true} {
+		t.Run(fmt.Sprintf("KeepPartials=%v", keep), func(t *testing.T) {
+			ctx := t.Context()
+			if keep {
+				ctx = KeepPartials(ctx)
+			}
+			s := InMemoryService()
+			created, err := s.Create(ctx, &CreateRequest{AppName: "app", UserID: "user", SessionID: "session"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, partial := range []bool{true, false} {
+				event := &Event{ID: fmt.Sprintf("event_%v", partial), Author: "model", LLMResponse: model.LLMResponse{Partial: partial}}
+				if err := s.AppendEvent(ctx, created.Session, event); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got, err := s.Get(ctx, &GetRequest{AppName: "app", UserID: "user", SessionID: "session"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := 1
+			if keep {
+				want = 2
+			}
+			if n := got.Session.Events().Len(); n != want {
+				t.Errorf("Get() returned %d events, want %d", n, want)
+			}
+		})
+	}
+}
@@ -28,12 +28,40 @@ type Service interface {
 	List(context.Context, *ListRequest) (*ListResponse, error)
 	Delete(context.Context, *DeleteRequest) error
 	// AppendEvent is used to append an event to a session, and remove temporary state keys from the event.
+	// Partial events are ignored, unless the context is marked by [KeepPartials].
 	AppendEvent(context.Context, Session, *Event) error
 }
 
+type keepPartialsKey struct{}
+
+// KeepPartials returns a copy of ctx in which the partial events appended
+// are stored rather than ignored. The runner marks the appends of the
+// partial events selected by its runner.PartialPolicy.
+func KeepPartials(ctx context.Context) context.Context {
+	return context.WithValue(ctx, keepPartialsKey{}, true)
+}
+
+// KeepsPartials reports whether the partial events appended with ctx are
+// stored, see [KeepPartials].
+func KeepsPartials(ctx context.Context) bool {
+	keep, _ := ctx.Value(keepPartialsKey{}).(bool)
+	return keep
+}
+
 // InMemoryService returns an in-memory implementation of the session service.
 func InMemoryService() Service {
+	return InMemoryServiceWithConfig(InMemoryConfig{})
+}
+
+// InMemoryServiceWithConfig is like [InMemoryService], with the service
+// configured by cfg. The sessions of the service implement
+// [VersionedSession].
+func InMemoryServiceWithConfig(cfg InMemoryConfig) Service {
+	if cfg.StateHistory <= 0 {
+		cfg.StateHistory = 100
+	}
 	return &inMemoryService{
+		cfg:       cfg,
 		appState:  make(map[string]stateMap),
 		userState: make(map[string]map[string]stateMap),
 	}
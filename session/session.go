@@ -15,13 +15,13 @@
 package session
 
 import (
+	"context"
 	"errors"
 	"iter"
 	"time"
 
-	"github.com/google/uuid"
-
 	"google.golang.org/adk/model"
+	"google.golang.org/adk/runtimedeps"
 )
 
 // Session represents a series of interactions between a user and agents.
@@ -114,6 +114,12 @@ type Event struct {
 	// Agent client will know from this field about which function call is long running.
 	// Only valid for function call event.
 	LongRunningToolIDs []string
+
+	// BaseStateVersion is the version of the session state the state
+	// changes of the event were computed against. It is set by the services
+	// detecting the conflicts between concurrent invocations, see
+	// [VersionedSession].
+	BaseStateVersion int64 `json:",omitempty"`
 }
 
 // IsFinalResponse returns whether the event is the final response of an agent.
@@ -130,10 +136,17 @@ func (e *Event) IsFinalResponse() bool {
 
 // NewEvent creates a new event defining now as the timestamp.
 func NewEvent(invocationID string) *Event {
+	return NewEventWithContext(context.Background(), invocationID)
+}
+
+// NewEventWithContext is like [NewEvent], with the ID and the timestamp of
+// the event given by the dependencies of ctx, see [runtimedeps].
+func NewEventWithContext(ctx context.Context, invocationID string) *Event {
+	deps := runtimedeps.FromContext(ctx)
 	return &Event{
-		ID:           uuid.NewString(),
+		ID:           deps.NewID(),
 		InvocationID: invocationID,
-		Timestamp:    time.Now(),
+		Timestamp:    deps.Now(),
 		Actions:      EventActions{StateDelta: make(map[string]any)},
 	}
 }
@@ -142,6 +155,9 @@ func NewEvent(invocationID string) *Event {
 type EventActions struct {
 	// Set by agent.Context implementation.
 	StateDelta map[string]any
+	// StateOps are the updates of state values recorded as operations, see
+	// [StateOp]. They are applied after StateDelta.
+	StateOps []StateOp
 
 	// Indicates that the event is updating an artifact. key is the filename,
 	// value is the version.
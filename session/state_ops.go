@@ -0,0 +1,184 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"maps"
+	"reflect"
+	"strings"
+)
+
+// StateOpKind is the kind of a [StateOp].
+type StateOpKind string
+
+const (
+	// StateOpAppend appends the items of the payload, a []any, to the list
+	// of the key.
+	StateOpAppend StateOpKind = "append"
+	// StateOpIncrement adds the payload, a number, to the counter of the
+	// key.
+	StateOpIncrement StateOpKind = "increment"
+	// StateOpMerge sets the fields of the payload, a map[string]any, in the
+	// map of the key.
+	StateOpMerge StateOpKind = "merge"
+)
+
+// StateOp is an update of a state value recorded as an operation rather
+// than as the full new value, so that the events of the tools updating
+// large values on every call stay small.
+//
+// The operations are applied after the StateDelta of their event, in order.
+// The operations of the successive events on the same key combine: the
+// increments of a counter add up, the items appended to a list are ordered
+// by event, and the fields merged in a map are set by the last event setting
+// them. An operation on a key whose value has another type, e.g. an append
+// to a string, replaces the value as if it were missing.
+type StateOp struct {
+	Op      StateOpKind `json:"op"`
+	Key     string      `json:"key"`
+	Payload any         `json:"payload"`
+}
+
+// AppendState records the append of items to the list of the state key.
+func (a *EventActions) AppendState(key string, items ...any) {
+	a.StateOps = append(a.StateOps, StateOp{Op: StateOpAppend, Key: key, Payload: items})
+}
+
+// IncrementState records the addition of delta to the counter of the state
+// key.
+func (a *EventActions) IncrementState(key string, delta int64) {
+	a.StateOps = append(a.StateOps, StateOp{Op: StateOpIncrement, Key: key, Payload: delta})
+}
+
+// MergeState records the merge of fields in the map of the state key.
+func (a *EventActions) MergeState(key string, fields map[string]any) {
+	a.StateOps = append(a.StateOps, StateOp{Op: StateOpMerge, Key: key, Payload: maps.Clone(fields)})
+}
+
+// ApplyStateOps applies ops to state, in order. The values of state are
+// replaced, not modified in place.
+func ApplyStateOps(state map[string]any, ops []StateOp) {
+	for _, op := range ops {
+		state[op.Key] = applyStateOp(state[op.Key], op)
+	}
+}
+
+func applyStateOp(current any, op StateOp) any {
+	switch op.Op {
+	case StateOpAppend:
+		items := toList(op.Payload)
+		list := toList(current)
+		return append(append(make([]any, 0, len(list)+len(items)), list...), items...)
+	case StateOpIncrement:
+		if sum, ok := addNumbers(current, op.Payload); ok {
+			return sum
+		}
+		return op.Payload
+	case StateOpMerge:
+		fields, _ := op.Payload.(map[string]any)
+		merged, ok := current.(map[string]any)
+		merged = maps.Clone(merged)
+		if !ok || merged == nil {
+			merged = make(map[string]any, len(fields))
+		}
+		maps.Copy(merged, fields)
+		return merged
+	default:
+		return current
+	}
+}
+
+// toList returns the items of v if it is a slice, or nil.
+func toList(v any) []any {
+	if list, ok := v.([]any); ok {
+		return list
+	}
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() || rv.Kind() != reflect.Slice {
+		return nil
+	}
+	list := make([]any, rv.Len())
+	for i := range list {
+		list[i] = rv.Index(i).Interface()
+	}
+	return list
+}
+
+// addNumbers returns a+b if both are numbers. The sum is an int64 if both
+// are integers, and a float64 otherwise, e.g. once a counter went through a
+// JSON encoding.
+func addNumbers(a, b any) (any, bool) {
+	ai, aInt := toInt64(a)
+	bi, bInt := toInt64(b)
+	if aInt && bInt {
+		return ai + bi, true
+	}
+	af, aOK := toFloat64(a)
+	bf, bOK := toFloat64(b)
+	if !aOK || !bOK {
+		return nil, false
+	}
+	return af + bf, true
+}
+
+func toInt64(v any) (int64, bool) {
+	rv := reflect.ValueOf(v)
+	switch {
+	case !rv.IsValid():
+		return 0, false
+	case rv.CanInt():
+		return rv.Int(), true
+	case rv.CanUint():
+		return int64(rv.Uint()), true
+	}
+	return 0, false
+}
+
+func toFloat64(v any) (float64, bool) {
+	if i, ok := toInt64(v); ok {
+		return float64(i), true
+	}
+	rv := reflect.ValueOf(v)
+	if rv.IsValid() && rv.CanFloat() {
+		return rv.Float(), true
+	}
+	return 0, false
+}
+
+// trimTempStateOps returns ops without the operations on temporary keys.
+func trimTempStateOps(ops []StateOp) []StateOp {
+	var kept []StateOp
+	for _, op := range ops {
+		if !strings.HasPrefix(op.Key, KeyPrefixTemp) {
+			kept = append(kept, op)
+		}
+	}
+	return kept
+}
+
+// applyScopedStateOps applies ops to the app, user and session states: the
+// operations on the keys with the app and user prefixes are applied to the
+// app and user states, without the prefix.
+func applyScopedStateOps(appState, userState, sessionState map[string]any, ops []StateOp) {
+	for _, op := range ops {
+		state := sessionState
+		if key, ok := strings.CutPrefix(op.Key, KeyPrefixApp); ok {
+			state, op.Key = appState, key
+		} else if key, ok := strings.CutPrefix(op.Key, KeyPrefixUser); ok {
+			state, op.Key = userState, key
+		}
+		ApplyStateOps(state, []StateOp{op})
+	}
+}
@@ -0,0 +1,218 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"google.golang.org/adk/session"
+)
+
+// naiveState applies the operations by rewriting the full values, as the
+// tools did before the operations existed.
+type naiveState map[string]any
+
+func (n naiveState) apply(op session.StateOp) any {
+	switch op.Op {
+	case session.StateOpAppend:
+		list, _ := n[op.Key].([]any)
+		n[op.Key] = append(slices.Clone(list), op.Payload.([]any)...)
+	case session.StateOpIncrement:
+		counter, _ := n[op.Key].(int64)
+		n[op.Key] = counter + op.Payload.(int64)
+	case session.StateOpMerge:
+		m, _ := n[op.Key].(map[string]any)
+		m = maps.Clone(m)
+		if m == nil {
+			m = map[string]any{}
+		}
+		maps.Copy(m, op.Payload.(map[string]any))
+		n[op.Key] = m
+	}
+	return n[op.Key]
+}
+
+type stateSession struct {
+	service session.Service
+	session session.Session
+}
+
+func newStateSession(t *testing.T, id string) *stateSession {
+	t.Helper()
+	service := session.InMemoryService()
+	resp, err := service.Create(t.Context(), &session.CreateRequest{AppName: "app", UserID: "user", SessionID: id})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &stateSession{service: service, session: resp.Session}
+}
+
+func (s *stateSession) append(t *testing.T, actions session.EventActions) *session.Event {
+	t.Helper()
+	event := session.NewEvent("invocation")
+	event.Author = "agent"
+	event.Actions = actions
+	if err := s.service.AppendEvent(t.Context(), s.session, event); err != nil {
+		t.Fatal(err)
+	}
+	return event
+}
+
+func (s *stateSession) state(t *testing.T) map[string]any {
+	t.Helper()
+	resp, err := s.service.Get(t.Context(), &session.GetRequest{AppName: "app", UserID: "user", SessionID: s.session.ID()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return maps.Collect(resp.Session.State().All())
+}
+
+func TestStateOps_ReplayMatchesFullValues(t *testing.T) {
+	ops := newStateSession(t, "ops")
+	full := newStateSession(t, "full")
+	naive := naiveState{}
+	r := rand.New(rand.NewPCG(1, 2))
+
+	keys := []string{"progress", "items", "meta", "user:seen", "app:calls", "temp:scratch"}
+	for i := range 300 {
+		var actions session.EventActions
+		delta := map[string]any{}
+		for range 1 + r.IntN(3) {
+			key := keys[r.IntN(len(keys))]
+			var op session.StateOp
+			switch r.IntN(4) {
+			case 0:
+				op = session.StateOp{Op: session.StateOpAppend, Key: key, Payload: []any{fmt.Sprintf("item-%d", i)}}
+				actions.AppendState(key, op.Payload.([]any)...)
+			case 1:
+				op = session.StateOp{Op: session.StateOpIncrement, Key: key, Payload: int64(r.IntN(10))}
+				actions.IncrementState(key, op.Payload.(int64))
+			case 2:
+				op = session.StateOp{Op: session.StateOpMerge, Key: key, Payload: map[string]any{fmt.Sprintf("f%d", r.IntN(5)): i}}
+				actions.MergeState(key, op.Payload.(map[string]any))
+			case 3:
+				// A full-value update, in the same event as the operations.
+				value := fmt.Sprintf("value-%d", i)
+				if actions.StateDelta == nil {
+					actions.StateDelta = map[string]any{}
+				}
+				if _, ok := delta[key]; ok {
+					// The full values are applied before the operations of
+					// their event: keep the sequences comparable.
+					continue
+				}
+				actions.StateDelta[key] = value
+				naive[key] = value
+				delta[key] = value
+				continue
+			}
+			// Operations on values of another type replace them, like the
+			// naive implementation does.
+			delta[key] = naive.apply(op)
+		}
+		ops.append(t, actions)
+		full.append(t, session.EventActions{StateDelta: maps.Clone(delta)})
+	}
+
+	got, want := ops.state(t), full.state(t)
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("state rebuilt from operations mismatch (-full values +operations):\n%s", diff)
+	}
+	for key := range got {
+		if strings.HasPrefix(key, session.KeyPrefixTemp) {
+			t.Errorf("temporary key %q was stored", key)
+		}
+	}
+	if len(got) < 4 {
+		t.Errorf("state = %v, want most of the keys", got)
+	}
+}
+
+func TestStateOps_Conflicts(t *testing.T) {
+	s := newStateSession(t, "conflicts")
+	// Two tool calls of the same step, recorded in separate events.
+	var first, second session.EventActions
+	first.IncrementState("counter", 2)
+	first.AppendState("list", "a")
+	first.MergeState("map", map[string]any{"x": 1, "y": 1})
+	second.IncrementState("counter", 3)
+	second.AppendState("list", "b", "c")
+	second.MergeState("map", map[string]any{"y": 2, "z": 2})
+	s.append(t, first)
+	s.append(t, second)
+
+	want := map[string]any{
+		"counter": int64(5),
+		"list":    []any{"a", "b", "c"},
+		"map":     map[string]any{"x": 1, "y": 2, "z": 2},
+	}
+	if diff := cmp.Diff(want, s.state(t)); diff != "" {
+		t.Errorf("state mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestStateOps_IncompatibleValues(t *testing.T) {
+	s := newStateSession(t, "incompatible")
+	s.append(t, session.EventActions{StateDelta: map[string]any{"list": "not a list", "counter": "ten", "map": 3, "ints": []int{1, 2}}})
+	var actions session.EventActions
+	actions.AppendState("list", "a")
+	actions.IncrementState("counter", 1)
+	actions.MergeState("map", map[string]any{"k": "v"})
+	actions.AppendState("ints", 3)
+	s.append(t, actions)
+
+	want := map[string]any{
+		"list":    []any{"a"},
+		"counter": int64(1),
+		"map":     map[string]any{"k": "v"},
+		"ints":    []any{1, 2, 3},
+	}
+	if diff := cmp.Diff(want, s.state(t)); diff != "" {
+		t.Errorf("state mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestStateOps_DeltaSize(t *testing.T) {
+	var opsSize, fullSize int
+	var list []any
+	for i := range 200 {
+		item := fmt.Sprintf("step %d completed", i)
+		var actions session.EventActions
+		actions.AppendState("log", item)
+		b, err := json.Marshal(actions)
+		if err != nil {
+			t.Fatal(err)
+		}
+		opsSize += len(b)
+
+		list = append(list, item)
+		b, err = json.Marshal(session.EventActions{StateDelta: map[string]any{"log": list}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		fullSize += len(b)
+	}
+	if opsSize*10 > fullSize {
+		t.Errorf("stored operations take %d bytes, want less than a tenth of the %d bytes of the full values", opsSize, fullSize)
+	}
+}
@@ -0,0 +1,250 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package strictness decides what the components do when they are about to
+// drop or degrade data, e.g. a content that cannot be converted for a model
+// or an artifact that cannot be loaded.
+//
+// By default, the components drop the data silently. A [Policy] carried by
+// the context of a run, or set on an agent, makes them record a [Warning]
+// instead, or fail with a [*DropError]:
+//
+//	ctx = strictness.NewContext(ctx, &strictness.Policy{
+//		Level:     strictness.Warn,
+//		Overrides: map[strictness.Category]strictness.Level{strictness.Conversion: strictness.Error},
+//	})
+//	for ev, err := range r.Run(ctx, userID, sessionID, msg, cfg) {
+//		...
+//		for _, w := range strictness.Warnings(ev) {
+//			...
+//		}
+//	}
+//
+// The runner collects the warnings of each invocation, and attaches them to
+// the custom metadata of its final responses, see [Warnings].
+//
+// The components call [Report] where they drop or degrade data, and
+// propagate the error it returns.
+package strictness
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+
+	"google.golang.org/adk/session"
+)
+
+// MetadataKey is the key of the warnings in the custom metadata of the final
+// responses.
+const MetadataKey = "adk_strictness_warnings"
+
+// Level is what happens when data is dropped or degraded.
+type Level int
+
+const (
+	// Silent drops the data silently. It is the default.
+	Silent Level = iota
+	// Warn drops the data and records a warning.
+	Warn
+	// Error fails with a [*DropError] instead of dropping the data.
+	Error
+)
+
+// String implements fmt.Stringer.
+func (l Level) String() string {
+	switch l {
+	case Silent:
+		return "silent"
+	case Warn:
+		return "warn"
+	case Error:
+		return "error"
+	default:
+		return fmt.Sprintf("Level(%d)", int(l))
+	}
+}
+
+// Category groups the data dropped by the components.
+type Category string
+
+const (
+	// Conversion is the data that the model adapters cannot convert, e.g.
+	// unsupported parts, roles or configuration fields.
+	Conversion Category = "conversion"
+	// Tools is the data of the tools dropped, e.g. a tool without a
+	// declaration.
+	Tools Category = "tools"
+	// Artifacts is the artifacts that cannot be listed or loaded.
+	Artifacts Category = "artifacts"
+	// Instructions is the instructions that cannot be rendered.
+	Instructions Category = "instructions"
+)
+
+// Policy is the level of each category.
+type Policy struct {
+	// Level is the level of the categories without an override.
+	Level Level
+	// Overrides are the levels of some categories.
+	Overrides map[Category]Level
+}
+
+// LevelOf returns the level of category c. A nil policy is Silent.
+func (p *Policy) LevelOf(c Category) Level {
+	if p == nil {
+		return Silent
+	}
+	if l, ok := p.Overrides[c]; ok {
+		return l
+	}
+	return p.Level
+}
+
+// Warning is data dropped or degraded by a component.
+type Warning struct {
+	Category  Category `json:"category"`
+	Component string   `json:"component"`
+	Message   string   `json:"message"`
+}
+
+// String returns the warning as "component: message".
+func (w Warning) String() string {
+	return w.Component + ": " + w.Message
+}
+
+// ErrDropped is the error wrapped by the [*DropError] returned by Report.
+var ErrDropped = errors.New("data dropped")
+
+// DropError is the error returned by Report for a category at level Error.
+type DropError struct {
+	Warning
+}
+
+// Error implements error.
+func (e *DropError) Error() string {
+	return fmt.Sprintf("%s: %s (%s strictness)", e.Component, e.Message, e.Category)
+}
+
+// Unwrap returns ErrDropped.
+func (e *DropError) Unwrap() error {
+	return ErrDropped
+}
+
+// Collector collects the warnings of an invocation. It is safe for
+// concurrent use.
+type Collector struct {
+	mu       sync.Mutex
+	warnings []Warning
+}
+
+// Add records w.
+func (c *Collector) Add(w Warning) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.warnings = append(c.warnings, w)
+}
+
+// Warnings returns the warnings recorded, in order.
+func (c *Collector) Warnings() []Warning {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return slices.Clone(c.warnings)
+}
+
+type (
+	policyKey    struct{}
+	collectorKey struct{}
+)
+
+// NewContext returns a copy of ctx carrying p. It replaces the policy of
+// ctx, if any.
+func NewContext(ctx context.Context, p *Policy) context.Context {
+	return context.WithValue(ctx, policyKey{}, p)
+}
+
+// FromContext returns the policy carried by ctx, or nil.
+func FromContext(ctx context.Context) *Policy {
+	if ctx == nil {
+		return nil
+	}
+	p, _ := ctx.Value(policyKey{}).(*Policy)
+	return p
+}
+
+// WithCollector returns a copy of ctx carrying c, which records the warnings
+// reported with it.
+func WithCollector(ctx context.Context, c *Collector) context.Context {
+	return context.WithValue(ctx, collectorKey{}, c)
+}
+
+// CollectorFromContext returns the collector carried by ctx, or nil.
+func CollectorFromContext(ctx context.Context) *Collector {
+	if ctx == nil {
+		return nil
+	}
+	c, _ := ctx.Value(collectorKey{}).(*Collector)
+	return c
+}
+
+// Enabled reports whether the data of category c dropped with ctx is
+// reported. Components can check it to avoid the cost of detecting drops.
+func Enabled(ctx context.Context, c Category) bool {
+	return FromContext(ctx).LevelOf(c) != Silent
+}
+
+// Report reports that component is dropping or degrading data of category
+// c, as explained by the message formatted from format and args. Depending
+// on the level of c in the policy of ctx, it does nothing, records a warning
+// in the collector of ctx, or returns a [*DropError] that the component must
+// propagate instead of dropping the data.
+func Report(ctx context.Context, c Category, component, format string, args ...any) error {
+	level := FromContext(ctx).LevelOf(c)
+	if level == Silent {
+		return nil
+	}
+	w := Warning{Category: c, Component: component, Message: fmt.Sprintf(format, args...)}
+	if level >= Error {
+		return &DropError{Warning: w}
+	}
+	if collector := CollectorFromContext(ctx); collector != nil {
+		collector.Add(w)
+	}
+	return nil
+}
+
+// Warnings returns the warnings attached to ev by the runner.
+func Warnings(ev *session.Event) []Warning {
+	if ev == nil {
+		return nil
+	}
+	warnings, _ := ev.CustomMetadata[MetadataKey].([]Warning)
+	return warnings
+}
+
+// Attach attaches warnings to the custom metadata of ev, after those already
+// attached.
+func Attach(ev *session.Event, warnings []Warning) {
+	if len(warnings) == 0 {
+		return
+	}
+	metadata := maps.Clone(ev.CustomMetadata)
+	if metadata == nil {
+		metadata = make(map[string]any)
+	}
+	metadata[MetadataKey] = append(Warnings(ev), warnings...)
+	ev.CustomMetadata = metadata
+}
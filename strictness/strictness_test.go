@@ -0,0 +1,81 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package strictness_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/strictness"
+)
+
+func TestReport(t *testing.T) {
+	policy := &strictness.Policy{
+		Level: strictness.Warn,
+		Overrides: map[strictness.Category]strictness.Level{
+			strictness.Artifacts: strictness.Silent,
+			strictness.Tools:     strictness.Error,
+		},
+	}
+	collector := &strictness.Collector{}
+	ctx := strictness.WithCollector(strictness.NewContext(t.Context(), policy), collector)
+
+	if err := strictness.Report(ctx, strictness.Conversion, "openai", "dropped part %d", 3); err != nil {
+		t.Errorf("Report() at level Warn failed: %v", err)
+	}
+	if err := strictness.Report(ctx, strictness.Artifacts, "loadartifactstool", "artifact %q is unavailable", "a.txt"); err != nil {
+		t.Errorf("Report() at level Silent failed: %v", err)
+	}
+	err := strictness.Report(ctx, strictness.Tools, "toolutils.PackTool", "tool %q has no declaration", "t")
+	var dropErr *strictness.DropError
+	if !errors.As(err, &dropErr) || !errors.Is(err, strictness.ErrDropped) {
+		t.Fatalf("Report() at level Error = %v, want a DropError", err)
+	}
+	if diff := cmp.Diff(strictness.Warning{Category: strictness.Tools, Component: "toolutils.PackTool", Message: `tool "t" has no declaration`}, dropErr.Warning); diff != "" {
+		t.Errorf("DropError mismatch (-want +got):\n%s", diff)
+	}
+
+	want := []strictness.Warning{{Category: strictness.Conversion, Component: "openai", Message: "dropped part 3"}}
+	if diff := cmp.Diff(want, collector.Warnings()); diff != "" {
+		t.Errorf("Warnings() mismatch (-want +got):\n%s", diff)
+	}
+
+	// Without a policy, the data is dropped silently.
+	if err := strictness.Report(t.Context(), strictness.Tools, "toolutils.PackTool", "dropped"); err != nil {
+		t.Errorf("Report() without a policy failed: %v", err)
+	}
+	if strictness.Enabled(t.Context(), strictness.Conversion) || !strictness.Enabled(ctx, strictness.Conversion) {
+		t.Error("Enabled() does not follow the policy")
+	}
+}
+
+func TestAttach(t *testing.T) {
+	ev := &session.Event{}
+	ev.CustomMetadata = map[string]any{"other": 1}
+	first := []strictness.Warning{{Category: strictness.Conversion, Component: "openai", Message: "a"}}
+	second := []strictness.Warning{{Category: strictness.Tools, Component: "toolutils.PackTool", Message: "b"}}
+	strictness.Attach(ev, first)
+	strictness.Attach(ev, nil)
+	strictness.Attach(ev, second)
+	if diff := cmp.Diff(append(first, second...), strictness.Warnings(ev)); diff != "" {
+		t.Errorf("Warnings() mismatch (-want +got):\n%s", diff)
+	}
+	if ev.CustomMetadata["other"] != 1 {
+		t.Errorf("CustomMetadata = %v, want the other keys kept", ev.CustomMetadata)
+	}
+}
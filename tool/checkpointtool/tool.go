@@ -0,0 +1,106 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package checkpointtool provides a tool that lets the model create, list
+// and restore conversation checkpoints, e.g. when the user asks to go back to
+// an earlier step of a wizard. See package [checkpoint].
+package checkpointtool
+
+import (
+	"fmt"
+
+	"google.golang.org/adk/agent/checkpoint"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+)
+
+// Action is an action of the conversation_checkpoint tool.
+type Action string
+
+const (
+	// ActionCreate creates a checkpoint.
+	ActionCreate Action = "create"
+	// ActionList lists the checkpoints.
+	ActionList Action = "list"
+	// ActionRestore restores a checkpoint.
+	ActionRestore Action = "restore"
+)
+
+// Config is the configuration of the conversation_checkpoint tool.
+type Config struct {
+	// Allow decides whether the model may perform an action.
+	// Optional: if nil, all the actions are allowed.
+	Allow func(ctx tool.Context, action Action, label string) bool
+	// Pending decides what happens to the pending long-running operations
+	// when a checkpoint is restored. Defaults to checkpoint.PendingCancel.
+	Pending checkpoint.PendingPolicy
+}
+
+// Args is the arguments of the conversation_checkpoint tool.
+type Args struct {
+	// Action is one of "create", "list" and "restore".
+	Action Action `json:"action"`
+	// Label names the checkpoint to create or restore.
+	Label string `json:"label,omitempty"`
+}
+
+// New creates an instance of the conversation_checkpoint tool.
+func New(cfg Config) (tool.Tool, error) {
+	if cfg.Pending == "" {
+		cfg.Pending = checkpoint.PendingCancel
+	}
+	t, err := functiontool.New(functiontool.Config{
+		Name: "conversation_checkpoint",
+		Description: "Manages checkpoints of the conversation.\n" +
+			"Use action \"create\" with a label to remember the current point of the conversation, " +
+			"\"list\" to list the checkpoints, and \"restore\" with a label to go back to a checkpoint " +
+			"when the user asks to undo the later steps.\n",
+	}, func(ctx tool.Context, args Args) (map[string]any, error) {
+		return run(ctx, cfg, args)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error creating conversation checkpoint tool: %w", err)
+	}
+	return t, nil
+}
+
+func run(ctx tool.Context, cfg Config, args Args) (map[string]any, error) {
+	if cfg.Allow != nil && !cfg.Allow(ctx, args.Action, args.Label) {
+		return map[string]any{"error": fmt.Sprintf("the %s action is not allowed", args.Action)}, nil
+	}
+	switch args.Action {
+	case ActionCreate:
+		if _, err := checkpoint.Create(ctx, args.Label); err != nil {
+			return nil, err
+		}
+		return map[string]any{"status": fmt.Sprintf("checkpoint %q created", args.Label)}, nil
+	case ActionList:
+		checkpoints, err := checkpoint.List(ctx.ReadonlyState())
+		if err != nil {
+			return nil, err
+		}
+		labels := make([]string, 0, len(checkpoints))
+		for _, cp := range checkpoints {
+			labels = append(labels, cp.Label)
+		}
+		return map[string]any{"checkpoints": labels}, nil
+	case ActionRestore:
+		if err := checkpoint.Restore(ctx, args.Label, cfg.Pending); err != nil {
+			return nil, err
+		}
+		return map[string]any{"status": fmt.Sprintf("conversation restored to checkpoint %q; the later steps were undone", args.Label)}, nil
+	default:
+		return nil, fmt.Errorf("unknown action %q: want %q, %q or %q", args.Action, ActionCreate, ActionList, ActionRestore)
+	}
+}
@@ -0,0 +1,164 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package checkpointtool_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/checkpoint"
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/internal/testutil"
+	"google.golang.org/adk/runner"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/checkpointtool"
+	"google.golang.org/adk/tool/functiontool"
+)
+
+type destinationArgs struct {
+	City string `json:"city"`
+}
+
+func TestCheckpointTool(t *testing.T) {
+	checkpointTool, err := checkpointtool.New(checkpointtool.Config{
+		Allow: func(_ tool.Context, action checkpointtool.Action, _ string) bool {
+			return action != checkpointtool.ActionList
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	destinationTool, err := functiontool.New(functiontool.Config{Name: "set_destination", Description: "sets the destination"}, func(ctx tool.Context, args destinationArgs) (map[string]any, error) {
+		if err := ctx.State().Set("destination", args.City); err != nil {
+			return nil, err
+		}
+		return map[string]any{"status": "ok"}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	call := func(name string, args map[string]any) *genai.Content {
+		return genai.NewContentFromFunctionCall(name, args, genai.RoleModel)
+	}
+	text := func(s string) *genai.Content { return genai.NewContentFromText(s, genai.RoleModel) }
+	testModel := &testutil.MockModel{Responses: []*genai.Content{
+		// Turn 1.
+		call("conversation_checkpoint", map[string]any{"action": "create", "label": "before_destination"}),
+		text("Where do you want to go?"),
+		// Turn 2.
+		call("set_destination", map[string]any{"city": "Paris"}),
+		text("Paris it is."),
+		// Turn 3.
+		call("conversation_checkpoint", map[string]any{"action": "list"}),
+		call("conversation_checkpoint", map[string]any{"action": "restore", "label": "before_destination"}),
+		text("Let's start over: where do you want to go?"),
+	}}
+	a, err := llmagent.New(llmagent.Config{
+		Name:  "travel_agent",
+		Model: testModel,
+		Tools: []tool.Tool{checkpointTool, destinationTool},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sessionService := session.InMemoryService()
+	created, err := sessionService.Create(t.Context(), &session.CreateRequest{AppName: "app", UserID: "user", SessionID: "session"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := runner.New(runner.Config{AppName: "app", Agent: a, SessionService: sessionService})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var results []map[string]any
+	for _, msg := range []string{"plan a trip", "Paris", "actually go back to before we chose the destination"} {
+		for ev, err := range r.Run(t.Context(), "user", created.Session.ID(), genai.NewContentFromText(msg, genai.RoleUser), agent.RunConfig{}) {
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, p := range ev.Content.Parts {
+				if p.FunctionResponse != nil && p.FunctionResponse.Name == "conversation_checkpoint" {
+					results = append(results, p.FunctionResponse.Response)
+				}
+			}
+		}
+	}
+
+	wantResults := []map[string]any{
+		{"status": `checkpoint "before_destination" created`},
+		{"error": "the list action is not allowed"},
+		{"status": `conversation restored to checkpoint "before_destination"; the later steps were undone`},
+	}
+	if diff := cmp.Diff(wantResults, results); diff != "" {
+		t.Errorf("tool results mismatch (-want +got):\n%s", diff)
+	}
+
+	// The request after the restore leaves out the second turn.
+	var texts []string
+	for _, c := range testModel.Requests[len(testModel.Requests)-1].Contents {
+		for _, p := range c.Parts {
+			switch {
+			case p.Text != "":
+				texts = append(texts, p.Text)
+			case p.FunctionCall != nil:
+				texts = append(texts, "call "+p.FunctionCall.Name)
+			case p.FunctionResponse != nil:
+				texts = append(texts, "response "+p.FunctionResponse.Name)
+			}
+		}
+	}
+	wantTexts := []string{
+		"plan a trip",
+		"call conversation_checkpoint",
+		"response conversation_checkpoint",
+		"Where do you want to go?",
+		"actually go back to before we chose the destination",
+		"call conversation_checkpoint",
+		"response conversation_checkpoint",
+		"call conversation_checkpoint",
+		"response conversation_checkpoint",
+	}
+	if diff := cmp.Diff(wantTexts, texts); diff != "" {
+		t.Errorf("contents after restore mismatch (-want +got):\n%s", diff)
+	}
+
+	got, err := sessionService.Get(t.Context(), &session.GetRequest{AppName: "app", UserID: "user", SessionID: created.Session.ID()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if destination, err := got.Session.State().Get("destination"); err != nil || destination != nil {
+		t.Errorf("destination after restore = (%v, %v), want (nil, nil)", destination, err)
+	}
+
+	var events []*session.Event
+	for ev := range got.Session.Events().All() {
+		events = append(events, ev)
+	}
+	superseded, err := checkpoint.Superseded(got.Session.State(), events)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The user message, the function call and response and the answer of the
+	// second turn stay in the log.
+	if len(superseded) != 4 || len(events) != 14 {
+		t.Errorf("got %d superseded events out of %d, want 4 out of 14", len(superseded), len(events))
+	}
+}
@@ -0,0 +1,339 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package describetool lets tools declare themselves to the model with
+// compressed declarations, and provides the describe_tool meta tool the
+// model calls to get the full details of a compressed tool on demand.
+//
+// A tool opts into a compressed declaration with [Compress]: its
+// description is truncated and the descriptions and examples of its
+// parameters are removed, which keeps the requests small. The first
+// compressed tool of a request also declares describe_tool, which returns
+// the original declaration of the compressed tools rendered as readable
+// text, and instructs the model to call it before using an unfamiliar
+// compressed tool:
+//
+//	search, err := describetool.Compress(searchTool, describetool.Config{
+//		MaxDescriptionLength: 80,
+//		UsageNotes:           "Prefer one broad query over several narrow ones.",
+//	})
+//	...
+//	a, err := llmagent.New(llmagent.Config{
+//		...
+//		Tools: []tool.Tool{search},
+//	})
+package describetool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/internal/toolinternal"
+	"google.golang.org/adk/internal/toolinternal/toolutils"
+	"google.golang.org/adk/internal/utils"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/tool"
+)
+
+// Name is the name of the describe_tool meta tool. It is reserved: the
+// meta tool itself is never compressed.
+const Name = "describe_tool"
+
+// instruction is added to the requests declaring compressed tools.
+const instruction = "The declarations of some tools are abbreviated: they are listed in the parameters of " + Name + "." +
+	" Before using one of them for the first time, call " + Name + " with its name to get its full description and parameters."
+
+// Config configures the compression of a tool declaration.
+type Config struct {
+	// MaxDescriptionLength is the maximum length, in characters, of the
+	// declared description. Longer descriptions are truncated. Defaults to
+	// 100.
+	MaxDescriptionLength int
+	// UsageNotes are returned by describe_tool with the full declaration,
+	// e.g. advice or examples of calls. They are never declared.
+	UsageNotes string
+}
+
+// Compress returns t declared with a compressed declaration, as configured
+// by cfg. t must be a function tool; its calls are unchanged.
+func Compress(t tool.Tool, cfg Config) (tool.Tool, error) {
+	ft, ok := t.(toolinternal.FunctionTool)
+	if !ok {
+		return nil, fmt.Errorf("tool %q is not a function tool", t.Name())
+	}
+	if t.Name() == Name {
+		return nil, fmt.Errorf("tool %q cannot be compressed", Name)
+	}
+	if cfg.MaxDescriptionLength <= 0 {
+		cfg.MaxDescriptionLength = 100
+	}
+	return &compressedTool{FunctionTool: ft, cfg: cfg}, nil
+}
+
+type compressedTool struct {
+	toolinternal.FunctionTool
+	cfg Config
+}
+
+func (t *compressedTool) Description() string {
+	return truncate(t.FunctionTool.Description(), t.cfg.MaxDescriptionLength)
+}
+
+func (t *compressedTool) Declaration() *genai.FunctionDeclaration {
+	return compress(t.FunctionTool.Declaration(), t.cfg.MaxDescriptionLength)
+}
+
+// ProcessRequest lets the wrapped tool process the request, replaces its
+// declaration by the compressed one, and registers the original one with
+// the describe_tool of the request.
+func (t *compressedTool) ProcessRequest(ctx tool.Context, req *model.LLMRequest) error {
+	describe, err := describeToolOf(ctx, req)
+	if err != nil {
+		return err
+	}
+	original := t.FunctionTool.Declaration()
+	if p, ok := t.FunctionTool.(toolinternal.RequestProcessor); ok {
+		if err := p.ProcessRequest(ctx, req); err != nil {
+			return err
+		}
+		for _, gt := range req.Config.Tools {
+			if gt == nil {
+				continue
+			}
+			for i, decl := range gt.FunctionDeclarations {
+				if decl != nil && decl.Name == t.Name() {
+					original = decl
+					gt.FunctionDeclarations[i] = compress(decl, t.cfg.MaxDescriptionLength)
+				}
+			}
+		}
+	} else if err := toolutils.PackTool(ctx, req, t); err != nil {
+		return err
+	}
+	describe.add(t.Name(), described{decl: original, notes: t.cfg.UsageNotes})
+	return nil
+}
+
+// describeToolOf returns the describe_tool of req, declaring it on first
+// use.
+func describeToolOf(ctx context.Context, req *model.LLMRequest) (*describeTool, error) {
+	switch t := req.Tools[Name].(type) {
+	case *describeTool:
+		return t, nil
+	case nil:
+	default:
+		return nil, fmt.Errorf("tool %q conflicts with the %s meta tool", Name, Name)
+	}
+	t := &describeTool{tools: make(map[string]described)}
+	t.decl = &genai.FunctionDeclaration{
+		Name:        t.Name(),
+		Description: t.Description(),
+		Parameters: &genai.Schema{
+			Type: genai.TypeObject,
+			Properties: map[string]*genai.Schema{
+				"name": {Type: genai.TypeString, Description: "The name of the tool to describe."},
+			},
+			Required: []string{"name"},
+		},
+	}
+	if err := toolutils.PackTool(ctx, req, t); err != nil {
+		return nil, err
+	}
+	utils.AppendInstructions(req, instruction)
+	return t, nil
+}
+
+type described struct {
+	decl  *genai.FunctionDeclaration
+	notes string
+}
+
+// describeTool returns the original declarations of the compressed tools
+// of a request. It is created for each request, and retains the
+// declarations for the calls of its response.
+type describeTool struct {
+	// decl is the declaration of the request, listing the compressed tools.
+	decl  *genai.FunctionDeclaration
+	tools map[string]described
+}
+
+func (t *describeTool) add(name string, d described) {
+	if _, ok := t.tools[name]; !ok {
+		param := t.decl.Parameters.Properties["name"]
+		param.Enum = append(param.Enum, name)
+	}
+	t.tools[name] = d
+}
+
+func (t *describeTool) Name() string {
+	return Name
+}
+
+func (t *describeTool) Description() string {
+	return "Returns the full description, parameters and usage notes of a tool whose declaration is abbreviated."
+}
+
+func (t *describeTool) IsLongRunning() bool {
+	return false
+}
+
+func (t *describeTool) Declaration() *genai.FunctionDeclaration {
+	return t.decl
+}
+
+func (t *describeTool) Run(ctx tool.Context, args any) (map[string]any, error) {
+	m, ok := args.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("unexpected args type, got: %T", args)
+	}
+	name, _ := m["name"].(string)
+	d, ok := t.tools[name]
+	if !ok {
+		names := make([]string, 0, len(t.tools))
+		for n := range t.tools {
+			names = append(names, n)
+		}
+		slices.Sort(names)
+		return map[string]any{
+			"error":       fmt.Sprintf("unknown tool %q", name),
+			"valid_names": names,
+		}, nil
+	}
+	result := map[string]any{
+		"name":        name,
+		"description": d.decl.Description,
+		"parameters":  renderParameters(d.decl),
+	}
+	if d.notes != "" {
+		result["usage_notes"] = d.notes
+	}
+	return result, nil
+}
+
+// truncate truncates s to n runes, marking the truncation with an ellipsis.
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return strings.TrimSpace(string(runes[:n-1])) + "…"
+}
+
+// CompressDeclaration returns decl compressed the way the declarations of
+// the tools returned by [Compress] are: its description is truncated to
+// maxDescriptionLength characters, defaulting to 100, and the descriptions
+// and examples of its parameters are removed. decl is not modified.
+func CompressDeclaration(decl *genai.FunctionDeclaration, maxDescriptionLength int) *genai.FunctionDeclaration {
+	if maxDescriptionLength <= 0 {
+		maxDescriptionLength = 100
+	}
+	return compress(decl, maxDescriptionLength)
+}
+
+// compress returns a copy of decl with its description truncated to n
+// runes, and without the descriptions and examples of its parameters.
+func compress(decl *genai.FunctionDeclaration, n int) *genai.FunctionDeclaration {
+	if decl == nil {
+		return nil
+	}
+	c := *decl
+	c.Description = truncate(decl.Description, n)
+	if decl.Parameters != nil {
+		c.Parameters = compressSchema(decl.Parameters)
+	}
+	if decl.ParametersJsonSchema != nil {
+		if m, err := schemaMap(decl.ParametersJsonSchema); err == nil {
+			compressSchemaMap(m)
+			c.ParametersJsonSchema = m
+		}
+	}
+	return &c
+}
+
+func compressSchema(s *genai.Schema) *genai.Schema {
+	if s == nil {
+		return nil
+	}
+	c := *s
+	c.Description, c.Example, c.Title = "", nil, ""
+	c.Items = compressSchema(s.Items)
+	if s.Properties != nil {
+		c.Properties = make(map[string]*genai.Schema, len(s.Properties))
+		for name, p := range s.Properties {
+			c.Properties[name] = compressSchema(p)
+		}
+	}
+	if s.AnyOf != nil {
+		c.AnyOf = make([]*genai.Schema, len(s.AnyOf))
+		for i, a := range s.AnyOf {
+			c.AnyOf[i] = compressSchema(a)
+		}
+	}
+	return &c
+}
+
+// schemaMap returns a JSON schema as a generic map.
+func schemaMap(schema any) (map[string]any, error) {
+	b, err := json.Marshal(schema)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]any
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// compressSchemaMap removes the descriptions and examples of the JSON
+// schema m and of its subschemas.
+func compressSchemaMap(m map[string]any) {
+	for _, key := range []string{"description", "examples", "example", "title"} {
+		delete(m, key)
+	}
+	for _, key := range []string{"properties", "$defs", "definitions"} {
+		if props, ok := m[key].(map[string]any); ok {
+			for _, p := range props {
+				if sub, ok := p.(map[string]any); ok {
+					compressSchemaMap(sub)
+				}
+			}
+		}
+	}
+	for _, key := range []string{"items", "additionalProperties", "not"} {
+		if sub, ok := m[key].(map[string]any); ok {
+			compressSchemaMap(sub)
+		}
+	}
+	for _, key := range []string{"anyOf", "oneOf", "allOf", "prefixItems"} {
+		if subs, ok := m[key].([]any); ok {
+			for _, s := range subs {
+				if sub, ok := s.(map[string]any); ok {
+					compressSchemaMap(sub)
+				}
+			}
+		}
+	}
+}
+
+var (
+	_ toolinternal.FunctionTool     = (*compressedTool)(nil)
+	_ toolinternal.RequestProcessor = (*compressedTool)(nil)
+	_ toolinternal.FunctionTool     = (*describeTool)(nil)
+)
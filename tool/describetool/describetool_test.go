@@ -0,0 +1,195 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package describetool_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/jsonschema-go/jsonschema"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/internal/testutil"
+	"google.golang.org/adk/internal/testutil/golden"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/describetool"
+	"google.golang.org/adk/tool/functiontool"
+	"google.golang.org/adk/tool/geminitool"
+)
+
+const searchDescription = "Searches the product catalog. The results are ranked by relevance," +
+	" and only the products in stock are returned unless include_out_of_stock is set."
+
+type searchArgs struct {
+	Query   string   `json:"query"`
+	Sort    string   `json:"sort,omitempty"`
+	Filters *filters `json:"filters,omitempty"`
+	Tags    []string `json:"tags,omitempty"`
+}
+
+type filters struct {
+	MaxPrice          float64 `json:"max_price,omitempty"`
+	IncludeOutOfStock bool    `json:"include_out_of_stock,omitempty"`
+}
+
+func newSearchTool(t *testing.T) tool.Tool {
+	t.Helper()
+	schema := &jsonschema.Schema{
+		Type: "object",
+		Properties: map[string]*jsonschema.Schema{
+			"query": {Type: "string", Description: "The search terms.", Examples: []any{"red shoes"}},
+			"sort":  {Type: "string", Description: "The order of the results.", Enum: []any{"relevance", "price"}, Default: []byte(`"relevance"`)},
+			"filters": {Type: "object", Description: "Restricts the results.", Properties: map[string]*jsonschema.Schema{
+				"max_price":            {Type: "number", Description: "The maximum price, in dollars."},
+				"include_out_of_stock": {Type: "boolean", Description: "Whether to include the products out of stock."},
+			}},
+			"tags": {Type: "array", Description: "Tags the products must have.", Items: &jsonschema.Schema{Type: "string"}},
+		},
+		Required: []string{"query"},
+	}
+	search, err := functiontool.New(functiontool.Config{Name: "search", Description: searchDescription, InputSchema: schema}, func(tool.Context, searchArgs) (map[string]any, error) {
+		return map[string]any{"results": []string{}}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return search
+}
+
+func declarations(req *model.LLMRequest) map[string]*genai.FunctionDeclaration {
+	decls := make(map[string]*genai.FunctionDeclaration)
+	for _, gt := range req.Config.Tools {
+		for _, d := range gt.FunctionDeclarations {
+			decls[d.Name] = d
+		}
+	}
+	return decls
+}
+
+func TestDescribeTool(t *testing.T) {
+	search, err := describetool.Compress(newSearchTool(t), describetool.Config{
+		MaxDescriptionLength: 30,
+		UsageNotes:           "Prefer one broad query over several narrow ones.",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lookup, err := functiontool.New(functiontool.Config{Name: "lookup", Description: "Looks up a product by ID."}, func(tool.Context, struct{}) (map[string]any, error) {
+		return nil, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	testModel := &testutil.MockModel{Responses: []*genai.Content{
+		genai.NewContentFromFunctionCall(describetool.Name, map[string]any{"name": "search"}, genai.RoleModel),
+		genai.NewContentFromFunctionCall(describetool.Name, map[string]any{"name": "unknown"}, genai.RoleModel),
+		genai.NewContentFromText("done", genai.RoleModel),
+	}}
+	a, err := llmagent.New(llmagent.Config{Name: "agent", Model: testModel, Tools: []tool.Tool{search, lookup}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	events, err := testutil.CollectEvents(testutil.NewTestAgentRunner(t, a).Run(t, "session", "find red shoes"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var responses []map[string]any
+	for _, ev := range events {
+		for _, p := range ev.Content.Parts {
+			if p.FunctionResponse != nil {
+				responses = append(responses, p.FunctionResponse.Response)
+			}
+		}
+	}
+	if len(responses) != 2 {
+		t.Fatalf("got %d function responses, want 2", len(responses))
+	}
+
+	t.Run("Declarations", func(t *testing.T) {
+		req := testModel.Requests[0]
+		decls := declarations(req)
+		if got, want := decls["search"].Description, "Searches the product catalog.…"; got != want {
+			t.Errorf("compressed description = %q, want %q", got, want)
+		}
+		if strings.Contains(toJSON(t, decls["search"].ParametersJsonSchema), "search terms") {
+			t.Errorf("compressed parameters = %s, want no descriptions", toJSON(t, decls["search"].ParametersJsonSchema))
+		}
+		if got, want := decls["lookup"].Description, "Looks up a product by ID."; got != want {
+			t.Errorf("uncompressed description = %q, want %q", got, want)
+		}
+		// The meta tool itself is never compressed.
+		describe := decls[describetool.Name]
+		if describe == nil || strings.HasSuffix(describe.Description, "…") || describe.Parameters.Properties["name"].Description == "" {
+			t.Fatalf("%s declaration = %+v, want the full declaration", describetool.Name, describe)
+		}
+		if diff := cmp.Diff([]string{"search"}, describe.Parameters.Properties["name"].Enum); diff != "" {
+			t.Errorf("described tools mismatch (-want +got):\n%s", diff)
+		}
+		if instructions := req.Config.SystemInstruction.Parts[0].Text; !strings.Contains(instructions, "call "+describetool.Name) {
+			t.Errorf("instructions = %q, want the describe_tool instruction", instructions)
+		}
+	})
+
+	t.Run("FullDetails", func(t *testing.T) {
+		got := responses[0]
+		if got["description"] != searchDescription {
+			t.Errorf("description = %q, want %q", got["description"], searchDescription)
+		}
+		if got["usage_notes"] != "Prefer one broad query over several narrow ones." {
+			t.Errorf("usage_notes = %q, want the usage notes", got["usage_notes"])
+		}
+		parameters, _ := got["parameters"].(string)
+		golden.Text(t, parameters)
+	})
+
+	t.Run("UnknownName", func(t *testing.T) {
+		want := map[string]any{"error": `unknown tool "unknown"`, "valid_names": []string{"search"}}
+		if diff := cmp.Diff(want, responses[1]); diff != "" {
+			t.Errorf("response mismatch (-want +got):\n%s", diff)
+		}
+	})
+}
+
+func TestCompress_Invalid(t *testing.T) {
+	meta, err := functiontool.New(functiontool.Config{Name: describetool.Name}, func(tool.Context, struct{}) (map[string]any, error) {
+		return nil, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, tl := range map[string]tool.Tool{
+		"MetaTool":    meta,
+		"NotFunction": geminitool.New("search", &genai.Tool{GoogleSearch: &genai.GoogleSearch{}}),
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := describetool.Compress(tl, describetool.Config{}); err == nil {
+				t.Error("Compress() succeeded, want an error")
+			}
+		})
+	}
+}
+
+func toJSON(t *testing.T, v any) string {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
@@ -0,0 +1,148 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package describetool
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"google.golang.org/genai"
+)
+
+// renderParameters renders the parameters of decl as readable text, one
+// line per parameter with its type, whether it is required, its description,
+// enum and examples, nested parameters being indented.
+func renderParameters(decl *genai.FunctionDeclaration) string {
+	var schema any = decl.ParametersJsonSchema
+	if decl.Parameters != nil {
+		schema = decl.Parameters
+	}
+	if schema == nil {
+		return "No parameters."
+	}
+	m, err := schemaMap(schema)
+	if err != nil {
+		return fmt.Sprintf("The parameters could not be rendered: %v", err)
+	}
+	var b strings.Builder
+	renderProperties(&b, m, 0)
+	if b.Len() == 0 {
+		return "No parameters."
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+func renderProperties(b *strings.Builder, schema map[string]any, depth int) {
+	props, _ := schema["properties"].(map[string]any)
+	var required []string
+	for _, r := range asSlice(schema["required"]) {
+		required = append(required, fmt.Sprint(r))
+	}
+	names := make([]string, 0, len(props))
+	for name := range props {
+		names = append(names, name)
+	}
+	// Required parameters come first.
+	slices.SortFunc(names, func(a, b string) int {
+		ra, rb := slices.Contains(required, a), slices.Contains(required, b)
+		switch {
+		case ra && !rb:
+			return -1
+		case rb && !ra:
+			return 1
+		}
+		return strings.Compare(a, b)
+	})
+	indent := strings.Repeat("  ", depth)
+	for _, name := range names {
+		p, _ := props[name].(map[string]any)
+		qualifiers := []string{typeName(p)}
+		if slices.Contains(required, name) {
+			qualifiers = append(qualifiers, "required")
+		}
+		fmt.Fprintf(b, "%s- %s (%s)", indent, name, strings.Join(qualifiers, ", "))
+		if d, _ := p["description"].(string); d != "" {
+			fmt.Fprintf(b, ": %s", d)
+		}
+		b.WriteString("\n")
+		if enum := asSlice(p["enum"]); len(enum) > 0 {
+			fmt.Fprintf(b, "%s  One of: %s\n", indent, values(enum))
+		}
+		if def, ok := p["default"]; ok {
+			fmt.Fprintf(b, "%s  Default: %s\n", indent, values([]any{def}))
+		}
+		examples := asSlice(p["examples"])
+		if ex, ok := p["example"]; ok {
+			examples = append(examples, ex)
+		}
+		if len(examples) > 0 {
+			fmt.Fprintf(b, "%s  Examples: %s\n", indent, values(examples))
+		}
+		renderProperties(b, p, depth+1)
+		if items, ok := p["items"].(map[string]any); ok {
+			renderProperties(b, items, depth+1)
+		}
+	}
+}
+
+// typeName returns the type of the JSON schema s, e.g. "string" or "array
+// of integer".
+func typeName(s map[string]any) string {
+	var types []string
+	for _, t := range asSlice(s["type"]) {
+		types = append(types, strings.ToLower(fmt.Sprint(t)))
+	}
+	if t, ok := s["type"].(string); ok {
+		types = []string{strings.ToLower(t)}
+	}
+	name := strings.Join(types, " or ")
+	if name == "" {
+		name = "any"
+	}
+	if items, ok := s["items"].(map[string]any); ok && slices.Contains(types, "array") {
+		name += " of " + typeName(items)
+	}
+	return name
+}
+
+func asSlice(v any) []any {
+	switch v := v.(type) {
+	case []any:
+		return v
+	case []string:
+		s := make([]any, len(v))
+		for i, e := range v {
+			s[i] = e
+		}
+		return s
+	}
+	return nil
+}
+
+// values renders values as JSON, separated by commas.
+func values(vs []any) string {
+	s := make([]string, len(vs))
+	for i, v := range vs {
+		b, err := json.Marshal(v)
+		if err != nil {
+			s[i] = fmt.Sprint(v)
+			continue
+		}
+		s[i] = string(b)
+	}
+	return strings.Join(s, ", ")
+}
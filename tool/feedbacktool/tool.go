@@ -0,0 +1,148 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package feedbacktool provides a tool that lets the model record the
+// feedback of the user on its answers. See package [feedback].
+package feedbacktool
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/google/jsonschema-go/jsonschema"
+
+	"google.golang.org/adk/feedback"
+	"google.golang.org/adk/runtimedeps"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+)
+
+// PreviousAnswer is the answer reference resolved to the last answer of the
+// agent before the current invocation. It is the default reference.
+const PreviousAnswer = "previous"
+
+// Config is the configuration of the record_feedback tool.
+type Config struct {
+	// SessionService is used to read the history of the session, where the
+	// rated answers are looked up.
+	SessionService session.Service
+	// Sink receives the feedback records, in addition to the session state.
+	// Optional.
+	Sink feedback.Sink
+	// Fingerprint returns the fingerprint of the agent configuration stored
+	// in the records. Optional.
+	Fingerprint func(ctx tool.Context) string
+}
+
+// Args is the arguments of the record_feedback tool.
+type Args struct {
+	// Rating is one of "thumbs_up" and "thumbs_down".
+	Rating feedback.Rating `json:"rating"`
+	// Comment is the free-text feedback of the user.
+	Comment string `json:"comment,omitempty"`
+	// Answer is the ID of the rated answer event. Defaults to the previous
+	// answer.
+	Answer string `json:"answer,omitempty"`
+}
+
+// New creates an instance of the record_feedback tool.
+func New(cfg Config) (tool.Tool, error) {
+	if cfg.SessionService == nil {
+		return nil, errors.New("session service is required")
+	}
+	schema, err := jsonschema.For[Args](nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating record feedback tool: %w", err)
+	}
+	schema.Properties["rating"].Enum = []any{string(feedback.RatingUp), string(feedback.RatingDown)}
+	schema.Properties["comment"].Description = "The feedback of the user in their own words, if any."
+	schema.Properties["answer"].Description = fmt.Sprintf("The rated answer. Defaults to %q, the answer before the feedback.", PreviousAnswer)
+
+	t, err := functiontool.New(functiontool.Config{
+		Name: "record_feedback",
+		Description: "Records the feedback of the user on an answer.\n" +
+			"Use it when the user says that an answer was helpful or not, with the rating and the comment of the user.\n",
+		InputSchema: schema,
+	}, func(ctx tool.Context, args Args) (map[string]any, error) {
+		return run(ctx, cfg, args)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error creating record feedback tool: %w", err)
+	}
+	return t, nil
+}
+
+func run(ctx tool.Context, cfg Config, args Args) (map[string]any, error) {
+	if !args.Rating.Valid() {
+		return nil, fmt.Errorf("invalid rating %q: want %q or %q", args.Rating, feedback.RatingUp, feedback.RatingDown)
+	}
+	resp, err := cfg.SessionService.Get(ctx, &session.GetRequest{
+		AppName:   ctx.AppName(),
+		UserID:    ctx.UserID(),
+		SessionID: ctx.SessionID(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+	var events []*session.Event
+	for ev := range resp.Session.Events().All() {
+		events = append(events, ev)
+	}
+
+	var answer, user *session.Event
+	if args.Answer == "" || args.Answer == PreviousAnswer {
+		answer, user = feedback.PreviousAnswer(events, ctx.InvocationID())
+	} else {
+		answer, user = feedback.Answer(events, args.Answer)
+	}
+	if answer == nil {
+		return map[string]any{"error": "there is no answer to rate"}, nil
+	}
+
+	deps := runtimedeps.FromContext(ctx)
+	now := deps.Now()
+	record := &feedback.Record{
+		ID:            deps.NewID(),
+		AppName:       ctx.AppName(),
+		UserID:        ctx.UserID(),
+		SessionID:     ctx.SessionID(),
+		Rating:        args.Rating,
+		Comment:       args.Comment,
+		AnswerEventID: answer.ID,
+		InvocationID:  answer.InvocationID,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	if user != nil {
+		record.UserEventID = user.ID
+	}
+	if cfg.Fingerprint != nil {
+		record.ConfigFingerprint = cfg.Fingerprint(ctx)
+	}
+
+	record, updated, err := feedback.Store(ctx.State(), record)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Sink != nil {
+		if err := cfg.Sink.Write(ctx, record); err != nil {
+			return nil, fmt.Errorf("failed to write feedback: %w", err)
+		}
+	}
+	if updated {
+		return map[string]any{"status": "feedback updated"}, nil
+	}
+	return map[string]any{"status": "feedback recorded"}, nil
+}
@@ -0,0 +1,180 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package feedbacktool_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/feedback"
+	"google.golang.org/adk/internal/testutil"
+	"google.golang.org/adk/runner"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/feedbacktool"
+)
+
+func TestFeedbackTool(t *testing.T) {
+	sessionService := session.InMemoryService()
+	var written []*feedback.Record
+	feedbackTool, err := feedbacktool.New(feedbacktool.Config{
+		SessionService: sessionService,
+		Sink: feedback.SinkFunc(func(_ context.Context, r *feedback.Record) error {
+			written = append(written, r)
+			return nil
+		}),
+		Fingerprint: func(tool.Context) string { return "config-v1" },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	call := func(args map[string]any) *genai.Content {
+		return genai.NewContentFromFunctionCall("record_feedback", args, genai.RoleModel)
+	}
+	text := func(s string) *genai.Content { return genai.NewContentFromText(s, genai.RoleModel) }
+	testModel := &testutil.MockModel{Responses: []*genai.Content{
+		// Turn 1.
+		text("The capital of Australia is Sydney."),
+		// Turn 2.
+		call(map[string]any{"rating": "thumbs_up"}),
+		text("Thanks for your feedback!"),
+		// Turn 3: the acknowledgement of turn 2 is not the previous answer.
+		call(map[string]any{"rating": "thumbs_down", "comment": "it is Canberra"}),
+		text("Sorry, the capital of Australia is Canberra."),
+	}}
+	a, err := llmagent.New(llmagent.Config{
+		Name:  "geography_agent",
+		Model: testModel,
+		Tools: []tool.Tool{feedbackTool},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	created, err := sessionService.Create(t.Context(), &session.CreateRequest{AppName: "app", UserID: "user", SessionID: "session"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := runner.New(runner.Config{AppName: "app", Agent: a, SessionService: sessionService})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var results []map[string]any
+	for _, msg := range []string{"What is the capital of Australia?", "great, thanks", "wait, that's wrong"} {
+		for ev, err := range r.Run(t.Context(), "user", created.Session.ID(), genai.NewContentFromText(msg, genai.RoleUser), agent.RunConfig{}) {
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, p := range ev.Content.Parts {
+				if p.FunctionResponse != nil {
+					results = append(results, p.FunctionResponse.Response)
+				}
+			}
+		}
+	}
+
+	wantResults := []map[string]any{
+		{"status": "feedback recorded"},
+		{"status": "feedback updated"},
+	}
+	if diff := cmp.Diff(wantResults, results); diff != "" {
+		t.Errorf("tool results mismatch (-want +got):\n%s", diff)
+	}
+
+	got, err := sessionService.Get(t.Context(), &session.GetRequest{AppName: "app", UserID: "user", SessionID: created.Session.ID()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var events []*session.Event
+	for ev := range got.Session.Events().All() {
+		events = append(events, ev)
+	}
+	userEvent, answerEvent := events[0], events[1]
+
+	want := &feedback.Record{
+		AppName:           "app",
+		UserID:            "user",
+		SessionID:         created.Session.ID(),
+		Rating:            feedback.RatingDown,
+		Comment:           "it is Canberra",
+		AnswerEventID:     answerEvent.ID,
+		UserEventID:       userEvent.ID,
+		InvocationID:      answerEvent.InvocationID,
+		ConfigFingerprint: "config-v1",
+	}
+	ignoreGenerated := cmpopts.IgnoreFields(feedback.Record{}, "ID", "CreatedAt", "UpdatedAt")
+	if len(written) != 2 {
+		t.Fatalf("sink got %d records, want 2", len(written))
+	}
+	if diff := cmp.Diff(want, written[1], ignoreGenerated); diff != "" {
+		t.Errorf("sink record mismatch (-want +got):\n%s", diff)
+	}
+	if written[0].ID != written[1].ID || written[0].Rating != feedback.RatingUp {
+		t.Errorf("sink records = %+v, %+v, want an update of the same record", written[0], written[1])
+	}
+
+	stored, err := feedback.Load(got.Session.State())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]*feedback.Record{want}, stored, ignoreGenerated); diff != "" {
+		t.Errorf("stored records mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestFeedbackTool_NoAnswer(t *testing.T) {
+	sessionService := session.InMemoryService()
+	feedbackTool, err := feedbacktool.New(feedbacktool.Config{SessionService: sessionService})
+	if err != nil {
+		t.Fatal(err)
+	}
+	testModel := &testutil.MockModel{Responses: []*genai.Content{
+		genai.NewContentFromFunctionCall("record_feedback", map[string]any{"rating": "thumbs_up"}, genai.RoleModel),
+		genai.NewContentFromText("There is nothing to rate yet.", genai.RoleModel),
+	}}
+	a, err := llmagent.New(llmagent.Config{Name: "agent", Model: testModel, Tools: []tool.Tool{feedbackTool}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	created, err := sessionService.Create(t.Context(), &session.CreateRequest{AppName: "app", UserID: "user"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := runner.New(runner.Config{AppName: "app", Agent: a, SessionService: sessionService})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var results []map[string]any
+	for ev, err := range r.Run(t.Context(), "user", created.Session.ID(), genai.NewContentFromText("thumbs up", genai.RoleUser), agent.RunConfig{}) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, p := range ev.Content.Parts {
+			if p.FunctionResponse != nil {
+				results = append(results, p.FunctionResponse.Response)
+			}
+		}
+	}
+	if diff := cmp.Diff([]map[string]any{{"error": "there is no answer to rate"}}, results); diff != "" {
+		t.Errorf("tool results mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -18,8 +18,11 @@ package functiontool
 import (
 	"errors"
 	"fmt"
+	"maps"
 	"reflect"
 	"runtime/debug"
+	"slices"
+	"strings"
 
 	"github.com/google/jsonschema-go/jsonschema"
 	"google.golang.org/genai"
@@ -28,6 +31,7 @@ import (
 	"google.golang.org/adk/internal/typeutil"
 	"google.golang.org/adk/model"
 	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/toolresult"
 )
 
 // FunctionTool: borrow implementation from MCP go.
@@ -46,6 +50,17 @@ type Config struct {
 	OutputSchema *jsonschema.Schema
 	// IsLongRunning makes a FunctionTool a long-running operation.
 	IsLongRunning bool
+	// ReadOnly annotates the tool as read-only and idempotent: the handler
+	// has no side effects, and returns the same result when called again
+	// with the same arguments. See [tool.IsReadOnly].
+	ReadOnly bool
+	// ShowProvenance appends the provenance of the results returned in a
+	// [toolresult.Envelope] to the data seen by the model, as a compact
+	// citation block under the "citations" key.
+	ShowProvenance bool
+	// Validation selects how the arguments violating the input schema are
+	// handled. By default, any violation rejects the call.
+	Validation ValidationMode
 }
 
 // Func represents a Go function that can be wrapped in a tool.
@@ -57,6 +72,12 @@ var ErrInvalidArgument = errors.New("invalid argument")
 
 // New creates a new tool with a name, description, and the provided handler.
 // Input schema is automatically inferred from the input and output types.
+//
+// The string fields of the args struct can be normalized before calling the
+// handler with an adk tag listing the forms of
+// [google.golang.org/adk/tool/textnorm] to apply, e.g.
+// `adk:"normalize=fold,trim"`. The values sent by the model are returned by
+// [OriginalArgs].
 func New[TArgs, TResults any](cfg Config, handler Func[TArgs, TResults]) (tool.Tool, error) {
 	// TODO: How can we improve UX for functions that does not require an argument, returns a simple type value, or returns a no result?
 	//  https://github.com/modelcontextprotocol/go-sdk/discussions/37
@@ -74,16 +95,34 @@ func New[TArgs, TResults any](cfg Config, handler Func[TArgs, TResults]) (tool.T
 	if err != nil {
 		return nil, fmt.Errorf("failed to infer input schema: %w", err)
 	}
-	oschema, err := resolvedSchema[TResults](cfg.OutputSchema)
+	var oschema *jsonschema.Resolved
+	if isEnvelope[TResults]() {
+		// The schema of the data cannot be inferred.
+		if cfg.OutputSchema != nil {
+			oschema, err = cfg.OutputSchema.Resolve(nil)
+		}
+	} else {
+		oschema, err = resolvedSchema[TResults](cfg.OutputSchema)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to infer output schema: %w", err)
 	}
+	normalized, err := normalizedFields(argsType)
+	if err != nil {
+		return nil, fmt.Errorf("invalid normalization: %w", err)
+	}
+	numeric, err := numericFields(argsType, ischema)
+	if err != nil {
+		return nil, fmt.Errorf("invalid normalization: %w", err)
+	}
 
 	return &functionTool[TArgs, TResults]{
 		cfg:          cfg,
 		inputSchema:  ischema,
 		outputSchema: oschema,
 		handler:      handler,
+		normalized:   normalized,
+		numeric:      numeric,
 	}, nil
 }
 
@@ -98,6 +137,10 @@ type functionTool[TArgs, TResults any] struct {
 
 	// handler is the Go function.
 	handler Func[TArgs, TResults]
+	// normalized are the string arguments normalized before calling handler.
+	normalized []normalizedField
+	// numeric are the numeric arguments, read from strings if needed.
+	numeric []numericField
 }
 
 // Description implements tool.Tool.
@@ -115,9 +158,14 @@ func (f *functionTool[TArgs, TResults]) IsLongRunning() bool {
 	return f.cfg.IsLongRunning
 }
 
+// IsReadOnly reports whether the tool is annotated as read-only.
+func (f *functionTool[TArgs, TResults]) IsReadOnly() bool {
+	return f.cfg.ReadOnly
+}
+
 // ProcessRequest packs the function tool's declaration into the LLM request.
 func (f *functionTool[TArgs, TResults]) ProcessRequest(ctx tool.Context, req *model.LLMRequest) error {
-	return toolutils.PackTool(req, f)
+	return toolutils.PackTool(ctx, req, f)
 }
 
 // FunctionDeclaration implements interfaces.FunctionTool.
@@ -158,14 +206,54 @@ func (f *functionTool[TArgs, TResults]) Run(ctx tool.Context, args any) (result
 	if !ok {
 		return nil, fmt.Errorf("unexpected args type, got: %T", args)
 	}
+	var normalizations []Normalization
+	if len(f.numeric) > 0 {
+		if m, normalizations, err = normalizeNumbers(m, f.numeric, func() string { return userLocale(ctx) }); err != nil {
+			return nil, err
+		}
+	}
+	var notes []ValidationNote
+	if f.cfg.Validation == ValidationLenient && f.inputSchema != nil {
+		m, notes = repairArgs(m, f.inputSchema.Schema())
+	}
 	input, err := typeutil.ConvertToWithJSONSchema[map[string]any, TArgs](m, f.inputSchema)
 	if err != nil {
 		return nil, err
 	}
+	if len(f.normalized) > 0 {
+		normalizations = append(normalizations, normalize(&input, f.normalized, userLocale(ctx))...)
+	}
+	if len(normalizations) > 0 {
+		slices.SortFunc(normalizations, func(a, b Normalization) int { return strings.Compare(a.Arg, b.Arg) })
+		ctx = &normalizedContext{Context: ctx, normalizations: normalizations}
+	}
 	output, err := f.handler(ctx, input)
 	if err != nil {
 		return nil, err
 	}
+	result, err = f.result(output)
+	if err != nil || len(notes) == 0 {
+		return result, err
+	}
+	result = maps.Clone(result)
+	if result == nil {
+		result = make(map[string]any)
+	}
+	result["validation_notes"] = resultNotes(notes)
+	return result, nil
+}
+
+// result converts the output of the handler to the result of the tool.
+func (f *functionTool[TArgs, TResults]) result(output TResults) (map[string]any, error) {
+	switch env := any(output).(type) {
+	case toolresult.Envelope:
+		return f.envelopeResult(env)
+	case *toolresult.Envelope:
+		if env == nil {
+			return nil, fmt.Errorf("tool %q returned a nil envelope", f.Name())
+		}
+		return f.envelopeResult(*env)
+	}
 	resp, err := typeutil.ConvertToWithJSONSchema[TResults, map[string]any](output, f.outputSchema)
 	if err == nil { // all good
 		return resp, nil
@@ -184,6 +272,30 @@ func (f *functionTool[TArgs, TResults]) Run(ctx tool.Context, args any) (result
 	return wrappedOutput, nil
 }
 
+// envelopeResult returns the result holding env, whose data is validated
+// against the output schema.
+func (f *functionTool[TArgs, TResults]) envelopeResult(env toolresult.Envelope) (map[string]any, error) {
+	if f.outputSchema != nil {
+		if err := f.outputSchema.Validate(env.Data); err != nil {
+			return nil, fmt.Errorf("invalid result of tool %q: %w", f.Name(), err)
+		}
+	}
+	if f.cfg.ShowProvenance && len(env.Provenance) > 0 {
+		env.Data = maps.Clone(env.Data)
+		if env.Data == nil {
+			env.Data = make(map[string]any)
+		}
+		env.Data["citations"] = toolresult.CitationBlock(env.Provenance)
+	}
+	return toolresult.Wrap(env), nil
+}
+
+// isEnvelope reports whether T is toolresult.Envelope or a pointer to it.
+func isEnvelope[T any]() bool {
+	t := reflect.TypeFor[T]()
+	return t == reflect.TypeFor[toolresult.Envelope]() || t == reflect.TypeFor[*toolresult.Envelope]()
+}
+
 // ** NOTE FOR REVIEWERS **
 // Initially I started to borrow the design of the MCP ServerTool and
 // ToolHandlerFor/ToolHandler [1], but got diverged.
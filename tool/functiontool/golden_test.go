@@ -0,0 +1,78 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package functiontool_test
+
+import (
+	"testing"
+
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/internal/testutil/golden"
+	"google.golang.org/adk/internal/toolinternal"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+)
+
+type forecastArgs struct {
+	City  string `json:"city" jsonschema:"the name of the city"`
+	Days  int    `json:"days,omitempty" jsonschema:"the number of days to forecast"`
+	Units string `json:"units,omitempty"`
+}
+
+type forecastResult struct {
+	Summary      string    `json:"summary"`
+	Temperatures []float64 `json:"temperatures"`
+}
+
+func newForecastTool(t *testing.T, longRunning bool) tool.Tool {
+	t.Helper()
+	forecast, err := functiontool.New(functiontool.Config{
+		Name:          "get_forecast",
+		Description:   "Returns the weather forecast of a city.",
+		IsLongRunning: longRunning,
+	}, func(tool.Context, forecastArgs) (forecastResult, error) {
+		return forecastResult{}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return forecast
+}
+
+func TestFunctionTool_GoldenDeclaration(t *testing.T) {
+	golden.Declaration(t, newForecastTool(t, false))
+}
+
+func TestFunctionTool_GoldenPackedRequest(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		longRunning bool
+	}{
+		{"Regular", false},
+		{"LongRunning", true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req := &model.LLMRequest{
+				Model:    "gemini-2.5-flash",
+				Contents: []*genai.Content{genai.NewContentFromText("Will it rain in Paris?", genai.RoleUser)},
+			}
+			if err := newForecastTool(t, tc.longRunning).(toolinternal.RequestProcessor).ProcessRequest(nil, req); err != nil {
+				t.Fatal(err)
+			}
+			golden.PackedRequest(t, req)
+		})
+	}
+}
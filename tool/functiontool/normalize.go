@@ -0,0 +1,206 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package functiontool
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/textnorm"
+)
+
+// normalizedField is a string argument normalized before calling the
+// handler, as configured by its adk tag.
+type normalizedField struct {
+	index int
+	// name is the name of the argument, i.e. of the JSON field.
+	name  string
+	forms []textnorm.Form
+}
+
+// normalizedFields returns the fields of the args struct with an adk tag
+// such as `adk:"normalize=fold,trim"`. Only the string, *string and
+// []string fields can be normalized.
+func normalizedFields(argsType reflect.Type) ([]normalizedField, error) {
+	if argsType.Kind() != reflect.Struct {
+		return nil, nil
+	}
+	var fields []normalizedField
+	for i := range argsType.NumField() {
+		field := argsType.Field(i)
+		tag, ok := field.Tag.Lookup("adk")
+		if !ok {
+			continue
+		}
+		if strings.HasPrefix(tag, "currency_of=") {
+			// See numericFields.
+			continue
+		}
+		value, ok := strings.CutPrefix(tag, "normalize=")
+		if !ok {
+			return nil, fmt.Errorf("field %s: unknown adk tag %q: %w", field.Name, tag, ErrInvalidArgument)
+		}
+		forms, err := textnorm.ParseForms(value)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w: %w", field.Name, err, ErrInvalidArgument)
+		}
+		switch t := field.Type; {
+		case t.Kind() == reflect.String,
+			t.Kind() == reflect.Pointer && t.Elem().Kind() == reflect.String,
+			t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.String:
+		default:
+			return nil, fmt.Errorf("field %s of type %v cannot be normalized: %w", field.Name, field.Type, ErrInvalidArgument)
+		}
+		fields = append(fields, normalizedField{index: i, name: jsonName(field), forms: forms})
+	}
+	return fields, nil
+}
+
+func jsonName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
+
+// normalize normalizes the fields of args, a struct or a pointer to a
+// struct. It returns the normalizations of the fields which changed.
+func normalize(args any, fields []normalizedField, locale string) []Normalization {
+	v := reflect.ValueOf(args)
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	var normalizations []Normalization
+	for _, field := range fields {
+		fv := v.Field(field.index)
+		switch fv.Kind() {
+		case reflect.String:
+			if s := fv.String(); normalizeValue(fv, s, locale, field.forms) {
+				normalizations = append(normalizations, Normalization{Arg: field.name, Original: s, Normalized: fv.String()})
+			}
+		case reflect.Pointer:
+			if !fv.IsNil() {
+				if s := fv.Elem().String(); normalizeValue(fv.Elem(), s, locale, field.forms) {
+					normalizations = append(normalizations, Normalization{Arg: field.name, Original: s, Normalized: fv.Elem().String()})
+				}
+			}
+		case reflect.Slice:
+			original := make([]string, fv.Len())
+			changed := false
+			for i := range fv.Len() {
+				original[i] = fv.Index(i).String()
+				changed = normalizeValue(fv.Index(i), original[i], locale, field.forms) || changed
+			}
+			if changed {
+				normalized := make([]string, fv.Len())
+				for i := range fv.Len() {
+					normalized[i] = fv.Index(i).String()
+				}
+				normalizations = append(normalizations, Normalization{Arg: field.name, Original: original, Normalized: normalized})
+			}
+		}
+	}
+	return normalizations
+}
+
+// normalizeValue sets the string value v to the normalization of s. It
+// reports whether it changed.
+func normalizeValue(v reflect.Value, s, locale string, forms []textnorm.Form) bool {
+	normalized := textnorm.Normalize(s, locale, forms...)
+	if normalized == s {
+		return false
+	}
+	v.SetString(normalized)
+	return true
+}
+
+// userLocale returns the locale of the user in the session state, if any.
+func userLocale(ctx tool.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	locale, err := ctx.State().Get(textnorm.LocaleStateKey)
+	if err != nil {
+		return ""
+	}
+	s, _ := locale.(string)
+	return s
+}
+
+// Normalization records the normalization of an argument of a call.
+type Normalization struct {
+	// Arg is the name of the argument.
+	Arg string
+	// Original is the value sent by the model: a string, or a []string for
+	// the slices.
+	Original any
+	// Normalized is the value passed to the handler: a string, a []string,
+	// or a float64 for the numeric arguments.
+	Normalized any
+	// Currency is the ISO 4217 code of the currency found in the original
+	// value of a numeric argument, if any.
+	Currency string
+}
+
+type normalizationsKey struct{}
+
+// normalizedContext is the tool.Context passed to the handlers of the calls
+// with normalized arguments. It holds the normalizations.
+type normalizedContext struct {
+	tool.Context
+	normalizations []Normalization
+}
+
+func (c *normalizedContext) Value(key any) any {
+	if key == (normalizationsKey{}) {
+		return c.normalizations
+	}
+	if c.Context == nil {
+		return nil
+	}
+	return c.Context.Value(key)
+}
+
+// Normalizations returns the normalizations of the arguments of the current
+// call, sorted by argument name. It is meant for auditing.
+func Normalizations(ctx tool.Context) []Normalization {
+	if ctx == nil {
+		return nil
+	}
+	normalizations, _ := ctx.Value(normalizationsKey{}).([]Normalization)
+	return normalizations
+}
+
+// OriginalArgs returns the values of the arguments of the current call as
+// sent by the model, before their normalization, by argument name. Only the
+// arguments changed by the normalization are included: a string, or a
+// []string for the slices. It is meant for auditing.
+func OriginalArgs(ctx tool.Context) map[string]any {
+	normalizations := Normalizations(ctx)
+	if len(normalizations) == 0 {
+		return nil
+	}
+	originals := make(map[string]any, len(normalizations))
+	for _, n := range normalizations {
+		originals[n.Arg] = n.Original
+	}
+	return originals
+}
@@ -0,0 +1,331 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package functiontool_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/jsonschema-go/jsonschema"
+
+	icontext "google.golang.org/adk/internal/context"
+	"google.golang.org/adk/internal/sessioninternal"
+	"google.golang.org/adk/internal/toolinternal"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+	"google.golang.org/adk/tool/textnorm"
+)
+
+type customerArgs struct {
+	Name    string   `json:"name" adk:"normalize=fold,diacritics,space,trim"`
+	Code    *string  `json:"code,omitempty" adk:"normalize=nfkc"`
+	Tags    []string `json:"tags,omitempty" adk:"normalize=fold"`
+	Comment string   `json:"comment,omitempty"`
+}
+
+type customerResult struct {
+	Args      customerArgs   `json:"args"`
+	Originals map[string]any `json:"originals,omitempty"`
+}
+
+func newToolContext(t *testing.T, state map[string]any) tool.Context {
+	t.Helper()
+	service := session.InMemoryService()
+	resp, err := service.Create(t.Context(), &session.CreateRequest{AppName: "app", UserID: "user", State: state})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := icontext.NewInvocationContext(t.Context(), icontext.InvocationContextParams{
+		Session: sessioninternal.NewMutableSession(service, resp.Session),
+	})
+	return toolinternal.NewToolContext(ctx, "", &session.EventActions{})
+}
+
+func TestNew_Normalize(t *testing.T) {
+	customer, err := functiontool.New(functiontool.Config{Name: "find_customer"}, func(ctx tool.Context, args customerArgs) (customerResult, error) {
+		return customerResult{Args: args, Originals: functiontool.OriginalArgs(ctx)}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	run := func(t *testing.T, ctx tool.Context, args map[string]any) map[string]any {
+		t.Helper()
+		result, err := customer.(toolinternal.FunctionTool).Run(ctx, args)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+
+	testCases := []struct {
+		name  string
+		state map[string]any
+		args  map[string]any
+		want  map[string]any
+	}{
+		{
+			name: "Normalized",
+			args: map[string]any{"name": "  Jürgen   MÜLLER ", "code": "ＡＢＣ１２３", "tags": []any{"VIP", "new"}, "comment": "Keep AS IS"},
+			want: map[string]any{
+				"args": map[string]any{"name": "jurgen muller", "code": "ABC123", "tags": []any{"vip", "new"}, "comment": "Keep AS IS"},
+				"originals": map[string]any{
+					"name": "  Jürgen   MÜLLER ",
+					"code": "ＡＢＣ１２３",
+					"tags": []any{"VIP", "new"},
+				},
+			},
+		},
+		{
+			name: "Unchanged",
+			args: map[string]any{"name": "muller"},
+			want: map[string]any{
+				"args": map[string]any{"name": "muller"},
+			},
+		},
+		{
+			name:  "TurkishLocale",
+			state: map[string]any{textnorm.LocaleStateKey: "tr-TR"},
+			args:  map[string]any{"name": "ISPARTA"},
+			want: map[string]any{
+				"args":      map[string]any{"name": "ısparta"},
+				"originals": map[string]any{"name": "ISPARTA"},
+			},
+		},
+		{
+			name: "DefaultLocale",
+			args: map[string]any{"name": "ISPARTA"},
+			want: map[string]any{
+				"args":      map[string]any{"name": "isparta"},
+				"originals": map[string]any{"name": "ISPARTA"},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := run(t, newToolContext(t, tc.state), tc.args)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Run() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestNew_InvalidNormalizeTag(t *testing.T) {
+	type unknownForm struct {
+		Name string `json:"name" adk:"normalize=upper"`
+	}
+	if _, err := functiontool.New(functiontool.Config{Name: "f"}, func(tool.Context, unknownForm) (any, error) { return nil, nil }); !errors.Is(err, functiontool.ErrInvalidArgument) {
+		t.Errorf("New() with an unknown form error = %v, want ErrInvalidArgument", err)
+	}
+	type notString struct {
+		Count int `json:"count" adk:"normalize=trim"`
+	}
+	if _, err := functiontool.New(functiontool.Config{Name: "f"}, func(tool.Context, notString) (any, error) { return nil, nil }); !errors.Is(err, functiontool.ErrInvalidArgument) {
+		t.Errorf("New() with a normalized int error = %v, want ErrInvalidArgument", err)
+	}
+}
+
+type orderArgs struct {
+	Quantity int     `json:"quantity,omitempty"`
+	Amount   float64 `json:"amount,omitempty"`
+	Currency string  `json:"currency,omitempty" adk:"currency_of=amount"`
+	Discount float64 `json:"discount,omitempty"`
+	Growth   float64 `json:"growth,omitempty"`
+}
+
+type orderResult struct {
+	Args           orderArgs                    `json:"args"`
+	Normalizations []functiontool.Normalization `json:"normalizations,omitempty"`
+}
+
+func TestNew_NormalizeNumbers(t *testing.T) {
+	schema, err := jsonschema.For[orderArgs](nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The discount is documented as a fraction.
+	schema.Properties["discount"].Minimum = new(float64)
+	schema.Properties["discount"].Maximum = new(float64)
+	*schema.Properties["discount"].Maximum = 1
+	order, err := functiontool.New(functiontool.Config{Name: "order", InputSchema: schema}, func(ctx tool.Context, args orderArgs) (orderResult, error) {
+		return orderResult{Args: args, Normalizations: functiontool.Normalizations(ctx)}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	en := map[string]any{textnorm.LocaleStateKey: "en-US"}
+	de := map[string]any{textnorm.LocaleStateKey: "de-DE"}
+	fr := map[string]any{textnorm.LocaleStateKey: "fr-FR"}
+	testCases := []struct {
+		name  string
+		state map[string]any
+		args  map[string]any
+		want  orderResult
+	}{
+		{
+			name: "Thousands",
+			args: map[string]any{"quantity": "5k"},
+			want: orderResult{Args: orderArgs{Quantity: 5000}, Normalizations: []functiontool.Normalization{{Arg: "quantity", Original: "5k", Normalized: 5000.0}}},
+		},
+		{
+			name: "Millions",
+			args: map[string]any{"amount": "2.5 million"},
+			want: orderResult{Args: orderArgs{Amount: 2500000}, Normalizations: []functiontool.Normalization{{Arg: "amount", Original: "2.5 million", Normalized: 2500000.0}}},
+		},
+		{
+			name: "Billions",
+			args: map[string]any{"quantity": "1.2bn"},
+			want: orderResult{Args: orderArgs{Quantity: 1200000000}, Normalizations: []functiontool.Normalization{{Arg: "quantity", Original: "1.2bn", Normalized: 1200000000.0}}},
+		},
+		{
+			name: "CurrencySymbol",
+			args: map[string]any{"amount": "$1,200.50"},
+			want: orderResult{
+				Args:           orderArgs{Amount: 1200.5, Currency: "USD"},
+				Normalizations: []functiontool.Normalization{{Arg: "amount", Original: "$1,200.50", Normalized: 1200.5, Currency: "USD"}},
+			},
+		},
+		{
+			name:  "CurrencySuffixAndSpaces",
+			state: fr,
+			args:  map[string]any{"amount": "1 200,50 €"},
+			want: orderResult{
+				Args:           orderArgs{Amount: 1200.5, Currency: "EUR"},
+				Normalizations: []functiontool.Normalization{{Arg: "amount", Original: "1 200,50 €", Normalized: 1200.5, Currency: "EUR"}},
+			},
+		},
+		{
+			name: "CurrencyCode",
+			args: map[string]any{"amount": "USD 300"},
+			want: orderResult{
+				Args:           orderArgs{Amount: 300, Currency: "USD"},
+				Normalizations: []functiontool.Normalization{{Arg: "amount", Original: "USD 300", Normalized: 300.0, Currency: "USD"}},
+			},
+		},
+		{
+			name: "CurrencySentByModel",
+			args: map[string]any{"amount": "€5", "currency": "GBP"},
+			want: orderResult{
+				Args:           orderArgs{Amount: 5, Currency: "GBP"},
+				Normalizations: []functiontool.Normalization{{Arg: "amount", Original: "€5", Normalized: 5.0, Currency: "EUR"}},
+			},
+		},
+		{
+			name: "Percentages",
+			args: map[string]any{"discount": "15%", "growth": "-50%"},
+			want: orderResult{
+				Args: orderArgs{Discount: 0.15, Growth: -50},
+				Normalizations: []functiontool.Normalization{
+					{Arg: "discount", Original: "15%", Normalized: 0.15},
+					{Arg: "growth", Original: "-50%", Normalized: -50.0},
+				},
+			},
+		},
+		{
+			name:  "EnglishGrouping",
+			state: en,
+			args:  map[string]any{"amount": "1,200"},
+			want:  orderResult{Args: orderArgs{Amount: 1200}, Normalizations: []functiontool.Normalization{{Arg: "amount", Original: "1,200", Normalized: 1200.0}}},
+		},
+		{
+			name:  "GermanDecimal",
+			state: de,
+			args:  map[string]any{"amount": "1,200"},
+			want:  orderResult{Args: orderArgs{Amount: 1.2}, Normalizations: []functiontool.Normalization{{Arg: "amount", Original: "1,200", Normalized: 1.2}}},
+		},
+		{
+			name:  "GermanGrouping",
+			state: de,
+			args:  map[string]any{"amount": "1.200.000,5"},
+			want:  orderResult{Args: orderArgs{Amount: 1200000.5}, Normalizations: []functiontool.Normalization{{Arg: "amount", Original: "1.200.000,5", Normalized: 1200000.5}}},
+		},
+		{
+			name: "UnambiguousWithoutLocale",
+			args: map[string]any{"amount": "1,5"},
+			want: orderResult{Args: orderArgs{Amount: 1.5}, Normalizations: []functiontool.Normalization{{Arg: "amount", Original: "1,5", Normalized: 1.5}}},
+		},
+		{
+			name: "Numbers",
+			args: map[string]any{"quantity": 3, "amount": 9.5},
+			want: orderResult{Args: orderArgs{Quantity: 3, Amount: 9.5}},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := order.(toolinternal.FunctionTool).Run(newToolContext(t, tc.state), tc.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, err := toMap(tc.want)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(want, result); diff != "" {
+				t.Errorf("Run() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	for _, tc := range []struct {
+		name string
+		args map[string]any
+	}{
+		{"NotInteger", map[string]any{"quantity": "2.5"}},
+		{"NotNumber", map[string]any{"amount": "a few"}},
+		{"UnknownSuffix", map[string]any{"amount": "5 dozen"}},
+		{"MisplacedSeparator", map[string]any{"amount": "12,00,000"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := order.(toolinternal.FunctionTool).Run(newToolContext(t, en), tc.args); !errors.Is(err, functiontool.ErrInvalidArgument) {
+				t.Errorf("Run() error = %v, want ErrInvalidArgument", err)
+			}
+		})
+	}
+
+	t.Run("Ambiguous", func(t *testing.T) {
+		_, err := order.(toolinternal.FunctionTool).Run(newToolContext(t, nil), map[string]any{"amount": "1,200"})
+		var ambiguous *functiontool.AmbiguousNumberError
+		if !errors.As(err, &ambiguous) {
+			t.Fatalf("Run() error = %v, want an AmbiguousNumberError", err)
+		}
+		want := &functiontool.AmbiguousNumberError{Arg: "amount", Value: "1,200", Readings: []float64{1200, 1.2}}
+		if diff := cmp.Diff(want, ambiguous); diff != "" {
+			t.Errorf("AmbiguousNumberError mismatch (-want +got):\n%s", diff)
+		}
+	})
+}
+
+// toMap returns v as decoded from JSON.
+func toMap(v any) (map[string]any, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]any
+	return m, json.Unmarshal(b, &m)
+}
+
+func TestNew_InvalidCurrencyTag(t *testing.T) {
+	type unknownAmount struct {
+		Currency string `json:"currency" adk:"currency_of=amount"`
+	}
+	if _, err := functiontool.New(functiontool.Config{Name: "f"}, func(tool.Context, unknownAmount) (any, error) { return nil, nil }); !errors.Is(err, functiontool.ErrInvalidArgument) {
+		t.Errorf("New() with an unknown amount error = %v, want ErrInvalidArgument", err)
+	}
+}
@@ -0,0 +1,401 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package functiontool
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"math/big"
+	"reflect"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/google/jsonschema-go/jsonschema"
+	"golang.org/x/text/language"
+)
+
+// AmbiguousNumberError is returned by the calls with a numeric argument that
+// reads differently depending on the locale, e.g. "1,200" which is 1200 in
+// English and 1.2 in French, when the locale of the user is not known.
+type AmbiguousNumberError struct {
+	// Arg is the name of the argument.
+	Arg string
+	// Value is the value sent by the model.
+	Value string
+	// Readings are the possible values.
+	Readings []float64
+}
+
+// Error implements error.
+func (e *AmbiguousNumberError) Error() string {
+	return fmt.Sprintf("argument %q: %q is ambiguous, it may be %v or %v: send the number without thousands separators", e.Arg, e.Value, e.Readings[0], e.Readings[1])
+}
+
+// Unwrap returns ErrInvalidArgument.
+func (e *AmbiguousNumberError) Unwrap() error {
+	return ErrInvalidArgument
+}
+
+// numericField is a numeric argument, which the model may send as a string
+// such as "5k" or "$1,200.50".
+type numericField struct {
+	name    string
+	integer bool
+	// fraction is set if the schema of the argument documents a range
+	// within 0 and 1: percentages are converted to fractions.
+	fraction bool
+	// currency is the name of the argument receiving the currency of the
+	// value, if any.
+	currency string
+}
+
+// numericFields returns the numeric fields of the args struct, with their
+// companion currency fields declared by an adk tag such as
+// `adk:"currency_of=amount"`.
+func numericFields(argsType reflect.Type, schema *jsonschema.Resolved) ([]numericField, error) {
+	if argsType.Kind() != reflect.Struct {
+		return nil, nil
+	}
+	var fields []numericField
+	byName := make(map[string]int)
+	for i := range argsType.NumField() {
+		field := argsType.Field(i)
+		t := field.Type
+		if t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		var integer bool
+		switch t.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			integer = true
+		case reflect.Float32, reflect.Float64:
+		default:
+			continue
+		}
+		name := jsonName(field)
+		nf := numericField{name: name, integer: integer}
+		if schema != nil {
+			if p := schema.Schema().Properties[name]; p != nil && p.Minimum != nil && p.Maximum != nil {
+				nf.fraction = *p.Minimum >= 0 && *p.Maximum <= 1
+			}
+		}
+		byName[name] = len(fields)
+		fields = append(fields, nf)
+	}
+	for i := range argsType.NumField() {
+		field := argsType.Field(i)
+		amount, ok := strings.CutPrefix(field.Tag.Get("adk"), "currency_of=")
+		if !ok {
+			continue
+		}
+		j, ok := byName[amount]
+		if !ok {
+			return nil, fmt.Errorf("field %s: %q is not a numeric argument: %w", field.Name, amount, ErrInvalidArgument)
+		}
+		if t := field.Type; t.Kind() != reflect.String && (t.Kind() != reflect.Pointer || t.Elem().Kind() != reflect.String) {
+			return nil, fmt.Errorf("field %s of type %v cannot hold a currency: %w", field.Name, field.Type, ErrInvalidArgument)
+		}
+		fields[j].currency = jsonName(field)
+	}
+	return fields, nil
+}
+
+// normalizeNumbers returns a copy of args whose numeric arguments sent as
+// strings are replaced by their values, and the normalizations done. The
+// locale of the user is only looked up if needed.
+func normalizeNumbers(args map[string]any, fields []numericField, locale func() string) (map[string]any, []Normalization, error) {
+	var normalized map[string]any
+	var normalizations []Normalization
+	for _, field := range fields {
+		s, ok := args[field.name].(string)
+		if !ok {
+			continue
+		}
+		n, err := parseNumber(s, locale)
+		if err != nil {
+			var ambiguous *AmbiguousNumberError
+			if errors.As(err, &ambiguous) {
+				ambiguous.Arg = field.name
+				return nil, nil, ambiguous
+			}
+			return nil, nil, fmt.Errorf("argument %q: %w", field.name, err)
+		}
+		if n.percent && field.fraction {
+			n.value.Quo(n.value, big.NewRat(100, 1))
+		}
+		if field.integer && !n.value.IsInt() {
+			return nil, nil, fmt.Errorf("argument %q: %q is not an integer: %w", field.name, s, ErrInvalidArgument)
+		}
+		value, _ := n.value.Float64()
+
+		if normalized == nil {
+			normalized = make(map[string]any, len(args))
+			maps.Copy(normalized, args)
+		}
+		normalized[field.name] = value
+		normalization := Normalization{Arg: field.name, Original: s, Normalized: value}
+		if field.currency != "" && n.currency != "" {
+			normalization.Currency = n.currency
+			if c, _ := normalized[field.currency].(string); c == "" {
+				normalized[field.currency] = n.currency
+			}
+		}
+		normalizations = append(normalizations, normalization)
+	}
+	if normalized == nil {
+		return args, nil, nil
+	}
+	return normalized, normalizations, nil
+}
+
+// number is a number read from a string.
+type number struct {
+	value    *big.Rat
+	currency string
+	percent  bool
+}
+
+// currencySymbols maps the currency symbols to their ISO 4217 codes. The
+// longer symbols are matched first.
+var currencySymbols = map[string]string{
+	"US$": "USD",
+	"R$":  "BRL",
+	"C$":  "CAD",
+	"A$":  "AUD",
+	"$":   "USD",
+	"€":   "EUR",
+	"£":   "GBP",
+	"¥":   "JPY",
+	"₹":   "INR",
+	"₩":   "KRW",
+	"₽":   "RUB",
+}
+
+var currencySymbolsByLength = func() []string {
+	symbols := make([]string, 0, len(currencySymbols))
+	for s := range currencySymbols {
+		symbols = append(symbols, s)
+	}
+	slices.SortFunc(symbols, func(a, b string) int { return len(b) - len(a) })
+	return symbols
+}()
+
+// multipliers maps the magnitude suffixes to their values.
+var multipliers = map[string]int64{
+	"k":        1e3,
+	"thousand": 1e3,
+	"m":        1e6,
+	"mm":       1e6,
+	"mn":       1e6,
+	"million":  1e6,
+	"b":        1e9,
+	"bn":       1e9,
+	"billion":  1e9,
+}
+
+// parseNumber reads s, a number possibly with a sign, a currency symbol or
+// code, a magnitude suffix, a percent sign and thousands separators.
+func parseNumber(s string, locale func() string) (number, error) {
+	var n number
+	t := strings.TrimSpace(s)
+	negative := false
+	t, negative = cutSign(t)
+	t, n.currency = cutCurrency(t)
+	if !negative {
+		t, negative = cutSign(t)
+	}
+	if t, n.percent = strings.CutSuffix(t, "%"); n.percent {
+		t = strings.TrimSpace(t)
+	}
+
+	multiplier := int64(1)
+	if word := strings.TrimLeftFunc(t, func(r rune) bool { return !unicode.IsLetter(r) }); word != "" {
+		m, ok := multipliers[strings.ToLower(word)]
+		if !ok {
+			return n, fmt.Errorf("cannot read %q as a number: %w", s, ErrInvalidArgument)
+		}
+		multiplier = m
+		t = strings.TrimSpace(strings.TrimSuffix(t, word))
+	}
+
+	digits, err := canonicalDigits(s, t, locale)
+	if err != nil {
+		return n, err
+	}
+	value, ok := new(big.Rat).SetString(digits)
+	if !ok {
+		return n, fmt.Errorf("cannot read %q as a number: %w", s, ErrInvalidArgument)
+	}
+	value.Mul(value, big.NewRat(multiplier, 1))
+	if negative {
+		value.Neg(value)
+	}
+	n.value = value
+	return n, nil
+}
+
+func cutSign(s string) (string, bool) {
+	for _, minus := range []string{"-", "−"} {
+		if rest, ok := strings.CutPrefix(s, minus); ok {
+			return strings.TrimSpace(rest), true
+		}
+	}
+	if rest, ok := strings.CutPrefix(s, "+"); ok {
+		return strings.TrimSpace(rest), false
+	}
+	return s, false
+}
+
+// cutCurrency removes the currency symbol or ISO code prefixing or suffixing
+// s, and returns its code.
+func cutCurrency(s string) (string, string) {
+	for _, symbol := range currencySymbolsByLength {
+		if rest, ok := strings.CutPrefix(s, symbol); ok {
+			return strings.TrimSpace(rest), currencySymbols[symbol]
+		}
+		if rest, ok := strings.CutSuffix(s, symbol); ok {
+			return strings.TrimSpace(rest), currencySymbols[symbol]
+		}
+	}
+	if len(s) > 3 && isCurrencyCode(s[:3]) && !unicode.IsLetter(rune(s[3])) {
+		return strings.TrimSpace(s[3:]), s[:3]
+	}
+	if len(s) > 3 && isCurrencyCode(s[len(s)-3:]) && !unicode.IsLetter(rune(s[len(s)-4])) {
+		return strings.TrimSpace(s[:len(s)-3]), s[len(s)-3:]
+	}
+	return s, ""
+}
+
+func isCurrencyCode(s string) bool {
+	for _, r := range s {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// canonicalDigits returns t, the digits and separators of the number s,
+// without the thousands separators and with a dot as decimal separator.
+func canonicalDigits(s, t string, locale func() string) (string, error) {
+	t = strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '\u00a0', '\u202f', '\'', '’':
+			// Thousands separators of some locales.
+			return -1
+		}
+		return r
+	}, t)
+	if t == "" || strings.Trim(t, "0123456789.,") != "" {
+		return "", fmt.Errorf("cannot read %q as a number: %w", s, ErrInvalidArgument)
+	}
+
+	commas, dots := strings.Count(t, ","), strings.Count(t, ".")
+	var decimal, group string
+	switch {
+	case commas > 0 && dots > 0:
+		// The last separator is the decimal one.
+		if strings.LastIndex(t, ",") > strings.LastIndex(t, ".") {
+			decimal, group = ",", "."
+		} else {
+			decimal, group = ".", ","
+		}
+		if strings.Count(t, decimal) > 1 {
+			return "", fmt.Errorf("cannot read %q as a number: %w", s, ErrInvalidArgument)
+		}
+	case commas > 1:
+		group = ","
+	case dots > 1:
+		group = "."
+	case commas == 1 || dots == 1:
+		sep := ","
+		if dots == 1 {
+			sep = "."
+		}
+		before, after, _ := strings.Cut(t, sep)
+		if len(after) != 3 || before == "" || before == "0" {
+			decimal = sep
+			break
+		}
+		// "1,200" is 1200 or 1.2 depending on the locale.
+		switch decimalSeparator(locale()) {
+		case sep:
+			decimal = sep
+		case "":
+			grouped, _ := new(big.Rat).SetString(before + after)
+			fractional, _ := new(big.Rat).SetString(before + "." + after)
+			g, _ := grouped.Float64()
+			f, _ := fractional.Float64()
+			return "", &AmbiguousNumberError{Value: s, Readings: []float64{g, f}}
+		default:
+			group = sep
+		}
+	}
+
+	integer, fraction, _ := strings.Cut(t, decimal)
+	if decimal == "" {
+		integer, fraction = t, ""
+	}
+	if group != "" {
+		groups := strings.Split(integer, group)
+		for i, g := range groups {
+			if (i == 0 && (len(g) == 0 || len(g) > 3)) || (i > 0 && len(g) != 3) {
+				return "", fmt.Errorf("cannot read %q as a number: misplaced thousands separator: %w", s, ErrInvalidArgument)
+			}
+		}
+		integer = strings.Join(groups, "")
+	}
+	if integer == "" {
+		integer = "0"
+	}
+	if fraction == "" {
+		return integer, nil
+	}
+	return integer + "." + fraction, nil
+}
+
+// decimalSeparator returns the decimal separator of the locale, or an empty
+// string if it is not known.
+func decimalSeparator(locale string) string {
+	tag, err := language.Parse(locale)
+	if err != nil || locale == "" {
+		return ""
+	}
+	base, _ := tag.Base()
+	region, _ := tag.Region()
+	switch base.String() {
+	case "en", "ja", "zh", "ko", "hi", "th", "he", "ms", "fil", "ga":
+		return "."
+	case "es":
+		switch region.String() {
+		case "MX", "US", "PR", "GT", "HN", "NI", "PA", "DO", "SV":
+			return "."
+		}
+		return ","
+	case "de", "it", "fr":
+		if region.String() == "CH" || region.String() == "LI" {
+			return "."
+		}
+		return ","
+	case "pt", "nl", "ru", "pl", "tr", "sv", "da", "nb", "nn", "no", "fi", "cs", "sk", "hu", "ro",
+		"id", "vi", "uk", "el", "bg", "hr", "sl", "sr", "lt", "lv", "et", "ca", "az":
+		return ","
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,187 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package functiontool
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/jsonschema-go/jsonschema"
+)
+
+// ValidationMode selects how the arguments violating the input schema of a
+// tool are handled.
+type ValidationMode int
+
+const (
+	// ValidationStrict rejects the calls with any violation. It is the
+	// default.
+	ValidationStrict ValidationMode = iota
+	// ValidationLenient repairs the soft violations of the optional
+	// arguments and calls the handler, reporting the repairs to the model
+	// under the "validation_notes" key of the result. The soft violations
+	// are the arguments unknown to a schema disallowing additional
+	// properties, which are removed, the strings longer than their
+	// maxLength, which are truncated, and the values out of their enum,
+	// which are replaced by their default if they have one. Other
+	// violations, such as a missing required argument or a required
+	// argument of the wrong type, still reject the call.
+	ValidationLenient
+)
+
+// ValidationAction is the repair of a soft violation.
+type ValidationAction string
+
+const (
+	// ValidationStripped means that an unknown argument was removed.
+	ValidationStripped ValidationAction = "stripped"
+	// ValidationClamped means that a string was truncated to its maxLength.
+	ValidationClamped ValidationAction = "clamped"
+	// ValidationDefaulted means that a value out of its enum was replaced
+	// by its default.
+	ValidationDefaulted ValidationAction = "defaulted"
+)
+
+// ValidationNote records the repair of a soft violation of the input
+// schema, see [ValidationLenient].
+type ValidationNote struct {
+	// Arg is the path of the argument, e.g. "filter.labels[1]".
+	Arg    string
+	Action ValidationAction
+	// Original is the value sent by the model.
+	Original any
+	// Message explains the repair to the model.
+	Message string
+}
+
+// repairArgs returns a copy of args where the soft violations of schema are
+// repaired, and the notes of the repairs sorted by argument. args is not
+// modified.
+func repairArgs(args map[string]any, schema *jsonschema.Schema) (map[string]any, []ValidationNote) {
+	var notes []ValidationNote
+	args = repairObject("", args, schema, &notes)
+	slices.SortFunc(notes, func(a, b ValidationNote) int { return strings.Compare(a.Arg, b.Arg) })
+	return args, notes
+}
+
+func repairObject(path string, obj map[string]any, s *jsonschema.Schema, notes *[]ValidationNote) map[string]any {
+	if s == nil || obj == nil {
+		return obj
+	}
+	out := maps.Clone(obj)
+	for name, v := range obj {
+		arg := name
+		if path != "" {
+			arg = path + "." + name
+		}
+		ps, ok := s.Properties[name]
+		if !ok {
+			if isFalseSchema(s.AdditionalProperties) {
+				delete(out, name)
+				*notes = append(*notes, ValidationNote{Arg: arg, Action: ValidationStripped, Original: v,
+					Message: "unknown argument removed"})
+			}
+			continue
+		}
+		if !slices.Contains(s.Required, name) {
+			v = repairOptional(arg, v, ps, notes)
+		}
+		out[name] = repairValue(arg, v, ps, notes)
+	}
+	return out
+}
+
+// repairValue repairs the objects nested in v.
+func repairValue(path string, v any, s *jsonschema.Schema, notes *[]ValidationNote) any {
+	switch v := v.(type) {
+	case map[string]any:
+		return repairObject(path, v, s, notes)
+	case []any:
+		if s.Items == nil {
+			return v
+		}
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = repairValue(path+"["+strconv.Itoa(i)+"]", item, s.Items, notes)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// repairOptional repairs v, the value of an optional argument.
+func repairOptional(arg string, v any, s *jsonschema.Schema, notes *[]ValidationNote) any {
+	if str, ok := v.(string); ok && s.MaxLength != nil && utf8.RuneCountInString(str) > *s.MaxLength {
+		clamped := string([]rune(str)[:*s.MaxLength])
+		*notes = append(*notes, ValidationNote{Arg: arg, Action: ValidationClamped, Original: v,
+			Message: fmt.Sprintf("truncated to the maximum length of %d characters", *s.MaxLength)})
+		return clamped
+	}
+	if len(s.Enum) > 0 && s.Default != nil && !inEnum(v, s.Enum) {
+		var def any
+		if err := json.Unmarshal(s.Default, &def); err != nil {
+			return v
+		}
+		*notes = append(*notes, ValidationNote{Arg: arg, Action: ValidationDefaulted, Original: v,
+			Message: fmt.Sprintf("%s is not an allowed value, replaced by the default %s", jsonText(v), s.Default)})
+		return def
+	}
+	return v
+}
+
+// inEnum reports whether v is one of the values of enum, compared by their
+// JSON encoding.
+func inEnum(v any, enum []any) bool {
+	text := jsonText(v)
+	for _, e := range enum {
+		if jsonText(e) == text {
+			return true
+		}
+	}
+	return false
+}
+
+func jsonText(v any) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(bytes.TrimSuffix(buf.Bytes(), []byte("\n")))
+}
+
+// isFalseSchema reports whether s is the schema matching nothing, as used
+// for additionalProperties: false.
+func isFalseSchema(s *jsonschema.Schema) bool {
+	return s != nil && s.Not != nil && reflect.DeepEqual(*s.Not, jsonschema.Schema{})
+}
+
+// resultNotes returns notes as seen by the model in the results.
+func resultNotes(notes []ValidationNote) []any {
+	out := make([]any, len(notes))
+	for i, n := range notes {
+		out[i] = map[string]any{"arg": n.Arg, "action": string(n.Action), "message": n.Message}
+	}
+	return out
+}
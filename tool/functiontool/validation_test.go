@@ -0,0 +1,168 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package functiontool_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/jsonschema-go/jsonschema"
+
+	"google.golang.org/adk/internal/toolinternal"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+)
+
+type searchFilter struct {
+	Label string `json:"label,omitempty"`
+}
+
+type searchArgs struct {
+	Query   string        `json:"query"`
+	Limit   int           `json:"limit"`
+	Comment string        `json:"comment,omitempty"`
+	Sort    string        `json:"sort,omitempty"`
+	Filter  *searchFilter `json:"filter,omitempty"`
+}
+
+func newSearchTool(t *testing.T, mode functiontool.ValidationMode) toolinternal.FunctionTool {
+	t.Helper()
+	maxLength := func(n int) *int { return &n }
+	schema := &jsonschema.Schema{
+		Type: "object",
+		Properties: map[string]*jsonschema.Schema{
+			"query":   {Type: "string", MaxLength: maxLength(5)},
+			"limit":   {Type: "integer"},
+			"comment": {Type: "string", MaxLength: maxLength(10)},
+			"sort":    {Type: "string", Enum: []any{"relevance", "date"}, Default: json.RawMessage(`"relevance"`)},
+			"filter": {
+				Type: "object",
+				Properties: map[string]*jsonschema.Schema{
+					"label": {Type: "string", MaxLength: maxLength(3)},
+				},
+				AdditionalProperties: &jsonschema.Schema{Not: &jsonschema.Schema{}},
+			},
+		},
+		Required:             []string{"query", "limit"},
+		AdditionalProperties: &jsonschema.Schema{Not: &jsonschema.Schema{}},
+	}
+	search, err := functiontool.New(functiontool.Config{Name: "search", InputSchema: schema, Validation: mode}, func(_ tool.Context, args searchArgs) (searchArgs, error) {
+		return args, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return search.(toolinternal.FunctionTool)
+}
+
+func TestNew_LenientValidation(t *testing.T) {
+	search := newSearchTool(t, functiontool.ValidationLenient)
+
+	testCases := []struct {
+		name string
+		args map[string]any
+		want map[string]any
+	}{
+		{
+			name: "Valid",
+			args: map[string]any{"query": "shoes", "limit": 3, "sort": "date"},
+			want: map[string]any{"query": "shoes", "limit": float64(3), "sort": "date"},
+		},
+		{
+			name: "Stripped",
+			args: map[string]any{"query": "shoes", "limit": 3, "verbose": true, "filter": map[string]any{"label": "red", "size": 42}},
+			want: map[string]any{
+				"query":  "shoes",
+				"limit":  float64(3),
+				"filter": map[string]any{"label": "red"},
+				"validation_notes": []any{
+					map[string]any{"arg": "filter.size", "action": "stripped", "message": "unknown argument removed"},
+					map[string]any{"arg": "verbose", "action": "stripped", "message": "unknown argument removed"},
+				},
+			},
+		},
+		{
+			name: "Clamped",
+			args: map[string]any{"query": "shoes", "limit": 3, "comment": "größer als zehn", "filter": map[string]any{"label": "green"}},
+			want: map[string]any{
+				"query":   "shoes",
+				"limit":   float64(3),
+				"comment": "größer als",
+				"filter":  map[string]any{"label": "gre"},
+				"validation_notes": []any{
+					map[string]any{"arg": "comment", "action": "clamped", "message": "truncated to the maximum length of 10 characters"},
+					map[string]any{"arg": "filter.label", "action": "clamped", "message": "truncated to the maximum length of 3 characters"},
+				},
+			},
+		},
+		{
+			name: "Defaulted",
+			args: map[string]any{"query": "shoes", "limit": 3, "sort": "popularity"},
+			want: map[string]any{
+				"query": "shoes",
+				"limit": float64(3),
+				"sort":  "relevance",
+				"validation_notes": []any{
+					map[string]any{"arg": "sort", "action": "defaulted", "message": `"popularity" is not an allowed value, replaced by the default "relevance"`},
+				},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			original, err := json.Marshal(tc.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := search.Run(nil, tc.args)
+			if err != nil {
+				t.Fatalf("Run() failed: %v", err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Run() mismatch (-want +got):\n%s", diff)
+			}
+			if after, _ := json.Marshal(tc.args); string(after) != string(original) {
+				t.Errorf("Run() modified the args: %s, want %s", after, original)
+			}
+		})
+	}
+}
+
+func TestNew_ValidationFatal(t *testing.T) {
+	lenient := newSearchTool(t, functiontool.ValidationLenient)
+	strict := newSearchTool(t, functiontool.ValidationStrict)
+
+	testCases := []struct {
+		name string
+		tool toolinternal.FunctionTool
+		args map[string]any
+	}{
+		{"MissingRequired", lenient, map[string]any{"query": "shoes"}},
+		{"RequiredWrongType", lenient, map[string]any{"query": "shoes", "limit": "ten"}},
+		{"RequiredTooLong", lenient, map[string]any{"query": "running shoes", "limit": 3}},
+		{"OptionalWrongType", lenient, map[string]any{"query": "shoes", "limit": 3, "comment": 7}},
+		{"StrictStripped", strict, map[string]any{"query": "shoes", "limit": 3, "verbose": true}},
+		{"StrictClamped", strict, map[string]any{"query": "shoes", "limit": 3, "comment": "longer than ten"}},
+		{"StrictDefaulted", strict, map[string]any{"query": "shoes", "limit": 3, "sort": "popularity"}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got, err := tc.tool.Run(nil, tc.args); err == nil {
+				t.Errorf("Run() = %v, want an error", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,31 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package geminitool_test
+
+import (
+	"testing"
+
+	"google.golang.org/adk/internal/testutil/golden"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/tool/geminitool"
+)
+
+func TestGoogleSearch_GoldenPackedRequest(t *testing.T) {
+	req := &model.LLMRequest{Model: "gemini-2.5-flash"}
+	if err := (geminitool.GoogleSearch{}).ProcessRequest(nil, req); err != nil {
+		t.Fatal(err)
+	}
+	golden.PackedRequest(t, req)
+}
@@ -31,9 +31,11 @@ package geminitool
 
 import (
 	"fmt"
+	"slices"
 
 	"google.golang.org/genai"
 
+	"google.golang.org/adk/internal/toolinternal/toolutils"
 	"google.golang.org/adk/model"
 	"google.golang.org/adk/tool"
 )
@@ -81,6 +83,21 @@ func setTool(req *model.LLMRequest, t *genai.Tool) error {
 		req.Config = &genai.GenerateContentConfig{}
 	}
 
+	// Declaring the same native tool twice makes the backend reject the
+	// request, so the tools already present are not added again.
+	names := toolutils.NativeToolNames(t)
+	if len(names) > 0 && len(t.FunctionDeclarations) == 0 {
+		present := make(map[string]bool)
+		for _, existing := range req.Config.Tools {
+			for _, name := range toolutils.NativeToolNames(existing) {
+				present[name] = true
+			}
+		}
+		if !slices.ContainsFunc(names, func(name string) bool { return !present[name] }) {
+			return nil
+		}
+	}
+
 	req.Config.Tools = append(req.Config.Tools, t)
 	return nil
 }
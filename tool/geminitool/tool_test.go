@@ -62,6 +62,22 @@ func TestGeminiTool_ProcessRequest(t *testing.T) {
 				{GoogleSearch: &genai.GoogleSearch{}},
 			},
 		},
+		{
+			name: "native tool already present",
+			inputTool: &genai.Tool{
+				GoogleSearch: &genai.GoogleSearch{},
+			},
+			req: &model.LLMRequest{
+				Config: &genai.GenerateContentConfig{
+					Tools: []*genai.Tool{
+						{GoogleSearch: &genai.GoogleSearch{}},
+					},
+				},
+			},
+			wantTools: []*genai.Tool{
+				{GoogleSearch: &genai.GoogleSearch{}},
+			},
+		},
 		{
 			name:    "error on nil request",
 			wantErr: true,
@@ -0,0 +1,153 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package intenttoolset
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"unicode"
+
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/model"
+)
+
+// Classifier maps the text of a user message to intents. An empty result
+// means that no intent was recognized with enough confidence.
+type Classifier interface {
+	Classify(ctx context.Context, text string) ([]string, error)
+}
+
+// ErrLowConfidence is returned by the model classifier when the model is not
+// confident enough in its classification.
+var ErrLowConfidence = errors.New("intent classification confidence is too low")
+
+// KeywordClassifier classifies messages with keyword rules: it maps every
+// intent to keywords or phrases, and returns the intents having a keyword in
+// the message. Matching is case-insensitive and on word boundaries, so that
+// "bill" does not match "billion".
+type KeywordClassifier map[string][]string
+
+// Classify implements Classifier.
+func (c KeywordClassifier) Classify(_ context.Context, text string) ([]string, error) {
+	words := " " + strings.Join(splitWords(text), " ") + " "
+	var intents []string
+	for _, intent := range slices.Sorted(maps.Keys(c)) {
+		for _, keyword := range c[intent] {
+			if kw := strings.Join(splitWords(keyword), " "); kw != "" && strings.Contains(words, " "+kw+" ") {
+				intents = append(intents, intent)
+				break
+			}
+		}
+	}
+	return intents, nil
+}
+
+func splitWords(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// ModelClassifierConfig is the configuration of [NewModelClassifier].
+type ModelClassifierConfig struct {
+	// Model classifies the messages. A small and cheap model is enough.
+	Model model.LLM
+	// Intents maps the intents to their description, which tells the model
+	// when they apply.
+	Intents map[string]string
+	// MinConfidence is the confidence, between 0 and 1, below which the
+	// classification fails with ErrLowConfidence. Zero accepts all the
+	// classifications.
+	MinConfidence float64
+}
+
+type modelClassifier struct {
+	cfg ModelClassifierConfig
+}
+
+// NewModelClassifier returns a Classifier asking a model for the intents of
+// the messages, along with its confidence.
+func NewModelClassifier(cfg ModelClassifierConfig) (Classifier, error) {
+	if cfg.Model == nil {
+		return nil, errors.New("model is required")
+	}
+	if len(cfg.Intents) == 0 {
+		return nil, errors.New("at least one intent is required")
+	}
+	return &modelClassifier{cfg: cfg}, nil
+}
+
+type classification struct {
+	Intents    []string `json:"intents"`
+	Confidence float64  `json:"confidence"`
+}
+
+// Classify implements Classifier.
+func (c *modelClassifier) Classify(ctx context.Context, text string) ([]string, error) {
+	var b strings.Builder
+	b.WriteString("Classify the intents of the user message below. The possible intents are:\n")
+	names := slices.Sorted(maps.Keys(c.cfg.Intents))
+	for _, name := range names {
+		fmt.Fprintf(&b, "- %s: %s\n", name, c.cfg.Intents[name])
+	}
+	b.WriteString("Answer with the matching intents, possibly none, and your confidence between 0 and 1.\n\nUser message:\n")
+	b.WriteString(text)
+
+	req := &model.LLMRequest{
+		Model:    c.cfg.Model.Name(),
+		Contents: []*genai.Content{genai.NewContentFromText(b.String(), genai.RoleUser)},
+		Config: &genai.GenerateContentConfig{
+			Temperature:      genai.Ptr[float32](0),
+			ResponseMIMEType: "application/json",
+			ResponseSchema: &genai.Schema{
+				Type: genai.TypeObject,
+				Properties: map[string]*genai.Schema{
+					"intents":    {Type: genai.TypeArray, Items: &genai.Schema{Type: genai.TypeString, Enum: names}},
+					"confidence": {Type: genai.TypeNumber},
+				},
+				Required: []string{"intents", "confidence"},
+			},
+		},
+	}
+
+	var answer strings.Builder
+	for resp, err := range c.cfg.Model.GenerateContent(ctx, req, false) {
+		if err != nil {
+			return nil, fmt.Errorf("failed to classify the intents: %w", err)
+		}
+		if resp.Content == nil {
+			continue
+		}
+		for _, p := range resp.Content.Parts {
+			if p != nil && !p.Thought {
+				answer.WriteString(p.Text)
+			}
+		}
+	}
+	var result classification
+	if err := json.Unmarshal([]byte(answer.String()), &result); err != nil {
+		return nil, fmt.Errorf("failed to parse the intent classification %q: %w", answer.String(), err)
+	}
+	if result.Confidence < c.cfg.MinConfidence {
+		return nil, fmt.Errorf("%w: %v < %v", ErrLowConfidence, result.Confidence, c.cfg.MinConfidence)
+	}
+	return result.Intents, nil
+}
@@ -0,0 +1,206 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package intenttoolset exposes to the model only the tools relevant to the
+// intents of the latest user message.
+//
+// [New] returns a toolset grouping other toolsets by intent. On every turn,
+// a [Classifier] maps the text of the user message to intents, and only the
+// toolsets of these intents are exposed, along with the always-on ones. When
+// the classification fails or finds no intent, all the toolsets are exposed.
+package intenttoolset
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/tool"
+)
+
+// Config is the configuration of the toolset returned by [New].
+type Config struct {
+	// Name is the name of the toolset.
+	Name string
+	// Classifier maps the latest user message to intents.
+	Classifier Classifier
+	// Groups maps the intents to the toolsets exposed for them.
+	Groups map[string][]tool.Toolset
+	// AlwaysOn are the toolsets exposed on every turn.
+	AlwaysOn []tool.Toolset
+	// OnDecision is called with the exposure decision of every turn, e.g. to
+	// record the decisions in an analytics system and tune the classifier.
+	// Optional.
+	OnDecision func(ctx agent.ReadonlyContext, d Decision)
+}
+
+// Decision is the exposure decision of a turn.
+type Decision struct {
+	InvocationID string
+	// Intents are the intents returned by the classifier, restricted to the
+	// configured ones.
+	Intents []string
+	// Fallback reports whether all the toolsets were exposed, because the
+	// classification failed or found no intent.
+	Fallback bool
+	// Err is the error of the classifier, if any.
+	Err error
+	// Tools are the names of the exposed tools.
+	Tools []string
+}
+
+// maxCachedTurns bounds the number of turns whose intents are cached.
+const maxCachedTurns = 256
+
+type toolset struct {
+	cfg Config
+
+	mu sync.Mutex
+	// turns caches the intents chosen for the recent invocations, in order.
+	turns      map[string]turnIntents
+	turnsOrder []string
+}
+
+type turnIntents struct {
+	intents  []string
+	fallback bool
+}
+
+// New returns a toolset exposing the toolsets of the intents of the latest
+// user message, see the package documentation.
+//
+// The intents are classified once per invocation, so that the tools stay
+// the same for all the model calls of the turn.
+func New(cfg Config) (tool.Toolset, error) {
+	if cfg.Name == "" {
+		return nil, errors.New("intenttoolset: name is required")
+	}
+	if cfg.Classifier == nil {
+		return nil, errors.New("intenttoolset: classifier is required")
+	}
+	if len(cfg.Groups) == 0 {
+		return nil, errors.New("intenttoolset: at least one intent group is required")
+	}
+	return &toolset{cfg: cfg, turns: make(map[string]turnIntents)}, nil
+}
+
+// Name implements tool.Toolset.
+func (s *toolset) Name() string {
+	return s.cfg.Name
+}
+
+// Tools implements tool.Toolset.
+func (s *toolset) Tools(ctx agent.ReadonlyContext) ([]tool.Tool, error) {
+	turn, cached := s.cachedTurn(ctx.InvocationID())
+	var classifyErr error
+	if !cached {
+		turn, classifyErr = s.classify(ctx)
+		s.cacheTurn(ctx.InvocationID(), turn)
+	}
+
+	toolsets := slices.Clone(s.cfg.AlwaysOn)
+	if turn.fallback {
+		for _, intent := range slices.Sorted(maps.Keys(s.cfg.Groups)) {
+			toolsets = append(toolsets, s.cfg.Groups[intent]...)
+		}
+	} else {
+		for _, intent := range turn.intents {
+			toolsets = append(toolsets, s.cfg.Groups[intent]...)
+		}
+	}
+
+	var tools []tool.Tool
+	seen := make(map[tool.Toolset]bool)
+	names := make(map[string]bool)
+	for _, ts := range toolsets {
+		// A toolset can belong to several groups.
+		if seen[ts] {
+			continue
+		}
+		seen[ts] = true
+		tsTools, err := ts.Tools(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the tools of toolset %q: %w", ts.Name(), err)
+		}
+		for _, t := range tsTools {
+			if !names[t.Name()] {
+				names[t.Name()] = true
+				tools = append(tools, t)
+			}
+		}
+	}
+
+	if !cached && s.cfg.OnDecision != nil {
+		s.cfg.OnDecision(ctx, Decision{
+			InvocationID: ctx.InvocationID(),
+			Intents:      turn.intents,
+			Fallback:     turn.fallback,
+			Err:          classifyErr,
+			Tools:        slices.Sorted(maps.Keys(names)),
+		})
+	}
+	return tools, nil
+}
+
+// classify returns the configured intents of the latest user message.
+func (s *toolset) classify(ctx agent.ReadonlyContext) (turnIntents, error) {
+	var texts []string
+	if c := ctx.UserContent(); c != nil {
+		for _, p := range c.Parts {
+			if p != nil && p.Text != "" && !p.Thought {
+				texts = append(texts, p.Text)
+			}
+		}
+	}
+	if len(texts) == 0 {
+		return turnIntents{fallback: true}, nil
+	}
+	intents, err := s.cfg.Classifier.Classify(ctx, strings.Join(texts, "\n"))
+	if err != nil {
+		return turnIntents{fallback: true}, err
+	}
+	var known []string
+	for _, intent := range intents {
+		if _, ok := s.cfg.Groups[intent]; ok && !slices.Contains(known, intent) {
+			known = append(known, intent)
+		}
+	}
+	slices.Sort(known)
+	return turnIntents{intents: known, fallback: len(known) == 0}, nil
+}
+
+func (s *toolset) cachedTurn(invocationID string) (turnIntents, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	turn, ok := s.turns[invocationID]
+	return turn, ok
+}
+
+func (s *toolset) cacheTurn(invocationID string, turn turnIntents) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.turns[invocationID]; ok {
+		return
+	}
+	s.turns[invocationID] = turn
+	s.turnsOrder = append(s.turnsOrder, invocationID)
+	if len(s.turnsOrder) > maxCachedTurns {
+		delete(s.turns, s.turnsOrder[0])
+		s.turnsOrder = s.turnsOrder[1:]
+	}
+}
@@ -0,0 +1,278 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package intenttoolset_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent"
+	icontext "google.golang.org/adk/internal/context"
+	"google.golang.org/adk/internal/testutil"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/intenttoolset"
+)
+
+type namedTool string
+
+func (t namedTool) Name() string        { return string(t) }
+func (t namedTool) Description() string { return "" }
+func (t namedTool) IsLongRunning() bool { return false }
+
+type staticToolset struct {
+	name  string
+	tools []tool.Tool
+}
+
+func (s *staticToolset) Name() string { return s.name }
+
+func (s *staticToolset) Tools(agent.ReadonlyContext) ([]tool.Tool, error) { return s.tools, nil }
+
+func newStaticToolset(name string, tools ...string) tool.Toolset {
+	s := &staticToolset{name: name}
+	for _, t := range tools {
+		s.tools = append(s.tools, namedTool(t))
+	}
+	return s
+}
+
+// countingClassifier counts the calls to a classifier.
+type countingClassifier struct {
+	intenttoolset.Classifier
+	calls int
+}
+
+func (c *countingClassifier) Classify(ctx context.Context, text string) ([]string, error) {
+	c.calls++
+	return c.Classifier.Classify(ctx, text)
+}
+
+type failingClassifier struct{}
+
+func (failingClassifier) Classify(context.Context, string) ([]string, error) {
+	return nil, errors.New("classifier is down")
+}
+
+var rules = intenttoolset.KeywordClassifier{
+	"billing": {"invoice", "refund", "bill"},
+	"account": {"password", "sign in"},
+}
+
+func newContext(t *testing.T, text string) agent.ReadonlyContext {
+	return icontext.NewReadonlyContext(icontext.NewInvocationContext(t.Context(), icontext.InvocationContextParams{
+		UserContent: genai.NewContentFromText(text, genai.RoleUser),
+	}))
+}
+
+func newToolset(t *testing.T, classifier intenttoolset.Classifier, decisions *[]intenttoolset.Decision) tool.Toolset {
+	t.Helper()
+	ts, err := intenttoolset.New(intenttoolset.Config{
+		Name:       "support",
+		Classifier: classifier,
+		Groups: map[string][]tool.Toolset{
+			"billing": {newStaticToolset("billing", "get_invoice", "refund")},
+			"account": {newStaticToolset("account", "reset_password")},
+		},
+		AlwaysOn: []tool.Toolset{newStaticToolset("common", "handoff")},
+		OnDecision: func(_ agent.ReadonlyContext, d intenttoolset.Decision) {
+			*decisions = append(*decisions, d)
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ts
+}
+
+func toolNames(t *testing.T, ts tool.Toolset, ctx agent.ReadonlyContext) []string {
+	t.Helper()
+	tools, err := ts.Tools(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, tl := range tools {
+		names = append(names, tl.Name())
+	}
+	return names
+}
+
+func TestKeywordClassifier(t *testing.T) {
+	for _, tc := range []struct {
+		text string
+		want []string
+	}{
+		{"Where is my INVOICE?", []string{"billing"}},
+		{"I can't sign in and I want a refund", []string{"account", "billing"}},
+		{"Can't sign-in", []string{"account"}},
+		{"A billion thanks", nil},
+		{"Hello", nil},
+	} {
+		got, err := rules.Classify(t.Context(), tc.text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(tc.want, got); diff != "" {
+			t.Errorf("Classify(%q) mismatch (-want +got):\n%s", tc.text, diff)
+		}
+	}
+}
+
+func TestToolset(t *testing.T) {
+	all := []string{"handoff", "reset_password", "get_invoice", "refund"}
+	for _, tc := range []struct {
+		name         string
+		classifier   intenttoolset.Classifier
+		text         string
+		want         []string
+		wantDecision intenttoolset.Decision
+		wantErr      string
+	}{
+		{
+			name:         "Billing",
+			classifier:   rules,
+			text:         "I want a refund",
+			want:         []string{"handoff", "get_invoice", "refund"},
+			wantDecision: intenttoolset.Decision{Intents: []string{"billing"}, Tools: []string{"get_invoice", "handoff", "refund"}},
+		},
+		{
+			name:         "NoIntent",
+			classifier:   rules,
+			text:         "Hello",
+			want:         all,
+			wantDecision: intenttoolset.Decision{Fallback: true, Tools: []string{"get_invoice", "handoff", "refund", "reset_password"}},
+		},
+		{
+			name:         "UnknownIntent",
+			classifier:   intenttoolset.KeywordClassifier{"shipping": {"parcel"}},
+			text:         "Where is my parcel?",
+			want:         all,
+			wantDecision: intenttoolset.Decision{Fallback: true, Tools: []string{"get_invoice", "handoff", "refund", "reset_password"}},
+		},
+		{
+			name:         "ClassifierError",
+			classifier:   failingClassifier{},
+			text:         "I want a refund",
+			want:         all,
+			wantDecision: intenttoolset.Decision{Fallback: true, Tools: []string{"get_invoice", "handoff", "refund", "reset_password"}},
+			wantErr:      "classifier is down",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var decisions []intenttoolset.Decision
+			ts := newToolset(t, tc.classifier, &decisions)
+			ctx := newContext(t, tc.text)
+			if diff := cmp.Diff(tc.want, toolNames(t, ts, ctx)); diff != "" {
+				t.Errorf("tools mismatch (-want +got):\n%s", diff)
+			}
+			tc.wantDecision.InvocationID = ctx.InvocationID()
+			if diff := cmp.Diff([]intenttoolset.Decision{tc.wantDecision}, decisions, cmpopts.IgnoreFields(intenttoolset.Decision{}, "Err")); diff != "" {
+				t.Errorf("decisions mismatch (-want +got):\n%s", diff)
+			}
+			if len(decisions) == 1 {
+				var gotErr string
+				if decisions[0].Err != nil {
+					gotErr = decisions[0].Err.Error()
+				}
+				if gotErr != tc.wantErr {
+					t.Errorf("decision error = %q, want %q", gotErr, tc.wantErr)
+				}
+			}
+		})
+	}
+}
+
+func TestToolset_PerTurnCache(t *testing.T) {
+	classifier := &countingClassifier{Classifier: rules}
+	var decisions []intenttoolset.Decision
+	ts := newToolset(t, classifier, &decisions)
+
+	turn := newContext(t, "Reset my password please")
+	for range 3 {
+		if diff := cmp.Diff([]string{"handoff", "reset_password"}, toolNames(t, ts, turn)); diff != "" {
+			t.Errorf("tools mismatch (-want +got):\n%s", diff)
+		}
+	}
+	if classifier.calls != 1 || len(decisions) != 1 {
+		t.Errorf("got %d classifications and %d decisions in a turn, want 1", classifier.calls, len(decisions))
+	}
+
+	next := newContext(t, "Send me the invoice")
+	if diff := cmp.Diff([]string{"handoff", "get_invoice", "refund"}, toolNames(t, ts, next)); diff != "" {
+		t.Errorf("tools of the next turn mismatch (-want +got):\n%s", diff)
+	}
+	if classifier.calls != 2 {
+		t.Errorf("got %d classifications in two turns, want 2", classifier.calls)
+	}
+}
+
+func TestModelClassifier(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		answer  string
+		want    []string
+		wantErr error
+	}{
+		{"Confident", `{"intents": ["account", "billing"], "confidence": 0.9}`, []string{"account", "billing"}, nil},
+		{"LowConfidence", `{"intents": ["billing"], "confidence": 0.3}`, nil, intenttoolset.ErrLowConfidence},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			llm := &testutil.MockModel{Responses: []*genai.Content{genai.NewContentFromText(tc.answer, genai.RoleModel)}}
+			classifier, err := intenttoolset.NewModelClassifier(intenttoolset.ModelClassifierConfig{
+				Model:         llm,
+				Intents:       map[string]string{"billing": "invoices and payments", "account": "sign-in and profile"},
+				MinConfidence: 0.5,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := classifier.Classify(t.Context(), "I was charged twice and locked out")
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("Classify() error = %v, want %v", err, tc.wantErr)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Classify() mismatch (-want +got):\n%s", diff)
+			}
+			req := llm.Requests[0]
+			if req.Config.ResponseSchema == nil || req.Config.ResponseMIMEType != "application/json" {
+				t.Errorf("request config = %+v, want a JSON response schema", req.Config)
+			}
+		})
+	}
+
+	// A low confidence makes the toolset expose all the tools.
+	llm := &testutil.MockModel{Responses: []*genai.Content{genai.NewContentFromText(`{"intents": ["billing"], "confidence": 0.1}`, genai.RoleModel)}}
+	classifier, err := intenttoolset.NewModelClassifier(intenttoolset.ModelClassifierConfig{
+		Model:         llm,
+		Intents:       map[string]string{"billing": "invoices and payments", "account": "sign-in and profile"},
+		MinConfidence: 0.5,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decisions []intenttoolset.Decision
+	ts := newToolset(t, classifier, &decisions)
+	if diff := cmp.Diff([]string{"handoff", "reset_password", "get_invoice", "refund"}, toolNames(t, ts, newContext(t, "hmm"))); diff != "" {
+		t.Errorf("tools mismatch (-want +got):\n%s", diff)
+	}
+	if len(decisions) != 1 || !decisions[0].Fallback || !errors.Is(decisions[0].Err, intenttoolset.ErrLowConfidence) {
+		t.Errorf("decisions = %+v, want a fallback with ErrLowConfidence", decisions)
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loadartifactstool_test
+
+import (
+	"testing"
+
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/internal/testutil/golden"
+	"google.golang.org/adk/internal/toolinternal"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/tool/loadartifactstool"
+)
+
+func TestLoadArtifactsTool_GoldenPackedRequest(t *testing.T) {
+	tc := createToolContext(t)
+	for name, part := range map[string]*genai.Part{
+		"report.pdf": genai.NewPartFromBytes([]byte("%PDF-1.7 report"), "application/pdf"),
+		"notes.txt":  genai.NewPartFromText("meeting notes"),
+	} {
+		if _, err := tc.Artifacts().Save(t.Context(), name, part); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The model asked for an artifact: its content is appended to the
+	// request.
+	req := &model.LLMRequest{
+		Model: "gemini-2.5-flash",
+		Contents: []*genai.Content{
+			genai.NewContentFromText("Summarize the report", genai.RoleUser),
+			genai.NewContentFromFunctionCall("load_artifacts", map[string]any{"artifact_names": []any{"report.pdf"}}, genai.RoleModel),
+			{
+				Role: genai.RoleUser,
+				Parts: []*genai.Part{{FunctionResponse: &genai.FunctionResponse{
+					ID:       "adk-0d1e2f",
+					Name:     "load_artifacts",
+					Response: map[string]any{"artifact_names": []string{"report.pdf"}},
+				}}},
+			},
+		},
+	}
+	if err := loadartifactstool.New().(toolinternal.RequestProcessor).ProcessRequest(tc, req); err != nil {
+		t.Fatal(err)
+	}
+	golden.PackedRequest(t, req)
+}
@@ -20,29 +20,79 @@ package loadartifactstool
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
+	"strings"
+	"sync"
+	"time"
 
-	"golang.org/x/sync/errgroup"
 	"google.golang.org/genai"
 
 	"google.golang.org/adk/agent"
 	"google.golang.org/adk/internal/toolinternal/toolutils"
 	"google.golang.org/adk/internal/utils"
 	"google.golang.org/adk/model"
+	"google.golang.org/adk/model/assembly"
+	"google.golang.org/adk/strictness"
 	"google.golang.org/adk/tool"
 )
 
+// FailuresStateKey is the session state key under which the artifacts that
+// recently failed to load are recorded.
+const FailuresStateKey = "_adk_load_artifacts_failures"
+
+// Config is the configuration of the tool created by [NewWithConfig].
+type Config struct {
+	// FailureThreshold is the number of failed loads of an artifact after
+	// which it is no longer loaded nor advertised to the model, until
+	// FailureTTL elapses. Defaults to 2.
+	FailureThreshold int
+	// FailureTTL is how long an artifact that reached FailureThreshold is
+	// skipped. Defaults to 10 minutes.
+	FailureTTL time.Duration
+	// ListFailureThreshold is the number of consecutive failures to list the
+	// artifacts after which the tool stops failing the model calls: the
+	// artifacts are not advertised and the model is told that they are
+	// unavailable, until the listing succeeds again. Defaults to 3.
+	ListFailureThreshold int
+	// Now returns the current time. Defaults to time.Now.
+	Now func() time.Time
+}
+
 // artifactsTool is a tool that loads artifacts and adds them to the session.
 type artifactsTool struct {
 	name        string
 	description string
+	cfg         Config
+
+	mu           sync.Mutex
+	listFailures int
 }
 
 // New creates a new loadArtifactsTool.
 func New() tool.Tool {
+	return NewWithConfig(Config{})
+}
+
+// NewWithConfig creates a new loadArtifactsTool configured by cfg.
+func NewWithConfig(cfg Config) tool.Tool {
+	if cfg.FailureThreshold <= 0 {
+		cfg.FailureThreshold = 2
+	}
+	if cfg.FailureTTL <= 0 {
+		cfg.FailureTTL = 10 * time.Minute
+	}
+	if cfg.ListFailureThreshold <= 0 {
+		cfg.ListFailureThreshold = 3
+	}
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
 	return &artifactsTool{
 		name:        "load_artifacts",
 		description: "Loads the artifacts and adds them to the session.",
+		cfg:         cfg,
 	}
 }
 
@@ -118,7 +168,7 @@ func (t *artifactsTool) Run(ctx tool.Context, args any) (map[string]any, error)
 // ProcessRequest processes the LLM request. It packs the tool, appends initial
 // instructions, and processes any load artifacts function calls.
 func (t *artifactsTool) ProcessRequest(ctx tool.Context, req *model.LLMRequest) error {
-	if err := toolutils.PackTool(req, t); err != nil {
+	if err := toolutils.PackTool(ctx, req, t); err != nil {
 		return err
 	}
 	if err := t.appendInitialInstructions(ctx, req); err != nil {
@@ -130,12 +180,45 @@ func (t *artifactsTool) ProcessRequest(ctx tool.Context, req *model.LLMRequest)
 func (t *artifactsTool) appendInitialInstructions(ctx tool.Context, req *model.LLMRequest) error {
 	resp, err := ctx.Artifacts().List(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to list artifacts: %w", err)
-	}
-	if len(resp.FileNames) == 0 {
+		if !t.listFailed() {
+			return fmt.Errorf("failed to list artifacts: %w", err)
+		}
+		if err := strictness.Report(ctx, strictness.Artifacts, "loadartifactstool", "failed to list artifacts: %v", err); err != nil {
+			return err
+		}
+		utils.AppendInstructions(req, "The artifacts are temporarily unavailable: do not call"+
+			" the `load_artifacts` function, and tell the user if they ask about an artifact.")
 		return nil
 	}
-	artifactNamesJSON, err := json.Marshal(resp.FileNames)
+	t.listSucceeded()
+
+	failures := t.failures(ctx)
+	var available, unavailable []string
+	for _, name := range resp.FileNames {
+		if failures.skipped(name, t.cfg.Now()) {
+			unavailable = append(unavailable, name)
+		} else {
+			available = append(available, name)
+		}
+	}
+	if len(available) > 0 {
+		if err := t.advertise(req, available); err != nil {
+			return err
+		}
+	}
+	if len(unavailable) > 0 {
+		unavailableJSON, err := json.Marshal(unavailable)
+		if err != nil {
+			return fmt.Errorf("failed to marshal artifact names: %w", err)
+		}
+		utils.AppendInstructions(req, fmt.Sprintf("The following artifacts could not be loaded"+
+			" recently and are unavailable:\n  %s", unavailableJSON))
+	}
+	return nil
+}
+
+func (t *artifactsTool) advertise(req *model.LLMRequest, artifactNames []string) error {
+	artifactNamesJSON, err := json.Marshal(artifactNames)
 	if err != nil {
 		return fmt.Errorf("failed to marshal artifact names: %w", err)
 	}
@@ -181,27 +264,64 @@ func (t *artifactsTool) processLoadArtifactsFunctionCall(ctx tool.Context, req *
 		return nil
 	}
 
+	// The artifacts failing to load are reported to the model, instead of
+	// failing the request: they may have been deleted from the store.
+	failures := t.failures(ctx)
+	now := t.cfg.Now()
 	results := make([]*genai.Content, len(artifactNames))
-	group, childCtx := errgroup.WithContext(ctx)
+	errs := make([]error, len(artifactNames))
+	loaded := make([]bool, len(artifactNames))
+	var wg sync.WaitGroup
 	artifactsService := ctx.Artifacts()
 
 	for i, artifactName := range artifactNames {
-		group.Go(func() error {
-			// Although not used, we need to pass childCtx for early return in case of an error.
-			content, err := t.loadIndividualArtifact(childCtx, artifactsService, artifactName)
-			if err != nil {
-				return fmt.Errorf("failed to load artifact %s: %w", artifactName, err)
+		if failures.skipped(artifactName, now) {
+			if err := strictness.Report(ctx, strictness.Artifacts, "loadartifactstool", "artifact %q is unavailable: %s", artifactName, failures[artifactName].Reason); err != nil {
+				wg.Wait()
+				return err
 			}
-			results[i] = content
-			return nil
-		})
+			results[i] = unavailableContent(artifactName, failures[artifactName].Reason)
+			continue
+		}
+		loaded[i] = true
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], errs[i] = t.loadIndividualArtifact(ctx, artifactsService, artifactName)
+		}()
 	}
+	wg.Wait()
 
-	if err := group.Wait(); err != nil {
-		return err
+	changed := false
+	for i, artifactName := range artifactNames {
+		switch {
+		case errs[i] != nil:
+			reason := "the artifact store failed"
+			if errors.Is(errs[i], fs.ErrNotExist) {
+				reason = "not found"
+			}
+			if err := strictness.Report(ctx, strictness.Artifacts, "loadartifactstool", "artifact %q could not be loaded: %v", artifactName, errs[i]); err != nil {
+				return err
+			}
+			results[i] = unavailableContent(artifactName, reason)
+			failures.record(artifactName, reason, now, t.cfg)
+			changed = true
+		case loaded[i]:
+			if _, ok := failures[artifactName]; ok {
+				delete(failures, artifactName)
+				changed = true
+			}
+		}
+	}
+	if changed {
+		if err := ctx.State().Set(FailuresStateKey, failures.encode()); err != nil {
+			return err
+		}
 	}
 
+	done := assembly.Step(req, "loadartifactstool", "inject artifacts", strings.Join(artifactNames, ", "))
 	req.Contents = append(req.Contents, results...)
+	done()
 	return nil
 }
 
@@ -218,3 +338,79 @@ func (t *artifactsTool) loadIndividualArtifact(ctx context.Context, artifactsSer
 		Role: genai.RoleUser,
 	}, nil
 }
+
+func unavailableContent(artifactName, reason string) *genai.Content {
+	return genai.NewContentFromText(fmt.Sprintf("artifact %s could not be loaded: %s", artifactName, reason), genai.RoleUser)
+}
+
+// listFailed records a failure to list the artifacts, and reports whether
+// the failures are persistent.
+func (t *artifactsTool) listFailed() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.listFailures++
+	return t.listFailures >= t.cfg.ListFailureThreshold
+}
+
+func (t *artifactsTool) listSucceeded() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.listFailures = 0
+}
+
+// loadFailure records the failed loads of an artifact in the session state.
+type loadFailure struct {
+	Failures int    `json:"failures"`
+	Reason   string `json:"reason"`
+	// Until is the Unix time until which the artifact is skipped, if it
+	// reached the failure threshold.
+	Until int64 `json:"until,omitempty"`
+}
+
+type loadFailures map[string]loadFailure
+
+// failures returns the load failures recorded in the session state.
+// Invalid records are ignored.
+func (t *artifactsTool) failures(ctx tool.Context) loadFailures {
+	failures := make(loadFailures)
+	v, err := ctx.State().Get(FailuresStateKey)
+	if err != nil {
+		return failures
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return failures
+	}
+	_ = json.Unmarshal(b, &failures)
+	return failures
+}
+
+// skipped reports whether the artifact is negatively cached at now.
+func (f loadFailures) skipped(artifactName string, now time.Time) bool {
+	failure, ok := f[artifactName]
+	return ok && failure.Until > now.Unix()
+}
+
+func (f loadFailures) record(artifactName, reason string, now time.Time, cfg Config) {
+	failure := f[artifactName]
+	failure.Failures++
+	failure.Reason = reason
+	if failure.Failures >= cfg.FailureThreshold {
+		failure.Until = now.Add(cfg.FailureTTL).Unix()
+	}
+	f[artifactName] = failure
+}
+
+// encode returns the failures as stored in the session state, i.e. as they
+// are read back from JSON.
+func (f loadFailures) encode() map[string]any {
+	m := make(map[string]any, len(f))
+	for name, failure := range f {
+		entry := map[string]any{"failures": float64(failure.Failures), "reason": failure.Reason}
+		if failure.Until != 0 {
+			entry["until"] = float64(failure.Until)
+		}
+		m[name] = entry
+	}
+	return m
+}
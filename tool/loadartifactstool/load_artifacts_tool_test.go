@@ -15,17 +15,27 @@
 package loadartifactstool_test
 
 import (
+	"context"
+	"errors"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"google.golang.org/genai"
 
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/llmagent"
 	"google.golang.org/adk/artifact"
 	artifactinternal "google.golang.org/adk/internal/artifact"
 	icontext "google.golang.org/adk/internal/context"
+	"google.golang.org/adk/internal/sessioninternal"
+	"google.golang.org/adk/internal/testutil"
 	"google.golang.org/adk/internal/toolinternal"
 	"google.golang.org/adk/model"
+	"google.golang.org/adk/runner"
+	"google.golang.org/adk/session"
 	"google.golang.org/adk/tool"
 	"google.golang.org/adk/tool/loadartifactstool"
 )
@@ -279,17 +289,267 @@ func TestLoadArtifactsTool_ProcessRequest_Artifacts_OtherFunctionCall(t *testing
 
 func createToolContext(t *testing.T) tool.Context {
 	t.Helper()
+	return createToolContextWithService(t, artifact.InMemoryService())
+}
+
+func createToolContextWithService(t *testing.T, service artifact.Service) tool.Context {
+	t.Helper()
 
 	artifacts := &artifactinternal.Artifacts{
-		Service:   artifact.InMemoryService(),
+		Service:   service,
 		AppName:   "app",
 		UserID:    "user",
 		SessionID: "session",
 	}
+	sessionService := session.InMemoryService()
+	resp, err := sessionService.Create(t.Context(), &session.CreateRequest{AppName: "app", UserID: "user", SessionID: "session"})
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	ctx := icontext.NewInvocationContext(t.Context(), icontext.InvocationContextParams{
 		Artifacts: artifacts,
+		Session:   sessioninternal.NewMutableSession(sessionService, resp.Session),
 	})
 
 	return toolinternal.NewToolContext(ctx, "", nil)
 }
+
+// flakyService is an artifact service whose loads of the broken artifacts
+// and lists can be made to fail.
+type flakyService struct {
+	artifact.Service
+	broken    map[string]bool
+	listFails bool
+
+	// mu guards loads, the artifacts being loaded concurrently.
+	mu    sync.Mutex
+	loads map[string]int
+}
+
+func newFlakyService(broken ...string) *flakyService {
+	s := &flakyService{Service: artifact.InMemoryService(), broken: make(map[string]bool), loads: make(map[string]int)}
+	for _, name := range broken {
+		s.broken[name] = true
+	}
+	return s
+}
+
+func (s *flakyService) Load(ctx context.Context, req *artifact.LoadRequest) (*artifact.LoadResponse, error) {
+	s.mu.Lock()
+	s.loads[req.FileName]++
+	s.mu.Unlock()
+	if s.broken[req.FileName] {
+		return nil, errors.New("permission denied")
+	}
+	return s.Service.Load(ctx, req)
+}
+
+// loadCount returns the number of loads of the artifact name.
+func (s *flakyService) loadCount(name string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.loads[name]
+}
+
+func (s *flakyService) List(ctx context.Context, req *artifact.ListRequest) (*artifact.ListResponse, error) {
+	if s.listFails {
+		return nil, errors.New("store unavailable")
+	}
+	return s.Service.List(ctx, req)
+}
+
+// loadRequest returns a request answering a call to load_artifacts with
+// names.
+func loadRequest(names ...string) *model.LLMRequest {
+	return &model.LLMRequest{Contents: []*genai.Content{{
+		Role:  genai.RoleUser,
+		Parts: []*genai.Part{genai.NewPartFromFunctionResponse("load_artifacts", map[string]any{"artifact_names": names})},
+	}}}
+}
+
+// texts returns the first text of the contents appended to req.
+func texts(req *model.LLMRequest) []string {
+	var got []string
+	for _, c := range req.Contents[1:] {
+		got = append(got, c.Parts[0].Text)
+	}
+	return got
+}
+
+// saveArtifacts saves artifacts in service, as a previous invocation would:
+// the artifacts saved through the tool context are served from memory.
+func saveArtifacts(t *testing.T, service artifact.Service, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		req := &artifact.SaveRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: name, Part: genai.NewPartFromText("content of " + name)}
+		if _, err := service.Save(t.Context(), req); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestLoadArtifactsTool_ProcessRequest_PartialFailure(t *testing.T) {
+	service := newFlakyService("broken.txt")
+	tc := createToolContextWithService(t, service)
+	saveArtifacts(t, service, "doc1.txt", "broken.txt")
+
+	req := loadRequest("doc1.txt", "missing.txt", "broken.txt")
+	if err := loadartifactstool.New().(toolinternal.RequestProcessor).ProcessRequest(tc, req); err != nil {
+		t.Fatalf("ProcessRequest failed: %v", err)
+	}
+	want := []string{
+		"Artifact doc1.txt is:",
+		"artifact missing.txt could not be loaded: not found",
+		"artifact broken.txt could not be loaded: the artifact store failed",
+	}
+	if diff := cmp.Diff(want, texts(req)); diff != "" {
+		t.Errorf("appended contents mismatch (-want +got):\n%s", diff)
+	}
+	if got := req.Contents[1].Parts[1].Text; got != "content of doc1.txt" {
+		t.Errorf("content of doc1.txt = %q", got)
+	}
+
+	failures, err := tc.State().Get(loadartifactstool.FailuresStateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantFailures := map[string]any{
+		"missing.txt": map[string]any{"failures": float64(1), "reason": "not found"},
+		"broken.txt":  map[string]any{"failures": float64(1), "reason": "the artifact store failed"},
+	}
+	if diff := cmp.Diff(wantFailures, failures); diff != "" {
+		t.Errorf("recorded failures mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestLoadArtifactsTool_ProcessRequest_NegativeCache(t *testing.T) {
+	service := newFlakyService("broken.txt")
+	tc := createToolContextWithService(t, service)
+	saveArtifacts(t, service, "doc1.txt", "broken.txt")
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	processor := loadartifactstool.NewWithConfig(loadartifactstool.Config{
+		FailureThreshold: 2,
+		FailureTTL:       time.Minute,
+		Now:              func() time.Time { return now },
+	}).(toolinternal.RequestProcessor)
+
+	process := func(t *testing.T, names ...string) *model.LLMRequest {
+		t.Helper()
+		req := loadRequest(names...)
+		if err := processor.ProcessRequest(tc, req); err != nil {
+			t.Fatalf("ProcessRequest failed: %v", err)
+		}
+		return req
+	}
+	instruction := func(req *model.LLMRequest) string {
+		var b strings.Builder
+		for _, p := range req.Config.SystemInstruction.Parts {
+			b.WriteString(p.Text + "\n")
+		}
+		return b.String()
+	}
+
+	// Below the threshold, the broken artifact is still advertised and loaded.
+	req := process(t, "broken.txt")
+	if !strings.Contains(instruction(req), `["broken.txt","doc1.txt"]`) {
+		t.Errorf("instruction = %q, want both artifacts advertised", instruction(req))
+	}
+	process(t, "broken.txt")
+
+	// The threshold is reached: the artifact is skipped and not advertised.
+	req = process(t, "broken.txt", "doc1.txt")
+	if service.loadCount("broken.txt") != 2 {
+		t.Errorf("loads of broken.txt = %d, want 2", service.loadCount("broken.txt"))
+	}
+	want := []string{"artifact broken.txt could not be loaded: the artifact store failed", "Artifact doc1.txt is:"}
+	if diff := cmp.Diff(want, texts(req)); diff != "" {
+		t.Errorf("appended contents mismatch (-want +got):\n%s", diff)
+	}
+	if got := instruction(req); !strings.Contains(got, "could not be loaded recently and are unavailable:\n  [\"broken.txt\"]") || !strings.Contains(got, "You have a list of artifacts:\n  [\"doc1.txt\"]") {
+		t.Errorf("instruction = %q, want broken.txt annotated and omitted", got)
+	}
+
+	// After the TTL, the load is retried and succeeds.
+	now = now.Add(2 * time.Minute)
+	delete(service.broken, "broken.txt")
+	req = process(t, "broken.txt")
+	if service.loadCount("broken.txt") != 3 {
+		t.Errorf("loads of broken.txt = %d, want 3", service.loadCount("broken.txt"))
+	}
+	if diff := cmp.Diff([]string{"Artifact broken.txt is:"}, texts(req)); diff != "" {
+		t.Errorf("appended contents after the TTL mismatch (-want +got):\n%s", diff)
+	}
+	if failures, _ := tc.State().Get(loadartifactstool.FailuresStateKey); len(failures.(map[string]any)) != 0 {
+		t.Errorf("recorded failures = %v, want none", failures)
+	}
+}
+
+func TestLoadArtifactsTool_ProcessRequest_DegradedList(t *testing.T) {
+	service := newFlakyService()
+	tc := createToolContextWithService(t, service)
+	saveArtifacts(t, service, "doc1.txt")
+	processor := loadartifactstool.NewWithConfig(loadartifactstool.Config{ListFailureThreshold: 2}).(toolinternal.RequestProcessor)
+
+	service.listFails = true
+	if err := processor.ProcessRequest(tc, &model.LLMRequest{}); err == nil {
+		t.Error("ProcessRequest succeeded, want the list error below the threshold")
+	}
+	for range 2 {
+		req := &model.LLMRequest{}
+		if err := processor.ProcessRequest(tc, req); err != nil {
+			t.Fatalf("ProcessRequest in degraded mode failed: %v", err)
+		}
+		if got := req.Config.SystemInstruction.Parts[0].Text; !strings.Contains(got, "The artifacts are temporarily unavailable") {
+			t.Errorf("instruction in degraded mode = %q, want a warning", got)
+		}
+	}
+
+	service.listFails = false
+	req := &model.LLMRequest{}
+	if err := processor.ProcessRequest(tc, req); err != nil {
+		t.Fatalf("ProcessRequest failed: %v", err)
+	}
+	if got := req.Config.SystemInstruction.Parts[0].Text; !strings.Contains(got, `["doc1.txt"]`) {
+		t.Errorf("instruction after recovery = %q, want the artifacts advertised", got)
+	}
+	// The failures are counted again from zero.
+	service.listFails = true
+	if err := processor.ProcessRequest(tc, &model.LLMRequest{}); err == nil {
+		t.Error("ProcessRequest succeeded, want the list error after recovery")
+	}
+}
+
+func TestLoadArtifactsTool_FailuresPersisted(t *testing.T) {
+	llm := &testutil.MockModel{Responses: []*genai.Content{
+		genai.NewContentFromFunctionCall("load_artifacts", map[string]any{"artifact_names": []any{"missing.txt"}}, genai.RoleModel),
+		genai.NewContentFromText("It is gone.", genai.RoleModel),
+	}}
+	a, err := llmagent.New(llmagent.Config{Name: "agent", Model: llm, Tools: []tool.Tool{loadartifactstool.New()}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessionService := session.InMemoryService()
+	r, err := runner.New(runner.Config{AppName: "app", Agent: a, SessionService: sessionService, ArtifactService: artifact.InMemoryService()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sessionService.Create(t.Context(), &session.CreateRequest{AppName: "app", UserID: "user", SessionID: "session"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := testutil.CollectEvents(r.Run(t.Context(), "user", "session", genai.NewContentFromText("show missing.txt", genai.RoleUser), agent.RunConfig{})); err != nil {
+		t.Fatal(err)
+	}
+
+	last := llm.Requests[len(llm.Requests)-1].Contents
+	if got := last[len(last)-1].Parts[0].Text; got != "artifact missing.txt could not be loaded: not found" {
+		t.Errorf("last content = %q, want the load failure", got)
+	}
+	resp, err := sessionService.Get(t.Context(), &session.GetRequest{AppName: "app", UserID: "user", SessionID: "session"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := resp.Session.State().Get(loadartifactstool.FailuresStateKey); err != nil {
+		t.Errorf("failures not saved in the session state: %v", err)
+	}
+}
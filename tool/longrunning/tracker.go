@@ -0,0 +1,304 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package longrunning tracks the operations started by long-running tools,
+// and notifies webhooks of their completion.
+//
+// A long-running tool registers its operation in a [Tracker] when it starts
+// it. Whatever watches the operation, e.g. a poller of the remote job, marks
+// it completed or failed with [Tracker.Complete] or [Tracker.Fail]. If the
+// operation has a [Webhook], the tracker then posts a [Payload] to it in the
+// background, with retries, so that the application is notified even if the
+// user left the conversation.
+package longrunning
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"sync"
+	"text/template"
+	"time"
+
+	"google.golang.org/adk/httpx"
+	"google.golang.org/adk/runtimedeps"
+)
+
+// ErrUnknownOperation is returned for an operation ID that is not tracked.
+var ErrUnknownOperation = errors.New("unknown operation")
+
+// Status is the status of an operation.
+type Status string
+
+const (
+	StatusRunning   Status = "running"
+	StatusCompleted Status = "completed"
+	StatusFailed    Status = "failed"
+)
+
+// DeliveryState is the state of the webhook delivery of an operation.
+type DeliveryState string
+
+const (
+	// DeliveryNone means that there is nothing to deliver yet: the
+	// operation is running, or has no webhook.
+	DeliveryNone DeliveryState = ""
+	// DeliveryPending means that the delivery is in progress, possibly
+	// waiting before a retry.
+	DeliveryPending DeliveryState = "pending"
+	// DeliveryDelivered means that the webhook accepted the payload.
+	DeliveryDelivered DeliveryState = "delivered"
+	// DeliveryDeadLettered means that the delivery failed for good: the
+	// attempts were exhausted or the webhook rejected the payload.
+	DeliveryDeadLettered DeliveryState = "dead_lettered"
+)
+
+// SessionRef identifies the session that started an operation.
+type SessionRef struct {
+	AppName   string `json:"appName"`
+	UserID    string `json:"userId"`
+	SessionID string `json:"sessionId"`
+}
+
+// Webhook is the target notified of the completion of an operation.
+type Webhook struct {
+	// URL is a text/template executed with the [Operation], e.g.
+	// "https://example.com/ops/{{.ID}}?tool={{.ToolName}}".
+	URL string
+	// Secret, if set, signs the payloads: the SignatureHeader header holds
+	// the HMAC-SHA256 of the body, see [Sign] and [Verify].
+	Secret []byte
+	// Headers are added to the requests.
+	Headers map[string]string
+}
+
+// Delivery is the webhook delivery status of an operation.
+type Delivery struct {
+	State DeliveryState
+	// Attempts is the number of requests sent to the webhook.
+	Attempts int
+	// LastError is the error of the last failed attempt.
+	LastError string
+	// DeliveredAt is the time the webhook accepted the payload.
+	DeliveredAt time.Time
+}
+
+// Operation is the record of an operation.
+type Operation struct {
+	// ID identifies the operation, e.g. the resource id returned by the tool.
+	ID       string
+	ToolName string
+	Session  SessionRef
+	Status   Status
+	// Result is the result of a completed operation.
+	Result map[string]any
+	// Error is the error of a failed operation.
+	Error string
+	// Webhook is notified when the operation completes or fails.
+	// Optional.
+	Webhook  *Webhook
+	Delivery Delivery
+
+	StartedAt, FinishedAt time.Time
+}
+
+// Config is the configuration of a [Tracker].
+type Config struct {
+	// Client sends the webhook requests.
+	// Optional: if nil, httpx.DefaultClient is used, which propagates the
+	// session of the operation in the headers of the requests.
+	Client *http.Client
+	// MaxAttempts bounds the number of requests of a delivery. Defaults to 5.
+	MaxAttempts int
+	// Backoff is the delay before the first retry; it doubles with every
+	// retry. Defaults to one second.
+	Backoff time.Duration
+	// MaxBackoff caps the delay between retries. Defaults to one minute.
+	MaxBackoff time.Duration
+	// Timeout bounds every request. Defaults to 30 seconds.
+	Timeout time.Duration
+	// MaxPayloadBytes caps the size of the payloads: the result of an
+	// operation is left out of a larger payload, which is marked truncated.
+	// Defaults to 64 KiB.
+	MaxPayloadBytes int
+	// RedactFields are the names of the result fields, at any depth, whose
+	// values are replaced by "[REDACTED]" in the payloads.
+	RedactFields []string
+	// DeadLetter is called with the operation and the last error when its
+	// delivery fails for good.
+	// Optional.
+	DeadLetter func(op Operation, err error)
+	// Deps tell the time of the operations and wait between the retries.
+	// Optional: if nil, the real ones are used.
+	Deps *runtimedeps.Deps
+}
+
+// Tracker tracks the operations and delivers their completion
+// notifications.
+type Tracker struct {
+	cfg    Config
+	redact map[string]bool
+
+	mu  sync.Mutex
+	ops map[string]*Operation
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewTracker returns a Tracker with the given configuration.
+func NewTracker(cfg Config) *Tracker {
+	if cfg.Client == nil {
+		cfg.Client = httpx.DefaultClient
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 5
+	}
+	if cfg.Backoff <= 0 {
+		cfg.Backoff = time.Second
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = time.Minute
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 30 * time.Second
+	}
+	if cfg.MaxPayloadBytes <= 0 {
+		cfg.MaxPayloadBytes = 64 << 10
+	}
+	redact := make(map[string]bool, len(cfg.RedactFields))
+	for _, f := range cfg.RedactFields {
+		redact[f] = true
+	}
+	if cfg.Deps == nil {
+		cfg.Deps = runtimedeps.Real()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Tracker{cfg: cfg, redact: redact, ops: make(map[string]*Operation), ctx: ctx, cancel: cancel}
+}
+
+// Register starts tracking a running operation. The ID and the tool name
+// are required, and the URL template of the webhook, if any, must be valid.
+func (t *Tracker) Register(op Operation) error {
+	if op.ID == "" || op.ToolName == "" {
+		return errors.New("operation ID and tool name are required")
+	}
+	if op.Webhook != nil {
+		if _, err := template.New("url").Parse(op.Webhook.URL); err != nil {
+			return fmt.Errorf("invalid webhook URL template of operation %q: %w", op.ID, err)
+		}
+		wh := *op.Webhook
+		wh.Headers = maps.Clone(wh.Headers)
+		op.Webhook = &wh
+	}
+	op.Status = StatusRunning
+	op.Result, op.Error, op.Delivery = nil, "", Delivery{}
+	if op.StartedAt.IsZero() {
+		op.StartedAt = t.cfg.Deps.Now()
+	}
+	op.FinishedAt = time.Time{}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, ok := t.ops[op.ID]; ok {
+		return fmt.Errorf("operation %q is already registered", op.ID)
+	}
+	t.ops[op.ID] = &op
+	return nil
+}
+
+// Get returns the record of the operation.
+func (t *Tracker) Get(id string) (Operation, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	op, ok := t.ops[id]
+	if !ok {
+		return Operation{}, fmt.Errorf("%w: %q", ErrUnknownOperation, id)
+	}
+	return *op, nil
+}
+
+// Complete marks the operation completed with the given result, and
+// notifies its webhook.
+func (t *Tracker) Complete(id string, result map[string]any) error {
+	return t.finish(id, StatusCompleted, result, "")
+}
+
+// Fail marks the operation failed with the given error, and notifies its
+// webhook.
+func (t *Tracker) Fail(id string, opErr error) error {
+	msg := "operation failed"
+	if opErr != nil {
+		msg = opErr.Error()
+	}
+	return t.finish(id, StatusFailed, nil, msg)
+}
+
+func (t *Tracker) finish(id string, status Status, result map[string]any, errMsg string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	op, ok := t.ops[id]
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownOperation, id)
+	}
+	if op.Status != StatusRunning {
+		return fmt.Errorf("operation %q is already %s", id, op.Status)
+	}
+	op.Status, op.Result, op.Error, op.FinishedAt = status, result, errMsg, t.cfg.Deps.Now()
+	if op.Webhook == nil {
+		return nil
+	}
+	if t.ctx.Err() != nil {
+		return fmt.Errorf("tracker is shut down: the completion of operation %q is not delivered", id)
+	}
+	op.Delivery.State = DeliveryPending
+	t.wg.Add(1)
+	go func(snapshot Operation) {
+		defer t.wg.Done()
+		t.deliver(snapshot)
+	}(*op)
+	return nil
+}
+
+// Shutdown waits for the deliveries in progress. If ctx is done first, the
+// remaining deliveries are dead-lettered and ctx.Err() is returned. The
+// completions reported after Shutdown are not delivered.
+func (t *Tracker) Shutdown(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		t.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		t.cancel()
+		return nil
+	case <-ctx.Done():
+		t.cancel()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// updateDelivery applies update to the delivery status of the operation.
+func (t *Tracker) updateDelivery(id string, update func(*Delivery)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if op, ok := t.ops[id]; ok {
+		update(&op.Delivery)
+	}
+}
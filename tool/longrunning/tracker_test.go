@@ -0,0 +1,341 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package longrunning_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	"google.golang.org/adk/httpx"
+	"google.golang.org/adk/tool/longrunning"
+)
+
+type request struct {
+	Path      string
+	Header    http.Header
+	Body      []byte
+	Signature string
+}
+
+// receiver is a webhook receiver answering with the given statuses in
+// order, then with 200.
+type receiver struct {
+	mu       sync.Mutex
+	statuses []int
+	requests []request
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests = append(r.requests, request{Path: req.URL.RequestURI(), Header: req.Header, Body: body, Signature: req.Header.Get(longrunning.SignatureHeader)})
+	status := http.StatusOK
+	if len(r.requests) <= len(r.statuses) {
+		status = r.statuses[len(r.requests)-1]
+	}
+	w.WriteHeader(status)
+}
+
+func newTracker(t *testing.T, cfg longrunning.Config) *longrunning.Tracker {
+	t.Helper()
+	cfg.Backoff = time.Millisecond
+	tracker := longrunning.NewTracker(cfg)
+	t.Cleanup(func() { _ = tracker.Shutdown(t.Context()) })
+	return tracker
+}
+
+func register(t *testing.T, tracker *longrunning.Tracker, url string, secret string) {
+	t.Helper()
+	err := tracker.Register(longrunning.Operation{
+		ID:       "op-1",
+		ToolName: "export",
+		Session:  longrunning.SessionRef{AppName: "app", UserID: "user", SessionID: "session"},
+		Webhook: &longrunning.Webhook{
+			URL:     url + "/ops/{{.ID}}?tool={{.ToolName}}&status={{.Status}}",
+			Secret:  []byte(secret),
+			Headers: map[string]string{"X-Tenant": "acme"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTracker_Delivered(t *testing.T) {
+	rcv := &receiver{}
+	srv := httptest.NewServer(rcv)
+	defer srv.Close()
+	tracker := newTracker(t, longrunning.Config{RedactFields: []string{"token"}})
+	register(t, tracker, srv.URL, "")
+
+	if err := tracker.Complete("op-1", map[string]any{"file": "export.csv", "rows": 3, "auth": map[string]any{"token": "secret"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := tracker.Complete("op-1", nil); err == nil {
+		t.Error("Complete() of a completed operation succeeded, want an error")
+	}
+	if err := tracker.Shutdown(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(rcv.requests) != 1 {
+		t.Fatalf("receiver got %d requests, want 1", len(rcv.requests))
+	}
+	req := rcv.requests[0]
+	if want := "/ops/op-1?tool=export&status=completed"; req.Path != want {
+		t.Errorf("request path = %q, want %q", req.Path, want)
+	}
+	if got := req.Header.Get("X-Tenant"); got != "acme" {
+		t.Errorf("X-Tenant header = %q, want %q", got, "acme")
+	}
+	if req.Signature != "" {
+		t.Errorf("unsigned request has signature %q", req.Signature)
+	}
+	var got longrunning.Payload
+	if err := json.Unmarshal(req.Body, &got); err != nil {
+		t.Fatal(err)
+	}
+	want := longrunning.Payload{
+		OperationID: "op-1",
+		ToolName:    "export",
+		Status:      longrunning.StatusCompleted,
+		Session:     longrunning.SessionRef{AppName: "app", UserID: "user", SessionID: "session"},
+		Result:      map[string]any{"file": "export.csv", "rows": float64(3), "auth": map[string]any{"token": "[REDACTED]"}},
+	}
+	if diff := cmp.Diff(want, got, cmpopts.IgnoreFields(longrunning.Payload{}, "FinishedAt")); diff != "" {
+		t.Errorf("payload mismatch (-want +got):\n%s", diff)
+	}
+
+	op, err := tracker.Get("op-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if op.Delivery.State != longrunning.DeliveryDelivered || op.Delivery.Attempts != 1 || op.Delivery.DeliveredAt.IsZero() {
+		t.Errorf("delivery = %+v, want delivered in 1 attempt", op.Delivery)
+	}
+}
+
+func TestTracker_OutboundHeaders(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		client *http.Client
+		want   string
+	}{
+		{"Default", nil, httpx.Hash(nil, "session")},
+		{"CustomClient", &http.Client{}, ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rcv := &receiver{}
+			srv := httptest.NewServer(rcv)
+			defer srv.Close()
+			tracker := newTracker(t, longrunning.Config{Client: tc.client})
+			register(t, tracker, srv.URL, "")
+			if err := tracker.Complete("op-1", nil); err != nil {
+				t.Fatal(err)
+			}
+			if err := tracker.Shutdown(t.Context()); err != nil {
+				t.Fatal(err)
+			}
+			if len(rcv.requests) != 1 {
+				t.Fatalf("receiver got %d requests, want 1", len(rcv.requests))
+			}
+			if got := rcv.requests[0].Header.Get("X-Session-Hash"); got != tc.want {
+				t.Errorf("X-Session-Hash header = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestTracker_RetryThenSuccess(t *testing.T) {
+	rcv := &receiver{statuses: []int{http.StatusServiceUnavailable, http.StatusTooManyRequests}}
+	srv := httptest.NewServer(rcv)
+	defer srv.Close()
+	tracker := newTracker(t, longrunning.Config{MaxAttempts: 3})
+	register(t, tracker, srv.URL, "")
+
+	if err := tracker.Fail("op-1", errors.New("quota exhausted")); err != nil {
+		t.Fatal(err)
+	}
+	if err := tracker.Shutdown(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(rcv.requests) != 3 {
+		t.Fatalf("receiver got %d requests, want 3", len(rcv.requests))
+	}
+	for _, req := range rcv.requests[1:] {
+		if string(req.Body) != string(rcv.requests[0].Body) {
+			t.Errorf("retried payload %s differs from %s", req.Body, rcv.requests[0].Body)
+		}
+	}
+	var got longrunning.Payload
+	if err := json.Unmarshal(rcv.requests[2].Body, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != longrunning.StatusFailed || got.Error != "quota exhausted" {
+		t.Errorf("payload = %+v, want a failed operation", got)
+	}
+	op, _ := tracker.Get("op-1")
+	if op.Delivery.State != longrunning.DeliveryDelivered || op.Delivery.Attempts != 3 {
+		t.Errorf("delivery = %+v, want delivered in 3 attempts", op.Delivery)
+	}
+}
+
+func TestTracker_Signature(t *testing.T) {
+	rcv := &receiver{}
+	srv := httptest.NewServer(rcv)
+	defer srv.Close()
+	tracker := newTracker(t, longrunning.Config{})
+	register(t, tracker, srv.URL, "s3cret")
+
+	if err := tracker.Complete("op-1", map[string]any{"ok": true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := tracker.Shutdown(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+
+	req := rcv.requests[0]
+	if !strings.HasPrefix(req.Signature, "sha256=") {
+		t.Errorf("signature = %q, want a sha256 signature", req.Signature)
+	}
+	if !longrunning.Verify([]byte("s3cret"), req.Body, req.Signature) {
+		t.Error("Verify() with the secret = false, want true")
+	}
+	if longrunning.Verify([]byte("other"), req.Body, req.Signature) {
+		t.Error("Verify() with another secret = true, want false")
+	}
+	tampered := strings.Replace(string(req.Body), "true", "false", 1)
+	if longrunning.Verify([]byte("s3cret"), []byte(tampered), req.Signature) {
+		t.Error("Verify() of a tampered body = true, want false")
+	}
+}
+
+func TestTracker_DeadLetter(t *testing.T) {
+	for _, tc := range []struct {
+		name         string
+		statuses     []int
+		wantAttempts int
+	}{
+		{"Exhausted", []int{500, 502, 503, 504}, 3},
+		{"Rejected", []int{http.StatusBadRequest}, 1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rcv := &receiver{statuses: tc.statuses}
+			srv := httptest.NewServer(rcv)
+			defer srv.Close()
+			var deadLetters []longrunning.Operation
+			tracker := newTracker(t, longrunning.Config{
+				MaxAttempts: 3,
+				DeadLetter: func(op longrunning.Operation, err error) {
+					if err == nil {
+						t.Error("dead letter has no error")
+					}
+					deadLetters = append(deadLetters, op)
+				},
+			})
+			register(t, tracker, srv.URL, "")
+
+			if err := tracker.Complete("op-1", nil); err != nil {
+				t.Fatal(err)
+			}
+			if err := tracker.Shutdown(t.Context()); err != nil {
+				t.Fatal(err)
+			}
+
+			if len(rcv.requests) != tc.wantAttempts {
+				t.Errorf("receiver got %d requests, want %d", len(rcv.requests), tc.wantAttempts)
+			}
+			if len(deadLetters) != 1 {
+				t.Fatalf("got %d dead letters, want 1", len(deadLetters))
+			}
+			if d := deadLetters[0].Delivery; d.State != longrunning.DeliveryDeadLettered || d.Attempts != tc.wantAttempts || d.LastError == "" {
+				t.Errorf("dead-lettered delivery = %+v, want dead-lettered after %d attempts", d, tc.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestTracker_PayloadCap(t *testing.T) {
+	rcv := &receiver{}
+	srv := httptest.NewServer(rcv)
+	defer srv.Close()
+	tracker := newTracker(t, longrunning.Config{MaxPayloadBytes: 512})
+	register(t, tracker, srv.URL, "")
+
+	if err := tracker.Complete("op-1", map[string]any{"data": strings.Repeat("x", 1024)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := tracker.Shutdown(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+
+	body := rcv.requests[0].Body
+	if len(body) > 512 {
+		t.Errorf("payload has %d bytes, want at most 512", len(body))
+	}
+	var got longrunning.Payload
+	if err := json.Unmarshal(body, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Result != nil || !got.ResultTruncated {
+		t.Errorf("payload = %+v, want a truncated result", got)
+	}
+}
+
+func TestTracker_Register(t *testing.T) {
+	tracker := newTracker(t, longrunning.Config{})
+	for _, tc := range []struct {
+		name string
+		op   longrunning.Operation
+	}{
+		{"NoID", longrunning.Operation{ToolName: "export"}},
+		{"NoToolName", longrunning.Operation{ID: "op"}},
+		{"InvalidTemplate", longrunning.Operation{ID: "op", ToolName: "export", Webhook: &longrunning.Webhook{URL: "http://x/{{.ID"}}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tracker.Register(tc.op); err == nil {
+				t.Error("Register() succeeded, want an error")
+			}
+		})
+	}
+
+	if err := tracker.Register(longrunning.Operation{ID: "op", ToolName: "export"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := tracker.Register(longrunning.Operation{ID: "op", ToolName: "export"}); err == nil {
+		t.Error("Register() of a registered operation succeeded, want an error")
+	}
+	if err := tracker.Complete("op", nil); err != nil {
+		t.Errorf("Complete() of an operation without webhook failed: %v", err)
+	}
+	if op, _ := tracker.Get("op"); op.Status != longrunning.StatusCompleted || op.Delivery.State != longrunning.DeliveryNone {
+		t.Errorf("operation = %+v, want completed without delivery", op)
+	}
+	if _, err := tracker.Get("missing"); !errors.Is(err, longrunning.ErrUnknownOperation) {
+		t.Errorf("Get() of a missing operation error = %v, want ErrUnknownOperation", err)
+	}
+}
@@ -0,0 +1,242 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package longrunning
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"text/template"
+	"time"
+
+	"google.golang.org/adk/httpx"
+)
+
+// SignatureHeader is the header holding the signature of the payloads of
+// the webhooks with a secret.
+const SignatureHeader = "X-ADK-Signature"
+
+const redacted = "[REDACTED]"
+
+// Payload is the JSON body posted to the webhook of an operation.
+type Payload struct {
+	OperationID string     `json:"operationId"`
+	ToolName    string     `json:"toolName"`
+	Status      Status     `json:"status"`
+	Session     SessionRef `json:"session"`
+	// Result is the result of a completed operation, with the redacted
+	// fields. It is left out of the payloads larger than the cap.
+	Result map[string]any `json:"result,omitempty"`
+	// ResultTruncated reports whether Result was left out.
+	ResultTruncated bool      `json:"resultTruncated,omitempty"`
+	Error           string    `json:"error,omitempty"`
+	FinishedAt      time.Time `json:"finishedAt"`
+}
+
+// Sign returns the value of the SignatureHeader header for body:
+// "sha256=" followed by the hex encoded HMAC-SHA256 of body with secret.
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature, the value of the SignatureHeader header
+// of a request, is the signature of body with secret. Webhook receivers use
+// it to authenticate the notifications.
+func Verify(secret, body []byte, signature string) bool {
+	return hmac.Equal([]byte(signature), []byte(Sign(secret, body)))
+}
+
+// payload returns the encoded payload of op, within the size cap.
+func (t *Tracker) payload(op Operation) ([]byte, error) {
+	p := Payload{
+		OperationID: op.ID,
+		ToolName:    op.ToolName,
+		Status:      op.Status,
+		Session:     op.Session,
+		Result:      t.redactMap(op.Result),
+		Error:       op.Error,
+		FinishedAt:  op.FinishedAt,
+	}
+	body, err := json.Marshal(p)
+	if err != nil || len(body) <= t.cfg.MaxPayloadBytes {
+		return body, err
+	}
+	p.Result, p.ResultTruncated = nil, p.Result != nil
+	if body, err = json.Marshal(p); err != nil || len(body) <= t.cfg.MaxPayloadBytes {
+		return body, err
+	}
+	// Only a long error message can still exceed the cap.
+	for p.Error != "" && len(body) > t.cfg.MaxPayloadBytes {
+		p.Error = strings.ToValidUTF8(p.Error[:len(p.Error)/2], "")
+		if body, err = json.Marshal(p); err != nil {
+			return nil, err
+		}
+	}
+	if len(body) <= t.cfg.MaxPayloadBytes {
+		return body, nil
+	}
+	return nil, fmt.Errorf("payload of operation %q exceeds %d bytes", op.ID, t.cfg.MaxPayloadBytes)
+}
+
+func (t *Tracker) redactMap(m map[string]any) map[string]any {
+	if m == nil || len(t.redact) == 0 {
+		return m
+	}
+	out := make(map[string]any, len(m))
+	for k, v := range m {
+		if t.redact[k] {
+			out[k] = redacted
+		} else {
+			out[k] = t.redactValue(v)
+		}
+	}
+	return out
+}
+
+func (t *Tracker) redactValue(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		return t.redactMap(v)
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = t.redactValue(item)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// webhookURL executes the URL template of the webhook of op.
+func webhookURL(op Operation) (string, error) {
+	tmpl, err := template.New("url").Option("missingkey=error").Parse(op.Webhook.URL)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, op); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// deliver posts the payload of op to its webhook, retrying the transient
+// failures, and records the outcome.
+func (t *Tracker) deliver(op Operation) {
+	deadLetter := func(err error) {
+		t.updateDelivery(op.ID, func(d *Delivery) {
+			d.State, d.LastError = DeliveryDeadLettered, err.Error()
+		})
+		if t.cfg.DeadLetter != nil {
+			op, _ := t.Get(op.ID)
+			t.cfg.DeadLetter(op, err)
+		}
+	}
+	body, err := t.payload(op)
+	if err != nil {
+		deadLetter(err)
+		return
+	}
+	url, err := webhookURL(op)
+	if err != nil {
+		deadLetter(fmt.Errorf("invalid webhook URL: %w", err))
+		return
+	}
+
+	backoff := t.cfg.Backoff
+	for attempt := 1; ; attempt++ {
+		err := t.post(op, url, body)
+		if err == nil {
+			t.updateDelivery(op.ID, func(d *Delivery) {
+				d.State, d.Attempts, d.LastError, d.DeliveredAt = DeliveryDelivered, attempt, "", t.cfg.Deps.Now()
+			})
+			return
+		}
+		t.updateDelivery(op.ID, func(d *Delivery) {
+			d.Attempts, d.LastError = attempt, err.Error()
+		})
+		var permanent *permanentError
+		if errors.As(err, &permanent) {
+			deadLetter(err)
+			return
+		}
+		if attempt >= t.cfg.MaxAttempts {
+			deadLetter(fmt.Errorf("delivery failed after %d attempts: %w", attempt, err))
+			return
+		}
+		if t.cfg.Deps.Sleep(t.ctx, backoff) != nil {
+			deadLetter(fmt.Errorf("delivery abandoned after %d attempts: %w", attempt, err))
+			return
+		}
+		backoff = min(2*backoff, t.cfg.MaxBackoff)
+	}
+}
+
+// permanentError is the error of a request rejected by the webhook: it is
+// not retried.
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+func (t *Tracker) post(op Operation, url string, body []byte) error {
+	wh := op.Webhook
+	ctx, cancel := context.WithTimeout(t.ctx, t.cfg.Timeout)
+	defer cancel()
+	ctx = httpx.NewContext(ctx, httpx.Metadata{AppName: op.Session.AppName, UserID: op.Session.UserID, SessionID: op.Session.SessionID})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return &permanentError{err}
+	}
+	for k, v := range wh.Headers {
+		req.Header.Set(k, v)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if len(wh.Secret) > 0 {
+		req.Header.Set(SignatureHeader, Sign(wh.Secret, body))
+	}
+	resp, err := t.cfg.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusRequestTimeout || resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	default:
+		return &permanentError{fmt.Errorf("webhook rejected the payload with status %d", resp.StatusCode)}
+	}
+}
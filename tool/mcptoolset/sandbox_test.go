@@ -0,0 +1,144 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcptoolset_test
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	icontext "google.golang.org/adk/internal/context"
+	"google.golang.org/adk/internal/toolinternal"
+	"google.golang.org/adk/tool/mcptoolset"
+	"google.golang.org/adk/tool/sandbox"
+)
+
+// serverEnv is set in the environment of the test binary run as a stdio MCP
+// server.
+const serverEnv = "MCPTOOLSET_TEST_STDIO_SERVER"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(serverEnv) == "1" {
+		runStdioServer()
+		return
+	}
+	os.Exit(m.Run())
+}
+
+type environmentOutput struct {
+	Env []string `json:"env"`
+	Dir string   `json:"dir"`
+}
+
+// runStdioServer runs an MCP server reporting its environment.
+func runStdioServer() {
+	server := mcp.NewServer(&mcp.Implementation{Name: "env_server", Version: "v1.0.0"}, nil)
+	mcp.AddTool(server, &mcp.Tool{Name: "environment", Description: "returns the environment"},
+		func(context.Context, *mcp.CallToolRequest, struct{}) (*mcp.CallToolResult, environmentOutput, error) {
+			dir, err := os.Getwd()
+			return nil, environmentOutput{Env: os.Environ(), Dir: dir}, err
+		})
+	if err := server.Run(context.Background(), &mcp.StdioTransport{}); err != nil {
+		os.Exit(1)
+	}
+}
+
+func TestSandbox(t *testing.T) {
+	t.Setenv("SECRET_TOKEN", "secret")
+	t.Setenv("MCPTOOLSET_TEST_INHERITED", "inherited")
+	root := t.TempDir()
+	dir := filepath.Join(root, "work")
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	executable, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ts, err := mcptoolset.New(mcptoolset.Config{
+		Transport: &mcp.CommandTransport{Command: exec.Command(executable)},
+		Sandbox: &sandbox.Policy{
+			Env:        map[string]string{serverEnv: "1"},
+			InheritEnv: []string{"MCPTOOLSET_TEST_INHERITED"},
+			Dir:        dir,
+			Root:       root,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	invCtx := icontext.NewInvocationContext(t.Context(), icontext.InvocationContextParams{})
+	tools, err := ts.Tools(icontext.NewReadonlyContext(invCtx))
+	if err != nil {
+		t.Fatalf("Failed to get tools: %v", err)
+	}
+	if len(tools) != 1 {
+		t.Fatalf("got %d tools, want 1", len(tools))
+	}
+	result, err := tools[0].(toolinternal.FunctionTool).Run(toolinternal.NewToolContext(invCtx, "", nil), map[string]any{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	output := result["output"].(map[string]any)
+	var env []string
+	for _, v := range output["env"].([]any) {
+		env = append(env, v.(string))
+	}
+	slices.Sort(env)
+	if diff := cmp.Diff([]string{"MCPTOOLSET_TEST_INHERITED=inherited", serverEnv + "=1"}, env); diff != "" {
+		t.Errorf("environment of the server mismatch (-want +got):\n%s", diff)
+	}
+	if got := output["dir"].(string); !strings.HasSuffix(got, string(filepath.Separator)+"work") {
+		t.Errorf("working directory of the server = %q, want %q", got, dir)
+	}
+}
+
+func TestSandbox_Invalid(t *testing.T) {
+	clientTransport, _ := mcp.NewInMemoryTransports()
+	for _, tc := range []struct {
+		name    string
+		cfg     mcptoolset.Config
+		wantErr string
+	}{
+		{
+			name:    "NotCommandTransport",
+			cfg:     mcptoolset.Config{Transport: clientTransport, Sandbox: &sandbox.Policy{}},
+			wantErr: "sandbox policy requires a *mcp.CommandTransport",
+		},
+		{
+			name: "DirOutsideRoot",
+			cfg: mcptoolset.Config{
+				Transport: &mcp.CommandTransport{Command: exec.Command("server")},
+				Sandbox:   &sandbox.Policy{Dir: "/tmp/other", Root: "/tmp/root"},
+			},
+			wantErr: `working directory "/tmp/other" is outside of the root "/tmp/root"`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := mcptoolset.New(tc.cfg)
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("New() error = %v, want an error containing %q", err, tc.wantErr)
+			}
+		})
+	}
+}
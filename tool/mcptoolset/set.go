@@ -23,8 +23,10 @@ import (
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 
 	"google.golang.org/adk/agent"
+	"google.golang.org/adk/httpx"
 	"google.golang.org/adk/internal/version"
 	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/sandbox"
 )
 
 // New returns MCP ToolSet.
@@ -50,13 +52,25 @@ import (
 //		},
 //	})
 func New(cfg Config) (tool.Toolset, error) {
+	transport := cfg.Transport
+	if cfg.Sandbox != nil {
+		ct, ok := transport.(*mcp.CommandTransport)
+		if !ok || ct.Command == nil {
+			return nil, fmt.Errorf("sandbox policy requires a *mcp.CommandTransport, got %T", transport)
+		}
+		if err := cfg.Sandbox.Apply(ct.Command); err != nil {
+			return nil, fmt.Errorf("failed to apply sandbox policy to MCP server %q: %w", ct.Command.Path, err)
+		}
+		transport = &sandboxedTransport{CommandTransport: ct, policy: cfg.Sandbox}
+	}
+	transport = withDefaultHTTPClient(transport)
 	client := cfg.Client
 	if client == nil {
 		client = mcp.NewClient(&mcp.Implementation{Name: "adk-mcp-client", Version: version.Version}, nil)
 	}
 	return &set{
 		client:     client,
-		transport:  cfg.Transport,
+		transport:  transport,
 		toolFilter: cfg.ToolFilter,
 	}, nil
 }
@@ -66,11 +80,59 @@ type Config struct {
 	// Client is an optional custom MCP client to use. If nil, a default client will be created.
 	Client *mcp.Client
 	// Transport that will be used to connect to MCP server.
+	//
+	// The HTTP transports without a client, *mcp.StreamableClientTransport
+	// and *mcp.SSEClientTransport, send the requests with
+	// httpx.DefaultClient, which propagates the metadata of the invocations
+	// in their headers.
 	Transport mcp.Transport
 	// ToolFilter selects tools for which tool.Predicate returns true.
 	// If ToolFilter is nil, then all tools are returned.
 	// tool.StringPredicate can be convenient if there's a known fixed list of tool names.
 	ToolFilter tool.Predicate
+	// Sandbox, if set, restricts the MCP server subprocess: the Transport
+	// must be a *mcp.CommandTransport whose command is not started. The
+	// policy is applied by New, and its limits once the server is started.
+	Sandbox *sandbox.Policy
+}
+
+// withDefaultHTTPClient returns a copy of the HTTP transport t using
+// httpx.DefaultClient if it has no client, or else t.
+func withDefaultHTTPClient(t mcp.Transport) mcp.Transport {
+	switch t := t.(type) {
+	case *mcp.StreamableClientTransport:
+		if t.HTTPClient == nil {
+			c := *t
+			c.HTTPClient = httpx.DefaultClient
+			return &c
+		}
+	case *mcp.SSEClientTransport:
+		if t.HTTPClient == nil {
+			c := *t
+			c.HTTPClient = httpx.DefaultClient
+			return &c
+		}
+	}
+	return t
+}
+
+// sandboxedTransport applies the limits of a sandbox policy to the started
+// MCP server.
+type sandboxedTransport struct {
+	*mcp.CommandTransport
+	policy *sandbox.Policy
+}
+
+func (t *sandboxedTransport) Connect(ctx context.Context) (mcp.Connection, error) {
+	conn, err := t.CommandTransport.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := t.policy.Limit(t.Command.Process); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	return conn, nil
 }
 
 type set struct {
@@ -134,6 +196,19 @@ func (s *set) Tools(ctx agent.ReadonlyContext) ([]tool.Tool, error) {
 	return adkTools, nil
 }
 
+// HealthCheck implements model.HealthChecker: it pings the MCP server,
+// connecting to it first if needed.
+func (s *set) HealthCheck(ctx context.Context) error {
+	session, err := s.getSession(ctx)
+	if err != nil {
+		return err
+	}
+	if err := session.Ping(ctx, nil); err != nil {
+		return fmt.Errorf("failed to ping MCP server: %w", err)
+	}
+	return nil
+}
+
 func (s *set) getSession(ctx context.Context) (*mcp.ClientSession, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -20,8 +20,10 @@ import (
 	"iter"
 	"log"
 	"net/http"
+	"net/http/httptest"
 	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -31,6 +33,7 @@ import (
 
 	"google.golang.org/adk/agent"
 	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/httpx"
 	icontext "google.golang.org/adk/internal/context"
 	"google.golang.org/adk/internal/httprr"
 	"google.golang.org/adk/internal/testutil"
@@ -307,3 +310,84 @@ func TestToolFilter(t *testing.T) {
 		t.Errorf("tools mismatch (-want +got):\n%s", diff)
 	}
 }
+
+func TestHTTPTransport_OutboundHeaders(t *testing.T) {
+	server := mcp.NewServer(&mcp.Implementation{Name: "weather_server", Version: "v1.0.0"}, nil)
+	mcp.AddTool(server, &mcp.Tool{Name: "get_weather", Description: "returns weather in the given city"}, weatherFunc)
+	handler := mcp.NewStreamableHTTPHandler(func(*http.Request) *mcp.Server { return server }, nil)
+	var (
+		mu       sync.Mutex
+		requests []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			mu.Lock()
+			requests = append(requests, r.Header.Get("X-Request-ID")+" "+r.Header.Get("X-Session-Hash"))
+			mu.Unlock()
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+
+	md := httpx.Metadata{SessionID: "s1", InvocationID: "e-1", AgentName: "agent"}
+	for _, tc := range []struct {
+		name   string
+		client *http.Client
+		want   string
+	}{
+		{"Default", nil, "e-1 " + httpx.Hash(nil, "s1")},
+		{"CustomClient", &http.Client{}, " "},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			mu.Lock()
+			requests = nil
+			mu.Unlock()
+			ts, err := mcptoolset.New(mcptoolset.Config{Transport: &mcp.StreamableClientTransport{Endpoint: srv.URL, HTTPClient: tc.client}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			ctx := icontext.NewReadonlyContext(icontext.NewInvocationContext(httpx.NewContext(t.Context(), md), icontext.InvocationContextParams{}))
+			if _, err := ts.Tools(ctx); err != nil {
+				t.Fatal(err)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if len(requests) == 0 {
+				t.Fatal("server got no requests")
+			}
+			for _, got := range requests {
+				if got != tc.want {
+					t.Errorf("request headers = %q, want %q", got, tc.want)
+				}
+			}
+		})
+	}
+}
+
+func TestHealthCheck(t *testing.T) {
+	clientTransport, serverTransport := mcp.NewInMemoryTransports()
+	server := mcp.NewServer(&mcp.Implementation{Name: "weather_server", Version: "v1.0.0"}, nil)
+	serverSession, err := server.Connect(t.Context(), serverTransport, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ts, err := mcptoolset.New(mcptoolset.Config{Transport: clientTransport})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checker, ok := ts.(model.HealthChecker)
+	if !ok {
+		t.Fatal("MCP toolset does not implement model.HealthChecker")
+	}
+	if err := checker.HealthCheck(t.Context()); err != nil {
+		t.Errorf("HealthCheck() failed: %v", err)
+	}
+
+	if err := serverSession.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := checker.HealthCheck(t.Context()); err == nil {
+		t.Error("HealthCheck() of a closed server succeeded, want an error")
+	}
+}
@@ -40,6 +40,7 @@ func convertTool(t *mcp.Tool, getSessionFunc getSessionFunc) (tool.Tool, error)
 			Description: t.Description,
 		},
 		getSessionFunc: getSessionFunc,
+		readOnly:       t.Annotations != nil && t.Annotations.ReadOnlyHint && t.Annotations.IdempotentHint,
 	}
 
 	// Since t.InputSchema and t.OutputSchema are pointers (*jsonschema.Schema) and the destination ResponseJsonSchema
@@ -62,6 +63,9 @@ type mcpTool struct {
 	funcDeclaration *genai.FunctionDeclaration
 
 	getSessionFunc getSessionFunc
+	// readOnly is set when the server annotates the tool as read-only and
+	// idempotent.
+	readOnly bool
 }
 
 // Name implements the tool.Tool.
@@ -79,8 +83,14 @@ func (t *mcpTool) IsLongRunning() bool {
 	return false
 }
 
+// IsReadOnly reports whether the server annotates the tool as read-only
+// and idempotent.
+func (t *mcpTool) IsReadOnly() bool {
+	return t.readOnly
+}
+
 func (t *mcpTool) ProcessRequest(ctx tool.Context, req *model.LLMRequest) error {
-	return toolutils.PackTool(req, t)
+	return toolutils.PackTool(ctx, req, t)
 }
 
 func (t *mcpTool) Declaration() *genai.FunctionDeclaration {
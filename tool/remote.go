@@ -0,0 +1,182 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/internal/toolinternal/toolutils"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/session"
+)
+
+// ToolDescriptor describes a tool run by remote workers, see [Remote].
+type ToolDescriptor struct {
+	// Declaration is the declaration of the tool sent to the model. It is
+	// required, and names the tool.
+	Declaration *genai.FunctionDeclaration
+	// StateKeys are the keys of the session state sent with the calls, for
+	// the tool to read. The other keys are not visible to the tool.
+	StateKeys []string
+}
+
+// RemoteCall is a call of a remote tool, dispatched to the workers by a
+// [RemoteTransport]. It carries a snapshot of the tool context.
+type RemoteCall struct {
+	Tool           string         `json:"tool"`
+	Args           map[string]any `json:"args,omitempty"`
+	FunctionCallID string         `json:"function_call_id"`
+	AppName        string         `json:"app_name"`
+	UserID         string         `json:"user_id"`
+	SessionID      string         `json:"session_id"`
+	AgentName      string         `json:"agent_name"`
+	// State holds the values of the state keys of the descriptor which are
+	// set.
+	State map[string]any `json:"state,omitempty"`
+}
+
+// RemoteResult is the result of a [RemoteCall].
+type RemoteResult struct {
+	Result map[string]any `json:"result,omitempty"`
+	// Error is set if the call failed.
+	Error *RemoteError `json:"error,omitempty"`
+	// StateDelta holds the state changes made by the tool, applied to the
+	// session by the caller.
+	StateDelta map[string]any `json:"state_delta,omitempty"`
+	// ArtifactDelta holds the versions of the artifacts saved by the tool.
+	ArtifactDelta map[string]int64 `json:"artifact_delta,omitempty"`
+}
+
+// RemoteError is the error of a failed [RemoteCall]. Remote tools can
+// return one to report a structured error to the caller.
+type RemoteError struct {
+	Tool string `json:"tool"`
+	// Code classifies the error, e.g. "not_found" or "deadline_exceeded".
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Error implements error.
+func (e *RemoteError) Error() string {
+	return fmt.Sprintf("remote tool %q failed (%s): %s", e.Tool, e.Code, e.Message)
+}
+
+// RemoteTransport dispatches the calls of the remote tools to the workers
+// running them, e.g. over HTTP or a message queue.
+type RemoteTransport interface {
+	Dispatch(ctx context.Context, call *RemoteCall) (*RemoteResult, error)
+}
+
+// RemoteTool is a function tool run by remote workers, see [Remote].
+type RemoteTool struct {
+	desc      ToolDescriptor
+	transport RemoteTransport
+}
+
+// Remote returns a function tool declared as described by d, whose calls
+// are dispatched by transport to the workers running the tool, e.g. hosted
+// by a toolserver.Server. The state changes and the artifacts saved by the
+// tool are applied to the caller's session.
+func Remote(d ToolDescriptor, transport RemoteTransport) *RemoteTool {
+	if d.Declaration == nil || d.Declaration.Name == "" {
+		panic("tool.Remote: the descriptor must have a named declaration")
+	}
+	if transport == nil {
+		panic("tool.Remote: transport is nil")
+	}
+	return &RemoteTool{desc: d, transport: transport}
+}
+
+// Name implements Tool.
+func (t *RemoteTool) Name() string {
+	return t.desc.Declaration.Name
+}
+
+// Description implements Tool.
+func (t *RemoteTool) Description() string {
+	return t.desc.Declaration.Description
+}
+
+// IsLongRunning implements Tool.
+func (t *RemoteTool) IsLongRunning() bool {
+	return false
+}
+
+// Declaration returns the declaration of the descriptor.
+func (t *RemoteTool) Declaration() *genai.FunctionDeclaration {
+	return t.desc.Declaration
+}
+
+// ProcessRequest declares the tool in req.
+func (t *RemoteTool) ProcessRequest(ctx Context, req *model.LLMRequest) error {
+	return toolutils.PackTool(ctx, req, t)
+}
+
+// Run dispatches the call to the workers. A failed call returns the
+// [RemoteError] of the result.
+func (t *RemoteTool) Run(ctx Context, args any) (map[string]any, error) {
+	m, ok := args.(map[string]any)
+	if args != nil && !ok {
+		return nil, fmt.Errorf("unexpected args type for remote tool %q: %T", t.Name(), args)
+	}
+	call := &RemoteCall{
+		Tool:           t.Name(),
+		Args:           m,
+		FunctionCallID: ctx.FunctionCallID(),
+		AppName:        ctx.AppName(),
+		UserID:         ctx.UserID(),
+		SessionID:      ctx.SessionID(),
+		AgentName:      ctx.AgentName(),
+	}
+	for _, key := range t.desc.StateKeys {
+		v, err := ctx.State().Get(key)
+		if errors.Is(err, session.ErrStateKeyNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read state key %q: %w", key, err)
+		}
+		if call.State == nil {
+			call.State = make(map[string]any)
+		}
+		call.State[key] = v
+	}
+
+	result, err := t.transport.Dispatch(ctx, call)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dispatch remote tool %q: %w", t.Name(), err)
+	}
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	for key, v := range result.StateDelta {
+		if err := ctx.State().Set(key, v); err != nil {
+			return nil, fmt.Errorf("failed to apply the state change of key %q: %w", key, err)
+		}
+	}
+	if len(result.ArtifactDelta) > 0 {
+		actions := ctx.Actions()
+		if actions.ArtifactDelta == nil {
+			actions.ArtifactDelta = make(map[string]int64)
+		}
+		maps.Copy(actions.ArtifactDelta, result.ArtifactDelta)
+	}
+	return result.Result, nil
+}
@@ -0,0 +1,242 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sandbox restricts the subprocesses launched by the tools, e.g.
+// the stdio MCP servers of mcptoolset.
+//
+// A [Policy] replaces the environment of a subprocess by an explicit one,
+// confines its working directory, and can deny it network access and lower
+// its priority and process limits. On Linux, network access is denied by
+// running the subprocess in a new network namespace, which only has a
+// loopback interface. Where namespaces are not available, e.g. on other
+// platforms or in containers without user namespaces, the proxy variables
+// are still removed from the environment and a warning is logged; set
+// [Policy.RequireNetworkIsolation] to fail instead.
+package sandbox
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// proxyVariables are removed from the environment of the subprocesses
+// denied network access.
+var proxyVariables = []string{
+	"HTTP_PROXY", "HTTPS_PROXY", "FTP_PROXY", "ALL_PROXY", "NO_PROXY",
+	"http_proxy", "https_proxy", "ftp_proxy", "all_proxy", "no_proxy",
+}
+
+// Policy restricts a subprocess. The zero Policy runs the subprocess with
+// an empty environment and no other restriction.
+type Policy struct {
+	// Env sets variables of the environment of the subprocess.
+	Env map[string]string
+	// InheritEnv are the names of the variables of the parent process
+	// passed to the subprocess, unless set by Env. The other variables are
+	// never inherited.
+	InheritEnv []string
+	// Dir is the working directory of the subprocess, used if the command
+	// does not set one. It defaults to Root.
+	Dir string
+	// Root, if set, confines the working directory of the subprocess: it
+	// must be Root or one of its subdirectories. Root does not restrict the
+	// files the subprocess can open.
+	Root string
+	// DenyNetwork denies network access to the subprocess.
+	DenyNetwork bool
+	// RequireNetworkIsolation makes Apply fail if DenyNetwork is set and
+	// network namespaces are not available, instead of only removing the
+	// proxy variables.
+	RequireNetworkIsolation bool
+	// Nice is the nice level of the subprocess, from 0 (the default
+	// priority) to 19 (the lowest).
+	Nice int
+	// MaxProcesses, if positive, limits the number of processes of the user
+	// of the subprocess (RLIMIT_NPROC).
+	MaxProcesses int
+}
+
+// Validate reports the invalid or contradictory settings of p.
+func (p *Policy) Validate() error {
+	var errs []error
+	for _, k := range slices.Sorted(maps.Keys(p.Env)) {
+		if k == "" || strings.ContainsAny(k, "=\x00") {
+			errs = append(errs, fmt.Errorf("invalid environment variable name %q", k))
+		} else if p.DenyNetwork && slices.Contains(proxyVariables, k) {
+			errs = append(errs, fmt.Errorf("environment variable %s is set although network access is denied", k))
+		}
+	}
+	for _, k := range p.InheritEnv {
+		if k == "" || strings.ContainsAny(k, "=\x00") {
+			errs = append(errs, fmt.Errorf("invalid inherited environment variable name %q", k))
+		}
+	}
+	if p.Root != "" && p.Dir != "" {
+		if err := checkConfined(p.Root, p.Dir); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if p.RequireNetworkIsolation && !p.DenyNetwork {
+		errs = append(errs, errors.New("network isolation is required although network access is not denied"))
+	}
+	if p.Nice < 0 || p.Nice > 19 {
+		errs = append(errs, fmt.Errorf("nice level %d is out of the range [0, 19]", p.Nice))
+	}
+	if p.MaxProcesses < 0 {
+		errs = append(errs, fmt.Errorf("invalid maximum number of processes %d", p.MaxProcesses))
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid sandbox policy: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// checkConfined returns an error if dir is not root or one of its
+// subdirectories.
+func checkConfined(root, dir string) error {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return err
+	}
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	rel, err := filepath.Rel(absRoot, absDir)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("working directory %q is outside of the root %q", dir, root)
+	}
+	return nil
+}
+
+// Environ returns the environment of the subprocesses, sorted by name.
+func (p *Policy) Environ() []string {
+	env := make(map[string]string)
+	for _, k := range p.InheritEnv {
+		if v, ok := os.LookupEnv(k); ok {
+			env[k] = v
+		}
+	}
+	maps.Copy(env, p.Env)
+	if p.DenyNetwork {
+		for _, k := range proxyVariables {
+			delete(env, k)
+		}
+	}
+	environ := make([]string, 0, len(env))
+	for _, k := range slices.Sorted(maps.Keys(env)) {
+		environ = append(environ, k+"="+env[k])
+	}
+	return environ
+}
+
+// Apply configures cmd, which must not be started, to run under p. The
+// limits on the priority and the processes are applied once the process is
+// started, by [Policy.Limit]: [Policy.Start] does both.
+func (p *Policy) Apply(cmd *exec.Cmd) error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+	// A nil environment would be inherited: Environ returns an empty one.
+	cmd.Env = p.Environ()
+	if cmd.Dir == "" {
+		cmd.Dir = p.Dir
+		if cmd.Dir == "" {
+			cmd.Dir = p.Root
+		}
+	}
+	if p.Root != "" && cmd.Dir != "" {
+		if err := checkConfined(p.Root, cmd.Dir); err != nil {
+			return err
+		}
+	}
+	if p.DenyNetwork {
+		if NetworkIsolationSupported() {
+			isolateNetwork(cmd)
+		} else if p.RequireNetworkIsolation {
+			return errors.New("network isolation is not supported on this system")
+		} else {
+			log.Printf("Network isolation is not supported on this system: %s only runs without the proxy variables", cmd.Path)
+		}
+	}
+	return nil
+}
+
+// Limit applies the nice level and the process limit of p to proc. The
+// process runs without them until Limit returns.
+func (p *Policy) Limit(proc *os.Process) error {
+	if p.Nice == 0 && p.MaxProcesses == 0 {
+		return nil
+	}
+	if err := limit(proc.Pid, p.Nice, p.MaxProcesses); err != nil {
+		return fmt.Errorf("failed to limit process %d: %w", proc.Pid, err)
+	}
+	return nil
+}
+
+// Start applies p to cmd and starts it. The process is killed if its
+// limits cannot be applied.
+func (p *Policy) Start(cmd *exec.Cmd) error {
+	if err := p.Apply(cmd); err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	if err := p.Limit(cmd.Process); err != nil {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		return err
+	}
+	return nil
+}
+
+// Policies are the policies of the subprocesses of several tools: a
+// default policy and the policies overriding it for specific tools.
+type Policies struct {
+	def       Policy
+	overrides map[string]Policy
+}
+
+// NewPolicies returns the Policies with the given default policy and the
+// overrides of the tools, by tool name. An override replaces the default
+// policy entirely. The policies are validated.
+func NewPolicies(def Policy, overrides map[string]Policy) (*Policies, error) {
+	if err := def.Validate(); err != nil {
+		return nil, fmt.Errorf("default policy: %w", err)
+	}
+	for _, name := range slices.Sorted(maps.Keys(overrides)) {
+		p := overrides[name]
+		if err := p.Validate(); err != nil {
+			return nil, fmt.Errorf("policy of tool %q: %w", name, err)
+		}
+	}
+	return &Policies{def: def, overrides: maps.Clone(overrides)}, nil
+}
+
+// For returns the policy of the tool with the given name.
+func (ps *Policies) For(tool string) *Policy {
+	p, ok := ps.overrides[tool]
+	if !ok {
+		p = ps.def
+	}
+	return &p
+}
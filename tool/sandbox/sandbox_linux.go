@@ -0,0 +1,72 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sandbox
+
+import (
+	"os"
+	"os/exec"
+	"sync"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// NetworkIsolationSupported reports whether the subprocesses can be run in
+// a new network namespace. Unprivileged processes need user namespaces.
+func NetworkIsolationSupported() bool {
+	return networkIsolationSupported()
+}
+
+var networkIsolationSupported = sync.OnceValue(func() bool {
+	path, err := exec.LookPath("true")
+	if err != nil {
+		return false
+	}
+	cmd := exec.Command(path)
+	isolateNetwork(cmd)
+	return cmd.Run() == nil
+})
+
+func isolateNetwork(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	attr := cmd.SysProcAttr
+	attr.Cloneflags |= syscall.CLONE_NEWNET
+	if os.Geteuid() == 0 {
+		return
+	}
+	// The user namespace maps the user to itself, so that the subprocess
+	// keeps its file permissions.
+	attr.Cloneflags |= syscall.CLONE_NEWUSER
+	attr.UidMappings = []syscall.SysProcIDMap{{ContainerID: os.Getuid(), HostID: os.Getuid(), Size: 1}}
+	attr.GidMappings = []syscall.SysProcIDMap{{ContainerID: os.Getgid(), HostID: os.Getgid(), Size: 1}}
+	attr.GidMappingsEnableSetgroups = false
+}
+
+func limit(pid, nice, maxProcesses int) error {
+	if nice != 0 {
+		if err := unix.Setpriority(unix.PRIO_PROCESS, pid, nice); err != nil {
+			return err
+		}
+	}
+	if maxProcesses > 0 {
+		rlimit := &unix.Rlimit{Cur: uint64(maxProcesses), Max: uint64(maxProcesses)}
+		if err := unix.Prlimit(pid, unix.RLIMIT_NPROC, rlimit, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
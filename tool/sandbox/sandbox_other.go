@@ -0,0 +1,35 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !linux
+
+package sandbox
+
+import (
+	"log"
+	"os/exec"
+)
+
+// NetworkIsolationSupported reports whether the subprocesses can be run in
+// a new network namespace, which is only supported on Linux.
+func NetworkIsolationSupported() bool {
+	return false
+}
+
+func isolateNetwork(*exec.Cmd) {}
+
+func limit(pid, nice, maxProcesses int) error {
+	log.Printf("The nice level and the process limit of process %d are not applied on this platform", pid)
+	return nil
+}
@@ -0,0 +1,229 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sandbox_test
+
+import (
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	"google.golang.org/adk/tool/sandbox"
+)
+
+func output(t *testing.T, p *sandbox.Policy, name string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command(name, args...)
+	if err := p.Apply(cmd); err != nil {
+		t.Fatal(err)
+	}
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("%s failed: %v", name, err)
+	}
+	return string(out)
+}
+
+func TestApply_Environment(t *testing.T) {
+	if _, err := exec.LookPath("env"); err != nil {
+		t.Skip("env is not available")
+	}
+	t.Setenv("SECRET_TOKEN", "secret")
+	t.Setenv("SANDBOX_TEST_LANG", "fr")
+	t.Setenv("HTTPS_PROXY", "http://proxy:3128")
+
+	for _, tc := range []struct {
+		name   string
+		policy *sandbox.Policy
+		want   []string
+	}{
+		{"Empty", &sandbox.Policy{}, nil},
+		{
+			name:   "InheritAndOverride",
+			policy: &sandbox.Policy{InheritEnv: []string{"SANDBOX_TEST_LANG", "HTTPS_PROXY", "SANDBOX_TEST_UNSET"}, Env: map[string]string{"MODE": "test"}},
+			want:   []string{"HTTPS_PROXY=http://proxy:3128", "MODE=test", "SANDBOX_TEST_LANG=fr"},
+		},
+		{
+			name:   "Override",
+			policy: &sandbox.Policy{InheritEnv: []string{"SANDBOX_TEST_LANG"}, Env: map[string]string{"SANDBOX_TEST_LANG": "en"}},
+			want:   []string{"SANDBOX_TEST_LANG=en"},
+		},
+		{
+			// The proxy variables are scrubbed whether or not the network
+			// can be isolated.
+			name:   "DenyNetwork",
+			policy: &sandbox.Policy{InheritEnv: []string{"SANDBOX_TEST_LANG", "HTTPS_PROXY"}, DenyNetwork: true},
+			want:   []string{"SANDBOX_TEST_LANG=fr"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.want, strings.Fields(output(t, tc.policy, "env")), cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("environment mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestApply_Dir(t *testing.T) {
+	root := t.TempDir()
+	if err := os.Mkdir(root+"/work", 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		name    string
+		policy  *sandbox.Policy
+		cmdDir  string
+		wantDir string
+		wantErr bool
+	}{
+		{name: "Root", policy: &sandbox.Policy{Root: root}, wantDir: root},
+		{name: "Dir", policy: &sandbox.Policy{Root: root, Dir: root + "/work"}, wantDir: root + "/work"},
+		{name: "CommandDir", policy: &sandbox.Policy{Root: root}, cmdDir: root + "/work", wantDir: root + "/work"},
+		{name: "CommandDirOutside", policy: &sandbox.Policy{Root: root}, cmdDir: root + "/..", wantErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cmd := exec.Command("server")
+			cmd.Dir = tc.cmdDir
+			err := tc.policy.Apply(cmd)
+			if tc.wantErr {
+				if err == nil {
+					t.Error("Apply() succeeded, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cmd.Dir != tc.wantDir {
+				t.Errorf("Dir = %q, want %q", cmd.Dir, tc.wantDir)
+			}
+		})
+	}
+}
+
+func TestApply_DenyNetwork(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("network isolation is only supported on Linux")
+	}
+	if !sandbox.NetworkIsolationSupported() {
+		t.Skip("network namespaces are not available")
+	}
+	// /proc/net/dev lists the interfaces of the network namespace of the
+	// reading process.
+	var interfaces []string
+	for _, line := range strings.Split(output(t, &sandbox.Policy{DenyNetwork: true}, "cat", "/proc/net/dev"), "\n") {
+		if name, _, ok := strings.Cut(line, ":"); ok {
+			interfaces = append(interfaces, strings.TrimSpace(name))
+		}
+	}
+	if diff := cmp.Diff([]string{"lo"}, interfaces); diff != "" {
+		t.Errorf("network interfaces mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestStart_Limits(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("limits are only checked on Linux")
+	}
+	cmd := exec.Command("sleep", "60")
+	p := &sandbox.Policy{Nice: 10, MaxProcesses: 64}
+	if err := p.Start(cmd); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	}()
+
+	limits, err := os.ReadFile("/proc/" + strconv.Itoa(cmd.Process.Pid) + "/limits")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var maxProcesses []string
+	for _, line := range strings.Split(string(limits), "\n") {
+		if strings.HasPrefix(line, "Max processes") {
+			maxProcesses = strings.Fields(strings.TrimPrefix(line, "Max processes"))
+		}
+	}
+	if diff := cmp.Diff([]string{"64", "64", "processes"}, maxProcesses); diff != "" {
+		t.Errorf("process limit mismatch (-want +got):\n%s", diff)
+	}
+
+	stat, err := os.ReadFile("/proc/" + strconv.Itoa(cmd.Process.Pid) + "/stat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The fields following the command name, which is in parentheses,
+	// start at the state (field 3): the nice level is field 19.
+	_, rest, _ := strings.Cut(string(stat), ") ")
+	if fields := strings.Fields(rest); fields[16] != "10" {
+		t.Errorf("nice level = %s, want 10", fields[16])
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		policy  sandbox.Policy
+		wantErr string
+	}{
+		{"Valid", sandbox.Policy{Env: map[string]string{"A": "1"}, Root: "/srv", Dir: "/srv/work", DenyNetwork: true, Nice: 19, MaxProcesses: 10}, ""},
+		{"InvalidName", sandbox.Policy{Env: map[string]string{"A=B": "1"}}, `invalid environment variable name "A=B"`},
+		{"InvalidInheritedName", sandbox.Policy{InheritEnv: []string{""}}, `invalid inherited environment variable name ""`},
+		{"ProxyWithoutNetwork", sandbox.Policy{Env: map[string]string{"https_proxy": "http://proxy"}, DenyNetwork: true}, "environment variable https_proxy is set although network access is denied"},
+		{"DirOutsideRoot", sandbox.Policy{Root: "/srv", Dir: "/srv/../etc"}, `working directory "/srv/../etc" is outside of the root "/srv"`},
+		{"IsolationWithoutDenial", sandbox.Policy{RequireNetworkIsolation: true}, "network isolation is required although network access is not denied"},
+		{"NegativeNice", sandbox.Policy{Nice: -5}, "nice level -5 is out of the range [0, 19]"},
+		{"NegativeMaxProcesses", sandbox.Policy{MaxProcesses: -1}, "invalid maximum number of processes -1"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.policy.Validate()
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() failed: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("Validate() error = %v, want an error containing %q", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewPolicies(t *testing.T) {
+	ps, err := sandbox.NewPolicies(sandbox.Policy{DenyNetwork: true}, map[string]sandbox.Policy{
+		"fetcher": {InheritEnv: []string{"HTTPS_PROXY"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(&sandbox.Policy{DenyNetwork: true}, ps.For("runner")); diff != "" {
+		t.Errorf("For(runner) mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(&sandbox.Policy{InheritEnv: []string{"HTTPS_PROXY"}}, ps.For("fetcher")); diff != "" {
+		t.Errorf("For(fetcher) mismatch (-want +got):\n%s", diff)
+	}
+
+	_, err = sandbox.NewPolicies(sandbox.Policy{}, map[string]sandbox.Policy{"fetcher": {Nice: 40}})
+	if want := `policy of tool "fetcher": invalid sandbox policy: nice level 40 is out of the range [0, 19]`; err == nil || err.Error() != want {
+		t.Errorf("NewPolicies() error = %v, want %q", err, want)
+	}
+}
@@ -0,0 +1,284 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package stagetoolset constrains the tools available to the model to the
+// current stage of a workflow, e.g. collect → verify → provision → confirm
+// for an onboarding agent.
+//
+// [New] returns a toolset wrapping other toolsets. The stages of the
+// workflow form a state machine: every stage lists the names of the tools
+// exposed in it, and the transitions between the stages are guarded by
+// [Predicate]s over the session state. The current stage is stored in the
+// session state, so that it persists across turns.
+//
+// A transition happens either when the model calls the built-in
+// advance_stage tool, or automatically when its trigger tool succeeds. On
+// every model call, the instruction of the current stage is appended to the
+// system instruction.
+package stagetoolset
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+)
+
+// AdvanceToolName is the name of the built-in tool moving the workflow to
+// another stage.
+const AdvanceToolName = "advance_stage"
+
+// Stage is a stage of a workflow.
+type Stage struct {
+	Name string
+	// Tools are the names of the tools exposed in the stage, in addition to
+	// the always available ones.
+	Tools []string
+	// Instruction tells the model what to do in the stage.
+	Instruction string
+}
+
+// Predicate is a condition over the session state guarding a transition.
+type Predicate struct {
+	// Name describes the condition to the model when it is not met, e.g.
+	// "verification_complete == true".
+	Name  string
+	Check func(state session.ReadonlyState) bool
+}
+
+// StateEquals returns a Predicate checking that the state holds value at
+// key. The values are compared by their JSON encoding, so that e.g. the
+// numbers read from a persisted state match.
+func StateEquals(key string, value any) Predicate {
+	want, err := json.Marshal(value)
+	return Predicate{
+		Name: fmt.Sprintf("%s == %s", key, want),
+		Check: func(state session.ReadonlyState) bool {
+			v, getErr := state.Get(key)
+			if err != nil || getErr != nil {
+				return false
+			}
+			got, err := json.Marshal(v)
+			return err == nil && string(got) == string(want)
+		},
+	}
+}
+
+// Transition is an allowed transition between two stages.
+type Transition struct {
+	From, To string
+	// Guards must all be met for the transition to happen.
+	Guards []Predicate
+	// Trigger, if set, is the name of a tool of the From stage whose
+	// successful calls make the transition automatically. Otherwise, the
+	// transition is made by the model with the advance_stage tool.
+	Trigger string
+}
+
+// Config is the configuration of the toolset returned by [New].
+type Config struct {
+	// Name is the name of the workflow. It identifies the stage of the
+	// workflow in the session state.
+	Name string
+	// Stages are the stages of the workflow. The first one is the initial
+	// stage.
+	Stages []Stage
+	// Transitions are the allowed transitions.
+	Transitions []Transition
+	// Toolsets provide the tools of the stages.
+	Toolsets []tool.Toolset
+	// AlwaysAvailable are the names of the tools exposed in all the stages.
+	AlwaysAvailable []string
+}
+
+// TransitionError is the error of a transition that is not allowed, or
+// whose guards are not met. The model gets it as the result of the
+// advance_stage tool.
+type TransitionError struct {
+	From, To string
+	// Unmet are the names of the unmet guards. It is empty if there is no
+	// transition between the stages.
+	Unmet []string
+}
+
+// Error implements error.
+func (e *TransitionError) Error() string {
+	if len(e.Unmet) == 0 {
+		return fmt.Sprintf("cannot advance from stage %q to stage %q: there is no such transition", e.From, e.To)
+	}
+	return fmt.Sprintf("cannot advance from stage %q to stage %q: unmet conditions: %s", e.From, e.To, strings.Join(e.Unmet, ", "))
+}
+
+func (e *TransitionError) result() map[string]any {
+	result := map[string]any{"error": e.Error(), "from": e.From, "to": e.To}
+	if len(e.Unmet) > 0 {
+		result["unmet_conditions"] = e.Unmet
+	}
+	return result
+}
+
+// Toolset exposes the tools of the current stage of a workflow, see the
+// package documentation.
+type Toolset struct {
+	cfg    Config
+	stages map[string]*Stage
+}
+
+// New returns a Toolset for the given workflow. The workflow is validated:
+// the stages must have unique names, and the transitions must link known
+// stages.
+func New(cfg Config) (*Toolset, error) {
+	if cfg.Name == "" {
+		return nil, errors.New("stagetoolset: name is required")
+	}
+	if len(cfg.Stages) == 0 {
+		return nil, errors.New("stagetoolset: at least one stage is required")
+	}
+	s := &Toolset{cfg: cfg, stages: make(map[string]*Stage)}
+	for i := range cfg.Stages {
+		stage := &cfg.Stages[i]
+		if stage.Name == "" {
+			return nil, fmt.Errorf("stagetoolset: stage %d has no name", i)
+		}
+		if _, ok := s.stages[stage.Name]; ok {
+			return nil, fmt.Errorf("stagetoolset: duplicate stage %q", stage.Name)
+		}
+		s.stages[stage.Name] = stage
+	}
+	seen := make(map[[2]string]bool)
+	for _, t := range cfg.Transitions {
+		from, ok := s.stages[t.From]
+		if !ok {
+			return nil, fmt.Errorf("stagetoolset: transition from unknown stage %q", t.From)
+		}
+		if _, ok := s.stages[t.To]; !ok {
+			return nil, fmt.Errorf("stagetoolset: transition to unknown stage %q", t.To)
+		}
+		if seen[[2]string{t.From, t.To}] {
+			return nil, fmt.Errorf("stagetoolset: duplicate transition from %q to %q", t.From, t.To)
+		}
+		seen[[2]string{t.From, t.To}] = true
+		if t.Trigger != "" && !slices.Contains(from.Tools, t.Trigger) && !slices.Contains(cfg.AlwaysAvailable, t.Trigger) {
+			return nil, fmt.Errorf("stagetoolset: trigger %q of the transition from %q to %q is not a tool of stage %q", t.Trigger, t.From, t.To, t.From)
+		}
+		for _, g := range t.Guards {
+			if g.Name == "" || g.Check == nil {
+				return nil, fmt.Errorf("stagetoolset: transition from %q to %q has a guard without a name or a check", t.From, t.To)
+			}
+		}
+	}
+	return s, nil
+}
+
+// StateKey returns the key of the session state holding the current stage
+// of the workflow with the given name.
+func StateKey(workflow string) string {
+	return "_adk_workflow_stage_" + workflow
+}
+
+// Stage returns the current stage in state.
+func (s *Toolset) Stage(state session.ReadonlyState) string {
+	v, err := state.Get(StateKey(s.cfg.Name))
+	if name, ok := v.(string); err == nil && ok {
+		if _, known := s.stages[name]; known {
+			return name
+		}
+	}
+	return s.cfg.Stages[0].Name
+}
+
+// Name implements tool.Toolset.
+func (s *Toolset) Name() string {
+	return s.cfg.Name
+}
+
+// Tools implements tool.Toolset. It returns the tools of the current stage,
+// the always available ones, and the built-in tools.
+func (s *Toolset) Tools(ctx agent.ReadonlyContext) ([]tool.Tool, error) {
+	stage := s.stages[s.Stage(ctx.ReadonlyState())]
+	var tools []tool.Tool
+	for _, ts := range s.cfg.Toolsets {
+		tsTools, err := ts.Tools(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the tools of toolset %q: %w", ts.Name(), err)
+		}
+		for _, t := range tsTools {
+			if slices.Contains(stage.Tools, t.Name()) || slices.Contains(s.cfg.AlwaysAvailable, t.Name()) {
+				tools = append(tools, s.wrap(t))
+			}
+		}
+	}
+	tools = append(tools, &instructionTool{set: s})
+	if targets := s.manualTargets(stage.Name); len(targets) > 0 {
+		tools = append(tools, &advanceTool{set: s, targets: targets})
+	}
+	return tools, nil
+}
+
+// manualTargets returns the stages reachable from the given stage with the
+// advance_stage tool.
+func (s *Toolset) manualTargets(from string) []string {
+	var targets []string
+	for _, t := range s.cfg.Transitions {
+		if t.From == from && t.Trigger == "" {
+			targets = append(targets, t.To)
+		}
+	}
+	return targets
+}
+
+// advance moves the workflow from the current stage to the given one, if
+// the transition is allowed by fits and its guards are met.
+func (s *Toolset) advance(state session.State, to string, fits func(Transition) bool) error {
+	from := s.Stage(state)
+	for _, t := range s.cfg.Transitions {
+		if t.From != from || t.To != to || !fits(t) {
+			continue
+		}
+		var unmet []string
+		for _, g := range t.Guards {
+			if !g.Check(state) {
+				unmet = append(unmet, g.Name)
+			}
+		}
+		if len(unmet) > 0 {
+			return &TransitionError{From: from, To: to, Unmet: unmet}
+		}
+		return state.Set(StateKey(s.cfg.Name), to)
+	}
+	return &TransitionError{From: from, To: to}
+}
+
+// instructions returns the instructions of the given stage.
+func (s *Toolset) instructions(stage *Stage) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "You are in the %q stage of the %q workflow.", stage.Name, s.cfg.Name)
+	if stage.Instruction != "" {
+		b.WriteString("\n")
+		b.WriteString(stage.Instruction)
+	}
+	if targets := s.manualTargets(stage.Name); len(targets) > 0 {
+		quoted := make([]string, len(targets))
+		for i, t := range targets {
+			quoted[i] = fmt.Sprintf("%q", t)
+		}
+		fmt.Fprintf(&b, "\nWhen the stage is complete, call the `%s` function to move to the next stage: %s.", AdvanceToolName, strings.Join(quoted, ", "))
+	}
+	return b.String()
+}
@@ -0,0 +1,246 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stagetoolset_test
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/internal/testutil"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+	"google.golang.org/adk/tool/stagetoolset"
+)
+
+type staticToolset struct {
+	tools []tool.Tool
+}
+
+func (s *staticToolset) Name() string { return "onboarding_tools" }
+
+func (s *staticToolset) Tools(agent.ReadonlyContext) ([]tool.Tool, error) { return s.tools, nil }
+
+type noArgs struct{}
+
+// newStateTool returns a tool setting the given state key to true.
+func newStateTool(t *testing.T, name, key string) tool.Tool {
+	t.Helper()
+	tl, err := functiontool.New(functiontool.Config{Name: name, Description: name}, func(ctx tool.Context, _ noArgs) (map[string]any, error) {
+		if key != "" {
+			if err := ctx.State().Set(key, true); err != nil {
+				return nil, err
+			}
+		}
+		return map[string]any{"ok": true}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tl
+}
+
+func newOnboarding(t *testing.T, transitions ...stagetoolset.Transition) *stagetoolset.Toolset {
+	t.Helper()
+	if transitions == nil {
+		transitions = []stagetoolset.Transition{
+			{From: "collect", To: "verify", Guards: []stagetoolset.Predicate{stagetoolset.StateEquals("info_collected", true)}},
+			{From: "verify", To: "provision", Guards: []stagetoolset.Predicate{stagetoolset.StateEquals("verification_complete", true)}},
+			{From: "provision", To: "confirm", Trigger: "provision_account"},
+		}
+	}
+	ts, err := stagetoolset.New(stagetoolset.Config{
+		Name: "onboarding",
+		Stages: []stagetoolset.Stage{
+			{Name: "collect", Tools: []string{"collect_info"}, Instruction: "Collect the name of the user."},
+			{Name: "verify", Tools: []string{"verify_identity"}, Instruction: "Verify the identity of the user."},
+			{Name: "provision", Tools: []string{"provision_account"}, Instruction: "Provision the account."},
+			{Name: "confirm", Tools: []string{"send_confirmation"}, Instruction: "Confirm the account to the user."},
+		},
+		Transitions: transitions,
+		Toolsets: []tool.Toolset{&staticToolset{tools: []tool.Tool{
+			newStateTool(t, "collect_info", "info_collected"),
+			newStateTool(t, "verify_identity", "verification_complete"),
+			newStateTool(t, "provision_account", ""),
+			newStateTool(t, "send_confirmation", ""),
+			newStateTool(t, "get_help", ""),
+		}}},
+		AlwaysAvailable: []string{"get_help"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ts
+}
+
+func call(name string, args map[string]any) *genai.Content {
+	return genai.NewContentFromFunctionCall(name, args, genai.RoleModel)
+}
+
+// requestTools returns the names of the tools declared in req.
+func requestTools(req *model.LLMRequest) []string {
+	return slices.Sorted(maps.Keys(req.Tools))
+}
+
+func requestInstruction(req *model.LLMRequest) string {
+	var parts []string
+	for _, p := range req.Config.SystemInstruction.Parts {
+		parts = append(parts, p.Text)
+	}
+	return strings.Join(parts, "\n")
+}
+
+func functionResponses(t *testing.T, runner *testutil.TestAgentRunner, message string) []map[string]any {
+	t.Helper()
+	events, err := testutil.CollectEvents(runner.Run(t, "session", message))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var responses []map[string]any
+	for _, ev := range events {
+		for _, p := range ev.Content.Parts {
+			if p.FunctionResponse != nil {
+				responses = append(responses, p.FunctionResponse.Response)
+			}
+		}
+	}
+	return responses
+}
+
+func TestWorkflow(t *testing.T) {
+	testModel := &testutil.MockModel{Responses: []*genai.Content{
+		// Turn 1: collect, then advance.
+		call("collect_info", nil),
+		call("advance_stage", map[string]any{"stage": "verify"}),
+		genai.NewContentFromText("Let's verify your identity.", genai.RoleModel),
+		// Turn 2: the advance is blocked until the identity is verified;
+		// provisioning advances automatically.
+		call("advance_stage", map[string]any{"stage": "provision"}),
+		call("verify_identity", nil),
+		call("advance_stage", map[string]any{"stage": "provision"}),
+		call("provision_account", nil),
+		genai.NewContentFromText("Your account is ready.", genai.RoleModel),
+	}}
+	a, err := llmagent.New(llmagent.Config{Name: "onboarding_agent", Model: testModel, Toolsets: []tool.Toolset{newOnboarding(t)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	runner := testutil.NewTestAgentRunner(t, a)
+
+	want := []map[string]any{{"ok": true}, {"stage": "verify"}}
+	if diff := cmp.Diff(want, functionResponses(t, runner, "hi, I'm Ada")); diff != "" {
+		t.Errorf("turn 1 responses mismatch (-want +got):\n%s", diff)
+	}
+	want = []map[string]any{
+		{
+			"error":            `cannot advance from stage "verify" to stage "provision": unmet conditions: verification_complete == true`,
+			"from":             "verify",
+			"to":               "provision",
+			"unmet_conditions": []string{"verification_complete == true"},
+		},
+		{"ok": true},
+		{"stage": "provision"},
+		{"ok": true},
+	}
+	if diff := cmp.Diff(want, functionResponses(t, runner, "here is my ID")); diff != "" {
+		t.Errorf("turn 2 responses mismatch (-want +got):\n%s", diff)
+	}
+
+	wantTools := [][]string{
+		{"advance_stage", "collect_info", "get_help"},
+		{"advance_stage", "collect_info", "get_help"},
+		{"advance_stage", "get_help", "verify_identity"},
+		// The stage persisted across the turns.
+		{"advance_stage", "get_help", "verify_identity"},
+		{"advance_stage", "get_help", "verify_identity"},
+		{"advance_stage", "get_help", "verify_identity"},
+		{"get_help", "provision_account"},
+		{"get_help", "send_confirmation"},
+	}
+	var gotTools [][]string
+	for _, req := range testModel.Requests {
+		gotTools = append(gotTools, requestTools(req))
+	}
+	if diff := cmp.Diff(wantTools, gotTools); diff != "" {
+		t.Errorf("declared tools mismatch (-want +got):\n%s", diff)
+	}
+
+	for i, want := range map[int]string{
+		0: "You are in the \"collect\" stage of the \"onboarding\" workflow.\nCollect the name of the user.\n" +
+			"When the stage is complete, call the `advance_stage` function to move to the next stage: \"verify\".",
+		7: "You are in the \"confirm\" stage of the \"onboarding\" workflow.\nConfirm the account to the user.",
+	} {
+		if got := requestInstruction(testModel.Requests[i]); !strings.Contains(got, want) {
+			t.Errorf("instruction of request %d = %q, want it to contain %q", i, got, want)
+		}
+	}
+}
+
+func TestWorkflow_BlockedTrigger(t *testing.T) {
+	ts := newOnboarding(t,
+		stagetoolset.Transition{From: "collect", To: "verify", Trigger: "collect_info", Guards: []stagetoolset.Predicate{stagetoolset.StateEquals("consent", "yes")}},
+	)
+	testModel := &testutil.MockModel{Responses: []*genai.Content{
+		call("collect_info", nil),
+		genai.NewContentFromText("I need your consent first.", genai.RoleModel),
+	}}
+	a, err := llmagent.New(llmagent.Config{Name: "onboarding_agent", Model: testModel, Toolsets: []tool.Toolset{ts}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	runner := testutil.NewTestAgentRunner(t, a)
+
+	// The trigger succeeds but the guard is not met: the workflow stays in
+	// the stage.
+	want := []map[string]any{{"ok": true}}
+	if diff := cmp.Diff(want, functionResponses(t, runner, "hi")); diff != "" {
+		t.Errorf("responses mismatch (-want +got):\n%s", diff)
+	}
+	for i, req := range testModel.Requests {
+		if diff := cmp.Diff([]string{"collect_info", "get_help"}, requestTools(req)); diff != "" {
+			t.Errorf("declared tools of request %d mismatch (-want +got):\n%s", i, diff)
+		}
+	}
+}
+
+func TestNew_Invalid(t *testing.T) {
+	stages := []stagetoolset.Stage{{Name: "a", Tools: []string{"x"}}, {Name: "b"}}
+	for _, tc := range []struct {
+		name    string
+		cfg     stagetoolset.Config
+		wantErr string
+	}{
+		{"NoName", stagetoolset.Config{Stages: stages}, "name is required"},
+		{"NoStages", stagetoolset.Config{Name: "w"}, "at least one stage is required"},
+		{"DuplicateStage", stagetoolset.Config{Name: "w", Stages: []stagetoolset.Stage{{Name: "a"}, {Name: "a"}}}, `duplicate stage "a"`},
+		{"UnknownStage", stagetoolset.Config{Name: "w", Stages: stages, Transitions: []stagetoolset.Transition{{From: "a", To: "c"}}}, `transition to unknown stage "c"`},
+		{"TriggerOfOtherStage", stagetoolset.Config{Name: "w", Stages: stages, Transitions: []stagetoolset.Transition{{From: "b", To: "a", Trigger: "x"}}}, `trigger "x" of the transition from "b" to "a" is not a tool of stage "b"`},
+		{"InvalidGuard", stagetoolset.Config{Name: "w", Stages: stages, Transitions: []stagetoolset.Transition{{From: "a", To: "b", Guards: []stagetoolset.Predicate{{Name: "g"}}}}}, "has a guard without a name or a check"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := stagetoolset.New(tc.cfg)
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("New() error = %v, want an error containing %q", err, tc.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,180 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stagetoolset
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/internal/toolinternal"
+	"google.golang.org/adk/internal/toolinternal/toolutils"
+	"google.golang.org/adk/internal/utils"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/tool"
+)
+
+// wrap returns t, whose calls are checked against the current stage and
+// trigger the automatic transitions, if it is a function tool.
+func (s *Toolset) wrap(t tool.Tool) tool.Tool {
+	ft, ok := t.(toolinternal.FunctionTool)
+	if !ok {
+		return t
+	}
+	return &stageTool{FunctionTool: ft, set: s}
+}
+
+type stageTool struct {
+	toolinternal.FunctionTool
+	set *Toolset
+}
+
+// Run runs the wrapped tool if it is available in the current stage, and
+// makes the transition it triggers once it succeeds.
+func (t *stageTool) Run(ctx tool.Context, args any) (map[string]any, error) {
+	name := t.Name()
+	stage := t.set.Stage(ctx.State())
+	if !slices.Contains(t.set.stages[stage].Tools, name) && !slices.Contains(t.set.cfg.AlwaysAvailable, name) {
+		return nil, fmt.Errorf("tool %q is not available in stage %q", name, stage)
+	}
+	result, err := t.FunctionTool.Run(ctx, args)
+	if err != nil {
+		return result, err
+	}
+	if _, failed := result["error"]; failed {
+		return result, nil
+	}
+	for _, tr := range t.set.cfg.Transitions {
+		if tr.From != stage || tr.Trigger != name {
+			continue
+		}
+		err := t.set.advance(ctx.State(), tr.To, func(c Transition) bool { return c.Trigger == name })
+		var terr *TransitionError
+		if errors.As(err, &terr) {
+			// The guards are not met: the workflow stays in the stage.
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		break
+	}
+	return result, nil
+}
+
+// ProcessRequest lets the wrapped tool declare itself, and dispatches its
+// calls to t.
+func (t *stageTool) ProcessRequest(ctx tool.Context, req *model.LLMRequest) error {
+	if p, ok := t.FunctionTool.(toolinternal.RequestProcessor); ok {
+		if err := p.ProcessRequest(ctx, req); err != nil {
+			return err
+		}
+		if req.Tools[t.Name()] == t.FunctionTool {
+			req.Tools[t.Name()] = t
+		}
+		return nil
+	}
+	return toolutils.PackTool(ctx, req, t)
+}
+
+// instructionTool appends the instructions of the current stage to the
+// requests.
+type instructionTool struct {
+	set *Toolset
+}
+
+func (t *instructionTool) Name() string {
+	return "workflow_stage_instruction"
+}
+
+func (t *instructionTool) Description() string {
+	return "Tells the model about the current stage of the workflow."
+}
+
+func (t *instructionTool) IsLongRunning() bool {
+	return false
+}
+
+func (t *instructionTool) ProcessRequest(ctx tool.Context, req *model.LLMRequest) error {
+	utils.AppendInstructions(req, t.set.instructions(t.set.stages[t.set.Stage(ctx.State())]))
+	return nil
+}
+
+// advanceTool moves the workflow to one of the stages reachable from the
+// current one.
+type advanceTool struct {
+	set     *Toolset
+	targets []string
+}
+
+func (t *advanceTool) Name() string {
+	return AdvanceToolName
+}
+
+func (t *advanceTool) Description() string {
+	return "Moves the workflow to the given stage, once the current stage is complete."
+}
+
+func (t *advanceTool) IsLongRunning() bool {
+	return false
+}
+
+func (t *advanceTool) Declaration() *genai.FunctionDeclaration {
+	return &genai.FunctionDeclaration{
+		Name:        t.Name(),
+		Description: t.Description(),
+		Parameters: &genai.Schema{
+			Type: genai.TypeObject,
+			Properties: map[string]*genai.Schema{
+				"stage": {Type: genai.TypeString, Enum: t.targets, Description: "The stage to move to."},
+			},
+			Required: []string{"stage"},
+		},
+	}
+}
+
+func (t *advanceTool) Run(ctx tool.Context, args any) (map[string]any, error) {
+	m, ok := args.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("unexpected args type, got: %T", args)
+	}
+	to, ok := m["stage"].(string)
+	if !ok {
+		return nil, fmt.Errorf("stage must be a string, got: %T", m["stage"])
+	}
+	err := t.set.advance(ctx.State(), to, func(c Transition) bool { return c.Trigger == "" })
+	var terr *TransitionError
+	if errors.As(err, &terr) {
+		return terr.result(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{"stage": to}, nil
+}
+
+func (t *advanceTool) ProcessRequest(ctx tool.Context, req *model.LLMRequest) error {
+	return toolutils.PackTool(ctx, req, t)
+}
+
+var (
+	_ toolinternal.FunctionTool     = (*stageTool)(nil)
+	_ toolinternal.RequestProcessor = (*stageTool)(nil)
+	_ toolinternal.RequestProcessor = (*instructionTool)(nil)
+	_ toolinternal.FunctionTool     = (*advanceTool)(nil)
+	_ toolinternal.RequestProcessor = (*advanceTool)(nil)
+)
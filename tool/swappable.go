@@ -0,0 +1,289 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tool
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"slices"
+	"sync"
+	"sync/atomic"
+
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/internal/toolinternal/toolutils"
+	"google.golang.org/adk/model"
+)
+
+// ErrUnavailable is wrapped by the errors of the calls to a disabled
+// [SwappableTool].
+var ErrUnavailable = errors.New("tool is temporarily unavailable")
+
+// UnavailableError is returned by the calls to a disabled [SwappableTool].
+type UnavailableError struct {
+	Tool   string
+	Reason string
+}
+
+// Error implements error.
+func (e *UnavailableError) Error() string {
+	if e.Reason == "" {
+		return fmt.Sprintf("tool %q is temporarily unavailable", e.Tool)
+	}
+	return fmt.Sprintf("tool %q is temporarily unavailable: %s", e.Tool, e.Reason)
+}
+
+// Unwrap returns ErrUnavailable.
+func (e *UnavailableError) Unwrap() error {
+	return ErrUnavailable
+}
+
+// The interfaces implemented by the tools run by the agents, see
+// internal/toolinternal.
+type (
+	declarer interface {
+		Declaration() *genai.FunctionDeclaration
+	}
+	runner interface {
+		Run(ctx Context, args any) (map[string]any, error)
+	}
+	requestProcessor interface {
+		ProcessRequest(ctx Context, req *model.LLMRequest) error
+	}
+)
+
+// SwappableTool is a Tool whose implementation can be replaced or disabled
+// at runtime, e.g. by a feature flag watcher or an admin endpoint, without
+// rebuilding the agents using it.
+//
+// The calls in flight complete against the implementation they started
+// with.
+type SwappableTool struct {
+	name   string
+	state  atomic.Pointer[swappableState]
+	hide   atomic.Bool
+	swapMu sync.Mutex
+}
+
+type swappableState struct {
+	tool     Tool
+	disabled bool
+	reason   string
+}
+
+var (
+	swappablesMu sync.RWMutex
+	swappables   = make(map[string]*SwappableTool)
+)
+
+// Swappable returns a SwappableTool named name delegating to initial, which
+// must have the same name. The tool is registered in a process-wide registry
+// where [LookupSwappable] finds it; it replaces the tool previously
+// registered under the same name.
+func Swappable(name string, initial Tool) *SwappableTool {
+	if initial == nil || initial.Name() != name {
+		panic(fmt.Sprintf("tool.Swappable: initial tool must be named %q", name))
+	}
+	t := &SwappableTool{name: name}
+	t.state.Store(&swappableState{tool: initial})
+
+	swappablesMu.Lock()
+	defer swappablesMu.Unlock()
+	swappables[name] = t
+	return t
+}
+
+// LookupSwappable returns the SwappableTool registered under name.
+func LookupSwappable(name string) (*SwappableTool, bool) {
+	swappablesMu.RLock()
+	defer swappablesMu.RUnlock()
+	t, ok := swappables[name]
+	return t, ok
+}
+
+// SwappableTools returns the registered SwappableTools, sorted by name.
+func SwappableTools() []*SwappableTool {
+	swappablesMu.RLock()
+	defer swappablesMu.RUnlock()
+	names := slices.Sorted(maps.Keys(swappables))
+	tools := make([]*SwappableTool, 0, len(names))
+	for _, name := range names {
+		tools = append(tools, swappables[name])
+	}
+	return tools
+}
+
+// Name implements Tool.
+func (t *SwappableTool) Name() string {
+	return t.name
+}
+
+// Description implements Tool.
+func (t *SwappableTool) Description() string {
+	return t.state.Load().tool.Description()
+}
+
+// IsLongRunning implements Tool.
+func (t *SwappableTool) IsLongRunning() bool {
+	return t.state.Load().tool.IsLongRunning()
+}
+
+// IsReadOnly reports whether the current implementation is annotated as
+// read-only, see [IsReadOnly].
+func (t *SwappableTool) IsReadOnly() bool {
+	return IsReadOnly(t.state.Load().tool)
+}
+
+// Current returns the current implementation.
+func (t *SwappableTool) Current() Tool {
+	return t.state.Load().tool
+}
+
+// Swap replaces the implementation. The new tool must have the same name,
+// and be of the same kind: a function tool can only be replaced by a
+// function tool, and a long-running tool by a long-running tool. Changes of
+// the declaration are allowed: they are logged, and the model is told about
+// them by the agents. Swap keeps the tool disabled if it is.
+func (t *SwappableTool) Swap(newTool Tool) error {
+	if newTool == nil {
+		return fmt.Errorf("cannot swap tool %q with nil", t.name)
+	}
+	t.swapMu.Lock()
+	defer t.swapMu.Unlock()
+	current := t.state.Load()
+	if err := checkCompatible(current.tool, newTool); err != nil {
+		return fmt.Errorf("cannot swap tool %q: %w", t.name, err)
+	}
+	if changed, err := declarationChanged(current.tool, newTool); err != nil {
+		return fmt.Errorf("cannot swap tool %q: %w", t.name, err)
+	} else if changed {
+		log.Printf("Tool %q swapped with an implementation declaring a different schema", t.name)
+	}
+	next := *current
+	next.tool = newTool
+	t.state.Store(&next)
+	return nil
+}
+
+// Disable makes the calls to the tool fail with an [UnavailableError]
+// giving reason, until [SwappableTool.Enable] is called. The tool is still
+// declared to the model, unless [SwappableTool.HideWhenDisabled] is set.
+func (t *SwappableTool) Disable(reason string) {
+	t.swapMu.Lock()
+	defer t.swapMu.Unlock()
+	next := *t.state.Load()
+	next.disabled, next.reason = true, reason
+	t.state.Store(&next)
+}
+
+// Enable enables the tool disabled by [SwappableTool.Disable].
+func (t *SwappableTool) Enable() {
+	t.swapMu.Lock()
+	defer t.swapMu.Unlock()
+	next := *t.state.Load()
+	next.disabled, next.reason = false, ""
+	t.state.Store(&next)
+}
+
+// Disabled reports whether the tool is disabled, and why.
+func (t *SwappableTool) Disabled() (bool, string) {
+	s := t.state.Load()
+	return s.disabled, s.reason
+}
+
+// HideWhenDisabled sets whether the disabled tool is left out of the tools
+// declared to the model. It returns t.
+func (t *SwappableTool) HideWhenDisabled(hide bool) *SwappableTool {
+	t.hide.Store(hide)
+	return t
+}
+
+// Declaration returns the declaration of the current implementation, or nil
+// if it is not a function tool.
+func (t *SwappableTool) Declaration() *genai.FunctionDeclaration {
+	if d, ok := t.state.Load().tool.(declarer); ok {
+		return d.Declaration()
+	}
+	return nil
+}
+
+// Run runs the current implementation.
+func (t *SwappableTool) Run(ctx Context, args any) (map[string]any, error) {
+	s := t.state.Load()
+	if s.disabled {
+		return nil, &UnavailableError{Tool: t.name, Reason: s.reason}
+	}
+	r, ok := s.tool.(runner)
+	if !ok {
+		return nil, fmt.Errorf("tool %q is not a function tool", t.name)
+	}
+	return r.Run(ctx, args)
+}
+
+// ProcessRequest declares the current implementation in req. Calls to a
+// function tool are dispatched through t, so that they see the later swaps.
+func (t *SwappableTool) ProcessRequest(ctx Context, req *model.LLMRequest) error {
+	s := t.state.Load()
+	if s.disabled && t.hide.Load() {
+		return nil
+	}
+	if _, ok := s.tool.(declarer); ok {
+		return toolutils.PackTool(ctx, req, t)
+	}
+	if s.disabled {
+		// Other tools are run by the model: they cannot be answered with an
+		// error.
+		return nil
+	}
+	p, ok := s.tool.(requestProcessor)
+	if !ok {
+		return fmt.Errorf("tool %q does not implement RequestProcessor() method", t.name)
+	}
+	return p.ProcessRequest(ctx, req)
+}
+
+func checkCompatible(current, next Tool) error {
+	if next.Name() != current.Name() {
+		return fmt.Errorf("tool is named %q, want %q", next.Name(), current.Name())
+	}
+	_, currentFunc := current.(declarer)
+	_, nextFunc := next.(declarer)
+	if currentFunc != nextFunc {
+		return errors.New("a function tool cannot be swapped with another kind of tool")
+	}
+	if current.IsLongRunning() != next.IsLongRunning() {
+		return errors.New("tool cannot change whether it is long-running")
+	}
+	return nil
+}
+
+func declarationChanged(current, next Tool) (bool, error) {
+	currentDecl, ok := current.(declarer)
+	if !ok {
+		return false, nil
+	}
+	a, err := json.Marshal(currentDecl.Declaration())
+	if err != nil {
+		return false, err
+	}
+	b, err := json.Marshal(next.(declarer).Declaration())
+	if err != nil {
+		return false, err
+	}
+	return string(a) != string(b), nil
+}
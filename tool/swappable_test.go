@@ -0,0 +1,259 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tool_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/internal/testutil"
+	"google.golang.org/adk/internal/toolinternal"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+	"google.golang.org/adk/tool/geminitool"
+)
+
+type lookupArgs struct {
+	Query string `json:"query"`
+}
+
+type lookupResult struct {
+	Version string `json:"version"`
+}
+
+// newLookupTool returns a function tool returning version. If release is
+// not nil, the calls signal started and wait for release.
+func newLookupTool(t *testing.T, name, version string, started chan<- struct{}, release <-chan struct{}) tool.Tool {
+	t.Helper()
+	lookup, err := functiontool.New(functiontool.Config{Name: name, Description: "looks things up"}, func(_ tool.Context, args lookupArgs) (lookupResult, error) {
+		if release != nil {
+			started <- struct{}{}
+			<-release
+		}
+		return lookupResult{Version: version}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return lookup
+}
+
+func run(t *testing.T, tl tool.Tool) (map[string]any, error) {
+	t.Helper()
+	ft, ok := tl.(toolinternal.FunctionTool)
+	if !ok {
+		t.Fatalf("%T is not a function tool", tl)
+	}
+	return ft.Run(nil, map[string]any{"query": "q"})
+}
+
+func TestSwappable_SwapDuringCalls(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	swappable := tool.Swappable("lookup_swap", newLookupTool(t, "lookup_swap", "v1", started, release))
+
+	// A call started before the swap completes against v1.
+	inFlight := make(chan map[string]any)
+	go func() {
+		result, err := run(t, swappable)
+		if err != nil {
+			t.Errorf("Run() failed: %v", err)
+		}
+		inFlight <- result
+	}()
+	<-started
+
+	v2 := newLookupTool(t, "lookup_swap", "v2", nil, nil)
+	v3 := newLookupTool(t, "lookup_swap", "v3", nil, nil)
+	if err := swappable.Swap(v2); err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			next := v2
+			if i%2 == 1 {
+				next = v3
+			}
+			if err := swappable.Swap(next); err != nil {
+				t.Errorf("Swap() failed: %v", err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			result, err := run(t, swappable)
+			if err != nil {
+				t.Errorf("Run() failed: %v", err)
+			} else if v := result["version"]; v != "v2" && v != "v3" {
+				t.Errorf("Run() = %v, want a version", result)
+			}
+		}()
+	}
+	wg.Wait()
+	if err := swappable.Swap(v2); err != nil {
+		t.Fatal(err)
+	}
+	if result, err := run(t, swappable); err != nil || result["version"] != "v2" {
+		t.Errorf("Run() after swap = (%v, %v), want version v2", result, err)
+	}
+
+	close(release)
+	if result := <-inFlight; result["version"] != "v1" {
+		t.Errorf("in-flight Run() = %v, want version v1", result)
+	}
+}
+
+func TestSwappable_IncompatibleSwap(t *testing.T) {
+	swappable := tool.Swappable("lookup_incompatible", newLookupTool(t, "lookup_incompatible", "v1", nil, nil))
+
+	longRunning, err := functiontool.New(functiontool.Config{Name: "lookup_incompatible", IsLongRunning: true}, func(tool.Context, lookupArgs) (lookupResult, error) {
+		return lookupResult{}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		name string
+		tool tool.Tool
+	}{
+		{"Nil", nil},
+		{"OtherName", newLookupTool(t, "other", "v2", nil, nil)},
+		{"NotFunction", geminitool.New("lookup_incompatible", &genai.Tool{GoogleSearch: &genai.GoogleSearch{}})},
+		{"LongRunning", longRunning},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := swappable.Swap(tc.tool); err == nil {
+				t.Error("Swap() succeeded, want an error")
+			}
+			if result, err := run(t, swappable); err != nil || result["version"] != "v1" {
+				t.Errorf("Run() after a rejected swap = (%v, %v), want version v1", result, err)
+			}
+		})
+	}
+
+	// Schema changes are allowed.
+	type otherArgs struct {
+		ID int `json:"id"`
+	}
+	changed, err := functiontool.New(functiontool.Config{Name: "lookup_incompatible"}, func(tool.Context, otherArgs) (lookupResult, error) {
+		return lookupResult{Version: "v2"}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := swappable.Swap(changed); err != nil {
+		t.Errorf("Swap() with a different schema failed: %v", err)
+	}
+}
+
+func TestSwappable_Disable(t *testing.T) {
+	swappable := tool.Swappable("lookup_disable", newLookupTool(t, "lookup_disable", "v1", nil, nil))
+	if got, ok := tool.LookupSwappable("lookup_disable"); !ok || got != swappable {
+		t.Fatalf("LookupSwappable() = (%v, %v), want the registered tool", got, ok)
+	}
+
+	declared := func(t *testing.T) []string {
+		t.Helper()
+		req := &model.LLMRequest{}
+		if err := swappable.ProcessRequest(nil, req); err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for name := range req.Tools {
+			names = append(names, name)
+		}
+		return names
+	}
+
+	swappable.Disable("upstream outage")
+	_, err := run(t, swappable)
+	var unavailable *tool.UnavailableError
+	if !errors.As(err, &unavailable) || !errors.Is(err, tool.ErrUnavailable) {
+		t.Fatalf("Run() of a disabled tool error = %v, want an UnavailableError", err)
+	}
+	if diff := cmp.Diff(&tool.UnavailableError{Tool: "lookup_disable", Reason: "upstream outage"}, unavailable); diff != "" {
+		t.Errorf("UnavailableError mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"lookup_disable"}, declared(t)); diff != "" {
+		t.Errorf("declared tools of a disabled tool mismatch (-want +got):\n%s", diff)
+	}
+
+	swappable.HideWhenDisabled(true)
+	if got := declared(t); len(got) != 0 {
+		t.Errorf("declared tools of a hidden disabled tool = %v, want none", got)
+	}
+
+	swappable.Enable()
+	if diff := cmp.Diff([]string{"lookup_disable"}, declared(t)); diff != "" {
+		t.Errorf("declared tools of an enabled tool mismatch (-want +got):\n%s", diff)
+	}
+	if result, err := run(t, swappable); err != nil || result["version"] != "v1" {
+		t.Errorf("Run() of an enabled tool = (%v, %v), want version v1", result, err)
+	}
+}
+
+func TestSwappable_Agent(t *testing.T) {
+	swappable := tool.Swappable("lookup_agent", newLookupTool(t, "lookup_agent", "v1", nil, nil))
+	call := genai.NewContentFromFunctionCall("lookup_agent", map[string]any{"query": "q"}, genai.RoleModel)
+	testModel := &testutil.MockModel{Responses: []*genai.Content{
+		call,
+		genai.NewContentFromText("done", genai.RoleModel),
+		call,
+		genai.NewContentFromText("done", genai.RoleModel),
+	}}
+	a, err := llmagent.New(llmagent.Config{Name: "agent", Model: testModel, Tools: []tool.Tool{swappable}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	runner := testutil.NewTestAgentRunner(t, a)
+
+	responses := func(t *testing.T) []map[string]any {
+		t.Helper()
+		var got []map[string]any
+		events, err := testutil.CollectEvents(runner.Run(t, "session", "look it up"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, ev := range events {
+			for _, p := range ev.Content.Parts {
+				if p.FunctionResponse != nil {
+					got = append(got, p.FunctionResponse.Response)
+				}
+			}
+		}
+		return got
+	}
+
+	if diff := cmp.Diff([]map[string]any{{"version": "v1"}}, responses(t)); diff != "" {
+		t.Errorf("responses mismatch (-want +got):\n%s", diff)
+	}
+
+	// The agent is not rebuilt: it dispatches to the swapped tool.
+	if err := swappable.Swap(newLookupTool(t, "lookup_agent", "v2", nil, nil)); err != nil {
+		t.Fatal(err)
+	}
+	swappable.Disable("maintenance")
+	want := []map[string]any{{"error": `tool "lookup_agent" is temporarily unavailable: maintenance`}}
+	if diff := cmp.Diff(want, responses(t)); diff != "" {
+		t.Errorf("responses of the disabled tool mismatch (-want +got):\n%s", diff)
+	}
+}
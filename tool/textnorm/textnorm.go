@@ -0,0 +1,146 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package textnorm normalizes the text of tool arguments, so that the
+// identifiers spoken aloud, typed in another script or with other accents
+// match the ones known by the tools: "ＡＢＣ１２３" matches "abc123" and
+// "Müller" matches "muller".
+//
+// The function tools apply the normalizations given by the adk tag of their
+// string arguments, see [google.golang.org/adk/tool/functiontool].
+package textnorm
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/language"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+
+	"google.golang.org/adk/session"
+)
+
+// LocaleStateKey is the session state key of the locale of the user, e.g.
+// "tr-TR". It is used by the function tools for the locale-aware
+// normalizations.
+const LocaleStateKey = session.KeyPrefixUser + "locale"
+
+// Form is a normalization of the text.
+type Form string
+
+const (
+	// FormNFKC applies the Unicode NFKC normalization: compatibility
+	// characters, such as the full-width forms, are replaced by their
+	// canonical equivalents.
+	FormNFKC Form = "nfkc"
+	// FormFold applies FormNFKC and folds the case, see [Fold].
+	FormFold Form = "fold"
+	// FormDiacritics strips the diacritics, see [StripDiacritics].
+	FormDiacritics Form = "diacritics"
+	// FormSpace collapses the white space, see [CollapseSpace].
+	FormSpace Form = "space"
+	// FormTrim removes the leading and trailing white space.
+	FormTrim Form = "trim"
+)
+
+// ParseForms parses a comma-separated list of forms, e.g. "fold,trim".
+func ParseForms(s string) ([]Form, error) {
+	var forms []Form
+	for name := range strings.SplitSeq(s, ",") {
+		form := Form(strings.TrimSpace(name))
+		switch form {
+		case FormNFKC, FormFold, FormDiacritics, FormSpace, FormTrim:
+			forms = append(forms, form)
+		default:
+			return nil, fmt.Errorf("unknown text normalization %q", name)
+		}
+	}
+	return forms, nil
+}
+
+// Normalize applies forms to s, in order. locale is used by the
+// locale-aware forms; it may be empty.
+func Normalize(s, locale string, forms ...Form) string {
+	for _, form := range forms {
+		switch form {
+		case FormNFKC:
+			s = NFKC(s)
+		case FormFold:
+			s = Fold(NFKC(s), locale)
+		case FormDiacritics:
+			s = StripDiacritics(s)
+		case FormSpace:
+			s = CollapseSpace(s)
+		case FormTrim:
+			s = strings.TrimSpace(s)
+		}
+	}
+	return s
+}
+
+// NFKC returns the NFKC normalization of s.
+func NFKC(s string) string {
+	return norm.NFKC.String(s)
+}
+
+// Fold folds the case of s, for case-insensitive comparisons. For the
+// Turkish and Azerbaijani locales, the dotted and dotless i are folded to
+// distinct letters: "I" is folded to "ı" and "İ" to "i". Other locales,
+// including the empty or invalid ones, use the Unicode case folding.
+func Fold(s, locale string) string {
+	if tag, err := language.Parse(locale); err == nil {
+		if base, _ := tag.Base(); base.String() == "tr" || base.String() == "az" {
+			return cases.Lower(tag).String(s)
+		}
+	}
+	return cases.Fold().String(s)
+}
+
+// StripDiacritics removes the diacritical marks from s: "Müller" becomes
+// "Muller". Letters which are not decomposed by Unicode, such as "ß" or "ø",
+// are kept.
+func StripDiacritics(s string) string {
+	t := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	result, _, err := transform.String(t, s)
+	if err != nil {
+		return s
+	}
+	return result
+}
+
+// CollapseSpace replaces the runs of white space in s by a single space.
+// Leading and trailing white space is collapsed but not removed.
+func CollapseSpace(s string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range s {
+		if unicode.IsSpace(r) {
+			space = true
+			continue
+		}
+		if space {
+			b.WriteByte(' ')
+			space = false
+		}
+		b.WriteRune(r)
+	}
+	if space {
+		b.WriteByte(' ')
+	}
+	return b.String()
+}
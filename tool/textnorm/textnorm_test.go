@@ -0,0 +1,83 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package textnorm_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"google.golang.org/adk/tool/textnorm"
+)
+
+func TestNormalizations(t *testing.T) {
+	testCases := []struct {
+		name string
+		fn   func(string) string
+		in   string
+		want string
+	}{
+		{"NFKC/FullWidth", textnorm.NFKC, "ＡＢＣ１２３", "ABC123"},
+		{"NFKC/Ligature", textnorm.NFKC, "ﬁle", "file"},
+		{"Fold", func(s string) string { return textnorm.Fold(s, "") }, "Straße MÜLLER", "strasse müller"},
+		{"Fold/English", func(s string) string { return textnorm.Fold(s, "en-US") }, "ISTANBUL", "istanbul"},
+		{"StripDiacritics", textnorm.StripDiacritics, "Müller Ångström café", "Muller Angstrom cafe"},
+		{"StripDiacritics/Kept", textnorm.StripDiacritics, "Straße Øre", "Straße Øre"},
+		{"CollapseSpace", textnorm.CollapseSpace, " John \t\n  Smith　 ", " John Smith "},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.fn(tc.in); got != tc.want {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestFold_Turkish(t *testing.T) {
+	testCases := []struct {
+		locale string
+		in     string
+		want   string
+	}{
+		{"tr", "DİYARBAKIR", "diyarbakır"},
+		{"tr-TR", "ISPARTA", "ısparta"},
+		{"az", "İLHAM", "ilham"},
+		// Without a Turkish locale, the dotted capital I keeps its dot.
+		{"", "DİYARBAKIR", "di̇yarbakir"},
+		{"not a locale", "ISPARTA", "isparta"},
+	}
+	for _, tc := range testCases {
+		if got := textnorm.Fold(tc.in, tc.locale); got != tc.want {
+			t.Errorf("Fold(%q, %q) = %q, want %q", tc.in, tc.locale, got, tc.want)
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	forms, err := textnorm.ParseForms("fold, diacritics,space,trim")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]textnorm.Form{textnorm.FormFold, textnorm.FormDiacritics, textnorm.FormSpace, textnorm.FormTrim}, forms); diff != "" {
+		t.Errorf("ParseForms() mismatch (-want +got):\n%s", diff)
+	}
+	if got, want := textnorm.Normalize("  ＡＢＣ１２３   Müller ", "", forms...), "abc123 muller"; got != want {
+		t.Errorf("Normalize() = %q, want %q", got, want)
+	}
+	if _, err := textnorm.ParseForms("fold,upper"); err == nil {
+		t.Error("ParseForms() with an unknown form succeeded, want an error")
+	}
+}
@@ -53,6 +53,34 @@ type Context interface {
 	SearchMemory(context.Context, string) (*memory.SearchResponse, error)
 }
 
+// StateStale reports whether the session state seen by the tool may be
+// stale: state changes were committed by a concurrent invocation of the same
+// session since it was read. Cautious tools can then call [RefreshState]
+// before reading the state. It reports false if the session service does
+// not detect the conflicts, see [session.VersionedSession].
+func StateStale(ctx Context) bool {
+	s, ok := ctx.(interface{ StateStale() bool })
+	return ok && s.StateStale()
+}
+
+// RefreshState reads the session state seen by the tool again. The state
+// changes made by the tool so far are kept.
+func RefreshState(ctx Context) error {
+	if s, ok := ctx.(interface{ RefreshState(context.Context) error }); ok {
+		return s.RefreshState(ctx)
+	}
+	return nil
+}
+
+// IsReadOnly reports whether t is annotated as read-only and idempotent:
+// calling it has no side effects, and calling it again with the same
+// arguments returns the same result. Such tools may be called
+// speculatively, e.g. to prefetch their results.
+func IsReadOnly(t Tool) bool {
+	r, ok := t.(interface{ IsReadOnly() bool })
+	return ok && r.IsReadOnly()
+}
+
 // Toolset is an interface for a collection of tools. It allows grouping
 // related tools together and providing them to an agent.
 type Toolset interface {
@@ -15,9 +15,23 @@
 package tool_test
 
 import (
+	"context"
+	"errors"
+	"iter"
+	"slices"
+	"strings"
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/internal/testutil"
 	"google.golang.org/adk/internal/toolinternal"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/runner"
+	"google.golang.org/adk/session"
 	"google.golang.org/adk/tool"
 	"google.golang.org/adk/tool/agenttool"
 	"google.golang.org/adk/tool/functiontool"
@@ -103,3 +117,171 @@ func TestTypes(t *testing.T) {
 		})
 	}
 }
+
+// writerModel calls the write tool with the arguments in the user message,
+// "key=value [hold] [refresh]", then answers "done". It is stateless, so that
+// concurrent invocations can share it.
+type writerModel struct{}
+
+func (writerModel) Name() string { return "writer" }
+
+func (writerModel) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {
+		last := req.Contents[len(req.Contents)-1]
+		if last.Parts[0].FunctionResponse != nil {
+			yield(&model.LLMResponse{Content: genai.NewContentFromText("done", genai.RoleModel)}, nil)
+			return
+		}
+		fields := strings.Fields(last.Parts[0].Text)
+		key, value, _ := strings.Cut(fields[0], "=")
+		args := map[string]any{"key": key, "value": value, "hold": slices.Contains(fields, "hold"), "refresh": slices.Contains(fields, "refresh")}
+		yield(&model.LLMResponse{Content: genai.NewContentFromFunctionCall("write", args, genai.RoleModel)}, nil)
+	}
+}
+
+type writeArgs struct {
+	Key     string `json:"key"`
+	Value   string `json:"value"`
+	Hold    bool   `json:"hold"`
+	Refresh bool   `json:"refresh"`
+}
+
+type writeResult struct {
+	Stale bool           `json:"stale"`
+	State map[string]any `json:"state"`
+}
+
+// concurrentInvocations runs the held invocation of message held and, while
+// its tool waits, the invocation of message other, on the same session. It
+// returns the result of the held tool call and the errors of the runs.
+func concurrentInvocations(t *testing.T, cfg session.InMemoryConfig, held, other string) (result writeResult, heldErr, otherErr error, state map[string]any) {
+	t.Helper()
+	started, release := make(chan struct{}), make(chan struct{})
+	write, err := functiontool.New(functiontool.Config{Name: "write", Description: "writes the state"}, func(ctx tool.Context, args writeArgs) (writeResult, error) {
+		if args.Hold {
+			started <- struct{}{}
+			<-release
+		}
+		if err := ctx.State().Set(args.Key, args.Value); err != nil {
+			return writeResult{}, err
+		}
+		result := writeResult{Stale: tool.StateStale(ctx)}
+		if result.Stale && args.Refresh {
+			if err := tool.RefreshState(ctx); err != nil {
+				return writeResult{}, err
+			}
+		}
+		result.State = make(map[string]any)
+		for k, v := range ctx.State().All() {
+			result.State[k] = v
+		}
+		return result, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := llmagent.New(llmagent.Config{Name: "writer", Model: writerModel{}, Tools: []tool.Tool{write}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	service := session.InMemoryServiceWithConfig(cfg)
+	if _, err := service.Create(t.Context(), &session.CreateRequest{AppName: "app", UserID: "user", SessionID: "s"}); err != nil {
+		t.Fatal(err)
+	}
+	r, err := runner.New(runner.Config{AppName: "app", Agent: a, SessionService: service})
+	if err != nil {
+		t.Fatal(err)
+	}
+	run := func(msg string) ([]*session.Event, error) {
+		return testutil.CollectEvents(r.Run(t.Context(), "user", "s", genai.NewContentFromText(msg, genai.RoleUser), agent.RunConfig{}))
+	}
+
+	heldDone := make(chan struct{})
+	var heldEvents []*session.Event
+	go func() {
+		defer close(heldDone)
+		heldEvents, heldErr = run(held)
+	}()
+	<-started
+	_, otherErr = run(other)
+	close(release)
+	<-heldDone
+
+	for _, ev := range heldEvents {
+		if ev.Content == nil {
+			continue
+		}
+		for _, p := range ev.Content.Parts {
+			if p.FunctionResponse != nil {
+				result.Stale, _ = p.FunctionResponse.Response["stale"].(bool)
+				result.State, _ = p.FunctionResponse.Response["state"].(map[string]any)
+			}
+		}
+	}
+	resp, err := service.Get(t.Context(), &session.GetRequest{AppName: "app", UserID: "user", SessionID: "s"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	state = make(map[string]any)
+	for k, v := range resp.Session.State().All() {
+		state[k] = v
+	}
+	return result, heldErr, otherErr, state
+}
+
+func TestStateStale_ConcurrentInvocations(t *testing.T) {
+	t.Run("DisjointKeys", func(t *testing.T) {
+		result, heldErr, otherErr, state := concurrentInvocations(t, session.InMemoryConfig{}, "x=a hold refresh", "y=b")
+		if heldErr != nil || otherErr != nil {
+			t.Fatalf("runs failed: %v, %v", heldErr, otherErr)
+		}
+		// The held tool saw the concurrent change after refreshing, along
+		// with its own.
+		want := writeResult{Stale: true, State: map[string]any{"x": "a", "y": "b"}}
+		if diff := cmp.Diff(want, result); diff != "" {
+			t.Errorf("held tool result mismatch (-want +got):\n%s", diff)
+		}
+		if diff := cmp.Diff(map[string]any{"x": "a", "y": "b"}, state); diff != "" {
+			t.Errorf("state mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("OverlappingKeys", func(t *testing.T) {
+		_, heldErr, otherErr, state := concurrentInvocations(t, session.InMemoryConfig{}, "k=a hold", "k=b")
+		if otherErr != nil {
+			t.Fatal(otherErr)
+		}
+		// The conflict is surfaced, not lost silently.
+		if !errors.Is(heldErr, session.ErrStateConflict) {
+			t.Errorf("held run error = %v, want a conflict", heldErr)
+		}
+		if diff := cmp.Diff(map[string]any{"k": "b"}, state); diff != "" {
+			t.Errorf("state mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("OverlappingKeysRefreshed", func(t *testing.T) {
+		// The tool refreshing the state commits against the current version:
+		// its write is deliberate.
+		result, heldErr, otherErr, state := concurrentInvocations(t, session.InMemoryConfig{}, "k=a hold refresh", "k=b")
+		if heldErr != nil || otherErr != nil {
+			t.Fatalf("runs failed: %v, %v", heldErr, otherErr)
+		}
+		if !result.Stale {
+			t.Error("StateStale() = false, want true")
+		}
+		if diff := cmp.Diff(map[string]any{"k": "a"}, state); diff != "" {
+			t.Errorf("state mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("OverlappingKeysOverwrite", func(t *testing.T) {
+		_, heldErr, otherErr, state := concurrentInvocations(t, session.InMemoryConfig{ConflictPolicy: session.ConflictOverwrite}, "k=a hold", "k=b")
+		if heldErr != nil || otherErr != nil {
+			t.Fatalf("runs failed: %v, %v", heldErr, otherErr)
+		}
+		if diff := cmp.Diff(map[string]any{"k": "a"}, state); diff != "" {
+			t.Errorf("state mismatch (-want +got):\n%s", diff)
+		}
+	})
+}
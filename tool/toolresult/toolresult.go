@@ -0,0 +1,228 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package toolresult defines the envelope of the tool results carrying
+// metadata about their provenance and freshness.
+//
+// A tool returns an [Envelope] wrapped by [Wrap], or a function tool returns
+// it directly. The model only sees the data of the envelope: its metadata is
+// saved in the custom metadata of the function response event, under
+// [MetadataKey], where caching layers, UIs and citation matchers read it with
+// [FromEvent] and [Provenance]. Plain results are not affected.
+package toolresult
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"strings"
+	"time"
+
+	"google.golang.org/adk/session"
+)
+
+// MetadataKey is the session.Event.CustomMetadata key holding the metadata
+// of the enveloped results of a function response event, by function call
+// ID.
+const MetadataKey = "tool_results"
+
+// envelopeKey is the key of the results returned by Wrap holding the
+// metadata of the envelope.
+const envelopeKey = "_adk_envelope"
+
+// Source is a source a tool result was derived from.
+type Source struct {
+	Title string `json:"title,omitempty"`
+	URI   string `json:"uri,omitempty"`
+	// Snippet is the part of the source supporting the result.
+	Snippet string `json:"snippet,omitempty"`
+}
+
+// Envelope is a tool result with metadata about where it came from and how
+// fresh it is.
+type Envelope struct {
+	// Data is the result seen by the model.
+	Data map[string]any
+	// Provenance lists the sources of the result.
+	Provenance []Source
+	// GeneratedAt is when the result was produced.
+	GeneratedAt time.Time
+	// TTL is how long the result stays valid after GeneratedAt. Zero means
+	// that the result must not be reused.
+	TTL time.Duration
+	// Confidence is the confidence of the tool in the result, between 0 and
+	// 1, if known.
+	Confidence *float64
+}
+
+// ExpiresAt returns the time after which the result is stale, or the zero
+// time if it must not be reused.
+func (e Envelope) ExpiresAt() time.Time {
+	if e.TTL <= 0 || e.GeneratedAt.IsZero() {
+		return time.Time{}
+	}
+	return e.GeneratedAt.Add(e.TTL)
+}
+
+// Fresh reports whether the result can still be reused at now.
+func (e Envelope) Fresh(now time.Time) bool {
+	expiresAt := e.ExpiresAt()
+	return !expiresAt.IsZero() && now.Before(expiresAt)
+}
+
+// Wrap returns the result of a tool holding env. The data of env are
+// copied.
+func Wrap(env Envelope) map[string]any {
+	result := make(map[string]any, len(env.Data)+1)
+	maps.Copy(result, env.Data)
+	result[envelopeKey] = env.metadata()
+	return result
+}
+
+// Unwrap returns the envelope of a result returned by [Wrap]. It reports
+// false for the other results, which are returned as the data of an
+// envelope without metadata.
+func Unwrap(result map[string]any) (Envelope, bool) {
+	m, ok := result[envelopeKey]
+	if !ok {
+		return Envelope{Data: result}, false
+	}
+	md, ok := decodeMetadata(m)
+	if !ok {
+		return Envelope{Data: result}, false
+	}
+	data := maps.Clone(result)
+	delete(data, envelopeKey)
+	env := md.envelope()
+	env.Data = data
+	return env, true
+}
+
+// Metadata returns the metadata of env as saved in the function response
+// events, i.e. with the JSON types.
+func (e Envelope) Metadata() map[string]any {
+	b, err := json.Marshal(e.metadata())
+	if err != nil {
+		return nil
+	}
+	var m map[string]any
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil
+	}
+	return m
+}
+
+// FromEvent returns the enveloped results of the function responses of ev,
+// by function call ID.
+func FromEvent(ev *session.Event) map[string]Envelope {
+	if ev == nil || ev.Content == nil {
+		return nil
+	}
+	all, _ := ev.CustomMetadata[MetadataKey].(map[string]any)
+	if len(all) == 0 {
+		return nil
+	}
+	envs := make(map[string]Envelope)
+	for _, p := range ev.Content.Parts {
+		if p.FunctionResponse == nil {
+			continue
+		}
+		md, ok := decodeMetadata(all[p.FunctionResponse.ID])
+		if !ok {
+			continue
+		}
+		env := md.envelope()
+		env.Data = p.FunctionResponse.Response
+		envs[p.FunctionResponse.ID] = env
+	}
+	return envs
+}
+
+// Provenance returns the sources of the enveloped results of the function
+// responses of ev, in the order of the responses.
+func Provenance(ev *session.Event) []Source {
+	envs := FromEvent(ev)
+	if len(envs) == 0 {
+		return nil
+	}
+	var sources []Source
+	for _, p := range ev.Content.Parts {
+		if p.FunctionResponse != nil {
+			sources = append(sources, envs[p.FunctionResponse.ID].Provenance...)
+		}
+	}
+	return sources
+}
+
+// CitationBlock returns a compact text listing sources, one per line,
+// e.g. "[1] Title <https://example.com>".
+func CitationBlock(sources []Source) string {
+	var b strings.Builder
+	for i, s := range sources {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		fmt.Fprintf(&b, "[%d]", i+1)
+		if s.Title != "" {
+			b.WriteString(" " + s.Title)
+		}
+		if s.URI != "" {
+			b.WriteString(" <" + s.URI + ">")
+		}
+	}
+	return b.String()
+}
+
+// metadata is the JSON form of the metadata of an envelope.
+type metadata struct {
+	Provenance  []Source  `json:"provenance,omitempty"`
+	GeneratedAt time.Time `json:"generated_at,omitzero"`
+	TTL         string    `json:"ttl,omitempty"`
+	Confidence  *float64  `json:"confidence,omitempty"`
+}
+
+func (e Envelope) metadata() *metadata {
+	md := &metadata{Provenance: e.Provenance, GeneratedAt: e.GeneratedAt, Confidence: e.Confidence}
+	if e.TTL > 0 {
+		md.TTL = e.TTL.String()
+	}
+	return md
+}
+
+func (md *metadata) envelope() Envelope {
+	env := Envelope{Provenance: md.Provenance, GeneratedAt: md.GeneratedAt, Confidence: md.Confidence}
+	env.TTL, _ = time.ParseDuration(md.TTL)
+	return env
+}
+
+// decodeMetadata decodes the metadata held by a result returned by Wrap, or
+// saved in an event.
+func decodeMetadata(v any) (*metadata, bool) {
+	switch v := v.(type) {
+	case *metadata:
+		return v, v != nil
+	case map[string]any:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, false
+		}
+		var md metadata
+		if err := json.Unmarshal(b, &md); err != nil {
+			return nil, false
+		}
+		return &md, true
+	default:
+		return nil, false
+	}
+}
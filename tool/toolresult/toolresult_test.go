@@ -0,0 +1,266 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package toolresult_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/internal/testutil"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+	"google.golang.org/adk/tool/toolresult"
+)
+
+var generatedAt = time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+func TestWrapUnwrap(t *testing.T) {
+	confidence := 0.8
+	env := toolresult.Envelope{
+		Data:        map[string]any{"price": 42.0},
+		Provenance:  []toolresult.Source{{Title: "Catalog", URI: "https://example.com/catalog"}},
+		GeneratedAt: generatedAt,
+		TTL:         5 * time.Minute,
+		Confidence:  &confidence,
+	}
+	got, ok := toolresult.Unwrap(toolresult.Wrap(env))
+	if !ok {
+		t.Fatal("Unwrap() of a wrapped result reported false")
+	}
+	if diff := cmp.Diff(env, got); diff != "" {
+		t.Errorf("Unwrap() mismatch (-want +got):\n%s", diff)
+	}
+
+	plain := map[string]any{"price": 42.0}
+	got, ok = toolresult.Unwrap(plain)
+	if ok {
+		t.Error("Unwrap() of a plain result reported true")
+	}
+	if diff := cmp.Diff(toolresult.Envelope{Data: plain}, got); diff != "" {
+		t.Errorf("Unwrap() of a plain result mismatch (-want +got):\n%s", diff)
+	}
+
+	for _, tc := range []struct {
+		name string
+		env  toolresult.Envelope
+		now  time.Time
+		want bool
+	}{
+		{"BeforeExpiry", env, generatedAt.Add(4 * time.Minute), true},
+		{"AfterExpiry", env, generatedAt.Add(5 * time.Minute), false},
+		{"NoTTL", toolresult.Envelope{GeneratedAt: generatedAt}, generatedAt, false},
+	} {
+		if got := tc.env.Fresh(tc.now); got != tc.want {
+			t.Errorf("%s: Fresh() = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestCitationBlock(t *testing.T) {
+	got := toolresult.CitationBlock([]toolresult.Source{
+		{Title: "Catalog", URI: "https://example.com/catalog"},
+		{URI: "https://example.com/prices"},
+		{Title: "Notes"},
+	})
+	want := "[1] Catalog <https://example.com/catalog>\n[2] <https://example.com/prices>\n[3] Notes"
+	if got != want {
+		t.Errorf("CitationBlock() = %q, want %q", got, want)
+	}
+}
+
+type priceArgs struct {
+	Item string `json:"item"`
+}
+
+// runAgent runs an agent calling the tools in calls, in parallel, and
+// returns the function response event.
+func runAgent(t *testing.T, tools []tool.Tool, calls ...*genai.FunctionCall) *session.Event {
+	t.Helper()
+	var parts []*genai.Part
+	for _, c := range calls {
+		parts = append(parts, &genai.Part{FunctionCall: c})
+	}
+	llm := &testutil.MockModel{Responses: []*genai.Content{
+		{Role: genai.RoleModel, Parts: parts},
+		genai.NewContentFromText("done", genai.RoleModel),
+	}}
+	a, err := llmagent.New(llmagent.Config{Name: "agent", Model: llm, Tools: tools})
+	if err != nil {
+		t.Fatal(err)
+	}
+	events, err := testutil.CollectEvents(testutil.NewTestAgentRunner(t, a).Run(t, "session", "prices?"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, ev := range events {
+		if ev.Content != nil && ev.Content.Parts[0].FunctionResponse != nil {
+			return ev
+		}
+	}
+	t.Fatal("no function response event")
+	return nil
+}
+
+func TestEnvelope_Agent(t *testing.T) {
+	sources := []toolresult.Source{{Title: "Catalog", URI: "https://example.com/catalog"}}
+	price, err := functiontool.New(functiontool.Config{Name: "price", ShowProvenance: true}, func(_ tool.Context, args priceArgs) (toolresult.Envelope, error) {
+		return toolresult.Envelope{
+			Data:        map[string]any{"price": 42.0},
+			Provenance:  sources,
+			GeneratedAt: generatedAt,
+			TTL:         time.Minute,
+		}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stock, err := functiontool.New(functiontool.Config{Name: "stock"}, func(_ tool.Context, args priceArgs) (map[string]any, error) {
+		return map[string]any{"count": 3.0}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ev := runAgent(t, []tool.Tool{price, stock},
+		&genai.FunctionCall{ID: "call-price", Name: "price", Args: map[string]any{"item": "lamp"}},
+		&genai.FunctionCall{ID: "call-stock", Name: "stock", Args: map[string]any{"item": "lamp"}},
+	)
+
+	// The model sees the data and the citations, not the metadata.
+	var responses []map[string]any
+	for _, p := range ev.Content.Parts {
+		responses = append(responses, p.FunctionResponse.Response)
+	}
+	wantResponses := []map[string]any{
+		{"price": 42.0, "citations": "[1] Catalog <https://example.com/catalog>"},
+		{"count": 3.0},
+	}
+	if diff := cmp.Diff(wantResponses, responses); diff != "" {
+		t.Errorf("function responses mismatch (-want +got):\n%s", diff)
+	}
+
+	// The metadata is saved in the event.
+	envs := toolresult.FromEvent(ev)
+	want := map[string]toolresult.Envelope{"call-price": {
+		Data:        wantResponses[0],
+		Provenance:  sources,
+		GeneratedAt: generatedAt,
+		TTL:         time.Minute,
+	}}
+	if diff := cmp.Diff(want, envs); diff != "" {
+		t.Errorf("FromEvent() mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(sources, toolresult.Provenance(ev)); diff != "" {
+		t.Errorf("Provenance() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestEnvelope_PlainResults(t *testing.T) {
+	stock, err := functiontool.New(functiontool.Config{Name: "stock"}, func(_ tool.Context, args priceArgs) (map[string]any, error) {
+		return map[string]any{"count": 3.0}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ev := runAgent(t, []tool.Tool{stock}, &genai.FunctionCall{ID: "call-stock", Name: "stock", Args: map[string]any{"item": "lamp"}})
+	if diff := cmp.Diff(map[string]any{"count": 3.0}, ev.Content.Parts[0].FunctionResponse.Response); diff != "" {
+		t.Errorf("function response mismatch (-want +got):\n%s", diff)
+	}
+	if ev.CustomMetadata != nil || toolresult.FromEvent(ev) != nil {
+		t.Errorf("custom metadata = %v, want none", ev.CustomMetadata)
+	}
+}
+
+// cache is a caching consumer reusing the enveloped results while they are
+// fresh.
+type cache struct {
+	now     func() time.Time
+	entries map[string]toolresult.Envelope
+}
+
+func (c *cache) key(t tool.Tool, args map[string]any) string {
+	return fmt.Sprint(t.Name(), args)
+}
+
+func (c *cache) before(_ tool.Context, t tool.Tool, args map[string]any) (map[string]any, error) {
+	if env, ok := c.entries[c.key(t, args)]; ok && env.Fresh(c.now()) {
+		return toolresult.Wrap(env), nil
+	}
+	return nil, nil
+}
+
+func (c *cache) after(_ tool.Context, t tool.Tool, args, result map[string]any, err error) (map[string]any, error) {
+	if env, ok := toolresult.Unwrap(result); ok && err == nil {
+		c.entries[c.key(t, args)] = env
+	}
+	return nil, nil
+}
+
+func TestEnvelope_CachingConsumer(t *testing.T) {
+	now := generatedAt
+	calls := 0
+	price, err := functiontool.New(functiontool.Config{Name: "price"}, func(_ tool.Context, args priceArgs) (toolresult.Envelope, error) {
+		calls++
+		return toolresult.Envelope{Data: map[string]any{"price": float64(calls)}, GeneratedAt: now, TTL: time.Minute}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &cache{now: func() time.Time { return now }, entries: make(map[string]toolresult.Envelope)}
+	call := genai.NewContentFromFunctionCall("price", map[string]any{"item": "lamp"}, genai.RoleModel)
+	llm := &testutil.MockModel{}
+	for range 3 {
+		llm.Responses = append(llm.Responses, call, genai.NewContentFromText("done", genai.RoleModel))
+	}
+	a, err := llmagent.New(llmagent.Config{
+		Name:                "agent",
+		Model:               llm,
+		Tools:               []tool.Tool{price},
+		BeforeToolCallbacks: []llmagent.BeforeToolCallback{c.before},
+		AfterToolCallbacks:  []llmagent.AfterToolCallback{c.after},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	runner := testutil.NewTestAgentRunner(t, a)
+
+	var got []any
+	for _, advance := range []time.Duration{0, 30 * time.Second, time.Minute} {
+		now = now.Add(advance)
+		parts, err := testutil.CollectParts(runner.Run(t, "session", "price?"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, p := range parts {
+			if p.FunctionResponse != nil {
+				got = append(got, p.FunctionResponse.Response["price"])
+			}
+		}
+	}
+	// The second call is answered from the cache, the third one is made
+	// after the TTL.
+	if diff := cmp.Diff([]any{1.0, 1.0, 2.0}, got); diff != "" {
+		t.Errorf("prices mismatch (-want +got):\n%s", diff)
+	}
+	if calls != 2 {
+		t.Errorf("tool calls = %d, want 2", calls)
+	}
+}
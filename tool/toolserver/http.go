@@ -0,0 +1,158 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package toolserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"google.golang.org/adk/httpx"
+	"google.golang.org/adk/tool"
+)
+
+// DefaultMaxPayloadBytes is the default cap of the size of the calls and of
+// their results.
+const DefaultMaxPayloadBytes = 4 << 20
+
+// DefaultTimeout is the default timeout of the calls dispatched by the HTTP
+// transport.
+const DefaultTimeout = 30 * time.Second
+
+// ServeHTTP executes the call posted as JSON in the body of r, and writes
+// its result as JSON. The requests that fail the authentication are
+// rejected with 401 Unauthorized, the ones larger than the cap with 413
+// Request Entity Too Large.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.cfg.Authenticate != nil {
+		if err := s.cfg.Authenticate(r); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+	}
+	var call tool.RemoteCall
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, s.cfg.MaxRequestBytes)).Decode(&call); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, fmt.Sprintf("request exceeds %d bytes", tooLarge.Limit), http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "invalid call: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	body, err := json.Marshal(s.Execute(r.Context(), &call))
+	if err != nil {
+		http.Error(w, "invalid result: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(body)
+}
+
+// HTTPTransportConfig configures the transport returned by
+// [NewHTTPTransport].
+type HTTPTransportConfig struct {
+	// URL is the endpoint of the [Server] running the tools. Required.
+	URL string
+	// Client sends the requests. Defaults to httpx.DefaultClient, which
+	// propagates the metadata of the invocations in their headers.
+	Client *http.Client
+	// Timeout bounds the duration of each call. Defaults to DefaultTimeout.
+	Timeout time.Duration
+	// MaxRequestBytes and MaxResponseBytes cap the size of the calls and of
+	// their results. They default to DefaultMaxPayloadBytes.
+	MaxRequestBytes  int64
+	MaxResponseBytes int64
+	// Authorize is called with every request before it is sent, e.g. to
+	// set its Authorization header. Optional.
+	Authorize func(r *http.Request) error
+}
+
+type httpTransport struct {
+	cfg HTTPTransportConfig
+}
+
+// NewHTTPTransport returns a transport posting the calls of the remote
+// tools to a [Server] as JSON.
+func NewHTTPTransport(cfg HTTPTransportConfig) (tool.RemoteTransport, error) {
+	if cfg.URL == "" {
+		return nil, errors.New("URL is required")
+	}
+	if cfg.Client == nil {
+		cfg.Client = httpx.DefaultClient
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultTimeout
+	}
+	if cfg.MaxRequestBytes <= 0 {
+		cfg.MaxRequestBytes = DefaultMaxPayloadBytes
+	}
+	if cfg.MaxResponseBytes <= 0 {
+		cfg.MaxResponseBytes = DefaultMaxPayloadBytes
+	}
+	return &httpTransport{cfg: cfg}, nil
+}
+
+// Dispatch implements tool.RemoteTransport.
+func (t *httpTransport) Dispatch(ctx context.Context, call *tool.RemoteCall) (*tool.RemoteResult, error) {
+	body, err := json.Marshal(call)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode the call: %w", err)
+	}
+	if int64(len(body)) > t.cfg.MaxRequestBytes {
+		return nil, fmt.Errorf("call of %d bytes exceeds %d bytes", len(body), t.cfg.MaxRequestBytes)
+	}
+	ctx, cancel := context.WithTimeout(ctx, t.cfg.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if t.cfg.Authorize != nil {
+		if err := t.cfg.Authorize(req); err != nil {
+			return nil, fmt.Errorf("failed to authorize the request: %w", err)
+		}
+	}
+	resp, err := t.cfg.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, t.cfg.MaxResponseBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > t.cfg.MaxResponseBytes {
+		return nil, fmt.Errorf("result exceeds %d bytes", t.cfg.MaxResponseBytes)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("server returned %s: %s", resp.Status, bytes.TrimSpace(data))
+	}
+	var result tool.RemoteResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("invalid result: %w", err)
+	}
+	return &result, nil
+}
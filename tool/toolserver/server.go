@@ -0,0 +1,216 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package toolserver hosts tools run on behalf of remote agents, see
+// [tool.Remote]. A [Server] executes the calls dispatched by any transport;
+// it also serves the HTTP protocol of [NewHTTPTransport].
+package toolserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"maps"
+	"net/http"
+	"time"
+
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/artifact"
+	iartifact "google.golang.org/adk/internal/artifact"
+	icontext "google.golang.org/adk/internal/context"
+	"google.golang.org/adk/internal/toolinternal"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+)
+
+// The codes of the errors reported by the server.
+const (
+	CodeUnknownTool      = "unknown_tool"
+	CodeInvalidRequest   = "invalid_request"
+	CodeDeadlineExceeded = "deadline_exceeded"
+	CodeToolError        = "tool_error"
+)
+
+// Config configures a [Server].
+type Config struct {
+	// Tools are the function tools hosted by the server.
+	Tools []tool.Tool
+	// Timeout bounds the duration of each call. Zero means no limit.
+	Timeout time.Duration
+	// Artifacts is the service storing the artifacts saved by the tools. It
+	// must be shared with the callers, which only get the saved versions.
+	// Optional: if nil, the tools cannot use the artifacts.
+	Artifacts artifact.Service
+	// MaxRequestBytes caps the size of the HTTP requests. Defaults to
+	// DefaultMaxPayloadBytes.
+	MaxRequestBytes int64
+	// Authenticate authenticates the HTTP requests before they are
+	// executed, e.g. by checking a bearer token. Optional.
+	Authenticate func(r *http.Request) error
+}
+
+// Server executes the calls of the tools it hosts.
+type Server struct {
+	cfg   Config
+	tools map[string]toolinternal.FunctionTool
+}
+
+// New returns a server hosting the tools of cfg.
+func New(cfg Config) (*Server, error) {
+	if cfg.MaxRequestBytes <= 0 {
+		cfg.MaxRequestBytes = DefaultMaxPayloadBytes
+	}
+	s := &Server{cfg: cfg, tools: make(map[string]toolinternal.FunctionTool, len(cfg.Tools))}
+	for _, t := range cfg.Tools {
+		ft, ok := t.(toolinternal.FunctionTool)
+		if !ok {
+			return nil, fmt.Errorf("tool %q is not a function tool", t.Name())
+		}
+		if _, dup := s.tools[t.Name()]; dup {
+			return nil, fmt.Errorf("duplicate tool %q", t.Name())
+		}
+		s.tools[t.Name()] = ft
+	}
+	return s, nil
+}
+
+// Execute runs call with a tool context rebuilt from its snapshot. The
+// failures of the call are reported in the error of the result: a
+// [tool.RemoteError] returned by the tool is passed as is.
+func (s *Server) Execute(ctx context.Context, call *tool.RemoteCall) *tool.RemoteResult {
+	fail := func(code, format string, args ...any) *tool.RemoteResult {
+		return &tool.RemoteResult{Error: &tool.RemoteError{Tool: call.Tool, Code: code, Message: fmt.Sprintf(format, args...)}}
+	}
+	t, ok := s.tools[call.Tool]
+	if !ok {
+		return fail(CodeUnknownTool, "tool %q is not hosted by this server", call.Tool)
+	}
+	if s.cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.cfg.Timeout)
+		defer cancel()
+	}
+
+	actions := &session.EventActions{StateDelta: make(map[string]any)}
+	toolCtx, err := s.toolContext(ctx, call, actions)
+	if err != nil {
+		return fail(CodeInvalidRequest, "%v", err)
+	}
+	result, err := t.Run(toolCtx, call.Args)
+	var remoteErr *tool.RemoteError
+	switch {
+	case errors.As(err, &remoteErr):
+		return &tool.RemoteResult{Error: remoteErr}
+	case err != nil && ctx.Err() != nil:
+		return fail(CodeDeadlineExceeded, "%v", err)
+	case err != nil:
+		return fail(CodeToolError, "%v", err)
+	}
+	return &tool.RemoteResult{Result: result, StateDelta: actions.StateDelta, ArtifactDelta: actions.ArtifactDelta}
+}
+
+// toolContext returns the context of the tool running call. The state
+// changes and the versions of the saved artifacts are recorded in actions.
+func (s *Server) toolContext(ctx context.Context, call *tool.RemoteCall, actions *session.EventActions) (tool.Context, error) {
+	caller, err := agent.New(agent.Config{Name: call.AgentName})
+	if err != nil {
+		return nil, err
+	}
+	var artifacts agent.Artifacts = unavailableArtifacts{}
+	if s.cfg.Artifacts != nil {
+		artifacts = &iartifact.Artifacts{Service: s.cfg.Artifacts, AppName: call.AppName, UserID: call.UserID, SessionID: call.SessionID}
+	}
+	ictx := icontext.NewInvocationContext(ctx, icontext.InvocationContextParams{
+		Artifacts: artifacts,
+		Session: &snapshotSession{
+			id:      call.SessionID,
+			appName: call.AppName,
+			userID:  call.UserID,
+			state:   snapshotState(maps.Clone(call.State)),
+		},
+		Agent: caller,
+	})
+	return toolinternal.NewToolContext(ictx, call.FunctionCallID, actions), nil
+}
+
+// snapshotSession is the session seen by the hosted tools: its state holds
+// the keys sent by the caller, and it has no events.
+type snapshotSession struct {
+	id, appName, userID string
+	state               snapshotState
+}
+
+func (s *snapshotSession) ID() string                { return s.id }
+func (s *snapshotSession) AppName() string           { return s.appName }
+func (s *snapshotSession) UserID() string            { return s.userID }
+func (s *snapshotSession) State() session.State      { return &s.state }
+func (s *snapshotSession) Events() session.Events    { return noEvents{} }
+func (s *snapshotSession) LastUpdateTime() time.Time { return time.Time{} }
+
+type snapshotState map[string]any
+
+func (s *snapshotState) Get(key string) (any, error) {
+	v, ok := (*s)[key]
+	if !ok {
+		return nil, session.ErrStateKeyNotExist
+	}
+	return v, nil
+}
+
+func (s *snapshotState) Set(key string, v any) error {
+	if *s == nil {
+		*s = make(snapshotState)
+	}
+	(*s)[key] = v
+	return nil
+}
+
+func (s *snapshotState) All() iter.Seq2[string, any] {
+	return maps.All(*s)
+}
+
+type noEvents struct{}
+
+func (noEvents) All() iter.Seq[*session.Event] { return func(func(*session.Event) bool) {} }
+func (noEvents) Len() int                      { return 0 }
+func (noEvents) At(int) *session.Event         { return nil }
+
+// errNoArtifacts is returned by the artifacts of the servers without an
+// artifact service.
+var errNoArtifacts = errors.New("artifacts are not available to the remote tools of this server")
+
+type unavailableArtifacts struct{}
+
+func (unavailableArtifacts) Save(context.Context, string, *genai.Part) (*artifact.SaveResponse, error) {
+	return nil, errNoArtifacts
+}
+
+func (unavailableArtifacts) List(context.Context) (*artifact.ListResponse, error) {
+	return nil, errNoArtifacts
+}
+
+func (unavailableArtifacts) Load(context.Context, string) (*artifact.LoadResponse, error) {
+	return nil, errNoArtifacts
+}
+
+func (unavailableArtifacts) LoadVersion(context.Context, string, int) (*artifact.LoadResponse, error) {
+	return nil, errNoArtifacts
+}
+
+func (unavailableArtifacts) Delete(context.Context, string) error {
+	return errNoArtifacts
+}
@@ -0,0 +1,287 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package toolserver_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/httpx"
+	"google.golang.org/adk/internal/testutil"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+	"google.golang.org/adk/tool/toolserver"
+)
+
+type renderArgs struct {
+	URL string `json:"url"`
+}
+
+// newRenderTool returns the tool hosted by the server: it renders a page,
+// and remembers it in the state.
+func newRenderTool(t *testing.T) tool.Tool {
+	t.Helper()
+	render, err := functiontool.New(functiontool.Config{Name: "render", Description: "renders a page"}, func(ctx tool.Context, args renderArgs) (map[string]any, error) {
+		switch args.URL {
+		case "missing":
+			return nil, &tool.RemoteError{Tool: "render", Code: "not_found", Message: "no such page"}
+		case "slow":
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+		viewport, err := ctx.State().Get("viewport")
+		if err != nil {
+			return nil, err
+		}
+		if _, err := ctx.State().Get("secret"); err == nil {
+			return nil, errors.New("undeclared state key sent")
+		}
+		if err := ctx.State().Set("last_rendered", args.URL); err != nil {
+			return nil, err
+		}
+		return map[string]any{"title": "Page " + args.URL, "viewport": viewport, "call": ctx.FunctionCallID()}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return render
+}
+
+func TestRemote(t *testing.T) {
+	const token = "Bearer s3cret"
+	server, err := toolserver.New(toolserver.Config{
+		Tools:   []tool.Tool{newRenderTool(t)},
+		Timeout: time.Second,
+		Authenticate: func(r *http.Request) error {
+			if r.Header.Get("Authorization") != token {
+				return errors.New("invalid token")
+			}
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(server)
+	defer srv.Close()
+
+	testCases := []struct {
+		name      string
+		url       string
+		timeout   time.Duration
+		authorize bool
+		want      map[string]any
+		// wantErr is a substring of the error seen by the model.
+		wantErr   string
+		wantDelta map[string]any
+	}{
+		{
+			name:      "success with state write-back",
+			url:       "home",
+			authorize: true,
+			want:      map[string]any{"title": "Page home", "viewport": "1280x720", "call": "call-1"},
+			wantDelta: map[string]any{"last_rendered": "home"},
+		},
+		{
+			name:      "structured error",
+			url:       "missing",
+			authorize: true,
+			wantErr:   `remote tool "render" failed (not_found): no such page`,
+		},
+		{
+			name:      "timeout",
+			url:       "slow",
+			timeout:   50 * time.Millisecond,
+			authorize: true,
+			wantErr:   "context deadline exceeded",
+		},
+		{
+			name:    "unauthenticated",
+			url:     "home",
+			wantErr: "401 Unauthorized",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := toolserver.HTTPTransportConfig{URL: srv.URL, Timeout: tc.timeout}
+			if tc.authorize {
+				cfg.Authorize = func(r *http.Request) error {
+					r.Header.Set("Authorization", token)
+					return nil
+				}
+			}
+			transport, err := toolserver.NewHTTPTransport(cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			remote := tool.Remote(tool.ToolDescriptor{
+				Declaration: &genai.FunctionDeclaration{Name: "render", Description: "renders a page"},
+				StateKeys:   []string{"viewport"},
+			}, transport)
+
+			call := &genai.Content{Role: genai.RoleModel, Parts: []*genai.Part{{FunctionCall: &genai.FunctionCall{ID: "call-1", Name: "render", Args: map[string]any{"url": tc.url}}}}}
+			testModel := &testutil.MockModel{Responses: []*genai.Content{call, genai.NewContentFromText("done", genai.RoleModel)}}
+			a, err := llmagent.New(llmagent.Config{Name: "agent", Model: testModel, Tools: []tool.Tool{remote}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			runner := testutil.NewTestAgentRunner(t, a)
+			runner.SetInitSessionState(map[string]any{"viewport": "1280x720", "secret": "hidden"})
+			events, err := testutil.CollectEvents(runner.Run(t, "session", "render it"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var response *genai.FunctionResponse
+			var delta map[string]any
+			for _, ev := range events {
+				for _, p := range ev.Content.Parts {
+					if p.FunctionResponse != nil {
+						response, delta = p.FunctionResponse, ev.Actions.StateDelta
+					}
+				}
+			}
+			if response == nil {
+				t.Fatal("no function response")
+			}
+			if tc.wantErr != "" {
+				if msg, _ := response.Response["error"].(string); !strings.Contains(msg, tc.wantErr) {
+					t.Errorf("function response = %v, want an error containing %q", response.Response, tc.wantErr)
+				}
+				return
+			}
+			if diff := cmp.Diff(tc.want, response.Response); diff != "" {
+				t.Errorf("function response mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.wantDelta, delta); diff != "" {
+				t.Errorf("state delta mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRemote_OutboundHeaders(t *testing.T) {
+	server, err := toolserver.New(toolserver.Config{Tools: []tool.Tool{newRenderTool(t)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var headers http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = r.Header.Clone()
+		server.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+
+	for _, tc := range []struct {
+		name   string
+		client *http.Client
+		want   bool
+	}{
+		{"Default", nil, true},
+		{"CustomClient", &http.Client{}, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			transport, err := toolserver.NewHTTPTransport(toolserver.HTTPTransportConfig{URL: srv.URL, Client: tc.client})
+			if err != nil {
+				t.Fatal(err)
+			}
+			remote := tool.Remote(tool.ToolDescriptor{Declaration: &genai.FunctionDeclaration{Name: "render"}, StateKeys: []string{"viewport"}}, transport)
+			call := genai.NewContentFromFunctionCall("render", map[string]any{"url": "home"}, genai.RoleModel)
+			testModel := &testutil.MockModel{Responses: []*genai.Content{call, genai.NewContentFromText("done", genai.RoleModel)}}
+			a, err := llmagent.New(llmagent.Config{Name: "renderer", Model: testModel, Tools: []tool.Tool{remote}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			runner := testutil.NewTestAgentRunner(t, a)
+			runner.SetInitSessionState(map[string]any{"viewport": "1280x720"})
+			if _, err := testutil.CollectEvents(runner.Run(t, "session", "render it")); err != nil {
+				t.Fatal(err)
+			}
+
+			got := map[string]string{}
+			for _, name := range []string{"X-Request-ID", "X-Session-Hash", "X-Agent-Name"} {
+				if v := headers.Get(name); v != "" {
+					got[name] = v
+				}
+			}
+			want := map[string]string{}
+			if tc.want {
+				// The invocation ID is generated by the runner.
+				if !strings.HasPrefix(got["X-Request-ID"], "e-") {
+					t.Errorf("X-Request-ID header = %q, want an invocation ID", got["X-Request-ID"])
+				}
+				delete(got, "X-Request-ID")
+				want = map[string]string{"X-Session-Hash": httpx.Hash(nil, "session"), "X-Agent-Name": "renderer"}
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("headers mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestServer_Execute(t *testing.T) {
+	server, err := toolserver.New(toolserver.Config{Tools: []tool.Tool{newRenderTool(t)}, Timeout: 20 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		call *tool.RemoteCall
+		want *tool.RemoteResult
+	}{
+		{
+			call: &tool.RemoteCall{Tool: "screenshot"},
+			want: &tool.RemoteResult{Error: &tool.RemoteError{Tool: "screenshot", Code: toolserver.CodeUnknownTool, Message: `tool "screenshot" is not hosted by this server`}},
+		},
+		{
+			call: &tool.RemoteCall{Tool: "render", Args: map[string]any{"url": "slow"}},
+			want: &tool.RemoteResult{Error: &tool.RemoteError{Tool: "render", Code: toolserver.CodeDeadlineExceeded, Message: "context deadline exceeded"}},
+		},
+		{
+			call: &tool.RemoteCall{Tool: "render", Args: map[string]any{"url": "home"}},
+			want: &tool.RemoteResult{Error: &tool.RemoteError{Tool: "render", Code: toolserver.CodeToolError, Message: session.ErrStateKeyNotExist.Error()}},
+		},
+	} {
+		if diff := cmp.Diff(tc.want, server.Execute(t.Context(), tc.call)); diff != "" {
+			t.Errorf("Execute(%v) mismatch (-want +got):\n%s", tc.call.Tool, diff)
+		}
+	}
+}
+
+func TestServer_MaxRequestBytes(t *testing.T) {
+	server, err := toolserver.New(toolserver.Config{Tools: []tool.Tool{newRenderTool(t)}, MaxRequestBytes: 64})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(server)
+	defer srv.Close()
+	resp, err := http.Post(srv.URL, "application/json", strings.NewReader(`{"tool": "render", "args": {"url": "`+strings.Repeat("a", 100)+`"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Errorf("status = %d, want %d", resp.StatusCode, http.StatusRequestEntityTooLarge)
+	}
+}
@@ -0,0 +1,269 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tool
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"google.golang.org/adk/agent"
+)
+
+// ToolUIDescriptor describes a function tool for the forms of a user
+// interface, e.g. to run the tool manually from an admin console.
+type ToolUIDescriptor struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// Toolset is the name of the toolset providing the tool.
+	Toolset     string `json:"toolset,omitempty"`
+	LongRunning bool   `json:"longRunning,omitempty"`
+	// Fields are the input fields of the tool, flattened as documented by
+	// [DescribeForUI].
+	Fields []UIField `json:"fields"`
+}
+
+// UIField describes an input field of a tool.
+type UIField struct {
+	// Name is the path of the field, see [DescribeForUI].
+	Name string `json:"name"`
+	// Type is the JSON schema type of the field: "string", "integer",
+	// "number", "boolean", "array" or "object".
+	Type string `json:"type"`
+	// ItemType is the type of the items of the array fields.
+	ItemType string `json:"itemType,omitempty"`
+	// Required reports whether the field must be set, i.e. whether it and
+	// all the objects containing it are required.
+	Required    bool   `json:"required"`
+	Enum        []any  `json:"enum,omitempty"`
+	Default     any    `json:"default,omitempty"`
+	Description string `json:"description,omitempty"`
+	// Sensitive reports whether the field holds a secret, which the forms
+	// should mask. It is declared by the writeOnly keyword of the schema.
+	Sensitive bool `json:"sensitive,omitempty"`
+	// Injected reports whether the field is set by the application rather
+	// than by the caller, so that the forms should not ask for it. It is
+	// declared by the readOnly keyword of the schema.
+	Injected bool `json:"injected,omitempty"`
+}
+
+// uiMaxDepth is the depth of the nested objects flattened by DescribeForUI.
+const uiMaxDepth = 3
+
+// DescribeForUI returns the descriptors of the function tools of sets, in
+// order, for the given context. The declarations of the tools are those
+// they would send to the model in this context. The tools without a
+// function declaration, run by the model itself, are left out.
+//
+// The input schema of each tool is flattened into fields: the properties of
+// the nested objects are named by their path, with dots, e.g.
+// "address.city", and the properties of the objects in arrays with "[]",
+// e.g. "items[].sku". The objects nested deeper than 3 levels are described
+// by a single field of type "object". References to the definitions of the
+// schema ($ref) are resolved. The properties are ordered by the
+// propertyOrdering of the schema if it has one; otherwise the required
+// properties come first, in the order of the schema, followed by the others
+// sorted by name.
+func DescribeForUI(ctx agent.ReadonlyContext, sets []Toolset) ([]ToolUIDescriptor, error) {
+	descriptors := []ToolUIDescriptor{}
+	for _, set := range sets {
+		tools, err := set.Tools(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list the tools of toolset %q: %w", set.Name(), err)
+		}
+		for _, t := range tools {
+			d, ok := t.(declarer)
+			if !ok {
+				continue
+			}
+			decl := d.Declaration()
+			if decl == nil {
+				continue
+			}
+			descriptor := ToolUIDescriptor{
+				Name:        decl.Name,
+				Description: decl.Description,
+				Toolset:     set.Name(),
+				LongRunning: t.IsLongRunning(),
+				Fields:      []UIField{},
+			}
+			var schema any = decl.ParametersJsonSchema
+			if schema == nil && decl.Parameters != nil {
+				schema = decl.Parameters
+			}
+			if schema != nil {
+				root, err := toSchemaMap(schema)
+				if err != nil {
+					return nil, fmt.Errorf("invalid input schema of tool %q: %w", decl.Name, err)
+				}
+				f := &uiFlattener{root: root}
+				f.flatten(root, "", true, 1)
+				descriptor.Fields = f.fields
+			}
+			descriptors = append(descriptors, descriptor)
+		}
+	}
+	return descriptors, nil
+}
+
+// toSchemaMap converts a JSON schema, or a genai.Schema, to its JSON
+// representation.
+func toSchemaMap(schema any) (map[string]any, error) {
+	b, err := json.Marshal(schema)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]any
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+type uiFlattener struct {
+	root   map[string]any
+	fields []UIField
+}
+
+// flatten adds the fields of the properties of the object schema s.
+func (f *uiFlattener) flatten(s map[string]any, prefix string, required bool, depth int) {
+	s = f.resolve(s)
+	properties, _ := s["properties"].(map[string]any)
+	for _, name := range propertyOrder(s, properties) {
+		prop, _ := properties[name].(map[string]any)
+		prop = f.resolve(prop)
+		path := prefix + name
+		propRequired := required && slices.Contains(stringList(s["required"]), name)
+		field := UIField{
+			Name:        path,
+			Type:        schemaType(prop),
+			Required:    propRequired,
+			Default:     prop["default"],
+			Description: stringValue(prop["description"]),
+			Sensitive:   prop["writeOnly"] == true,
+			Injected:    prop["readOnly"] == true,
+		}
+		if enum, ok := prop["enum"].([]any); ok {
+			field.Enum = enum
+		}
+		switch field.Type {
+		case "object":
+			if depth < uiMaxDepth && prop["properties"] != nil {
+				f.flatten(prop, path+".", propRequired, depth+1)
+				continue
+			}
+		case "array":
+			items, _ := prop["items"].(map[string]any)
+			items = f.resolve(items)
+			field.ItemType = schemaType(items)
+			if enum, ok := items["enum"].([]any); ok && field.Enum == nil {
+				field.Enum = enum
+			}
+			if field.ItemType == "object" && depth < uiMaxDepth && items["properties"] != nil {
+				f.flatten(items, path+"[].", propRequired, depth+1)
+				continue
+			}
+		}
+		f.fields = append(f.fields, field)
+	}
+}
+
+// resolve returns the schema referenced by s, if it is a reference to a
+// definition of the root schema.
+func (f *uiFlattener) resolve(s map[string]any) map[string]any {
+	for range uiMaxDepth {
+		ref, ok := s["$ref"].(string)
+		if !ok {
+			return s
+		}
+		var target map[string]any
+		for _, prefix := range []string{"#/$defs/", "#/definitions/"} {
+			if name, ok := strings.CutPrefix(ref, prefix); ok {
+				defs, _ := f.root[strings.TrimSuffix(strings.TrimPrefix(prefix, "#/"), "/")].(map[string]any)
+				target, _ = defs[name].(map[string]any)
+			}
+		}
+		if target == nil {
+			return s
+		}
+		s = target
+	}
+	return s
+}
+
+// propertyOrder returns the names of properties in the order documented by
+// DescribeForUI.
+func propertyOrder(s map[string]any, properties map[string]any) []string {
+	var names []string
+	seen := make(map[string]bool)
+	add := func(name string) {
+		if _, ok := properties[name]; ok && !seen[name] {
+			names = append(names, name)
+			seen[name] = true
+		}
+	}
+	ordering := stringList(s["propertyOrdering"])
+	if len(ordering) == 0 {
+		ordering = stringList(s["required"])
+	}
+	for _, name := range ordering {
+		add(name)
+	}
+	rest := make([]string, 0, len(properties))
+	for name := range properties {
+		if !seen[name] {
+			rest = append(rest, name)
+		}
+	}
+	slices.Sort(rest)
+	return append(names, rest...)
+}
+
+// schemaType returns the type of s, in lower case as in JSON schema. The
+// nullable types, such as ["string", "null"], are reported as their
+// non-null type.
+func schemaType(s map[string]any) string {
+	switch t := s["type"].(type) {
+	case string:
+		return strings.ToLower(t)
+	case []any:
+		for _, v := range t {
+			if v, ok := v.(string); ok && v != "null" {
+				return strings.ToLower(v)
+			}
+		}
+	}
+	if s["properties"] != nil {
+		return "object"
+	}
+	return ""
+}
+
+func stringList(v any) []string {
+	list, _ := v.([]any)
+	var result []string
+	for _, s := range list {
+		if s, ok := s.(string); ok {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+func stringValue(v any) string {
+	s, _ := v.(string)
+	return s
+}
@@ -0,0 +1,195 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tool_test
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/jsonschema-go/jsonschema"
+	"google.golang.org/genai"
+
+	"google.golang.org/adk/agent"
+	icontext "google.golang.org/adk/internal/context"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+	"google.golang.org/adk/tool/geminitool"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+// staticToolset is a toolset of fixed tools.
+type staticToolset struct {
+	name  string
+	tools []tool.Tool
+}
+
+func (s *staticToolset) Name() string { return s.name }
+
+func (s *staticToolset) Tools(agent.ReadonlyContext) ([]tool.Tool, error) { return s.tools, nil }
+
+type ticketArgs struct {
+	Title    string   `json:"title" jsonschema:"the title of the ticket"`
+	Priority string   `json:"priority" jsonschema:"how urgent the ticket is"`
+	Labels   []string `json:"labels,omitempty"`
+	Reporter struct {
+		Email   string `json:"email"`
+		Account struct {
+			ID    string `json:"id"`
+			Owner struct {
+				Name string `json:"name"`
+			} `json:"owner"`
+		} `json:"account"`
+	} `json:"reporter"`
+	APIToken string `json:"api_token,omitempty" jsonschema:"the token of the ticketing system"`
+	TenantID string `json:"tenant_id,omitempty"`
+}
+
+func newTicketTool(t *testing.T) tool.Tool {
+	t.Helper()
+	schema, err := jsonschema.For[ticketArgs](nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema.Properties["priority"].Enum = []any{"low", "medium", "high"}
+	schema.Properties["priority"].Default = json.RawMessage(`"medium"`)
+	schema.Properties["labels"].Items.Enum = []any{"bug", "feature"}
+	schema.Properties["api_token"].WriteOnly = true
+	schema.Properties["tenant_id"].ReadOnly = true
+	ticket, err := functiontool.New(functiontool.Config{
+		Name:        "create_ticket",
+		Description: "Creates a support ticket.",
+		InputSchema: schema,
+	}, func(tool.Context, ticketArgs) (map[string]any, error) {
+		return nil, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ticket
+}
+
+func newOrderTool(t *testing.T) tool.Tool {
+	t.Helper()
+	schema := &jsonschema.Schema{
+		Type:     "object",
+		Required: []string{"items"},
+		Properties: map[string]*jsonschema.Schema{
+			"items": {Type: "array", Items: &jsonschema.Schema{Ref: "#/$defs/item"}},
+			"note":  {Types: []string{"null", "string"}},
+		},
+		Defs: map[string]*jsonschema.Schema{
+			"item": {
+				Type:     "object",
+				Required: []string{"sku"},
+				Properties: map[string]*jsonschema.Schema{
+					"sku":      {Type: "string", Description: "the product reference"},
+					"quantity": {Type: "integer", Default: json.RawMessage(`1`)},
+				},
+			},
+		},
+	}
+	order, err := functiontool.New(functiontool.Config{
+		Name:          "place_order",
+		Description:   "Places an order.",
+		InputSchema:   schema,
+		IsLongRunning: true,
+	}, func(tool.Context, map[string]any) (map[string]any, error) {
+		return nil, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return order
+}
+
+func TestDescribeForUI(t *testing.T) {
+	sets := []tool.Toolset{
+		&staticToolset{name: "support", tools: []tool.Tool{
+			newTicketTool(t),
+			geminitool.New("search", &genai.Tool{GoogleSearch: &genai.GoogleSearch{}}),
+		}},
+		&staticToolset{name: "shop", tools: []tool.Tool{newOrderTool(t)}},
+	}
+	ctx := icontext.NewReadonlyContext(icontext.NewInvocationContext(t.Context(), icontext.InvocationContextParams{}))
+	descriptors, err := tool.DescribeForUI(ctx, sets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := json.MarshalIndent(descriptors, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = append(got, '\n')
+
+	golden := filepath.Join("testdata", "describe_for_ui.json")
+	if *update {
+		if err := os.WriteFile(golden, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(string(want), string(got)); diff != "" {
+		t.Errorf("DescribeForUI() mismatch (-want +got):\n%s", diff)
+	}
+
+	// The output is stable.
+	again, err := tool.DescribeForUI(ctx, sets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(descriptors, again); diff != "" {
+		t.Errorf("DescribeForUI() is not stable (-first +second):\n%s", diff)
+	}
+}
+
+func TestDescribeForUI_DynamicDeclaration(t *testing.T) {
+	swappable := tool.Swappable("ui_lookup", newLookupTool(t, "ui_lookup", "v1", nil, nil))
+	sets := []tool.Toolset{&staticToolset{name: "lookup", tools: []tool.Tool{swappable}}}
+	ctx := icontext.NewReadonlyContext(icontext.NewInvocationContext(t.Context(), icontext.InvocationContextParams{}))
+
+	type otherArgs struct {
+		ID int `json:"id"`
+	}
+	changed, err := functiontool.New(functiontool.Config{Name: "ui_lookup", Description: "finds by ID"}, func(tool.Context, otherArgs) (lookupResult, error) {
+		return lookupResult{}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := swappable.Swap(changed); err != nil {
+		t.Fatal(err)
+	}
+	descriptors, err := tool.DescribeForUI(ctx, sets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []tool.ToolUIDescriptor{{
+		Name:        "ui_lookup",
+		Description: "finds by ID",
+		Toolset:     "lookup",
+		Fields:      []tool.UIField{{Name: "id", Type: "integer", Required: true}},
+	}}
+	if diff := cmp.Diff(want, descriptors); diff != "" {
+		t.Errorf("DescribeForUI() mismatch (-want +got):\n%s", diff)
+	}
+}